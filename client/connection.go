@@ -41,6 +41,12 @@ type ConnectionArgs struct {
 	// Authentication interactor
 	AuthInteractor []httpbakery.Interactor
 
+	// API token to authenticate with instead of a client certificate (API extension: auth_tokens)
+	BearerToken string
+
+	// File in which to keep the tokens obtained when using OpenID Connect authentication (API extension: oidc)
+	OIDCTokensPath string
+
 	// Custom proxy
 	Proxy func(*http.Request) (*url.URL, error)
 
@@ -59,6 +65,36 @@ type ConnectionArgs struct {
 	// Caching support for image servers
 	CachePath   string
 	CacheExpiry time.Duration
+
+	// Number of times to retry requests failing with a transient error (connection failure or 503 status).
+	// Requests with a streamed body (such as file uploads) are never retried.
+	// Requests other than GET are only retried when failing to connect or with a 503 status, as they may
+	// otherwise have been applied already.
+	RetryCount int
+
+	// Delay before the first retry, doubled after each attempt (defaults to 500ms)
+	RetryDelay time.Duration
+
+	// Maximum delay between retries (defaults to 30s)
+	RetryMaxDelay time.Duration
+
+	// Keep idle connections open to reuse them for later requests
+	KeepAlive bool
+
+	// Maximum number of idle connections kept open when KeepAlive is set (defaults to 100)
+	MaxIdleConns int
+
+	// How long idle connections are kept open when KeepAlive is set (defaults to 90s)
+	IdleConnTimeout time.Duration
+
+	// Attempt to use HTTP/2 for HTTPS requests (websockets always use HTTP/1.1)
+	HTTP2 bool
+
+	// Transparently reconnect event listeners when their connection is lost, replaying
+	// the events missed in between (API extension: event_sequence).
+	// Lifecycle handlers get an "events-lost" event if some of them couldn't be replayed.
+	// The retry delays are used between reconnection attempts.
+	EventsReconnect bool
 }
 
 // ConnectLXD lets you connect to a remote LXD daemon over HTTPs.
@@ -88,10 +124,12 @@ func ConnectLXDHTTP(args *ConnectionArgs, client *http.Client) (InstanceServer,
 
 	// Initialize the client struct
 	server := ProtocolLXD{
-		httpHost:      "https://custom.socket",
-		httpProtocol:  "custom",
-		httpUserAgent: args.UserAgent,
-		chConnected:   make(chan struct{}, 1),
+		httpHost:        "https://custom.socket",
+		httpProtocol:    "custom",
+		httpUserAgent:   args.UserAgent,
+		chConnected:     make(chan struct{}, 1),
+		retry:           newRetryPolicy(args),
+		eventsReconnect: args.EventsReconnect,
 	}
 
 	// Setup the HTTP client
@@ -126,11 +164,13 @@ func ConnectLXDUnix(path string, args *ConnectionArgs) (InstanceServer, error) {
 
 	// Initialize the client struct
 	server := ProtocolLXD{
-		httpHost:      "http://unix.socket",
-		httpUnixPath:  path,
-		httpProtocol:  "unix",
-		httpUserAgent: args.UserAgent,
-		chConnected:   make(chan struct{}, 1),
+		httpHost:        "http://unix.socket",
+		httpUnixPath:    path,
+		httpProtocol:    "unix",
+		httpUserAgent:   args.UserAgent,
+		chConnected:     make(chan struct{}, 1),
+		retry:           newRetryPolicy(args),
+		eventsReconnect: args.EventsReconnect,
 	}
 
 	// Determine the socket path
@@ -153,6 +193,8 @@ func ConnectLXDUnix(path string, args *ConnectionArgs) (InstanceServer, error) {
 	if err != nil {
 		return nil, err
 	}
+
+	tuneHTTPTransport(httpClient, args)
 	server.http = httpClient
 
 	// Test the connection and seed the server information
@@ -207,6 +249,8 @@ func ConnectSimpleStreams(url string, args *ConnectionArgs) (ImageServer, error)
 	if err != nil {
 		return nil, err
 	}
+
+	tuneHTTPTransport(httpClient, args)
 	server.http = httpClient
 
 	// Get simplestreams client
@@ -253,20 +297,29 @@ func httpsLXD(url string, args *ConnectionArgs) (InstanceServer, error) {
 		httpHost:         url,
 		httpProtocol:     "https",
 		httpUserAgent:    args.UserAgent,
+		httpBearerToken:  args.BearerToken,
 		bakeryInteractor: args.AuthInteractor,
 		chConnected:      make(chan struct{}, 1),
+		retry:            newRetryPolicy(args),
+		eventsReconnect:  args.EventsReconnect,
 	}
 
-	if args.AuthType == "candid" {
+	if args.AuthType == "candid" || args.AuthType == "oidc" || args.BearerToken != "" {
 		server.RequireAuthenticated(true)
 	}
 
+	if args.AuthType == "oidc" {
+		server.oidcClient = newOIDCClient(args.OIDCTokensPath)
+	}
+
 	// Setup the HTTP client
 	httpClient, err := tlsHTTPClient(args.HTTPClient, args.TLSClientCert, args.TLSClientKey, args.TLSCA, args.TLSServerCert, args.InsecureSkipVerify, args.Proxy)
 	if err != nil {
 		return nil, err
 	}
 
+	tuneHTTPTransport(httpClient, args)
+
 	if args.CookieJar != nil {
 		httpClient.Jar = args.CookieJar
 	}
@@ -142,4 +142,26 @@
 //  if err != nil {
 //    return err
 //  }
+//
+// Example - cancellation
+//
+// This starts a container but gives up if it takes more than a minute.
+// All the requests, websockets and operation waits of a client returned by
+// WithContext are aborted when its context is cancelled.
+//
+//  ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+//  defer cancel()
+//
+//  op, err := c.WithContext(ctx).UpdateContainerState(name, req, "")
+//  if err != nil {
+//    return err
+//  }
+//
+//  // Wait for the operation or the timeout
+//  err = op.Wait()
+//  if err != nil {
+//    return err
+//  }
+//
+// A single wait can also be bounded using op.WaitContext(ctx).
 package lxd
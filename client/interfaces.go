@@ -1,6 +1,7 @@
 package lxd
 
 import (
+	"context"
 	"io"
 	"net/http"
 
@@ -20,6 +21,7 @@ type Operation interface {
 	RemoveHandler(target *EventTarget) (err error)
 	Refresh() (err error)
 	Wait() (err error)
+	WaitContext(ctx context.Context) (err error)
 }
 
 // The RemoteOperation type represents an Operation that may be using multiple servers.
@@ -28,6 +30,7 @@ type RemoteOperation interface {
 	CancelTarget() (err error)
 	GetTarget() (op *api.Operation, err error)
 	Wait() (err error)
+	WaitContext(ctx context.Context) (err error)
 }
 
 // The Server type represents a generic read-only server.
@@ -69,21 +72,38 @@ type InstanceServer interface {
 	// Server functions
 	GetServer() (server *api.Server, ETag string, err error)
 	GetServerResources() (resources *api.Resources, err error)
+	GetServerPreseed() (preseed *api.InitPreseed, err error)
+	GetMetrics() (metrics string, err error)
+	GetInstancesMetrics() (metrics []api.InstanceMetrics, err error)
+	GetIdmapAllocations() (allocations []api.IdmapAllocation, err error)
 	UpdateServer(server api.ServerPut, ETag string) (err error)
 	HasExtension(extension string) (exists bool)
 	RequireAuthenticated(authenticated bool)
 	IsClustered() (clustered bool)
 	UseTarget(name string) (client InstanceServer)
 	UseProject(name string) (client InstanceServer)
+	WithContext(ctx context.Context) (client InstanceServer)
 
 	// Certificate functions
 	GetCertificateFingerprints() (fingerprints []string, err error)
 	GetCertificates() (certificates []api.Certificate, err error)
 	GetCertificate(fingerprint string) (certificate *api.Certificate, ETag string, err error)
 	CreateCertificate(certificate api.CertificatesPost) (err error)
+	CreateCertificateToken(certificate api.CertificatesPost) (op Operation, err error)
 	UpdateCertificate(fingerprint string, certificate api.CertificatePut, ETag string) (err error)
 	DeleteCertificate(fingerprint string) (err error)
 
+	// API token functions
+	GetAuthTokenNames() (names []string, err error)
+	GetAuthTokens() (tokens []api.AuthToken, err error)
+	GetAuthToken(name string) (token *api.AuthToken, ETag string, err error)
+	CreateAuthToken(token api.AuthTokensPost) (secret *api.AuthTokenSecret, err error)
+	UpdateAuthToken(name string, token api.AuthTokenPut, ETag string) (err error)
+	DeleteAuthToken(name string) (err error)
+
+	// Batch functions ("api_batch" API extension)
+	RunBatch(batch api.BatchPost) (results []api.BatchResult, err error)
+
 	// Container functions
 	GetContainerNames() (names []string, err error)
 	GetContainers() (containers []api.Container, err error)
@@ -152,8 +172,13 @@ type InstanceServer interface {
 	UpdateInstance(name string, instance api.InstancePut, ETag string) (op Operation, err error)
 	RenameInstance(name string, instance api.InstancePost) (op Operation, err error)
 	MigrateInstance(name string, instance api.InstancePost) (op Operation, err error)
+	RebuildInstance(name string, instance api.InstanceRebuildPost) (op Operation, err error)
+	RebuildInstanceFromImage(source ImageServer, image api.Image, name string, req api.InstanceRebuildPost) (op RemoteOperation, err error)
+	ConvertInstance(name string, instance api.InstanceConvertPost) (op Operation, err error)
+	FlattenInstance(name string) (op Operation, err error)
 	DeleteInstance(name string) (op Operation, err error)
 	UpdateInstances(state api.InstancesPut, ETag string) (op Operation, err error)
+	UpdateInstancesState(state api.InstancesStatePut, ETag string) (op Operation, err error)
 
 	ExecInstance(instanceName string, exec api.InstanceExecPost, args *InstanceExecArgs) (op Operation, err error)
 	ConsoleInstance(instanceName string, console api.InstanceConsolePost, args *InstanceConsoleArgs) (op Operation, err error)
@@ -162,9 +187,16 @@ type InstanceServer interface {
 	GetInstanceConsoleLog(instanceName string, args *InstanceConsoleLogArgs) (content io.ReadCloser, err error)
 	DeleteInstanceConsoleLog(instanceName string, args *InstanceConsoleLogArgs) (err error)
 
+	GetInstanceNVRAM(instanceName string) (content io.ReadCloser, err error)
+	UpdateInstanceNVRAM(instanceName string, content io.Reader) (err error)
+	ResetInstanceNVRAM(instanceName string) (err error)
+
 	GetInstanceFile(instanceName string, path string) (content io.ReadCloser, resp *InstanceFileResponse, err error)
 	CreateInstanceFile(instanceName string, path string, args InstanceFileArgs) (err error)
 	DeleteInstanceFile(instanceName string, path string) (err error)
+	GetInstanceFileTree(instanceName string, path string) (content io.ReadCloser, err error)
+	CreateInstanceFileTree(instanceName string, path string, content io.Reader) (err error)
+	GetInstanceFileManifest(instanceName string, path string) (entries []api.InstanceFileManifestEntry, err error)
 
 	GetInstanceSnapshotNames(instanceName string) (names []string, err error)
 	GetInstanceSnapshots(instanceName string) (snapshots []api.InstanceSnapshot, err error)
@@ -186,12 +218,16 @@ type InstanceServer interface {
 	CreateInstanceFromBackup(args InstanceBackupArgs) (op Operation, err error)
 
 	GetInstanceState(name string) (state *api.InstanceState, ETag string, err error)
+	GetInstanceMetrics(name string, period string) (usage *api.InstanceUsage, err error)
 	UpdateInstanceState(name string, state api.InstanceStatePut, ETag string) (op Operation, err error)
 
 	GetInstanceLogfiles(name string) (logfiles []string, err error)
 	GetInstanceLogfile(name string, filename string) (content io.ReadCloser, err error)
 	DeleteInstanceLogfile(name string, filename string) (err error)
 
+	GetInstanceExecRecordings(name string) (recordings []api.InstanceExecRecording, err error)
+	GetInstanceExecRecordingFile(name string, recording string) (content io.ReadCloser, err error)
+
 	GetInstanceMetadata(name string) (metadata *api.ImageMetadata, ETag string, err error)
 	UpdateInstanceMetadata(name string, metadata api.ImageMetadata, ETag string) (err error)
 
@@ -202,6 +238,7 @@ type InstanceServer interface {
 
 	// Event handling functions
 	GetEvents() (listener *EventListener, err error)
+	GetEventsFiltered(filter api.EventFilter) (listener *EventListener, err error)
 
 	// Image functions
 	CreateImage(image api.ImagesPost, args *ImageCreateArgs) (op Operation, err error)
@@ -234,6 +271,14 @@ type InstanceServer interface {
 	UpdateNetworkForward(networkName string, listenAddress string, forward api.NetworkForwardPut, ETag string) (err error)
 	DeleteNetworkForward(networkName string, listenAddress string) (err error)
 
+	// Network load balancer functions ("network_load_balancer" API extension)
+	GetNetworkLoadBalancerAddresses(networkName string) ([]string, error)
+	GetNetworkLoadBalancers(networkName string) ([]api.NetworkLoadBalancer, error)
+	GetNetworkLoadBalancer(networkName string, listenAddress string) (loadBalancer *api.NetworkLoadBalancer, ETag string, err error)
+	CreateNetworkLoadBalancer(networkName string, loadBalancer api.NetworkLoadBalancersPost) error
+	UpdateNetworkLoadBalancer(networkName string, listenAddress string, loadBalancer api.NetworkLoadBalancerPut, ETag string) (err error)
+	DeleteNetworkLoadBalancer(networkName string, listenAddress string) (err error)
+
 	// Network ACL functions ("network_acl" API extension)
 	GetNetworkACLNames() (names []string, err error)
 	GetNetworkACLs() (acls []api.NetworkACL, err error)
@@ -249,7 +294,10 @@ type InstanceServer interface {
 	GetOperation(uuid string) (op *api.Operation, ETag string, err error)
 	GetOperationWait(uuid string, timeout int) (op *api.Operation, ETag string, err error)
 	GetOperationWaitSecret(uuid string, secret string, timeout int) (op *api.Operation, ETag string, err error)
+	GetOperationWaitStatus(uuid string, status api.StatusCode, timeout int) (op *api.Operation, ETag string, err error)
+	GetOperationsWait(uuids []string, timeout int) (operations []api.Operation, err error)
 	GetOperationWebsocket(uuid string, secret string) (conn *websocket.Conn, err error)
+	GetOperationObserverWebsocket(uuid string) (conn *websocket.Conn, err error)
 	DeleteOperation(uuid string) (err error)
 
 	// Profile functions
@@ -270,6 +318,25 @@ type InstanceServer interface {
 	UpdateProject(name string, project api.ProjectPut, ETag string) (err error)
 	RenameProject(name string, project api.ProjectPost) (op Operation, err error)
 	DeleteProject(name string) (err error)
+	GetProjectExportFile(name string, req *ProjectExportFileRequest) (resp *BackupFileResponse, err error)
+	CreateProjectFromExport(args ProjectImportArgs) (op Operation, err error)
+
+	// Instance template functions ("instance_templates" API extension)
+	GetInstanceTemplateNames() (names []string, err error)
+	GetInstanceTemplates() (templates []api.InstanceTemplate, err error)
+	GetInstanceTemplate(name string) (template *api.InstanceTemplate, ETag string, err error)
+	CreateInstanceTemplate(template api.InstanceTemplatesPost) (err error)
+	UpdateInstanceTemplate(name string, template api.InstanceTemplatePut, ETag string) (err error)
+	DeleteInstanceTemplate(name string) (err error)
+	CreateInstanceFromTemplate(name string, instance api.InstanceTemplateInstancesPost) (op Operation, err error)
+
+	// Secret functions ("secrets" API extension)
+	GetSecretNames() (names []string, err error)
+	GetSecrets() (secrets []api.Secret, err error)
+	GetSecret(name string) (secret *api.Secret, ETag string, err error)
+	CreateSecret(secret api.SecretsPost) (err error)
+	UpdateSecret(name string, secret api.SecretPut, ETag string) (err error)
+	DeleteSecret(name string) (err error)
 
 	// Storage pool functions ("storage" API extension)
 	GetStoragePoolNames() (names []string, err error)
@@ -332,6 +399,12 @@ type InstanceServer interface {
 	UpdateWarning(UUID string, warning api.WarningPut, ETag string) (err error)
 	DeleteWarning(UUID string) (err error)
 
+	// Background task functions
+	GetTaskNames() (names []string, err error)
+	GetTasks() (tasks []api.Task, err error)
+	GetTask(name string) (task *api.Task, ETag string, err error)
+	UpdateTaskState(name string, state api.TaskStatePut) (err error)
+
 	// Internal functions (for internal use)
 	RawQuery(method string, path string, data interface{}, queryETag string) (resp *api.Response, ETag string, err error)
 	RawWebsocket(path string) (conn *websocket.Conn, err error)
@@ -474,6 +547,39 @@ type StoragePoolVolumeBackupArgs struct {
 	Name string
 }
 
+// The ProjectExportFileRequest struct is used for a project export download request.
+type ProjectExportFileRequest struct {
+	// Writer for the export file
+	ExportFile io.Writer
+
+	// Whether to leave the instance and volume snapshots out
+	InstanceOnly bool
+
+	// Whether to use the storage driver optimized format for the backups
+	OptimizedStorage bool
+
+	// Compression algorithm of the backups
+	CompressionAlgorithm string
+
+	// Progress handler (called whenever some progress is made)
+	ProgressHandler func(progress ioprogress.ProgressData)
+
+	// A canceler that can be used to interrupt the download
+	Canceler *cancel.Canceler
+}
+
+// The ProjectImportArgs struct is used when creating a project from an export.
+type ProjectImportArgs struct {
+	// The export file
+	ExportFile io.Reader
+
+	// Name to import the project as
+	Name string
+
+	// Storage pool to restore the instances and volumes to
+	PoolName string
+}
+
 // The InstanceBackupArgs struct is used when creating a instance from a backup.
 type InstanceBackupArgs struct {
 	// The backup file
@@ -503,6 +609,14 @@ type InstanceCopyArgs struct {
 	// API extension: container_incremental_copy
 	// Perform an incremental copy
 	Refresh bool
+
+	// API extension: instances_clone
+	// Create a copy-on-write clone sharing its data with the source
+	Clone bool
+
+	// API extension: instances_migration_pool_map
+	// Mapping of the source storage pools to the target storage pools
+	PoolMap map[string]string
 }
 
 // The InstanceSnapshotCopyArgs struct is used to pass additional options during instance copy.
@@ -2,6 +2,7 @@ package lxd
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -34,13 +35,24 @@ type ProtocolLXD struct {
 	httpUnixPath    string
 	httpProtocol    string
 	httpUserAgent   string
+	httpBearerToken string
 
 	bakeryClient         *httpbakery.Client
+	oidcClient           *oidcClient
 	bakeryInteractor     []httpbakery.Interactor
 	requireAuthenticated bool
 
 	clusterTarget string
 	project       string
+
+	// Context used for all the requests of this client, if set
+	ctx context.Context
+
+	retry           retryPolicy
+	eventsReconnect bool
+
+	// Filter applied by the server to the events websocket, if set
+	eventsFilter *api.EventFilter
 }
 
 // Disconnect gets rid of any background goroutines
@@ -95,12 +107,30 @@ func (r *ProtocolLXD) GetHTTPClient() (*http.Client, error) {
 
 // Do performs a Request, using macaroon authentication if set.
 func (r *ProtocolLXD) do(req *http.Request) (*http.Response, error) {
+	// Tie the request to the client context
+	if r.ctx != nil {
+		req = req.WithContext(r.ctx)
+	}
+
 	// Set the user agent
 	if r.httpUserAgent != "" {
 		req.Header.Set("User-Agent", r.httpUserAgent)
 	}
 
-	// Send the request through
+	// Set the API token
+	if r.httpBearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+r.httpBearerToken)
+	}
+
+	return r.doRetry(req)
+}
+
+// send sends a single request through the authentication client in use.
+func (r *ProtocolLXD) send(req *http.Request) (*http.Response, error) {
+	if r.oidcClient != nil {
+		return r.oidcClient.do(r.http, req)
+	}
+
 	if r.bakeryClient != nil {
 		r.addMacaroonHeaders(req)
 		return r.bakeryClient.Do(req)
@@ -166,7 +196,7 @@ func lxdParseResponse(resp *http.Response) (*api.Response, string, error) {
 
 	// Handle errors
 	if response.Type == api.ErrorResponse {
-		return nil, "", api.StatusErrorf(resp.StatusCode, response.Error)
+		return nil, "", api.StatusErrorTypef(resp.StatusCode, response.ErrorType, response.Error)
 	}
 
 	return &response, etag, nil
@@ -347,11 +377,18 @@ func (r *ProtocolLXD) rawWebsocket(url string) (*websocket.Conn, error) {
 	// Grab the http transport handler
 	httpTransport := r.http.Transport.(*http.Transport)
 
+	// Websockets can't be carried over HTTP/2
+	tlsConfig := httpTransport.TLSClientConfig
+	if tlsConfig != nil && shared.StringInSlice("h2", tlsConfig.NextProtos) {
+		tlsConfig = tlsConfig.Clone()
+		tlsConfig.NextProtos = []string{"http/1.1"}
+	}
+
 	// Setup a new websocket dialer based on it
 	dialer := websocket.Dialer{
 		//lint:ignore SA1019 DialContext doesn't exist in Go 1.13
 		NetDial:         httpTransport.Dial,
-		TLSClientConfig: httpTransport.TLSClientConfig,
+		TLSClientConfig: tlsConfig,
 		Proxy:           httpTransport.Proxy,
 	}
 
@@ -361,6 +398,17 @@ func (r *ProtocolLXD) rawWebsocket(url string) (*websocket.Conn, error) {
 		headers.Set("User-Agent", r.httpUserAgent)
 	}
 
+	if r.httpBearerToken != "" {
+		headers.Set("Authorization", "Bearer "+r.httpBearerToken)
+	}
+
+	if r.oidcClient != nil {
+		token := r.oidcClient.token()
+		if token != "" {
+			headers.Set("Authorization", "Bearer "+token)
+		}
+	}
+
 	if r.requireAuthenticated {
 		headers.Set("X-LXD-authenticated", "true")
 	}
@@ -376,7 +424,7 @@ func (r *ProtocolLXD) rawWebsocket(url string) (*websocket.Conn, error) {
 	}
 
 	// Establish the connection
-	conn, _, err := dialer.Dial(url, headers)
+	conn, _, err := dialer.DialContext(r.requestContext(), url, headers)
 	if err != nil {
 		return nil, err
 	}
@@ -387,6 +435,15 @@ func (r *ProtocolLXD) rawWebsocket(url string) (*websocket.Conn, error) {
 	return conn, err
 }
 
+// requestContext returns the context the requests of this client should use.
+func (r *ProtocolLXD) requestContext() context.Context {
+	if r.ctx == nil {
+		return context.Background()
+	}
+
+	return r.ctx
+}
+
 func (r *ProtocolLXD) websocket(path string) (*websocket.Conn, error) {
 	// Generate the URL
 	var url string
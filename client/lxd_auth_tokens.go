@@ -0,0 +1,107 @@
+package lxd
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/lxc/lxd/shared/api"
+)
+
+// API token handling functions
+
+// GetAuthTokenNames returns a list of API token names.
+func (r *ProtocolLXD) GetAuthTokenNames() ([]string, error) {
+	if !r.HasExtension("auth_tokens") {
+		return nil, fmt.Errorf("The server is missing the required \"auth_tokens\" API extension")
+	}
+
+	// Fetch the raw values.
+	urls := []string{}
+	baseURL := "/auth/tokens"
+	_, err := r.queryStruct("GET", baseURL, nil, "", &urls)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse it.
+	return urlsToResourceNames(baseURL, urls...)
+}
+
+// GetAuthTokens returns a list of API tokens.
+func (r *ProtocolLXD) GetAuthTokens() ([]api.AuthToken, error) {
+	if !r.HasExtension("auth_tokens") {
+		return nil, fmt.Errorf("The server is missing the required \"auth_tokens\" API extension")
+	}
+
+	tokens := []api.AuthToken{}
+
+	_, err := r.queryStruct("GET", "/auth/tokens?recursion=1", nil, "", &tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+// GetAuthToken returns the API token with the given name.
+func (r *ProtocolLXD) GetAuthToken(name string) (*api.AuthToken, string, error) {
+	if !r.HasExtension("auth_tokens") {
+		return nil, "", fmt.Errorf("The server is missing the required \"auth_tokens\" API extension")
+	}
+
+	token := api.AuthToken{}
+
+	etag, err := r.queryStruct("GET", fmt.Sprintf("/auth/tokens/%s", url.PathEscape(name)), nil, "", &token)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &token, etag, nil
+}
+
+// CreateAuthToken creates a new API token and returns its secret.
+func (r *ProtocolLXD) CreateAuthToken(token api.AuthTokensPost) (*api.AuthTokenSecret, error) {
+	if !r.HasExtension("auth_tokens") {
+		return nil, fmt.Errorf("The server is missing the required \"auth_tokens\" API extension")
+	}
+
+	secret := api.AuthTokenSecret{}
+
+	// Send the request
+	_, err := r.queryStruct("POST", "/auth/tokens", token, "", &secret)
+	if err != nil {
+		return nil, err
+	}
+
+	return &secret, nil
+}
+
+// UpdateAuthToken updates the API token with the given name.
+func (r *ProtocolLXD) UpdateAuthToken(name string, token api.AuthTokenPut, ETag string) error {
+	if !r.HasExtension("auth_tokens") {
+		return fmt.Errorf("The server is missing the required \"auth_tokens\" API extension")
+	}
+
+	// Send the request
+	_, _, err := r.query("PUT", fmt.Sprintf("/auth/tokens/%s", url.PathEscape(name)), token, ETag)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteAuthToken revokes the API token with the given name.
+func (r *ProtocolLXD) DeleteAuthToken(name string) error {
+	if !r.HasExtension("auth_tokens") {
+		return fmt.Errorf("The server is missing the required \"auth_tokens\" API extension")
+	}
+
+	// Send the request
+	_, _, err := r.query("DELETE", fmt.Sprintf("/auth/tokens/%s", url.PathEscape(name)), nil, "")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
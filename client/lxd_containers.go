@@ -1740,6 +1740,10 @@ func (r *ProtocolLXD) GetContainerBackupFile(containerName string, name string,
 		request.Header.Set("User-Agent", r.httpUserAgent)
 	}
 
+	if r.httpBearerToken != "" {
+		request.Header.Set("Authorization", "Bearer "+r.httpBearerToken)
+	}
+
 	// Start the request
 	response, doneCh, err := cancel.CancelableDownload(req.Canceler, r.http, request)
 	if err != nil {
@@ -1763,7 +1767,7 @@ func (r *ProtocolLXD) GetContainerBackupFile(containerName string, name string,
 			Tracker: &ioprogress.ProgressTracker{
 				Length: response.ContentLength,
 				Handler: func(percent int64, speed int64) {
-					req.ProgressHandler(ioprogress.ProgressData{Text: fmt.Sprintf("%d%% (%s/s)", percent, units.GetByteSizeString(speed, 2))})
+					req.ProgressHandler(ioprogress.NewProgressData(fmt.Sprintf("%d%% (%s/s)", percent, units.GetByteSizeString(speed, 2)), percent, response.ContentLength, speed))
 				},
 			},
 		}
@@ -2,14 +2,76 @@ package lxd
 
 import (
 	"encoding/json"
+	"fmt"
+	neturl "net/url"
+	"strings"
 	"time"
 
+	"github.com/gorilla/websocket"
+
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/logger"
 )
 
 // Event handling functions
 
+// GetEventsFiltered connects to the LXD monitoring interface, the server only sending the events matching
+// the filter. As the filter applies to the whole stream, a dedicated connection is used.
+func (r *ProtocolLXD) GetEventsFiltered(filter api.EventFilter) (*EventListener, error) {
+	if !r.HasExtension("event_filters") {
+		return nil, fmt.Errorf("The server is missing the required \"event_filters\" API extension")
+	}
+
+	client := &ProtocolLXD{
+		server:               r.server,
+		http:                 r.http,
+		httpCertificate:      r.httpCertificate,
+		httpHost:             r.httpHost,
+		httpUnixPath:         r.httpUnixPath,
+		httpProtocol:         r.httpProtocol,
+		httpUserAgent:        r.httpUserAgent,
+		httpBearerToken:      r.httpBearerToken,
+		bakeryClient:         r.bakeryClient,
+		oidcClient:           r.oidcClient,
+		bakeryInteractor:     r.bakeryInteractor,
+		requireAuthenticated: r.requireAuthenticated,
+		project:              r.project,
+		clusterTarget:        r.clusterTarget,
+		ctx:                  r.ctx,
+		retry:                r.retry,
+		eventsReconnect:      r.eventsReconnect,
+		eventsFilter:         &filter,
+	}
+
+	return client.GetEvents()
+}
+
+// eventsURL returns the URL of the events websocket, resuming after the provided sequence number if not 0.
+func (r *ProtocolLXD) eventsURL(after uint64) (string, error) {
+	values := neturl.Values{}
+	if r.eventsFilter != nil {
+		if len(r.eventsFilter.Types) > 0 {
+			values.Set("type", strings.Join(r.eventsFilter.Types, ","))
+		}
+
+		if len(r.eventsFilter.Resources) > 0 {
+			values.Set("resource", strings.Join(r.eventsFilter.Resources, ","))
+		}
+	}
+
+	if after > 0 {
+		values.Set("after", fmt.Sprintf("%d", after))
+	}
+
+	path := "/events"
+	if len(values) > 0 {
+		path = fmt.Sprintf("/events?%s", values.Encode())
+	}
+
+	return r.setQueryAttributes(path)
+}
+
 // GetEvents connects to the LXD monitoring interface
 func (r *ProtocolLXD) GetEvents() (*EventListener, error) {
 	// Prevent anything else from interacting with the listeners
@@ -29,7 +91,7 @@ func (r *ProtocolLXD) GetEvents() (*EventListener, error) {
 	}
 
 	// Setup a new connection with LXD
-	url, err := r.setQueryAttributes("/events")
+	url, err := r.eventsURL(0)
 	if err != nil {
 		return nil, err
 	}
@@ -42,6 +104,9 @@ func (r *ProtocolLXD) GetEvents() (*EventListener, error) {
 	// Initialize the event listener list if we were able to connect to the events websocket.
 	r.eventListeners = []*EventListener{&listener}
 
+	// Set once this connection is done with, protected by the listeners lock.
+	stopped := false
+
 	// Spawn a watcher that will close the websocket connection after all
 	// listeners are gone.
 	stopCh := make(chan struct{})
@@ -54,10 +119,16 @@ func (r *ProtocolLXD) GetEvents() (*EventListener, error) {
 			}
 
 			r.eventListenersLock.Lock()
+			if stopped {
+				r.eventListenersLock.Unlock()
+				break
+			}
+
 			if len(r.eventListeners) == 0 {
 				// We don't need the connection anymore, disconnect
 				conn.Close()
 
+				stopped = true
 				r.eventListeners = nil
 				r.eventListenersLock.Unlock()
 				break
@@ -68,13 +139,33 @@ func (r *ProtocolLXD) GetEvents() (*EventListener, error) {
 
 	// Spawn the listener
 	go func() {
+		var lastSequence uint64
+
 		for {
 			_, data, err := conn.ReadMessage()
 			if err != nil {
+				// Reconnect, resuming after the last event received
+				if r.eventsReconnect {
+					newConn := r.reconnectEvents(lastSequence, &stopped)
+					if newConn != nil {
+						r.eventListenersLock.Lock()
+						conn = newConn
+						r.eventListenersLock.Unlock()
+						continue
+					}
+				}
+
 				// Prevent anything else from interacting with the listeners
 				r.eventListenersLock.Lock()
 				defer r.eventListenersLock.Unlock()
 
+				// The connection was closed as nobody is listening anymore
+				if stopped {
+					return
+				}
+
+				stopped = true
+
 				// Tell all the current listeners about the failure
 				for _, listener := range r.eventListeners {
 					listener.err = err
@@ -103,6 +194,19 @@ func (r *ProtocolLXD) GetEvents() (*EventListener, error) {
 				continue
 			}
 
+			// The server sends the events in sequence order, so the last one received is the point up to which
+			// nothing was missed. Lower sequence numbers are duplicates and an "events-lost" event (which has
+			// no sequence number) means the sequence may have restarted along with the server.
+			if event.Sequence > 0 {
+				if event.Sequence <= lastSequence {
+					continue
+				}
+
+				lastSequence = event.Sequence
+			} else if isEventsLost(event) {
+				lastSequence = 0
+			}
+
 			// Send the message to all handlers
 			r.eventListenersLock.Lock()
 			for _, listener := range r.eventListeners {
@@ -122,3 +226,59 @@ func (r *ProtocolLXD) GetEvents() (*EventListener, error) {
 
 	return &listener, nil
 }
+
+// isEventsLost returns whether the event is the one sent by the server when resuming the event stream after
+// events which can't be replayed anymore.
+func isEventsLost(event api.Event) bool {
+	if event.Type != "lifecycle" {
+		return false
+	}
+
+	lifecycle := api.EventLifecycle{}
+	err := json.Unmarshal(event.Metadata, &lifecycle)
+	if err != nil {
+		return false
+	}
+
+	return lifecycle.Action == "events-lost"
+}
+
+// reconnectEvents attempts to reconnect to the events websocket, backing off between attempts, until it
+// succeeds or the connection is no longer needed. Servers supporting it replay the events sent after
+// lastSequence.
+func (r *ProtocolLXD) reconnectEvents(lastSequence uint64, stopped *bool) *websocket.Conn {
+	delay := r.retry.delay
+
+	for {
+		select {
+		case <-time.After(delay):
+		case <-r.chConnected:
+			return nil
+		case <-r.requestContext().Done():
+			return nil
+		}
+
+		r.eventListenersLock.Lock()
+		done := *stopped || len(r.eventListeners) == 0
+		r.eventListenersLock.Unlock()
+		if done {
+			return nil
+		}
+
+		url, err := r.eventsURL(lastSequence)
+		if err != nil {
+			return nil
+		}
+
+		conn, err := r.websocket(url)
+		if err == nil {
+			logger.Debug("Reconnected to the events websocket", "after", lastSequence)
+			return conn
+		}
+
+		delay *= 2
+		if delay > r.retry.maxDelay {
+			delay = r.retry.maxDelay
+		}
+	}
+}
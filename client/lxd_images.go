@@ -183,11 +183,11 @@ func lxdDownloadImage(fingerprint string, uri string, userAgent string, client *
 
 		if response.ContentLength > 0 {
 			reader.Tracker.Handler = func(percent int64, speed int64) {
-				req.ProgressHandler(ioprogress.ProgressData{Text: fmt.Sprintf("%d%% (%s/s)", percent, units.GetByteSizeString(speed, 2))})
+				req.ProgressHandler(ioprogress.NewProgressData(fmt.Sprintf("%d%% (%s/s)", percent, units.GetByteSizeString(speed, 2)), percent, response.ContentLength, speed))
 			}
 		} else {
 			reader.Tracker.Handler = func(received int64, speed int64) {
-				req.ProgressHandler(ioprogress.ProgressData{Text: fmt.Sprintf("%s (%s/s)", units.GetByteSizeString(received, 2), units.GetByteSizeString(speed, 2))})
+				req.ProgressHandler(ioprogress.NewProgressData(fmt.Sprintf("%s (%s/s)", units.GetByteSizeString(received, 2), units.GetByteSizeString(speed, 2)), received, 0, speed))
 
 			}
 		}
@@ -441,7 +441,7 @@ func (r *ProtocolLXD) CreateImage(image api.ImagesPost, args *ImageCreateArgs) (
 				Tracker: &ioprogress.ProgressTracker{
 					Length: size,
 					Handler: func(percent int64, speed int64) {
-						args.ProgressHandler(ioprogress.ProgressData{Text: fmt.Sprintf("%d%% (%s/s)", percent, units.GetByteSizeString(speed, 2))})
+						args.ProgressHandler(ioprogress.NewProgressData(fmt.Sprintf("%d%% (%s/s)", percent, units.GetByteSizeString(speed, 2)), percent, size, speed))
 					},
 				},
 			}
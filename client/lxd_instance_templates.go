@@ -0,0 +1,120 @@
+package lxd
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/lxc/lxd/shared/api"
+)
+
+// GetInstanceTemplateNames returns a list of instance template names.
+func (r *ProtocolLXD) GetInstanceTemplateNames() ([]string, error) {
+	if !r.HasExtension("instance_templates") {
+		return nil, fmt.Errorf(`The server is missing the required "instance_templates" API extension`)
+	}
+
+	// Fetch the raw URL values.
+	urls := []string{}
+	baseURL := "/instance-templates"
+	_, err := r.queryStruct("GET", baseURL, nil, "", &urls)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse it.
+	return urlsToResourceNames(baseURL, urls...)
+}
+
+// GetInstanceTemplates returns a list of instance template structs.
+func (r *ProtocolLXD) GetInstanceTemplates() ([]api.InstanceTemplate, error) {
+	if !r.HasExtension("instance_templates") {
+		return nil, fmt.Errorf(`The server is missing the required "instance_templates" API extension`)
+	}
+
+	templates := []api.InstanceTemplate{}
+
+	// Fetch the raw value.
+	_, err := r.queryStruct("GET", "/instance-templates?recursion=1", nil, "", &templates)
+	if err != nil {
+		return nil, err
+	}
+
+	return templates, nil
+}
+
+// GetInstanceTemplate returns an instance template entry for the provided name.
+func (r *ProtocolLXD) GetInstanceTemplate(name string) (*api.InstanceTemplate, string, error) {
+	if !r.HasExtension("instance_templates") {
+		return nil, "", fmt.Errorf(`The server is missing the required "instance_templates" API extension`)
+	}
+
+	template := api.InstanceTemplate{}
+
+	// Fetch the raw value.
+	etag, err := r.queryStruct("GET", fmt.Sprintf("/instance-templates/%s", url.PathEscape(name)), nil, "", &template)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &template, etag, nil
+}
+
+// CreateInstanceTemplate defines a new instance template using the provided struct.
+func (r *ProtocolLXD) CreateInstanceTemplate(template api.InstanceTemplatesPost) error {
+	if !r.HasExtension("instance_templates") {
+		return fmt.Errorf(`The server is missing the required "instance_templates" API extension`)
+	}
+
+	// Send the request.
+	_, _, err := r.query("POST", "/instance-templates", template, "")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UpdateInstanceTemplate updates the instance template to match the provided struct.
+func (r *ProtocolLXD) UpdateInstanceTemplate(name string, template api.InstanceTemplatePut, ETag string) error {
+	if !r.HasExtension("instance_templates") {
+		return fmt.Errorf(`The server is missing the required "instance_templates" API extension`)
+	}
+
+	// Send the request.
+	_, _, err := r.query("PUT", fmt.Sprintf("/instance-templates/%s", url.PathEscape(name)), template, ETag)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteInstanceTemplate deletes an existing instance template.
+func (r *ProtocolLXD) DeleteInstanceTemplate(name string) error {
+	if !r.HasExtension("instance_templates") {
+		return fmt.Errorf(`The server is missing the required "instance_templates" API extension`)
+	}
+
+	// Send the request.
+	_, _, err := r.query("DELETE", fmt.Sprintf("/instance-templates/%s", url.PathEscape(name)), nil, "")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// CreateInstanceFromTemplate requests that LXD creates a new instance from the instance template.
+func (r *ProtocolLXD) CreateInstanceFromTemplate(name string, instance api.InstanceTemplateInstancesPost) (Operation, error) {
+	if !r.HasExtension("instance_templates") {
+		return nil, fmt.Errorf(`The server is missing the required "instance_templates" API extension`)
+	}
+
+	// Send the request.
+	op, _, err := r.queryOperation("POST", fmt.Sprintf("/instance-templates/%s/instances", url.PathEscape(name)), instance, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return op, nil
+}
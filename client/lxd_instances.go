@@ -99,6 +99,22 @@ func (r *ProtocolLXD) UpdateInstances(state api.InstancesPut, ETag string) (Oper
 	return op, nil
 }
 
+// UpdateInstancesState changes the state of the selected instances, across all the cluster members.
+// The per-instance results are in the "results" field of the operation metadata.
+func (r *ProtocolLXD) UpdateInstancesState(state api.InstancesStatePut, ETag string) (Operation, error) {
+	if !r.HasExtension("instances_state_bulk") {
+		return nil, fmt.Errorf("The server is missing the required \"instances_state_bulk\" API extension")
+	}
+
+	// Send the request
+	op, _, err := r.queryOperation("PUT", "/instances/state", state, ETag)
+	if err != nil {
+		return nil, err
+	}
+
+	return op, nil
+}
+
 // GetInstancesFull returns a list of instances including snapshots, backups and state.
 func (r *ProtocolLXD) GetInstancesFull(instanceType api.InstanceType) ([]api.InstanceFull, error) {
 	instances := []api.InstanceFull{}
@@ -417,6 +433,14 @@ func (r *ProtocolLXD) CopyInstance(source InstanceServer, instance api.Instance,
 			}
 		}
 
+		if args.Clone && !r.HasExtension("instances_clone") {
+			return nil, fmt.Errorf("The server is missing the required \"instances_clone\" API extension")
+		}
+
+		if len(args.PoolMap) > 0 && !r.HasExtension("instances_migration_pool_map") {
+			return nil, fmt.Errorf("The target server is missing the required \"instances_migration_pool_map\" API extension")
+		}
+
 		// Allow overriding the target name
 		if args.Name != "" {
 			req.Name = args.Name
@@ -426,6 +450,7 @@ func (r *ProtocolLXD) CopyInstance(source InstanceServer, instance api.Instance,
 		req.Source.InstanceOnly = args.InstanceOnly
 		req.Source.ContainerOnly = args.InstanceOnly // For legacy servers.
 		req.Source.Refresh = args.Refresh
+		req.Source.PoolMap = args.PoolMap
 	}
 
 	if req.Source.Live {
@@ -457,6 +482,10 @@ func (r *ProtocolLXD) CopyInstance(source InstanceServer, instance api.Instance,
 		req.Source.Type = "copy"
 		req.Source.Source = instance.Name
 
+		if args != nil && args.Clone {
+			req.Source.Type = "clone"
+		}
+
 		// Copy the instance
 		op, err := r.CreateInstance(req)
 		if err != nil {
@@ -477,6 +506,10 @@ func (r *ProtocolLXD) CopyInstance(source InstanceServer, instance api.Instance,
 		return &rop, nil
 	}
 
+	if args != nil && args.Clone {
+		return nil, fmt.Errorf("Clones can only be created on the server of the source instance")
+	}
+
 	// Source request
 	sourceReq := api.InstancePost{
 		Migration:     true,
@@ -624,6 +657,186 @@ func (r *ProtocolLXD) RenameInstance(name string, instance api.InstancePost) (Op
 	return op, nil
 }
 
+// RebuildInstance requests that LXD re-creates the root volume of the instance from an image, or empty.
+func (r *ProtocolLXD) RebuildInstance(name string, instance api.InstanceRebuildPost) (Operation, error) {
+	if !r.HasExtension("instances_rebuild") {
+		return nil, fmt.Errorf("The server is missing the required \"instances_rebuild\" API extension")
+	}
+
+	path, _, err := r.instanceTypeToPath(api.InstanceTypeAny)
+	if err != nil {
+		return nil, err
+	}
+
+	// Send the request
+	op, _, err := r.queryOperation("POST", fmt.Sprintf("%s/%s/rebuild", path, url.PathEscape(name)), instance, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return op, nil
+}
+
+// tryRebuildInstance attempts to rebuild the instance from the image source using each of the provided
+// server URLs in turn.
+func (r *ProtocolLXD) tryRebuildInstance(name string, req api.InstanceRebuildPost, urls []string) (RemoteOperation, error) {
+	if len(urls) == 0 {
+		return nil, fmt.Errorf("The source server isn't listening on the network")
+	}
+
+	rop := remoteOperation{
+		chDone: make(chan bool),
+	}
+
+	// Forward targetOp to remote op
+	go func() {
+		success := false
+		var errors []remoteOperationResult
+		for _, serverURL := range urls {
+			req.Source.Server = serverURL
+
+			op, err := r.RebuildInstance(name, req)
+			if err != nil {
+				errors = append(errors, remoteOperationResult{URL: serverURL, Error: err})
+				continue
+			}
+
+			rop.handlerLock.Lock()
+			rop.targetOp = op
+			rop.handlerLock.Unlock()
+
+			for _, handler := range rop.handlers {
+				rop.targetOp.AddHandler(handler)
+			}
+
+			err = rop.targetOp.Wait()
+			if err != nil {
+				errors = append(errors, remoteOperationResult{URL: serverURL, Error: err})
+
+				if shared.IsConnectionError(err) {
+					continue
+				}
+
+				break
+			}
+
+			success = true
+			break
+		}
+
+		if !success {
+			rop.err = remoteOperationError("Failed instance rebuild", errors)
+		}
+
+		close(rop.chDone)
+	}()
+
+	return &rop, nil
+}
+
+// RebuildInstanceFromImage is a convenience function to make it easier to rebuild an instance from an image.
+func (r *ProtocolLXD) RebuildInstanceFromImage(source ImageServer, image api.Image, name string, req api.InstanceRebuildPost) (RemoteOperation, error) {
+	// Set the minimal source fields
+	req.Source.Type = "image"
+
+	// Optimization for the local image case
+	if r == source {
+		// Always use fingerprints for local case
+		req.Source.Fingerprint = image.Fingerprint
+		req.Source.Alias = ""
+
+		op, err := r.RebuildInstance(name, req)
+		if err != nil {
+			return nil, err
+		}
+
+		rop := remoteOperation{
+			targetOp: op,
+			chDone:   make(chan bool),
+		}
+
+		// Forward targetOp to remote op
+		go func() {
+			rop.err = rop.targetOp.Wait()
+			close(rop.chDone)
+		}()
+
+		return &rop, nil
+	}
+
+	// Minimal source fields for remote image
+	req.Source.Mode = "pull"
+
+	// If we have an alias and the image is public, use that
+	if req.Source.Alias != "" && image.Public {
+		req.Source.Fingerprint = ""
+	} else {
+		req.Source.Fingerprint = image.Fingerprint
+		req.Source.Alias = ""
+	}
+
+	// Get source server connection information
+	info, err := source.GetConnectionInfo()
+	if err != nil {
+		return nil, err
+	}
+
+	req.Source.Protocol = info.Protocol
+	req.Source.Certificate = info.Certificate
+
+	// Generate secret token if needed
+	if !image.Public {
+		secret, err := source.GetImageSecret(image.Fingerprint)
+		if err != nil {
+			return nil, err
+		}
+
+		req.Source.Secret = secret
+	}
+
+	return r.tryRebuildInstance(name, req, info.Addresses)
+}
+
+// FlattenInstance requests that LXD makes an instance created as a clone independent of its source.
+func (r *ProtocolLXD) FlattenInstance(name string) (Operation, error) {
+	if !r.HasExtension("instances_clone") {
+		return nil, fmt.Errorf("The server is missing the required \"instances_clone\" API extension")
+	}
+
+	path, _, err := r.instanceTypeToPath(api.InstanceTypeAny)
+	if err != nil {
+		return nil, err
+	}
+
+	// Send the request
+	op, _, err := r.queryOperation("POST", fmt.Sprintf("%s/%s/flatten", path, url.PathEscape(name)), nil, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return op, nil
+}
+
+// ConvertInstance requests that LXD converts a container into a virtual machine or vice versa.
+func (r *ProtocolLXD) ConvertInstance(name string, instance api.InstanceConvertPost) (Operation, error) {
+	if !r.HasExtension("instances_convert") {
+		return nil, fmt.Errorf("The server is missing the required \"instances_convert\" API extension")
+	}
+
+	path, _, err := r.instanceTypeToPath(api.InstanceTypeAny)
+	if err != nil {
+		return nil, err
+	}
+
+	// Send the request
+	op, _, err := r.queryOperation("POST", fmt.Sprintf("%s/%s/convert", path, url.PathEscape(name)), instance, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return op, nil
+}
+
 func (r *ProtocolLXD) tryMigrateInstance(source InstanceServer, name string, req api.InstancePost, urls []string) (RemoteOperation, error) {
 	if len(urls) == 0 {
 		return nil, fmt.Errorf("The target server isn't listening on the network")
@@ -696,6 +909,10 @@ func (r *ProtocolLXD) MigrateInstance(name string, instance api.InstancePost) (O
 		return nil, fmt.Errorf("The server is missing the required \"instance_pool_move\" API extension")
 	}
 
+	if instance.Project != "" && !r.HasExtension("instance_project_move") {
+		return nil, fmt.Errorf("The server is missing the required \"instance_project_move\" API extension")
+	}
+
 	// Quick check.
 	if !instance.Migration {
 		return nil, fmt.Errorf("Can't ask for a rename through MigrateInstance")
@@ -740,6 +957,12 @@ func (r *ProtocolLXD) ExecInstance(instanceName string, exec api.InstanceExecPos
 		}
 	}
 
+	if exec.UserName != "" || exec.GroupName != "" || exec.Timeout > 0 {
+		if !r.HasExtension("instance_exec_options") {
+			return nil, fmt.Errorf("The server is missing the required \"instance_exec_options\" API extension")
+		}
+	}
+
 	var uri string
 
 	if r.IsAgent() {
@@ -1046,6 +1269,118 @@ func (r *ProtocolLXD) CreateInstanceFile(instanceName string, filePath string, a
 	return nil
 }
 
+// instanceFileTreeURL returns the URL used to transfer the tree at filePath as a tar stream.
+func (r *ProtocolLXD) instanceFileTreeURL(instanceName string, filePath string) (string, error) {
+	var err error
+	var requestURL string
+
+	if !r.HasExtension("instance_file_tree") {
+		return "", fmt.Errorf("The server is missing the required \"instance_file_tree\" API extension")
+	}
+
+	values := map[string]string{"path": filePath, "format": "tar"}
+	if r.IsAgent() {
+		requestURL, err = shared.URLEncode(fmt.Sprintf("%s/1.0/files", r.httpHost), values)
+	} else {
+		var path string
+
+		path, _, err = r.instanceTypeToPath(api.InstanceTypeAny)
+		if err != nil {
+			return "", err
+		}
+
+		requestURL, err = shared.URLEncode(fmt.Sprintf("%s/1.0%s/%s/files", r.httpHost, path, url.PathEscape(instanceName)), values)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return r.setQueryAttributes(requestURL)
+}
+
+// GetInstanceFileTree retrieves the tree at the provided path from the instance as a tar stream.
+// Entries are named relative to the parent directory of the path.
+func (r *ProtocolLXD) GetInstanceFileTree(instanceName string, filePath string) (io.ReadCloser, error) {
+	requestURL, err := r.instanceFileTreeURL(instanceName, filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", requestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Send the request
+	resp, err := r.do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check the return value for a cleaner error
+	if resp.StatusCode != http.StatusOK {
+		_, _, err := lxdParseResponse(resp)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return resp.Body, nil
+}
+
+// CreateInstanceFileTree extracts the provided tar stream into the directory at the provided path in the instance.
+func (r *ProtocolLXD) CreateInstanceFileTree(instanceName string, filePath string, content io.Reader) error {
+	requestURL, err := r.instanceFileTreeURL(instanceName, filePath)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", requestURL, content)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/x-tar")
+
+	// Send the request
+	resp, err := r.do(req)
+	if err != nil {
+		return err
+	}
+
+	// Check the return value for a cleaner error
+	_, _, err = lxdParseResponse(resp)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetInstanceFileManifest describes every entry of the tree at the provided path in the instance.
+// Entry paths are relative to the provided path.
+func (r *ProtocolLXD) GetInstanceFileManifest(instanceName string, filePath string) ([]api.InstanceFileManifestEntry, error) {
+	if !r.HasExtension("instance_file_manifest") {
+		return nil, fmt.Errorf("The server is missing the required \"instance_file_manifest\" API extension")
+	}
+
+	path, v, err := r.instanceTypeToPath(api.InstanceTypeAny)
+	if err != nil {
+		return nil, err
+	}
+
+	v.Set("path", filePath)
+	v.Set("format", "manifest")
+
+	entries := []api.InstanceFileManifestEntry{}
+	_, err = r.queryStruct("GET", fmt.Sprintf("%s/%s/files?%s", path, url.PathEscape(instanceName), v.Encode()), nil, "", &entries)
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
 // DeleteInstanceFile deletes a file in the instance.
 func (r *ProtocolLXD) DeleteInstanceFile(instanceName string, filePath string) error {
 	if !r.HasExtension("file_delete") {
@@ -1535,6 +1870,33 @@ func (r *ProtocolLXD) GetInstanceState(name string) (*api.InstanceState, string,
 	return &state, etag, nil
 }
 
+// GetInstanceMetrics returns the resource usage samples recorded for the instance over the period
+// (e.g. "1h", the server default being used when empty).
+func (r *ProtocolLXD) GetInstanceMetrics(name string, period string) (*api.InstanceUsage, error) {
+	if !r.HasExtension("instances_usage_history") {
+		return nil, fmt.Errorf("The server is missing the required \"instances_usage_history\" API extension")
+	}
+
+	path, v, err := r.instanceTypeToPath(api.InstanceTypeAny)
+	if err != nil {
+		return nil, err
+	}
+
+	if period != "" {
+		v.Set("period", period)
+	}
+
+	usage := api.InstanceUsage{}
+
+	// Fetch the raw value
+	_, err = r.queryStruct("GET", fmt.Sprintf("%s/%s/metrics?%s", path, url.PathEscape(name), v.Encode()), nil, "", &usage)
+	if err != nil {
+		return nil, err
+	}
+
+	return &usage, nil
+}
+
 // UpdateInstanceState updates the instance to match the requested state.
 func (r *ProtocolLXD) UpdateInstanceState(name string, state api.InstanceStatePut, ETag string) (Operation, error) {
 	path, _, err := r.instanceTypeToPath(api.InstanceTypeAny)
@@ -1625,6 +1987,71 @@ func (r *ProtocolLXD) DeleteInstanceLogfile(name string, filename string) error
 	return nil
 }
 
+// GetInstanceExecRecordings returns the recordings of the interactive exec sessions of the instance.
+func (r *ProtocolLXD) GetInstanceExecRecordings(name string) ([]api.InstanceExecRecording, error) {
+	if !r.HasExtension("instances_exec_recording") {
+		return nil, fmt.Errorf("The server is missing the required \"instances_exec_recording\" API extension")
+	}
+
+	path, _, err := r.instanceTypeToPath(api.InstanceTypeAny)
+	if err != nil {
+		return nil, err
+	}
+
+	recordings := []api.InstanceExecRecording{}
+
+	// Fetch the raw value
+	_, err = r.queryStruct("GET", fmt.Sprintf("%s/%s/recordings?recursion=1", path, url.PathEscape(name)), nil, "", &recordings)
+	if err != nil {
+		return nil, err
+	}
+
+	return recordings, nil
+}
+
+// GetInstanceExecRecordingFile returns the content of the requested exec session recording.
+//
+// Note that it's the caller's responsibility to close the returned ReadCloser
+func (r *ProtocolLXD) GetInstanceExecRecordingFile(name string, recording string) (io.ReadCloser, error) {
+	if !r.HasExtension("instances_exec_recording") {
+		return nil, fmt.Errorf("The server is missing the required \"instances_exec_recording\" API extension")
+	}
+
+	path, _, err := r.instanceTypeToPath(api.InstanceTypeAny)
+	if err != nil {
+		return nil, err
+	}
+
+	// Prepare the HTTP request
+	url := fmt.Sprintf("%s/1.0%s/%s/recordings/%s", r.httpHost, path, url.PathEscape(name), url.PathEscape(recording))
+
+	url, err = r.setQueryAttributes(url)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Send the request
+	resp, err := r.do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check the return value for a cleaner error
+	if resp.StatusCode != http.StatusOK {
+		_, _, err := lxdParseResponse(resp)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return resp.Body, err
+}
+
 // GetInstanceMetadata returns instance metadata.
 func (r *ProtocolLXD) GetInstanceMetadata(name string) (*api.ImageMetadata, string, error) {
 	path, _, err := r.instanceTypeToPath(api.InstanceTypeAny)
@@ -2013,6 +2440,110 @@ func (r *ProtocolLXD) DeleteInstanceConsoleLog(instanceName string, args *Instan
 	return nil
 }
 
+// GetInstanceNVRAM returns the UEFI NVRAM of a virtual machine.
+//
+// Note that it's the caller's responsibility to close the returned ReadCloser
+func (r *ProtocolLXD) GetInstanceNVRAM(instanceName string) (io.ReadCloser, error) {
+	path, _, err := r.instanceTypeToPath(api.InstanceTypeAny)
+	if err != nil {
+		return nil, err
+	}
+
+	if !r.HasExtension("instances_nvram") {
+		return nil, fmt.Errorf("The server is missing the required \"instances_nvram\" API extension")
+	}
+
+	// Prepare the HTTP request
+	url := fmt.Sprintf("%s/1.0%s/%s/nvram", r.httpHost, path, url.PathEscape(instanceName))
+
+	url, err = r.setQueryAttributes(url)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Send the request
+	resp, err := r.do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check the return value for a cleaner error
+	if resp.StatusCode != http.StatusOK {
+		_, _, err := lxdParseResponse(resp)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return resp.Body, err
+}
+
+// UpdateInstanceNVRAM replaces the UEFI NVRAM of a stopped virtual machine.
+func (r *ProtocolLXD) UpdateInstanceNVRAM(instanceName string, content io.Reader) error {
+	path, _, err := r.instanceTypeToPath(api.InstanceTypeAny)
+	if err != nil {
+		return err
+	}
+
+	if !r.HasExtension("instances_nvram") {
+		return fmt.Errorf("The server is missing the required \"instances_nvram\" API extension")
+	}
+
+	// Prepare the HTTP request
+	url := fmt.Sprintf("%s/1.0%s/%s/nvram", r.httpHost, path, url.PathEscape(instanceName))
+
+	url, err = r.setQueryAttributes(url)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("PUT", url, content)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	// Send the request
+	resp, err := r.do(req)
+	if err != nil {
+		return err
+	}
+
+	// Check the return value for a cleaner error
+	_, _, err = lxdParseResponse(resp)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ResetInstanceNVRAM re-creates the UEFI NVRAM of a stopped virtual machine from the firmware defaults.
+func (r *ProtocolLXD) ResetInstanceNVRAM(instanceName string) error {
+	path, _, err := r.instanceTypeToPath(api.InstanceTypeAny)
+	if err != nil {
+		return err
+	}
+
+	if !r.HasExtension("instances_nvram") {
+		return fmt.Errorf("The server is missing the required \"instances_nvram\" API extension")
+	}
+
+	// Send the request
+	_, _, err = r.query("DELETE", fmt.Sprintf("%s/%s/nvram", path, url.PathEscape(instanceName)), nil, "")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
 // GetInstanceBackupNames returns a list of backup names for the instance.
 func (r *ProtocolLXD) GetInstanceBackupNames(instanceName string) ([]string, error) {
 	if !r.HasExtension("container_backup") {
@@ -2166,6 +2697,10 @@ func (r *ProtocolLXD) GetInstanceBackupFile(instanceName string, name string, re
 		request.Header.Set("User-Agent", r.httpUserAgent)
 	}
 
+	if r.httpBearerToken != "" {
+		request.Header.Set("Authorization", "Bearer "+r.httpBearerToken)
+	}
+
 	// Start the request
 	response, doneCh, err := cancel.CancelableDownload(req.Canceler, r.http, request)
 	if err != nil {
@@ -2189,7 +2724,7 @@ func (r *ProtocolLXD) GetInstanceBackupFile(instanceName string, name string, re
 			Tracker: &ioprogress.ProgressTracker{
 				Length: response.ContentLength,
 				Handler: func(percent int64, speed int64) {
-					req.ProgressHandler(ioprogress.ProgressData{Text: fmt.Sprintf("%d%% (%s/s)", percent, units.GetByteSizeString(speed, 2))})
+					req.ProgressHandler(ioprogress.NewProgressData(fmt.Sprintf("%d%% (%s/s)", percent, units.GetByteSizeString(speed, 2)), percent, response.ContentLength, speed))
 				},
 			},
 		}
@@ -0,0 +1,311 @@
+package lxd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/macaroon-bakery.v2/httpbakery"
+)
+
+// oidcTokens are the tokens obtained from an OpenID Connect provider.
+type oidcTokens struct {
+	AccessToken  string    `json:"access_token"`
+	IDToken      string    `json:"id_token"`
+	RefreshToken string    `json:"refresh_token"`
+	Expiry       time.Time `json:"expiry"`
+
+	// Audience expected by the server, if any.
+	Audience string `json:"audience"`
+}
+
+// oidcProvider is the subset of the OpenID Connect discovery document used for logging in.
+type oidcProvider struct {
+	DeviceAuthorizationEndpoint string `json:"device_authorization_endpoint"`
+	TokenEndpoint               string `json:"token_endpoint"`
+}
+
+// oidcClient logs into an OpenID Connect provider using the device authorization flow, and keeps
+// the resulting tokens, optionally persisted to a file.
+type oidcClient struct {
+	tokensPath string
+	httpClient *http.Client
+
+	mu     sync.Mutex
+	tokens *oidcTokens
+}
+
+func newOIDCClient(tokensPath string) *oidcClient {
+	c := &oidcClient{
+		tokensPath: tokensPath,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+
+	if tokensPath != "" {
+		content, err := ioutil.ReadFile(tokensPath)
+		if err == nil {
+			tokens := oidcTokens{}
+			if json.Unmarshal(content, &tokens) == nil {
+				c.tokens = &tokens
+			}
+		}
+	}
+
+	return c
+}
+
+// token returns the token to send to the server, if any.
+func (c *oidcClient) token() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.tokens == nil {
+		return ""
+	}
+
+	// Servers expecting a specific audience validate access tokens, others validate ID tokens.
+	if c.tokens.Audience != "" || c.tokens.IDToken == "" {
+		return c.tokens.AccessToken
+	}
+
+	return c.tokens.IDToken
+}
+
+// do sends the request with the current token, logging in again and retrying the request if the
+// server doesn't accept it.
+func (c *oidcClient) do(client *http.Client, req *http.Request) (*http.Response, error) {
+	token := c.token()
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// The server only advertises its provider to clients it didn't authenticate.
+	issuer := resp.Header.Get("X-LXD-OIDC-issuer")
+	clientID := resp.Header.Get("X-LXD-OIDC-clientid")
+	if issuer == "" || clientID == "" {
+		return resp, nil
+	}
+
+	// Only retry requests whose body can be sent again.
+	if req.Body != nil && req.GetBody == nil {
+		return resp, nil
+	}
+
+	resp.Body.Close()
+
+	audience := resp.Header.Get("X-LXD-OIDC-audience")
+	err = c.login(issuer, clientID, audience)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetBody != nil {
+		req.Body, err = req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.token())
+
+	return client.Do(req)
+}
+
+// login refreshes the tokens if possible, and otherwise goes through the device authorization flow.
+func (c *oidcClient) login(issuer string, clientID string, audience string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	provider := oidcProvider{}
+	err := c.getJSON(strings.TrimSuffix(issuer, "/")+"/.well-known/openid-configuration", &provider)
+	if err != nil {
+		return fmt.Errorf("Failed to discover OpenID Connect provider: %w", err)
+	}
+
+	// Try refreshing the tokens first, falling back to logging in again.
+	var tokens *oidcTokens
+	if c.tokens != nil && c.tokens.RefreshToken != "" && c.tokens.Audience == audience {
+		tokens, _ = c.requestTokens(provider.TokenEndpoint, url.Values{
+			"grant_type":    {"refresh_token"},
+			"refresh_token": {c.tokens.RefreshToken},
+			"client_id":     {clientID},
+		})
+	}
+
+	if tokens == nil {
+		tokens, err = c.deviceLogin(provider, clientID, audience)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Keep the refresh token if the provider didn't issue a new one.
+	if tokens.RefreshToken == "" && c.tokens != nil {
+		tokens.RefreshToken = c.tokens.RefreshToken
+	}
+
+	tokens.Audience = audience
+	c.tokens = tokens
+
+	if c.tokensPath != "" {
+		content, err := json.Marshal(tokens)
+		if err != nil {
+			return err
+		}
+
+		err = ioutil.WriteFile(c.tokensPath, content, 0600)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deviceLogin goes through the device authorization flow, asking the user to log in with their browser.
+func (c *oidcClient) deviceLogin(provider oidcProvider, clientID string, audience string) (*oidcTokens, error) {
+	if provider.DeviceAuthorizationEndpoint == "" {
+		return nil, fmt.Errorf("OpenID Connect provider doesn't support the device authorization flow")
+	}
+
+	values := url.Values{
+		"client_id": {clientID},
+		"scope":     {"openid email profile offline_access"},
+	}
+
+	if audience != "" {
+		values.Set("audience", audience)
+	}
+
+	device := struct {
+		DeviceCode              string `json:"device_code"`
+		UserCode                string `json:"user_code"`
+		VerificationURI         string `json:"verification_uri"`
+		VerificationURIComplete string `json:"verification_uri_complete"`
+		ExpiresIn               int    `json:"expires_in"`
+		Interval                int    `json:"interval"`
+	}{}
+
+	err := c.postForm(provider.DeviceAuthorizationEndpoint, values, &device)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to start OpenID Connect login: %w", err)
+	}
+
+	fmt.Printf("URL: %s\n", device.VerificationURI)
+	fmt.Printf("Code: %s\n\n", device.UserCode)
+
+	if device.VerificationURIComplete != "" {
+		u, err := url.Parse(device.VerificationURIComplete)
+		if err == nil {
+			_ = httpbakery.OpenWebBrowser(u)
+		}
+	}
+
+	interval := time.Duration(device.Interval) * time.Second
+	if interval == 0 {
+		interval = 5 * time.Second
+	}
+
+	expiry := time.Now().Add(time.Duration(device.ExpiresIn) * time.Second)
+	if device.ExpiresIn == 0 {
+		expiry = time.Now().Add(10 * time.Minute)
+	}
+
+	for time.Now().Before(expiry) {
+		time.Sleep(interval)
+
+		tokens, err := c.requestTokens(provider.TokenEndpoint, url.Values{
+			"grant_type":  {"urn:ietf:params:oauth:grant-type:device_code"},
+			"device_code": {device.DeviceCode},
+			"client_id":   {clientID},
+		})
+		if err == nil {
+			return tokens, nil
+		}
+
+		switch err.Error() {
+		case "authorization_pending":
+			continue
+		case "slow_down":
+			interval += 5 * time.Second
+			continue
+		}
+
+		return nil, fmt.Errorf("Failed OpenID Connect login: %w", err)
+	}
+
+	return nil, fmt.Errorf("OpenID Connect login timed out")
+}
+
+// requestTokens requests tokens from the token endpoint. Errors returned by the provider are
+// returned as is, to allow polling during the device authorization flow.
+func (c *oidcClient) requestTokens(endpoint string, values url.Values) (*oidcTokens, error) {
+	result := struct {
+		AccessToken  string `json:"access_token"`
+		IDToken      string `json:"id_token"`
+		RefreshToken string `json:"refresh_token"`
+		ExpiresIn    int    `json:"expires_in"`
+		Error        string `json:"error"`
+	}{}
+
+	err := c.postForm(endpoint, values, &result)
+	if err != nil && result.Error == "" {
+		return nil, err
+	}
+
+	if result.Error != "" {
+		return nil, fmt.Errorf("%s", result.Error)
+	}
+
+	return &oidcTokens{
+		AccessToken:  result.AccessToken,
+		IDToken:      result.IDToken,
+		RefreshToken: result.RefreshToken,
+		Expiry:       time.Now().Add(time.Duration(result.ExpiresIn) * time.Second),
+	}, nil
+}
+
+func (c *oidcClient) getJSON(endpoint string, target interface{}) error {
+	resp, err := c.httpClient.Get(endpoint)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Unexpected response from %q: %s", endpoint, resp.Status)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(target)
+}
+
+func (c *oidcClient) postForm(endpoint string, values url.Values, target interface{}) error {
+	resp, err := c.httpClient.PostForm(endpoint, values)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	err = json.NewDecoder(resp.Body).Decode(target)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Unexpected response from %q: %s", endpoint, resp.Status)
+	}
+
+	return nil
+}
@@ -81,6 +81,47 @@ func (r *ProtocolLXD) GetOperationWaitSecret(uuid string, secret string, timeout
 	return &op, etag, nil
 }
 
+// GetOperationWaitStatus returns an Operation entry for the provided uuid once it reaches the given status, is complete or hits the timeout
+func (r *ProtocolLXD) GetOperationWaitStatus(uuid string, status api.StatusCode, timeout int) (*api.Operation, string, error) {
+	if !r.HasExtension("operation_wait_status") {
+		return nil, "", fmt.Errorf(`The server is missing the required "operation_wait_status" API extension`)
+	}
+
+	op := api.Operation{}
+
+	// Fetch the raw value
+	etag, err := r.queryStruct("GET", fmt.Sprintf("/operations/%s/wait?status=%s&timeout=%d", url.PathEscape(uuid), url.QueryEscape(status.String()), timeout), nil, "", &op)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &op, etag, nil
+}
+
+// GetOperationsWait returns the Operation entries for the provided uuids once they're all complete or hit the timeout
+func (r *ProtocolLXD) GetOperationsWait(uuids []string, timeout int) ([]api.Operation, error) {
+	if !r.HasExtension("operation_wait_status") {
+		return nil, fmt.Errorf(`The server is missing the required "operation_wait_status" API extension`)
+	}
+
+	values := url.Values{}
+	for _, uuid := range uuids {
+		values.Add("id", uuid)
+	}
+
+	values.Set("timeout", fmt.Sprintf("%d", timeout))
+
+	ops := []api.Operation{}
+
+	// Fetch the raw value
+	_, err := r.queryStruct("GET", fmt.Sprintf("/operations/wait?%s", values.Encode()), nil, "", &ops)
+	if err != nil {
+		return nil, err
+	}
+
+	return ops, nil
+}
+
 // GetOperationWebsocket returns a websocket connection for the provided operation
 func (r *ProtocolLXD) GetOperationWebsocket(uuid string, secret string) (*websocket.Conn, error) {
 	path := fmt.Sprintf("/operations/%s/websocket", url.PathEscape(uuid))
@@ -91,6 +132,31 @@ func (r *ProtocolLXD) GetOperationWebsocket(uuid string, secret string) (*websoc
 	return r.websocket(path)
 }
 
+// GetOperationObserverWebsocket returns a read-only websocket following the output of the console or exec session
+// run by the provided operation.
+func (r *ProtocolLXD) GetOperationObserverWebsocket(uuid string) (*websocket.Conn, error) {
+	if !r.HasExtension("instance_session_observers") {
+		return nil, fmt.Errorf("The server is missing the required \"instance_session_observers\" API extension")
+	}
+
+	op, _, err := r.GetOperation(uuid)
+	if err != nil {
+		return nil, err
+	}
+
+	fds, ok := op.Metadata["fds"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("Operation %q doesn't provide any websocket", uuid)
+	}
+
+	secret, ok := fds["observer"].(string)
+	if !ok {
+		return nil, fmt.Errorf("Operation %q can't be observed", uuid)
+	}
+
+	return r.GetOperationWebsocket(uuid, secret)
+}
+
 // DeleteOperation deletes (cancels) a running operation
 func (r *ProtocolLXD) DeleteOperation(uuid string) error {
 	// Send the request
@@ -2,9 +2,14 @@ package lxd
 
 import (
 	"fmt"
+	"io"
+	"net/http"
 	"net/url"
 
 	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/cancel"
+	"github.com/lxc/lxd/shared/ioprogress"
+	"github.com/lxc/lxd/shared/units"
 )
 
 // Project handling functions
@@ -137,3 +142,132 @@ func (r *ProtocolLXD) DeleteProject(name string) error {
 
 	return nil
 }
+
+// GetProjectExportFile downloads an export of the project
+func (r *ProtocolLXD) GetProjectExportFile(name string, req *ProjectExportFileRequest) (*BackupFileResponse, error) {
+	if !r.HasExtension("project_export") {
+		return nil, fmt.Errorf("The server is missing the required \"project_export\" API extension")
+	}
+
+	// Build the URL
+	values := url.Values{}
+	if req.InstanceOnly {
+		values.Set("instance-only", "true")
+	}
+
+	if req.OptimizedStorage {
+		values.Set("optimized-storage", "true")
+	}
+
+	if req.CompressionAlgorithm != "" {
+		values.Set("compression", req.CompressionAlgorithm)
+	}
+
+	uri := fmt.Sprintf("%s/1.0/projects/%s/export", r.httpHost, url.PathEscape(name))
+	if len(values) > 0 {
+		uri += "?" + values.Encode()
+	}
+
+	// Prepare the download request
+	request, err := http.NewRequest("GET", uri, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.httpUserAgent != "" {
+		request.Header.Set("User-Agent", r.httpUserAgent)
+	}
+
+	if r.httpBearerToken != "" {
+		request.Header.Set("Authorization", "Bearer "+r.httpBearerToken)
+	}
+
+	// Start the request
+	response, doneCh, err := cancel.CancelableDownload(req.Canceler, r.http, request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	defer close(doneCh)
+
+	if response.StatusCode != http.StatusOK {
+		_, _, err := lxdParseResponse(response)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Handle the data, the export being streamed as it's generated its size isn't known upfront
+	body := response.Body
+	if req.ProgressHandler != nil {
+		body = &ioprogress.ProgressReader{
+			ReadCloser: response.Body,
+			Tracker: &ioprogress.ProgressTracker{
+				Handler: func(received int64, speed int64) {
+					req.ProgressHandler(ioprogress.NewProgressData(fmt.Sprintf("%s (%s/s)", units.GetByteSizeString(received, 2), units.GetByteSizeString(speed, 2)), received, 0, speed))
+				},
+			},
+		}
+	}
+
+	size, err := io.Copy(req.ExportFile, body)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := BackupFileResponse{}
+	resp.Size = size
+
+	return &resp, nil
+}
+
+// CreateProjectFromExport creates a project along with its content from an export
+func (r *ProtocolLXD) CreateProjectFromExport(args ProjectImportArgs) (Operation, error) {
+	if !r.HasExtension("project_export") {
+		return nil, fmt.Errorf("The server is missing the required \"project_export\" API extension")
+	}
+
+	// Prepare the HTTP request
+	req, err := http.NewRequest("POST", fmt.Sprintf("%s/1.0/projects", r.httpHost), args.ExportFile)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	if args.Name != "" {
+		req.Header.Set("X-LXD-name", args.Name)
+	}
+
+	if args.PoolName != "" {
+		req.Header.Set("X-LXD-pool", args.PoolName)
+	}
+
+	// Send the request
+	resp, err := r.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Handle errors
+	response, _, err := lxdParseResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get to the operation
+	respOperation, err := response.MetadataAsOperation()
+	if err != nil {
+		return nil, err
+	}
+
+	// Setup an Operation wrapper
+	op := operation{
+		Operation: *respOperation,
+		r:         r,
+		chActive:  make(chan bool),
+	}
+
+	return &op, nil
+}
@@ -0,0 +1,106 @@
+package lxd
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/lxc/lxd/shared/api"
+)
+
+// GetSecretNames returns a list of secret names.
+func (r *ProtocolLXD) GetSecretNames() ([]string, error) {
+	if !r.HasExtension("secrets") {
+		return nil, fmt.Errorf(`The server is missing the required "secrets" API extension`)
+	}
+
+	// Fetch the raw URL values.
+	urls := []string{}
+	baseURL := "/secrets"
+	_, err := r.queryStruct("GET", baseURL, nil, "", &urls)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse it.
+	return urlsToResourceNames(baseURL, urls...)
+}
+
+// GetSecrets returns a list of secret structs, without their values.
+func (r *ProtocolLXD) GetSecrets() ([]api.Secret, error) {
+	if !r.HasExtension("secrets") {
+		return nil, fmt.Errorf(`The server is missing the required "secrets" API extension`)
+	}
+
+	secrets := []api.Secret{}
+
+	// Fetch the raw value.
+	_, err := r.queryStruct("GET", "/secrets?recursion=1", nil, "", &secrets)
+	if err != nil {
+		return nil, err
+	}
+
+	return secrets, nil
+}
+
+// GetSecret returns a secret entry for the provided name, without its value.
+func (r *ProtocolLXD) GetSecret(name string) (*api.Secret, string, error) {
+	if !r.HasExtension("secrets") {
+		return nil, "", fmt.Errorf(`The server is missing the required "secrets" API extension`)
+	}
+
+	secret := api.Secret{}
+
+	// Fetch the raw value.
+	etag, err := r.queryStruct("GET", fmt.Sprintf("/secrets/%s", url.PathEscape(name)), nil, "", &secret)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &secret, etag, nil
+}
+
+// CreateSecret defines a new secret using the provided struct.
+func (r *ProtocolLXD) CreateSecret(secret api.SecretsPost) error {
+	if !r.HasExtension("secrets") {
+		return fmt.Errorf(`The server is missing the required "secrets" API extension`)
+	}
+
+	// Send the request.
+	_, _, err := r.query("POST", "/secrets", secret, "")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UpdateSecret updates the secret to match the provided struct.
+// The value is left unchanged if none is provided.
+func (r *ProtocolLXD) UpdateSecret(name string, secret api.SecretPut, ETag string) error {
+	if !r.HasExtension("secrets") {
+		return fmt.Errorf(`The server is missing the required "secrets" API extension`)
+	}
+
+	// Send the request.
+	_, _, err := r.query("PUT", fmt.Sprintf("/secrets/%s", url.PathEscape(name)), secret, ETag)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteSecret deletes an existing secret.
+func (r *ProtocolLXD) DeleteSecret(name string) error {
+	if !r.HasExtension("secrets") {
+		return fmt.Errorf(`The server is missing the required "secrets" API extension`)
+	}
+
+	// Send the request.
+	_, _, err := r.query("DELETE", fmt.Sprintf("/secrets/%s", url.PathEscape(name)), nil, "")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -1,7 +1,10 @@
 package lxd
 
 import (
+	"context"
 	"fmt"
+	"io/ioutil"
+	"net/http"
 
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/api"
@@ -89,6 +92,110 @@ func (r *ProtocolLXD) GetServerResources() (*api.Resources, error) {
 	return &resources, nil
 }
 
+// GetServerPreseed returns the configuration of the LXD server in the "lxd init --preseed" format.
+func (r *ProtocolLXD) GetServerPreseed() (*api.InitPreseed, error) {
+	if !r.HasExtension("server_preseed") {
+		return nil, fmt.Errorf("The server is missing the required \"server_preseed\" API extension")
+	}
+
+	preseed := api.InitPreseed{}
+
+	// Fetch the raw value
+	_, err := r.queryStruct("GET", "/preseed", nil, "", &preseed)
+	if err != nil {
+		return nil, err
+	}
+
+	return &preseed, nil
+}
+
+// GetIdmapAllocations returns the ranges of host uids/gids allocated to the instances with an isolated idmap.
+func (r *ProtocolLXD) GetIdmapAllocations() ([]api.IdmapAllocation, error) {
+	if !r.HasExtension("idmap_allocations") {
+		return nil, fmt.Errorf("The server is missing the required \"idmap_allocations\" API extension")
+	}
+
+	allocations := []api.IdmapAllocation{}
+
+	_, err := r.queryStruct("GET", "/idmap-allocations", nil, "", &allocations)
+	if err != nil {
+		return nil, err
+	}
+
+	return allocations, nil
+}
+
+// RunBatch runs a list of API requests on the server in a single round trip and returns their results.
+func (r *ProtocolLXD) RunBatch(batch api.BatchPost) ([]api.BatchResult, error) {
+	if !r.HasExtension("api_batch") {
+		return nil, fmt.Errorf("The server is missing the required \"api_batch\" API extension")
+	}
+
+	results := []api.BatchResult{}
+
+	_, err := r.queryStruct("POST", "/batch", batch, "", &results)
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// GetMetrics returns the text OpenMetrics data.
+func (r *ProtocolLXD) GetMetrics() (string, error) {
+	if !r.HasExtension("metrics") {
+		return "", fmt.Errorf("The server is missing the required \"metrics\" API extension")
+	}
+
+	// Prepare the request.
+	requestURL, err := r.setQueryAttributes(fmt.Sprintf("%s/1.0/metrics", r.httpHost))
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequest("GET", requestURL, nil)
+	if err != nil {
+		return "", err
+	}
+
+	// Send the request.
+	resp, err := r.do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		_, _, err := lxdParseResponse(resp)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	content, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	return string(content), nil
+}
+
+// GetInstancesMetrics returns the resource usage counters of all the instances in the project across the cluster.
+func (r *ProtocolLXD) GetInstancesMetrics() ([]api.InstanceMetrics, error) {
+	if !r.HasExtension("instances_metrics") {
+		return nil, fmt.Errorf("The server is missing the required \"instances_metrics\" API extension")
+	}
+
+	metrics := []api.InstanceMetrics{}
+
+	_, err := r.queryStruct("GET", "/metrics/instances", nil, "", &metrics)
+	if err != nil {
+		return nil, err
+	}
+
+	return metrics, nil
+}
+
 // UseProject returns a client that will use a specific project.
 func (r *ProtocolLXD) UseProject(name string) InstanceServer {
 	return &ProtocolLXD{
@@ -96,13 +203,19 @@ func (r *ProtocolLXD) UseProject(name string) InstanceServer {
 		http:                 r.http,
 		httpCertificate:      r.httpCertificate,
 		httpHost:             r.httpHost,
+		httpUnixPath:         r.httpUnixPath,
 		httpProtocol:         r.httpProtocol,
 		httpUserAgent:        r.httpUserAgent,
+		httpBearerToken:      r.httpBearerToken,
 		bakeryClient:         r.bakeryClient,
+		oidcClient:           r.oidcClient,
 		bakeryInteractor:     r.bakeryInteractor,
 		requireAuthenticated: r.requireAuthenticated,
 		clusterTarget:        r.clusterTarget,
 		project:              name,
+		ctx:                  r.ctx,
+		retry:                r.retry,
+		eventsReconnect:      r.eventsReconnect,
 	}
 }
 
@@ -115,13 +228,43 @@ func (r *ProtocolLXD) UseTarget(name string) InstanceServer {
 		http:                 r.http,
 		httpCertificate:      r.httpCertificate,
 		httpHost:             r.httpHost,
+		httpUnixPath:         r.httpUnixPath,
 		httpProtocol:         r.httpProtocol,
 		httpUserAgent:        r.httpUserAgent,
+		httpBearerToken:      r.httpBearerToken,
 		bakeryClient:         r.bakeryClient,
+		oidcClient:           r.oidcClient,
 		bakeryInteractor:     r.bakeryInteractor,
 		requireAuthenticated: r.requireAuthenticated,
 		project:              r.project,
 		clusterTarget:        name,
+		ctx:                  r.ctx,
+		retry:                r.retry,
+		eventsReconnect:      r.eventsReconnect,
+	}
+}
+
+// WithContext returns a client that will use the given context for all its requests,
+// websockets and operation waits. Cancelling the context aborts any of those in progress.
+func (r *ProtocolLXD) WithContext(ctx context.Context) InstanceServer {
+	return &ProtocolLXD{
+		server:               r.server,
+		http:                 r.http,
+		httpCertificate:      r.httpCertificate,
+		httpHost:             r.httpHost,
+		httpUnixPath:         r.httpUnixPath,
+		httpProtocol:         r.httpProtocol,
+		httpUserAgent:        r.httpUserAgent,
+		httpBearerToken:      r.httpBearerToken,
+		bakeryClient:         r.bakeryClient,
+		oidcClient:           r.oidcClient,
+		bakeryInteractor:     r.bakeryInteractor,
+		requireAuthenticated: r.requireAuthenticated,
+		project:              r.project,
+		clusterTarget:        r.clusterTarget,
+		ctx:                  ctx,
+		retry:                r.retry,
+		eventsReconnect:      r.eventsReconnect,
 	}
 }
 
@@ -769,6 +769,10 @@ func (r *ProtocolLXD) GetStoragePoolVolumeBackupFile(pool string, volName string
 		request.Header.Set("User-Agent", r.httpUserAgent)
 	}
 
+	if r.httpBearerToken != "" {
+		request.Header.Set("Authorization", "Bearer "+r.httpBearerToken)
+	}
+
 	// Start the request
 	response, doneCh, err := cancel.CancelableDownload(req.Canceler, r.http, request)
 	if err != nil {
@@ -792,7 +796,7 @@ func (r *ProtocolLXD) GetStoragePoolVolumeBackupFile(pool string, volName string
 			Tracker: &ioprogress.ProgressTracker{
 				Length: response.ContentLength,
 				Handler: func(percent int64, speed int64) {
-					req.ProgressHandler(ioprogress.ProgressData{Text: fmt.Sprintf("%d%% (%s/s)", percent, units.GetByteSizeString(speed, 2))})
+					req.ProgressHandler(ioprogress.NewProgressData(fmt.Sprintf("%d%% (%s/s)", percent, units.GetByteSizeString(speed, 2)), percent, response.ContentLength, speed))
 				},
 			},
 		}
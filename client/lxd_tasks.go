@@ -0,0 +1,75 @@
+package lxd
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/lxc/lxd/shared/api"
+)
+
+// Background task handling functions
+
+// GetTaskNames returns a list of background task names.
+func (r *ProtocolLXD) GetTaskNames() ([]string, error) {
+	if !r.HasExtension("tasks") {
+		return nil, fmt.Errorf("The server is missing the required \"tasks\" API extension")
+	}
+
+	// Fetch the raw values.
+	urls := []string{}
+	baseURL := "/tasks"
+	_, err := r.queryStruct("GET", baseURL, nil, "", &urls)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse it.
+	return urlsToResourceNames(baseURL, urls...)
+}
+
+// GetTasks returns a list of background tasks.
+func (r *ProtocolLXD) GetTasks() ([]api.Task, error) {
+	if !r.HasExtension("tasks") {
+		return nil, fmt.Errorf("The server is missing the required \"tasks\" API extension")
+	}
+
+	tasks := []api.Task{}
+
+	_, err := r.queryStruct("GET", "/tasks?recursion=1", nil, "", &tasks)
+	if err != nil {
+		return nil, err
+	}
+
+	return tasks, nil
+}
+
+// GetTask returns the background task with the given name.
+func (r *ProtocolLXD) GetTask(name string) (*api.Task, string, error) {
+	if !r.HasExtension("tasks") {
+		return nil, "", fmt.Errorf("The server is missing the required \"tasks\" API extension")
+	}
+
+	task := api.Task{}
+
+	etag, err := r.queryStruct("GET", fmt.Sprintf("/tasks/%s", url.PathEscape(name)), nil, "", &task)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &task, etag, nil
+}
+
+// UpdateTaskState runs, pauses or resumes the background task with the given name.
+func (r *ProtocolLXD) UpdateTaskState(name string, state api.TaskStatePut) error {
+	if !r.HasExtension("tasks") {
+		return fmt.Errorf("The server is missing the required \"tasks\" API extension")
+	}
+
+	// Send the request
+	_, _, err := r.query("PUT", fmt.Sprintf("/tasks/%s/state", url.PathEscape(name)), state, "")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -1,6 +1,7 @@
 package lxd
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"sync"
@@ -102,6 +103,11 @@ func (op *operation) Refresh() error {
 
 // Wait lets you wait until the operation reaches a final state
 func (op *operation) Wait() error {
+	return op.WaitContext(op.r.requestContext())
+}
+
+// WaitContext lets you wait until the operation reaches a final state or the context is cancelled
+func (op *operation) WaitContext(ctx context.Context) error {
 	op.handlerLock.Lock()
 	// Check if not done already
 	if op.StatusCode.IsFinal() {
@@ -122,7 +128,11 @@ func (op *operation) Wait() error {
 		return err
 	}
 
-	<-op.chActive
+	select {
+	case <-op.chActive:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 
 	// We're done, parse the result
 	if op.Err != "" {
@@ -315,10 +325,23 @@ func (op *remoteOperation) GetTarget() (*api.Operation, error) {
 
 // Wait lets you wait until the operation reaches a final state
 func (op *remoteOperation) Wait() error {
-	<-op.chDone
+	return op.WaitContext(context.Background())
+}
+
+// WaitContext lets you wait until the operation reaches a final state or the context is cancelled
+func (op *remoteOperation) WaitContext(ctx context.Context) error {
+	select {
+	case <-op.chDone:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 
 	if op.chPost != nil {
-		<-op.chPost
+		select {
+		case <-op.chPost:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
 	}
 
 	return op.err
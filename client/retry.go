@@ -0,0 +1,109 @@
+package lxd
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/lxc/lxd/shared/logger"
+)
+
+// retryPolicy holds the retry settings of a client.
+type retryPolicy struct {
+	count    int
+	delay    time.Duration
+	maxDelay time.Duration
+}
+
+// newRetryPolicy returns the retry policy matching the connection arguments.
+func newRetryPolicy(args *ConnectionArgs) retryPolicy {
+	policy := retryPolicy{
+		count:    args.RetryCount,
+		delay:    args.RetryDelay,
+		maxDelay: args.RetryMaxDelay,
+	}
+
+	if policy.delay <= 0 {
+		policy.delay = 500 * time.Millisecond
+	}
+
+	if policy.maxDelay <= 0 {
+		policy.maxDelay = 30 * time.Second
+	}
+
+	return policy
+}
+
+// isTransientFailure returns whether a request failed in a way that may succeed if retried.
+// Requests other than GET are only retried when they can't have reached the server (connection failure)
+// or were rejected without being processed (503 status), as replaying them may apply them twice.
+func isTransientFailure(req *http.Request, resp *http.Response, err error) bool {
+	if err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return false
+		}
+
+		var opErr *net.OpError
+		if errors.As(err, &opErr) && opErr.Op == "dial" {
+			return true
+		}
+
+		if req.Method != "GET" {
+			return false
+		}
+
+		if opErr != nil {
+			return true
+		}
+
+		return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNRESET)
+	}
+
+	// The API is unavailable while the daemon starts or the cluster database changes leader.
+	return resp.StatusCode == http.StatusServiceUnavailable
+}
+
+// doRetry sends the request, retrying it with an exponential backoff on transient failures.
+func (r *ProtocolLXD) doRetry(req *http.Request) (*http.Response, error) {
+	delay := r.retry.delay
+
+	for attempt := 0; ; attempt++ {
+		resp, err := r.send(req)
+		if attempt >= r.retry.count || !isTransientFailure(req, resp, err) {
+			return resp, err
+		}
+
+		// Requests with a streamed body can't be replayed.
+		if req.Body != nil && req.GetBody == nil {
+			return resp, err
+		}
+
+		if resp != nil {
+			resp.Body.Close()
+		}
+
+		logger.Debug("Retrying request to LXD", "method", req.Method, "url", req.URL.String(), "attempt", attempt+1, "delay", delay)
+
+		select {
+		case <-time.After(delay):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+
+		if req.GetBody != nil {
+			req.Body, err = req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		delay *= 2
+		if delay > r.retry.maxDelay {
+			delay = r.retry.maxDelay
+		}
+	}
+}
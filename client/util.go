@@ -8,6 +8,7 @@ import (
 	"net/url"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/lxc/lxd/shared"
 )
@@ -138,6 +139,36 @@ func unixHTTPClient(client *http.Client, path string) (*http.Client, error) {
 	return client, nil
 }
 
+// tuneHTTPTransport applies the connection reuse settings of the connection arguments to the client transport.
+func tuneHTTPTransport(client *http.Client, args *ConnectionArgs) {
+	transport, ok := client.Transport.(*http.Transport)
+	if !ok {
+		return
+	}
+
+	if args.KeepAlive {
+		transport.DisableKeepAlives = false
+
+		transport.MaxIdleConns = 100
+		if args.MaxIdleConns > 0 {
+			transport.MaxIdleConns = args.MaxIdleConns
+		}
+
+		// All requests of a client go to the same host.
+		transport.MaxIdleConnsPerHost = transport.MaxIdleConns
+
+		transport.IdleConnTimeout = 90 * time.Second
+		if args.IdleConnTimeout > 0 {
+			transport.IdleConnTimeout = args.IdleConnTimeout
+		}
+	}
+
+	if args.HTTP2 && transport.TLSClientConfig != nil {
+		transport.ForceAttemptHTTP2 = true
+		transport.TLSClientConfig.NextProtos = []string{"h2", "http/1.1"}
+	}
+}
+
 // remoteOperationResult used for storing the error that occurred for a particular remote URL.
 type remoteOperationResult struct {
 	URL   string
@@ -55,10 +55,20 @@ func (c *cmdAliasAdd) Command() *cobra.Command {
 	cmd.Use = usage("add", i18n.G("<alias> <target>"))
 	cmd.Short = i18n.G("Add new aliases")
 	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
-		`Add new aliases`))
+		`Add new aliases
+
+The target can refer to the arguments passed to the alias:
+  @ARGS@ is replaced by all the arguments
+  @ARG1@, @ARG2@, ... are replaced by the matching argument
+  @ARG2:default@ is replaced by the second argument or "default" if it's missing
+
+Arguments not used by a placeholder are added at the end.`))
 	cmd.Example = cli.FormatSection("", i18n.G(
 		`lxc alias add list "list -c ns46S"
-    Overwrite the "list" command to pass -c ns46S.`))
+    Overwrite the "list" command to pass -c ns46S.
+
+lxc alias add login "exec @ARG1@ -- su -l @ARG2:ubuntu@"
+    Add a "login" command, "lxc login c1" opening a shell as the ubuntu user in c1.`))
 
 	cmd.RunE = c.Run
 
@@ -102,7 +112,7 @@ func (c *cmdAliasList) Command() *cobra.Command {
 	cmd.Short = i18n.G("List aliases")
 	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
 		`List aliases`))
-	cmd.Flags().StringVarP(&c.flagFormat, "format", "f", "table", i18n.G("Format (csv|json|table|yaml)")+"``")
+	cmd.Flags().StringVarP(&c.flagFormat, "format", "f", "table", i18n.G("Format (csv|json|table|yaml|compact)")+"``")
 
 	cmd.RunE = c.Run
 
@@ -101,7 +101,7 @@ func (c *cmdClusterList) Command() *cobra.Command {
 	cmd.Short = i18n.G("List all the cluster members")
 	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
 		`List all the cluster members`))
-	cmd.Flags().StringVarP(&c.flagFormat, "format", "f", "table", i18n.G("Format (csv|json|table|yaml)")+"``")
+	cmd.Flags().StringVarP(&c.flagFormat, "format", "f", "table", i18n.G("Format (csv|json|table|yaml|compact)")+"``")
 
 	cmd.RunE = c.Run
 
@@ -171,6 +171,8 @@ func (c *cmdClusterList) Run(cmd *cobra.Command, args []string) error {
 type cmdClusterShow struct {
 	global  *cmdGlobal
 	cluster *cmdCluster
+
+	flagFormat string
 }
 
 func (c *cmdClusterShow) Command() *cobra.Command {
@@ -180,6 +182,8 @@ func (c *cmdClusterShow) Command() *cobra.Command {
 	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
 		`Show details of a cluster member`))
 
+	cmd.Flags().StringVarP(&c.flagFormat, "format", "f", "yaml", i18n.G("Format (json|yaml)")+"``")
+
 	cmd.RunE = c.Run
 
 	return cmd
@@ -206,13 +210,10 @@ func (c *cmdClusterShow) Run(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	// Render as YAML
-	data, err := yaml.Marshal(&member)
+	err = utils.RenderObject(c.flagFormat, &member)
 	if err != nil {
 		return err
 	}
-
-	fmt.Printf("%s", data)
 	return nil
 }
 
@@ -659,7 +660,7 @@ func (c *cmdClusterListTokens) Command() *cobra.Command {
 	cmd.Use = usage("list-tokens", i18n.G("[<remote>:]"))
 	cmd.Short = i18n.G("List all active cluster member join tokens")
 	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(`List all active cluster member join tokens`))
-	cmd.Flags().StringVarP(&c.flagFormat, "format", "f", "table", i18n.G("Format (csv|json|table|yaml)")+"``")
+	cmd.Flags().StringVarP(&c.flagFormat, "format", "f", "table", i18n.G("Format (csv|json|table|yaml|compact)")+"``")
 
 	cmd.RunE = c.Run
 
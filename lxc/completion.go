@@ -0,0 +1,107 @@
+package main
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	lxd "github.com/lxc/lxd/client"
+	"github.com/lxc/lxd/shared/api"
+)
+
+// cmpRemotes returns the names of the remotes (with their ":" suffix), only including the ones able to
+// run instances unless images is set.
+func (g *cmdGlobal) cmpRemotes(images bool) []string {
+	results := []string{}
+	for name, remote := range g.conf.Remotes {
+		if !images && (remote.Protocol == "simplestreams" || remote.Public) {
+			continue
+		}
+
+		results = append(results, name+":")
+	}
+
+	sort.Strings(results)
+
+	return results
+}
+
+// cmpNames returns the names listed by the server toComplete refers to, followed by the remotes when
+// toComplete doesn't include one yet. Names from a remote other than the default one are prefixed with it.
+func (g *cmdGlobal) cmpNames(toComplete string, list func(d lxd.InstanceServer) ([]string, error)) ([]string, cobra.ShellCompDirective) {
+	results := []string{}
+
+	resources, err := g.ParseServers(toComplete)
+	if err == nil && len(resources) > 0 {
+		resource := resources[0]
+
+		names, err := list(resource.server)
+		if err == nil {
+			prefix := ""
+			if strings.Contains(toComplete, ":") {
+				prefix = resource.remote + ":"
+			}
+
+			for _, name := range names {
+				results = append(results, prefix+name)
+			}
+		}
+	}
+
+	if !strings.Contains(toComplete, ":") {
+		results = append(results, g.cmpRemotes(false)...)
+	}
+
+	return results, cobra.ShellCompDirectiveNoFileComp
+}
+
+// cmpInstances completes instance names.
+func (g *cmdGlobal) cmpInstances(toComplete string) ([]string, cobra.ShellCompDirective) {
+	return g.cmpNames(toComplete, func(d lxd.InstanceServer) ([]string, error) {
+		return d.GetInstanceNames(api.InstanceTypeAny)
+	})
+}
+
+// cmpProfiles completes profile names.
+func (g *cmdGlobal) cmpProfiles(toComplete string) ([]string, cobra.ShellCompDirective) {
+	return g.cmpNames(toComplete, func(d lxd.InstanceServer) ([]string, error) {
+		return d.GetProfileNames()
+	})
+}
+
+// cmpStoragePools completes storage pool names.
+func (g *cmdGlobal) cmpStoragePools(toComplete string) ([]string, cobra.ShellCompDirective) {
+	return g.cmpNames(toComplete, func(d lxd.InstanceServer) ([]string, error) {
+		return d.GetStoragePoolNames()
+	})
+}
+
+// cmpImages completes image aliases, including the ones of image servers.
+func (g *cmdGlobal) cmpImages(toComplete string) ([]string, cobra.ShellCompDirective) {
+	results := []string{}
+
+	remote, _, err := g.conf.ParseRemote(toComplete)
+	if err == nil {
+		d, err := g.conf.GetImageServer(remote)
+		if err == nil {
+			names, err := d.GetImageAliasNames()
+			if err == nil {
+				prefix := ""
+				if strings.Contains(toComplete, ":") {
+					prefix = remote + ":"
+				}
+
+				for _, name := range names {
+					results = append(results, prefix+name)
+				}
+			}
+		}
+	}
+
+	if !strings.Contains(toComplete, ":") {
+		results = append(results, g.cmpRemotes(true)...)
+	}
+
+	return results, cobra.ShellCompDirectiveNoFileComp
+}
@@ -10,6 +10,7 @@ import (
 	"gopkg.in/yaml.v2"
 
 	"github.com/lxc/lxd/client"
+	"github.com/lxc/lxd/lxc/utils"
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/api"
 	cli "github.com/lxc/lxd/shared/cmd"
@@ -604,6 +605,7 @@ type cmdConfigShow struct {
 	config *cmdConfig
 
 	flagExpanded bool
+	flagFormat   string
 }
 
 func (c *cmdConfigShow) Command() *cobra.Command {
@@ -615,6 +617,8 @@ func (c *cmdConfigShow) Command() *cobra.Command {
 
 	cmd.Flags().BoolVarP(&c.flagExpanded, "expanded", "e", false, i18n.G("Show the expanded configuration"))
 	cmd.Flags().StringVar(&c.config.flagTarget, "target", "", i18n.G("Cluster member name")+"``")
+	cmd.Flags().StringVarP(&c.flagFormat, "format", "f", "yaml", i18n.G("Format (json|yaml)")+"``")
+
 	cmd.RunE = c.Run
 
 	return cmd
@@ -641,7 +645,7 @@ func (c *cmdConfigShow) Run(cmd *cobra.Command, args []string) error {
 	resource := resources[0]
 
 	// Show configuration
-	var data []byte
+	var data interface{}
 
 	if resource.name == "" {
 		// Quick check.
@@ -665,10 +669,7 @@ func (c *cmdConfigShow) Run(cmd *cobra.Command, args []string) error {
 		}
 
 		brief := server.Writable()
-		data, err = yaml.Marshal(&brief)
-		if err != nil {
-			return err
-		}
+		data = &brief
 	} else {
 		// Quick checks.
 		if c.config.flagTarget != "" {
@@ -708,15 +709,10 @@ func (c *cmdConfigShow) Run(cmd *cobra.Command, args []string) error {
 			}
 		}
 
-		data, err = yaml.Marshal(&brief)
-		if err != nil {
-			return err
-		}
+		data = brief
 	}
 
-	fmt.Printf("%s", data)
-
-	return nil
+	return utils.RenderObject(c.flagFormat, data)
 }
 
 // Unset
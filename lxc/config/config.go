@@ -62,6 +62,11 @@ func (c *Config) CookiesPath(remote string) string {
 	return c.ConfigPath("jars", remote)
 }
 
+// OIDCTokensPath returns the path for the remote's OpenID Connect tokens
+func (c *Config) OIDCTokensPath(remote string) string {
+	return c.ConfigPath("oidctokens", fmt.Sprintf("%s.json", remote))
+}
+
 // ServerCertPath returns the path for the remote's server certificate
 func (c *Config) ServerCertPath(remote string) string {
 	if c.Remotes[remote].Global == true {
@@ -94,7 +94,7 @@ func (c *Config) GetInstanceServer(name string) (lxd.InstanceServer, error) {
 	}
 
 	// HTTPs
-	if remote.AuthType != "candid" && (args.TLSClientCert == "" || args.TLSClientKey == "") {
+	if !shared.StringInSlice(remote.AuthType, []string{"candid", "oidc"}) && (args.TLSClientCert == "" || args.TLSClientKey == "") {
 		return nil, fmt.Errorf("Missing TLS client certificate and key")
 	}
 
@@ -245,6 +245,17 @@ func (c *Config) getConnectionArgs(name string) (*lxd.ConnectionArgs, error) {
 		args.CookieJar = c.cookieJars[name]
 	}
 
+	if args.AuthType == "oidc" {
+		if !shared.PathExists(c.ConfigPath("oidctokens")) {
+			err := os.MkdirAll(c.ConfigPath("oidctokens"), 0700)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		args.OIDCTokensPath = c.OIDCTokensPath(name)
+	}
+
 	// Stop here if no TLS involved
 	if strings.HasPrefix(remote.Addr, "unix:") {
 		return &args, nil
@@ -261,7 +272,7 @@ func (c *Config) getConnectionArgs(name string) (*lxd.ConnectionArgs, error) {
 	}
 
 	// Stop here if no client certificate involved
-	if remote.Protocol == "simplestreams" || remote.AuthType == "candid" {
+	if remote.Protocol == "simplestreams" || remote.AuthType == "candid" || remote.AuthType == "oidc" {
 		return &args, nil
 	}
 
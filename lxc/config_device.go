@@ -2,11 +2,12 @@ package main
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/spf13/cobra"
-	"gopkg.in/yaml.v2"
 
+	"github.com/lxc/lxd/lxc/utils"
 	cli "github.com/lxc/lxd/shared/cmd"
 	"github.com/lxc/lxd/shared/i18n"
 )
@@ -265,6 +266,8 @@ type cmdConfigDeviceList struct {
 	config       *cmdConfig
 	configDevice *cmdConfigDevice
 	profile      *cmdProfile
+
+	flagFormat string
 }
 
 func (c *cmdConfigDeviceList) Command() *cobra.Command {
@@ -279,6 +282,8 @@ func (c *cmdConfigDeviceList) Command() *cobra.Command {
 		cmd.Use = usage("list", i18n.G("[<remote>:]<profile>"))
 	}
 
+	cmd.Flags().StringVarP(&c.flagFormat, "format", "f", "", i18n.G("Format (csv|json|table|yaml|compact), only the names are listed by default")+"``")
+
 	cmd.RunE = c.Run
 
 	return cmd
@@ -304,30 +309,46 @@ func (c *cmdConfigDeviceList) Run(cmd *cobra.Command, args []string) error {
 	}
 
 	// List the devices
-	var devices []string
+	var devices map[string]map[string]string
 	if c.profile != nil {
 		profile, _, err := resource.server.GetProfile(resource.name)
 		if err != nil {
 			return err
 		}
 
-		for k := range profile.Devices {
-			devices = append(devices, k)
-		}
+		devices = profile.Devices
 	} else {
 		inst, _, err := resource.server.GetInstance(resource.name)
 		if err != nil {
 			return err
 		}
 
-		for k := range inst.Devices {
-			devices = append(devices, k)
-		}
+		devices = inst.Devices
 	}
 
-	fmt.Printf("%s\n", strings.Join(devices, "\n"))
+	names := []string{}
+	for k := range devices {
+		names = append(names, k)
+	}
 
-	return nil
+	sort.Strings(names)
+
+	if c.flagFormat == "" {
+		fmt.Printf("%s\n", strings.Join(names, "\n"))
+		return nil
+	}
+
+	data := [][]string{}
+	for _, name := range names {
+		data = append(data, []string{name, devices[name]["type"]})
+	}
+
+	header := []string{
+		i18n.G("NAME"),
+		i18n.G("TYPE"),
+	}
+
+	return utils.RenderTable(c.flagFormat, header, data, devices)
 }
 
 // Override
@@ -638,6 +659,8 @@ type cmdConfigDeviceShow struct {
 	config       *cmdConfig
 	configDevice *cmdConfigDevice
 	profile      *cmdProfile
+
+	flagFormat string
 }
 
 func (c *cmdConfigDeviceShow) Command() *cobra.Command {
@@ -651,6 +674,8 @@ func (c *cmdConfigDeviceShow) Command() *cobra.Command {
 	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
 		`Show full device configuration`))
 
+	cmd.Flags().StringVarP(&c.flagFormat, "format", "f", "yaml", i18n.G("Format (json|yaml)")+"``")
+
 	cmd.RunE = c.Run
 
 	return cmd
@@ -693,13 +718,11 @@ func (c *cmdConfigDeviceShow) Run(cmd *cobra.Command, args []string) error {
 		devices = inst.Devices
 	}
 
-	data, err := yaml.Marshal(&devices)
+	err = utils.RenderObject(c.flagFormat, &devices)
 	if err != nil {
 		return err
 	}
 
-	fmt.Print(string(data))
-
 	return nil
 }
 
@@ -8,6 +8,7 @@ import (
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v2"
 
+	"github.com/lxc/lxd/lxc/utils"
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/api"
 	cli "github.com/lxc/lxd/shared/cmd"
@@ -168,6 +169,8 @@ type cmdConfigMetadataShow struct {
 	global         *cmdGlobal
 	config         *cmdConfig
 	configMetadata *cmdConfigMetadata
+
+	flagFormat string
 }
 
 func (c *cmdConfigMetadataShow) Command() *cobra.Command {
@@ -177,6 +180,8 @@ func (c *cmdConfigMetadataShow) Command() *cobra.Command {
 	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
 		`Show instance metadata files`))
 
+	cmd.Flags().StringVarP(&c.flagFormat, "format", "f", "yaml", i18n.G("Format (json|yaml)")+"``")
+
 	cmd.RunE = c.Run
 
 	return cmd
@@ -207,11 +212,10 @@ func (c *cmdConfigMetadataShow) Run(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	content, err := yaml.Marshal(metadata)
+	err = utils.RenderObject(c.flagFormat, metadata)
 	if err != nil {
 		return err
 	}
-	fmt.Printf("%s", content)
 
 	return nil
 }
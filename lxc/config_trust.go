@@ -10,6 +10,7 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v2"
 
@@ -68,18 +69,30 @@ type cmdConfigTrustAdd struct {
 	flagName       string
 	flagProjects   string
 	flagRestricted bool
+	flagType       string
 }
 
 func (c *cmdConfigTrustAdd) Command() *cobra.Command {
 	cmd := &cobra.Command{}
-	cmd.Use = usage("add", i18n.G("[<remote>:] <cert>"))
+	cmd.Use = usage("add", i18n.G("[<remote>:] [<cert>]"))
 	cmd.Short = i18n.G("Add new trusted clients")
 	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
-		`Add new trusted clients`))
+		`Add new trusted clients
+
+When no certificate is given, a single-use token is generated instead for the
+client named with --name. The client then adds the server with:
+  lxc remote add <remote> <token>`))
+	cmd.Example = cli.FormatSection("", i18n.G(
+		`lxc config trust add client.crt
+    Trust the certificate in client.crt.
+
+lxc config trust add --name laptop
+    Generate a token for the "laptop" client to add the server as a remote.`))
 
 	cmd.Flags().BoolVar(&c.flagRestricted, "restricted", false, i18n.G("Restrict the certificate to one or more projects"))
 	cmd.Flags().StringVar(&c.flagProjects, "projects", "", i18n.G("List of projects to restrict the certificate to")+"``")
 	cmd.Flags().StringVar(&c.flagName, "name", "", i18n.G("Alternative certificate name")+"``")
+	cmd.Flags().StringVar(&c.flagType, "type", "client", i18n.G("Type of certificate (client or metrics)")+"``")
 
 	cmd.RunE = c.Run
 
@@ -88,14 +101,14 @@ func (c *cmdConfigTrustAdd) Command() *cobra.Command {
 
 func (c *cmdConfigTrustAdd) Run(cmd *cobra.Command, args []string) error {
 	// Quick checks.
-	exit, err := c.global.CheckArgs(cmd, args, 1, 2)
+	exit, err := c.global.CheckArgs(cmd, args, 0, 2)
 	if exit {
 		return err
 	}
 
-	// Parse remote
+	// Parse remote, a single argument is the certificate unless it ends with a colon.
 	remote := ""
-	if len(args) > 0 {
+	if len(args) == 2 || (len(args) == 1 && strings.HasSuffix(args[0], ":")) {
 		remote = args[0]
 	}
 
@@ -106,6 +119,15 @@ func (c *cmdConfigTrustAdd) Run(cmd *cobra.Command, args []string) error {
 
 	resource := resources[0]
 
+	if !shared.StringInSlice(c.flagType, []string{api.CertificateTypeClient, api.CertificateTypeMetrics}) {
+		return fmt.Errorf(i18n.G("Unknown certificate type %q"), c.flagType)
+	}
+
+	// Generate a certificate add token when no certificate is given.
+	if len(args) == 0 || remote == args[len(args)-1] {
+		return c.addToken(resource)
+	}
+
 	// Load the certificate.
 	fname := args[len(args)-1]
 	if fname == "-" {
@@ -130,7 +152,7 @@ func (c *cmdConfigTrustAdd) Run(cmd *cobra.Command, args []string) error {
 	cert := api.CertificatesPost{}
 	cert.Certificate = base64.StdEncoding.EncodeToString(x509Cert.Raw)
 	cert.Name = name
-	cert.Type = api.CertificateTypeClient
+	cert.Type = c.flagType
 	cert.Restricted = c.flagRestricted
 	if c.flagProjects != "" {
 		cert.Projects = strings.Split(c.flagProjects, ",")
@@ -139,6 +161,85 @@ func (c *cmdConfigTrustAdd) Run(cmd *cobra.Command, args []string) error {
 	return resource.server.CreateCertificate(cert)
 }
 
+// addToken requests a single-use certificate add token for the client named with --name.
+func (c *cmdConfigTrustAdd) addToken(resource remoteResource) error {
+	if c.flagName == "" {
+		return fmt.Errorf(i18n.G("A client name must be provided with --name to generate a token"))
+	}
+
+	if c.flagType != api.CertificateTypeClient {
+		return fmt.Errorf(i18n.G("Tokens can only be generated for client certificates"))
+	}
+
+	cert := api.CertificatesPost{}
+	cert.Name = c.flagName
+	cert.Type = c.flagType
+	cert.Token = true
+	cert.Restricted = c.flagRestricted
+	if c.flagProjects != "" {
+		cert.Projects = strings.Split(c.flagProjects, ",")
+	}
+
+	op, err := resource.server.CreateCertificateToken(cert)
+	if err != nil {
+		return err
+	}
+
+	opAPI := op.Get()
+	token, err := certificateAddTokenOperationToAPI(&opAPI)
+	if err != nil {
+		return errors.Wrapf(err, "Failed converting token operation to certificate add token")
+	}
+
+	if !c.global.flagQuiet {
+		fmt.Printf(i18n.G("Client %s certificate add token:")+"\n", c.flagName)
+	}
+
+	fmt.Println(token.String())
+
+	return nil
+}
+
+func certificateAddTokenOperationToAPI(op *api.Operation) (*api.CertificateAddToken, error) {
+	clientName, ok := op.Metadata["clientName"].(string)
+	if !ok {
+		return nil, fmt.Errorf("Operation clientName is type %T not string", op.Metadata["clientName"])
+	}
+
+	secret, ok := op.Metadata["secret"].(string)
+	if !ok {
+		return nil, fmt.Errorf("Operation secret is type %T not string", op.Metadata["secret"])
+	}
+
+	fingerprint, ok := op.Metadata["fingerprint"].(string)
+	if !ok {
+		return nil, fmt.Errorf("Operation fingerprint is type %T not string", op.Metadata["fingerprint"])
+	}
+
+	addresses, ok := op.Metadata["addresses"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("Operation addresses is type %T not []interface{}", op.Metadata["addresses"])
+	}
+
+	token := api.CertificateAddToken{
+		ClientName:  clientName,
+		Secret:      secret,
+		Fingerprint: fingerprint,
+		Addresses:   make([]string, 0, len(addresses)),
+	}
+
+	for i, address := range addresses {
+		addressString, ok := address.(string)
+		if !ok {
+			return nil, fmt.Errorf("Operation address index %d is type %T not string", i, address)
+		}
+
+		token.Addresses = append(token.Addresses, addressString)
+	}
+
+	return &token, nil
+}
+
 // Edit
 type cmdConfigTrustEdit struct {
 	global      *cmdGlobal
@@ -264,7 +365,7 @@ func (c *cmdConfigTrustList) Command() *cobra.Command {
 	cmd.Short = i18n.G("List trusted clients")
 	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
 		`List trusted clients`))
-	cmd.Flags().StringVarP(&c.flagFormat, "format", "f", "table", i18n.G("Format (csv|json|table|yaml)")+"``")
+	cmd.Flags().StringVarP(&c.flagFormat, "format", "f", "table", i18n.G("Format (csv|json|table|yaml|compact)")+"``")
 
 	cmd.RunE = c.Run
 
@@ -379,6 +480,8 @@ type cmdConfigTrustShow struct {
 	global      *cmdGlobal
 	config      *cmdConfig
 	configTrust *cmdConfigTrust
+
+	flagFormat string
 }
 
 func (c *cmdConfigTrustShow) Command() *cobra.Command {
@@ -388,6 +491,8 @@ func (c *cmdConfigTrustShow) Command() *cobra.Command {
 	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
 		`Show trust configurations`))
 
+	cmd.Flags().StringVarP(&c.flagFormat, "format", "f", "yaml", i18n.G("Format (json|yaml)")+"``")
+
 	cmd.RunE = c.Run
 
 	return cmd
@@ -419,12 +524,10 @@ func (c *cmdConfigTrustShow) Run(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	data, err := yaml.Marshal(&cert)
+	err = utils.RenderObject(c.flagFormat, &cert)
 	if err != nil {
 		return err
 	}
 
-	fmt.Printf("%s", data)
-
 	return nil
 }
@@ -2,7 +2,9 @@ package main
 
 import (
 	"fmt"
+	"sort"
 	"strings"
+	"sync"
 
 	"github.com/spf13/cobra"
 
@@ -25,11 +27,14 @@ type cmdCopy struct {
 	flagEphemeral     bool
 	flagInstanceOnly  bool
 	flagMode          string
+	flagPoolMap       []string
 	flagStateless     bool
 	flagStorage       string
 	flagTarget        string
 	flagTargetProject string
 	flagRefresh       bool
+	flagVolumes       bool
+	flagParallel      int
 }
 
 func (c *cmdCopy) Command() *cobra.Command {
@@ -41,6 +46,13 @@ func (c *cmdCopy) Command() *cobra.Command {
 		`Copy instances within or in between LXD servers`))
 
 	cmd.RunE = c.Run
+	cmd.ValidArgsFunction = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return c.global.cmpInstances(toComplete)
+		}
+
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
 	cmd.Flags().StringArrayVarP(&c.flagConfig, "config", "c", nil, i18n.G("Config key/value to apply to the new instance")+"``")
 	cmd.Flags().StringArrayVarP(&c.flagDevice, "device", "d", nil, i18n.G("New key/value to apply to a specific device")+"``")
 	cmd.Flags().StringArrayVarP(&c.flagProfile, "profile", "p", nil, i18n.G("Profile to apply to the new instance")+"``")
@@ -49,10 +61,13 @@ func (c *cmdCopy) Command() *cobra.Command {
 	cmd.Flags().BoolVar(&c.flagInstanceOnly, "instance-only", false, i18n.G("Copy the instance without its snapshots"))
 	cmd.Flags().BoolVar(&c.flagStateless, "stateless", false, i18n.G("Copy a stateful instance stateless"))
 	cmd.Flags().StringVarP(&c.flagStorage, "storage", "s", "", i18n.G("Storage pool name")+"``")
+	cmd.Flags().StringArrayVar(&c.flagPoolMap, "pool-map", nil, i18n.G("Map a source storage pool to a target storage pool (SOURCE=TARGET)")+"``")
 	cmd.Flags().StringVar(&c.flagTarget, "target", "", i18n.G("Cluster member name")+"``")
 	cmd.Flags().StringVar(&c.flagTargetProject, "target-project", "", i18n.G("Copy to a project different from the source")+"``")
 	cmd.Flags().BoolVar(&c.flagNoProfiles, "no-profiles", false, i18n.G("Create the instance with no profiles applied"))
 	cmd.Flags().BoolVar(&c.flagRefresh, "refresh", false, i18n.G("Perform an incremental copy"))
+	cmd.Flags().BoolVar(&c.flagVolumes, "volumes", false, i18n.G("Also copy the custom storage volumes attached to the instance"))
+	cmd.Flags().IntVar(&c.flagParallel, "parallel", 4, i18n.G("Number of storage volumes to transfer at the same time")+"``")
 
 	return cmd
 }
@@ -147,6 +162,22 @@ func (c *cmdCopy) copyInstance(conf *config.Config, sourceResource string, destR
 		deviceMap[deviceFields[0]][keyFields[0]] = keyFields[1]
 	}
 
+	// Parse the storage pool mapping
+	poolMap := map[string]string{}
+	for _, entry := range c.flagPoolMap {
+		fields := strings.SplitN(entry, "=", 2)
+		if len(fields) != 2 || fields[0] == "" || fields[1] == "" {
+			return fmt.Errorf(i18n.G("Bad syntax, expecting <source pool>=<target pool>: %s"), entry)
+		}
+
+		poolMap[fields[0]] = fields[1]
+	}
+
+	// Attached volumes would clash with the source ones when copying within the same project.
+	if c.flagVolumes && sourceRemote == destRemote && c.flagTargetProject == "" {
+		return fmt.Errorf(i18n.G("--volumes can't be used when copying within the same project"))
+	}
+
 	var op lxd.RemoteOperation
 	var writable api.InstancePut
 	var start bool
@@ -156,6 +187,10 @@ func (c *cmdCopy) copyInstance(conf *config.Config, sourceResource string, destR
 			return fmt.Errorf(i18n.G("--instance-only can't be passed when the source is a snapshot"))
 		}
 
+		if c.flagVolumes {
+			return fmt.Errorf(i18n.G("--volumes can only be used with instances"))
+		}
+
 		// Prepare the instance creation request
 		args := lxd.InstanceSnapshotCopyArgs{
 			Name: destName,
@@ -250,6 +285,7 @@ func (c *cmdCopy) copyInstance(conf *config.Config, sourceResource string, destR
 			InstanceOnly: instanceOnly,
 			Mode:         mode,
 			Refresh:      c.flagRefresh,
+			PoolMap:      poolMap,
 		}
 
 		// Copy of an instance into a new instance
@@ -327,6 +363,19 @@ func (c *cmdCopy) copyInstance(conf *config.Config, sourceResource string, destR
 			dest = dest.UseTarget(c.flagTarget)
 		}
 
+		// Copy the attached volumes first so the instance's disk devices are valid on the target.
+		if c.flagVolumes {
+			volSource := source
+			if source.IsClustered() && entry.Location != "" {
+				volSource = source.UseTarget(entry.Location)
+			}
+
+			err = c.copyVolumes(volSource, dest, instanceCustomVolumes(entry.ExpandedDevices), poolMap, mode, instanceOnly)
+			if err != nil {
+				return err
+			}
+		}
+
 		op, err = dest.CopyInstance(source, *entry, &args)
 		if err != nil {
 			return err
@@ -433,6 +482,128 @@ func (c *cmdCopy) copyInstance(conf *config.Config, sourceResource string, destR
 	return nil
 }
 
+// instanceVolume is a custom storage volume attached to an instance.
+type instanceVolume struct {
+	pool string
+	name string
+}
+
+// instanceCustomVolumes returns the custom storage volumes attached to an instance through its disk devices.
+func instanceCustomVolumes(devices map[string]map[string]string) []instanceVolume {
+	volumes := []instanceVolume{}
+	for _, device := range devices {
+		if device["type"] != "disk" || device["pool"] == "" || device["source"] == "" || device["path"] == "/" {
+			continue
+		}
+
+		volumes = append(volumes, instanceVolume{pool: device["pool"], name: device["source"]})
+	}
+
+	sort.Slice(volumes, func(i, j int) bool {
+		if volumes[i].pool != volumes[j].pool {
+			return volumes[i].pool < volumes[j].pool
+		}
+
+		return volumes[i].name < volumes[j].name
+	})
+
+	return volumes
+}
+
+// copyVolumes copies custom storage volumes to the destination server, transferring up to --parallel
+// volumes at the same time. Volumes are copied to the pool of the same name unless mapped to another one.
+func (c *cmdCopy) copyVolumes(source lxd.InstanceServer, dest lxd.InstanceServer, volumes []instanceVolume, poolMap map[string]string, mode string, volumeOnly bool) error {
+	if len(volumes) == 0 {
+		return nil
+	}
+
+	if c.flagParallel < 1 {
+		return fmt.Errorf(i18n.G("Invalid --parallel value %d, it must be at least 1"), c.flagParallel)
+	}
+
+	threads := c.flagParallel
+	if len(volumes) < threads {
+		threads = len(volumes)
+	}
+
+	progress := utils.ProgressRenderer{
+		Format: i18n.G("Transferring volumes: %s"),
+		Quiet:  c.global.flagQuiet,
+	}
+
+	done := 0
+	errs := []error{}
+	lock := sync.Mutex{}
+	queue := make(chan instanceVolume, threads)
+	wg := sync.WaitGroup{}
+
+	progress.Update(fmt.Sprintf("0/%d", len(volumes)))
+
+	for i := 0; i < threads; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for volume := range queue {
+				err := c.copyVolume(source, dest, volume, poolMap, mode, volumeOnly)
+
+				lock.Lock()
+				if err != nil {
+					errs = append(errs, fmt.Errorf(i18n.G("Failed copying volume %s/%s: %w"), volume.pool, volume.name, err))
+				} else {
+					done++
+				}
+
+				progress.Update(fmt.Sprintf("%d/%d", done, len(volumes)))
+				lock.Unlock()
+			}
+		}()
+	}
+
+	for _, volume := range volumes {
+		queue <- volume
+	}
+
+	close(queue)
+	wg.Wait()
+
+	if len(errs) > 0 {
+		progress.Done("")
+		return errs[0]
+	}
+
+	progress.Done("")
+
+	return nil
+}
+
+// copyVolume copies a single custom storage volume to the destination server.
+func (c *cmdCopy) copyVolume(source lxd.InstanceServer, dest lxd.InstanceServer, volume instanceVolume, poolMap map[string]string, mode string, volumeOnly bool) error {
+	srcVol, _, err := source.GetStoragePoolVolume(volume.pool, "custom", volume.name)
+	if err != nil {
+		return err
+	}
+
+	destPool := volume.pool
+	if poolMap[volume.pool] != "" {
+		destPool = poolMap[volume.pool]
+	}
+
+	args := &lxd.StoragePoolVolumeCopyArgs{
+		Name:       volume.name,
+		Mode:       mode,
+		VolumeOnly: volumeOnly,
+		Refresh:    c.flagRefresh,
+	}
+
+	op, err := dest.CopyStoragePoolVolume(destPool, source, volume.pool, *srcVol, args)
+	if err != nil {
+		return err
+	}
+
+	return op.Wait()
+}
+
 func (c *cmdCopy) Run(cmd *cobra.Command, args []string) error {
 	conf := c.global.conf
 
@@ -13,6 +13,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/lxc/lxd/client"
+	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/api"
 	cli "github.com/lxc/lxd/shared/cmd"
 	"github.com/lxc/lxd/shared/i18n"
@@ -28,9 +29,11 @@ type cmdExec struct {
 	flagForceInteractive    bool
 	flagForceNonInteractive bool
 	flagDisableStdin        bool
-	flagUser                uint32
-	flagGroup               uint32
+	flagEnvironmentFile     string
+	flagUser                string
+	flagGroup               string
 	flagCwd                 string
+	flagTimeout             int
 }
 
 func (c *cmdExec) Command() *cobra.Command {
@@ -50,14 +53,23 @@ executable, passing the shell commands as arguments, for example:
 Mode defaults to non-interactive, interactive mode is selected if both stdin AND stdout are terminals (stderr is ignored).`))
 
 	cmd.RunE = c.Run
+	cmd.ValidArgsFunction = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return c.global.cmpInstances(toComplete)
+		}
+
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
 	cmd.Flags().StringArrayVar(&c.flagEnvironment, "env", nil, i18n.G("Environment variable to set (e.g. HOME=/home/foo)")+"``")
 	cmd.Flags().StringVar(&c.flagMode, "mode", "auto", i18n.G("Override the terminal mode (auto, interactive or non-interactive)")+"``")
 	cmd.Flags().BoolVarP(&c.flagForceInteractive, "force-interactive", "t", false, i18n.G("Force pseudo-terminal allocation"))
 	cmd.Flags().BoolVarP(&c.flagForceNonInteractive, "force-noninteractive", "T", false, i18n.G("Disable pseudo-terminal allocation"))
 	cmd.Flags().BoolVarP(&c.flagDisableStdin, "disable-stdin", "n", false, i18n.G("Disable stdin (reads from /dev/null)"))
-	cmd.Flags().Uint32Var(&c.flagUser, "user", 0, i18n.G("User ID to run the command as (default 0)")+"``")
-	cmd.Flags().Uint32Var(&c.flagGroup, "group", 0, i18n.G("Group ID to run the command as (default 0)")+"``")
+	cmd.Flags().StringVar(&c.flagEnvironmentFile, "env-file", "", i18n.G("File of environment variables to set (one KEY=VALUE per line)")+"``")
+	cmd.Flags().StringVar(&c.flagUser, "user", "", i18n.G("User ID or name to run the command as (default 0)")+"``")
+	cmd.Flags().StringVar(&c.flagGroup, "group", "", i18n.G("Group ID or name to run the command as (default 0)")+"``")
 	cmd.Flags().StringVar(&c.flagCwd, "cwd", "", i18n.G("Directory to run the command in (default /root)")+"``")
+	cmd.Flags().IntVar(&c.flagTimeout, "timeout", 0, i18n.G("Kill the command after this many seconds")+"``")
 
 	return cmd
 }
@@ -114,7 +126,27 @@ func (c *cmdExec) Run(cmd *cobra.Command, args []string) error {
 		env["TERM"] = myTerm
 	}
 
-	for _, arg := range c.flagEnvironment {
+	// Variables passed with --env take precedence over the ones of --env-file.
+	envArgs := []string{}
+	if c.flagEnvironmentFile != "" {
+		content, err := ioutil.ReadFile(shared.HostPathFollow(c.flagEnvironmentFile))
+		if err != nil {
+			return fmt.Errorf(i18n.G("Failed to read the environment file: %w"), err)
+		}
+
+		for _, line := range strings.Split(string(content), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+
+			envArgs = append(envArgs, line)
+		}
+	}
+
+	envArgs = append(envArgs, c.flagEnvironment...)
+
+	for _, arg := range envArgs {
 		pieces := strings.SplitN(arg, "=", 2)
 		value := ""
 		if len(pieces) > 1 {
@@ -123,6 +155,31 @@ func (c *cmdExec) Run(cmd *cobra.Command, args []string) error {
 		env[pieces[0]] = value
 	}
 
+	if c.flagTimeout < 0 {
+		return fmt.Errorf(i18n.G("The timeout can't be negative"))
+	}
+
+	// Numeric ids are used as is, anything else is resolved inside the instance.
+	var uid, gid uint32
+	var userName, groupName string
+	if c.flagUser != "" {
+		id, err := strconv.ParseUint(c.flagUser, 10, 32)
+		if err != nil {
+			userName = c.flagUser
+		}
+
+		uid = uint32(id)
+	}
+
+	if c.flagGroup != "" {
+		id, err := strconv.ParseUint(c.flagGroup, 10, 32)
+		if err != nil {
+			groupName = c.flagGroup
+		}
+
+		gid = uint32(id)
+	}
+
 	// Configure the terminal
 	stdinFd := getStdinFd()
 	stdoutFd := getStdoutFd()
@@ -184,9 +241,12 @@ func (c *cmdExec) Run(cmd *cobra.Command, args []string) error {
 		Environment: env,
 		Width:       width,
 		Height:      height,
-		User:        c.flagUser,
-		Group:       c.flagGroup,
+		User:        uid,
+		Group:       gid,
 		Cwd:         c.flagCwd,
+		UserName:    userName,
+		GroupName:   groupName,
+		Timeout:     c.flagTimeout,
 	}
 
 	execArgs := lxd.InstanceExecArgs{
@@ -84,6 +84,10 @@ func (c *cmdFile) Command() *cobra.Command {
 	filePushCmd := cmdFilePush{global: c.global, file: c}
 	cmd.AddCommand(filePushCmd.Command())
 
+	// Sync
+	fileSyncCmd := cmdFileSync{global: c.global, file: c}
+	cmd.AddCommand(fileSyncCmd.Command())
+
 	// Edit
 	fileEditCmd := cmdFileEdit{global: c.global, file: c, filePull: &filePullCmd, filePush: &filePushCmd}
 	cmd.AddCommand(fileEditCmd.Command())
@@ -376,10 +380,9 @@ func (c *cmdFilePull) Run(cmd *cobra.Command, args []string) error {
 						return
 					}
 
-					progress.UpdateProgress(ioprogress.ProgressData{
-						Text: fmt.Sprintf("%s (%s/s)",
-							units.GetByteSizeString(bytesReceived, 2),
-							units.GetByteSizeString(speed, 2))})
+					progress.UpdateProgress(ioprogress.NewProgressData(fmt.Sprintf("%s (%s/s)",
+						units.GetByteSizeString(bytesReceived, 2),
+						units.GetByteSizeString(speed, 2)), bytesReceived, 0, speed))
 				},
 			},
 		}
@@ -632,9 +635,7 @@ func (c *cmdFilePush) Run(cmd *cobra.Command, args []string) error {
 			Tracker: &ioprogress.ProgressTracker{
 				Length: fstat.Size(),
 				Handler: func(percent int64, speed int64) {
-					progress.UpdateProgress(ioprogress.ProgressData{
-						Text: fmt.Sprintf("%d%% (%s/s)", percent, units.GetByteSizeString(speed, 2)),
-					})
+					progress.UpdateProgress(ioprogress.NewProgressData(fmt.Sprintf("%d%% (%s/s)", percent, units.GetByteSizeString(speed, 2)), percent, fstat.Size(), speed))
 				},
 			},
 		}, f)
@@ -695,10 +696,9 @@ func (c *cmdFile) recursivePullFile(d lxd.InstanceServer, inst string, p string,
 			WriteCloser: f,
 			Tracker: &ioprogress.ProgressTracker{
 				Handler: func(bytesReceived int64, speed int64) {
-					progress.UpdateProgress(ioprogress.ProgressData{
-						Text: fmt.Sprintf("%s (%s/s)",
-							units.GetByteSizeString(bytesReceived, 2),
-							units.GetByteSizeString(speed, 2))})
+					progress.UpdateProgress(ioprogress.NewProgressData(fmt.Sprintf("%s (%s/s)",
+						units.GetByteSizeString(bytesReceived, 2),
+						units.GetByteSizeString(speed, 2)), bytesReceived, 0, speed))
 				},
 			},
 		}
@@ -799,9 +799,8 @@ func (c *cmdFile) recursivePushFile(d lxd.InstanceServer, inst string, source st
 				Tracker: &ioprogress.ProgressTracker{
 					Length: contentLength,
 					Handler: func(percent int64, speed int64) {
-						progress.UpdateProgress(ioprogress.ProgressData{
-							Text: fmt.Sprintf("%d%% (%s/s)", percent,
-								units.GetByteSizeString(speed, 2))})
+						progress.UpdateProgress(ioprogress.NewProgressData(fmt.Sprintf("%d%% (%s/s)", percent,
+							units.GetByteSizeString(speed, 2)), percent, contentLength, speed))
 					},
 				},
 			}, args.Content)
@@ -0,0 +1,577 @@
+package main
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/cobra"
+
+	lxd "github.com/lxc/lxd/client"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	cli "github.com/lxc/lxd/shared/cmd"
+	"github.com/lxc/lxd/shared/i18n"
+)
+
+// Sync
+type cmdFileSync struct {
+	global *cmdGlobal
+	file   *cmdFile
+
+	flagDelete   bool
+	flagWatch    bool
+	flagInterval int
+
+	checksums map[string]fileSyncChecksum
+}
+
+// fileSyncChecksum caches the checksum of a local file for as long as its size and modification time don't change.
+type fileSyncChecksum struct {
+	size     int64
+	modTime  time.Time
+	checksum string
+}
+
+func (c *cmdFileSync) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("sync", i18n.G("<source path> <target path>"))
+	cmd.Short = i18n.G("Synchronize a directory with an instance")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Synchronize a directory with an instance
+
+If the source is an existing local directory, its content is pushed to the
+[<remote>:]<instance>/<path> target. Otherwise the content of the
+[<remote>:]<instance>/<path> source is pulled into the local target directory.
+
+Only the new and modified files are transferred.`))
+	cmd.Example = cli.FormatSection("", i18n.G(
+		`lxc file sync ./src foo/srv/app --watch
+    Push the content of ./src to /srv/app in the instance and keep pushing changes.
+
+lxc file sync foo/var/log ./logs --delete
+    Pull the content of /var/log from the instance, removing local files which don't exist there.`))
+
+	cmd.Flags().BoolVar(&c.flagDelete, "delete", false, i18n.G("Delete the files which don't exist in the source"))
+	cmd.Flags().BoolVarP(&c.flagWatch, "watch", "w", false, i18n.G("Keep synchronizing as the source changes"))
+	cmd.Flags().IntVar(&c.flagInterval, "interval", 2, i18n.G("Polling interval in seconds when watching an instance")+"``")
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdFileSync) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.CheckArgs(cmd, args, 2, 2)
+	if exit {
+		return err
+	}
+
+	if c.flagInterval < 1 {
+		return fmt.Errorf(i18n.G("The polling interval must be at least one second"))
+	}
+
+	c.checksums = map[string]fileSyncChecksum{}
+
+	// Figure out the direction.
+	push := shared.PathExists(args[0])
+	localPath := args[1]
+	instancePath := args[0]
+	if push {
+		localPath = args[0]
+		instancePath = args[1]
+
+		if !shared.IsDir(localPath) {
+			return fmt.Errorf(i18n.G("Only directories can be synchronized"))
+		}
+	}
+
+	// Parse remote
+	resources, err := c.global.ParseServers(instancePath)
+	if err != nil {
+		return err
+	}
+
+	resource := resources[0]
+	pathSpec := strings.SplitN(resource.name, "/", 2)
+	if len(pathSpec) != 2 {
+		return fmt.Errorf(i18n.G("Invalid instance path %s"), instancePath)
+	}
+
+	instName := pathSpec[0]
+	remotePath := path.Clean("/" + pathSpec[1])
+	localPath = filepath.Clean(localPath)
+
+	// Make sure the target exists before listing it.
+	if push {
+		err = c.file.recursiveMkdir(resource.server, instName, remotePath, nil, -1, -1)
+		if err != nil {
+			return err
+		}
+	}
+
+	sync := func() error {
+		if push {
+			return c.push(resource.server, instName, localPath, remotePath)
+		}
+
+		return c.pull(resource.server, instName, remotePath, localPath)
+	}
+
+	err = sync()
+	if err != nil {
+		return err
+	}
+
+	if !c.flagWatch {
+		return nil
+	}
+
+	// Failures while watching are usually transient (files changing during the transfer), keep going.
+	retry := func() {
+		err := sync()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, i18n.G("Failed to synchronize: %v")+"\n", err)
+		}
+	}
+
+	if push {
+		return c.watchLocal(localPath, retry)
+	}
+
+	for {
+		time.Sleep(time.Duration(c.flagInterval) * time.Second)
+		retry()
+	}
+}
+
+// push sends the entries of localRoot which differ from the ones of remoteRoot as a single tar stream.
+func (c *cmdFileSync) push(d lxd.InstanceServer, inst string, localRoot string, remoteRoot string) error {
+	local, err := c.localManifest(localRoot)
+	if err != nil {
+		return err
+	}
+
+	entries, err := d.GetInstanceFileManifest(inst, remoteRoot)
+	if err != nil {
+		return err
+	}
+
+	remote := map[string]api.InstanceFileManifestEntry{}
+	for _, entry := range entries {
+		remote[entry.Path] = entry
+	}
+
+	changed := []string{}
+	for name, entry := range local {
+		// The root directory is created by the extraction itself.
+		if name == "." || entry.Type == "other" {
+			continue
+		}
+
+		remoteEntry, ok := remote[name]
+		if !ok || fileSyncDiffers(entry, remoteEntry) {
+			changed = append(changed, name)
+		}
+	}
+
+	// Parents always sort before their children.
+	sort.Strings(changed)
+
+	if len(changed) > 0 {
+		reader, writer := io.Pipe()
+		go func() {
+			writer.CloseWithError(fileSyncWriteTar(writer, localRoot, changed))
+		}()
+
+		err = d.CreateInstanceFileTree(inst, remoteRoot, reader)
+		reader.Close()
+		if err != nil {
+			return err
+		}
+
+		for _, name := range changed {
+			c.printf(i18n.G("Pushed %s")+"\n", path.Join(remoteRoot, name))
+		}
+	}
+
+	if c.flagDelete {
+		for _, name := range fileSyncExtra(local, remote) {
+			err := d.DeleteInstanceFile(inst, path.Join(remoteRoot, name))
+			if err != nil {
+				return err
+			}
+
+			c.printf(i18n.G("Deleted %s")+"\n", path.Join(remoteRoot, name))
+		}
+	}
+
+	return nil
+}
+
+// pull fetches the entries of remoteRoot which differ from the ones of localRoot one file at a time.
+func (c *cmdFileSync) pull(d lxd.InstanceServer, inst string, remoteRoot string, localRoot string) error {
+	entries, err := d.GetInstanceFileManifest(inst, remoteRoot)
+	if err != nil {
+		return err
+	}
+
+	remote := map[string]api.InstanceFileManifestEntry{}
+	for _, entry := range entries {
+		if entry.Path == "." && entry.Type != "directory" {
+			return fmt.Errorf(i18n.G("Only directories can be synchronized"))
+		}
+
+		remote[entry.Path] = entry
+	}
+
+	err = os.MkdirAll(localRoot, 0755)
+	if err != nil {
+		return err
+	}
+
+	local, err := c.localManifest(localRoot)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(remote))
+	for name := range remote {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		entry := remote[name]
+		if name == "." || entry.Type == "other" {
+			continue
+		}
+
+		localEntry, ok := local[name]
+		if ok && !fileSyncDiffers(entry, localEntry) {
+			continue
+		}
+
+		target := filepath.Join(localRoot, filepath.FromSlash(path.Clean("/"+name)))
+
+		// Replace entries of a different type.
+		if ok && localEntry.Type != entry.Type {
+			err := os.RemoveAll(target)
+			if err != nil {
+				return err
+			}
+		}
+
+		switch entry.Type {
+		case "directory":
+			err = os.MkdirAll(target, os.FileMode(entry.Mode))
+			if err == nil {
+				err = os.Chmod(target, os.FileMode(entry.Mode))
+			}
+		case "symlink":
+			err = os.Remove(target)
+			if err == nil || os.IsNotExist(err) {
+				err = os.Symlink(entry.Target, target)
+			}
+		case "file":
+			err = c.pullFile(d, inst, path.Join(remoteRoot, name), target, entry)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		c.printf(i18n.G("Pulled %s")+"\n", target)
+	}
+
+	if c.flagDelete {
+		for _, name := range fileSyncExtra(local, remote) {
+			target := filepath.Join(localRoot, filepath.FromSlash(name))
+			err := os.RemoveAll(target)
+			if err != nil {
+				return err
+			}
+
+			c.printf(i18n.G("Deleted %s")+"\n", target)
+		}
+	}
+
+	return nil
+}
+
+// pullFile atomically replaces target with the content of the file at p in the instance.
+func (c *cmdFileSync) pullFile(d lxd.InstanceServer, inst string, p string, target string, entry api.InstanceFileManifestEntry) error {
+	buf, _, err := d.GetInstanceFile(inst, p)
+	if err != nil {
+		return err
+	}
+	defer buf.Close()
+
+	f, err := ioutil.TempFile(filepath.Dir(target), ".lxc-sync-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	_, err = io.Copy(f, buf)
+	f.Close()
+	if err != nil {
+		return err
+	}
+
+	err = os.Chmod(f.Name(), os.FileMode(entry.Mode))
+	if err != nil {
+		return err
+	}
+
+	err = os.Chtimes(f.Name(), entry.ModifiedAt, entry.ModifiedAt)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), target)
+}
+
+// watchLocal calls sync whenever something changes below root, waiting for the changes to settle.
+func (c *cmdFileSync) watchLocal(root string, sync func()) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf(i18n.G("Failed to setup the file watcher: %w"), err)
+	}
+	defer watcher.Close()
+
+	watch := func(dir string) error {
+		return filepath.Walk(dir, func(p string, fi os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if !fi.IsDir() {
+				return nil
+			}
+
+			return watcher.Add(p)
+		})
+	}
+
+	err = watch(root)
+	if err != nil {
+		return err
+	}
+
+	var trigger <-chan time.Time
+	for {
+		select {
+		case ev := <-watcher.Events:
+			// New directories need to be watched too.
+			if ev.Op&fsnotify.Create != 0 && shared.IsDir(ev.Name) {
+				err := watch(ev.Name)
+				if err != nil && !os.IsNotExist(err) {
+					return err
+				}
+			}
+
+			trigger = time.After(500 * time.Millisecond)
+		case <-trigger:
+			trigger = nil
+			sync()
+		case err := <-watcher.Errors:
+			return err
+		}
+	}
+}
+
+// localManifest describes the tree at root the same way the server does for instances.
+func (c *cmdFileSync) localManifest(root string) (map[string]api.InstanceFileManifestEntry, error) {
+	entries := map[string]api.InstanceFileManifestEntry{}
+
+	err := filepath.Walk(root, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		name, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+
+		mode, uid, gid := shared.GetOwnerMode(fi)
+		entry := api.InstanceFileManifestEntry{
+			Path:       filepath.ToSlash(name),
+			Mode:       int64(mode.Perm()),
+			UID:        int64(uid),
+			GID:        int64(gid),
+			ModifiedAt: fi.ModTime(),
+		}
+
+		if fi.IsDir() {
+			entry.Type = "directory"
+		} else if fi.Mode()&os.ModeSymlink != 0 {
+			entry.Type = "symlink"
+			entry.Target, err = os.Readlink(p)
+			if err != nil {
+				return err
+			}
+		} else if fi.Mode().IsRegular() {
+			entry.Type = "file"
+			entry.Size = fi.Size()
+			entry.Checksum, err = c.checksum(p, fi)
+			if err != nil {
+				return err
+			}
+		} else {
+			entry.Type = "other"
+		}
+
+		entries[entry.Path] = entry
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// checksum returns the SHA256 checksum of the file at p, only reading it again if it changed.
+func (c *cmdFileSync) checksum(p string, fi os.FileInfo) (string, error) {
+	cached, ok := c.checksums[p]
+	if ok && cached.size == fi.Size() && cached.modTime.Equal(fi.ModTime()) {
+		return cached.checksum, nil
+	}
+
+	f, err := os.Open(p)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	_, err = io.Copy(hash, f)
+	if err != nil {
+		return "", err
+	}
+
+	checksum := fmt.Sprintf("%x", hash.Sum(nil))
+	c.checksums[p] = fileSyncChecksum{size: fi.Size(), modTime: fi.ModTime(), checksum: checksum}
+
+	return checksum, nil
+}
+
+func (c *cmdFileSync) printf(format string, args ...interface{}) {
+	if c.global.flagQuiet {
+		return
+	}
+
+	fmt.Printf(format, args...)
+}
+
+// fileSyncDiffers returns whether the destination entry needs to be replaced by the source one.
+// Ownership isn't compared as it usually can't be reproduced on the local side.
+func fileSyncDiffers(src api.InstanceFileManifestEntry, dst api.InstanceFileManifestEntry) bool {
+	if src.Type != dst.Type {
+		return true
+	}
+
+	switch src.Type {
+	case "file":
+		return src.Checksum != dst.Checksum || src.Mode != dst.Mode
+	case "directory":
+		return src.Mode != dst.Mode
+	case "symlink":
+		return src.Target != dst.Target
+	}
+
+	return false
+}
+
+// fileSyncExtra returns the destination entries missing from the source, children first.
+func fileSyncExtra(src map[string]api.InstanceFileManifestEntry, dst map[string]api.InstanceFileManifestEntry) []string {
+	extra := []string{}
+	for name := range dst {
+		_, ok := src[name]
+		if !ok && name != "." {
+			extra = append(extra, name)
+		}
+	}
+
+	sort.Sort(sort.Reverse(sort.StringSlice(extra)))
+
+	return extra
+}
+
+// fileSyncWriteTar writes the provided entries of root to w as a tar stream, named relative to root.
+func fileSyncWriteTar(w io.Writer, root string, names []string) error {
+	tw := tar.NewWriter(w)
+
+	for _, name := range names {
+		p := filepath.Join(root, filepath.FromSlash(name))
+
+		fi, err := os.Lstat(p)
+		if err != nil {
+			return err
+		}
+
+		link := ""
+		if fi.Mode()&os.ModeSymlink != 0 {
+			link, err = os.Readlink(p)
+			if err != nil {
+				return err
+			}
+		}
+
+		hdr, err := tar.FileInfoHeader(fi, link)
+		if err != nil {
+			return err
+		}
+
+		hdr.Name = name
+		if fi.IsDir() {
+			hdr.Name += "/"
+		}
+
+		// Only keep the numeric ownership, defaulting to root when it's unknown.
+		_, uid, gid := shared.GetOwnerMode(fi)
+		hdr.Uid = 0
+		hdr.Gid = 0
+		if uid >= 0 && gid >= 0 {
+			hdr.Uid = uid
+			hdr.Gid = gid
+		}
+
+		hdr.Uname = ""
+		hdr.Gname = ""
+
+		err = tw.WriteHeader(hdr)
+		if err != nil {
+			return err
+		}
+
+		if hdr.Typeflag == tar.TypeReg {
+			f, err := os.Open(p)
+			if err != nil {
+				return err
+			}
+
+			// Only write the size announced in the header even if the file grew.
+			_, err = io.Copy(tw, io.LimitReader(f, hdr.Size))
+			f.Close()
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return tw.Close()
+}
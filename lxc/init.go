@@ -32,6 +32,7 @@ type cmdInit struct {
 	flagNoProfiles bool
 	flagEmpty      bool
 	flagVM         bool
+	flagVar        []string
 }
 
 func (c *cmdInit) Command() *cobra.Command {
@@ -42,10 +43,20 @@ func (c *cmdInit) Command() *cobra.Command {
 	cmd.Example = cli.FormatSection("", i18n.G(`lxc init ubuntu:18.04 u1
 
 lxc init ubuntu:18.04 u1 < config.yaml
-    Create the instance with configuration from config.yaml`))
+    Create the instance with configuration from config.yaml
+
+lxc init template:webserver web01 --var env=prod
+    Create the instance from the "webserver" instance template`))
 	cmd.Hidden = true
 
 	cmd.RunE = c.Run
+	cmd.ValidArgsFunction = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return c.global.cmpImages(toComplete)
+		}
+
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
 	cmd.Flags().StringArrayVarP(&c.flagConfig, "config", "c", nil, i18n.G("Config key/value to apply to the new instance")+"``")
 	cmd.Flags().StringArrayVarP(&c.flagProfile, "profile", "p", nil, i18n.G("Profile to apply to the new instance")+"``")
 	cmd.Flags().BoolVarP(&c.flagEphemeral, "ephemeral", "e", false, i18n.G("Ephemeral instance"))
@@ -56,6 +67,7 @@ lxc init ubuntu:18.04 u1 < config.yaml
 	cmd.Flags().BoolVar(&c.flagNoProfiles, "no-profiles", false, i18n.G("Create the instance with no profiles applied"))
 	cmd.Flags().BoolVar(&c.flagEmpty, "empty", false, i18n.G("Create an empty instance"))
 	cmd.Flags().BoolVar(&c.flagVM, "vm", false, i18n.G("Create a virtual machine"))
+	cmd.Flags().StringArrayVar(&c.flagVar, "var", nil, i18n.G("Value of a variable of the instance template (key=value)")+"``")
 
 	return cmd
 }
@@ -100,6 +112,16 @@ func (c *cmdInit) create(conf *config.Config, args []string) (lxd.InstanceServer
 		}
 	}
 
+	// Instance templates are referenced as template:<name>, unless a remote is called "template".
+	_, isRemote := conf.Remotes["template"]
+	if len(args) > 0 && strings.HasPrefix(args[0], "template:") && !isRemote {
+		return c.createFromTemplate(conf, strings.TrimPrefix(args[0], "template:"), args[1:])
+	}
+
+	if len(c.flagVar) > 0 {
+		return nil, "", fmt.Errorf(i18n.G("--var can only be used when creating instances from templates"))
+	}
+
 	if len(args) > 0 {
 		iremote, image, err = conf.ParseRemote(args[0])
 		if err != nil {
@@ -370,6 +392,99 @@ func (c *cmdInit) create(conf *config.Config, args []string) (lxd.InstanceServer
 	return d, name, nil
 }
 
+func (c *cmdInit) createFromTemplate(conf *config.Config, template string, args []string) (lxd.InstanceServer, string, error) {
+	var remote string
+	var name string
+	var err error
+
+	if len(c.flagConfig) > 0 || len(c.flagProfile) > 0 || c.flagNetwork != "" || c.flagStorage != "" || c.flagType != "" || c.flagNoProfiles || c.flagEmpty || c.flagVM {
+		return nil, "", fmt.Errorf(i18n.G("Only --var, --ephemeral and --target can be used when creating instances from templates"))
+	}
+
+	if len(args) > 0 {
+		remote, name, err = conf.ParseRemote(args[0])
+	} else {
+		remote, name, err = conf.ParseRemote("")
+	}
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	d, err := conf.GetInstanceServer(remote)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if c.flagTarget != "" {
+		d = d.UseTarget(c.flagTarget)
+	}
+
+	req := api.InstanceTemplateInstancesPost{
+		Name:      name,
+		Variables: map[string]string{},
+		Ephemeral: c.flagEphemeral,
+	}
+
+	for _, entry := range c.flagVar {
+		if !strings.Contains(entry, "=") {
+			return nil, "", fmt.Errorf(i18n.G("Bad key=value pair: %s"), entry)
+		}
+
+		fields := strings.SplitN(entry, "=", 2)
+		req.Variables[fields[0]] = fields[1]
+	}
+
+	if !c.global.flagQuiet {
+		if name == "" {
+			fmt.Printf(i18n.G("Creating the instance") + "\n")
+		} else {
+			fmt.Printf(i18n.G("Creating %s")+"\n", name)
+		}
+	}
+
+	op, err := d.CreateInstanceFromTemplate(template, req)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Watch the background operation
+	progress := utils.ProgressRenderer{
+		Format: i18n.G("Retrieving image: %s"),
+		Quiet:  c.global.flagQuiet,
+	}
+
+	_, err = op.AddHandler(progress.UpdateOp)
+	if err != nil {
+		progress.Done("")
+		return nil, "", err
+	}
+
+	err = utils.CancelableWait(op, &progress)
+	if err != nil {
+		progress.Done("")
+		return nil, "", err
+	}
+	progress.Done("")
+
+	opInfo := op.Get()
+	instances := opInfo.Resources["instances"]
+	if len(instances) == 0 {
+		return nil, "", fmt.Errorf(i18n.G("Didn't get any affected image, instance or snapshot from server"))
+	}
+
+	if name == "" {
+		fields := strings.Split(instances[0], "/")
+		name = fields[len(fields)-1]
+		fmt.Printf(i18n.G("Instance name is: %s")+"\n", name)
+	}
+
+	// Validate the network setup
+	c.checkNetwork(d, name)
+
+	return d, name, nil
+}
+
 func (c *cmdInit) guessImage(conf *config.Config, d lxd.InstanceServer, remote string, iremote string, image string) (string, string) {
 	if remote != iremote {
 		return iremote, image
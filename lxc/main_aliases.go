@@ -7,6 +7,8 @@ import (
 	"os/user"
 	"path"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/lxc/lxd/lxc/config"
@@ -16,9 +18,11 @@ import (
 
 // defaultAliases contains LXC's built-in command line aliases.  The built-in
 // aliases are checked only if no user-defined alias was found.
-var defaultAliases = map[string]string{
-	"shell": "exec @ARGS@ -- su -l",
-}
+var defaultAliases = map[string]string{}
+
+// aliasArgPattern matches the positional placeholders of aliases: @ARG1@, @ARG2@, ... optionally with a
+// default value used when the argument is missing (@ARG2:default@).
+var aliasArgPattern = regexp.MustCompile(`@ARG([0-9]+)(?::([^@]*))?@`)
 
 func findAlias(aliases map[string]string, origArgs []string) ([]string, []string, bool) {
 	foundAlias := false
@@ -44,7 +48,7 @@ func findAlias(aliases map[string]string, origArgs []string) ([]string, []string
 	return aliasKey, aliasValue, foundAlias
 }
 
-func expandAlias(conf *config.Config, args []string) ([]string, bool) {
+func expandAlias(conf *config.Config, args []string) ([]string, bool, error) {
 	var newArgs []string
 	var origArgs []string
 
@@ -62,30 +66,61 @@ func expandAlias(conf *config.Config, args []string) ([]string, bool) {
 	if !foundAlias {
 		aliasKey, aliasValue, foundAlias = findAlias(defaultAliases, origArgs)
 		if !foundAlias {
-			return []string{}, false
+			return []string{}, false, nil
 		}
 	}
 
 	if !strings.HasPrefix(aliasValue[0], "/") {
 		newArgs = append([]string{origArgs[0]}, newArgs...)
 	}
+
+	// Arguments passed after the alias itself.
+	atArgs := origArgs[len(aliasKey)+1:]
+	usedArgs := map[int]bool{}
 	hasReplacedArgsVar := false
 
 	for _, aliasArg := range aliasValue {
-		if aliasArg == "@ARGS@" && len(origArgs) > 2 {
-			newArgs = append(newArgs, origArgs[2:]...)
+		if aliasArg == "@ARGS@" {
+			newArgs = append(newArgs, atArgs...)
 			hasReplacedArgsVar = true
-		} else {
-			newArgs = append(newArgs, aliasArg)
+			continue
 		}
+
+		var err error
+		aliasArg = aliasArgPattern.ReplaceAllStringFunc(aliasArg, func(match string) string {
+			fields := aliasArgPattern.FindStringSubmatch(match)
+			index, _ := strconv.Atoi(fields[1])
+
+			if index >= 1 && index <= len(atArgs) {
+				usedArgs[index] = true
+				return atArgs[index-1]
+			}
+
+			// Fall back to the default value if there's one.
+			if strings.Contains(match, ":") {
+				return fields[2]
+			}
+
+			err = fmt.Errorf(i18n.G("Missing argument %d for alias %q"), index, strings.Join(aliasKey, " "))
+			return match
+		})
+		if err != nil {
+			return nil, false, err
+		}
+
+		newArgs = append(newArgs, aliasArg)
 	}
 
 	if !hasReplacedArgsVar {
-		// Add the rest of the arguments
-		newArgs = append(newArgs, origArgs[len(aliasKey)+1:]...)
+		// Add the arguments which weren't consumed by a placeholder.
+		for i, arg := range atArgs {
+			if !usedArgs[i+1] {
+				newArgs = append(newArgs, arg)
+			}
+		}
 	}
 
-	return newArgs, true
+	return newArgs, true, nil
 }
 
 func execIfAliases() error {
@@ -126,7 +161,11 @@ func execIfAliases() error {
 	}
 
 	// Expand the aliases
-	newArgs, expanded := expandAlias(conf, args)
+	newArgs, expanded, err := expandAlias(conf, args)
+	if err != nil {
+		return err
+	}
+
 	if !expanded {
 		return nil
 	}
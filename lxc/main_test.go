@@ -39,6 +39,9 @@ func TestExpandAliases(t *testing.T) {
 		"foo":       "list @ARGS@ -c n",
 		"ssh":       "/usr/bin/ssh @ARGS@",
 		"bar":       "exec c1 -- @ARGS@",
+		"login":     "exec @ARG1@ -- su -l @ARG2:ubuntu@",
+		"cp to":     "file push @ARG2@ @ARG1@/root/",
+		"fwd":       "exec @ARG1@ -- ssh -p @ARG2:22@",
 	}
 
 	testcases := []aliasTestcase{
@@ -62,12 +65,33 @@ func TestExpandAliases(t *testing.T) {
 			input:    []string{"lxc", "bar", "ls", "/"},
 			expected: []string{"lxc", "exec", "c1", "--", "ls", "/"},
 		},
+		{
+			input:    []string{"lxc", "login", "c1"},
+			expected: []string{"lxc", "exec", "c1", "--", "su", "-l", "ubuntu"},
+		},
+		{
+			input:    []string{"lxc", "login", "c1", "root"},
+			expected: []string{"lxc", "exec", "c1", "--", "su", "-l", "root"},
+		},
+		{
+			input:    []string{"lxc", "cp", "to", "c1", "foo", "bar"},
+			expected: []string{"lxc", "file", "push", "foo", "c1/root/", "bar"},
+		},
+		{
+			input:    []string{"lxc", "fwd", "c1", "2222", "-v"},
+			expected: []string{"lxc", "exec", "c1", "--", "ssh", "-p", "2222", "-v"},
+		},
 	}
 
 	conf := &config.Config{Aliases: aliases}
 
 	for _, tc := range testcases {
-		result, expanded := expandAlias(conf, tc.input)
+		result, expanded, err := expandAlias(conf, tc.input)
+		if err != nil {
+			t.Errorf("failed to expand %s: %v", tc.input, err)
+			continue
+		}
+
 		if !expanded {
 			if !slicesEqual(tc.input, tc.expected) {
 				t.Errorf("didn't expand when expected to: %s", tc.input)
@@ -80,3 +104,12 @@ func TestExpandAliases(t *testing.T) {
 		}
 	}
 }
+
+func TestExpandAliasesMissingArgument(t *testing.T) {
+	conf := &config.Config{Aliases: map[string]string{"login": "exec @ARG1@ -- su -l"}}
+
+	_, _, err := expandAlias(conf, []string{"lxc", "login"})
+	if err == nil {
+		t.Errorf("expected an error when a placeholder has no argument")
+	}
+}
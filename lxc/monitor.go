@@ -7,18 +7,21 @@ import (
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v2"
 
+	lxd "github.com/lxc/lxd/client"
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/api"
 	cli "github.com/lxc/lxd/shared/cmd"
 	"github.com/lxc/lxd/shared/i18n"
 	"github.com/lxc/lxd/shared/log15"
 	"github.com/lxc/lxd/shared/logging"
+	"github.com/lxc/lxd/shared/termios"
 )
 
 type cmdMonitor struct {
 	global *cmdGlobal
 
 	flagType        []string
+	flagInstance    []string
 	flagPretty      bool
 	flagLogLevel    string
 	flagAllProjects bool
@@ -41,13 +44,17 @@ lxc monitor --pretty --type=logging --loglevel=info
     Show a pretty log of messages with info level or higher.
 
 lxc monitor --type=lifecycle
-    Only show lifecycle events.`))
+    Only show lifecycle events.
+
+lxc monitor --project=foo --instance=c1 --pretty
+    Show a pretty log of the events related to instance c1 of project foo.`))
 	cmd.Hidden = true
 
 	cmd.RunE = c.Run
 	cmd.Flags().BoolVar(&c.flagPretty, "pretty", false, i18n.G("Pretty rendering (short for --format=pretty)"))
 	cmd.Flags().BoolVar(&c.flagAllProjects, "all-projects", false, i18n.G("Show events from all projects"))
 	cmd.Flags().StringArrayVar(&c.flagType, "type", nil, i18n.G("Event type to listen for")+"``")
+	cmd.Flags().StringArrayVar(&c.flagInstance, "instance", nil, i18n.G("Only show events related to this instance")+"``")
 	cmd.Flags().StringVar(&c.flagLogLevel, "loglevel", "", i18n.G("Minimum level for log messages")+"``")
 	cmd.Flags().StringVarP(&c.flagFormat, "format", "f", "yaml", i18n.G("Format (json|pretty|yaml)")+"``")
 
@@ -97,7 +104,21 @@ func (c *cmdMonitor) Run(cmd *cobra.Command, args []string) error {
 		d = d.UseProject("*")
 	}
 
-	listener, err := d.GetEvents()
+	// Let the server do the filtering when possible.
+	resources := []string{}
+	for _, name := range c.flagInstance {
+		resources = append(resources, fmt.Sprintf("/1.0/instances/%s", name))
+	}
+
+	var listener *lxd.EventListener
+	if d.HasExtension("event_filters") && (len(c.flagType) > 0 || len(resources) > 0) {
+		listener, err = d.GetEventsFiltered(api.EventFilter{Types: c.flagType, Resources: resources})
+	} else if len(resources) > 0 {
+		return fmt.Errorf(i18n.G("The server doesn't support filtering events by instance"))
+	} else {
+		listener, err = d.GetEvents()
+	}
+
 	if err != nil {
 		return err
 	}
@@ -112,9 +133,14 @@ func (c *cmdMonitor) Run(cmd *cobra.Command, args []string) error {
 
 	chError := make(chan error, 1)
 
+	// Only use colors when writing to a terminal.
+	prettyFormat := logging.LogfmtFormat()
+	if termios.IsTerminal(getStdoutFd()) {
+		prettyFormat = logging.TerminalFormat()
+	}
+
 	handler := func(event api.Event) {
 		if c.flagFormat == "pretty" {
-			format := logging.TerminalFormat()
 			record, err := event.ToLogging()
 			if err != nil {
 				chError <- err
@@ -127,6 +153,11 @@ func (c *cmdMonitor) Run(cmd *cobra.Command, args []string) error {
 				return
 			}
 
+			// Tell the cluster members apart.
+			if event.Location != "" {
+				record.Ctx = append(record.Ctx, "location", event.Location)
+			}
+
 			log15Record := log15.Record{
 				Time: record.Time,
 				Lvl:  lvl,
@@ -138,7 +169,7 @@ func (c *cmdMonitor) Run(cmd *cobra.Command, args []string) error {
 			if event.Type == "logging" && (log15Record.Lvl > logLvl) {
 				return
 			}
-			fmt.Printf("%s", format.Format(&log15Record))
+			fmt.Printf("%s", prettyFormat.Format(&log15Record))
 			return
 		}
 
@@ -2,9 +2,11 @@ package main
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/spf13/cobra"
 
+	lxd "github.com/lxc/lxd/client"
 	"github.com/lxc/lxd/lxc/config"
 	"github.com/lxc/lxd/lxc/utils"
 	"github.com/lxc/lxd/shared"
@@ -23,10 +25,13 @@ type cmdMove struct {
 	flagInstanceOnly  bool
 	flagDevice        []string
 	flagMode          string
+	flagPoolMap       []string
 	flagStateless     bool
 	flagStorage       string
 	flagTarget        string
 	flagTargetProject string
+	flagVolumes       bool
+	flagParallel      int
 }
 
 func (c *cmdMove) Command() *cobra.Command {
@@ -44,9 +49,19 @@ lxc move <old name> <new name> [--instance-only]
     Rename a local instance.
 
 lxc move <instance>/<old snapshot name> <instance>/<new snapshot name>
-    Rename a snapshot.`))
+    Rename a snapshot.
+
+lxc move <instance> <instance> --target-project <project>
+    Move a stopped instance to another project of the same server.`))
 
 	cmd.RunE = c.Run
+	cmd.ValidArgsFunction = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return c.global.cmpInstances(toComplete)
+		}
+
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
 	cmd.Flags().StringArrayVarP(&c.flagConfig, "config", "c", nil, i18n.G("Config key/value to apply to the target instance")+"``")
 	cmd.Flags().StringArrayVarP(&c.flagDevice, "device", "d", nil, i18n.G("New key/value to apply to a specific device")+"``")
 	cmd.Flags().StringArrayVarP(&c.flagProfile, "profile", "p", nil, i18n.G("Profile to apply to the target instance")+"``")
@@ -55,8 +70,11 @@ lxc move <instance>/<old snapshot name> <instance>/<new snapshot name>
 	cmd.Flags().StringVar(&c.flagMode, "mode", moveDefaultMode, i18n.G("Transfer mode. One of pull (default), push or relay.")+"``")
 	cmd.Flags().BoolVar(&c.flagStateless, "stateless", false, i18n.G("Copy a stateful instance stateless"))
 	cmd.Flags().StringVarP(&c.flagStorage, "storage", "s", "", i18n.G("Storage pool name")+"``")
+	cmd.Flags().StringArrayVar(&c.flagPoolMap, "pool-map", nil, i18n.G("Map a source storage pool to a target storage pool (SOURCE=TARGET)")+"``")
 	cmd.Flags().StringVar(&c.flagTarget, "target", "", i18n.G("Cluster member name")+"``")
 	cmd.Flags().StringVar(&c.flagTargetProject, "target-project", "", i18n.G("Copy to a project different from the source")+"``")
+	cmd.Flags().BoolVar(&c.flagVolumes, "volumes", false, i18n.G("Also move the custom storage volumes attached to the instance"))
+	cmd.Flags().IntVar(&c.flagParallel, "parallel", 4, i18n.G("Number of storage volumes to transfer at the same time")+"``")
 
 	return cmd
 }
@@ -98,6 +116,10 @@ func (c *cmdMove) Run(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	if c.flagVolumes && sourceRemote == destRemote {
+		return fmt.Errorf(i18n.G("The --volumes flag can only be used when moving between servers"))
+	}
+
 	// As an optimization, if the source an destination are the same, do
 	// this via a simple rename. This only works for instances that aren't
 	// running, instances that are running should be live migrated (of
@@ -183,7 +205,7 @@ func (c *cmdMove) Run(cmd *cobra.Command, args []string) error {
 	}
 
 	// Support for server-side pool move.
-	if c.flagStorage != "" && sourceRemote == destRemote {
+	if c.flagStorage != "" && c.flagTargetProject == "" && sourceRemote == destRemote {
 		source, err := conf.GetInstanceServer(sourceRemote)
 		if err != nil {
 			return err
@@ -198,7 +220,31 @@ func (c *cmdMove) Run(cmd *cobra.Command, args []string) error {
 				return fmt.Errorf(i18n.G("The --mode flag can't be used with --storage"))
 			}
 
-			return moveInstancePool(conf, sourceResource, destResource, c.flagInstanceOnly, c.flagStorage)
+			return moveInstancePool(conf, sourceResource, destResource, c.flagInstanceOnly, c.flagStorage, "")
+		}
+	}
+
+	// Support for server-side project move.
+	if c.flagTargetProject != "" && c.flagTarget == "" && sourceRemote == destRemote {
+		source, err := conf.GetInstanceServer(sourceRemote)
+		if err != nil {
+			return err
+		}
+
+		if source.HasExtension("instance_project_move") {
+			if c.flagStateless {
+				return fmt.Errorf(i18n.G("The --stateless flag can't be used with --target-project"))
+			}
+
+			if c.flagMode != moveDefaultMode {
+				return fmt.Errorf(i18n.G("The --mode flag can't be used with --target-project"))
+			}
+
+			if c.flagConfig != nil || c.flagDevice != nil || c.flagProfile != nil || c.flagNoProfiles {
+				return fmt.Errorf(i18n.G("Can't override configuration or profiles when moving between projects"))
+			}
+
+			return moveInstancePool(conf, sourceResource, destResource, c.flagInstanceOnly, c.flagStorage, c.flagTargetProject)
 		}
 	}
 
@@ -210,10 +256,34 @@ func (c *cmdMove) Run(cmd *cobra.Command, args []string) error {
 	cpy.flagDevice = c.flagDevice
 	cpy.flagProfile = c.flagProfile
 	cpy.flagNoProfiles = c.flagNoProfiles
+	cpy.flagPoolMap = c.flagPoolMap
+	cpy.flagVolumes = c.flagVolumes
+	cpy.flagParallel = c.flagParallel
 
 	stateful := !c.flagStateless
 	instanceOnly := c.flagInstanceOnly
 
+	// Record the attached volumes so they can be removed from the source once moved.
+	var source lxd.InstanceServer
+	var volumes []instanceVolume
+	if c.flagVolumes {
+		source, err = conf.GetInstanceServer(sourceRemote)
+		if err != nil {
+			return err
+		}
+
+		inst, _, err := source.GetInstance(sourceName)
+		if err != nil {
+			return err
+		}
+
+		if source.IsClustered() && inst.Location != "" {
+			source = source.UseTarget(inst.Location)
+		}
+
+		volumes = instanceCustomVolumes(inst.ExpandedDevices)
+	}
+
 	// A move is just a copy followed by a delete; however, we want to
 	// keep the volatile entries around since we are moving the instance.
 	err = cpy.copyInstance(conf, sourceResource, destResource, true, -1, stateful, instanceOnly, mode, c.flagStorage, true)
@@ -229,6 +299,24 @@ func (c *cmdMove) Run(cmd *cobra.Command, args []string) error {
 		return errors.Wrap(err, "Failed to delete original instance after copying it")
 	}
 
+	// Delete the moved volumes which aren't used by anything else on the source.
+	for _, volume := range volumes {
+		vol, _, err := source.GetStoragePoolVolume(volume.pool, "custom", volume.name)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to get original volume %s/%s after copying it", volume.pool, volume.name)
+		}
+
+		if len(vol.UsedBy) > 0 {
+			fmt.Fprintf(os.Stderr, i18n.G("Keeping volume %s/%s on the source as it's still in use")+"\n", volume.pool, volume.name)
+			continue
+		}
+
+		err = source.DeleteStoragePoolVolume(volume.pool, "custom", volume.name)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to delete original volume %s/%s after copying it", volume.pool, volume.name)
+		}
+	}
+
 	return nil
 }
 
@@ -305,8 +393,8 @@ func moveClusterInstance(conf *config.Config, sourceResource string, destResourc
 	return nil
 }
 
-// Move an instance between pools using special POST /instances/<name> API.
-func moveInstancePool(conf *config.Config, sourceResource string, destResource string, instanceOnly bool, storage string) error {
+// Move an instance between pools and/or projects using special POST /instances/<name> API.
+func moveInstancePool(conf *config.Config, sourceResource string, destResource string, instanceOnly bool, storage string, targetProject string) error {
 	// Parse the source.
 	sourceRemote, sourceName, err := conf.ParseRemote(sourceResource)
 	if err != nil {
@@ -335,11 +423,12 @@ func moveInstancePool(conf *config.Config, sourceResource string, destResource s
 		return errors.Wrap(err, i18n.G("Failed to connect to cluster member"))
 	}
 
-	// Pass the new pool to the migration API.
+	// Pass the new pool and project to the migration API.
 	req := api.InstancePost{
 		Name:         destName,
 		Migration:    true,
 		Pool:         storage,
+		Project:      targetProject,
 		InstanceOnly: instanceOnly,
 	}
 
@@ -91,7 +91,7 @@ func (c *cmdNetworkACLList) Command() *cobra.Command {
 	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G("List available network ACL"))
 
 	cmd.RunE = c.Run
-	cmd.Flags().StringVarP(&c.flagFormat, "format", "f", "table", i18n.G("Format (csv|json|table|yaml)")+"``")
+	cmd.Flags().StringVarP(&c.flagFormat, "format", "f", "table", i18n.G("Format (csv|json|table|yaml|compact)")+"``")
 
 	return cmd
 }
@@ -152,6 +152,8 @@ func (c *cmdNetworkACLList) Run(cmd *cobra.Command, args []string) error {
 type cmdNetworkACLShow struct {
 	global     *cmdGlobal
 	networkACL *cmdNetworkACL
+
+	flagFormat string
 }
 
 func (c *cmdNetworkACLShow) Command() *cobra.Command {
@@ -159,6 +161,8 @@ func (c *cmdNetworkACLShow) Command() *cobra.Command {
 	cmd.Use = usage("show", i18n.G("[<remote>:]<ACL>"))
 	cmd.Short = i18n.G("Show network ACL configurations")
 	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G("Show network ACL configurations"))
+	cmd.Flags().StringVarP(&c.flagFormat, "format", "f", "yaml", i18n.G("Format (json|yaml)")+"``")
+
 	cmd.RunE = c.Run
 
 	return cmd
@@ -191,13 +195,11 @@ func (c *cmdNetworkACLShow) Run(cmd *cobra.Command, args []string) error {
 
 	sort.Strings(netACL.UsedBy)
 
-	data, err := yaml.Marshal(&netACL)
+	err = utils.RenderObject(c.flagFormat, &netACL)
 	if err != nil {
 		return err
 	}
 
-	fmt.Printf("%s", data)
-
 	return nil
 }
 
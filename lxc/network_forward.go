@@ -83,7 +83,7 @@ func (c *cmdNetworkForwardList) Command() *cobra.Command {
 	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G("List available network forwards"))
 
 	cmd.RunE = c.Run
-	cmd.Flags().StringVarP(&c.flagFormat, "format", "f", "table", i18n.G("Format (csv|json|table|yaml)")+"``")
+	cmd.Flags().StringVarP(&c.flagFormat, "format", "f", "table", i18n.G("Format (csv|json|table|yaml|compact)")+"``")
 
 	return cmd
 }
@@ -154,6 +154,8 @@ func (c *cmdNetworkForwardList) Run(cmd *cobra.Command, args []string) error {
 type cmdNetworkForwardShow struct {
 	global         *cmdGlobal
 	networkForward *cmdNetworkForward
+
+	flagFormat string
 }
 
 func (c *cmdNetworkForwardShow) Command() *cobra.Command {
@@ -161,6 +163,8 @@ func (c *cmdNetworkForwardShow) Command() *cobra.Command {
 	cmd.Use = usage("show", i18n.G("[<remote>:]<network> <listen_address>"))
 	cmd.Short = i18n.G("Show network forward configurations")
 	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G("Show network forward configurations"))
+	cmd.Flags().StringVarP(&c.flagFormat, "format", "f", "yaml", i18n.G("Format (json|yaml)")+"``")
+
 	cmd.RunE = c.Run
 
 	cmd.Flags().StringVar(&c.networkForward.flagTarget, "target", "", i18n.G("Cluster member name")+"``")
@@ -204,13 +208,11 @@ func (c *cmdNetworkForwardShow) Run(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	data, err := yaml.Marshal(&forward)
+	err = utils.RenderObject(c.flagFormat, &forward)
 	if err != nil {
 		return err
 	}
 
-	fmt.Printf("%s", data)
-
 	return nil
 }
 
@@ -6,7 +6,6 @@ import (
 	"strings"
 
 	"github.com/spf13/cobra"
-	"gopkg.in/yaml.v2"
 
 	"github.com/lxc/lxd/lxc/utils"
 	cli "github.com/lxc/lxd/shared/cmd"
@@ -104,7 +103,7 @@ func (c *cmdOperationList) Command() *cobra.Command {
 	cmd.Short = i18n.G("List background operations")
 	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
 		`List background operations`))
-	cmd.Flags().StringVarP(&c.flagFormat, "format", "f", "table", i18n.G("Format (csv|json|table|yaml)")+"``")
+	cmd.Flags().StringVarP(&c.flagFormat, "format", "f", "table", i18n.G("Format (csv|json|table|yaml|compact)")+"``")
 
 	cmd.RunE = c.Run
 
@@ -175,6 +174,8 @@ func (c *cmdOperationList) Run(cmd *cobra.Command, args []string) error {
 type cmdOperationShow struct {
 	global    *cmdGlobal
 	operation *cmdOperation
+
+	flagFormat string
 }
 
 func (c *cmdOperationShow) Command() *cobra.Command {
@@ -187,6 +188,8 @@ func (c *cmdOperationShow) Command() *cobra.Command {
 		`lxc operation show 344a79e4-d88a-45bf-9c39-c72c26f6ab8a
     Show details on that operation UUID`))
 
+	cmd.Flags().StringVarP(&c.flagFormat, "format", "f", "yaml", i18n.G("Format (json|yaml)")+"``")
+
 	cmd.RunE = c.Run
 
 	return cmd
@@ -213,13 +216,10 @@ func (c *cmdOperationShow) Run(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	// Render as YAML
-	data, err := yaml.Marshal(&op)
+	err = utils.RenderObject(c.flagFormat, &op)
 	if err != nil {
 		return err
 	}
 
-	fmt.Printf("%s", data)
-
 	return nil
 }
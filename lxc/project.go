@@ -42,10 +42,18 @@ func (c *cmdProject) Command() *cobra.Command {
 	projectEditCmd := cmdProjectEdit{global: c.global, project: c}
 	cmd.AddCommand(projectEditCmd.Command())
 
+	// Export
+	projectExportCmd := cmdProjectExport{global: c.global, project: c}
+	cmd.AddCommand(projectExportCmd.Command())
+
 	// Get
 	projectGetCmd := cmdProjectGet{global: c.global, project: c}
 	cmd.AddCommand(projectGetCmd.Command())
 
+	// Import
+	projectImportCmd := cmdProjectImport{global: c.global, project: c}
+	cmd.AddCommand(projectImportCmd.Command())
+
 	// List
 	projectListCmd := cmdProjectList{global: c.global, project: c}
 	cmd.AddCommand(projectListCmd.Command())
@@ -391,7 +399,7 @@ func (c *cmdProjectList) Command() *cobra.Command {
 	cmd.Short = i18n.G("List projects")
 	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
 		`List projects`))
-	cmd.Flags().StringVarP(&c.flagFormat, "format", "f", "table", i18n.G("Format (csv|json|table|yaml)")+"``")
+	cmd.Flags().StringVarP(&c.flagFormat, "format", "f", "table", i18n.G("Format (csv|json|table|yaml|compact)")+"``")
 
 	cmd.RunE = c.Run
 
@@ -626,6 +634,8 @@ func (c *cmdProjectUnset) Run(cmd *cobra.Command, args []string) error {
 type cmdProjectShow struct {
 	global  *cmdGlobal
 	project *cmdProject
+
+	flagFormat string
 }
 
 func (c *cmdProjectShow) Command() *cobra.Command {
@@ -635,6 +645,8 @@ func (c *cmdProjectShow) Command() *cobra.Command {
 	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
 		`Show project options`))
 
+	cmd.Flags().StringVarP(&c.flagFormat, "format", "f", "yaml", i18n.G("Format (json|yaml)")+"``")
+
 	cmd.RunE = c.Run
 
 	return cmd
@@ -665,13 +677,11 @@ func (c *cmdProjectShow) Run(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	data, err := yaml.Marshal(&project)
+	err = utils.RenderObject(c.flagFormat, &project)
 	if err != nil {
 		return err
 	}
 
-	fmt.Printf("%s", data)
-
 	return nil
 }
 
@@ -746,7 +756,7 @@ func (c *cmdProjectInfo) Command() *cobra.Command {
 	cmd.Short = i18n.G("Get a summary of resource allocations")
 	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
 		`Get a summary of resource allocations`))
-	cmd.Flags().StringVarP(&c.flagFormat, "format", "f", "table", i18n.G("Format (csv|json|table|yaml)")+"``")
+	cmd.Flags().StringVarP(&c.flagFormat, "format", "f", "table", i18n.G("Format (csv|json|table|yaml|compact)")+"``")
 
 	cmd.RunE = c.Run
 
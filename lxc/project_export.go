@@ -0,0 +1,420 @@
+package main
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	lxd "github.com/lxc/lxd/client"
+	"github.com/lxc/lxd/lxc/utils"
+	"github.com/lxc/lxd/shared"
+	cli "github.com/lxc/lxd/shared/cmd"
+	"github.com/lxc/lxd/shared/i18n"
+	"github.com/lxc/lxd/shared/ioprogress"
+	"github.com/lxc/lxd/shared/units"
+)
+
+// projectExportIsArchive tells whether the given export path refers to a tarball rather than a directory.
+func projectExportIsArchive(path string) bool {
+	return strings.HasSuffix(path, ".tar")
+}
+
+// Export
+type cmdProjectExport struct {
+	global  *cmdGlobal
+	project *cmdProject
+
+	flagInstanceOnly         bool
+	flagOptimizedStorage     bool
+	flagCompressionAlgorithm string
+}
+
+func (c *cmdProjectExport) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("export", i18n.G("[<remote>:]<project> <target>"))
+	cmd.Short = i18n.G("Export a project")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Export a project
+
+The project configuration, its profiles, networks and images (when the project has
+its own), its custom storage volumes and its instances are exported, the instances
+and volumes as backups.
+
+The target is a tarball if its name ends with .tar and a new directory otherwise.`))
+	cmd.Example = cli.FormatSection("", i18n.G(
+		`lxc project export tenant1 tenant1.tar
+    Export the tenant1 project into the tenant1.tar tarball.
+
+lxc project export tenant1 /srv/exports/tenant1 --optimized-storage
+    Export the tenant1 project into a directory, using optimized storage backups.`))
+
+	cmd.Flags().BoolVar(&c.flagInstanceOnly, "instance-only", false, i18n.G("Don't export instance and volume snapshots"))
+	cmd.Flags().BoolVar(&c.flagOptimizedStorage, "optimized-storage", false, i18n.G("Use storage driver optimized format (can only be imported on a similar pool)"))
+	cmd.Flags().StringVar(&c.flagCompressionAlgorithm, "compression", "", i18n.G("Compression algorithm to use (`none` for uncompressed)"))
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdProjectExport) Run(cmd *cobra.Command, args []string) error {
+	conf := c.global.conf
+
+	// Quick checks.
+	exit, err := c.global.CheckArgs(cmd, args, 2, 2)
+	if exit {
+		return err
+	}
+
+	remote, projectName, err := conf.ParseRemote(args[0])
+	if err != nil {
+		return err
+	}
+
+	d, err := conf.GetInstanceServer(remote)
+	if err != nil {
+		return err
+	}
+
+	target := shared.HostPathFollow(args[1])
+	if shared.PathExists(target) {
+		return fmt.Errorf(i18n.G("The target %q already exists"), args[1])
+	}
+
+	progress := utils.ProgressRenderer{
+		Format: i18n.G("Exporting the project: %s"),
+		Quiet:  c.global.flagQuiet,
+	}
+
+	req := lxd.ProjectExportFileRequest{
+		InstanceOnly:         c.flagInstanceOnly,
+		OptimizedStorage:     c.flagOptimizedStorage,
+		CompressionAlgorithm: c.flagCompressionAlgorithm,
+		ProgressHandler:      progress.UpdateProgress,
+	}
+
+	if projectExportIsArchive(target) {
+		err = c.exportArchive(d, projectName, target, &req)
+	} else {
+		err = c.exportDirectory(d, projectName, target, &req)
+	}
+
+	if err != nil {
+		progress.Done("")
+		return err
+	}
+
+	progress.Done(fmt.Sprintf(i18n.G("Project %s exported to %s"), projectName, args[1]))
+
+	return nil
+}
+
+// exportArchive writes the project export to a tarball.
+func (c *cmdProjectExport) exportArchive(d lxd.InstanceServer, projectName string, target string, req *lxd.ProjectExportFileRequest) error {
+	file, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	req.ExportFile = file
+	_, err = d.GetProjectExportFile(projectName, req)
+	if err != nil {
+		file.Close()
+		os.Remove(target)
+		return err
+	}
+
+	return file.Close()
+}
+
+// exportDirectory unpacks the project export to a new directory as it's received.
+func (c *cmdProjectExport) exportDirectory(d lxd.InstanceServer, projectName string, target string, req *lxd.ProjectExportFileRequest) error {
+	err := os.Mkdir(target, 0700)
+	if err != nil {
+		return err
+	}
+
+	reader, writer := io.Pipe()
+	unpackErr := make(chan error, 1)
+	go func() {
+		err := projectExportUnpack(reader, target)
+		if err == nil {
+			// Consume any padding following the end of the archive.
+			_, err = io.Copy(ioutil.Discard, reader)
+		}
+
+		// Stop the download if the export can't be unpacked.
+		reader.CloseWithError(err)
+		unpackErr <- err
+	}()
+
+	req.ExportFile = writer
+	_, err = d.GetProjectExportFile(projectName, req)
+	writer.CloseWithError(err)
+
+	// Wait for the unpacking to be over before cleaning up.
+	errUnpack := <-unpackErr
+	if err == nil {
+		err = errUnpack
+	}
+
+	if err != nil {
+		os.RemoveAll(target)
+		return err
+	}
+
+	return nil
+}
+
+// Import
+type cmdProjectImport struct {
+	global  *cmdGlobal
+	project *cmdProject
+
+	flagStorage string
+}
+
+func (c *cmdProjectImport) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("import", i18n.G("[<remote>:]<source> [<project>]"))
+	cmd.Short = i18n.G("Import a project")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Import a project
+
+The source is a tarball or a directory written by "lxc project export".
+The project is created, optionally under a new name, along with everything
+that was exported with it.
+
+The storage pools and the networks of the default project used by the profiles
+and instances have to exist on the target server.`))
+	cmd.Example = cli.FormatSection("", i18n.G(
+		`lxc project import tenant1.tar
+    Re-create the tenant1 project from the tenant1.tar tarball.
+
+lxc project import remote:/srv/exports/tenant1 tenant2 --storage pool1
+    Create the tenant2 project from an export directory, with the instances on pool1.`))
+
+	cmd.Flags().StringVarP(&c.flagStorage, "storage", "s", "", i18n.G("Storage pool name for the instances and custom volumes")+"``")
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdProjectImport) Run(cmd *cobra.Command, args []string) error {
+	conf := c.global.conf
+
+	// Quick checks.
+	exit, err := c.global.CheckArgs(cmd, args, 1, 2)
+	if exit {
+		return err
+	}
+
+	remote, source, err := conf.ParseRemote(args[0])
+	if err != nil {
+		return err
+	}
+
+	d, err := conf.GetInstanceServer(remote)
+	if err != nil {
+		return err
+	}
+
+	source = shared.HostPathFollow(source)
+
+	progress := utils.ProgressRenderer{
+		Format: i18n.G("Importing the project: %s"),
+		Quiet:  c.global.flagQuiet,
+	}
+
+	importArgs := lxd.ProjectImportArgs{
+		PoolName: c.flagStorage,
+	}
+
+	if len(args) > 1 {
+		importArgs.Name = args[1]
+	}
+
+	if shared.IsDir(source) {
+		// Export directories are packed as they're uploaded.
+		reader, writer := io.Pipe()
+		go func() {
+			writer.CloseWithError(projectExportPack(source, writer))
+		}()
+
+		defer reader.Close()
+
+		importArgs.ExportFile = &ioprogress.ProgressReader{
+			ReadCloser: reader,
+			Tracker: &ioprogress.ProgressTracker{
+				Handler: func(received int64, speed int64) {
+					progress.UpdateProgress(ioprogress.NewProgressData(fmt.Sprintf("%s (%s/s)", units.GetByteSizeString(received, 2), units.GetByteSizeString(speed, 2)), received, 0, speed))
+				},
+			},
+		}
+	} else {
+		file, err := os.Open(source)
+		if err != nil {
+			return err
+		}
+
+		defer file.Close()
+
+		fstat, err := file.Stat()
+		if err != nil {
+			return err
+		}
+
+		importArgs.ExportFile = &ioprogress.ProgressReader{
+			ReadCloser: file,
+			Tracker: &ioprogress.ProgressTracker{
+				Length: fstat.Size(),
+				Handler: func(percent int64, speed int64) {
+					progress.UpdateProgress(ioprogress.NewProgressData(fmt.Sprintf("%d%% (%s/s)", percent, units.GetByteSizeString(speed, 2)), percent, fstat.Size(), speed))
+				},
+			},
+		}
+	}
+
+	op, err := d.CreateProjectFromExport(importArgs)
+	if err != nil {
+		progress.Done("")
+		return err
+	}
+
+	err = utils.CancelableWait(op, &progress)
+	if err != nil {
+		progress.Done("")
+		return err
+	}
+
+	progress.Done(i18n.G("Project imported successfully!"))
+
+	return nil
+}
+
+// projectExportPack writes the content of an export directory as a tarball, the index last.
+func projectExportPack(dir string, w io.Writer) error {
+	tw := tar.NewWriter(w)
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		name, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		// The index goes last, like in the exports written by the server.
+		if name == "." || name == "index.yaml" {
+			return nil
+		}
+
+		return projectExportPackFile(tw, path, name, info)
+	})
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(dir, "index.yaml")
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	err = projectExportPackFile(tw, path, "index.yaml", info)
+	if err != nil {
+		return err
+	}
+
+	return tw.Close()
+}
+
+// projectExportPackFile writes a file or directory of an export directory to a tarball.
+func projectExportPackFile(tw *tar.Writer, path string, name string, info os.FileInfo) error {
+	header, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+
+	header.Name = filepath.ToSlash(name)
+	err = tw.WriteHeader(header)
+	if err != nil {
+		return err
+	}
+
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+// projectExportUnpack extracts an export tarball to a directory.
+func projectExportUnpack(r io.Reader, dir string) error {
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return fmt.Errorf(i18n.G("Failed to read the project export: %w"), err)
+		}
+
+		// Don't let entries escape the target directory.
+		name := filepath.Clean(filepath.FromSlash(header.Name))
+		if filepath.IsAbs(name) || name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) {
+			return fmt.Errorf(i18n.G("Invalid path %q in the project export"), header.Name)
+		}
+
+		path := filepath.Join(dir, name)
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(path, 0700)
+			if err != nil {
+				return err
+			}
+
+		case tar.TypeReg:
+			err = os.MkdirAll(filepath.Dir(path), 0700)
+			if err != nil {
+				return err
+			}
+
+			f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+			if err != nil {
+				return err
+			}
+
+			_, err = io.Copy(f, tr)
+			f.Close()
+			if err != nil {
+				return err
+			}
+
+		default:
+			return fmt.Errorf(i18n.G("Unsupported entry %q in the project export"), header.Name)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// An export directory packed to a tarball is unpacked to the same layout.
+func TestProjectExportPackUnpack(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "lxc-project-export-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	source := filepath.Join(tmpDir, "source")
+	require.NoError(t, os.MkdirAll(filepath.Join(source, "volumes", "default"), 0700))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(source, "index.yaml"), []byte("version: 1\n"), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(source, "volumes", "default", "data.backup"), []byte("backup"), 0600))
+
+	archive := bytes.Buffer{}
+	require.NoError(t, projectExportPack(source, &archive))
+
+	// The index comes last.
+	tr := tar.NewReader(bytes.NewReader(archive.Bytes()))
+	last := ""
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+
+		require.NoError(t, err)
+		last = header.Name
+	}
+
+	assert.Equal(t, "index.yaml", last)
+
+	target := filepath.Join(tmpDir, "target")
+	require.NoError(t, os.Mkdir(target, 0700))
+	require.NoError(t, projectExportUnpack(&archive, target))
+
+	data, err := ioutil.ReadFile(filepath.Join(target, "index.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, "version: 1\n", string(data))
+
+	data, err = ioutil.ReadFile(filepath.Join(target, "volumes", "default", "data.backup"))
+	require.NoError(t, err)
+	assert.Equal(t, "backup", string(data))
+}
+
+// Entries escaping the target directory are rejected.
+func TestProjectExportUnpack_InvalidPath(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "lxc-project-export-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	for _, name := range []string{"../escape", "/etc/escape", "instances/../../escape"} {
+		archive := bytes.Buffer{}
+		tw := tar.NewWriter(&archive)
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0600, Size: 1, Typeflag: tar.TypeReg}))
+		_, err = tw.Write([]byte("x"))
+		require.NoError(t, err)
+		require.NoError(t, tw.Close())
+
+		target := filepath.Join(tmpDir, "target")
+		require.NoError(t, os.MkdirAll(target, 0700))
+		assert.Error(t, projectExportUnpack(&archive, target), name)
+		assert.NoFileExists(t, filepath.Join(tmpDir, "escape"))
+	}
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lxc/lxd/client"
+	"github.com/lxc/lxd/lxc/utils"
+	"github.com/lxc/lxd/shared/api"
+	cli "github.com/lxc/lxd/shared/cmd"
+	"github.com/lxc/lxd/shared/i18n"
+)
+
+type cmdRebuild struct {
+	global *cmdGlobal
+
+	flagEmpty bool
+	flagForce bool
+}
+
+func (c *cmdRebuild) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("rebuild", i18n.G("[<remote>:]<instance> [<remote>:]<image>"))
+	cmd.Short = i18n.G("Rebuild instances")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Rebuild instances
+
+The root disk of the instance is re-created from the image (or empty with --empty),
+while its configuration, devices and profiles are kept.`))
+	cmd.Example = cli.FormatSection("", i18n.G(
+		`lxc rebuild c1 ubuntu:20.04
+    Rebuild c1 from the ubuntu:20.04 image.
+
+lxc rebuild v1 --empty --force
+    Stop v1, replace its root disk with an empty one and start it again.`))
+
+	cmd.RunE = c.Run
+	cmd.ValidArgsFunction = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return c.global.cmpInstances(toComplete)
+		}
+
+		if len(args) == 1 {
+			return c.global.cmpImages(toComplete)
+		}
+
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	cmd.Flags().BoolVar(&c.flagEmpty, "empty", false, i18n.G("Rebuild with an empty root disk"))
+	cmd.Flags().BoolVarP(&c.flagForce, "force", "f", false, i18n.G("Stop and restart the instance if it's running"))
+
+	return cmd
+}
+
+func (c *cmdRebuild) Run(cmd *cobra.Command, args []string) error {
+	conf := c.global.conf
+
+	// Quick checks.
+	exit, err := c.global.CheckArgs(cmd, args, 1, 2)
+	if exit {
+		return err
+	}
+
+	if c.flagEmpty && len(args) > 1 {
+		return fmt.Errorf(i18n.G("Can't use an image with --empty"))
+	}
+
+	if !c.flagEmpty && len(args) < 2 {
+		return fmt.Errorf(i18n.G("An image is required unless --empty is passed"))
+	}
+
+	// Connect to the daemon.
+	remote, name, err := conf.ParseRemote(args[0])
+	if err != nil {
+		return err
+	}
+
+	d, err := conf.GetInstanceServer(remote)
+	if err != nil {
+		return err
+	}
+
+	current, _, err := d.GetInstance(name)
+	if err != nil {
+		return err
+	}
+
+	// Running instances have to be stopped first.
+	running := current.StatusCode == api.Running
+	if running {
+		if !c.flagForce {
+			return fmt.Errorf(i18n.G("The instance is currently running. Use --force to have it stopped and restarted"))
+		}
+
+		err = c.updateState(d, name, "stop")
+		if err != nil {
+			return err
+		}
+	}
+
+	if c.flagEmpty {
+		req := api.InstanceRebuildPost{}
+		req.Source.Type = "none"
+
+		op, err := d.RebuildInstance(name, req)
+		if err != nil {
+			return err
+		}
+
+		err = op.Wait()
+		if err != nil {
+			return err
+		}
+	} else {
+		err = c.rebuildFromImage(d, remote, name, current, args[1])
+		if err != nil {
+			return err
+		}
+	}
+
+	if running {
+		return c.updateState(d, name, "start")
+	}
+
+	return nil
+}
+
+// rebuildFromImage resolves the image the same way as lxc init does and rebuilds the instance from it.
+func (c *cmdRebuild) rebuildFromImage(d lxd.InstanceServer, remote string, name string, current *api.Instance, rawImage string) error {
+	conf := c.global.conf
+
+	iremote, image, err := conf.ParseRemote(rawImage)
+	if err != nil {
+		return err
+	}
+
+	initCmd := cmdInit{global: c.global}
+	iremote, image = initCmd.guessImage(conf, d, remote, iremote, image)
+
+	// Connect to the image server.
+	var imgRemote lxd.ImageServer
+	if iremote == remote {
+		imgRemote = d
+	} else {
+		imgRemote, err = conf.GetImageServer(iremote)
+		if err != nil {
+			return err
+		}
+	}
+
+	if image == "" {
+		image = "default"
+	}
+
+	req := api.InstanceRebuildPost{}
+
+	// Optimisation for simplestreams
+	var imgInfo *api.Image
+	if conf.Remotes[iremote].Protocol == "simplestreams" {
+		imgInfo = &api.Image{}
+		imgInfo.Fingerprint = image
+		imgInfo.Public = true
+		req.Source.Alias = image
+	} else {
+		// Attempt to resolve an image alias.
+		alias, _, err := imgRemote.GetImageAlias(image)
+		if err == nil {
+			req.Source.Alias = image
+			image = alias.Target
+		}
+
+		imgInfo, _, err = imgRemote.GetImage(image)
+		if err != nil {
+			return err
+		}
+
+		if imgInfo.Type != current.Type {
+			return fmt.Errorf(i18n.G("The image is of type %q but the instance is of type %q"), imgInfo.Type, current.Type)
+		}
+	}
+
+	op, err := d.RebuildInstanceFromImage(imgRemote, *imgInfo, name, req)
+	if err != nil {
+		return err
+	}
+
+	// Watch the background operation.
+	progress := utils.ProgressRenderer{
+		Format: i18n.G("Retrieving image: %s"),
+		Quiet:  c.global.flagQuiet,
+	}
+
+	_, err = op.AddHandler(progress.UpdateOp)
+	if err != nil {
+		progress.Done("")
+		return err
+	}
+
+	err = utils.CancelableWait(op, &progress)
+	if err != nil {
+		progress.Done("")
+		return err
+	}
+
+	progress.Done("")
+
+	return nil
+}
+
+// updateState stops or starts the instance and waits for it.
+func (c *cmdRebuild) updateState(d lxd.InstanceServer, name string, action string) error {
+	req := api.InstanceStatePut{
+		Action:  action,
+		Timeout: -1,
+		Force:   action == "stop",
+	}
+
+	op, err := d.UpdateInstanceState(name, req, "")
+	if err != nil {
+		return err
+	}
+
+	return op.Wait()
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	lxd "github.com/lxc/lxd/client"
+	"github.com/lxc/lxd/shared"
+	cli "github.com/lxc/lxd/shared/cmd"
+	"github.com/lxc/lxd/shared/i18n"
+)
+
+type cmdShell struct {
+	global *cmdGlobal
+
+	flagUser         string
+	flagEnvironment  []string
+	flagForwardAgent bool
+}
+
+func (c *cmdShell) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("shell", i18n.G("[<remote>:]<instance>"))
+	cmd.Short = i18n.G("Open a login shell in an instance")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Open a login shell in an instance
+
+The shell of the user is looked up in the instance's /etc/passwd and started
+as a login shell in the user's home directory, following the size of the terminal.
+
+With --forward-agent, the local SSH agent is made available inside the instance
+through a temporary proxy device for the duration of the session. This requires
+the instance to be on the local LXD server.`))
+	cmd.Example = cli.FormatSection("", i18n.G(
+		`lxc shell c1
+    Open a root shell in c1.
+
+lxc shell c1 --user ubuntu -A
+    Open a shell as the ubuntu user, forwarding the local SSH agent.`))
+
+	cmd.RunE = c.Run
+	cmd.ValidArgsFunction = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return c.global.cmpInstances(toComplete)
+		}
+
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	cmd.Flags().StringVar(&c.flagUser, "user", "root", i18n.G("User to open the shell as")+"``")
+	cmd.Flags().StringArrayVar(&c.flagEnvironment, "env", nil, i18n.G("Environment variable to set (e.g. HOME=/home/foo)")+"``")
+	cmd.Flags().BoolVarP(&c.flagForwardAgent, "forward-agent", "A", false, i18n.G("Forward the local SSH agent into the instance"))
+
+	return cmd
+}
+
+// shellAccount is an entry of the instance's /etc/passwd.
+type shellAccount struct {
+	uid   string
+	gid   string
+	home  string
+	shell string
+}
+
+func (c *cmdShell) Run(cmd *cobra.Command, args []string) error {
+	conf := c.global.conf
+
+	// Quick checks.
+	exit, err := c.global.CheckArgs(cmd, args, 1, 1)
+	if exit {
+		return err
+	}
+
+	remote, name, err := conf.ParseRemote(args[0])
+	if err != nil {
+		return err
+	}
+
+	d, err := conf.GetInstanceServer(remote)
+	if err != nil {
+		return err
+	}
+
+	exec := cmdExec{global: c.global, flagMode: "auto"}
+
+	// Start the user's own shell when it can be found, otherwise let su figure it out.
+	command := []string{"su", "-l", c.flagUser}
+	account, err := c.lookupAccount(d, name, c.flagUser)
+	if err == nil {
+		command = []string{account.shell, "-l"}
+		exec.flagUser = account.uid
+		exec.flagGroup = account.gid
+		exec.flagCwd = account.home
+		exec.flagEnvironment = append(exec.flagEnvironment, "HOME="+account.home, "USER="+c.flagUser, "LOGNAME="+c.flagUser, "SHELL="+account.shell)
+	}
+
+	if c.flagForwardAgent {
+		agentSocket := os.Getenv("SSH_AUTH_SOCK")
+		if agentSocket == "" {
+			return fmt.Errorf(i18n.G("No SSH agent to forward, SSH_AUTH_SOCK isn't set"))
+		}
+
+		// The proxy device connects to the agent from the server, so it has to be the local one.
+		if !strings.HasPrefix(conf.Remotes[remote].Addr, "unix:") {
+			return fmt.Errorf(i18n.G("The SSH agent can only be forwarded to instances of the local server"))
+		}
+
+		path, cleanup, err := c.forwardAgent(d, name, agentSocket, account)
+		if err != nil {
+			return err
+		}
+
+		defer cleanup()
+
+		exec.flagEnvironment = append(exec.flagEnvironment, "SSH_AUTH_SOCK="+path)
+	}
+
+	exec.flagEnvironment = append(exec.flagEnvironment, c.flagEnvironment...)
+
+	return exec.Run(cmd, append([]string{args[0]}, command...))
+}
+
+// lookupAccount finds the user in the instance's /etc/passwd.
+func (c *cmdShell) lookupAccount(d lxd.InstanceServer, name string, user string) (*shellAccount, error) {
+	content, _, err := d.GetInstanceFile(name, "/etc/passwd")
+	if err != nil {
+		return nil, err
+	}
+
+	defer content.Close()
+
+	scanner := bufio.NewScanner(content)
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), ":")
+		if len(fields) < 7 || fields[0] != user {
+			continue
+		}
+
+		account := shellAccount{uid: fields[2], gid: fields[3], home: fields[5], shell: fields[6]}
+		if account.home == "" {
+			account.home = "/"
+		}
+
+		if account.shell == "" {
+			account.shell = "/bin/sh"
+		}
+
+		return &account, nil
+	}
+
+	err = scanner.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, fmt.Errorf(i18n.G("User %q not found in the instance"), user)
+}
+
+// forwardAgent adds a temporary proxy device exposing the SSH agent socket inside the instance.
+// It returns the path of the socket in the instance and a function removing the device.
+func (c *cmdShell) forwardAgent(d lxd.InstanceServer, name string, agentSocket string, account *shellAccount) (string, func(), error) {
+	suffix, err := shared.RandomCryptoString()
+	if err != nil {
+		return "", nil, err
+	}
+
+	deviceName := fmt.Sprintf("ssh-agent-%s", suffix[:8])
+	path := fmt.Sprintf("/tmp/lxd-ssh-agent-%s.sock", suffix[:8])
+
+	device := map[string]string{
+		"type":    "proxy",
+		"bind":    "instance",
+		"listen":  "unix:" + path,
+		"connect": "unix:" + agentSocket,
+		"mode":    "0600",
+	}
+
+	if account != nil {
+		device["uid"] = account.uid
+		device["gid"] = account.gid
+	}
+
+	err = c.updateDevices(d, name, func(devices map[string]map[string]string) {
+		devices[deviceName] = device
+	})
+	if err != nil {
+		return "", nil, fmt.Errorf(i18n.G("Failed to add the SSH agent proxy device: %w"), err)
+	}
+
+	cleanup := func() {
+		err := c.updateDevices(d, name, func(devices map[string]map[string]string) {
+			delete(devices, deviceName)
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, i18n.G("Failed to remove the SSH agent proxy device %q: %v")+"\n", deviceName, err)
+		}
+	}
+
+	return path, cleanup, nil
+}
+
+// updateDevices applies a change to the local devices of an instance.
+func (c *cmdShell) updateDevices(d lxd.InstanceServer, name string, change func(devices map[string]map[string]string)) error {
+	inst, etag, err := d.GetInstance(name)
+	if err != nil {
+		return err
+	}
+
+	if inst.Devices == nil {
+		inst.Devices = map[string]map[string]string{}
+	}
+
+	change(inst.Devices)
+
+	op, err := d.UpdateInstance(name, inst.Writable(), etag)
+	if err != nil {
+		return err
+	}
+
+	return op.Wait()
+}
@@ -1208,7 +1208,7 @@ Column shorthand chars:
     u - Number of references (used by)
     L - Location of the instance (e.g. its cluster member)
     U - Current disk usage`))
-	cmd.Flags().StringVarP(&c.flagFormat, "format", "f", "table", i18n.G("Format (csv|json|table|yaml)")+"``")
+	cmd.Flags().StringVarP(&c.flagFormat, "format", "f", "table", i18n.G("Format (csv|json|table|yaml|compact)")+"``")
 
 	cmd.RunE = c.Run
 
@@ -1589,6 +1589,8 @@ type cmdStorageVolumeShow struct {
 	global        *cmdGlobal
 	storage       *cmdStorage
 	storageVolume *cmdStorageVolume
+
+	flagFormat string
 }
 
 func (c *cmdStorageVolumeShow) Command() *cobra.Command {
@@ -1605,6 +1607,8 @@ lxc storage volume show default container/data
     Will show the properties of the filesystem for a container called "data" in the "default" pool.`))
 
 	cmd.Flags().StringVar(&c.storage.flagTarget, "target", "", i18n.G("Cluster member name")+"``")
+	cmd.Flags().StringVarP(&c.flagFormat, "format", "f", "yaml", i18n.G("Format (json|yaml)")+"``")
+
 	cmd.RunE = c.Run
 
 	return cmd
@@ -1655,14 +1659,7 @@ func (c *cmdStorageVolumeShow) Run(cmd *cobra.Command, args []string) error {
 			return err
 		}
 
-		data, err := yaml.Marshal(&vol)
-		if err != nil {
-			return err
-		}
-
-		fmt.Printf("%s", data)
-
-		return nil
+		return utils.RenderObject(c.flagFormat, &vol)
 	}
 
 	vol, _, err := client.GetStoragePoolVolume(resource.name, volType, volName)
@@ -1672,13 +1669,11 @@ func (c *cmdStorageVolumeShow) Run(cmd *cobra.Command, args []string) error {
 
 	sort.Strings(vol.UsedBy)
 
-	data, err := yaml.Marshal(&vol)
+	err = utils.RenderObject(c.flagFormat, &vol)
 	if err != nil {
 		return err
 	}
 
-	fmt.Printf("%s", data)
-
 	return nil
 }
 
@@ -2097,7 +2092,7 @@ func (c *cmdStorageVolumeImport) Run(cmd *cobra.Command, args []string) error {
 			Tracker: &ioprogress.ProgressTracker{
 				Length: fstat.Size(),
 				Handler: func(percent int64, speed int64) {
-					progress.UpdateProgress(ioprogress.ProgressData{Text: fmt.Sprintf("%d%% (%s/s)", percent, units.GetByteSizeString(speed, 2))})
+					progress.UpdateProgress(ioprogress.NewProgressData(fmt.Sprintf("%d%% (%s/s)", percent, units.GetByteSizeString(speed, 2)), percent, fstat.Size(), speed))
 				},
 			},
 		},
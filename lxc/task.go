@@ -0,0 +1,253 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lxc/lxd/lxc/utils"
+	"github.com/lxc/lxd/shared/api"
+	cli "github.com/lxc/lxd/shared/cmd"
+	"github.com/lxc/lxd/shared/i18n"
+)
+
+type cmdTask struct {
+	global *cmdGlobal
+
+	flagTarget string
+}
+
+func (c *cmdTask) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("task")
+	cmd.Short = i18n.G("Manage background tasks")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Manage background tasks
+
+Background tasks are the recurring jobs of the server, like image updates
+or the pruning of expired snapshots and backups.`))
+
+	// List
+	taskListCmd := cmdTaskList{global: c.global, task: c}
+	cmd.AddCommand(taskListCmd.Command())
+
+	// Show
+	taskShowCmd := cmdTaskShow{global: c.global, task: c}
+	cmd.AddCommand(taskShowCmd.Command())
+
+	// Run
+	taskRunCmd := cmdTaskAction{global: c.global, task: c, action: "run",
+		short: i18n.G("Run background tasks right away")}
+	cmd.AddCommand(taskRunCmd.Command())
+
+	// Pause
+	taskPauseCmd := cmdTaskAction{global: c.global, task: c, action: "pause",
+		short: i18n.G("Pause the scheduled runs of background tasks")}
+	cmd.AddCommand(taskPauseCmd.Command())
+
+	// Resume
+	taskResumeCmd := cmdTaskAction{global: c.global, task: c, action: "resume",
+		short: i18n.G("Resume the scheduled runs of background tasks")}
+	cmd.AddCommand(taskResumeCmd.Command())
+
+	// Workaround for subcommand usage errors. See: https://github.com/spf13/cobra/issues/706
+	cmd.Args = cobra.NoArgs
+	cmd.Run = func(cmd *cobra.Command, args []string) { cmd.Usage() }
+	return cmd
+}
+
+// List
+type cmdTaskList struct {
+	global *cmdGlobal
+	task   *cmdTask
+
+	flagFormat string
+}
+
+func (c *cmdTaskList) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("list", i18n.G("[<remote>:]"))
+	cmd.Aliases = []string{"ls"}
+	cmd.Short = i18n.G("List background tasks")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`List background tasks`))
+
+	cmd.Flags().StringVarP(&c.flagFormat, "format", "f", "table", i18n.G("Format (csv|json|table|yaml|compact)")+"``")
+	cmd.Flags().StringVar(&c.task.flagTarget, "target", "", i18n.G("Cluster member name")+"``")
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdTaskList) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.CheckArgs(cmd, args, 0, 1)
+	if exit {
+		return err
+	}
+
+	// Parse remote
+	remote := ""
+	if len(args) > 0 {
+		remote = args[0]
+	}
+
+	remoteName, _, err := c.global.conf.ParseRemote(remote)
+	if err != nil {
+		return err
+	}
+
+	remoteServer, err := c.global.conf.GetInstanceServer(remoteName)
+	if err != nil {
+		return err
+	}
+
+	if c.task.flagTarget != "" {
+		remoteServer = remoteServer.UseTarget(c.task.flagTarget)
+	}
+
+	tasks, err := remoteServer.GetTasks()
+	if err != nil {
+		return err
+	}
+
+	// Render the table
+	data := [][]string{}
+	for _, task := range tasks {
+		data = append(data, []string{task.Name, strings.ToUpper(task.Status), c.formatTime(task.LastRunAt), c.formatTime(task.NextRunAt), task.Description})
+	}
+	sort.Sort(stringList(data))
+
+	rawData := make([]*api.Task, len(tasks))
+	for i := range tasks {
+		rawData[i] = &tasks[i]
+	}
+
+	headers := []string{
+		i18n.G("NAME"),
+		i18n.G("STATUS"),
+		i18n.G("LAST RUN"),
+		i18n.G("NEXT RUN"),
+		i18n.G("DESCRIPTION"),
+	}
+
+	return utils.RenderTable(c.flagFormat, headers, data, rawData)
+}
+
+func (c *cmdTaskList) formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.UTC().Format("Jan 2, 2006 at 3:04pm (MST)")
+}
+
+// Show
+type cmdTaskShow struct {
+	global *cmdGlobal
+	task   *cmdTask
+
+	flagFormat string
+}
+
+func (c *cmdTaskShow) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("show", i18n.G("[<remote>:]<task>"))
+	cmd.Short = i18n.G("Show background task details")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Show background task details`))
+
+	cmd.Flags().StringVarP(&c.flagFormat, "format", "f", "yaml", i18n.G("Format (json|yaml)")+"``")
+	cmd.Flags().StringVar(&c.task.flagTarget, "target", "", i18n.G("Cluster member name")+"``")
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdTaskShow) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.CheckArgs(cmd, args, 1, 1)
+	if exit {
+		return err
+	}
+
+	// Parse remote
+	remoteName, name, err := c.global.conf.ParseRemote(args[0])
+	if err != nil {
+		return err
+	}
+
+	remoteServer, err := c.global.conf.GetInstanceServer(remoteName)
+	if err != nil {
+		return err
+	}
+
+	if c.task.flagTarget != "" {
+		remoteServer = remoteServer.UseTarget(c.task.flagTarget)
+	}
+
+	task, _, err := remoteServer.GetTask(name)
+	if err != nil {
+		return err
+	}
+
+	return utils.RenderObject(c.flagFormat, &task)
+}
+
+// Run, pause and resume
+type cmdTaskAction struct {
+	global *cmdGlobal
+	task   *cmdTask
+
+	action string
+	short  string
+}
+
+func (c *cmdTaskAction) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage(c.action, i18n.G("[<remote>:]<task> [[<remote>:]<task>...]"))
+	cmd.Short = c.short
+	cmd.Long = cli.FormatSection(i18n.G("Description"), c.short)
+
+	cmd.Flags().StringVar(&c.task.flagTarget, "target", "", i18n.G("Cluster member name")+"``")
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdTaskAction) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.CheckArgs(cmd, args, 1, -1)
+	if exit {
+		return err
+	}
+
+	for _, arg := range args {
+		remoteName, name, err := c.global.conf.ParseRemote(arg)
+		if err != nil {
+			return err
+		}
+
+		remoteServer, err := c.global.conf.GetInstanceServer(remoteName)
+		if err != nil {
+			return err
+		}
+
+		if c.task.flagTarget != "" {
+			remoteServer = remoteServer.UseTarget(c.task.flagTarget)
+		}
+
+		err = remoteServer.UpdateTaskState(name, api.TaskStatePut{Action: c.action})
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+
+	return nil
+}
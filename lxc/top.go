@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lxc/lxd/lxc/utils"
+	"github.com/lxc/lxd/shared/api"
+	cli "github.com/lxc/lxd/shared/cmd"
+	"github.com/lxc/lxd/shared/i18n"
+	"github.com/lxc/lxd/shared/units"
+)
+
+type cmdTop struct {
+	global *cmdGlobal
+
+	flagRefresh int
+}
+
+func (c *cmdTop) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("top", i18n.G("[<remote>:]"))
+	cmd.Short = i18n.G("Show a live view of the instances resource usage")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Show a live view of the instances resource usage
+
+The CPU usage and network rates are computed between two refreshes,
+covering all the instances of the project across the cluster.`))
+
+	cmd.Flags().IntVar(&c.flagRefresh, "refresh", 2, i18n.G("Refresh interval in seconds")+"``")
+
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdTop) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.CheckArgs(cmd, args, 0, 1)
+	if exit {
+		return err
+	}
+
+	if c.flagRefresh < 1 {
+		return fmt.Errorf(i18n.G("The refresh interval must be at least one second"))
+	}
+
+	// Parse remote
+	remote := ""
+	if len(args) == 1 {
+		remote = args[0]
+	}
+
+	resources, err := c.global.ParseServers(remote)
+	if err != nil {
+		return err
+	}
+
+	resource := resources[0]
+	if resource.name != "" {
+		return fmt.Errorf(i18n.G("Filtering isn't supported yet"))
+	}
+
+	previous := map[string]api.InstanceMetrics{}
+	for {
+		metrics, err := resource.server.GetInstancesMetrics()
+		if err != nil {
+			return err
+		}
+
+		// Clear the screen and render the table in place.
+		fmt.Print("\033[H\033[2J")
+		fmt.Printf(i18n.G("Refreshing every %ds, press Ctrl+C to exit")+"\n\n", c.flagRefresh)
+
+		err = c.render(resource.server.IsClustered(), metrics, previous)
+		if err != nil {
+			return err
+		}
+
+		previous = map[string]api.InstanceMetrics{}
+		for _, entry := range metrics {
+			previous[entry.Project+"/"+entry.Name] = entry
+		}
+
+		time.Sleep(time.Duration(c.flagRefresh) * time.Second)
+	}
+}
+
+func (c *cmdTop) render(clustered bool, metrics []api.InstanceMetrics, previous map[string]api.InstanceMetrics) error {
+	type row struct {
+		cpu     float64
+		name    string
+		columns []string
+	}
+
+	rows := []row{}
+	for _, entry := range metrics {
+		cpu := "-"
+		rx := "-"
+		tx := "-"
+		cpuPercent := -1.0
+
+		// Rates need a previous sample of the same instance.
+		prev, ok := previous[entry.Project+"/"+entry.Name]
+		elapsed := entry.Timestamp.Sub(prev.Timestamp).Seconds()
+		if ok && elapsed > 0 && entry.Status == "Running" {
+			cpuPercent = float64(entry.CPUUsage-prev.CPUUsage) / (elapsed * float64(time.Second)) * 100
+			if cpuPercent < 0 {
+				cpuPercent = 0
+			}
+
+			cpu = fmt.Sprintf("%.1f%%", cpuPercent)
+			rx = topRate(entry.NetworkBytesReceived-prev.NetworkBytesReceived, elapsed)
+			tx = topRate(entry.NetworkBytesSent-prev.NetworkBytesSent, elapsed)
+		}
+
+		columns := []string{entry.Name, entry.Status, cpu, units.GetByteSizeString(entry.MemoryUsage, 2), units.GetByteSizeString(entry.DiskUsage, 2), rx, tx, fmt.Sprintf("%d", entry.Processes)}
+		if clustered {
+			columns = append(columns, entry.Location)
+		}
+
+		rows = append(rows, row{cpu: cpuPercent, name: entry.Name, columns: columns})
+	}
+
+	// Busiest instances first.
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].cpu != rows[j].cpu {
+			return rows[i].cpu > rows[j].cpu
+		}
+
+		return rows[i].name < rows[j].name
+	})
+
+	data := [][]string{}
+	for _, r := range rows {
+		data = append(data, r.columns)
+	}
+
+	header := []string{
+		i18n.G("NAME"),
+		i18n.G("STATUS"),
+		i18n.G("CPU"),
+		i18n.G("MEMORY"),
+		i18n.G("DISK"),
+		i18n.G("RX"),
+		i18n.G("TX"),
+		i18n.G("PROCESSES"),
+	}
+
+	if clustered {
+		header = append(header, i18n.G("LOCATION"))
+	}
+
+	return utils.RenderTable(utils.TableFormatTable, header, data, metrics)
+}
+
+// topRate formats the rate of a counter over the elapsed number of seconds.
+func topRate(delta int64, elapsed float64) string {
+	if delta < 0 {
+		delta = 0
+	}
+
+	return units.GetByteSizeString(int64(float64(delta)/elapsed), 2) + "/s"
+}
@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/lxc/lxd/shared/i18n"
+)
+
+// RenderObject renders a single object as YAML or JSON.
+func RenderObject(format string, obj interface{}) error {
+	switch format {
+	case TableFormatYAML:
+		out, err := yaml.Marshal(obj)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("%s", out)
+	case TableFormatJSON:
+		enc := json.NewEncoder(os.Stdout)
+
+		err := enc.Encode(obj)
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf(i18n.G("Invalid format %q"), format)
+	}
+
+	return nil
+}
@@ -3,6 +3,7 @@ package utils
 import (
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -10,6 +11,7 @@ import (
 	"github.com/lxc/lxd/shared/api"
 	"github.com/lxc/lxd/shared/ioprogress"
 	"github.com/lxc/lxd/shared/termios"
+	"github.com/lxc/lxd/shared/units"
 )
 
 // ProgressRenderer tracks the progress information
@@ -22,8 +24,12 @@ type ProgressRenderer struct {
 	done      bool
 	lock      sync.Mutex
 	terminal  int
+	start     time.Time
 }
 
+// progressBarWidth is the number of characters used by the bar itself.
+const progressBarWidth = 20
+
 func (p *ProgressRenderer) truncate(msg string) string {
 	width, _, err := termios.GetSize(int(os.Stdout.Fd()))
 	if err != nil {
@@ -174,6 +180,11 @@ func (p *ProgressRenderer) Warn(status string, timeout time.Duration) {
 
 // UpdateProgress is a helper to update the status using an iopgress instance
 func (p *ProgressRenderer) UpdateProgress(progress ioprogress.ProgressData) {
+	if progress.Percentage > 0 {
+		p.Update(p.bar("", int64(progress.Percentage), progress.TransferredBytes, progress.TotalBytes, progress.Speed))
+		return
+	}
+
 	p.Update(progress.Text)
 }
 
@@ -188,7 +199,97 @@ func (p *ProgressRenderer) UpdateOp(op api.Operation) {
 			continue
 		}
 
-		p.Update(value.(string))
+		text, ok := value.(string)
+		if !ok {
+			continue
+		}
+
+		// Render a bar when the stage reports a percentage.
+		stage, progress := opProgress(op.Metadata["progress"])
+		if stage == strings.TrimSuffix(key, "_progress") && progress["percent"] > 0 {
+			label := ""
+			i := strings.LastIndex(text, ": ")
+			if i > 0 {
+				label = text[:i]
+			}
+
+			text = p.bar(label, progress["percent"], progress["processed"], progress["total"], progress["speed"])
+		}
+
+		p.Update(text)
 		break
 	}
 }
+
+// bar renders a progress bar followed by the transferred size, rate and estimated time remaining.
+func (p *ProgressRenderer) bar(label string, percent int64, processed int64, total int64, speed int64) string {
+	if p.start.IsZero() {
+		p.start = time.Now()
+	}
+
+	if percent > 100 {
+		percent = 100
+	}
+
+	filled := int(percent) * progressBarWidth / 100
+	bar := strings.Repeat("=", filled)
+	if filled < progressBarWidth {
+		bar += ">" + strings.Repeat(" ", progressBarWidth-filled-1)
+	}
+
+	fields := []string{fmt.Sprintf("[%s] %d%%", bar, percent)}
+	if total > 0 {
+		fields = append(fields, fmt.Sprintf("%s/%s", units.GetByteSizeString(processed, 2), units.GetByteSizeString(total, 2)))
+	}
+
+	if speed > 0 {
+		fields = append(fields, fmt.Sprintf("%s/s", units.GetByteSizeString(speed, 2)))
+	}
+
+	// Estimate the remaining time from the rate when the size is known, from the elapsed time otherwise.
+	var eta time.Duration
+	if total > 0 && speed > 0 {
+		eta = time.Duration((total-processed)/speed) * time.Second
+	} else if percent > 0 {
+		elapsed := time.Since(p.start)
+		eta = time.Duration(int64(elapsed) * (100 - percent) / percent)
+	}
+
+	if percent < 100 && eta > 0 {
+		fields = append(fields, fmt.Sprintf("ETA %s", eta.Round(time.Second)))
+	}
+
+	msg := strings.Join(fields, " ")
+	if label != "" {
+		msg = fmt.Sprintf("%s: %s", label, msg)
+	}
+
+	return msg
+}
+
+// opProgress parses the structured "progress" entry of an operation's metadata into its stage and values.
+func opProgress(value interface{}) (string, map[string]int64) {
+	result := map[string]int64{}
+
+	values := map[string]string{}
+	switch v := value.(type) {
+	case map[string]string:
+		values = v
+	case map[string]interface{}:
+		for key, entry := range v {
+			str, ok := entry.(string)
+			if ok {
+				values[key] = str
+			}
+		}
+	}
+
+	for key, str := range values {
+		n, err := strconv.ParseInt(str, 10, 64)
+		if err == nil {
+			result[key] = n
+		}
+	}
+
+	return values["stage"], result
+}
@@ -13,10 +13,11 @@ import (
 
 // Table list format
 const (
-	TableFormatCSV   = "csv"
-	TableFormatJSON  = "json"
-	TableFormatTable = "table"
-	TableFormatYAML  = "yaml"
+	TableFormatCSV     = "csv"
+	TableFormatJSON    = "json"
+	TableFormatTable   = "table"
+	TableFormatYAML    = "yaml"
+	TableFormatCompact = "compact"
 )
 
 // RenderTable renders tabular data in various formats.
@@ -30,6 +31,22 @@ func RenderTable(format string, header []string, data [][]string, raw interface{
 		table.SetHeader(header)
 		table.AppendBulk(data)
 		table.Render()
+	case TableFormatCompact:
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetAutoWrapText(false)
+		table.SetAlignment(tablewriter.ALIGN_LEFT)
+		table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+		table.SetAutoFormatHeaders(false)
+		table.SetBorder(false)
+		table.SetHeaderLine(false)
+		table.SetColumnSeparator("")
+		table.SetCenterSeparator("")
+		table.SetRowSeparator("")
+		table.SetTablePadding("  ")
+		table.SetNoWhiteSpace(true)
+		table.SetHeader(header)
+		table.AppendBulk(data)
+		table.Render()
 	case TableFormatCSV:
 		w := csv.NewWriter(os.Stdout)
 		w.WriteAll(data)
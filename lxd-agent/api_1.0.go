@@ -19,6 +19,8 @@ var api10 = []APIEndpoint{
 	execCmd,
 	eventsCmd,
 	fileCmd,
+	healthcheckCmd,
+	hotplugCmd,
 	operationsCmd,
 	operationCmd,
 	operationWebsocket,
@@ -29,10 +29,23 @@ func fileHandler(d *Daemon, r *http.Request) response.Response {
 		return response.BadRequest(fmt.Errorf("missing path argument"))
 	}
 
+	format := r.FormValue("format")
+	if !shared.StringInSlice(format, []string{"", "tar"}) {
+		return response.BadRequest(fmt.Errorf("Invalid format %q", format))
+	}
+
 	switch r.Method {
 	case "GET":
+		if format == "tar" {
+			return fileTreeGet(path)
+		}
+
 		return fileGet(path, r)
 	case "POST":
+		if format == "tar" {
+			return fileTreePost(path, r)
+		}
+
 		return filePost(path, r)
 	case "DELETE":
 		return fileDelete(path, r)
@@ -59,7 +72,7 @@ func fileGet(path string, r *http.Request) response.Response {
 		files := make([]response.FileResponseEntry, 1)
 		files[0].Identifier = filepath.Base(path)
 
-		f, err := ioutil.TempFile(filepath.Dir(path), "lxd_getfile_")
+		f, err := ioutil.TempFile("", "lxd_getfile_")
 		if err != nil {
 			return response.SmartError(err)
 		}
@@ -220,7 +233,7 @@ func filePush(fType string, srcpath string, dstpath string, uid int64, gid int64
 			}
 
 			if mode == -1 {
-				mode = 0
+				mode = 0640
 			}
 		}
 
@@ -249,6 +262,14 @@ func filePush(fType string, srcpath string, dstpath string, uid int64, gid int64
 			return err
 		}
 
+		// Apply the mode to existing files too, and regardless of the umask.
+		if mode != -1 {
+			err = dst.Chmod(os.FileMode(mode))
+			if err != nil {
+				return err
+			}
+		}
+
 		err = os.Chown(dst.Name(), int(uid), int(gid))
 		if err != nil {
 			return err
@@ -285,7 +306,7 @@ func filePush(fType string, srcpath string, dstpath string, uid int64, gid int64
 		}
 
 		if mode == -1 {
-			mode = 0
+			mode = 0750
 		}
 
 		err := os.MkdirAll(dstpath, os.FileMode(mode))
@@ -293,6 +314,11 @@ func filePush(fType string, srcpath string, dstpath string, uid int64, gid int64
 			return err
 		}
 
+		err = os.Chmod(dstpath, os.FileMode(mode))
+		if err != nil {
+			return err
+		}
+
 		err = os.Chown(dstpath, int(uid), int(gid))
 		if err != nil {
 			return err
@@ -303,3 +329,18 @@ func filePush(fType string, srcpath string, dstpath string, uid int64, gid int64
 
 	return fmt.Errorf("Bad file type: %s", fType)
 }
+
+func fileTreeGet(path string) response.Response {
+	return response.StreamResponse("application/x-tar", func(w io.Writer) error {
+		return shared.TarTree(w, path, nil)
+	})
+}
+
+func fileTreePost(path string, r *http.Request) response.Response {
+	err := shared.UntarTree(r.Body, path, nil)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.EmptySyncResponse
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/lxc/lxd/lxd/healthcheck"
+	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/shared/api"
+)
+
+var healthcheckCmd = APIEndpoint{
+	Name: "healthcheck",
+	Path: "healthcheck",
+
+	Post: APIEndpointAction{Handler: healthcheckPost},
+}
+
+func healthcheckPost(d *Daemon, r *http.Request) response.Response {
+	probe := api.InstanceHealthProbe{}
+
+	err := json.NewDecoder(r.Body).Decode(&probe)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	err = healthcheck.Probe(probe.Type, probe.Address, time.Duration(probe.Timeout)*time.Second)
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	return response.EmptySyncResponse
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/lxc/lxd/lxd/response"
+)
+
+var hotplugCmd = APIEndpoint{
+	Name: "hotplug",
+	Path: "hotplug",
+
+	Post: APIEndpointAction{Handler: hotplugPost},
+}
+
+// hotplugPost brings the CPUs and memory blocks hotplugged by LXD online.
+func hotplugPost(d *Daemon, r *http.Request) response.Response {
+	err := hotplugOnline("/sys/devices/system/cpu/cpu*/online", "0", "1")
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	err = hotplugOnline("/sys/devices/system/memory/memory*/state", "offline", "online")
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	return response.EmptySyncResponse
+}
+
+// hotplugOnline writes the online value to the sysfs files matching the pattern which contain the offline
+// value.
+func hotplugOnline(pattern string, offline string, online string) error {
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			continue
+		}
+
+		if strings.TrimSpace(string(content)) != offline {
+			continue
+		}
+
+		err = ioutil.WriteFile(path, []byte(online), 0644)
+		if err != nil {
+			return fmt.Errorf("Failed onlining %q: %w", filepath.Dir(path), err)
+		}
+	}
+
+	return nil
+}
@@ -92,6 +92,8 @@ func restServer(d *Daemon) *http.Server {
 		response.NotFound(nil).Render(w)
 	})
 
+	d.restAPI = mux
+
 	return &http.Server{Handler: &lxdHttpServer{r: mux, d: d}}
 }
 
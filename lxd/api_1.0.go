@@ -15,6 +15,7 @@ import (
 	instanceDrivers "github.com/lxc/lxd/lxd/instance/drivers"
 	"github.com/lxc/lxd/lxd/lifecycle"
 	"github.com/lxc/lxd/lxd/node"
+	"github.com/lxc/lxd/lxd/operations"
 	"github.com/lxc/lxd/lxd/project"
 	"github.com/lxc/lxd/lxd/rbac"
 	"github.com/lxc/lxd/lxd/request"
@@ -37,6 +38,11 @@ var api10Cmd = APIEndpoint{
 var api10 = []APIEndpoint{
 	api10Cmd,
 	api10ResourcesCmd,
+	auditCmd,
+	auditExportCmd,
+	authTokenCmd,
+	authTokensCmd,
+	batchCmd,
 	certificateCmd,
 	certificatesCmd,
 	clusterCmd,
@@ -47,19 +53,31 @@ var api10 = []APIEndpoint{
 	instanceBackupCmd,
 	instanceBackupExportCmd,
 	instanceBackupsCmd,
+	instancesStateCmd, // Must come before instanceCmd as it would otherwise match it.
 	instanceCmd,
 	instanceConsoleCmd,
 	instanceExecCmd,
+	instanceExecRecordingCmd,
+	instanceExecRecordingsCmd,
 	instanceFileCmd,
+	instanceConvertCmd,
+	instanceFlattenCmd,
 	instanceLogCmd,
 	instanceLogsCmd,
 	instanceMetadataCmd,
 	instanceMetadataTemplatesCmd,
+	instanceMetricsCmd,
+	instanceNVRAMCmd,
+	instanceRebuildCmd,
 	instancesCmd,
 	instanceSnapshotCmd,
 	instanceSnapshotsCmd,
 	instanceStateCmd,
+	instanceTemplateCmd,
+	instanceTemplateInstancesCmd,
+	instanceTemplatesCmd,
 	eventsCmd,
+	idmapAllocationsCmd,
 	imageAliasCmd,
 	imageAliasesCmd,
 	imageCmd,
@@ -67,6 +85,8 @@ var api10 = []APIEndpoint{
 	imageRefreshCmd,
 	imagesCmd,
 	imageSecretCmd,
+	metricsCmd,
+	metricsInstancesCmd,
 	networkCmd,
 	networkLeasesCmd,
 	networksCmd,
@@ -75,15 +95,22 @@ var api10 = []APIEndpoint{
 	networkACLsCmd,
 	networkForwardCmd,
 	networkForwardsCmd,
+	networkLoadBalancerCmd,
+	networkLoadBalancersCmd,
+	operationsWaitCmd, // Must come before operationCmd as it would otherwise match it.
 	operationCmd,
 	operationsCmd,
 	operationWait,
 	operationWebsocket,
+	preseedCmd,
 	profileCmd,
 	profilesCmd,
 	projectCmd,
 	projectsCmd,
 	projectStateCmd,
+	projectExportCmd,
+	secretCmd,
+	secretsCmd,
 	storagePoolCmd,
 	storagePoolResourcesCmd,
 	storagePoolsCmd,
@@ -98,6 +125,9 @@ var api10 = []APIEndpoint{
 	storagePoolVolumeTypeStateCmd,
 	warningsCmd,
 	warningCmd,
+	tasksCmd,
+	taskCmd,
+	taskStateCmd,
 }
 
 // swagger:operation GET /1.0?public server server_get_untrusted
@@ -139,13 +169,14 @@ var api10 = []APIEndpoint{
 
 // swagger:operation GET /1.0 server server_get
 //
-// Get the server environment and configuration
+// # Get the server environment and configuration
 //
 // Shows the full server environment and configuration.
 //
 // ---
 // produces:
 //   - application/json
+//
 // parameters:
 //   - in: query
 //     name: target
@@ -157,31 +188,37 @@ var api10 = []APIEndpoint{
 //     description: Project name
 //     type: string
 //     example: default
+//
 // responses:
-//   "200":
-//     description: Server environment and configuration
-//     schema:
-//       type: object
-//       description: Sync response
-//       properties:
-//         type:
-//           type: string
-//           description: Response type
-//           example: sync
-//         status:
-//           type: string
-//           description: Status description
-//           example: Success
-//         status_code:
-//           type: integer
-//           description: Status code
-//           example: 200
-//         metadata:
-//           $ref: "#/definitions/Server"
-//   "500":
-//     $ref: "#/responses/InternalServerError"
+//
+//	"200":
+//	  description: Server environment and configuration
+//	  schema:
+//	    type: object
+//	    description: Sync response
+//	    properties:
+//	      type:
+//	        type: string
+//	        description: Response type
+//	        example: sync
+//	      status:
+//	        type: string
+//	        description: Status description
+//	        example: Success
+//	      status_code:
+//	        type: integer
+//	        description: Status code
+//	        example: 200
+//	      metadata:
+//	        $ref: "#/definitions/Server"
+//	"500":
+//	  $ref: "#/responses/InternalServerError"
 func api10Get(d *Daemon, r *http.Request) response.Response {
-	authMethods := []string{"tls"}
+	authMethods := []string{"tls", "token"}
+	if d.oidcAuth != nil {
+		authMethods = append(authMethods, "oidc")
+	}
+
 	err := d.cluster.Transaction(func(tx *db.ClusterTx) error {
 		config, err := cluster.ConfigLoad(tx)
 		if err != nil {
@@ -288,22 +325,24 @@ func api10Get(d *Daemon, r *http.Request) response.Response {
 	}
 
 	env := api.ServerEnvironment{
-		Addresses:              addresses,
-		Architectures:          architectures,
-		Certificate:            certificate,
-		CertificateFingerprint: certificateFingerprint,
-		Kernel:                 uname.Sysname,
-		KernelArchitecture:     uname.Machine,
-		KernelVersion:          uname.Release,
-		OSName:                 osInfo["NAME"],
-		OSVersion:              osInfo["VERSION_ID"],
-		Project:                projectName,
-		Server:                 "lxd",
-		ServerPid:              os.Getpid(),
-		ServerVersion:          version.Version,
-		ServerClustered:        clustered,
-		ServerName:             serverName,
-		Firewall:               fmt.Sprintf("%s", d.firewall),
+		Addresses:                  addresses,
+		Architectures:              architectures,
+		Certificate:                certificate,
+		CertificateFingerprint:     certificateFingerprint,
+		Kernel:                     uname.Sysname,
+		KernelArchitecture:         uname.Machine,
+		KernelVersion:              uname.Release,
+		OSName:                     osInfo["NAME"],
+		OSVersion:                  osInfo["VERSION_ID"],
+		Project:                    projectName,
+		Server:                     "lxd",
+		ServerPid:                  os.Getpid(),
+		ServerRootless:             d.config.Rootless,
+		ServerRootlessRestrictions: rootlessRestrictions(d.config),
+		ServerVersion:              version.Version,
+		ServerClustered:            clustered,
+		ServerName:                 serverName,
+		Firewall:                   fmt.Sprintf("%s", d.firewall),
 	}
 
 	env.KernelFeatures = map[string]string{
@@ -313,6 +352,7 @@ func api10Get(d *Daemon, r *http.Request) response.Response {
 		"seccomp_listener":          fmt.Sprintf("%v", d.os.SeccompListener),
 		"seccomp_listener_continue": fmt.Sprintf("%v", d.os.SeccompListenerContinue),
 		"shiftfs":                   fmt.Sprintf("%v", d.os.Shiftfs),
+		"time_namespace":            fmt.Sprintf("%v", d.os.TimeNamespace),
 	}
 
 	for _, driverInfo := range instanceDrivers.SupportedInstanceTypes() {
@@ -370,15 +410,17 @@ func api10Get(d *Daemon, r *http.Request) response.Response {
 
 // swagger:operation PUT /1.0 server server_put
 //
-// Update the server configuration
+// # Update the server configuration
 //
 // Updates the entire server configuration.
 //
 // ---
 // consumes:
 //   - application/json
+//
 // produces:
 //   - application/json
+//
 // parameters:
 //   - in: query
 //     name: target
@@ -390,18 +432,20 @@ func api10Get(d *Daemon, r *http.Request) response.Response {
 //     description: Server configuration
 //     required: true
 //     schema:
-//       $ref: "#/definitions/ServerPut"
+//     $ref: "#/definitions/ServerPut"
+//
 // responses:
-//   "200":
-//     $ref: "#/responses/EmptySyncResponse"
-//   "400":
-//     $ref: "#/responses/BadRequest"
-//   "403":
-//     $ref: "#/responses/Forbidden"
-//   "412":
-//     $ref: "#/responses/PreconditionFailed"
-//   "500":
-//     $ref: "#/responses/InternalServerError"
+//
+//	"200":
+//	  $ref: "#/responses/EmptySyncResponse"
+//	"400":
+//	  $ref: "#/responses/BadRequest"
+//	"403":
+//	  $ref: "#/responses/Forbidden"
+//	"412":
+//	  $ref: "#/responses/PreconditionFailed"
+//	"500":
+//	  $ref: "#/responses/InternalServerError"
 func api10Put(d *Daemon, r *http.Request) response.Response {
 	// If a target was specified, forward the request to the relevant node.
 	resp := forwardedResponseIfTargetIsRemote(d, r)
@@ -453,15 +497,17 @@ func api10Put(d *Daemon, r *http.Request) response.Response {
 
 // swagger:operation PATCH /1.0 server server_patch
 //
-// Partially update the server configuration
+// # Partially update the server configuration
 //
 // Updates a subset of the server configuration.
 //
 // ---
 // consumes:
 //   - application/json
+//
 // produces:
 //   - application/json
+//
 // parameters:
 //   - in: query
 //     name: target
@@ -473,18 +519,20 @@ func api10Put(d *Daemon, r *http.Request) response.Response {
 //     description: Server configuration
 //     required: true
 //     schema:
-//       $ref: "#/definitions/ServerPut"
+//     $ref: "#/definitions/ServerPut"
+//
 // responses:
-//   "200":
-//     $ref: "#/responses/EmptySyncResponse"
-//   "400":
-//     $ref: "#/responses/BadRequest"
-//   "403":
-//     $ref: "#/responses/Forbidden"
-//   "412":
-//     $ref: "#/responses/PreconditionFailed"
-//   "500":
-//     $ref: "#/responses/InternalServerError"
+//
+//	"200":
+//	  $ref: "#/responses/EmptySyncResponse"
+//	"400":
+//	  $ref: "#/responses/BadRequest"
+//	"403":
+//	  $ref: "#/responses/Forbidden"
+//	"412":
+//	  $ref: "#/responses/PreconditionFailed"
+//	"500":
+//	  $ref: "#/responses/InternalServerError"
 func api10Patch(d *Daemon, r *http.Request) response.Response {
 	// If a target was specified, forward the request to the relevant node.
 	resp := forwardedResponseIfTargetIsRemote(d, r)
@@ -566,6 +614,13 @@ func doApi10Update(d *Daemon, r *http.Request, req api.ServerPut, patch bool) re
 			}
 		}
 
+		if nodeValues["storage.recordings_volume"] != nil && nodeValues["storage.recordings_volume"] != newNodeConfig.StorageRecordingsVolume() {
+			err := daemonStorageValidate(s, nodeValues["storage.recordings_volume"].(string))
+			if err != nil {
+				return err
+			}
+		}
+
 		if patch {
 			nodeChanged, err = newNodeConfig.Patch(nodeValues)
 		} else {
@@ -670,11 +725,24 @@ func doApi10UpdateTriggers(d *Daemon, nodeChanged, clusterChanged map[string]str
 	candidChanged := false
 	rbacChanged := false
 	bgpChanged := false
+	logLevelsChanged := false
+	auditChanged := false
+	lokiChanged := false
+	syslogChanged := false
+	oidcChanged := false
 
 	for key := range clusterChanged {
 		switch key {
 		case "core.https_trusted_proxy":
 			d.endpoints.NetworkUpdateTrustedProxy(clusterChanged[key])
+		case "core.trust_ca_crl_url":
+			err := d.setupCRL(clusterChanged[key])
+			if err != nil {
+				return err
+			}
+		case "core.trust_ca_ocsp":
+			_, ocsp := clusterConfig.TrustCARevocation()
+			d.revocation.SetOCSP(ocsp)
 		case "core.proxy_http":
 			fallthrough
 		case "core.proxy_https":
@@ -695,6 +763,12 @@ func doApi10UpdateTriggers(d *Daemon, nodeChanged, clusterChanged map[string]str
 			candidChanged = true
 		case "cluster.images_minimal_replica":
 			autoSyncImages(d.ctx, d)
+		case "core.rate_limit_requests":
+			fallthrough
+		case "core.rate_limit_websockets":
+			d.rateLimiter.SetLimits(clusterConfig.RateLimits())
+		case "core.max_heavy_operations":
+			operations.SetHeavyOperationsLimit(int(clusterConfig.MaxHeavyOperations()))
 		case "cluster.offline_threshold":
 			d.gateway.HeartbeatOfflineThreshold = clusterConfig.OfflineThreshold()
 			d.taskClusterHeartbeat.Reset()
@@ -704,6 +778,10 @@ func doApi10UpdateTriggers(d *Daemon, nodeChanged, clusterChanged map[string]str
 			if !d.os.MockMode {
 				d.taskPruneImages.Reset()
 			}
+		case "instances.usage_interval":
+			if !d.os.MockMode {
+				d.taskInstanceUsage.Reset()
+			}
 		case "rbac.agent.url":
 			fallthrough
 		case "rbac.agent.username":
@@ -720,13 +798,49 @@ func doApi10UpdateTriggers(d *Daemon, nodeChanged, clusterChanged map[string]str
 			rbacChanged = true
 		case "core.bgp_asn":
 			bgpChanged = true
+		case "loki.api.url":
+			fallthrough
+		case "loki.auth.username":
+			fallthrough
+		case "loki.auth.password":
+			fallthrough
+		case "loki.loglevel":
+			lokiChanged = true
+		case "syslog.address":
+			fallthrough
+		case "syslog.loglevel":
+			syslogChanged = true
+		case "oidc.audience":
+			fallthrough
+		case "oidc.client.id":
+			fallthrough
+		case "oidc.groups.admin":
+			fallthrough
+		case "oidc.groups.claim":
+			fallthrough
+		case "oidc.groups.projects":
+			fallthrough
+		case "oidc.issuer":
+			oidcChanged = true
 		}
 	}
 
 	for key := range nodeChanged {
 		switch key {
+		case "core.log_level_db":
+			fallthrough
+		case "core.log_level_migration":
+			fallthrough
+		case "core.log_level_network":
+			fallthrough
+		case "core.log_level_storage":
+			logLevelsChanged = true
 		case "maas.machine":
 			maasChanged = true
+		case "core.audit_log_max_files":
+			fallthrough
+		case "core.audit_log_max_size":
+			auditChanged = true
 		case "core.bgp_address":
 			fallthrough
 		case "core.bgp_routerid":
@@ -765,6 +879,19 @@ func doApi10UpdateTriggers(d *Daemon, nodeChanged, clusterChanged map[string]str
 		}
 	}
 
+	_, ok = nodeChanged["core.https_listeners"]
+	if ok {
+		listeners, err := d.loadListeners(nodeConfig.HTTPSListeners())
+		if err != nil {
+			return err
+		}
+
+		err = d.endpoints.ListenersUpdate(listeners)
+		if err != nil {
+			return err
+		}
+	}
+
 	value, ok = nodeChanged["storage.backups_volume"]
 	if ok {
 		err := daemonStorageMove(s, "backups", value)
@@ -781,6 +908,53 @@ func doApi10UpdateTriggers(d *Daemon, nodeChanged, clusterChanged map[string]str
 		}
 	}
 
+	value, ok = nodeChanged["storage.recordings_volume"]
+	if ok {
+		err := daemonStorageMove(s, "recordings", value)
+		if err != nil {
+			return err
+		}
+	}
+
+	if logLevelsChanged {
+		err := daemonConfigSetLogLevels(nodeConfig)
+		if err != nil {
+			return err
+		}
+	}
+
+	if auditChanged && d.audit != nil {
+		err := d.audit.SetRetention(nodeConfig.AuditLogRetention())
+		if err != nil {
+			return err
+		}
+	}
+
+	if lokiChanged {
+		url, username, password, level := clusterConfig.LokiServer()
+		err := d.setupLokiClient(url, username, password, level)
+		if err != nil {
+			return err
+		}
+	}
+
+	if syslogChanged {
+		address, level := clusterConfig.SyslogServer()
+		err := d.setupSyslogClient(address, level)
+		if err != nil {
+			return err
+		}
+	}
+
+	if oidcChanged {
+		issuer, clientID, audience, groupsClaim := clusterConfig.OIDCServer()
+		groupsAdmin, groupsProjects := clusterConfig.OIDCGroups()
+		err := d.setupOIDC(issuer, clientID, audience, groupsClaim, groupsAdmin, groupsProjects)
+		if err != nil {
+			return err
+		}
+	}
+
 	if maasChanged {
 		url, key := clusterConfig.MAASController()
 		machine := nodeConfig.MAASMachine()
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/shared/api"
+)
+
+var auditCmd = APIEndpoint{
+	Path: "audit",
+
+	Get: APIEndpointAction{Handler: auditGet},
+}
+
+var auditExportCmd = APIEndpoint{
+	Path: "audit/export",
+
+	Get: APIEndpointAction{Handler: auditExportGet},
+}
+
+// swagger:operation GET /1.0/audit audit audit_get
+//
+// Get the audit log
+//
+// Returns the state-changing API requests handled by this server.
+//
+// ---
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: since
+//     description: Only return requests received at or after this time (RFC3339)
+//     type: string
+//     example: 2021-03-14T00:00:00Z
+//   - in: query
+//     name: username
+//     description: Only return requests made by this user
+//     type: string
+//     example: root
+//   - in: query
+//     name: limit
+//     description: Maximum number of entries to return, oldest first
+//     type: integer
+//     example: 100
+// responses:
+//   "200":
+//     description: API endpoints
+//     schema:
+//       type: object
+//       description: Sync response
+//       properties:
+//         type:
+//           type: string
+//           description: Response type
+//           example: sync
+//         status:
+//           type: string
+//           description: Status description
+//           example: Success
+//         status_code:
+//           type: integer
+//           description: Status code
+//           example: 200
+//         metadata:
+//           type: array
+//           description: List of audit log entries
+//           items:
+//             $ref: "#/definitions/AuditEntry"
+//   "400":
+//     $ref: "#/responses/BadRequest"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func auditGet(d *Daemon, r *http.Request) response.Response {
+	var since time.Time
+
+	sinceStr := queryParam(r, "since")
+	if sinceStr != "" {
+		var err error
+		since, err = time.Parse(time.RFC3339, sinceStr)
+		if err != nil {
+			return response.BadRequest(fmt.Errorf("Invalid since value %q: %w", sinceStr, err))
+		}
+	}
+
+	limit := -1
+	limitStr := queryParam(r, "limit")
+	if limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 0 {
+			return response.BadRequest(fmt.Errorf("Invalid limit value %q", limitStr))
+		}
+	}
+
+	username := queryParam(r, "username")
+
+	entries := []api.AuditEntry{}
+	err := d.audit.Walk(since, func(entry api.AuditEntry) bool {
+		if limit >= 0 && len(entries) >= limit {
+			return false
+		}
+
+		if username != "" && (entry.Requestor == nil || entry.Requestor.Username != username) {
+			return true
+		}
+
+		entries = append(entries, entry)
+		return true
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponse(true, entries)
+}
+
+// swagger:operation GET /1.0/audit/export audit audit_export_get
+//
+// Export the audit log
+//
+// Returns the raw audit log of this server, including its rotated entries, one JSON encoded entry per line.
+//
+// ---
+// produces:
+//   - application/octet-stream
+// responses:
+//   "200":
+//     description: Raw audit log
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func auditExportGet(d *Daemon, r *http.Request) response.Response {
+	return response.StreamResponse("application/octet-stream", d.audit.Export)
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/version"
+)
+
+var batchCmd = APIEndpoint{
+	Path: "batch",
+
+	Post: APIEndpointAction{Handler: batchPost, AccessHandler: allowAuthenticated},
+}
+
+// batchResponseWriter is a http.ResponseWriter recording the response of a batched request.
+type batchResponseWriter struct {
+	header     http.Header
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (w *batchResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *batchResponseWriter) Write(data []byte) (int, error) {
+	if w.statusCode == 0 {
+		w.statusCode = http.StatusOK
+	}
+
+	return w.body.Write(data)
+}
+
+func (w *batchResponseWriter) WriteHeader(statusCode int) {
+	if w.statusCode == 0 {
+		w.statusCode = statusCode
+	}
+}
+
+// swagger:operation POST /1.0/batch server batch_post
+//
+// Run a batch of requests
+//
+// Runs the provided API requests in order and returns their responses.
+// Each request is authenticated and authorized like a standalone request.
+//
+// ---
+// consumes:
+//   - application/json
+// produces:
+//   - application/json
+// parameters:
+//   - in: body
+//     name: batch
+//     description: Batch of requests
+//     required: true
+//     schema:
+//       $ref: "#/definitions/BatchPost"
+// responses:
+//   "200":
+//     description: Batch results
+//     schema:
+//       type: object
+//       description: Sync response
+//       properties:
+//         type:
+//           type: string
+//           description: Response type
+//           example: sync
+//         status:
+//           type: string
+//           description: Status description
+//           example: Success
+//         status_code:
+//           type: integer
+//           description: Status code
+//           example: 200
+//         metadata:
+//           type: array
+//           description: List of results
+//           items:
+//             $ref: "#/definitions/BatchResult"
+//   "400":
+//     $ref: "#/responses/BadRequest"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func batchPost(d *Daemon, r *http.Request) response.Response {
+	req := api.BatchPost{}
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	// Validate all the requests before running any of them.
+	reqURLs := make([]*url.URL, 0, len(req.Requests))
+	for i, batchReq := range req.Requests {
+		if !shared.StringInSlice(batchReq.Method, []string{"GET", "POST", "PUT", "PATCH", "DELETE"}) {
+			return response.BadRequest(fmt.Errorf("Invalid method %q for request %d", batchReq.Method, i))
+		}
+
+		reqURL, err := url.ParseRequestURI(batchReq.URL)
+		if err != nil {
+			return response.BadRequest(fmt.Errorf("Invalid URL for request %d: %w", i, err))
+		}
+
+		apiPrefix := fmt.Sprintf("/%s", version.APIVersion)
+		if reqURL.Path != apiPrefix && !strings.HasPrefix(reqURL.Path, apiPrefix+"/") {
+			return response.BadRequest(fmt.Errorf("Invalid URL %q for request %d", batchReq.URL, i))
+		}
+
+		// Batches can't be nested and streaming endpoints never complete.
+		if shared.StringInSlice(reqURL.Path, []string{apiPrefix + "/batch", apiPrefix + "/events"}) {
+			return response.BadRequest(fmt.Errorf("Endpoint %q can't be used in a batch", reqURL.Path))
+		}
+
+		reqURLs = append(reqURLs, reqURL)
+	}
+
+	results := []api.BatchResult{}
+	for i, batchReq := range req.Requests {
+		var body []byte
+		if batchReq.Body != nil {
+			body, err = json.Marshal(batchReq.Body)
+			if err != nil {
+				return response.BadRequest(err)
+			}
+		}
+
+		// Run the request through the API router using the credentials of the batch request.
+		subReq := r.Clone(r.Context())
+		subReq.Method = batchReq.Method
+		subReq.URL = reqURLs[i]
+		subReq.RequestURI = batchReq.URL
+		subReq.Body = ioutil.NopCloser(bytes.NewReader(body))
+		subReq.ContentLength = int64(len(body))
+		subReq.Form = nil
+		subReq.PostForm = nil
+		subReq.Header.Del("Content-Length")
+		subReq.Header.Del("If-Match")
+
+		w := &batchResponseWriter{header: http.Header{}}
+		d.restAPI.ServeHTTP(w, subReq)
+
+		result := api.BatchResult{StatusCode: w.statusCode}
+		err = json.Unmarshal(w.body.Bytes(), &result.Response)
+		if err != nil {
+			result.Response = api.Response{
+				Type:  api.ErrorResponse,
+				Code:  http.StatusInternalServerError,
+				Error: "Endpoint didn't return an API response",
+			}
+		}
+
+		results = append(results, result)
+
+		if req.StopOnError && (result.StatusCode >= http.StatusBadRequest || result.Response.Type == api.ErrorResponse) {
+			break
+		}
+	}
+
+	return response.SyncResponse(true, results)
+}
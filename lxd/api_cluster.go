@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"encoding/pem"
 	"fmt"
@@ -30,6 +31,7 @@ import (
 	"github.com/lxc/lxd/lxd/request"
 	"github.com/lxc/lxd/lxd/response"
 	"github.com/lxc/lxd/lxd/revert"
+	"github.com/lxc/lxd/lxd/secrets"
 	"github.com/lxc/lxd/lxd/util"
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/api"
@@ -1755,6 +1757,11 @@ func clusterCertificatePut(d *Daemon, r *http.Request) response.Response {
 		return response.BadRequest(fmt.Errorf("Private key must be base64 encoded PEM key: %v", err))
 	}
 
+	keypair, err := tls.X509KeyPair(certBytes, keyBytes)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
 	// First node forwards request to all other cluster nodes
 	if !isClusterNotification(r) {
 		servers, err := d.gateway.NodeStore().Get(context.Background())
@@ -1784,6 +1791,27 @@ func clusterCertificatePut(d *Daemon, r *http.Request) response.Response {
 		}
 	}
 
+	// Re-encrypt the secrets with the key derived from the new certificate. This is done only once,
+	// by the member that received the request, as the database is shared by the whole cluster.
+	if !isClusterNotification(r) {
+		oldKey, err := secrets.Key(d.endpoints.NetworkCert().KeyPair())
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		newKey, err := secrets.Key(keypair)
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
+			return secretsReencrypt(tx, oldKey, newKey)
+		})
+		if err != nil {
+			return response.SmartError(err)
+		}
+	}
+
 	err = util.WriteCert(d.os.VarDir, "cluster", certBytes, keyBytes, nil)
 	if err != nil {
 		return response.SmartError(err)
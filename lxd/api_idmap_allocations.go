@@ -0,0 +1,71 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/shared/api"
+)
+
+var idmapAllocationsCmd = APIEndpoint{
+	Path: "idmap-allocations",
+
+	Get: APIEndpointAction{Handler: idmapAllocationsGet},
+}
+
+// swagger:operation GET /1.0/idmap-allocations idmap-allocations idmap_allocations_get
+//
+// Get the idmap allocations
+//
+// Returns the ranges of host uids/gids allocated to the instances with an isolated idmap across the cluster.
+//
+// ---
+// produces:
+//   - application/json
+// responses:
+//   "200":
+//     description: API endpoints
+//     schema:
+//       type: object
+//       description: Sync response
+//       properties:
+//         type:
+//           type: string
+//           description: Response type
+//           example: sync
+//         status:
+//           type: string
+//           description: Status description
+//           example: Success
+//         status_code:
+//           type: integer
+//           description: Status code
+//           example: 200
+//         metadata:
+//           type: array
+//           description: List of idmap allocations
+//           items:
+//             $ref: "#/definitions/IdmapAllocation"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func idmapAllocationsGet(d *Daemon, r *http.Request) response.Response {
+	var allocations []db.IdmapAllocation
+	err := d.cluster.Transaction(func(tx *db.ClusterTx) error {
+		var err error
+		allocations, err = tx.GetIdmapAllocations()
+		return err
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	resp := make([]api.IdmapAllocation, 0, len(allocations))
+	for _, allocation := range allocations {
+		resp = append(resp, allocation.ToAPI())
+	}
+
+	return response.SyncResponse(true, resp)
+}
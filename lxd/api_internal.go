@@ -12,6 +12,7 @@ import (
 	"runtime"
 	runtimeDebug "runtime/debug"
 	"strconv"
+	"time"
 	"strings"
 
 	"github.com/gorilla/mux"
@@ -232,6 +233,16 @@ func internalWaitReady(d *Daemon, r *http.Request) response.Response {
 }
 
 func internalShutdown(d *Daemon, r *http.Request) response.Response {
+	timeout := queryParam(r, "timeout")
+	if timeout != "" {
+		seconds, err := strconv.Atoi(timeout)
+		if err != nil || seconds < 0 {
+			return response.BadRequest(fmt.Errorf("Invalid timeout %q", timeout))
+		}
+
+		d.shutdownTimeout = time.Duration(seconds) * time.Second
+	}
+
 	d.shutdownChan <- struct{}{}
 
 	force := queryParam(r, "force")
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/lxc/lxd/lxd/cluster"
+	"github.com/lxc/lxd/lxd/config"
+	deviceConfig "github.com/lxc/lxd/lxd/device/config"
+	"github.com/lxc/lxd/lxd/instance"
+	"github.com/lxc/lxd/lxd/instance/instancetype"
+	"github.com/lxc/lxd/lxd/network"
+	"github.com/lxc/lxd/lxd/node"
+	"github.com/lxc/lxd/lxd/project"
+	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/lxd/state"
+	storageDrivers "github.com/lxc/lxd/lxd/storage/drivers"
+	"github.com/lxc/lxd/shared"
+)
+
+// Define API endpoints for preseed actions.
+var internalPreseedValidateCmd = APIEndpoint{
+	Path: "preseed/validate",
+
+	Post: APIEndpointAction{Handler: internalPreseedValidate},
+}
+
+// init preseed adds API endpoints to handler slice.
+func init() {
+	apiInternal = append(apiInternal, internalPreseedValidateCmd)
+}
+
+// internalPreseedValidateResult returns the result of the preseed validation.
+type internalPreseedValidateResult struct {
+	Errors []string `json:"errors" yaml:"errors"` // Problems found in the preseed.
+}
+
+// internalPreseedValidate validates the node-specific entities of a preseed without applying anything.
+func internalPreseedValidate(d *Daemon, r *http.Request) response.Response {
+	req := initDataNode{}
+
+	// Parse the request.
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	res := internalPreseedValidateResult{
+		Errors: internalPreseedValidateNode(d.State(), req),
+	}
+
+	return response.SyncResponse(true, &res)
+}
+
+// internalPreseedValidateNode returns all the problems found in the preseed. Profile devices using storage pools
+// or networks which are defined by the preseed itself can only be partially validated as those don't exist yet.
+func internalPreseedValidateNode(s *state.State, preseed initDataNode) []string {
+	problems := []string{}
+	fail := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	// Validate the server configuration, split between the local and the cluster-wide keys.
+	nodeValues := map[string]string{}
+	clusterValues := map[string]string{}
+	for key, value := range preseed.Config {
+		_, ok := node.ConfigSchema[key]
+		if ok {
+			nodeValues[key] = fmt.Sprintf("%v", value)
+		} else {
+			clusterValues[key] = fmt.Sprintf("%v", value)
+		}
+	}
+
+	for _, err := range []error{
+		internalPreseedValidateConfig(node.ConfigSchema, nodeValues),
+		internalPreseedValidateConfig(cluster.ConfigSchema, clusterValues),
+	} {
+		errs, ok := err.(config.ErrorList)
+		if ok {
+			for _, err := range errs {
+				fail("Server configuration: %v", err)
+			}
+		} else if err != nil {
+			fail("Server configuration: %v", err)
+		}
+	}
+
+	// Validate the storage pools.
+	supportedDrivers := []string{}
+	for _, driver := range storageDrivers.SupportedDrivers(s) {
+		supportedDrivers = append(supportedDrivers, driver.Name)
+	}
+
+	pools := map[string]bool{}
+	for _, pool := range preseed.StoragePools {
+		if pools[pool.Name] {
+			fail("Storage pool %q: Defined more than once", pool.Name)
+			continue
+		}
+
+		if pool.Name != "" {
+			pools[pool.Name] = true
+		}
+
+		if pool.Driver == "" {
+			fail("Storage pool %q: No driver provided", pool.Name)
+			continue
+		}
+
+		if !shared.StringInSlice(pool.Driver, supportedDrivers) {
+			fail("Storage pool %q: Storage driver %q isn't supported by this server", pool.Name, pool.Driver)
+			continue
+		}
+
+		err := storagePoolValidate(pool.Name, pool.Driver, pool.Config)
+		if err != nil {
+			fail("Storage pool %q: %v", pool.Name, err)
+		}
+	}
+
+	// Validate the networks.
+	networks := map[string]bool{}
+	for _, n := range preseed.Networks {
+		projectName := n.Project
+		if projectName == "" {
+			projectName = project.Default
+		}
+
+		req := n.NetworksPost
+		if req.Type == "" {
+			if projectName != project.Default {
+				req.Type = "ovn" // Only OVN networks are allowed inside network enabled projects.
+			} else {
+				req.Type = "bridge"
+			}
+		}
+
+		if req.Config == nil {
+			req.Config = map[string]string{}
+		}
+
+		err := network.Validate(s, projectName, req)
+		if err != nil {
+			fail("Network %q in project %q: %v", req.Name, projectName, err)
+		}
+
+		if projectName == project.Default && req.Name != "" {
+			networks[req.Name] = true
+		}
+	}
+
+	// Validate the profiles.
+	for _, profile := range preseed.Profiles {
+		if profile.Name == "" || strings.Contains(profile.Name, "/") || shared.StringInSlice(profile.Name, []string{".", ".."}) {
+			fail("Profile %q: Invalid profile name", profile.Name)
+		}
+
+		err := instance.ValidConfig(s.OS, profile.Config, false, instancetype.Any)
+		if err != nil {
+			fail("Profile %q: %v", profile.Name, err)
+		}
+
+		for name, device := range profile.Devices {
+			if device["type"] == "" {
+				fail("Profile %q: Device %q: Missing device type", profile.Name, name)
+				continue
+			}
+
+			// The pool or network the device uses will only be created when applying the preseed.
+			if pools[device["pool"]] || networks[device["network"]] || networks[device["parent"]] {
+				continue
+			}
+
+			devices := deviceConfig.NewDevices(map[string]map[string]string{name: device})
+			err := instance.ValidDevices(s, s.Cluster, project.Default, instancetype.Any, devices, false)
+			if err != nil {
+				fail("Profile %q: %v", profile.Name, err)
+			}
+		}
+	}
+
+	// Validate the projects.
+	for _, p := range preseed.Projects {
+		err := projectValidateName(p.Name)
+		if err != nil {
+			fail("Project %q: %v", p.Name, err)
+			continue
+		}
+
+		err = projectValidateConfig(s, p.Name, p.Config)
+		if err != nil {
+			fail("Project %q: %v", p.Name, err)
+		}
+	}
+
+	return problems
+}
+
+// internalPreseedValidateConfig checks the configuration values against the schema, returning a config.ErrorList.
+func internalPreseedValidateConfig(schema config.Schema, values map[string]string) error {
+	_, err := config.Load(schema, values)
+	return err
+}
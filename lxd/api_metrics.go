@@ -0,0 +1,256 @@
+package main
+
+import (
+	"net/http"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/lxc/lxd/client"
+	"github.com/lxc/lxd/lxd/cluster"
+	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/instance"
+	"github.com/lxc/lxd/lxd/instance/instancetype"
+	"github.com/lxc/lxd/lxd/metrics"
+	"github.com/lxc/lxd/lxd/operations"
+	"github.com/lxc/lxd/lxd/rbac"
+	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/shared/api"
+	log "github.com/lxc/lxd/shared/log15"
+	"github.com/lxc/lxd/shared/logger"
+)
+
+var metricsCmd = APIEndpoint{
+	Path: "metrics",
+
+	Get: APIEndpointAction{Handler: metricsGet, AccessHandler: allowAuthenticated},
+}
+
+var metricsInstancesCmd = APIEndpoint{
+	Path: "metrics/instances",
+
+	Get: APIEndpointAction{Handler: metricsInstancesGet, AccessHandler: allowProjectPermission("containers", "view")},
+}
+
+// swagger:operation GET /1.0/metrics metrics metrics_get
+//
+// Get metrics
+//
+// Gets metrics of the instances running on this server and of the daemon itself,
+// in the Prometheus text exposition format.
+//
+// ---
+// produces:
+//   - text/plain
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name (defaults to all projects the client has access to)
+//     type: string
+//     example: default
+// responses:
+//   "200":
+//     description: Metrics
+//     schema:
+//       type: string
+//       description: Instance and daemon metrics
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func metricsGet(d *Daemon, r *http.Request) response.Response {
+	projectName := queryParam(r, "project")
+
+	out := metrics.NewMetricSet(nil)
+
+	// Gather the metrics of the instances running on this member.
+	instances, err := instance.LoadNodeAll(d.State(), instancetype.Any)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	instancesByStatus := map[string]int{}
+	for _, inst := range instances {
+		if projectName != "" && inst.Project() != projectName {
+			continue
+		}
+
+		// Only include instances from projects the client can view.
+		if !rbac.UserHasPermission(r, inst.Project(), "view") {
+			continue
+		}
+
+		instancesByStatus[inst.State()]++
+
+		if !inst.IsRunning() {
+			continue
+		}
+
+		state, err := inst.RenderState()
+		if err != nil {
+			logger.Warn("Failed getting instance state for metrics", log.Ctx{"project": inst.Project(), "instance": inst.Name(), "err": err})
+			continue
+		}
+
+		labels := map[string]string{
+			"project": inst.Project(),
+			"name":    inst.Name(),
+			"type":    inst.Type().String(),
+		}
+
+		out.Merge(metrics.InstanceStateMetrics(state, labels))
+	}
+
+	for status, count := range instancesByStatus {
+		out.AddSamples(metrics.InstancesTotal, metrics.Sample{Value: float64(count), Labels: map[string]string{"status": status}})
+	}
+
+	// Only expose the daemon internals to clients which aren't restricted to specific projects.
+	if rbac.UserIsAdmin(r) {
+		out.Merge(daemonMetrics(d))
+	}
+
+	return response.SyncResponsePlain(true, out.String())
+}
+
+// daemonMetrics returns the metrics of the daemon itself.
+func daemonMetrics(d *Daemon) *metrics.MetricSet {
+	out := metrics.NewMetricSet(nil)
+
+	ops := operations.Clone()
+	running := 0
+	for _, op := range ops {
+		if op.Status() == api.Running {
+			running++
+		}
+	}
+
+	out.AddSamples(metrics.OperationsTotal, metrics.Sample{Value: float64(running)})
+
+	var warnings []db.Warning
+	err := d.cluster.Transaction(func(tx *db.ClusterTx) error {
+		var err error
+		warnings, err = tx.GetWarnings()
+		return err
+	})
+	if err != nil {
+		logger.Warn("Failed getting warnings for metrics", log.Ctx{"err": err})
+	} else {
+		active := 0
+		for _, w := range warnings {
+			if w.Status != db.WarningStatusResolved {
+				active++
+			}
+		}
+
+		out.AddSamples(metrics.WarningsTotal, metrics.Sample{Value: float64(active)})
+	}
+
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+
+	out.AddSamples(metrics.GoGoroutines, metrics.Sample{Value: float64(runtime.NumGoroutine())})
+	out.AddSamples(metrics.GoHeapAllocBytes, metrics.Sample{Value: float64(memStats.HeapAlloc)})
+	out.AddSamples(metrics.UptimeSeconds, metrics.Sample{Value: time.Since(d.startTime).Seconds()})
+
+	return out
+}
+
+// swagger:operation GET /1.0/metrics/instances metrics metrics_instances_get
+//
+// Get the instance usage counters
+//
+// Gets the resource usage counters of all the instances in the project across the cluster.
+// Rates (such as CPU usage percentage or network throughput) can be computed from two consecutive samples.
+//
+// ---
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+// responses:
+//   "200":
+//     description: Instance usage counters
+//     schema:
+//       type: object
+//       description: Sync response
+//       properties:
+//         type:
+//           type: string
+//           description: Response type
+//           example: sync
+//         status:
+//           type: string
+//           description: Status description
+//           example: Success
+//         status_code:
+//           type: integer
+//           description: Status code
+//           example: 200
+//         metadata:
+//           type: array
+//           description: List of instance usage counters
+//           items:
+//             $ref: "#/definitions/InstanceMetrics"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func metricsInstancesGet(d *Daemon, r *http.Request) response.Response {
+	projectName := projectParam(r)
+
+	instances, err := instanceLoadNodeProjectAll(d.State(), projectName, instancetype.Any)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	result := []api.InstanceMetrics{}
+	for _, inst := range instances {
+		state, err := inst.RenderState()
+		if err != nil {
+			logger.Warn("Failed getting instance state for metrics", log.Ctx{"project": inst.Project(), "instance": inst.Name(), "err": err})
+			continue
+		}
+
+		result = append(result, api.InstanceMetrics{
+			InstanceUsageSample: instanceUsageSampleFromState(state),
+			Name:                inst.Name(),
+			Project:             inst.Project(),
+			Location:            inst.Location(),
+			Type:                inst.Type().String(),
+			Status:              state.Status,
+			Processes:           state.Processes,
+		})
+	}
+
+	// Gather the instances of the other cluster members, unless we're one of them.
+	if !isClusterNotification(r) {
+		notifier, err := cluster.NewNotifier(d.State(), d.endpoints.NetworkCert(), d.serverCert(), cluster.NotifyAlive)
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		var resultLock sync.Mutex
+		err = notifier(func(client lxd.InstanceServer) error {
+			metrics, err := client.UseProject(projectName).GetInstancesMetrics()
+			if err != nil {
+				return err
+			}
+
+			resultLock.Lock()
+			result = append(result, metrics...)
+			resultLock.Unlock()
+
+			return nil
+		})
+		if err != nil {
+			return response.SmartError(err)
+		}
+	}
+
+	return response.SyncResponse(true, result)
+}
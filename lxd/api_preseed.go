@@ -0,0 +1,165 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/pkg/errors"
+
+	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/project"
+	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/shared/api"
+)
+
+var preseedCmd = APIEndpoint{
+	Path: "preseed",
+
+	Get: APIEndpointAction{Handler: preseedGet},
+}
+
+// swagger:operation GET /1.0/preseed server preseed_get
+//
+// Get the server preseed
+//
+// Describes the server configuration, storage pools, networks, profiles and projects
+// in the format used by "lxd init --preseed", so that an identical server can be rebuilt.
+//
+// ---
+// produces:
+//   - application/json
+// responses:
+//   "200":
+//     description: Server preseed
+//     schema:
+//       type: object
+//       description: Sync response
+//       properties:
+//         type:
+//           type: string
+//           description: Response type
+//           example: sync
+//         status:
+//           type: string
+//           description: Status description
+//           example: Success
+//         status_code:
+//           type: integer
+//           description: Status code
+//           example: 200
+//         metadata:
+//           $ref: "#/definitions/InitPreseed"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func preseedGet(d *Daemon, r *http.Request) response.Response {
+	config, err := preseedDump(d)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponse(true, config)
+}
+
+// preseedDump describes the server in the preseed format, the same way "lxd init --dump" does through the API.
+func preseedDump(d *Daemon) (*api.InitPreseed, error) {
+	config := api.InitPreseed{}
+
+	serverConfig, err := daemonConfigRender(d.State())
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to retrieve current server configuration")
+	}
+
+	config.Config = map[string]interface{}{}
+	for k, v := range serverConfig {
+		// Hidden keys (such as passwords) are rendered as true, their value can't be retrieved.
+		_, hidden := v.(bool)
+		if hidden {
+			continue
+		}
+
+		config.Config[k] = v
+	}
+
+	// Only include networks of the default project as projects are created after the networks when
+	// applying a preseed.
+	networks, err := d.cluster.GetNetworks(project.Default)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to retrieve networks of project %q", project.Default)
+	}
+
+	for _, name := range networks {
+		_, network, _, err := d.cluster.GetNetworkInAnyState(project.Default, name)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to retrieve network %q", name)
+		}
+
+		networksPost := api.InitNetworksProjectPost{}
+		networksPost.Config = network.Config
+		networksPost.Description = network.Description
+		networksPost.Name = network.Name
+		networksPost.Type = network.Type
+		networksPost.Project = project.Default
+
+		config.Networks = append(config.Networks, networksPost)
+	}
+
+	pools, err := d.cluster.GetStoragePoolNames()
+	if err != nil && err != db.ErrNoSuchObject {
+		return nil, errors.Wrap(err, "Failed to retrieve storage pools")
+	}
+
+	for _, name := range pools {
+		_, pool, _, err := d.cluster.GetStoragePoolInAnyState(name)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to retrieve storage pool %q", name)
+		}
+
+		storagePoolsPost := api.StoragePoolsPost{}
+		storagePoolsPost.Config = pool.Config
+		storagePoolsPost.Description = pool.Description
+		storagePoolsPost.Name = pool.Name
+		storagePoolsPost.Driver = pool.Driver
+
+		config.StoragePools = append(config.StoragePools, storagePoolsPost)
+	}
+
+	err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
+		projectName := project.Default
+		profiles, err := tx.GetProfiles(db.ProfileFilter{Project: &projectName})
+		if err != nil {
+			return errors.Wrap(err, "Failed to retrieve profiles")
+		}
+
+		for _, profile := range profiles {
+			profilesPost := api.ProfilesPost{}
+			profilesPost.Config = profile.Config
+			profilesPost.Description = profile.Description
+			profilesPost.Devices = profile.Devices
+			profilesPost.Name = profile.Name
+
+			config.Profiles = append(config.Profiles, profilesPost)
+		}
+
+		projects, err := tx.GetProjects(db.ProjectFilter{})
+		if err != nil {
+			return errors.Wrap(err, "Failed to retrieve projects")
+		}
+
+		for _, project := range projects {
+			projectsPost := api.ProjectsPost{}
+			projectsPost.Config = project.Config
+			projectsPost.Description = project.Description
+			projectsPost.Name = project.Name
+
+			config.Projects = append(config.Projects, projectsPost)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &config, nil
+}
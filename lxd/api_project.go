@@ -196,23 +196,30 @@ func projectsGet(d *Daemon, r *http.Request) response.Response {
 //
 // Add a project
 //
-// Creates a new project.
+// Creates a new project, either empty or from a project export.
 //
 // ---
 // consumes:
 //   - application/json
+//   - application/octet-stream
 // produces:
 //   - application/json
 // parameters:
 //   - in: body
 //     name: project
 //     description: Project
-//     required: true
+//     required: false
 //     schema:
 //       $ref: "#/definitions/ProjectsPost"
+//   - in: body
+//     name: raw_export
+//     description: Raw project export file
+//     required: false
 // responses:
 //   "200":
 //     $ref: "#/responses/EmptySyncResponse"
+//   "202":
+//     $ref: "#/responses/Operation"
 //   "400":
 //     $ref: "#/responses/BadRequest"
 //   "403":
@@ -220,6 +227,11 @@ func projectsGet(d *Daemon, r *http.Request) response.Response {
 //   "500":
 //     $ref: "#/responses/InternalServerError"
 func projectsPost(d *Daemon, r *http.Request) response.Response {
+	// If we're getting binary content, process separately.
+	if r.Header.Get("Content-Type") == "application/octet-stream" {
+		return projectImport(d, r, r.Body, r.Header.Get("X-LXD-name"), r.Header.Get("X-LXD-pool"))
+	}
+
 	// Parse the request.
 	project := db.Project{}
 
@@ -239,16 +251,26 @@ func projectsPost(d *Daemon, r *http.Request) response.Response {
 		return response.BadRequest(err)
 	}
 
+	err = projectCreate(d, r, project)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponseLocation(true, nil, fmt.Sprintf("/%s/projects/%s", version.APIVersion, project.Name))
+}
+
+// projectCreate validates and creates a new project.
+func projectCreate(d *Daemon, r *http.Request, project db.Project) error {
 	// Quick checks.
-	err = projectValidateName(project.Name)
+	err := projectValidateName(project.Name)
 	if err != nil {
-		return response.BadRequest(err)
+		return api.StatusErrorf(http.StatusBadRequest, "%v", err)
 	}
 
 	// Validate the configuration.
-	err = projectValidateConfig(d.State(), project.Config)
+	err = projectValidateConfig(d.State(), project.Name, project.Config)
 	if err != nil {
-		return response.BadRequest(err)
+		return api.StatusErrorf(http.StatusBadRequest, "%v", err)
 	}
 
 	var id int64
@@ -275,20 +297,20 @@ func projectsPost(d *Daemon, r *http.Request) response.Response {
 		return nil
 	})
 	if err != nil {
-		return response.SmartError(errors.Wrapf(err, "Failed creating project %q", project.Name))
+		return errors.Wrapf(err, "Failed creating project %q", project.Name)
 	}
 
 	if d.rbac != nil {
 		err = d.rbac.AddProject(id, project.Name)
 		if err != nil {
-			return response.SmartError(err)
+			return err
 		}
 	}
 
 	requestor := request.CreateRequestor(r)
 	d.State().Events.SendLifecycle(project.Name, lifecycle.ProjectCreated.Event(project.Name, requestor, nil))
 
-	return response.SyncResponseLocation(true, nil, fmt.Sprintf("/%s/projects/%s", version.APIVersion, project.Name))
+	return nil
 }
 
 // Create the default profile of a project.
@@ -560,7 +582,7 @@ func projectChange(d *Daemon, project *db.Project, req api.ProjectPut) response.
 	}
 
 	// Validate the configuration.
-	err := projectValidateConfig(d.State(), req.Config)
+	err := projectValidateConfig(d.State(), project.Name, req.Config)
 	if err != nil {
 		return response.BadRequest(err)
 	}
@@ -847,7 +869,11 @@ func isEitherAllowOrBlockOrManaged(value string) error {
 	return validate.Optional(validate.IsOneOf("block", "allow", "managed"))(value)
 }
 
-func projectValidateConfig(s *state.State, config map[string]string) error {
+func isEitherAllowOrBlockOrAllowlist(value string) error {
+	return validate.Optional(validate.IsOneOf("block", "allow", "allowlist"))(value)
+}
+
+func projectValidateConfig(s *state.State, projectName string, config map[string]string) error {
 	// Validate the project configuration.
 	projectConfigKeys := map[string]func(value string) error{
 		"backups.compression_algorithm":        validate.IsCompressionAlgorithm,
@@ -875,8 +901,10 @@ func projectValidateConfig(s *state.State, config map[string]string) error {
 		"restricted.containers.lowlevel":       isEitherAllowOrBlock,
 		"restricted.containers.privilege":      validate.Optional(validate.IsOneOf("allow", "unprivileged", "isolated")),
 		"restricted.virtual-machines.lowlevel": isEitherAllowOrBlock,
-		"restricted.devices.unix-char":         isEitherAllowOrBlock,
-		"restricted.devices.unix-block":        isEitherAllowOrBlock,
+		"restricted.virtual-machines.nesting":  isEitherAllowOrBlock,
+		"restricted.devices.unix-char":         isEitherAllowOrBlockOrAllowlist,
+		"restricted.devices.unix-block":        isEitherAllowOrBlockOrAllowlist,
+		"restricted.devices.unix.allowlist":    validate.Optional(project.ValidateUnixDeviceAllowlist),
 		"restricted.devices.unix-hotplug":      isEitherAllowOrBlock,
 		"restricted.devices.infiniband":        isEitherAllowOrBlock,
 		"restricted.devices.gpu":               isEitherAllowOrBlock,
@@ -887,9 +915,13 @@ func projectValidateConfig(s *state.State, config map[string]string) error {
 		"restricted.devices.disk":              isEitherAllowOrBlockOrManaged,
 		"restricted.networks.uplinks":          validate.IsAny,
 		"restricted.networks.subnets": validate.Optional(func(value string) error {
-			return projectValidateRestrictedSubnets(s, value)
+			return projectValidateRestrictedSubnets(s, projectName, value)
 		}),
-		"restricted.snapshots": isEitherAllowOrBlock,
+		"restricted.networks.vlans": validate.Optional(func(value string) error {
+			return projectValidateRestrictedVLANs(s, projectName, value)
+		}),
+		"restricted.snapshots":    isEitherAllowOrBlock,
+		"security.exec_recording": validate.Optional(validate.IsBool),
 	}
 
 	for k, v := range config {
@@ -947,9 +979,47 @@ func projectValidateName(name string) error {
 	return nil
 }
 
-// projectValidateRestrictedSubnets checks that the project's restricted.networks.subnets are properly formatted
-// and are within the specified uplink network's routes.
-func projectValidateRestrictedSubnets(s *state.State, value string) error {
+// projectValidateRestrictedSubnets checks that the project's restricted.networks.subnets are properly formatted,
+// are within the specified uplink network's routes and don't overlap with subnets allocated to other projects.
+func projectValidateRestrictedSubnets(s *state.State, projectName string, value string) error {
+	// Load the uplink subnets allocated to other projects.
+	otherProjectSubnets := make(map[string]map[string][]*net.IPNet)
+	err := s.Cluster.Transaction(func(tx *db.ClusterTx) error {
+		projects, err := tx.GetProjects(db.ProjectFilter{})
+		if err != nil {
+			return err
+		}
+
+		for _, p := range projects {
+			if p.Name == projectName || p.Config["restricted.networks.subnets"] == "" {
+				continue
+			}
+
+			for _, subnetRaw := range util.SplitNTrimSpace(p.Config["restricted.networks.subnets"], ",", -1, false) {
+				subnetParts := strings.SplitN(subnetRaw, ":", 2)
+				if len(subnetParts) != 2 {
+					continue
+				}
+
+				_, subnet, err := net.ParseCIDR(subnetParts[1])
+				if err != nil {
+					continue
+				}
+
+				if otherProjectSubnets[subnetParts[0]] == nil {
+					otherProjectSubnets[subnetParts[0]] = make(map[string][]*net.IPNet)
+				}
+
+				otherProjectSubnets[subnetParts[0]][p.Name] = append(otherProjectSubnets[subnetParts[0]][p.Name], subnet)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return errors.Wrapf(err, "Failed loading other project's restricted subnets")
+	}
+
 	for _, subnetRaw := range util.SplitNTrimSpace(value, ",", -1, false) {
 		subnetParts := strings.SplitN(subnetRaw, ":", 2)
 		if len(subnetParts) != 2 {
@@ -999,6 +1069,78 @@ func projectValidateRestrictedSubnets(s *state.State, value string) error {
 		if !foundMatch {
 			return fmt.Errorf("Uplink network %q doesn't contain %q in its routes", uplinkName, restrictedSubnet.String())
 		}
+
+		// Check that the restricted subnet isn't already allocated to another project.
+		for otherProjectName, otherSubnets := range otherProjectSubnets[uplinkName] {
+			for _, otherSubnet := range otherSubnets {
+				if network.SubnetOverlaps(otherSubnet, restrictedSubnet) {
+					return fmt.Errorf("Subnet %q overlaps with %q allocated to project %q from uplink network %q", restrictedSubnet.String(), otherSubnet.String(), otherProjectName, uplinkName)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// projectValidateRestrictedVLANs checks that the project's restricted.networks.vlans are properly formatted, refer
+// to existing uplink networks and don't overlap with VLANs allocated to other projects from the same uplink.
+func projectValidateRestrictedVLANs(s *state.State, projectName string, value string) error {
+	vlans, err := project.ParseRestrictedVLANs(value)
+	if err != nil {
+		return err
+	}
+
+	// Load the uplink VLANs allocated to other projects.
+	otherProjectVLANs := make(map[string]map[string][]project.VLANRange)
+	err = s.Cluster.Transaction(func(tx *db.ClusterTx) error {
+		projects, err := tx.GetProjects(db.ProjectFilter{})
+		if err != nil {
+			return err
+		}
+
+		for _, p := range projects {
+			if p.Name == projectName || p.Config["restricted.networks.vlans"] == "" {
+				continue
+			}
+
+			otherVLANs, err := project.ParseRestrictedVLANs(p.Config["restricted.networks.vlans"])
+			if err != nil {
+				continue
+			}
+
+			for uplinkName, ranges := range otherVLANs {
+				if otherProjectVLANs[uplinkName] == nil {
+					otherProjectVLANs[uplinkName] = make(map[string][]project.VLANRange)
+				}
+
+				otherProjectVLANs[uplinkName][p.Name] = ranges
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return errors.Wrapf(err, "Failed loading other project's restricted VLANs")
+	}
+
+	for uplinkName, ranges := range vlans {
+		// Check uplink exists.
+		_, _, _, err := s.Cluster.GetNetworkInAnyState(project.Default, uplinkName)
+		if err != nil {
+			return errors.Wrapf(err, "Invalid uplink network %q", uplinkName)
+		}
+
+		// Check that the restricted VLANs aren't already allocated to another project.
+		for _, vlanRange := range ranges {
+			for otherProjectName, otherVLANs := range otherProjectVLANs[uplinkName] {
+				for _, otherRange := range otherVLANs {
+					if vlanRange.Start <= otherRange.End && otherRange.Start <= vlanRange.End {
+						return fmt.Errorf("VLANs %d-%d overlap with VLANs %d-%d allocated to project %q from uplink network %q", vlanRange.Start, vlanRange.End, otherRange.Start, otherRange.End, otherProjectName, uplinkName)
+					}
+				}
+			}
+		}
 	}
 
 	return nil
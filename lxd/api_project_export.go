@@ -0,0 +1,1052 @@
+package main
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+
+	lxd "github.com/lxc/lxd/client"
+	"github.com/lxc/lxd/lxd/backup"
+	"github.com/lxc/lxd/lxd/cluster"
+	clusterRequest "github.com/lxc/lxd/lxd/cluster/request"
+	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/instance"
+	"github.com/lxc/lxd/lxd/instance/instancetype"
+	"github.com/lxc/lxd/lxd/lifecycle"
+	"github.com/lxc/lxd/lxd/network"
+	"github.com/lxc/lxd/lxd/operations"
+	"github.com/lxc/lxd/lxd/project"
+	"github.com/lxc/lxd/lxd/request"
+	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/lxd/revert"
+	storagePools "github.com/lxc/lxd/lxd/storage"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	log "github.com/lxc/lxd/shared/log15"
+	"github.com/lxc/lxd/shared/logger"
+	"github.com/lxc/lxd/shared/validate"
+)
+
+// projectExportVersion is the version of the project export layout.
+const projectExportVersion = 1
+
+// projectExportBackupExpiry is how long the backups taken for a project export are kept if they can't be
+// deleted once exported.
+const projectExportBackupExpiry = 24 * time.Hour
+
+// projectExportIndex describes the content of a project export. It's stored as index.yaml, the last entry of
+// the export tarball, so that truncated exports are rejected on import. The image files, custom volume backups
+// and instance backups are stored before it.
+type projectExportIndex struct {
+	Version   int                   `yaml:"version"`
+	Project   api.Project           `yaml:"project"`
+	Profiles  []api.Profile         `yaml:"profiles,omitempty"`
+	Networks  []api.Network         `yaml:"networks,omitempty"`
+	Images    []projectExportImage  `yaml:"images,omitempty"`
+	Volumes   []projectExportVolume `yaml:"volumes,omitempty"`
+	Instances []string              `yaml:"instances,omitempty"`
+}
+
+// projectExportImage is an image of an exported project.
+type projectExportImage struct {
+	Image  api.Image `yaml:"image"`
+	Rootfs bool      `yaml:"rootfs,omitempty"`
+}
+
+// projectExportVolume is a custom storage volume of an exported project.
+type projectExportVolume struct {
+	Pool string `yaml:"pool"`
+	Name string `yaml:"name"`
+}
+
+// projectExportArgs are the backup options of a project export.
+type projectExportArgs struct {
+	instanceOnly         bool
+	optimizedStorage     bool
+	compressionAlgorithm string
+}
+
+// projectExportInstancePath returns the path of the backup of an instance within an export.
+func projectExportInstancePath(name string) string {
+	return filepath.Join("instances", name+".backup")
+}
+
+// projectExportVolumePath returns the path of the backup of a custom volume within an export.
+func projectExportVolumePath(pool string, name string) string {
+	return filepath.Join("volumes", pool, name+".backup")
+}
+
+// projectExportImagePath returns the path of an image file within an export.
+func projectExportImagePath(fingerprint string, suffix string) string {
+	return filepath.Join("images", fingerprint+suffix)
+}
+
+var projectExportCmd = APIEndpoint{
+	Path: "projects/{name}/export",
+
+	Get: APIEndpointAction{Handler: projectExportGet},
+}
+
+// swagger:operation GET /1.0/projects/{name}/export projects project_export_get
+//
+// Export the project
+//
+// Exports the project configuration along with its profiles, networks and images when the project has its
+// own, its custom storage volumes and its instances as a tarball.
+// The instances and custom volumes are exported as backups.
+//
+// ---
+// produces:
+//   - application/x-tar
+// parameters:
+//   - in: query
+//     name: instance-only
+//     description: Whether to leave the instance and volume snapshots out
+//     type: boolean
+//     example: true
+//   - in: query
+//     name: optimized-storage
+//     description: Whether to use the storage driver optimized format
+//     type: boolean
+//     example: true
+//   - in: query
+//     name: compression
+//     description: Compression algorithm of the backups
+//     type: string
+//     example: gzip
+// responses:
+//   "200":
+//     description: Raw project export file
+//   "400":
+//     $ref: "#/responses/BadRequest"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "404":
+//     $ref: "#/responses/NotFound"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func projectExportGet(d *Daemon, r *http.Request) response.Response {
+	name := mux.Vars(r)["name"]
+
+	args := projectExportArgs{
+		instanceOnly:         shared.IsTrue(queryParam(r, "instance-only")),
+		optimizedStorage:     shared.IsTrue(queryParam(r, "optimized-storage")),
+		compressionAlgorithm: queryParam(r, "compression"),
+	}
+
+	if args.compressionAlgorithm != "" {
+		err := validate.IsCompressionAlgorithm(args.compressionAlgorithm)
+		if err != nil {
+			return response.BadRequest(err)
+		}
+	}
+
+	var p *db.Project
+	err := d.cluster.Transaction(func(tx *db.ClusterTx) error {
+		var err error
+		p, err = tx.GetProject(name)
+		if err != nil {
+			return err
+		}
+
+		return project.AllowBackupCreation(tx, name)
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	index, err := projectExportIndexLoad(d, p)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.StreamResponse("application/x-tar", func(w io.Writer) error {
+		return projectExportWrite(d, r, index, args, w)
+	})
+}
+
+// projectExportIndexLoad lists what's part of a project.
+func projectExportIndexLoad(d *Daemon, p *db.Project) (*projectExportIndex, error) {
+	index := projectExportIndex{Version: projectExportVersion}
+	index.Project.Name = p.Name
+	index.Project.Description = p.Description
+	index.Project.Config = p.Config
+
+	// The profiles, images and volumes of the default project are used by projects which don't have the
+	// matching feature enabled, those aren't part of the project.
+	hasProfiles := p.Name == project.Default || shared.IsTrue(p.Config["features.profiles"])
+	hasImages := p.Name == project.Default || shared.IsTrue(p.Config["features.images"])
+	hasVolumes := p.Name == project.Default || shared.IsTrue(p.Config["features.storage.volumes"])
+
+	err := d.cluster.Transaction(func(tx *db.ClusterTx) error {
+		if hasProfiles {
+			profiles, err := tx.GetProfiles(db.ProfileFilter{Project: &p.Name})
+			if err != nil {
+				return errors.Wrap(err, "Failed loading profiles")
+			}
+
+			for _, profile := range profiles {
+				index.Profiles = append(index.Profiles, *db.ProfileToAPI(&profile))
+			}
+		}
+
+		instances, err := tx.GetInstanceNames(p.Name)
+		if err != nil {
+			return errors.Wrap(err, "Failed loading instances")
+		}
+
+		index.Instances = instances
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if shared.IsTrue(p.Config["features.networks"]) {
+		networks, err := d.cluster.GetNetworks(p.Name)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed loading networks")
+		}
+
+		for _, name := range networks {
+			_, network, _, err := d.cluster.GetNetworkInAnyState(p.Name, name)
+			if err != nil {
+				return nil, errors.Wrapf(err, "Failed loading network %q", name)
+			}
+
+			index.Networks = append(index.Networks, *network)
+		}
+	}
+
+	if hasImages {
+		fingerprints, err := d.cluster.GetImagesFingerprints(p.Name, false)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed loading images")
+		}
+
+		for _, fingerprint := range fingerprints {
+			_, image, err := d.cluster.GetImage(fingerprint, db.ImageFilter{Project: &p.Name})
+			if err != nil {
+				return nil, errors.Wrapf(err, "Failed loading image %q", fingerprint)
+			}
+
+			index.Images = append(index.Images, projectExportImage{Image: *image})
+		}
+	}
+
+	if hasVolumes {
+		pools, err := d.cluster.GetStoragePoolNames()
+		if err != nil && err != db.ErrNoSuchObject {
+			return nil, errors.Wrap(err, "Failed loading storage pools")
+		}
+
+		for _, pool := range pools {
+			poolID, _, _, err := d.cluster.GetStoragePoolInAnyState(pool)
+			if err != nil {
+				return nil, errors.Wrapf(err, "Failed loading storage pool %q", pool)
+			}
+
+			volumes, err := d.cluster.GetStoragePoolVolumes(p.Name, poolID, []int{db.StoragePoolVolumeTypeCustom})
+			if err != nil {
+				return nil, errors.Wrapf(err, "Failed loading custom volumes of storage pool %q", pool)
+			}
+
+			for _, volume := range volumes {
+				// Snapshots are part of the volume backups.
+				if shared.IsSnapshot(volume.Name) {
+					continue
+				}
+
+				index.Volumes = append(index.Volumes, projectExportVolume{Pool: pool, Name: volume.Name})
+			}
+		}
+	}
+
+	return &index, nil
+}
+
+// projectExportWrite writes the project export tarball, taking backups of the custom volumes and instances.
+func projectExportWrite(d *Daemon, r *http.Request, index *projectExportIndex, args projectExportArgs, w io.Writer) error {
+	tw := tar.NewWriter(w)
+
+	for i, entry := range index.Images {
+		fingerprint := entry.Image.Fingerprint
+
+		err := instanceImageEnsureLocal(d, r, index.Project.Name, &entry.Image)
+		if err != nil {
+			return err
+		}
+
+		imagePath := shared.VarPath("images", fingerprint)
+		err = projectExportWriteFile(tw, projectExportImagePath(fingerprint, ".meta"), imagePath)
+		if err != nil {
+			return errors.Wrapf(err, "Failed exporting image %q", fingerprint)
+		}
+
+		// Unified images don't have a separate rootfs.
+		if shared.PathExists(imagePath + ".rootfs") {
+			err = projectExportWriteFile(tw, projectExportImagePath(fingerprint, ".rootfs"), imagePath+".rootfs")
+			if err != nil {
+				return errors.Wrapf(err, "Failed exporting image %q", fingerprint)
+			}
+
+			index.Images[i].Rootfs = true
+		}
+	}
+
+	backupName := fmt.Sprintf("project-export-%d", time.Now().UnixNano())
+
+	for _, volume := range index.Volumes {
+		err := projectExportVolumeBackup(d, r, index.Project.Name, volume, backupName, args, func(path string) error {
+			return projectExportWriteFile(tw, projectExportVolumePath(volume.Pool, volume.Name), path)
+		})
+		if err != nil {
+			return errors.Wrapf(err, "Failed exporting custom volume %q", volume.Name)
+		}
+	}
+
+	for _, name := range index.Instances {
+		err := projectExportInstanceBackup(d, r, index.Project.Name, name, backupName, args, func(path string) error {
+			return projectExportWriteFile(tw, projectExportInstancePath(name), path)
+		})
+		if err != nil {
+			return errors.Wrapf(err, "Failed exporting instance %q", name)
+		}
+	}
+
+	data, err := yaml.Marshal(index)
+	if err != nil {
+		return err
+	}
+
+	err = tw.WriteHeader(&tar.Header{
+		Name:     "index.yaml",
+		Mode:     0600,
+		Size:     int64(len(data)),
+		ModTime:  time.Now(),
+		Typeflag: tar.TypeReg,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = tw.Write(data)
+	if err != nil {
+		return err
+	}
+
+	return tw.Close()
+}
+
+// projectExportWriteFile adds the file at path to the export tarball.
+func projectExportWriteFile(tw *tar.Writer, name string, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	err = tw.WriteHeader(&tar.Header{
+		Name:     filepath.ToSlash(name),
+		Mode:     0600,
+		Size:     fi.Size(),
+		ModTime:  fi.ModTime(),
+		Typeflag: tar.TypeReg,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+// projectExportInstanceBackup takes a backup of an instance, passes its path to write and deletes it.
+// Instances located on other cluster members are backed up there.
+func projectExportInstanceBackup(d *Daemon, r *http.Request, projectName string, name string, backupName string, args projectExportArgs, write func(path string) error) error {
+	client, err := cluster.ConnectIfInstanceIsRemote(d.cluster, projectName, name, d.endpoints.NetworkCert(), d.serverCert(), r, instancetype.Any)
+	if err != nil {
+		return err
+	}
+
+	if client != nil {
+		client = client.UseProject(projectName)
+
+		req := api.InstanceBackupsPost{
+			Name:                 backupName,
+			ExpiresAt:            time.Now().Add(projectExportBackupExpiry),
+			ContainerOnly:        args.instanceOnly,
+			InstanceOnly:         args.instanceOnly,
+			OptimizedStorage:     args.optimizedStorage,
+			CompressionAlgorithm: args.compressionAlgorithm,
+		}
+
+		op, err := client.CreateInstanceBackup(name, req)
+		if err != nil {
+			return err
+		}
+
+		err = op.Wait()
+		if err != nil {
+			return err
+		}
+
+		defer func() {
+			op, err := client.DeleteInstanceBackup(name, backupName)
+			if err == nil {
+				op.Wait()
+			}
+		}()
+
+		return projectExportDownload(write, func(req *lxd.BackupFileRequest) error {
+			_, err := client.GetInstanceBackupFile(name, backupName, req)
+			return err
+		})
+	}
+
+	inst, err := instance.LoadByProjectAndName(d.State(), projectName, name)
+	if err != nil {
+		return err
+	}
+
+	fullName := name + shared.SnapshotDelimiter + backupName
+	backupArgs := db.InstanceBackup{
+		Name:                 fullName,
+		InstanceID:           inst.ID(),
+		CreationDate:         time.Now(),
+		ExpiryDate:           time.Now().Add(projectExportBackupExpiry),
+		InstanceOnly:         args.instanceOnly,
+		OptimizedStorage:     args.optimizedStorage,
+		CompressionAlgorithm: args.compressionAlgorithm,
+	}
+
+	err = backupCreate(d.State(), backupArgs, inst, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		b, err := instance.BackupLoadByName(d.State(), projectName, fullName)
+		if err == nil {
+			b.Delete()
+		}
+	}()
+
+	return write(shared.VarPath("backups", "instances", project.Instance(projectName, fullName)))
+}
+
+// projectExportVolumeBackup takes a backup of a custom volume, passes its path to write and deletes it.
+// Volumes located on other cluster members are backed up there.
+func projectExportVolumeBackup(d *Daemon, r *http.Request, projectName string, volume projectExportVolume, backupName string, args projectExportArgs, write func(path string) error) error {
+	client, err := cluster.ConnectIfVolumeIsRemote(d.State(), volume.Pool, projectName, volume.Name, db.StoragePoolVolumeTypeCustom, d.endpoints.NetworkCert(), d.serverCert(), r)
+	if err != nil {
+		return err
+	}
+
+	if client != nil {
+		client = client.UseProject(projectName)
+
+		req := api.StoragePoolVolumeBackupsPost{
+			Name:                 backupName,
+			ExpiresAt:            time.Now().Add(projectExportBackupExpiry),
+			VolumeOnly:           args.instanceOnly,
+			OptimizedStorage:     args.optimizedStorage,
+			CompressionAlgorithm: args.compressionAlgorithm,
+		}
+
+		op, err := client.CreateStoragePoolVolumeBackup(volume.Pool, volume.Name, req)
+		if err != nil {
+			return err
+		}
+
+		err = op.Wait()
+		if err != nil {
+			return err
+		}
+
+		defer func() {
+			op, err := client.DeleteStoragePoolVolumeBackup(volume.Pool, volume.Name, backupName)
+			if err == nil {
+				op.Wait()
+			}
+		}()
+
+		return projectExportDownload(write, func(req *lxd.BackupFileRequest) error {
+			_, err := client.GetStoragePoolVolumeBackupFile(volume.Pool, volume.Name, backupName, req)
+			return err
+		})
+	}
+
+	poolID, _, _, err := d.cluster.GetStoragePoolInAnyState(volume.Pool)
+	if err != nil {
+		return err
+	}
+
+	volumeID, _, err := d.cluster.GetLocalStoragePoolVolume(projectName, volume.Name, db.StoragePoolVolumeTypeCustom, poolID)
+	if err != nil {
+		return err
+	}
+
+	fullName := volume.Name + shared.SnapshotDelimiter + backupName
+	backupArgs := db.StoragePoolVolumeBackup{
+		Name:                 fullName,
+		VolumeID:             volumeID,
+		CreationDate:         time.Now(),
+		ExpiryDate:           time.Now().Add(projectExportBackupExpiry),
+		VolumeOnly:           args.instanceOnly,
+		OptimizedStorage:     args.optimizedStorage,
+		CompressionAlgorithm: args.compressionAlgorithm,
+	}
+
+	err = volumeBackupCreate(d.State(), backupArgs, projectName, volume.Pool, volume.Name, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		b, err := storagePoolVolumeBackupLoadByName(d.State(), projectName, volume.Pool, fullName)
+		if err == nil {
+			b.Delete()
+		}
+	}()
+
+	return write(shared.VarPath("backups", "custom", volume.Pool, project.StorageVolume(projectName, fullName)))
+}
+
+// projectExportDownload downloads a backup from another cluster member to a temporary file and passes its path
+// to write.
+func projectExportDownload(write func(path string) error, get func(req *lxd.BackupFileRequest) error) error {
+	f, err := ioutil.TempFile(shared.VarPath("backups"), fmt.Sprintf("%s_project_", backup.WorkingDirPrefix))
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	err = get(&lxd.BackupFileRequest{BackupFile: f})
+	if err != nil {
+		return err
+	}
+
+	return write(f.Name())
+}
+
+// projectImport creates a project from a project export. All the instances are created on this cluster member,
+// on the given storage pool if set. Exports are only imported into new projects, named after the exported project
+// unless another name is given.
+func projectImport(d *Daemon, r *http.Request, data io.Reader, projectName string, pool string) response.Response {
+	revert := revert.New()
+	defer revert.Fail()
+
+	// Unpack the export into a temporary directory.
+	dir, err := ioutil.TempDir(shared.VarPath("backups"), fmt.Sprintf("%s_project_", backup.WorkingDirPrefix))
+	if err != nil {
+		return response.InternalError(err)
+	}
+	revert.Add(func() { os.RemoveAll(dir) })
+
+	err = projectExportUnpack(data, dir)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	index, err := projectExportIndexRead(dir)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if projectName == "" {
+		projectName = index.Project.Name
+	}
+
+	_, err = d.cluster.GetProject(projectName)
+	if err == nil {
+		return response.Conflict(fmt.Errorf("Project %q already exists, pick another name for the imported project", projectName))
+	}
+
+	if errors.Cause(err) != db.ErrNoSuchObject {
+		return response.SmartError(err)
+	}
+
+	run := func(op *operations.Operation) error {
+		defer os.RemoveAll(dir)
+
+		return projectImportIndex(d, r, op, index, projectName, pool, dir)
+	}
+
+	resources := map[string][]string{}
+	resources["projects"] = []string{projectName}
+
+	op, err := operations.OperationCreate(d.State(), project.Default, operations.OperationClassTask, db.OperationProjectImport, resources, nil, run, nil, nil, r)
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	revert.Success()
+	return operations.OperationResponse(op)
+}
+
+// projectImportIndex re-creates the content of an unpacked project export in a new project. Everything created
+// is deleted again if the import fails.
+func projectImportIndex(d *Daemon, r *http.Request, op *operations.Operation, index *projectExportIndex, projectName string, pool string, dir string) error {
+	revert := revert.New()
+	defer revert.Fail()
+
+	p := db.Project{
+		Name:        projectName,
+		Description: index.Project.Description,
+		Config:      index.Project.Config,
+	}
+
+	err := projectCreate(d, r, p)
+	if err != nil {
+		return err
+	}
+
+	revert.Add(func() {
+		var id int64
+		err := d.cluster.Transaction(func(tx *db.ClusterTx) error {
+			var err error
+			id, err = tx.GetProjectID(projectName)
+			if err != nil {
+				return err
+			}
+
+			// The project's default profile goes along with it.
+			return tx.DeleteProject(projectName)
+		})
+		if err == nil && d.rbac != nil {
+			err = d.rbac.DeleteProject(id)
+		}
+
+		if err != nil {
+			logger.Error("Failed deleting imported project", log.Ctx{"project": projectName, "err": err})
+		}
+	})
+
+	err = projectImportNetworks(d, r, revert, projectName, index.Networks)
+	if err != nil {
+		return err
+	}
+
+	profileProject, _, err := project.ProfileProject(d.cluster, projectName)
+	if err != nil {
+		return err
+	}
+
+	for _, profile := range index.Profiles {
+		err = projectImportProfile(d, r, revert, profileProject, profile)
+		if err != nil {
+			return errors.Wrapf(err, "Failed importing profile %q", profile.Name)
+		}
+	}
+
+	for _, image := range index.Images {
+		err = projectImportImage(d, r, revert, projectName, image, dir)
+		if err != nil {
+			return errors.Wrapf(err, "Failed importing image %q", image.Image.Fingerprint)
+		}
+	}
+
+	volumeProject, err := project.StorageVolumeProject(d.cluster, projectName, db.StoragePoolVolumeTypeCustom)
+	if err != nil {
+		return err
+	}
+
+	for _, volume := range index.Volumes {
+		err = projectImportBackup(op, filepath.Join(dir, projectExportVolumePath(volume.Pool, volume.Name)), func(backupFile *os.File) (func(op *operations.Operation) error, error) {
+			_, restore, err := customVolumeCreateFromBackup(d, volumeProject, backupFile, volume.Pool, volume.Name)
+			return restore, err
+		})
+		if err != nil {
+			return errors.Wrapf(err, "Failed importing custom volume %q", volume.Name)
+		}
+
+		volume := volume
+		revert.Add(func() {
+			pool, err := storagePools.GetPoolByName(d.State(), volume.Pool)
+			if err == nil {
+				err = pool.DeleteCustomVolume(volumeProject, volume.Name, nil)
+			}
+
+			if err != nil {
+				logger.Error("Failed deleting imported custom volume", log.Ctx{"project": volumeProject, "pool": volume.Pool, "volume": volume.Name, "err": err})
+			}
+		})
+	}
+
+	for _, name := range index.Instances {
+		err = projectImportBackup(op, filepath.Join(dir, projectExportInstancePath(name)), func(backupFile *os.File) (func(op *operations.Operation) error, error) {
+			_, restore, err := instanceCreateFromBackup(d, projectName, backupFile, pool, "")
+			return restore, err
+		})
+		if err != nil {
+			return errors.Wrapf(err, "Failed importing instance %q", name)
+		}
+
+		name := name
+		revert.Add(func() {
+			inst, err := instance.LoadByProjectAndName(d.State(), projectName, name)
+			if err == nil {
+				err = inst.Delete(true)
+			}
+
+			if err != nil {
+				logger.Error("Failed deleting imported instance", log.Ctx{"project": projectName, "instance": name, "err": err})
+			}
+		})
+	}
+
+	revert.Success()
+	return nil
+}
+
+// projectImportNetworks creates the networks of an imported project, adding their deletion to the reverter.
+func projectImportNetworks(d *Daemon, r *http.Request, revert *revert.Reverter, projectName string, networks []api.Network) error {
+	if len(networks) == 0 {
+		return nil
+	}
+
+	networkProject, projectConfig, err := project.NetworkProject(d.cluster, projectName)
+	if err != nil {
+		return err
+	}
+
+	networkCreateLock.Lock()
+	defer networkCreateLock.Unlock()
+
+	for _, network := range networks {
+		req := api.NetworksPost{
+			Name:       network.Name,
+			Type:       network.Type,
+			NetworkPut: network.Writable(),
+		}
+
+		netType, err := networksPostValidate(d, networkProject, projectConfig, &req)
+		if err == nil {
+			err = networksCreate(d, r, networkProject, req, netType, clusterRequest.ClientTypeNormal)
+		}
+
+		if err != nil {
+			return errors.Wrapf(err, "Failed importing network %q", network.Name)
+		}
+
+		name := network.Name
+		revert.Add(func() {
+			err := projectImportDeleteNetwork(d, networkProject, name)
+			if err != nil {
+				logger.Error("Failed deleting imported network", log.Ctx{"project": networkProject, "network": name, "err": err})
+			}
+		})
+	}
+
+	return nil
+}
+
+// projectImportDeleteNetwork deletes a network created by a project import on all cluster members.
+func projectImportDeleteNetwork(d *Daemon, projectName string, name string) error {
+	n, err := network.LoadByName(d.State(), projectName, name)
+	if err != nil {
+		return err
+	}
+
+	if n.LocalStatus() != api.NetworkStatusPending {
+		err = n.Delete(clusterRequest.ClientTypeNormal)
+		if err != nil {
+			return err
+		}
+	}
+
+	notifier, err := cluster.NewNotifier(d.State(), d.endpoints.NetworkCert(), d.serverCert(), cluster.NotifyAll)
+	if err != nil {
+		return err
+	}
+
+	err = notifier(func(client lxd.InstanceServer) error {
+		return client.UseProject(projectName).DeleteNetwork(name)
+	})
+	if err != nil {
+		return err
+	}
+
+	return d.cluster.DeleteNetwork(projectName, name)
+}
+
+// projectImportProfile creates a profile of an imported project, or updates the default profile, adding the
+// reversal of the change to the reverter.
+func projectImportProfile(d *Daemon, r *http.Request, revert *revert.Reverter, projectName string, profile api.Profile) error {
+	if profile.Name != project.Default {
+		err := profileCreate(d, r, projectName, api.ProfilesPost{Name: profile.Name, ProfilePut: profile.Writable()})
+		if err != nil {
+			return err
+		}
+
+		revert.Add(func() {
+			err := d.cluster.Transaction(func(tx *db.ClusterTx) error {
+				return tx.DeleteProfile(projectName, profile.Name)
+			})
+			if err != nil {
+				logger.Error("Failed deleting imported profile", log.Ctx{"project": projectName, "profile": profile.Name, "err": err})
+			}
+		})
+
+		return nil
+	}
+
+	id, current, err := d.cluster.GetProfile(projectName, profile.Name)
+	if err != nil {
+		return err
+	}
+
+	err = doProfileUpdate(d, projectName, profile.Name, id, current, profile.Writable())
+	if err != nil {
+		return err
+	}
+
+	// The default profile may be the one of the default project when the project doesn't have its own profiles.
+	revert.Add(func() {
+		_, updated, err := d.cluster.GetProfile(projectName, profile.Name)
+		if err == nil {
+			err = doProfileUpdate(d, projectName, profile.Name, id, updated, current.Writable())
+		}
+
+		if err != nil {
+			logger.Error("Failed restoring default profile", log.Ctx{"project": projectName, "err": err})
+		}
+	})
+
+	return nil
+}
+
+// projectImportImage adds an exported image to the project, unless it's already there, adding its removal to
+// the reverter.
+func projectImportImage(d *Daemon, r *http.Request, revert *revert.Reverter, projectName string, entry projectExportImage, dir string) error {
+	image := entry.Image
+
+	exists, err := d.cluster.ImageExists(projectName, image.Fingerprint)
+	if err != nil {
+		return err
+	}
+
+	if exists {
+		return nil
+	}
+
+	paths := []string{filepath.Join(dir, projectExportImagePath(image.Fingerprint, ".meta"))}
+	if entry.Rootfs {
+		paths = append(paths, filepath.Join(dir, projectExportImagePath(image.Fingerprint, ".rootfs")))
+	}
+
+	// Check the image files match the fingerprint.
+	hash := sha256.New()
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+
+		_, err = io.Copy(hash, f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	if fmt.Sprintf("%x", hash.Sum(nil)) != image.Fingerprint {
+		return api.StatusErrorf(http.StatusBadRequest, "The image files don't match the fingerprint")
+	}
+
+	// Image files are shared by all projects.
+	imagePath := shared.VarPath("images", image.Fingerprint)
+	if !shared.PathExists(imagePath) {
+		revert.Add(func() { imageDeleteFromDisk(image.Fingerprint) })
+
+		err = shared.FileMove(paths[0], imagePath)
+		if err != nil {
+			return err
+		}
+
+		if entry.Rootfs {
+			err = shared.FileMove(paths[1], imagePath+".rootfs")
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	err = d.cluster.CreateImage(projectName, image.Fingerprint, image.Filename, image.Size, image.Public, image.AutoUpdate, image.Architecture, image.CreatedAt, image.ExpiresAt, image.Properties, image.Type)
+	if err != nil {
+		return err
+	}
+
+	id, _, err := d.cluster.GetImage(image.Fingerprint, db.ImageFilter{Project: &projectName})
+	if err != nil {
+		return err
+	}
+
+	revert.Add(func() {
+		err := d.cluster.DeleteImage(id)
+		if err != nil {
+			logger.Error("Failed deleting imported image", log.Ctx{"project": projectName, "fingerprint": image.Fingerprint, "err": err})
+		}
+	})
+
+	for _, alias := range image.Aliases {
+		err = d.cluster.CreateImageAlias(projectName, alias.Name, id, alias.Description)
+		if err != nil {
+			return errors.Wrapf(err, "Failed creating image alias %q", alias.Name)
+		}
+	}
+
+	requestor := request.CreateRequestor(r)
+	d.State().Events.SendLifecycle(projectName, lifecycle.ImageCreated.Event(image.Fingerprint, projectName, requestor, nil))
+
+	return nil
+}
+
+// projectImportBackup restores an instance or custom volume backup of a project export.
+func projectImportBackup(op *operations.Operation, path string, prepare func(backupFile *os.File) (func(op *operations.Operation) error, error)) error {
+	backupFile, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	defer backupFile.Close()
+
+	// Squashfs backups have to be converted to tarballs first.
+	_, algo, _, err := shared.DetectCompressionFile(backupFile)
+	if err != nil {
+		return err
+	}
+
+	if algo == ".squashfs" {
+		backupFile.Seek(0, 0)
+		tarFile, err := backupFileTemp(backupFile)
+		if err != nil {
+			return err
+		}
+
+		defer tarFile.Close()
+		backupFile = tarFile
+	}
+
+	restore, err := prepare(backupFile)
+	if err != nil {
+		return err
+	}
+
+	return restore(op)
+}
+
+// projectExportIndexRead reads and checks the index of an unpacked project export.
+func projectExportIndexRead(dir string) (*projectExportIndex, error) {
+	data, err := ioutil.ReadFile(filepath.Join(dir, "index.yaml"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, api.StatusErrorf(http.StatusBadRequest, "The project export is incomplete, index.yaml is missing")
+		}
+
+		return nil, err
+	}
+
+	index := projectExportIndex{}
+	err = yaml.UnmarshalStrict(data, &index)
+	if err != nil {
+		return nil, api.StatusErrorf(http.StatusBadRequest, "Failed parsing the project export index: %v", err)
+	}
+
+	if index.Version != projectExportVersion {
+		return nil, api.StatusErrorf(http.StatusBadRequest, "Unsupported project export version %d", index.Version)
+	}
+
+	// The names are used to find the files of the export.
+	names := []string{}
+	for _, image := range index.Images {
+		names = append(names, image.Image.Fingerprint)
+	}
+
+	for _, volume := range index.Volumes {
+		names = append(names, volume.Pool, volume.Name)
+	}
+
+	names = append(names, index.Instances...)
+
+	for _, name := range names {
+		if name == "" || name == "." || name == ".." || strings.Contains(name, "/") {
+			return nil, api.StatusErrorf(http.StatusBadRequest, "Invalid name %q in the project export index", name)
+		}
+	}
+
+	return &index, nil
+}
+
+// projectExportUnpack extracts a project export tarball to a directory. Only directories and regular files are
+// accepted.
+func projectExportUnpack(r io.Reader, dir string) error {
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return api.StatusErrorf(http.StatusBadRequest, "Failed reading the project export: %v", err)
+		}
+
+		// Don't let entries escape the target directory.
+		name := filepath.Clean(header.Name)
+		if filepath.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return api.StatusErrorf(http.StatusBadRequest, "Invalid path %q in the project export", header.Name)
+		}
+
+		path := filepath.Join(dir, name)
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(path, 0700)
+			if err != nil {
+				return err
+			}
+
+		case tar.TypeReg:
+			err = os.MkdirAll(filepath.Dir(path), 0700)
+			if err != nil {
+				return err
+			}
+
+			f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+			if err != nil {
+				return err
+			}
+
+			_, err = io.Copy(f, tr)
+			f.Close()
+			if err != nil {
+				return err
+			}
+
+		default:
+			return api.StatusErrorf(http.StatusBadRequest, "Unsupported entry %q in the project export", header.Name)
+		}
+	}
+}
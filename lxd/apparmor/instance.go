@@ -5,6 +5,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/lxc/lxd/lxd/cgroup"
@@ -139,13 +140,10 @@ func instanceProfileGenerate(state *state.State, inst instance) error {
 
 // instanceProfile generates the AppArmor profile template from the given instance.
 func instanceProfile(state *state.State, inst instance) (string, error) {
-	// Prepare raw.apparmor.
-	rawContent := ""
-	rawApparmor, ok := inst.ExpandedConfig()["raw.apparmor"]
-	if ok {
-		for _, line := range strings.Split(strings.Trim(rawApparmor, "\n"), "\n") {
-			rawContent += fmt.Sprintf("  %s\n", line)
-		}
+	// Prepare raw.apparmor and the named raw.apparmor.* policies.
+	rawContent, err := instanceRawContent(inst)
+	if err != nil {
+		return "", err
 	}
 
 	// Check for features.
@@ -221,3 +219,85 @@ func instanceProfile(state *state.State, inst instance) (string, error) {
 
 	return sb.String(), nil
 }
+
+// InstanceRawChanged returns whether raw.apparmor or any of the raw.apparmor.* keys is in the changed keys.
+func InstanceRawChanged(changedConfig []string) bool {
+	for _, k := range changedConfig {
+		if k == "raw.apparmor" || strings.HasPrefix(k, "raw.apparmor.") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// instanceRawContent returns the raw.apparmor policy of the instance followed by its named raw.apparmor.*
+// policies (sorted by name), so that policies coming from different profiles are all layered onto the
+// generated profile.
+func instanceRawContent(inst instance) (string, error) {
+	keys := []string{}
+	for k := range inst.ExpandedConfig() {
+		if strings.HasPrefix(k, "raw.apparmor.") {
+			keys = append(keys, k)
+		}
+	}
+
+	sort.Strings(keys)
+
+	_, ok := inst.ExpandedConfig()["raw.apparmor"]
+	if ok {
+		keys = append([]string{"raw.apparmor"}, keys...)
+	}
+
+	rawContent := ""
+	for _, k := range keys {
+		value := inst.ExpandedConfig()[k]
+
+		// Only the named policies are checked, raw.apparmor being free to alter the generated profile.
+		if k != "raw.apparmor" {
+			err := ValidateRaw(value)
+			if err != nil {
+				return "", fmt.Errorf("Invalid %q: %w", k, err)
+			}
+		}
+
+		rawContent += fmt.Sprintf("\n  ### Configuration: %s\n", k)
+		for _, line := range strings.Split(strings.Trim(value, "\n"), "\n") {
+			rawContent += fmt.Sprintf("  %s\n", line)
+		}
+	}
+
+	return rawContent, nil
+}
+
+// ValidateRaw checks that a raw AppArmor policy only contains rules that can be included in the
+// generated profile, that is that it can't close the profile it's included in.
+func ValidateRaw(value string) error {
+	depth := 0
+	for _, line := range strings.Split(value, "\n") {
+		// Skip the comments (but not the #include directives).
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "#") && !strings.HasPrefix(trimmed, "#include") {
+			continue
+		}
+
+		for _, c := range line {
+			switch c {
+			case '{':
+				depth++
+			case '}':
+				depth--
+			}
+
+			if depth < 0 {
+				return fmt.Errorf("Unexpected closing brace")
+			}
+		}
+	}
+
+	if depth != 0 {
+		return fmt.Errorf("Missing closing brace")
+	}
+
+	return nil
+}
@@ -568,8 +568,6 @@ profile "{{ .name }}" flags=(attach_disconnected,mediate_deleted) {
 {{- end }}
 
 {{- if .raw }}
-
-  ### Configuration: raw.apparmor
 {{ .raw }}
 {{- end }}
 }
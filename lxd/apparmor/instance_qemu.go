@@ -88,8 +88,6 @@ profile "{{ .name }}" flags=(attach_disconnected,mediate_deleted) {
 {{- end }}
 
 {{- if .raw }}
-
-  ### Configuration: raw.apparmor
 {{ .raw }}
 {{- end }}
 }
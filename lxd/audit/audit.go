@@ -0,0 +1,566 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lxc/lxd/lxd/request"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/version"
+)
+
+// DefaultMaxSize is the default size above which the audit log is rotated.
+const DefaultMaxSize = 100 * 1024 * 1024
+
+// DefaultMaxFiles is the default number of rotated files kept.
+const DefaultMaxFiles = 5
+
+// indexInterval is the number of entries between two points of the in-memory index of a log file.
+const indexInterval = 1000
+
+// indexPoint is the offset of an entry in a log file, along with its timestamp.
+type indexPoint struct {
+	timestamp time.Time
+	offset    int64
+}
+
+// Log is an append-only log of the state-changing API requests. Once it grows above its maximum size, the
+// log is rotated: the current file gets a ".1" suffix, the number of the previously rotated files is bumped
+// and those above the maximum number of rotated files are deleted.
+type Log struct {
+	path     string
+	maxSize  int64
+	maxFiles int
+	file     *os.File
+	mu       sync.Mutex
+
+	// State of the current file, used to rotate it and to skip to the requested entries when reading.
+	size    int64
+	count   int
+	index   []indexPoint
+	rotated [][]indexPoint // Indexes of the rotated files, newest first.
+}
+
+// New opens the audit log at the given path, creating it if needed. The log is rotated once bigger than
+// maxSize bytes (0 means no limit), keeping maxFiles rotated files.
+func New(path string, maxSize int64, maxFiles int) (*Log, error) {
+	l := &Log{path: path, maxSize: maxSize, maxFiles: maxFiles}
+
+	for n := 1; n <= maxFiles; n++ {
+		index, _, _, err := indexFile(l.rotatedPath(n))
+		if err != nil {
+			return nil, err
+		}
+
+		l.rotated = append(l.rotated, index)
+	}
+
+	err := l.prune()
+	if err != nil {
+		return nil, err
+	}
+
+	err = l.open()
+	if err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// SetRetention changes the size above which the log is rotated (0 means no limit) and the number of rotated
+// files kept, deleting the rotated files above it.
+func (l *Log) SetRetention(maxSize int64, maxFiles int) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.maxSize = maxSize
+	l.maxFiles = maxFiles
+
+	return l.prune()
+}
+
+// rotatedPath returns the path of the n-th most recently rotated file.
+func (l *Log) rotatedPath(n int) string {
+	return fmt.Sprintf("%s.%d", l.path, n)
+}
+
+// prune deletes the rotated files above the maximum number of rotated files.
+// Must be called with the lock held (or before the log is in use).
+func (l *Log) prune() error {
+	for n := l.maxFiles + 1; ; n++ {
+		err := os.Remove(l.rotatedPath(n))
+		if os.IsNotExist(err) {
+			break
+		}
+
+		if err != nil {
+			return fmt.Errorf("Failed to delete rotated audit log %q: %w", l.rotatedPath(n), err)
+		}
+	}
+
+	if len(l.rotated) > l.maxFiles {
+		l.rotated = l.rotated[:l.maxFiles]
+	}
+
+	for len(l.rotated) < l.maxFiles {
+		l.rotated = append(l.rotated, nil)
+	}
+
+	return nil
+}
+
+// files returns the paths of the log files and their indexes, oldest first.
+func (l *Log) files() ([]string, [][]indexPoint) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	paths := []string{}
+	indexes := [][]indexPoint{}
+	for n := len(l.rotated); n >= 1; n-- {
+		paths = append(paths, l.rotatedPath(n))
+		indexes = append(indexes, l.rotated[n-1])
+	}
+
+	paths = append(paths, l.path)
+	indexes = append(indexes, l.index)
+
+	return paths, indexes
+}
+
+// open opens the current log file and indexes its entries.
+func (l *Log) open() error {
+	var err error
+	l.index, l.count, l.size, err = indexFile(l.path)
+	if err != nil {
+		return err
+	}
+
+	l.file, err = os.OpenFile(l.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return fmt.Errorf("Failed to open audit log %q: %w", l.path, err)
+	}
+
+	return nil
+}
+
+// indexFile returns the index of the entries of a log file, their number and the size of the file.
+// A missing file has no entries.
+func indexFile(path string) ([]indexPoint, int, int64, error) {
+	index := []indexPoint{}
+	count := 0
+	var offset int64
+
+	err := walkFile(path, 0, func(entry api.AuditEntry, entryOffset int64, length int) bool {
+		if count%indexInterval == 0 {
+			index = append(index, indexPoint{timestamp: entry.Timestamp, offset: entryOffset})
+		}
+
+		count++
+		offset = entryOffset + int64(length)
+
+		return true
+	})
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	return index, count, offset, nil
+}
+
+// Path returns the path of the audit log file.
+func (l *Log) Path() string {
+	return l.path
+}
+
+// Close closes the audit log.
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.file.Close()
+}
+
+// rotate moves the current log file aside, bumping the number of the previously rotated ones (the oldest
+// being replaced once there are as many as allowed), and starts a new file.
+// Must be called with the lock held.
+func (l *Log) rotate() error {
+	err := l.file.Close()
+	if err != nil {
+		return err
+	}
+
+	if l.maxFiles > 0 {
+		for n := l.maxFiles - 1; n >= 1; n-- {
+			err = os.Rename(l.rotatedPath(n), l.rotatedPath(n+1))
+			if err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("Failed to rotate audit log %q: %w", l.rotatedPath(n), err)
+			}
+		}
+
+		err = os.Rename(l.path, l.rotatedPath(1))
+		if err != nil {
+			return fmt.Errorf("Failed to rotate audit log %q: %w", l.path, err)
+		}
+
+		l.rotated = append([][]indexPoint{l.index}, l.rotated[:l.maxFiles-1]...)
+	} else {
+		err = os.Remove(l.path)
+		if err != nil {
+			return fmt.Errorf("Failed to rotate audit log %q: %w", l.path, err)
+		}
+	}
+
+	return l.open()
+}
+
+// Record appends an entry to the audit log.
+func (l *Log) Record(entry api.AuditEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	line = append(line, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.maxSize > 0 && l.size > 0 && l.size+int64(len(line)) > l.maxSize {
+		err = l.rotate()
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = l.file.Write(line)
+	if err != nil {
+		return fmt.Errorf("Failed to write audit log entry: %w", err)
+	}
+
+	if l.count%indexInterval == 0 {
+		l.index = append(l.index, indexPoint{timestamp: entry.Timestamp, offset: l.size})
+	}
+
+	l.count++
+	l.size += int64(len(line))
+
+	return nil
+}
+
+// Walk calls fn for each entry recorded at or after the given time, oldest first, until it returns false.
+// Only the end of the log, starting shortly before the requested time, is read.
+func (l *Log) Walk(since time.Time, fn func(entry api.AuditEntry) bool) error {
+	files, indexes := l.files()
+
+	done := false
+	for i, path := range files {
+		// Skip the file if the next one starts before the requested time.
+		if i < len(files)-1 && len(indexes[i+1]) > 0 && !indexes[i+1][0].timestamp.After(since) {
+			continue
+		}
+
+		// Start from the last indexed entry before the requested time.
+		var offset int64
+		for _, point := range indexes[i] {
+			if point.timestamp.After(since) {
+				break
+			}
+
+			offset = point.offset
+		}
+
+		err := walkFile(path, offset, func(entry api.AuditEntry, _ int64, _ int) bool {
+			if entry.Timestamp.Before(since) {
+				return true
+			}
+
+			done = !fn(entry)
+
+			return !done
+		})
+		if err != nil {
+			return err
+		}
+
+		if done {
+			break
+		}
+	}
+
+	return nil
+}
+
+// Entries returns the entries recorded at or after the given time, oldest first.
+func (l *Log) Entries(since time.Time) ([]api.AuditEntry, error) {
+	entries := []api.AuditEntry{}
+	err := l.Walk(since, func(entry api.AuditEntry) bool {
+		entries = append(entries, entry)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// Export writes the raw content of the log, including the rotated entries, to w.
+func (l *Log) Export(w io.Writer) error {
+	files, _ := l.files()
+
+	for _, path := range files {
+		file, err := os.Open(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+
+			return fmt.Errorf("Failed to open audit log %q: %w", path, err)
+		}
+
+		_, err = io.Copy(w, file)
+		file.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// walkFile calls fn with each entry of the log file starting at the given offset, along with its offset and
+// length, until it returns false. A missing file has no entries.
+func walkFile(path string, offset int64, fn func(entry api.AuditEntry, offset int64, length int) bool) error {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+
+		return fmt.Errorf("Failed to open audit log %q: %w", path, err)
+	}
+	defer file.Close()
+
+	_, err = file.Seek(offset, io.SeekStart)
+	if err != nil {
+		return err
+	}
+
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			// Ignore a partially written last line.
+			break
+		}
+
+		if err != nil {
+			return fmt.Errorf("Failed to read audit log %q: %w", path, err)
+		}
+
+		entry := api.AuditEntry{}
+		err = json.Unmarshal(bytes.TrimSpace(line), &entry)
+		if err != nil {
+			return fmt.Errorf("Failed to parse audit log entry: %w", err)
+		}
+
+		if !fn(entry, offset, len(line)) {
+			break
+		}
+
+		offset += int64(len(line))
+	}
+
+	return nil
+}
+
+// Recorder wraps a request and its response writer to record the request in the audit log once handled.
+type Recorder struct {
+	http.ResponseWriter
+
+	log    *Log
+	req    *http.Request
+	start  time.Time
+	hash   hash.Hash
+	body   *hashingReadCloser
+	status int
+	entry  api.AuditEntry // Entry recorded by Finish.
+}
+
+// NewRecorder returns a Recorder for the request. The request body gets hashed as it is read.
+func (l *Log) NewRecorder(w http.ResponseWriter, r *http.Request) *Recorder {
+	rec := &Recorder{
+		ResponseWriter: w,
+		log:            l,
+		req:            r,
+		start:          time.Now(),
+		hash:           sha256.New(),
+	}
+
+	if r.Body != nil {
+		rec.body = &hashingReadCloser{reader: r.Body, hash: rec.hash}
+		r.Body = rec.body
+	}
+
+	return rec
+}
+
+// WriteHeader records the status code of the response.
+func (rec *Recorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+// Write records an implicit success status code if none was set.
+func (rec *Recorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+
+	return rec.ResponseWriter.Write(b)
+}
+
+// Flush flushes the underlying response writer if supported.
+func (rec *Recorder) Flush() {
+	flusher, ok := rec.ResponseWriter.(http.Flusher)
+	if ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack hijacks the underlying connection if supported.
+func (rec *Recorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := rec.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("Response writer doesn't support hijacking")
+	}
+
+	if rec.status == 0 {
+		rec.status = http.StatusSwitchingProtocols
+	}
+
+	return hijacker.Hijack()
+}
+
+// maxUnreadBody is the maximum amount of request body left unread by the handler that is read when finishing
+// the recording, so that the whole body gets hashed.
+const maxUnreadBody = 1024 * 1024
+
+// Finish records the request in the audit log, on behalf of the given requestor (which defaults to the one
+// found in the request context).
+// The part of the body left unread by the handler is read to complete its hash. If the body can't be fully
+// read (because it was closed by the handler or is too large), the recorded body hash is left empty.
+func (rec *Recorder) Finish(requestor *api.EventLifecycleRequestor) error {
+	if requestor == nil {
+		requestor = request.CreateRequestor(rec.req)
+	}
+
+	bodyHash := ""
+	if rec.body == nil || rec.body.complete() {
+		bodyHash = hex.EncodeToString(rec.hash.Sum(nil))
+	}
+
+	rec.entry = api.AuditEntry{
+		Timestamp:  rec.start,
+		Requestor:  requestor,
+		Method:     rec.req.Method,
+		URL:        rec.req.URL.RequestURI(),
+		BodyHash:   bodyHash,
+		StatusCode: rec.status,
+		Operation:  rec.Operation(),
+	}
+
+	return rec.log.Record(rec.entry)
+}
+
+// Operation returns the ID of the background operation started by the request, if any.
+func (rec *Recorder) Operation() string {
+	if rec.status != http.StatusAccepted {
+		return ""
+	}
+
+	location, err := url.Parse(rec.Header().Get("Location"))
+	if err != nil {
+		return ""
+	}
+
+	prefix := fmt.Sprintf("/%s/operations/", version.APIVersion)
+	if !strings.HasPrefix(location.Path, prefix) {
+		return ""
+	}
+
+	return strings.TrimPrefix(location.Path, prefix)
+}
+
+// FinishOperation records the final status of the background operation started by the request, in a new
+// entry timestamped with the time the operation completed. Must be called after Finish.
+func (rec *Recorder) FinishOperation(status string) error {
+	entry := rec.entry
+	entry.Timestamp = time.Now()
+	entry.OperationStatus = status
+
+	return rec.log.Record(entry)
+}
+
+// hashingReadCloser hashes the body of a request as it is read.
+type hashingReadCloser struct {
+	reader io.ReadCloser
+	hash   hash.Hash
+	eof    bool
+	closed bool
+}
+
+// Read reads from the body, adding the read data to the hash.
+func (h *hashingReadCloser) Read(p []byte) (int, error) {
+	n, err := h.reader.Read(p)
+	h.hash.Write(p[:n])
+	if err == io.EOF {
+		h.eof = true
+	}
+
+	return n, err
+}
+
+// Close closes the body.
+func (h *hashingReadCloser) Close() error {
+	if !h.eof {
+		h.closed = true
+	}
+
+	return h.reader.Close()
+}
+
+// complete reads the rest of the body (up to maxUnreadBody) and returns whether all of it was hashed.
+func (h *hashingReadCloser) complete() bool {
+	if h.eof {
+		return true
+	}
+
+	if h.closed {
+		return false
+	}
+
+	_, err := io.Copy(io.Discard, io.LimitReader(h, maxUnreadBody))
+	if err != nil {
+		return false
+	}
+
+	return h.eof
+}
@@ -0,0 +1,195 @@
+package audit_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lxc/lxd/lxd/audit"
+	"github.com/lxc/lxd/shared/api"
+)
+
+// Requests are recorded with the hash of their body and their response status.
+func TestRecorder(t *testing.T) {
+	log, err := audit.New(filepath.Join(t.TempDir(), "audit.log"), 0, 0)
+	require.NoError(t, err)
+	defer log.Close()
+
+	r := httptest.NewRequest("POST", "/1.0/instances?project=foo", strings.NewReader("{}"))
+	w := httptest.NewRecorder()
+
+	recorder := log.NewRecorder(w, r)
+	_, err = ioutil.ReadAll(r.Body)
+	require.NoError(t, err)
+	recorder.WriteHeader(http.StatusAccepted)
+	require.NoError(t, recorder.Finish(nil))
+
+	entries, err := log.Entries(time.Time{})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	assert.Equal(t, "POST", entries[0].Method)
+	assert.Equal(t, "/1.0/instances?project=foo", entries[0].URL)
+	assert.Equal(t, "44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a", entries[0].BodyHash)
+	assert.Equal(t, http.StatusAccepted, entries[0].StatusCode)
+	assert.Equal(t, http.StatusAccepted, w.Code)
+
+	// Entries older than the requested time are skipped.
+	entries, err = log.Entries(time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.Len(t, entries, 0)
+}
+
+// The part of the body left unread by the handler is hashed too, unless the handler closed the body.
+func TestRecorder_UnreadBody(t *testing.T) {
+	log, err := audit.New(filepath.Join(t.TempDir(), "audit.log"), 0, 0)
+	require.NoError(t, err)
+	defer log.Close()
+
+	r := httptest.NewRequest("POST", "/1.0/instances", strings.NewReader("{}"))
+	recorder := log.NewRecorder(httptest.NewRecorder(), r)
+	require.NoError(t, recorder.Finish(nil))
+
+	r = httptest.NewRequest("POST", "/1.0/instances", strings.NewReader("{}"))
+	recorder = log.NewRecorder(httptest.NewRecorder(), r)
+	require.NoError(t, r.Body.Close())
+	require.NoError(t, recorder.Finish(nil))
+
+	entries, err := log.Entries(time.Time{})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	assert.Equal(t, "44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a", entries[0].BodyHash)
+	assert.Equal(t, "", entries[1].BodyHash)
+}
+
+// The log is rotated once above its maximum size, keeping the entries of the previous files.
+func TestLog_Rotate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	log, err := audit.New(path, 1024, 2)
+	require.NoError(t, err)
+	defer log.Close()
+
+	start := time.Now().UTC()
+	for i := 0; i < 40; i++ {
+		require.NoError(t, log.Record(api.AuditEntry{Timestamp: start.Add(time.Duration(i) * time.Second), Method: "POST"}))
+	}
+
+	assert.FileExists(t, path+".1")
+	assert.FileExists(t, path+".2")
+	assert.NoFileExists(t, path+".3")
+
+	entries, err := log.Entries(time.Time{})
+	require.NoError(t, err)
+	assert.Greater(t, len(entries), 0)
+	assert.Less(t, len(entries), 40)
+
+	// All the returned entries are in order and the newest one is always kept.
+	for i := 1; i < len(entries); i++ {
+		assert.True(t, entries[i].Timestamp.After(entries[i-1].Timestamp))
+	}
+
+	assert.Equal(t, start.Add(39*time.Second), entries[len(entries)-1].Timestamp)
+
+	// The export contains the same entries.
+	buf := &bytes.Buffer{}
+	require.NoError(t, log.Export(buf))
+	assert.Equal(t, len(entries), strings.Count(buf.String(), "\n"))
+
+	// Entries past the rotated files are skipped when walking from a recent time.
+	recent, err := log.Entries(entries[len(entries)-1].Timestamp)
+	require.NoError(t, err)
+	assert.Len(t, recent, 1)
+
+	// Lowering the number of rotated files deletes the oldest ones.
+	require.NoError(t, log.SetRetention(1024, 1))
+	assert.FileExists(t, path+".1")
+	assert.NoFileExists(t, path+".2")
+
+	fewer, err := log.Entries(time.Time{})
+	require.NoError(t, err)
+	assert.Less(t, len(fewer), len(entries))
+	assert.Equal(t, start.Add(39*time.Second), fewer[len(fewer)-1].Timestamp)
+
+	// Reopening the log keeps the rotated entries.
+	require.NoError(t, log.Close())
+	log, err = audit.New(path, 1024, 1)
+	require.NoError(t, err)
+	defer log.Close()
+
+	reopened, err := log.Entries(time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, fewer, reopened)
+}
+
+// Requests starting a background operation are recorded with its ID, and its final status is recorded once
+// it's done.
+func TestRecorder_Operation(t *testing.T) {
+	log, err := audit.New(filepath.Join(t.TempDir(), "audit.log"), 0, 0)
+	require.NoError(t, err)
+	defer log.Close()
+
+	r := httptest.NewRequest("DELETE", "/1.0/instances/c1?project=foo", nil)
+	w := httptest.NewRecorder()
+
+	recorder := log.NewRecorder(w, r)
+	recorder.Header().Set("Location", "/1.0/operations/1234?project=foo")
+	recorder.WriteHeader(http.StatusAccepted)
+	require.NoError(t, recorder.Finish(nil))
+	assert.Equal(t, "1234", recorder.Operation())
+	require.NoError(t, recorder.FinishOperation("Success"))
+
+	entries, err := log.Entries(time.Time{})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	assert.Equal(t, "1234", entries[0].Operation)
+	assert.Equal(t, "", entries[0].OperationStatus)
+	assert.Equal(t, "DELETE", entries[1].Method)
+	assert.Equal(t, "/1.0/instances/c1?project=foo", entries[1].URL)
+	assert.Equal(t, "1234", entries[1].Operation)
+	assert.Equal(t, "Success", entries[1].OperationStatus)
+
+	// Synchronous requests have no operation.
+	r = httptest.NewRequest("POST", "/1.0/profiles", nil)
+	recorder = log.NewRecorder(httptest.NewRecorder(), r)
+	recorder.WriteHeader(http.StatusOK)
+	assert.Equal(t, "", recorder.Operation())
+}
+
+// Walking the log starts from the requested time and stops when asked to.
+func TestLog_Walk(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	log, err := audit.New(path, 0, 0)
+	require.NoError(t, err)
+
+	start := time.Now().UTC()
+	for i := 0; i < 2500; i++ {
+		require.NoError(t, log.Record(api.AuditEntry{Timestamp: start.Add(time.Duration(i) * time.Second), Method: "POST"}))
+	}
+
+	require.NoError(t, log.Close())
+
+	// Reopening the log indexes the existing entries.
+	log, err = audit.New(path, 0, 0)
+	require.NoError(t, err)
+	defer log.Close()
+
+	entries := []api.AuditEntry{}
+	err = log.Walk(start.Add(1500*time.Second), func(entry api.AuditEntry) bool {
+		entries = append(entries, entry)
+		return len(entries) < 10
+	})
+	require.NoError(t, err)
+	require.Len(t, entries, 10)
+	assert.Equal(t, start.Add(1500*time.Second), entries[0].Timestamp)
+	assert.Equal(t, start.Add(1509*time.Second), entries[9].Timestamp)
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/lxc/lxd/lxd/oidc"
+)
+
+// oidcClaimsFromRequest returns the claims of the OpenID Connect token passed as a bearer token in
+// the request, if any. An error is returned if the token isn't valid.
+func oidcClaimsFromRequest(auth *oidcAuth, r *http.Request) (*oidc.Claims, error) {
+	token := bearerToken(r)
+	if token == "" || !oidc.IsJWT(token) {
+		return nil, nil
+	}
+
+	return auth.verifier.Verify(token)
+}
+
+// oidcSetHeaders advertises the OpenID Connect provider to clients, so they can log in with it.
+func oidcSetHeaders(auth *oidcAuth, w http.ResponseWriter) {
+	w.Header().Set("X-LXD-OIDC-issuer", auth.verifier.Issuer())
+	w.Header().Set("X-LXD-OIDC-clientid", auth.verifier.ClientID())
+
+	if auth.verifier.Audience() != "" {
+		w.Header().Set("X-LXD-OIDC-audience", auth.verifier.Audience())
+	}
+}
@@ -0,0 +1,526 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/lifecycle"
+	"github.com/lxc/lxd/lxd/project"
+	"github.com/lxc/lxd/lxd/request"
+	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/lxd/util"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/version"
+)
+
+var authTokensCmd = APIEndpoint{
+	Path: "auth/tokens",
+
+	Get:  APIEndpointAction{Handler: authTokensGet},
+	Post: APIEndpointAction{Handler: authTokensPost},
+}
+
+var authTokenCmd = APIEndpoint{
+	Path: "auth/tokens/{name}",
+
+	Delete: APIEndpointAction{Handler: authTokenDelete},
+	Get:    APIEndpointAction{Handler: authTokenGet},
+	Patch:  APIEndpointAction{Handler: authTokenPatch},
+	Put:    APIEndpointAction{Handler: authTokenPut},
+}
+
+// authTokenHash returns the hash of an API token secret, as stored in the database.
+func authTokenHash(secret string) string {
+	hash := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(hash[:])
+}
+
+// bearerToken returns the bearer token passed in the Authorization header of the request, if any.
+func bearerToken(r *http.Request) string {
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, "Bearer ") {
+		return ""
+	}
+
+	return strings.TrimPrefix(header, "Bearer ")
+}
+
+// authTokenFromRequest returns the API token passed as a bearer token in the request, if any.
+// An error is returned if a bearer token was passed but isn't a valid, unexpired, API token.
+func authTokenFromRequest(d *Daemon, r *http.Request) (*db.AuthToken, error) {
+	secret := bearerToken(r)
+	if secret == "" {
+		return nil, nil
+	}
+
+	var token *db.AuthToken
+	err := d.cluster.Transaction(func(tx *db.ClusterTx) error {
+		var err error
+		token, err = tx.GetAuthTokenBySecretHash(authTokenHash(secret))
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Invalid API token")
+	}
+
+	if token.Expired() {
+		return nil, fmt.Errorf("API token %q has expired", token.Name)
+	}
+
+	return token, nil
+}
+
+// swagger:operation GET /1.0/auth/tokens auth auth_tokens_get
+//
+// Get the API tokens
+//
+// Returns a list of API tokens (URLs).
+//
+// ---
+// produces:
+//   - application/json
+// responses:
+//   "200":
+//     description: API endpoints
+//     schema:
+//       type: object
+//       description: Sync response
+//       properties:
+//         type:
+//           type: string
+//           description: Response type
+//           example: sync
+//         status:
+//           type: string
+//           description: Status description
+//           example: Success
+//         status_code:
+//           type: integer
+//           description: Status code
+//           example: 200
+//         metadata:
+//           type: array
+//           description: List of endpoints
+//           items:
+//             type: string
+//           example: |-
+//             [
+//               "/1.0/auth/tokens/ci",
+//               "/1.0/auth/tokens/backup"
+//             ]
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+
+// swagger:operation GET /1.0/auth/tokens?recursion=1 auth auth_tokens_get_recursion1
+//
+// Get the API tokens
+//
+// Returns a list of API tokens (structs).
+//
+// ---
+// produces:
+//   - application/json
+// responses:
+//   "200":
+//     description: API endpoints
+//     schema:
+//       type: object
+//       description: Sync response
+//       properties:
+//         type:
+//           type: string
+//           description: Response type
+//           example: sync
+//         status:
+//           type: string
+//           description: Status description
+//           example: Success
+//         status_code:
+//           type: integer
+//           description: Status code
+//           example: 200
+//         metadata:
+//           type: array
+//           description: List of API tokens
+//           items:
+//             $ref: "#/definitions/AuthToken"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func authTokensGet(d *Daemon, r *http.Request) response.Response {
+	recursion := util.IsRecursionRequest(r)
+
+	var dbTokens []db.AuthToken
+	err := d.cluster.Transaction(func(tx *db.ClusterTx) error {
+		var err error
+		dbTokens, err = tx.GetAuthTokens()
+		return err
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if recursion {
+		tokens := make([]api.AuthToken, 0, len(dbTokens))
+		for _, token := range dbTokens {
+			tokens = append(tokens, token.ToAPI())
+		}
+
+		return response.SyncResponse(true, tokens)
+	}
+
+	urls := make([]string, 0, len(dbTokens))
+	for _, token := range dbTokens {
+		urls = append(urls, fmt.Sprintf("/%s/auth/tokens/%s", version.APIVersion, url.PathEscape(token.Name)))
+	}
+
+	return response.SyncResponse(true, urls)
+}
+
+// swagger:operation POST /1.0/auth/tokens auth auth_tokens_post
+//
+// Create an API token
+//
+// Creates a new API token and returns its secret.
+// The secret is only returned once and can't be retrieved later.
+//
+// ---
+// consumes:
+//   - application/json
+// produces:
+//   - application/json
+// parameters:
+//   - in: body
+//     name: token
+//     description: API token
+//     required: true
+//     schema:
+//       $ref: "#/definitions/AuthTokensPost"
+// responses:
+//   "200":
+//     description: API token secret
+//     schema:
+//       type: object
+//       description: Sync response
+//       properties:
+//         type:
+//           type: string
+//           description: Response type
+//           example: sync
+//         status:
+//           type: string
+//           description: Status description
+//           example: Success
+//         status_code:
+//           type: integer
+//           description: Status code
+//           example: 200
+//         metadata:
+//           $ref: "#/definitions/AuthTokenSecret"
+//   "400":
+//     $ref: "#/responses/BadRequest"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func authTokensPost(d *Daemon, r *http.Request) response.Response {
+	req := api.AuthTokensPost{}
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	if req.Name == "" {
+		return response.BadRequest(fmt.Errorf("No name provided"))
+	}
+
+	if strings.Contains(req.Name, "/") {
+		return response.BadRequest(fmt.Errorf("API token names may not contain slashes"))
+	}
+
+	if !req.ExpiresAt.IsZero() && req.ExpiresAt.Before(time.Now()) {
+		return response.BadRequest(fmt.Errorf("API token expiry must be in the future"))
+	}
+
+	err = certificateValidateProjectRoles(req.Projects, req.ProjectRoles)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	secret, err := shared.RandomCryptoString()
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	token := db.AuthToken{
+		Name:        req.Name,
+		Description: req.Description,
+		SecretHash:  authTokenHash(secret),
+		Restricted:  req.Restricted,
+		Projects:    req.Projects,
+		Roles:       req.ProjectRoles,
+		ExpiresAt:   req.ExpiresAt,
+	}
+
+	err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
+		_, err := tx.GetAuthToken(req.Name)
+		if err == nil {
+			return api.StatusErrorf(http.StatusConflict, "API token %q already exists", req.Name)
+		} else if err != db.ErrNoSuchObject {
+			return err
+		}
+
+		_, err = tx.CreateAuthToken(token)
+		return err
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	d.State().Events.SendLifecycle(project.Default, lifecycle.AuthTokenCreated.Event(req.Name, request.CreateRequestor(r), nil))
+
+	return response.SyncResponseLocation(true, api.AuthTokenSecret{Name: req.Name, Secret: secret}, fmt.Sprintf("/%s/auth/tokens/%s", version.APIVersion, url.PathEscape(req.Name)))
+}
+
+// swagger:operation GET /1.0/auth/tokens/{name} auth auth_token_get
+//
+// Get the API token
+//
+// Gets a specific API token (without its secret).
+//
+// ---
+// produces:
+//   - application/json
+// responses:
+//   "200":
+//     description: API token
+//     schema:
+//       type: object
+//       description: Sync response
+//       properties:
+//         type:
+//           type: string
+//           description: Response type
+//           example: sync
+//         status:
+//           type: string
+//           description: Status description
+//           example: Success
+//         status_code:
+//           type: integer
+//           description: Status code
+//           example: 200
+//         metadata:
+//           $ref: "#/definitions/AuthToken"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "404":
+//     $ref: "#/responses/NotFound"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func authTokenGet(d *Daemon, r *http.Request) response.Response {
+	name, err := url.PathUnescape(mux.Vars(r)["name"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	var token *db.AuthToken
+	err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
+		token, err = tx.GetAuthToken(name)
+		return err
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	resp := token.ToAPI()
+
+	return response.SyncResponseETag(true, resp, resp.Writable())
+}
+
+// swagger:operation PATCH /1.0/auth/tokens/{name} auth auth_token_patch
+//
+// Partially update the API token
+//
+// Updates a subset of the API token configuration.
+//
+// ---
+// consumes:
+//   - application/json
+// produces:
+//   - application/json
+// parameters:
+//   - in: body
+//     name: token
+//     description: API token configuration
+//     required: true
+//     schema:
+//       $ref: "#/definitions/AuthTokenPut"
+// responses:
+//   "200":
+//     $ref: "#/responses/EmptySyncResponse"
+//   "400":
+//     $ref: "#/responses/BadRequest"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "412":
+//     $ref: "#/responses/PreconditionFailed"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func authTokenPatch(d *Daemon, r *http.Request) response.Response {
+	name, err := url.PathUnescape(mux.Vars(r)["name"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	// Get the current token.
+	var token *db.AuthToken
+	err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
+		token, err = tx.GetAuthToken(name)
+		return err
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	// Validate the ETag.
+	current := token.ToAPI()
+	err = util.EtagCheck(r, current.Writable())
+	if err != nil {
+		return response.PreconditionFailed(err)
+	}
+
+	// Apply the changes on top of the current configuration.
+	req := current.Writable()
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	return doAuthTokenUpdate(d, r, name, req)
+}
+
+// swagger:operation PUT /1.0/auth/tokens/{name} auth auth_token_put
+//
+// Update the API token
+//
+// Updates the entire API token configuration.
+//
+// ---
+// consumes:
+//   - application/json
+// produces:
+//   - application/json
+// parameters:
+//   - in: body
+//     name: token
+//     description: API token configuration
+//     required: true
+//     schema:
+//       $ref: "#/definitions/AuthTokenPut"
+// responses:
+//   "200":
+//     $ref: "#/responses/EmptySyncResponse"
+//   "400":
+//     $ref: "#/responses/BadRequest"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "412":
+//     $ref: "#/responses/PreconditionFailed"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func authTokenPut(d *Daemon, r *http.Request) response.Response {
+	name, err := url.PathUnescape(mux.Vars(r)["name"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	// Get the current token.
+	var token *db.AuthToken
+	err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
+		token, err = tx.GetAuthToken(name)
+		return err
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	// Validate the ETag.
+	current := token.ToAPI()
+	err = util.EtagCheck(r, current.Writable())
+	if err != nil {
+		return response.PreconditionFailed(err)
+	}
+
+	req := api.AuthTokenPut{}
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	return doAuthTokenUpdate(d, r, name, req)
+}
+
+// doAuthTokenUpdate replaces the configuration of an API token.
+func doAuthTokenUpdate(d *Daemon, r *http.Request, name string, req api.AuthTokenPut) response.Response {
+	err := d.cluster.Transaction(func(tx *db.ClusterTx) error {
+		return tx.UpdateAuthToken(name, req.Description)
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	d.State().Events.SendLifecycle(project.Default, lifecycle.AuthTokenUpdated.Event(name, request.CreateRequestor(r), nil))
+
+	return response.EmptySyncResponse
+}
+
+// swagger:operation DELETE /1.0/auth/tokens/{name} auth auth_token_delete
+//
+// Delete the API token
+//
+// Revokes the API token.
+//
+// ---
+// produces:
+//   - application/json
+// responses:
+//   "200":
+//     $ref: "#/responses/EmptySyncResponse"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "404":
+//     $ref: "#/responses/NotFound"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func authTokenDelete(d *Daemon, r *http.Request) response.Response {
+	name, err := url.PathUnescape(mux.Vars(r)["name"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
+		return tx.DeleteAuthToken(name)
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	d.State().Events.SendLifecycle(project.Default, lifecycle.AuthTokenDeleted.Event(name, request.CreateRequestor(r), nil))
+
+	return response.EmptySyncResponse
+}
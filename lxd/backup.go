@@ -18,6 +18,7 @@ import (
 	"github.com/lxc/lxd/lxd/instance"
 	"github.com/lxc/lxd/lxd/instance/instancetype"
 	"github.com/lxc/lxd/lxd/lifecycle"
+	"github.com/lxc/lxd/lxd/migration"
 	"github.com/lxc/lxd/lxd/operations"
 	"github.com/lxc/lxd/lxd/project"
 	"github.com/lxc/lxd/lxd/revert"
@@ -35,7 +36,7 @@ import (
 )
 
 // Create a new backup.
-func backupCreate(s *state.State, args db.InstanceBackup, sourceInst instance.Instance) error {
+func backupCreate(s *state.State, args db.InstanceBackup, sourceInst instance.Instance, op *operations.Operation) error {
 	logger := logging.AddContext(logger.Log, log.Ctx{"project": sourceInst.Project(), "instance": sourceInst.Name(), "name": args.Name})
 	logger.Debug("Instance backup started")
 	defer logger.Debug("Instance backup finished")
@@ -131,6 +132,9 @@ func backupCreate(s *state.State, args db.InstanceBackup, sourceInst instance.In
 	defer tarPipeWriter.Close() // Ensure that go routine below always ends.
 	tarWriter := instancewriter.NewInstanceTarWriter(tarPipeWriter, idmap)
 
+	// Report the progress of the tarball as it gets written.
+	tarReader := migration.ProgressReader(op, "backup_progress", "Backup")(tarPipeReader)
+
 	// Setup tar writer go routine, with optional compression.
 	tarWriterRes := make(chan error, 0)
 	var compressErr error
@@ -139,14 +143,14 @@ func backupCreate(s *state.State, args db.InstanceBackup, sourceInst instance.In
 		logger.Debug("Started backup tarball writer")
 		defer logger.Debug("Finished backup tarball writer")
 		if compress != "none" {
-			compressErr = compressFile(compress, tarPipeReader, tarFileWriter)
+			compressErr = compressFile(compress, tarReader, tarFileWriter)
 
 			// If a compression error occurred, close the tarPipeWriter to end the export.
 			if compressErr != nil {
 				tarPipeWriter.Close()
 			}
 		} else {
-			_, err = io.Copy(tarFileWriter, tarPipeReader)
+			_, err = io.Copy(tarFileWriter, tarReader)
 		}
 		resCh <- err
 	}(tarWriterRes)
@@ -311,7 +315,7 @@ func pruneExpiredContainerBackups(ctx context.Context, d *Daemon) error {
 	return nil
 }
 
-func volumeBackupCreate(s *state.State, args db.StoragePoolVolumeBackup, projectName string, poolName string, volumeName string) error {
+func volumeBackupCreate(s *state.State, args db.StoragePoolVolumeBackup, projectName string, poolName string, volumeName string, op *operations.Operation) error {
 	logger := logging.AddContext(logger.Log, log.Ctx{"project": projectName, "storage_volume": volumeName, "name": args.Name})
 	logger.Debug("Volume backup started")
 	defer logger.Debug("Volume backup finished")
@@ -393,6 +397,9 @@ func volumeBackupCreate(s *state.State, args db.StoragePoolVolumeBackup, project
 	defer tarPipeWriter.Close() // Ensure that go routine below always ends.
 	tarWriter := instancewriter.NewInstanceTarWriter(tarPipeWriter, nil)
 
+	// Report the progress of the tarball as it gets written.
+	tarReader := migration.ProgressReader(op, "backup_progress", "Backup")(tarPipeReader)
+
 	// Setup tar writer go routine, with optional compression.
 	tarWriterRes := make(chan error, 0)
 	var compressErr error
@@ -401,14 +408,14 @@ func volumeBackupCreate(s *state.State, args db.StoragePoolVolumeBackup, project
 		logger.Debug("Started backup tarball writer")
 		defer logger.Debug("Finished backup tarball writer")
 		if compress != "none" {
-			compressErr = compressFile(compress, tarPipeReader, tarFileWriter)
+			compressErr = compressFile(compress, tarReader, tarFileWriter)
 
 			// If a compression error occurred, close the tarPipeWriter to end the export.
 			if compressErr != nil {
 				tarPipeWriter.Close()
 			}
 		} else {
-			_, err = io.Copy(tarFileWriter, tarPipeReader)
+			_, err = io.Copy(tarFileWriter, tarReader)
 		}
 		resCh <- err
 	}(tarWriterRes)
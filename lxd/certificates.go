@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"crypto/x509"
 	"encoding/base64"
 	"encoding/json"
@@ -18,10 +19,13 @@ import (
 	clusterRequest "github.com/lxc/lxd/lxd/cluster/request"
 	"github.com/lxc/lxd/lxd/db"
 	"github.com/lxc/lxd/lxd/lifecycle"
+	"github.com/lxc/lxd/lxd/node"
+	"github.com/lxc/lxd/lxd/operations"
 	"github.com/lxc/lxd/lxd/project"
 	"github.com/lxc/lxd/lxd/rbac"
 	"github.com/lxc/lxd/lxd/request"
 	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/lxd/task"
 	"github.com/lxc/lxd/lxd/util"
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/api"
@@ -32,7 +36,7 @@ import (
 
 type certificateCache struct {
 	Certificates map[db.CertificateType]map[string]x509.Certificate
-	Projects     map[string][]string
+	Projects     map[string]map[string]string // Role of the restricted certificates in each of their projects.
 	Lock         sync.Mutex
 }
 
@@ -139,9 +143,15 @@ func certificatesGet(d *Daemon, r *http.Request) response.Response {
 		certResponses := []api.Certificate{}
 
 		var baseCerts []db.Certificate
+		var roles map[string]map[string]string
 		var err error
 		err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
 			baseCerts, err = tx.GetCertificates(db.CertificateFilter{})
+			if err != nil {
+				return err
+			}
+
+			roles, err = tx.GetCertificateProjectRoles(db.CertificateFilter{})
 			return err
 		})
 		if err != nil {
@@ -149,7 +159,9 @@ func certificatesGet(d *Daemon, r *http.Request) response.Response {
 		}
 
 		for _, baseCert := range baseCerts {
-			certResponses = append(certResponses, baseCert.ToAPI())
+			cert := baseCert.ToAPI()
+			cert.ProjectRoles = roles[baseCert.Fingerprint]
+			certResponses = append(certResponses, cert)
 		}
 		return response.SyncResponse(true, certResponses)
 	}
@@ -171,13 +183,19 @@ func updateCertificateCache(d *Daemon) {
 	logger.Debug("Refreshing trusted certificate cache")
 
 	newCerts := map[db.CertificateType]map[string]x509.Certificate{}
-	newProjects := map[string][]string{}
+	newProjects := map[string]map[string]string{}
 
 	var dbCerts []db.Certificate
+	var dbRoles map[string]map[string]string
 	var localCerts []db.Certificate
 	var err error
 	err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
 		dbCerts, err = tx.GetCertificates(db.CertificateFilter{})
+		if err != nil {
+			return err
+		}
+
+		dbRoles, err = tx.GetCertificateProjectRoles(db.CertificateFilter{})
 		return err
 	})
 	if err != nil {
@@ -205,7 +223,12 @@ func updateCertificateCache(d *Daemon) {
 		newCerts[dbCert.Type][shared.CertFingerprint(cert)] = *cert
 
 		if dbCert.Restricted {
-			newProjects[shared.CertFingerprint(cert)] = dbCert.Projects
+			roles := map[string]string{}
+			for _, projectName := range dbCert.Projects {
+				roles[projectName] = dbRoles[dbCert.Fingerprint][projectName]
+			}
+
+			newProjects[shared.CertFingerprint(cert)] = roles
 		}
 
 		// Add server certs to list of certificates to store in local database to allow cluster restart.
@@ -350,12 +373,144 @@ func clusterMemberJoinTokenValid(d *Daemon, r *http.Request, projectName string,
 	return nil, nil
 }
 
+// certificateTokenDecode decodes a base64 and JSON encoded certificate add token.
+func certificateTokenDecode(input string) (*api.CertificateAddToken, error) {
+	tokenJSON, err := base64.StdEncoding.DecodeString(input)
+	if err != nil {
+		return nil, err
+	}
+
+	var t api.CertificateAddToken
+	err = json.Unmarshal(tokenJSON, &t)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.ClientName == "" {
+		return nil, fmt.Errorf("No client name in certificate add token")
+	}
+
+	if t.Secret == "" {
+		return nil, fmt.Errorf("No secret in certificate add token")
+	}
+
+	return &t, nil
+}
+
+// certificateTokenValid searches for the certificate add token operation that matches the token provided.
+// Returns the matching operation if found and cancels the operation, otherwise returns nil.
+func certificateTokenValid(d *Daemon, r *http.Request, addToken *api.CertificateAddToken) (*api.Operation, error) {
+	ops, err := operationsGetByType(d, r, project.Default, db.OperationCertificateAddToken)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed getting certificate add token operations")
+	}
+
+	var foundOp *api.Operation
+	for _, op := range ops {
+		if op.StatusCode != api.Running {
+			continue // Tokens are single use, so if cancelled but not deleted yet its not available.
+		}
+
+		opSecret, ok := op.Metadata["secret"]
+		if !ok {
+			continue
+		}
+
+		opClientName, ok := op.Metadata["clientName"]
+		if !ok {
+			continue
+		}
+
+		if opClientName == addToken.ClientName && opSecret == addToken.Secret {
+			foundOp = op
+			break
+		}
+	}
+
+	if foundOp != nil {
+		// Token is single-use, so cancel it now.
+		err = operationCancel(d, r, project.Default, foundOp)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to cancel operation %q", foundOp.ID)
+		}
+
+		return foundOp, nil
+	}
+
+	// No operation found.
+	return nil, nil
+}
+
+// certificateTokenCreate creates a single-use certificate add token operation for the requested client.
+func certificateTokenCreate(d *Daemon, r *http.Request, req api.CertificatesPost) response.Response {
+	if req.Name == "" {
+		return response.BadRequest(fmt.Errorf("A client name must be provided for the certificate add token"))
+	}
+
+	if req.Type != api.CertificateTypeClient {
+		return response.BadRequest(fmt.Errorf("Certificate add tokens can only be created for client certificates"))
+	}
+
+	address, err := node.HTTPSAddress(d.db)
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	addresses, err := util.ListenAddresses(address)
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	if len(addresses) < 1 {
+		return response.BadRequest(fmt.Errorf("The server isn't listening on the network"))
+	}
+
+	// Generate the secret which the client will present, encoded inside the token, to get its
+	// certificate added.
+	secret, err := shared.RandomCryptoString()
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	// Include the fingerprint of the network certificate so the client can automatically trust it.
+	fingerprint, err := shared.CertFingerprintStr(string(d.endpoints.NetworkPublicKey()))
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	meta := map[string]interface{}{
+		"clientName":  req.Name,
+		"secret":      secret,
+		"fingerprint": fingerprint,
+		"addresses":   addresses,
+		"request":     req.CertificatePut, // Applied to the certificate once added.
+	}
+
+	resources := map[string][]string{}
+	resources["certificates"] = []string{}
+
+	op, err := operations.OperationCreate(d.State(), project.Default, operations.OperationClassToken, db.OperationCertificateAddToken, resources, meta, nil, nil, nil, r)
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	return operations.OperationResponse(op)
+}
+
+// certificatesPostTrusted returns whether the requestor can add a certificate without a password or token.
+// Only administrators can, as the added certificates aren't restricted unless requested, the other trusted
+// clients (restricted certificates and tokens, OpenID Connect and Candid users) go through the untrusted path.
+func certificatesPostTrusted(trusted bool, r *http.Request) bool {
+	return trusted && rbac.UserIsAdmin(r)
+}
+
 // swagger:operation POST /1.0/certificates?public certificates certificates_post_untrusted
 //
 // Add a trusted certificate
 //
 // Adds a certificate to the trust store as an untrusted user.
-// In this mode, the `password` property must be set to the correct value.
+// In this mode, the `password` property must be set to the correct value,
+// either the trust password or a certificate add token.
 //
 // The `certificate` field can be omitted in which case the TLS client
 // certificate in use for the connection will be retrieved and added to the
@@ -393,6 +548,12 @@ func clusterMemberJoinTokenValid(d *Daemon, r *http.Request, projectName string,
 // Adds a certificate to the trust store.
 // In this mode, the `password` property is always ignored.
 //
+// When the `token` property is set (or `?token=true` is passed), a
+// single-use certificate add token is created instead, as a token
+// operation. A client can then present that token as its `password`
+// to have its certificate added with the requested name, type and
+// restrictions.
+//
 // ---
 // consumes:
 //   - application/json
@@ -428,16 +589,55 @@ func certificatesPost(d *Daemon, r *http.Request) response.Response {
 		return response.SmartError(err)
 	}
 
-	trusted, _, protocol, err := d.Authenticate(nil, r)
+	trusted, _, _, err := d.Authenticate(nil, r)
 	if err != nil {
 		return response.SmartError(err)
 	}
 
-	if !trusted || (protocol == "candid" && !rbac.UserIsAdmin(r)) {
+	// Whether to create a certificate add token rather than add a certificate.
+	tokenRequested := req.Token || shared.IsTrue(queryParam(r, "token"))
+
+	// Name of the client when adding a certificate using a certificate add token.
+	var tokenClientName string
+
+	if !certificatesPostTrusted(trusted, r) {
+		// Only trusted administrators can create certificate add tokens.
+		if tokenRequested {
+			return response.Forbidden(nil)
+		}
+
 		if req.Password != "" {
+			// Check if certificate add token supplied as password.
+			addToken, addTokenErr := certificateTokenDecode(req.Password)
+
 			// Check if cluster member join token supplied as password.
 			joinToken, err := clusterMemberJoinTokenDecode(req.Password)
-			if err == nil {
+			if addTokenErr == nil {
+				// If so then check there is a matching certificate add operation.
+				addOp, err := certificateTokenValid(d, r, addToken)
+				if err != nil {
+					return response.InternalError(errors.Wrapf(err, "Failed during search for certificate add token operation"))
+				}
+
+				if addOp == nil {
+					return response.Forbidden(fmt.Errorf("No matching certificate add operation found"))
+				}
+
+				// Add the certificate with the name, type and restrictions requested with the token.
+				reqJSON, err := json.Marshal(addOp.Metadata["request"])
+				if err != nil {
+					return response.InternalError(err)
+				}
+
+				reqPut := api.CertificatePut{}
+				err = json.Unmarshal(reqJSON, &reqPut)
+				if err != nil {
+					return response.InternalError(errors.Wrapf(err, "Failed parsing certificate add token operation"))
+				}
+
+				req.CertificatePut = reqPut
+				tokenClientName = addToken.ClientName
+			} else if err == nil {
 				// If so then check there is a matching join operation.
 				joinOp, err := clusterMemberJoinTokenValid(d, r, project.Default, joinToken)
 				if err != nil {
@@ -464,6 +664,20 @@ func certificatesPost(d *Daemon, r *http.Request) response.Response {
 		return response.BadRequest(err)
 	}
 
+	err = certificateValidateProjectRoles(req.Projects, req.ProjectRoles)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	// Create a certificate add token rather than adding a certificate if requested.
+	if tokenRequested {
+		if !rbac.UserIsAdmin(r) {
+			return response.Forbidden(nil)
+		}
+
+		return certificateTokenCreate(d, r, req)
+	}
+
 	// Extract the certificate.
 	var cert *x509.Certificate
 	var name string
@@ -499,6 +713,10 @@ func certificatesPost(d *Daemon, r *http.Request) response.Response {
 		return response.BadRequest(fmt.Errorf("Can't use TLS data on non-TLS link"))
 	}
 
+	if tokenClientName != "" {
+		name = tokenClientName
+	}
+
 	fingerprint := shared.CertFingerprint(cert)
 
 	if !isClusterNotification(r) {
@@ -523,7 +741,7 @@ func certificatesPost(d *Daemon, r *http.Request) response.Response {
 			return response.SmartError(err)
 		}
 
-		err = d.cluster.UpdateCertificateProjects(int(id), dbCert.Projects)
+		err = d.cluster.UpdateCertificateProjects(int(id), dbCert.Projects, req.ProjectRoles)
 		if err != nil {
 			return response.SmartError(err)
 		}
@@ -537,7 +755,7 @@ func certificatesPost(d *Daemon, r *http.Request) response.Response {
 			Certificate: base64.StdEncoding.EncodeToString(cert.Raw),
 		}
 		req.Name = name
-		req.Type = api.CertificateTypeClient
+		req.Type = dbCert.ToAPIType()
 
 		err = notifier(func(client lxd.InstanceServer) error {
 			return client.CreateCertificate(req)
@@ -597,7 +815,11 @@ func certificateGet(d *Daemon, r *http.Request) response.Response {
 		return response.SmartError(err)
 	}
 
-	cert := dbCertInfo.ToAPI()
+	cert, err := certificateToAPI(d, dbCertInfo)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
 	return response.SyncResponseETag(true, cert, cert)
 }
 
@@ -643,7 +865,12 @@ func certificatePut(d *Daemon, r *http.Request) response.Response {
 	fingerprint = oldEntry.Fingerprint
 
 	// Validate the ETag.
-	err = util.EtagCheck(r, oldEntry.ToAPI())
+	oldCert, err := certificateToAPI(d, oldEntry)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	err = util.EtagCheck(r, oldCert)
 	if err != nil {
 		return response.PreconditionFailed(err)
 	}
@@ -703,13 +930,17 @@ func certificatePatch(d *Daemon, r *http.Request) response.Response {
 	fingerprint = oldEntry.Fingerprint
 
 	// Validate the ETag.
-	err = util.EtagCheck(r, oldEntry.ToAPI())
+	req, err := certificateToAPI(d, oldEntry)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	err = util.EtagCheck(r, req)
 	if err != nil {
 		return response.PreconditionFailed(err)
 	}
 
 	// Apply the changes.
-	req := oldEntry.ToAPI()
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		return response.BadRequest(err)
 	}
@@ -726,6 +957,11 @@ func doCertificateUpdate(d *Daemon, dbInfo db.Certificate, fingerprint string, r
 			return response.BadRequest(err)
 		}
 
+		err = certificateValidateProjectRoles(req.Projects, req.ProjectRoles)
+		if err != nil {
+			return response.BadRequest(err)
+		}
+
 		// Convert to the database type.
 		cert := db.Certificate{
 			// Read-only fields.
@@ -744,7 +980,7 @@ func doCertificateUpdate(d *Daemon, dbInfo db.Certificate, fingerprint string, r
 			return response.SmartError(err)
 		}
 
-		err = d.cluster.UpdateCertificateProjects(dbInfo.ID, cert.Projects)
+		err = d.cluster.UpdateCertificateProjects(dbInfo.ID, cert.Projects, req.ProjectRoles)
 		if err != nil {
 			return response.SmartError(err)
 		}
@@ -826,3 +1062,66 @@ func certificateDelete(d *Daemon, r *http.Request) response.Response {
 
 	return response.EmptySyncResponse
 }
+
+// certificateToAPI returns the API representation of a certificate, including its project roles.
+func certificateToAPI(d *Daemon, dbCert *db.Certificate) (api.Certificate, error) {
+	cert := dbCert.ToAPI()
+
+	roles, err := d.cluster.GetCertificateProjectRoles(dbCert.Fingerprint)
+	if err != nil {
+		return api.Certificate{}, err
+	}
+
+	cert.ProjectRoles = roles
+
+	return cert, nil
+}
+
+// certificateValidateProjectRoles checks that the roles are built-in roles given on allowed projects.
+func certificateValidateProjectRoles(projects []string, roles map[string]string) error {
+	for projectName, role := range roles {
+		if !shared.StringInSlice(projectName, projects) {
+			return fmt.Errorf("Role given on project %q which isn't an allowed project", projectName)
+		}
+
+		_, err := rbac.RolePermissions(role)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func updateCertificateRevocationListTask(d *Daemon) (task.Func, task.Schedule) {
+	f := func(ctx context.Context) {
+		url, err := cluster.ConfigGetString(d.cluster, "core.trust_ca_crl_url")
+		if err != nil {
+			logger.Error("Failed to load certificate revocation list URL", log.Ctx{"err": err})
+			return
+		}
+
+		if url == "" {
+			return
+		}
+
+		opRun := func(op *operations.Operation) error {
+			return d.setupCRL(url)
+		}
+
+		op, err := operations.OperationCreate(d.State(), "", operations.OperationClassTask, db.OperationCertificateRevocationListUpdate, nil, nil, opRun, nil, nil, nil)
+		if err != nil {
+			logger.Error("Failed to start certificate revocation list update operation", log.Ctx{"err": err})
+			return
+		}
+
+		logger.Info("Updating certificate revocation list")
+		_, err = op.Run()
+		if err != nil {
+			logger.Error("Failed to update certificate revocation list", log.Ctx{"err": err})
+		}
+		logger.Info("Done updating certificate revocation list")
+	}
+
+	return f, task.Hourly()
+}
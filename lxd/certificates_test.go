@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lxc/lxd/lxd/oidc"
+	"github.com/lxc/lxd/lxd/rbac"
+	"github.com/lxc/lxd/lxd/request"
+)
+
+// Test that only trusted administrators can add certificates without a password or token.
+func TestCertificatesPostTrusted(t *testing.T) {
+	tests := []struct {
+		name     string
+		trusted  bool
+		protocol string
+		access   *rbac.UserAccess
+		allowed  bool
+	}{
+		{
+			name:    "Untrusted",
+			trusted: false,
+			allowed: false,
+		},
+		{
+			name:     "Unrestricted certificate",
+			trusted:  true,
+			protocol: "tls",
+			access:   &rbac.UserAccess{Admin: true},
+			allowed:  true,
+		},
+		{
+			name:     "Restricted certificate",
+			trusted:  true,
+			protocol: "tls",
+			access:   &rbac.UserAccess{Projects: map[string][]string{"blah": {"view"}}},
+			allowed:  false,
+		},
+		{
+			name:     "Restricted token",
+			trusted:  true,
+			protocol: "token",
+			access:   &rbac.UserAccess{Projects: map[string][]string{"blah": {"view", "manage-containers"}}},
+			allowed:  false,
+		},
+		{
+			name:     "Missing access",
+			trusted:  true,
+			protocol: "token",
+			allowed:  false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/1.0/certificates", nil)
+			ctx := context.WithValue(r.Context(), request.CtxProtocol, test.protocol)
+			if test.access != nil {
+				ctx = context.WithValue(ctx, request.CtxAccess, test.access)
+			}
+
+			assert.Equal(t, test.allowed, certificatesPostTrusted(test.trusted, r.WithContext(ctx)))
+		})
+	}
+}
+
+// Test that OpenID Connect users can only add certificates without a password or token if they're
+// in an administrator group.
+func TestCertificatesPostTrusted_OIDC(t *testing.T) {
+	groups, err := oidc.ParseGroupsMapping("admins", "devs:blah:admin,ops:default:operator")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		groups  []string
+		allowed bool
+	}{
+		{
+			name:    "Administrator group",
+			groups:  []string{"devs", "admins"},
+			allowed: true,
+		},
+		{
+			name:    "Project administrator group",
+			groups:  []string{"devs"},
+			allowed: false,
+		},
+		{
+			name:    "Project operator group",
+			groups:  []string{"ops"},
+			allowed: false,
+		},
+		{
+			name:    "Unmapped group",
+			groups:  []string{"users"},
+			allowed: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			access, err := groups.UserAccess(&oidc.Claims{Subject: "1234", Groups: test.groups})
+			require.NoError(t, err)
+
+			r := httptest.NewRequest("POST", "/1.0/certificates", nil)
+			ctx := context.WithValue(r.Context(), request.CtxProtocol, "oidc")
+			ctx = context.WithValue(ctx, request.CtxAccess, access)
+
+			assert.Equal(t, test.allowed, certificatesPostTrusted(true, r.WithContext(ctx)))
+		})
+	}
+}
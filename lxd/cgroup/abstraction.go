@@ -616,6 +616,21 @@ func (cg *CGroup) SetCpuset(limit string) error {
 	return ErrUnknownVersion
 }
 
+// SetCpusetMems sets the currently allowed set of memory nodes for the cgroups
+func (cg *CGroup) SetCpusetMems(limit string) error {
+	version := cgControllers["cpuset"]
+	switch version {
+	case Unavailable:
+		return ErrControllerMissing
+	case V1:
+		return cg.rw.Set(version, "cpuset", "cpuset.mems", limit)
+	case V2:
+		return cg.rw.Set(version, "cpuset", "cpuset.mems", limit)
+	}
+
+	return ErrUnknownVersion
+}
+
 // GetMemoryStats returns memory stats
 func (cg *CGroup) GetMemoryStats() (map[string]uint64, error) {
 	var (
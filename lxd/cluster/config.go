@@ -5,6 +5,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"io"
+	"net/url"
 	"strconv"
 	"time"
 
@@ -13,6 +14,7 @@ import (
 
 	"github.com/lxc/lxd/lxd/config"
 	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/oidc"
 	"github.com/lxc/lxd/shared/validate"
 )
 
@@ -75,6 +77,18 @@ func (c *Config) TrustCACertificates() bool {
 	return c.m.GetBool("core.trust_ca_certificates")
 }
 
+// TrustCARevocation returns the URL of the certificate revocation list published by the CA and
+// whether to query the OCSP responders of the client certificates.
+func (c *Config) TrustCARevocation() (string, bool) {
+	return c.m.GetString("core.trust_ca_crl_url"), c.m.GetBool("core.trust_ca_ocsp")
+}
+
+// RateLimits returns the number of API requests per second and of concurrent websockets allowed to each
+// client (0 meaning no limit).
+func (c *Config) RateLimits() (int64, int64) {
+	return c.m.GetInt64("core.rate_limit_requests"), c.m.GetInt64("core.rate_limit_websockets")
+}
+
 // CandidServer returns all the Candid settings needed to connect to a server.
 func (c *Config) CandidServer() (string, string, int64, string) {
 	return c.m.GetString("candid.api.url"),
@@ -114,6 +128,33 @@ func (c *Config) HTTPSTrustedProxy() string {
 	return c.m.GetString("core.https_trusted_proxy")
 }
 
+// LokiServer returns all the settings needed to ship logs to a Loki server.
+func (c *Config) LokiServer() (string, string, string, string) {
+	return c.m.GetString("loki.api.url"),
+		c.m.GetString("loki.auth.username"),
+		c.m.GetString("loki.auth.password"),
+		c.m.GetString("loki.loglevel")
+}
+
+// SyslogServer returns all the settings needed to ship logs to a remote syslog server.
+func (c *Config) SyslogServer() (string, string) {
+	return c.m.GetString("syslog.address"), c.m.GetString("syslog.loglevel")
+}
+
+// OIDCServer returns all the settings needed to authenticate users with an OpenID Connect provider.
+func (c *Config) OIDCServer() (string, string, string, string) {
+	return c.m.GetString("oidc.issuer"),
+		c.m.GetString("oidc.client.id"),
+		c.m.GetString("oidc.audience"),
+		c.m.GetString("oidc.groups.claim")
+}
+
+// OIDCGroups returns the groups of OpenID Connect users granted admin access, and the project
+// roles granted to groups.
+func (c *Config) OIDCGroups() (string, string) {
+	return c.m.GetString("oidc.groups.admin"), c.m.GetString("oidc.groups.projects")
+}
+
 // MAASController the configured MAAS url and key, if any.
 func (c *Config) MAASController() (string, string) {
 	url := c.m.GetString("maas.api.url")
@@ -146,6 +187,31 @@ func (c *Config) MaxStandBy() int64 {
 	return c.m.GetInt64("cluster.max_standby")
 }
 
+// EventsExpiry returns for how long recorded events are kept before being pruned.
+func (c *Config) EventsExpiry() time.Duration {
+	n := c.m.GetInt64("core.events_expiry")
+	return time.Duration(n) * 24 * time.Hour
+}
+
+// InstancesUsageInterval returns the interval between samples of the resource usage of instances, 0
+// meaning no sampling.
+func (c *Config) InstancesUsageInterval() time.Duration {
+	n := c.m.GetInt64("instances.usage_interval")
+	return time.Duration(n) * time.Second
+}
+
+// InstancesShutdownAction returns what to do with running instances when LXD shuts down (stop,
+// stateful-stop or leave-running).
+func (c *Config) InstancesShutdownAction() string {
+	return c.m.GetString("instances.shutdown_action")
+}
+
+// MaxHeavyOperations returns the maximum number of heavy operations (image downloads, backups and
+// migrations) which may run concurrently on each member, 0 meaning no limit.
+func (c *Config) MaxHeavyOperations() int64 {
+	return c.m.GetInt64("core.max_heavy_operations")
+}
+
 // ShutdownTimeout returns the number of minutes to wait for running operation to complete
 // before LXD server shut down
 func (c *Config) ShutdownTimeout() time.Duration {
@@ -248,17 +314,23 @@ var ConfigSchema = config.Schema{
 	"cluster.max_voters":             {Type: config.Int64, Default: "3", Validator: maxVotersValidator},
 	"cluster.max_standby":            {Type: config.Int64, Default: "2", Validator: maxStandByValidator},
 	"core.bgp_asn":                   {Type: config.Int64, Default: "0", Validator: validate.Optional(validate.IsInRange(0, 4294967294))},
+	"core.events_expiry":             {Type: config.Int64, Default: "7", Validator: validate.Optional(validate.IsInRange(1, 3650))},
 	"core.https_allowed_headers":     {},
 	"core.https_allowed_methods":     {},
 	"core.https_allowed_origin":      {},
 	"core.https_allowed_credentials": {Type: config.Bool},
 	"core.https_trusted_proxy":       {},
+	"core.max_heavy_operations":      {Type: config.Int64, Default: "0", Validator: validate.Optional(validate.IsInRange(0, 1000))},
 	"core.proxy_http":                {},
 	"core.proxy_https":               {},
 	"core.proxy_ignore_hosts":        {},
+	"core.rate_limit_requests":       {Type: config.Int64, Default: "0", Validator: validate.Optional(validate.IsUint32)},
+	"core.rate_limit_websockets":     {Type: config.Int64, Default: "0", Validator: validate.Optional(validate.IsUint32)},
 	"core.shutdown_timeout":          {Type: config.Int64, Default: "5"},
 	"core.trust_password":            {Hidden: true, Setter: passwordSetter},
 	"core.trust_ca_certificates":     {Type: config.Bool},
+	"core.trust_ca_crl_url":          {Validator: httpURLValidator},
+	"core.trust_ca_ocsp":             {Type: config.Bool},
 	"candid.api.key":                 {},
 	"candid.api.url":                 {},
 	"candid.domains":                 {},
@@ -268,8 +340,20 @@ var ConfigSchema = config.Schema{
 	"images.compression_algorithm":   {Default: "gzip", Validator: validate.IsCompressionAlgorithm},
 	"images.default_architecture":    {Validator: validate.Optional(validate.IsArchitecture)},
 	"images.remote_cache_expiry":     {Type: config.Int64, Default: "10"},
+	"instances.shutdown_action":      {Default: "stop", Validator: validate.IsOneOf("stop", "stateful-stop", "leave-running")},
+	"instances.usage_interval":       {Type: config.Int64, Default: "60", Validator: validate.Optional(validate.IsUint32)},
+	"loki.api.url":                   {Validator: validate.Optional(httpURLValidator)},
+	"loki.auth.password":             {Hidden: true},
+	"loki.auth.username":             {},
+	"loki.loglevel":                  {Default: "info", Validator: validate.IsOneOf("debug", "info", "warn", "error")},
 	"maas.api.key":                   {},
 	"maas.api.url":                   {},
+	"oidc.audience":                  {},
+	"oidc.client.id":                 {},
+	"oidc.groups.admin":              {},
+	"oidc.groups.claim":              {Default: "groups"},
+	"oidc.groups.projects":           {Validator: oidcGroupsProjectsValidator},
+	"oidc.issuer":                    {Validator: validate.Optional(httpURLValidator)},
 	"rbac.agent.url":                 {},
 	"rbac.agent.username":            {},
 	"rbac.agent.private_key":         {},
@@ -278,6 +362,8 @@ var ConfigSchema = config.Schema{
 	"rbac.api.key":                   {},
 	"rbac.api.url":                   {},
 	"rbac.expiry":                    {Type: config.Int64, Default: "3600"},
+	"syslog.address":                 {Validator: validate.Optional(syslogAddressValidator)},
+	"syslog.loglevel":                {Default: "info", Validator: validate.IsOneOf("debug", "info", "warn", "error")},
 
 	// Keys deprecated since the implementation of the storage api.
 	"storage.lvm_fstype":           {Setter: deprecatedStorage, Default: "ext4"},
@@ -354,6 +440,37 @@ func maxStandByValidator(value string) error {
 	return nil
 }
 
+func httpURLValidator(value string) error {
+	u, err := url.Parse(value)
+	if err != nil {
+		return err
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("Value must be an HTTP or HTTPS URL")
+	}
+
+	return nil
+}
+
+func oidcGroupsProjectsValidator(value string) error {
+	_, err := oidc.ParseGroupsMapping("", value)
+	return err
+}
+
+func syslogAddressValidator(value string) error {
+	u, err := url.Parse(value)
+	if err != nil {
+		return err
+	}
+
+	if (u.Scheme != "udp" && u.Scheme != "tcp") || u.Hostname() == "" || u.Port() == "" {
+		return fmt.Errorf("Value must be in the form udp://HOST:PORT or tcp://HOST:PORT")
+	}
+
+	return nil
+}
+
 func passwordSetter(value string) (string, error) {
 	// Nothing to do on unset
 	if value == "" {
@@ -31,6 +31,7 @@ import (
 	"gopkg.in/macaroon-bakery.v2/bakery/identchecker"
 	"gopkg.in/macaroon-bakery.v2/httpbakery"
 
+	"github.com/lxc/lxd/lxd/audit"
 	"github.com/lxc/lxd/lxd/bgp"
 	"github.com/lxc/lxd/lxd/cluster"
 	"github.com/lxc/lxd/lxd/daemon"
@@ -42,8 +43,12 @@ import (
 	"github.com/lxc/lxd/lxd/instance"
 	instanceDrivers "github.com/lxc/lxd/lxd/instance/drivers"
 	"github.com/lxc/lxd/lxd/instance/instancetype"
+	"github.com/lxc/lxd/lxd/logshipping"
 	"github.com/lxc/lxd/lxd/maas"
 	"github.com/lxc/lxd/lxd/node"
+	"github.com/lxc/lxd/lxd/oidc"
+	"github.com/lxc/lxd/lxd/operations"
+	"github.com/lxc/lxd/lxd/ratelimit"
 	"github.com/lxc/lxd/lxd/rbac"
 	"github.com/lxc/lxd/lxd/request"
 	"github.com/lxc/lxd/lxd/response"
@@ -78,17 +83,32 @@ type Daemon struct {
 	readyChan    chan struct{} // Closed when LXD is fully ready
 	shutdownChan chan struct{}
 
+	// Time allowed for a shutdown requested through the API to complete (0 for no limit).
+	shutdownTimeout time.Duration
+
 	// Event servers
 	devlxdEvents *events.Server
 	events       *events.Server
 
+	// Audit log of the state-changing API requests
+	audit *audit.Log
+
+	// Clients shipping the logs and lifecycle events to remote servers
+	loki   logshipping.Client
+	syslog logshipping.Client
+
 	// Tasks registry for long-running background tasks
 	// Keep clustering tasks separate as they cause a lot of CPU wakeups
 	tasks        task.Group
 	clusterTasks task.Group
 
+	// Background tasks exposed through the API, by name
+	exposedTasks   map[string]*task.Task
+	exposedTasksMu sync.Mutex
+
 	// Indexes of tasks that need to be reset when their execution interval changes
 	taskPruneImages      *task.Task
+	taskInstanceUsage    *task.Task
 	taskClusterHeartbeat *task.Task
 
 	// Stores startup time of daemon
@@ -101,7 +121,17 @@ type Daemon struct {
 
 	proxy func(req *http.Request) (*url.URL, error)
 
+	// REST API router, used to run the requests of a batch
+	restAPI *mux.Router
+
 	externalAuth *externalAuth
+	oidcAuth     *oidcAuth
+
+	// Revocation checks of the client certificates signed by the CA.
+	revocation *util.RevocationChecker
+
+	// Per client limits of API requests and websockets.
+	rateLimiter *ratelimit.Limiter
 
 	// Stores last heartbeat node information to detect node changes.
 	lastNodeList *cluster.APIHeartbeat
@@ -124,12 +154,19 @@ type externalAuth struct {
 	bakery   *identchecker.Bakery
 }
 
+type oidcAuth struct {
+	verifier *oidc.Verifier
+	groups   *oidc.GroupsMapping
+}
+
 // DaemonConfig holds configuration values for Daemon.
 type DaemonConfig struct {
 	Group              string        // Group name the local unix socket should be chown'ed to
 	Trace              []string      // List of sub-systems to trace
 	RaftLatency        float64       // Coarse grain measure of the cluster latency
 	DqliteSetupTimeout time.Duration // How long to wait for the cluster database to be up
+	Rootless           bool          // Whether running as an unprivileged user in a user namespace
+	RootlessNetwork    bool          // Whether the rootless daemon has its own network namespace
 }
 
 // IdentityClientWrapper is a wrapper around an IdentityClient.
@@ -172,6 +209,8 @@ func newDaemon(config *DaemonConfig, os *sys.OS) *Daemon {
 
 	d := &Daemon{
 		clientCerts:  &certificateCache{},
+		revocation:   util.NewRevocationChecker(),
+		rateLimiter:  ratelimit.NewLimiter(0, 0),
 		config:       config,
 		devlxdEvents: devlxdEvents,
 		events:       lxdEvents,
@@ -284,6 +323,13 @@ func (d *Daemon) getTrustedCertificates() map[db.CertificateType]map[string]x509
 //
 // This does not perform authorization, only validates authentication.
 func (d *Daemon) Authenticate(w http.ResponseWriter, r *http.Request) (bool, string, string, error) {
+	trusted, username, protocol, _, err := d.authenticate(w, r)
+	return trusted, username, protocol, err
+}
+
+// authenticate is Authenticate, also returning the claims of the OpenID Connect token of the
+// request, so that they don't need to be verified again.
+func (d *Daemon) authenticate(w http.ResponseWriter, r *http.Request) (bool, string, string, *oidc.Claims, error) {
 	trustedCerts := d.getTrustedCertificates()
 
 	// Allow internal cluster traffic by checking against the trusted certfificates.
@@ -291,7 +337,7 @@ func (d *Daemon) Authenticate(w http.ResponseWriter, r *http.Request) (bool, str
 		for _, i := range r.TLS.PeerCertificates {
 			trusted, _ := util.CheckTrustState(*i, trustedCerts[db.CertificateTypeServer], d.endpoints.NetworkCert(), false)
 			if trusted {
-				return true, "", "cluster", nil
+				return true, "", "cluster", nil, nil
 			}
 		}
 	}
@@ -302,33 +348,58 @@ func (d *Daemon) Authenticate(w http.ResponseWriter, r *http.Request) (bool, str
 			conn := extractUnderlyingConn(w)
 			cred, err := ucred.GetCred(conn)
 			if err != nil {
-				return false, "", "", err
+				return false, "", "", nil, err
 			}
 
 			u, err := user.LookupId(fmt.Sprintf("%d", cred.Uid))
 			if err != nil {
-				return true, fmt.Sprintf("uid=%d", cred.Uid), "unix", nil
+				return true, fmt.Sprintf("uid=%d", cred.Uid), "unix", nil, nil
 			}
 
-			return true, u.Username, "unix", nil
+			return true, u.Username, "unix", nil, nil
 		}
 
-		return true, "", "unix", nil
+		return true, "", "unix", nil, nil
 	}
 
 	// Devlxd unix socket credentials on main API.
 	if r.RemoteAddr == "@devlxd" {
-		return false, "", "", fmt.Errorf("Main API query can't come from /dev/lxd socket")
+		return false, "", "", nil, fmt.Errorf("Main API query can't come from /dev/lxd socket")
 	}
 
 	// Cluster notification with wrong certificate.
 	if isClusterNotification(r) {
-		return false, "", "", fmt.Errorf("Cluster notification isn't using trusted server certificate")
+		return false, "", "", nil, fmt.Errorf("Cluster notification isn't using trusted server certificate")
 	}
 
 	// Bad query, no TLS found.
 	if r.TLS == nil {
-		return false, "", "", fmt.Errorf("Bad/missing TLS on network query")
+		return false, "", "", nil, fmt.Errorf("Bad/missing TLS on network query")
+	}
+
+	// Validate OpenID Connect tokens passed as bearer tokens.
+	oidcAuth := d.oidcAuth
+	if oidcAuth != nil {
+		claims, err := oidcClaimsFromRequest(oidcAuth, r)
+		if err != nil {
+			logger.Warn("Rejecting invalid OpenID Connect token", log.Ctx{"ip": r.RemoteAddr, "err": err})
+			return false, "", "", nil, nil
+		}
+
+		if claims != nil {
+			return true, claims.Username(), "oidc", claims, nil
+		}
+	}
+
+	// Validate API tokens passed as bearer tokens.
+	token, err := authTokenFromRequest(d, r)
+	if err != nil {
+		logger.Warn("Rejecting invalid API token", log.Ctx{"ip": r.RemoteAddr, "err": err})
+		return false, "", "", nil, nil
+	}
+
+	if token != nil {
+		return true, token.Name, "token", nil, nil
 	}
 
 	if d.externalAuth != nil && r.Header.Get(httpbakery.BakeryProtocolHeader) != "" {
@@ -344,33 +415,48 @@ func (d *Daemon) Authenticate(w http.ResponseWriter, r *http.Request) (bool, str
 		info, err := authChecker.Allow(ctx, ops...)
 		if err != nil {
 			// Bad macaroon.
-			return false, "", "", err
+			return false, "", "", nil, err
 		}
 
 		if info != nil && info.Identity != nil {
 			// Valid identity macaroon found.
-			return true, info.Identity.Id(), "candid", nil
+			return true, info.Identity.Id(), "candid", nil, nil
 		}
 
 		// Valid macaroon with no identity information.
-		return true, "", "candid", nil
+		return true, "", "candid", nil, nil
 	}
 
 	// Validate normal TLS access.
 	trustCACertificates, err := cluster.ConfigGetBool(d.cluster, "core.trust_ca_certificates")
 	if err != nil {
-		return false, "", "", err
+		return false, "", "", nil, err
 	}
 
 	for _, i := range r.TLS.PeerCertificates {
 		trusted, username := util.CheckTrustState(*i, trustedCerts[db.CertificateTypeClient], d.endpoints.NetworkCert(), trustCACertificates)
+		if trusted && d.certificateRevoked(i) {
+			logger.Warn("Rejecting revoked client certificate", log.Ctx{"fingerprint": username, "ip": r.RemoteAddr})
+			continue
+		}
+
 		if trusted {
-			return true, username, "tls", nil
+			return true, username, "tls", nil, nil
+		}
+	}
+
+	// Metrics certificates are only trusted for the metrics endpoint.
+	if r.URL.Path == fmt.Sprintf("/%s/metrics", version.APIVersion) {
+		for _, i := range r.TLS.PeerCertificates {
+			trusted, username := util.CheckTrustState(*i, trustedCerts[db.CertificateTypeMetrics], d.endpoints.NetworkCert(), false)
+			if trusted {
+				return true, username, "tls", nil, nil
+			}
 		}
 	}
 
 	// Reject unauthorized.
-	return false, "", "", nil
+	return false, "", "", nil, nil
 }
 
 func writeMacaroonsRequiredResponse(b *identchecker.Bakery, r *http.Request, w http.ResponseWriter, derr *bakery.DischargeRequiredError, expiry int64) {
@@ -464,7 +550,7 @@ func (d *Daemon) createCmd(restAPI *mux.Router, version string, c APIEndpoint) {
 		}
 
 		// Authentication
-		trusted, username, protocol, err := d.Authenticate(w, r)
+		trusted, username, protocol, oidcClaims, err := d.authenticate(w, r)
 		if err != nil {
 			// If not a macaroon discharge request, return the error
 			_, ok := err.(*bakery.DischargeRequiredError)
@@ -474,6 +560,77 @@ func (d *Daemon) createCmd(restAPI *mux.Router, version string, c APIEndpoint) {
 			}
 		}
 
+		// Advertise OpenID Connect to clients which aren't authenticated.
+		oidcAuth := d.oidcAuth
+		if !trusted && oidcAuth != nil {
+			oidcSetHeaders(oidcAuth, w)
+		}
+
+		// Record state-changing requests in the audit log, including the rejected ones.
+		if d.audit != nil && r.Method != "GET" && version != "internal" {
+			recorder := d.audit.NewRecorder(w, r)
+			w = recorder
+
+			defer func() {
+				requestor := request.CreateRequestor(r)
+				if requestor.Username == "" {
+					requestor.Username = username
+					requestor.Protocol = protocol
+				}
+
+				err := recorder.Finish(requestor)
+				if err != nil {
+					logger.Error("Failed to record request in audit log", log.Ctx{"url": r.URL.RequestURI(), "err": err})
+				}
+
+				// Record the final status of the operation started by the request once it's done.
+				opID := recorder.Operation()
+				if opID == "" {
+					return
+				}
+
+				op, err := operations.OperationGetInternal(opID)
+				if err == nil {
+					go func() {
+						_, _ = op.WaitFinal(-1)
+
+						err := recorder.FinishOperation(op.Status().String())
+						if err != nil {
+							logger.Error("Failed to record operation in audit log", log.Ctx{"operation": op.ID(), "err": err})
+						}
+					}()
+				}
+			}()
+		}
+
+		// Limit the rate of requests and the number of websockets of remote clients.
+		if version != "internal" && !shared.StringInSlice(protocol, []string{"unix", "cluster"}) {
+			client := fmt.Sprintf("%s:%s", protocol, username)
+			if !trusted {
+				host, _, _ := net.SplitHostPort(r.RemoteAddr)
+				client = fmt.Sprintf("ip:%s", host)
+			}
+
+			if !d.rateLimiter.Allow(client) {
+				logger.Warn("Rejecting request over the rate limit", log.Ctx{"ip": r.RemoteAddr, "username": username, "protocol": protocol})
+				w.Header().Set("Retry-After", "1")
+				response.TooManyRequests(nil).Render(w)
+				return
+			}
+
+			if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+				ww, ok := d.rateLimiter.Websocket(w, client)
+				if !ok {
+					logger.Warn("Rejecting websocket over the limit", log.Ctx{"ip": r.RemoteAddr, "username": username, "protocol": protocol})
+					response.TooManyRequests(fmt.Errorf("Too many websockets")).Render(w)
+					return
+				}
+
+				defer ww.Done()
+				w = ww
+			}
+		}
+
 		// Reject internal queries to remote, non-cluster, clients
 		if version == "internal" && !shared.StringInSlice(protocol, []string{"unix", "cluster"}) {
 			// Except for the initial cluster accept request (done over trusted TLS)
@@ -509,25 +666,42 @@ func (d *Daemon) createCmd(restAPI *mux.Router, version string, c APIEndpoint) {
 					if certProjects != nil {
 						projects, ok := certProjects[username]
 						if ok {
-							ua.Admin = false
-							ua.Projects = map[string][]string{}
-							for _, projectName := range projects {
-								ua.Projects[projectName] = []string{
-									"view",
-									"manage-containers",
-									"manage-images",
-									"manage-networks",
-									"manage-profiles",
-									"manage-storage-volumes",
-									"operate-containers",
-								}
-							}
+							return rbac.ProjectRolesUserAccess(projects)
 						}
 					}
 
 					return ua, nil
 				}
 
+				// OpenID Connect users.
+				if protocol == "oidc" {
+					oidcAuth := d.oidcAuth
+					if oidcAuth == nil {
+						return nil, fmt.Errorf("OpenID Connect authentication isn't configured")
+					}
+
+					return oidcAuth.groups.UserAccess(oidcClaims)
+				}
+
+				// API tokens.
+				if protocol == "token" {
+					var token *db.AuthToken
+					err := d.cluster.Transaction(func(tx *db.ClusterTx) error {
+						var err error
+						token, err = tx.GetAuthToken(username)
+						return err
+					})
+					if err != nil {
+						return nil, err
+					}
+
+					if token.Restricted {
+						return rbac.ProjectRolesUserAccess(token.Roles)
+					}
+
+					return ua, nil
+				}
+
 				// If no external authentication configured, we're done now.
 				if d.externalAuth == nil || d.rbac == nil || r.RemoteAddr == "@" {
 					return ua, nil
@@ -758,6 +932,12 @@ func (d *Daemon) init() error {
 		return err
 	}
 
+	// Open the audit log
+	d.audit, err = audit.New(shared.LogPath("audit.log"), audit.DefaultMaxSize, audit.DefaultMaxFiles)
+	if err != nil {
+		return err
+	}
+
 	// Bump some kernel limits to avoid issues
 	for _, limit := range []int{unix.RLIMIT_NOFILE} {
 		rLimit := unix.Rlimit{}
@@ -790,6 +970,7 @@ func (d *Daemon) init() error {
 		"devpts_fd",
 		"seccomp_proxy_send_notify_fd",
 		"idmapped_mounts_v2",
+		"time_namespace",
 	}
 	for _, extension := range lxcExtensions {
 		d.os.LXCFeatures[extension] = liblxc.HasApiExtension(extension)
@@ -862,6 +1043,13 @@ func (d *Daemon) init() error {
 		logger.Info(" - safe native terminal allocation : no")
 	}
 
+	if d.os.LXCFeatures["time_namespace"] && canUseTimeNamespace() {
+		d.os.TimeNamespace = true
+		logger.Info(" - time namespaces: yes")
+	} else {
+		logger.Info(" - time namespaces: no")
+	}
+
 	/*
 	 * During daemon startup we're the only thread that touches VFS3Fscaps
 	 * so we don't need to bother with atomic.StoreInt32() when touching
@@ -1006,6 +1194,16 @@ func (d *Daemon) init() error {
 		return errors.Wrap(err, "Failed to fetch debug address")
 	}
 
+	httpsListeners, err := node.HTTPSListeners(d.db)
+	if err != nil {
+		return errors.Wrap(err, "Failed to fetch HTTPS listeners")
+	}
+
+	listeners, err := d.loadListeners(httpsListeners)
+	if err != nil {
+		return err
+	}
+
 	/* Setup the web server */
 	config := &endpoints.Config{
 		Dir:                  d.os.VarDir,
@@ -1017,6 +1215,7 @@ func (d *Daemon) init() error {
 		NetworkAddress:       address,
 		ClusterAddress:       clusterAddress,
 		DebugAddress:         debugAddress,
+		Listeners:            listeners,
 	}
 	d.endpoints, err = endpoints.Up(config)
 	if err != nil {
@@ -1108,7 +1307,7 @@ func (d *Daemon) init() error {
 
 		logger.Debug("Restarting all the containers following directory rename")
 		s := d.State()
-		instancesShutdown(s)
+		instancesShutdown(s, "stop", 0)
 		instancesRestart(s)
 	}
 
@@ -1171,6 +1370,22 @@ func (d *Daemon) init() error {
 	maasAPIKey := ""
 	maasMachine := ""
 
+	lokiURL := ""
+	lokiUsername := ""
+	lokiPassword := ""
+	lokiLevel := ""
+	syslogAddress := ""
+	syslogLevel := ""
+
+	oidcIssuer := ""
+	oidcClientID := ""
+	oidcAudience := ""
+	oidcGroupsClaim := ""
+	oidcGroupsAdmin := ""
+	oidcGroupsProjects := ""
+	trustCACRLURL := ""
+	trustCAOCSP := false
+
 	logger.Info("Loading daemon configuration")
 	err = d.db.Transaction(func(tx *db.NodeTx) error {
 		config, err := node.ConfigLoad(tx)
@@ -1181,7 +1396,15 @@ func (d *Daemon) init() error {
 		maasMachine = config.MAASMachine()
 		bgpAddress = config.BGPAddress()
 		bgpRouterID = config.BGPRouterID()
-		return nil
+
+		if d.audit != nil {
+			err = d.audit.SetRetention(config.AuditLogRetention())
+			if err != nil {
+				return err
+			}
+		}
+
+		return daemonConfigSetLogLevels(config)
 	})
 	if err != nil {
 		return err
@@ -1194,6 +1417,7 @@ func (d *Daemon) init() error {
 		}
 
 		bgpASN = config.BGPASN()
+		operations.SetHeavyOperationsLimit(int(config.MaxHeavyOperations()))
 
 		d.proxy = shared.ProxyFromConfig(
 			config.ProxyHTTPS(), config.ProxyHTTP(), config.ProxyIgnoreHosts(),
@@ -1201,6 +1425,12 @@ func (d *Daemon) init() error {
 
 		candidAPIURL, candidAPIKey, candidExpiry, candidDomains = config.CandidServer()
 		maasAPIURL, maasAPIKey = config.MAASController()
+		lokiURL, lokiUsername, lokiPassword, lokiLevel = config.LokiServer()
+		syslogAddress, syslogLevel = config.SyslogServer()
+		oidcIssuer, oidcClientID, oidcAudience, oidcGroupsClaim = config.OIDCServer()
+		oidcGroupsAdmin, oidcGroupsProjects = config.OIDCGroups()
+		trustCACRLURL, trustCAOCSP = config.TrustCARevocation()
+		d.rateLimiter.SetLimits(config.RateLimits())
 		rbacAPIURL, rbacAPIKey, rbacExpiry, rbacAgentURL, rbacAgentUsername, rbacAgentPrivateKey, rbacAgentPublicKey = config.RBACServer()
 		d.gateway.HeartbeatOfflineThreshold = config.OfflineThreshold()
 
@@ -1228,6 +1458,20 @@ func (d *Daemon) init() error {
 		}
 	}
 
+	// Setup revocation checks of the CA signed client certificates, a failure to fetch the revocation
+	// list isn't fatal as it's periodically refreshed.
+	d.revocation.SetOCSP(trustCAOCSP)
+	err = d.setupCRL(trustCACRLURL)
+	if err != nil {
+		logger.Warn("Failed to load certificate revocation list", log.Ctx{"url": trustCACRLURL, "err": err})
+	}
+
+	// Setup OpenID Connect authentication.
+	err = d.setupOIDC(oidcIssuer, oidcClientID, oidcAudience, oidcGroupsClaim, oidcGroupsAdmin, oidcGroupsProjects)
+	if err != nil {
+		return err
+	}
+
 	// Setup BGP listener.
 	d.bgp = bgp.NewServer()
 	if bgpAddress != "" && bgpASN != 0 && bgpRouterID != "" {
@@ -1238,6 +1482,17 @@ func (d *Daemon) init() error {
 		logger.Info("Started BGP server")
 	}
 
+	// Ship logs and lifecycle events to remote servers.
+	err = d.setupLokiClient(lokiURL, lokiUsername, lokiPassword, lokiLevel)
+	if err != nil {
+		logger.Warn("Failed to setup Loki client", log.Ctx{"err": err})
+	}
+
+	err = d.setupSyslogClient(syslogAddress, syslogLevel)
+	if err != nil {
+		logger.Warn("Failed to setup syslog client", log.Ctx{"err": err})
+	}
+
 	// Setup the networks.
 	logger.Infof("Initializing networks")
 	err = networkStartup(d.State())
@@ -1248,6 +1503,12 @@ func (d *Daemon) init() error {
 	// Cleanup leftover images.
 	pruneLeftoverImages(d)
 
+	// Cleanup after the operations interrupted by the restart.
+	err = reconcileInterruptedOperations(d)
+	if err != nil {
+		logger.Warn("Failed to reconcile interrupted operations", log.Ctx{"err": err})
+	}
+
 	if !d.os.MockMode {
 		// Start the scheduler
 		go deviceEventListener(d.State())
@@ -1373,39 +1634,79 @@ func (d *Daemon) Ready() error {
 	//        but has not been fully completed.
 	if !d.os.MockMode {
 		// Log expiry (daily)
-		d.tasks.Add(expireLogsTask(d.State()))
+		d.exposeTask("expire-logs", d.tasks.Add(expireLogsTask(d.State())))
 
 		// Remove expired images (daily)
-		d.taskPruneImages = d.tasks.Add(pruneExpiredImagesTask(d))
+		d.taskPruneImages = d.exposeTask("prune-images", d.tasks.Add(pruneExpiredImagesTask(d)))
 
 		// Auto-update images (every 6 hours, configurable)
-		d.tasks.Add(autoUpdateImagesTask(d))
+		d.exposeTask("update-images", d.tasks.Add(autoUpdateImagesTask(d)))
 
 		// Auto-update instance types (daily)
-		d.tasks.Add(instanceRefreshTypesTask(d))
+		d.exposeTask("refresh-instance-types", d.tasks.Add(instanceRefreshTypesTask(d)))
 
 		// Remove expired container backups (hourly)
-		d.tasks.Add(pruneExpiredContainerBackupsTask(d))
+		d.exposeTask("prune-backups", d.tasks.Add(pruneExpiredContainerBackupsTask(d)))
 
 		// Take snapshot of containers (minutely check of configurable cron expression)
-		d.tasks.Add(autoCreateContainerSnapshotsTask(d))
+		d.exposeTask("create-snapshots", d.tasks.Add(autoCreateContainerSnapshotsTask(d)))
+
+		// Start and stop instances on schedule (minutely check of configurable cron expressions)
+		d.exposeTask("instance-schedules", d.tasks.Add(instanceSchedulesTask(d)))
 
 		// Remove expired container snapshots (minutely)
-		d.tasks.Add(pruneExpiredContainerSnapshotsTask(d))
+		d.exposeTask("prune-snapshots", d.tasks.Add(pruneExpiredContainerSnapshotsTask(d)))
 
 		// Remove expired custom volume snapshots (minutely)
-		d.tasks.Add(pruneExpireCustomVolumeSnapshotsTask(d))
+		d.exposeTask("prune-volume-snapshots", d.tasks.Add(pruneExpireCustomVolumeSnapshotsTask(d)))
 
 		// Take snapshot of custom volumes (minutely check of configurable cron expression)
-		d.tasks.Add(autoCreateCustomVolumeSnapshotsTask(d))
+		d.exposeTask("create-volume-snapshots", d.tasks.Add(autoCreateCustomVolumeSnapshotsTask(d)))
 
 		// Remove resolved warnings (daily)
-		d.tasks.Add(pruneResolvedWarningsTask(d))
+		d.exposeTask("prune-warnings", d.tasks.Add(pruneResolvedWarningsTask(d)))
+
+		// Remove expired events (daily)
+		d.exposeTask("prune-events", d.tasks.Add(pruneExpiredEventsTask(d)))
+
+		// Remove old operations history (daily)
+		d.exposeTask("prune-operations", d.tasks.Add(pruneOperationsHistoryTask(d)))
+
+		// Refresh the certificate revocation list published by the CA (hourly)
+		d.exposeTask("update-crl", d.tasks.Add(updateCertificateRevocationListTask(d)))
+
+		// Run the health checks of instances (every 5s, configurable per instance)
+		d.exposeTask("instance-health-checks", d.tasks.Add(instanceHealthChecksTask(d)))
+
+		// Trim the console logs of virtual machines (every 10s)
+		d.exposeTask("trim-console-logs", d.tasks.Add(instanceConsoleBufferTrimTask(d)))
+
+		// Sample the resource usage of instances (every minute by default)
+		d.taskInstanceUsage = d.exposeTask("instance-usage", d.tasks.Add(instanceUsageTask(d)))
 	}
 
 	// Start all background tasks
 	d.tasks.Start(d.ctx)
 
+	// Record the cluster member in lifecycle events
+	d.events.SetLocation(func() string {
+		var location string
+		err := d.cluster.Transaction(func(tx *db.ClusterTx) error {
+			var err error
+			location, err = tx.GetLocalNodeName()
+			return err
+		})
+		if err != nil {
+			logger.Warn("Failed to get local member name for lifecycle event", log.Ctx{"err": err})
+			return ""
+		}
+
+		return location
+	})
+
+	// Record lifecycle events and important log messages
+	d.events.SetHandler("history", eventsHistoryHandler(d))
+
 	// Get daemon state struct
 	s := d.State()
 
@@ -1514,6 +1815,10 @@ func (d *Daemon) Stop() error {
 		trackError(d.endpoints.Down(), "Shutdown endpoints")
 	}
 
+	if d.audit != nil {
+		trackError(d.audit.Close(), "Close audit log")
+	}
+
 	if shouldUnmount {
 		logger.Infof("Unmounting temporary filesystems")
 
@@ -1706,6 +2011,164 @@ func (d *Daemon) setupMAASController(server string, key string, machine string)
 	return nil
 }
 
+// setupOIDC (re)configures the validation of OpenID Connect tokens.
+func (d *Daemon) setupOIDC(issuer string, clientID string, audience string, groupsClaim string, groupsAdmin string, groupsProjects string) error {
+	// Both the issuer and the client ID are needed to validate tokens.
+	if issuer == "" || clientID == "" {
+		d.oidcAuth = nil
+		return nil
+	}
+
+	groups, err := oidc.ParseGroupsMapping(groupsAdmin, groupsProjects)
+	if err != nil {
+		return err
+	}
+
+	d.oidcAuth = &oidcAuth{
+		verifier: oidc.NewVerifier(issuer, clientID, audience, groupsClaim),
+		groups:   groups,
+	}
+
+	return nil
+}
+
+// setupCRL (re)loads the certificate revocation list published by the CA, if any.
+func (d *Daemon) setupCRL(url string) error {
+	if url == "" {
+		d.revocation.SetCRL(nil)
+		return nil
+	}
+
+	ca := d.endpoints.NetworkCert().CA()
+	if ca == nil {
+		return fmt.Errorf("A CA certificate is required to check its certificate revocation list")
+	}
+
+	return d.revocation.UpdateCRL(url, ca)
+}
+
+// certificateRevoked returns whether the certificate is signed by the CA and has since been revoked.
+func (d *Daemon) certificateRevoked(cert *x509.Certificate) bool {
+	ca := d.endpoints.NetworkCert().CA()
+	if ca == nil || cert.CheckSignatureFrom(ca) != nil {
+		return false
+	}
+
+	return d.revocation.Revoked(cert, ca)
+}
+
+// reloadCertificate re-reads the network certificate from disk and applies it to the network endpoint and
+// cluster gateway. Established connections keep using the previous certificate.
+func (d *Daemon) reloadCertificate() error {
+	cert, err := util.LoadCert(d.os.VarDir)
+	if err != nil {
+		return err
+	}
+
+	d.endpoints.NetworkUpdateCert(cert)
+	d.gateway.NetworkUpdateCert(cert)
+
+	logger.Info("Reloaded the server certificate", log.Ctx{"fingerprint": cert.Fingerprint()})
+
+	// Reload the keypairs of the additional listeners too.
+	httpsListeners, err := node.HTTPSListeners(d.db)
+	if err != nil {
+		return err
+	}
+
+	listeners, err := d.loadListeners(httpsListeners)
+	if err != nil {
+		return err
+	}
+
+	return d.endpoints.ListenersUpdate(listeners)
+}
+
+// loadListeners returns the given additional HTTPS listeners along with their keypair. Listeners without a
+// listeners/<name>.crt in the LXD directory use the network certificate.
+func (d *Daemon) loadListeners(httpsListeners []node.HTTPSListener) ([]endpoints.Listener, error) {
+	certsDir := filepath.Join(d.os.VarDir, "listeners")
+
+	listeners := make([]endpoints.Listener, 0, len(httpsListeners))
+	for _, httpsListener := range httpsListeners {
+		listener := endpoints.Listener{
+			Name:    httpsListener.Name,
+			Scope:   httpsListener.Scope,
+			Address: httpsListener.Address,
+		}
+
+		if shared.PathExists(filepath.Join(certsDir, fmt.Sprintf("%s.crt", listener.Name))) {
+			cert, err := shared.KeyPairAndCA(certsDir, listener.Name, shared.CertServer, true)
+			if err != nil {
+				return nil, errors.Wrapf(err, "Failed to load the certificate of listener %q", listener.Name)
+			}
+
+			listener.Cert = cert
+		}
+
+		listeners = append(listeners, listener)
+	}
+
+	return listeners, nil
+}
+
+// setupLokiClient (re)configures the shipping of the logs and lifecycle events to a Loki server.
+func (d *Daemon) setupLokiClient(url string, username string, password string, level string) error {
+	if d.loki != nil {
+		d.events.SetHandler("loki", nil)
+		d.loki.Stop()
+		d.loki = nil
+	}
+
+	if url == "" {
+		return nil
+	}
+
+	lvl, err := log.LvlFromString(level)
+	if err != nil {
+		return err
+	}
+
+	client, err := logshipping.NewLokiClient(url, username, password)
+	if err != nil {
+		return err
+	}
+
+	d.loki = client
+	d.events.SetHandler("loki", eventsShippingHandler(d, client, lvl))
+
+	return nil
+}
+
+// setupSyslogClient (re)configures the shipping of the logs and lifecycle events to a remote syslog
+// server.
+func (d *Daemon) setupSyslogClient(address string, level string) error {
+	if d.syslog != nil {
+		d.events.SetHandler("syslog", nil)
+		d.syslog.Stop()
+		d.syslog = nil
+	}
+
+	if address == "" {
+		return nil
+	}
+
+	lvl, err := log.LvlFromString(level)
+	if err != nil {
+		return err
+	}
+
+	client, err := logshipping.NewSyslogClient(address, "lxd")
+	if err != nil {
+		return err
+	}
+
+	d.syslog = client
+	d.events.SetHandler("syslog", eventsShippingHandler(d, client, lvl))
+
+	return nil
+}
+
 // Create a database connection and perform any updates needed.
 func initializeDbObject(d *Daemon) (*db.Dump, error) {
 	logger.Info("Initializing local database")
@@ -1,13 +1,26 @@
 package main
 
 import (
+	"fmt"
+
 	"github.com/lxc/lxd/lxd/cluster"
 	"github.com/lxc/lxd/lxd/db"
 	"github.com/lxc/lxd/lxd/node"
 	"github.com/lxc/lxd/lxd/state"
 	"github.com/lxc/lxd/shared"
+	log "github.com/lxc/lxd/shared/log15"
+	"github.com/lxc/lxd/shared/logging"
 )
 
+// daemonConfigLogSubsystems maps the subsystems which can have their own log level to the source paths
+// they are made of.
+var daemonConfigLogSubsystems = map[string][]string{
+	"db":        {"/lxd/db/"},
+	"migration": {"/lxd/migrate", "/lxd/migration/"},
+	"network":   {"/lxd/network/"},
+	"storage":   {"/lxd/storage/"},
+}
+
 func daemonConfigRender(state *state.State) (map[string]interface{}, error) {
 	config := map[string]interface{}{}
 
@@ -51,3 +64,22 @@ func daemonConfigSetProxy(d *Daemon, config *cluster.Config) {
 		config.ProxyIgnoreHosts(),
 	)
 }
+
+func daemonConfigSetLogLevels(config *node.Config) error {
+	for subsystem, paths := range daemonConfigLogSubsystems {
+		value := config.LogLevel(subsystem)
+		if value == "" {
+			logging.ResetSubsystemLevel(subsystem)
+			continue
+		}
+
+		level, err := log.LvlFromString(value)
+		if err != nil {
+			return fmt.Errorf("Invalid log level for subsystem %q: %w", subsystem, err)
+		}
+
+		logging.SetSubsystemLevel(subsystem, paths, level)
+	}
+
+	return nil
+}
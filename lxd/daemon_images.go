@@ -54,6 +54,19 @@ func (d *Daemon) imageDownloadLock(fingerprint string) locking.UnlockFunc {
 	return locking.Lock(fmt.Sprintf("ImageDownload_%s", fingerprint))
 }
 
+// imageCachedSourceFingerprint returns the fingerprint of the most recent auto-updated image cached in the cluster
+// from the given source and alias, or an empty string if there is none.
+func (d *Daemon) imageCachedSourceFingerprint(server string, protocol string, alias string, imageType string) string {
+	for _, architecture := range d.os.Architectures {
+		fingerprint, err := d.cluster.GetCachedImageSourceFingerprint(server, protocol, alias, imageType, architecture)
+		if err == nil {
+			return fingerprint
+		}
+	}
+
+	return ""
+}
+
 // ImageDownload resolves the image fingerprint and if not in the database, downloads it
 func (d *Daemon) ImageDownload(r *http.Request, op *operations.Operation, args *ImageDownloadArgs) (*api.Image, error) {
 	var err error
@@ -74,6 +87,9 @@ func (d *Daemon) ImageDownload(r *http.Request, op *operations.Operation, args *
 	// Default the fingerprint to the alias string we received
 	fp := alias
 
+	// Error reaching the image server, if any.
+	var remoteErr error
+
 	// Attempt to resolve the alias
 	if shared.StringInSlice(protocol, []string{"lxd", "simplestreams"}) {
 		clientArgs := &lxd.ConnectionArgs{
@@ -88,18 +104,18 @@ func (d *Daemon) ImageDownload(r *http.Request, op *operations.Operation, args *
 			// Setup LXD client
 			remote, err = lxd.ConnectPublicLXD(args.Server, clientArgs)
 			if err != nil {
-				return nil, errors.Wrapf(err, "Failed to connect to LXD server %q", args.Server)
+				remoteErr = errors.Wrapf(err, "Failed to connect to LXD server %q", args.Server)
 			}
 		} else {
 			// Setup simplestreams client
 			remote, err = lxd.ConnectSimpleStreams(args.Server, clientArgs)
 			if err != nil {
-				return nil, errors.Wrapf(err, "Failed to connect to simple streams server %q", args.Server)
+				remoteErr = errors.Wrapf(err, "Failed to connect to simple streams server %q", args.Server)
 			}
 		}
 
 		// For public images, handle aliases and initial metadata
-		if args.Secret == "" {
+		if remoteErr == nil && args.Secret == "" {
 			// Look for a matching alias
 			entry, _, err := remote.GetImageAliasType(args.Type, fp)
 			if err == nil {
@@ -109,10 +125,26 @@ func (d *Daemon) ImageDownload(r *http.Request, op *operations.Operation, args *
 			// Expand partial fingerprints
 			info, _, err = remote.GetImage(fp)
 			if err != nil {
-				return nil, errors.Wrapf(err, "Failed getting remote image info")
+				remoteErr = errors.Wrapf(err, "Failed getting remote image info")
+			} else {
+				fp = info.Fingerprint
+			}
+		}
+
+		// If the image server can't be reached, fall back to a copy of the image cached from it in the
+		// cluster. It's then transferred from the cluster members which have it rather than downloaded.
+		if remoteErr != nil {
+			cachedFingerprint := ""
+			if args.PreferCached {
+				cachedFingerprint = d.imageCachedSourceFingerprint(args.Server, protocol, alias, args.Type)
+			}
+
+			if cachedFingerprint == "" {
+				return nil, remoteErr
 			}
 
-			fp = info.Fingerprint
+			logger.Warn("Using cached image as the image server is unavailable", log.Ctx{"server": args.Server, "alias": alias, "fingerprint": cachedFingerprint, "err": remoteErr})
+			fp = cachedFingerprint
 		}
 	}
 
@@ -130,12 +162,9 @@ func (d *Daemon) ImageDownload(r *http.Request, op *operations.Operation, args *
 		return nil, err
 	}
 	if args.PreferCached && interval > 0 && alias != fp {
-		for _, architecture := range d.os.Architectures {
-			cachedFingerprint, err := d.cluster.GetCachedImageSourceFingerprint(args.Server, args.Protocol, alias, args.Type, architecture)
-			if err == nil && cachedFingerprint != fp {
-				fp = cachedFingerprint
-				break
-			}
+		cachedFingerprint := d.imageCachedSourceFingerprint(args.Server, protocol, alias, args.Type)
+		if cachedFingerprint != "" {
+			fp = cachedFingerprint
 		}
 	}
 
@@ -149,10 +178,10 @@ func (d *Daemon) ImageDownload(r *http.Request, op *operations.Operation, args *
 		}
 
 		if nodeAddress != "" {
-			// The image is available from another node, let's try to import it.
-			err = instanceImageTransfer(d, r, args.ProjectName, imgInfo.Fingerprint, nodeAddress)
+			// The image is available from other members, let's try to import it.
+			err = instanceImageTransferFromCluster(d, r, args.ProjectName, imgInfo.Fingerprint)
 			if err != nil {
-				return nil, errors.Wrapf(err, "Failed transferring image %q from %q", imgInfo.Fingerprint, nodeAddress)
+				return nil, errors.Wrapf(err, "Failed transferring image %q", imgInfo.Fingerprint)
 			}
 
 			// As the image record already exists in the project, just add the node ID to the image.
@@ -191,8 +220,8 @@ func (d *Daemon) ImageDownload(r *http.Request, op *operations.Operation, args *
 
 			// Transfer image if needed (after database record has been created above).
 			if nodeAddress != "" {
-				// The image is available from another node, let's try to import it.
-				err = instanceImageTransfer(d, r, args.ProjectName, info.Fingerprint, nodeAddress)
+				// The image is available from other members, let's try to import it.
+				err = instanceImageTransferFromCluster(d, r, args.ProjectName, imgInfo.Fingerprint)
 				if err != nil {
 					return nil, errors.Wrapf(err, "Failed transferring image")
 				}
@@ -243,6 +272,11 @@ func (d *Daemon) ImageDownload(r *http.Request, op *operations.Operation, args *
 		return info, nil
 	}
 
+	// The image isn't in the cluster and can't be downloaded.
+	if remoteErr != nil {
+		return nil, remoteErr
+	}
+
 	// Begin downloading
 	if op == nil {
 		ctxMap = log.Ctx{"alias": alias, "server": args.Server}
@@ -276,6 +310,7 @@ func (d *Daemon) ImageDownload(r *http.Request, op *operations.Operation, args *
 		}
 
 		if meta["download_progress"] != progress.Text {
+			shared.SetProgressMetadata(meta, "download", "", int64(progress.Percentage), progress.TransferredBytes, progress.TotalBytes, progress.Speed)
 			meta["download_progress"] = progress.Text
 			op.UpdateMetadata(meta)
 		}
@@ -405,7 +440,7 @@ func (d *Daemon) ImageDownload(r *http.Request, op *operations.Operation, args *
 			Tracker: &ioprogress.ProgressTracker{
 				Length: raw.ContentLength,
 				Handler: func(percent int64, speed int64) {
-					progress(ioprogress.ProgressData{Text: fmt.Sprintf("%d%% (%s/s)", percent, units.GetByteSizeString(speed, 2))})
+					progress(ioprogress.NewProgressData(fmt.Sprintf("%d%% (%s/s)", percent, units.GetByteSizeString(speed, 2)), percent, raw.ContentLength, speed))
 				},
 			},
 		}
@@ -22,6 +22,7 @@ import (
 func daemonStorageUnmount(s *state.State) error {
 	var storageBackups string
 	var storageImages string
+	var storageRecordings string
 
 	err := s.Node.Transaction(func(tx *db.NodeTx) error {
 		nodeConfig, err := node.ConfigLoad(tx)
@@ -31,6 +32,7 @@ func daemonStorageUnmount(s *state.State) error {
 
 		storageBackups = nodeConfig.StorageBackupsVolume()
 		storageImages = nodeConfig.StorageImagesVolume()
+		storageRecordings = nodeConfig.StorageRecordingsVolume()
 
 		return nil
 	})
@@ -76,6 +78,13 @@ func daemonStorageUnmount(s *state.State) error {
 		}
 	}
 
+	if storageRecordings != "" {
+		err := unmount("recordings", storageRecordings)
+		if err != nil {
+			return errors.Wrap(err, "Failed to unmount recordings storage")
+		}
+	}
+
 	pools, err := s.Cluster.GetStoragePoolNames()
 	if err != nil {
 		return fmt.Errorf("Failed to get storage pools: %w", err)
@@ -103,6 +112,7 @@ func daemonStorageUnmount(s *state.State) error {
 func daemonStorageMount(s *state.State) error {
 	var storageBackups string
 	var storageImages string
+	var storageRecordings string
 	err := s.Node.Transaction(func(tx *db.NodeTx) error {
 		nodeConfig, err := node.ConfigLoad(tx)
 		if err != nil {
@@ -111,6 +121,7 @@ func daemonStorageMount(s *state.State) error {
 
 		storageBackups = nodeConfig.StorageBackupsVolume()
 		storageImages = nodeConfig.StorageImagesVolume()
+		storageRecordings = nodeConfig.StorageRecordingsVolume()
 
 		return nil
 	})
@@ -156,6 +167,13 @@ func daemonStorageMount(s *state.State) error {
 		}
 	}
 
+	if storageRecordings != "" {
+		err := mount("recordings", storageRecordings)
+		if err != nil {
+			return errors.Wrap(err, "Failed to mount recordings storage")
+		}
+	}
+
 	return nil
 }
 
@@ -0,0 +1,219 @@
+//go:build linux && cgo && !agent
+// +build linux,cgo,!agent
+
+package db
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/lxc/lxd/lxd/db/query"
+	"github.com/lxc/lxd/shared/api"
+)
+
+// AuthToken is an API token usable as a bearer token.
+type AuthToken struct {
+	ID          int64
+	Name        string
+	Description string
+	SecretHash  string
+	Restricted  bool
+	Projects    []string
+	Roles       map[string]string // Role of the token in each of its projects.
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+}
+
+// Expired returns whether the token has expired.
+func (t *AuthToken) Expired() bool {
+	return !t.ExpiresAt.IsZero() && time.Now().After(t.ExpiresAt)
+}
+
+// ToAPI converts the database AuthToken struct to an api.AuthToken entry.
+func (t *AuthToken) ToAPI() api.AuthToken {
+	resp := api.AuthToken{
+		Name:       t.Name,
+		CreatedAt:  t.CreatedAt,
+		ExpiresAt:  t.ExpiresAt,
+		Restricted:   t.Restricted,
+		Projects:     t.Projects,
+		ProjectRoles: t.Roles,
+	}
+
+	resp.Description = t.Description
+
+	return resp
+}
+
+// GetAuthTokens returns all API tokens.
+func (c *ClusterTx) GetAuthTokens() ([]AuthToken, error) {
+	return c.getAuthTokens("")
+}
+
+// GetAuthToken returns the API token with the given name.
+func (c *ClusterTx) GetAuthToken(name string) (*AuthToken, error) {
+	tokens, err := c.getAuthTokens("WHERE auth_tokens.name = ?", name)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tokens) == 0 {
+		return nil, ErrNoSuchObject
+	}
+
+	return &tokens[0], nil
+}
+
+// GetAuthTokenBySecretHash returns the API token whose secret has the given hash.
+func (c *ClusterTx) GetAuthTokenBySecretHash(secretHash string) (*AuthToken, error) {
+	tokens, err := c.getAuthTokens("WHERE auth_tokens.secret_hash = ?", secretHash)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tokens) == 0 {
+		return nil, ErrNoSuchObject
+	}
+
+	return &tokens[0], nil
+}
+
+func (c *ClusterTx) getAuthTokens(where string, args ...interface{}) ([]AuthToken, error) {
+	tokens := []AuthToken{}
+	expiries := []*time.Time{}
+
+	stmt, err := c.tx.Prepare("SELECT id, name, description, secret_hash, restricted, created_at, expires_at FROM auth_tokens " + where + " ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	dest := func(i int) []interface{} {
+		tokens = append(tokens, AuthToken{})
+		expiries = append(expiries, nil)
+
+		return []interface{}{&tokens[i].ID, &tokens[i].Name, &tokens[i].Description, &tokens[i].SecretHash, &tokens[i].Restricted, &tokens[i].CreatedAt, &expiries[i]}
+	}
+
+	err = query.SelectObjects(stmt, dest, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to fetch API tokens")
+	}
+
+	for i := range tokens {
+		if expiries[i] != nil {
+			tokens[i].ExpiresAt = *expiries[i]
+		}
+
+		tokens[i].Projects, tokens[i].Roles, err = c.getAuthTokenProjects(tokens[i].ID)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to fetch projects of API token %q", tokens[i].Name)
+		}
+	}
+
+	return tokens, nil
+}
+
+// getAuthTokenProjects returns the projects of an API token and its role in each of them.
+func (c *ClusterTx) getAuthTokenProjects(id int64) ([]string, map[string]string, error) {
+	rows, err := c.tx.Query(`
+SELECT projects.name, auth_tokens_projects.role FROM auth_tokens_projects
+  JOIN projects ON projects.id = auth_tokens_projects.project_id
+  WHERE auth_tokens_projects.auth_token_id = ?
+  ORDER BY projects.name`, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer rows.Close()
+
+	projects := []string{}
+	roles := map[string]string{}
+	for rows.Next() {
+		var name, role string
+		err := rows.Scan(&name, &role)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		projects = append(projects, name)
+		roles[name] = role
+	}
+
+	return projects, roles, rows.Err()
+}
+
+// CreateAuthToken adds a new API token along with its allowed projects and its role in each of them.
+// Projects without a role get the admin role.
+func (c *ClusterTx) CreateAuthToken(token AuthToken) (int64, error) {
+	var expiresAt interface{}
+	if !token.ExpiresAt.IsZero() {
+		expiresAt = token.ExpiresAt
+	}
+
+	result, err := c.tx.Exec("INSERT INTO auth_tokens (name, description, secret_hash, restricted, created_at, expires_at) VALUES (?, ?, ?, ?, ?, ?)",
+		token.Name, token.Description, token.SecretHash, token.Restricted, time.Now().UTC(), expiresAt)
+	if err != nil {
+		return -1, errors.Wrapf(err, "Failed to create API token %q", token.Name)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return -1, err
+	}
+
+	for _, name := range token.Projects {
+		projectID, err := c.GetProjectID(name)
+		if err != nil {
+			return -1, err
+		}
+
+		role := token.Roles[name]
+		if role == "" {
+			role = "admin"
+		}
+
+		_, err = c.tx.Exec("INSERT INTO auth_tokens_projects (auth_token_id, project_id, role) VALUES (?, ?, ?)", id, projectID, role)
+		if err != nil {
+			return -1, err
+		}
+	}
+
+	return id, nil
+}
+
+// UpdateAuthToken updates the description of an API token.
+func (c *ClusterTx) UpdateAuthToken(name string, description string) error {
+	result, err := c.tx.Exec("UPDATE auth_tokens SET description = ? WHERE name = ?", description, name)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to update API token %q", name)
+	}
+
+	return authTokenRowsAffected(result)
+}
+
+// DeleteAuthToken deletes the API token with the given name.
+func (c *ClusterTx) DeleteAuthToken(name string) error {
+	result, err := c.tx.Exec("DELETE FROM auth_tokens WHERE name = ?", name)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to delete API token %q", name)
+	}
+
+	return authTokenRowsAffected(result)
+}
+
+func authTokenRowsAffected(result sql.Result) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return ErrNoSuchObject
+	}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+//go:build linux && cgo && !agent
+// +build linux,cgo,!agent
+
+package db_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lxc/lxd/lxd/db"
+)
+
+func TestAuthTokens(t *testing.T) {
+	tx, cleanup := db.NewTestClusterTx(t)
+	defer cleanup()
+
+	expiresAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+
+	_, err := tx.CreateAuthToken(db.AuthToken{
+		Name:       "ci",
+		SecretHash: "abcd",
+		Restricted: true,
+		Projects:   []string{"default"},
+		Roles:      map[string]string{"default": "viewer"},
+		ExpiresAt:  expiresAt,
+	})
+	require.NoError(t, err)
+
+	_, err = tx.CreateAuthToken(db.AuthToken{
+		Name:       "backup",
+		SecretHash: "efgh",
+		Restricted: true,
+		Projects:   []string{"default"},
+	})
+	require.NoError(t, err)
+
+	token, err := tx.GetAuthTokenBySecretHash("abcd")
+	require.NoError(t, err)
+	assert.Equal(t, "ci", token.Name)
+	assert.True(t, token.Restricted)
+	assert.Equal(t, []string{"default"}, token.Projects)
+	assert.Equal(t, map[string]string{"default": "viewer"}, token.Roles)
+	assert.True(t, expiresAt.Equal(token.ExpiresAt))
+	assert.False(t, token.Expired())
+
+	err = tx.UpdateAuthToken("ci", "CI system")
+	require.NoError(t, err)
+
+	tokens, err := tx.GetAuthTokens()
+	require.NoError(t, err)
+	require.Len(t, tokens, 2)
+	assert.Equal(t, "backup", tokens[0].Name)
+	assert.Equal(t, map[string]string{"default": "admin"}, tokens[0].Roles)
+	assert.Equal(t, "CI system", tokens[1].Description)
+
+	err = tx.DeleteAuthToken("ci")
+	require.NoError(t, err)
+
+	_, err = tx.GetAuthToken("ci")
+	assert.Equal(t, db.ErrNoSuchObject, err)
+
+	err = tx.DeleteAuthToken("ci")
+	assert.Equal(t, db.ErrNoSuchObject, err)
+}
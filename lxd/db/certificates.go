@@ -46,6 +46,9 @@ const CertificateTypeClient = CertificateType(1)
 // CertificateTypeServer indicates a server certificate type.
 const CertificateTypeServer = CertificateType(2)
 
+// CertificateTypeMetrics indicates a metrics certificate type.
+const CertificateTypeMetrics = CertificateType(3)
+
 // CertificateAPITypeToDBType converts an API type to the equivalent DB type.
 func CertificateAPITypeToDBType(apiType string) (CertificateType, error) {
 	switch apiType {
@@ -53,6 +56,8 @@ func CertificateAPITypeToDBType(apiType string) (CertificateType, error) {
 		return CertificateTypeClient, nil
 	case api.CertificateTypeServer:
 		return CertificateTypeServer, nil
+	case api.CertificateTypeMetrics:
+		return CertificateTypeMetrics, nil
 	}
 
 	return -1, fmt.Errorf("Invalid certificate type")
@@ -76,6 +81,8 @@ func (cert *Certificate) ToAPIType() string {
 		return api.CertificateTypeClient
 	case CertificateTypeServer:
 		return api.CertificateTypeServer
+	case CertificateTypeMetrics:
+		return api.CertificateTypeMetrics
 	}
 
 	return api.CertificateTypeUnknown
@@ -94,8 +101,9 @@ func (cert *Certificate) ToAPI() api.Certificate {
 	return resp
 }
 
-// UpdateCertificateProjects updates the list of projects on a certificate.
-func (c *ClusterTx) UpdateCertificateProjects(id int, projects []string) error {
+// UpdateCertificateProjects updates the list of projects on a certificate, along with the role of the
+// certificate in each of them. Projects without a role get the admin role.
+func (c *ClusterTx) UpdateCertificateProjects(id int, projects []string, roles map[string]string) error {
 	// Clear all projects from the restrictions.
 	q := "DELETE FROM certificates_projects WHERE certificate_id=?"
 	_, err := c.tx.Exec(q, id)
@@ -110,8 +118,13 @@ func (c *ClusterTx) UpdateCertificateProjects(id int, projects []string) error {
 			return err
 		}
 
-		q := "INSERT INTO certificates_projects (certificate_id, project_id) VALUES (?, ?)"
-		_, err = c.tx.Exec(q, id, projID)
+		role := roles[name]
+		if role == "" {
+			role = "admin"
+		}
+
+		q := "INSERT INTO certificates_projects (certificate_id, project_id, role) VALUES (?, ?, ?)"
+		_, err = c.tx.Exec(q, id, projID, role)
 		if err != nil {
 			return err
 		}
@@ -120,6 +133,61 @@ func (c *ClusterTx) UpdateCertificateProjects(id int, projects []string) error {
 	return nil
 }
 
+// GetCertificateProjectRoles returns the role of the certificates matching the filter in each of their
+// allowed projects, indexed by certificate fingerprint and project name. Only the Fingerprint filter
+// field is supported.
+func (c *ClusterTx) GetCertificateProjectRoles(filter CertificateFilter) (map[string]map[string]string, error) {
+	q := `SELECT certificates.fingerprint, projects.name, certificates_projects.role
+FROM certificates_projects
+JOIN certificates ON certificates.id = certificates_projects.certificate_id
+JOIN projects ON projects.id = certificates_projects.project_id`
+	args := []interface{}{}
+
+	if filter.Fingerprint != nil {
+		q += " WHERE certificates.fingerprint = ?"
+		args = append(args, *filter.Fingerprint)
+	}
+
+	stmt, err := c.tx.Prepare(q)
+	if err != nil {
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	roles := []struct {
+		fingerprint string
+		project     string
+		role        string
+	}{}
+
+	dest := func(i int) []interface{} {
+		roles = append(roles, struct {
+			fingerprint string
+			project     string
+			role        string
+		}{})
+
+		return []interface{}{&roles[i].fingerprint, &roles[i].project, &roles[i].role}
+	}
+
+	err = query.SelectObjects(stmt, dest, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to fetch certificate project roles")
+	}
+
+	result := map[string]map[string]string{}
+	for _, r := range roles {
+		if result[r.fingerprint] == nil {
+			result[r.fingerprint] = map[string]string{}
+		}
+
+		result[r.fingerprint][r.project] = r.role
+	}
+
+	return result, nil
+}
+
 // CertificateFilter specifies potential query parameter fields.
 type CertificateFilter struct {
 	Fingerprint *string
@@ -210,14 +278,30 @@ func (c *Cluster) UpdateCertificate(fingerprint string, cert Certificate) error
 	return err
 }
 
-// UpdateCertificateProjects updates the list of projects on a certificate.
-func (c *Cluster) UpdateCertificateProjects(id int, projects []string) error {
+// UpdateCertificateProjects updates the list of projects on a certificate, along with the role of the
+// certificate in each of them.
+func (c *Cluster) UpdateCertificateProjects(id int, projects []string, roles map[string]string) error {
 	err := c.Transaction(func(tx *ClusterTx) error {
-		return tx.UpdateCertificateProjects(id, projects)
+		return tx.UpdateCertificateProjects(id, projects, roles)
 	})
 	return err
 }
 
+// GetCertificateProjectRoles returns the role of a certificate in each of its allowed projects.
+func (c *Cluster) GetCertificateProjectRoles(fingerprint string) (map[string]string, error) {
+	var roles map[string]map[string]string
+	err := c.Transaction(func(tx *ClusterTx) error {
+		var err error
+		roles, err = tx.GetCertificateProjectRoles(CertificateFilter{Fingerprint: &fingerprint})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return roles[fingerprint], nil
+}
+
 // GetCertificates returns all available local certificates.
 func (n *NodeTx) GetCertificates() ([]Certificate, error) {
 	dbCerts := []struct {
@@ -6,6 +6,25 @@ package cluster
 // modify the database schema, please add a new schema update to update.go
 // and the run 'make update-schema'.
 const freshSchema = `
+CREATE TABLE auth_tokens (
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	name TEXT NOT NULL,
+	description TEXT NOT NULL,
+	secret_hash TEXT NOT NULL,
+	restricted INTEGER NOT NULL DEFAULT 0,
+	created_at DATETIME NOT NULL,
+	expires_at DATETIME,
+	UNIQUE (name),
+	UNIQUE (secret_hash)
+);
+CREATE TABLE auth_tokens_projects (
+	auth_token_id INTEGER NOT NULL,
+	project_id INTEGER NOT NULL,
+	role TEXT NOT NULL DEFAULT 'admin',
+	FOREIGN KEY (auth_token_id) REFERENCES auth_tokens (id) ON DELETE CASCADE,
+	FOREIGN KEY (project_id) REFERENCES projects (id) ON DELETE CASCADE,
+	UNIQUE (auth_token_id, project_id)
+);
 CREATE TABLE certificates (
     id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
     fingerprint TEXT NOT NULL,
@@ -18,6 +37,7 @@ CREATE TABLE certificates (
 CREATE TABLE certificates_projects (
 	certificate_id INTEGER NOT NULL,
 	project_id INTEGER NOT NULL,
+	role TEXT NOT NULL DEFAULT 'admin',
 	FOREIGN KEY (certificate_id) REFERENCES certificates (id) ON DELETE CASCADE,
 	FOREIGN KEY (project_id) REFERENCES projects (id) ON DELETE CASCADE,
 	UNIQUE (certificate_id, project_id)
@@ -35,6 +55,25 @@ CREATE TABLE config (
     value TEXT,
     UNIQUE (key)
 );
+CREATE TABLE events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    node_id INTEGER NOT NULL,
+    project_id INTEGER,
+    type TEXT NOT NULL,
+    timestamp DATETIME NOT NULL,
+    metadata TEXT NOT NULL,
+    FOREIGN KEY (node_id) REFERENCES nodes (id) ON DELETE CASCADE,
+    FOREIGN KEY (project_id) REFERENCES projects (id) ON DELETE CASCADE
+);
+CREATE INDEX events_timestamp_idx ON events (timestamp);
+CREATE TABLE idmap_allocations (
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	instance_id INTEGER NOT NULL,
+	base INTEGER NOT NULL,
+	size INTEGER NOT NULL,
+	FOREIGN KEY (instance_id) REFERENCES instances (id) ON DELETE CASCADE,
+	UNIQUE (instance_id)
+);
 CREATE TABLE "images" (
     id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
     fingerprint TEXT NOT NULL,
@@ -97,6 +136,15 @@ CREATE TABLE images_source (
     alias TEXT NOT NULL,
     FOREIGN KEY (image_id) REFERENCES images (id) ON DELETE CASCADE
 );
+CREATE TABLE instance_templates (
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	project_id INTEGER NOT NULL,
+	name TEXT NOT NULL,
+	description TEXT NOT NULL,
+	definition TEXT NOT NULL,
+	FOREIGN KEY (project_id) REFERENCES projects (id) ON DELETE CASCADE,
+	UNIQUE (project_id, name)
+);
 CREATE TABLE "instances" (
     id INTEGER primary key AUTOINCREMENT NOT NULL,
     node_id INTEGER NOT NULL,
@@ -348,6 +396,26 @@ CREATE TABLE "networks_forwards_config" (
 	UNIQUE (network_forward_id, key),
 	FOREIGN KEY (network_forward_id) REFERENCES "networks_forwards" (id) ON DELETE CASCADE
 );
+CREATE TABLE "networks_load_balancers" (
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	network_id INTEGER NOT NULL,
+	node_id INTEGER,
+	listen_address TEXT NOT NULL,
+	description TEXT NOT NULL,
+	backends TEXT NOT NULL,
+	ports TEXT NOT NULL,
+	UNIQUE (network_id, node_id, listen_address),
+	FOREIGN KEY (network_id) REFERENCES "networks" (id) ON DELETE CASCADE,
+	FOREIGN KEY (node_id) REFERENCES "nodes" (id) ON DELETE CASCADE
+);
+CREATE TABLE "networks_load_balancers_config" (
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	network_load_balancer_id INTEGER NOT NULL,
+	key VARCHAR(255) NOT NULL,
+	value TEXT,
+	UNIQUE (network_load_balancer_id, key),
+	FOREIGN KEY (network_load_balancer_id) REFERENCES "networks_load_balancers" (id) ON DELETE CASCADE
+);
 CREATE TABLE "networks_nodes" (
     id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
     network_id INTEGER NOT NULL,
@@ -393,6 +461,19 @@ CREATE TABLE "operations" (
     FOREIGN KEY (node_id) REFERENCES nodes (id) ON DELETE CASCADE,
     FOREIGN KEY (project_id) REFERENCES projects (id) ON DELETE CASCADE
 );
+CREATE TABLE operations_history (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    uuid TEXT NOT NULL,
+    node_id INTEGER NOT NULL,
+    status_code INTEGER NOT NULL,
+    state TEXT NOT NULL,
+    updated_at DATETIME NOT NULL,
+    project_id INTEGER,
+    type INTEGER NOT NULL DEFAULT 0,
+    UNIQUE (uuid),
+    FOREIGN KEY (node_id) REFERENCES nodes (id) ON DELETE CASCADE,
+    FOREIGN KEY (project_id) REFERENCES projects (id) ON DELETE CASCADE
+);
 CREATE TABLE "profiles" (
     id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
     name TEXT NOT NULL,
@@ -533,6 +614,15 @@ CREATE VIEW projects_used_by_ref (name,
     networks_acls.name,
     projects.name)
     FROM networks_acls JOIN projects ON project_id=projects.id;
+CREATE TABLE secrets (
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	project_id INTEGER NOT NULL,
+	name TEXT NOT NULL,
+	description TEXT NOT NULL,
+	value TEXT NOT NULL,
+	FOREIGN KEY (project_id) REFERENCES projects (id) ON DELETE CASCADE,
+	UNIQUE (project_id, name)
+);
 CREATE TABLE storage_pools (
     id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
     name TEXT NOT NULL,
@@ -676,5 +766,5 @@ CREATE TABLE warnings (
 );
 CREATE UNIQUE INDEX warnings_unique_node_id_project_id_entity_type_code_entity_id_type_code ON warnings(IFNULL(node_id, -1), IFNULL(project_id, -1), entity_type_code, entity_id, type_code);
 
-INSERT INTO schema (version, updated_at) VALUES (50, strftime("%s"))
+INSERT INTO schema (version, updated_at) VALUES (58, strftime("%s"))
 `
@@ -89,6 +89,192 @@ var updates = map[int]schema.Update{
 	48: updateFromV47,
 	49: updateFromV48,
 	50: updateFromV49,
+	51: updateFromV50,
+	52: updateFromV51,
+	53: updateFromV52,
+	54: updateFromV53,
+	55: updateFromV54,
+	56: updateFromV55,
+	57: updateFromV56,
+	58: updateFromV57,
+}
+
+// updateFromV57 creates the networks_load_balancers and networks_load_balancers_config tables.
+func updateFromV57(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+CREATE TABLE "networks_load_balancers" (
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	network_id INTEGER NOT NULL,
+	node_id INTEGER,
+	listen_address TEXT NOT NULL,
+	description TEXT NOT NULL,
+	backends TEXT NOT NULL,
+	ports TEXT NOT NULL,
+	UNIQUE (network_id, node_id, listen_address),
+	FOREIGN KEY (network_id) REFERENCES "networks" (id) ON DELETE CASCADE,
+	FOREIGN KEY (node_id) REFERENCES "nodes" (id) ON DELETE CASCADE
+);
+
+CREATE TABLE "networks_load_balancers_config" (
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	network_load_balancer_id INTEGER NOT NULL,
+	key VARCHAR(255) NOT NULL,
+	value TEXT,
+	UNIQUE (network_load_balancer_id, key),
+	FOREIGN KEY (network_load_balancer_id) REFERENCES "networks_load_balancers" (id) ON DELETE CASCADE
+);
+`)
+	if err != nil {
+		return errors.Wrap(err, `Failed creating network load balancers tables`)
+	}
+
+	return nil
+}
+
+// updateFromV56 creates the instance_templates table.
+func updateFromV56(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+CREATE TABLE instance_templates (
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	project_id INTEGER NOT NULL,
+	name TEXT NOT NULL,
+	description TEXT NOT NULL,
+	definition TEXT NOT NULL,
+	FOREIGN KEY (project_id) REFERENCES projects (id) ON DELETE CASCADE,
+	UNIQUE (project_id, name)
+);
+`)
+	if err != nil {
+		return errors.Wrap(err, "Failed to create instance_templates table")
+	}
+
+	return nil
+}
+
+// updateFromV55 creates the idmap_allocations table.
+func updateFromV55(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+CREATE TABLE idmap_allocations (
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	instance_id INTEGER NOT NULL,
+	base INTEGER NOT NULL,
+	size INTEGER NOT NULL,
+	FOREIGN KEY (instance_id) REFERENCES instances (id) ON DELETE CASCADE,
+	UNIQUE (instance_id)
+);
+`)
+	if err != nil {
+		return errors.Wrap(err, "Failed to create idmap_allocations table")
+	}
+
+	return nil
+}
+
+// updateFromV54 creates the secrets table.
+func updateFromV54(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+CREATE TABLE secrets (
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	project_id INTEGER NOT NULL,
+	name TEXT NOT NULL,
+	description TEXT NOT NULL,
+	value TEXT NOT NULL,
+	FOREIGN KEY (project_id) REFERENCES projects (id) ON DELETE CASCADE,
+	UNIQUE (project_id, name)
+);
+`)
+	if err != nil {
+		return errors.Wrap(err, "Failed to create secrets table")
+	}
+
+	return nil
+}
+
+// updateFromV53 creates the auth_tokens tables.
+func updateFromV53(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+CREATE TABLE auth_tokens (
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	name TEXT NOT NULL,
+	description TEXT NOT NULL,
+	secret_hash TEXT NOT NULL,
+	restricted INTEGER NOT NULL DEFAULT 0,
+	created_at DATETIME NOT NULL,
+	expires_at DATETIME,
+	UNIQUE (name),
+	UNIQUE (secret_hash)
+);
+CREATE TABLE auth_tokens_projects (
+	auth_token_id INTEGER NOT NULL,
+	project_id INTEGER NOT NULL,
+	role TEXT NOT NULL DEFAULT 'admin',
+	FOREIGN KEY (auth_token_id) REFERENCES auth_tokens (id) ON DELETE CASCADE,
+	FOREIGN KEY (project_id) REFERENCES projects (id) ON DELETE CASCADE,
+	UNIQUE (auth_token_id, project_id)
+);
+`)
+	if err != nil {
+		return errors.Wrap(err, "Failed to create auth_tokens tables")
+	}
+
+	return nil
+}
+
+// updateFromV52 adds the role of restricted certificates in their projects.
+func updateFromV52(tx *sql.Tx) error {
+	_, err := tx.Exec("ALTER TABLE certificates_projects ADD COLUMN role TEXT NOT NULL DEFAULT 'admin';")
+	if err != nil {
+		return errors.Wrap(err, "Failed to add role column to certificates_projects table")
+	}
+
+	return nil
+}
+
+// updateFromV51 creates the operations_history table.
+func updateFromV51(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+CREATE TABLE operations_history (
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	uuid TEXT NOT NULL,
+	node_id INTEGER NOT NULL,
+	status_code INTEGER NOT NULL,
+	state TEXT NOT NULL,
+	updated_at DATETIME NOT NULL,
+	project_id INTEGER,
+	type INTEGER NOT NULL DEFAULT 0,
+	UNIQUE (uuid),
+	FOREIGN KEY (node_id) REFERENCES nodes (id) ON DELETE CASCADE,
+	FOREIGN KEY (project_id) REFERENCES projects (id) ON DELETE CASCADE
+);
+`)
+	if err != nil {
+		return errors.Wrap(err, "Failed to create operations_history table")
+	}
+
+	return nil
+}
+
+// updateFromV50 creates the events table.
+func updateFromV50(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+CREATE TABLE events (
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	node_id INTEGER NOT NULL,
+	project_id INTEGER,
+	type TEXT NOT NULL,
+	timestamp DATETIME NOT NULL,
+	metadata TEXT NOT NULL,
+	FOREIGN KEY (node_id) REFERENCES nodes (id) ON DELETE CASCADE,
+	FOREIGN KEY (project_id) REFERENCES projects (id) ON DELETE CASCADE
+);
+
+CREATE INDEX events_timestamp_idx ON events (timestamp);
+`)
+	if err != nil {
+		return errors.Wrap(err, "Failed to create events table")
+	}
+
+	return nil
 }
 
 // updateFromV49 creates the networks_forwards and networks_forwards_config tables.
@@ -0,0 +1,133 @@
+//go:build linux && cgo && !agent
+// +build linux,cgo,!agent
+
+package db
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/lxc/lxd/lxd/db/cluster"
+	"github.com/lxc/lxd/lxd/db/query"
+)
+
+// Event is a value object holding db-related details about a historical event.
+type Event struct {
+	ID        int
+	Node      string
+	Project   string
+	Type      string
+	Timestamp time.Time
+	Metadata  string
+}
+
+// EventFilter specifies potential query parameter fields.
+type EventFilter struct {
+	Since   time.Time
+	Types   []string
+	Project *string
+	Global  bool // Whether to include the events not tied to any project along with the project ones.
+}
+
+var eventCreate = cluster.RegisterStmt(`
+INSERT INTO events (node_id, project_id, type, timestamp, metadata)
+  VALUES (?, (SELECT projects.id FROM projects WHERE projects.name = ?), ?, ?, ?)
+`)
+
+var eventDeleteBefore = cluster.RegisterStmt(`
+DELETE FROM events WHERE timestamp < ?
+`)
+
+// CreateEvent records an event emitted by the local member.
+func (c *ClusterTx) CreateEvent(object Event) error {
+	stmt := c.stmt(eventCreate)
+
+	_, err := stmt.Exec(c.nodeID, object.Project, object.Type, object.Timestamp, object.Metadata)
+	if err != nil {
+		return errors.Wrap(err, "Failed to create event")
+	}
+
+	return nil
+}
+
+// GetEvents returns the recorded events matching the given filter, oldest first.
+func (c *ClusterTx) GetEvents(filter EventFilter) ([]Event, error) {
+	q := `SELECT events.id, nodes.name AS node, IFNULL(projects.name, "") AS project, events.type, events.timestamp, events.metadata
+	FROM events JOIN nodes ON events.node_id = nodes.id LEFT JOIN projects ON events.project_id = projects.id`
+
+	where := []string{}
+	args := []interface{}{}
+
+	if !filter.Since.IsZero() {
+		where = append(where, "events.timestamp >= ?")
+		args = append(args, filter.Since)
+	}
+
+	if len(filter.Types) > 0 {
+		where = append(where, fmt.Sprintf("events.type IN %s", query.Params(len(filter.Types))))
+		for _, eventType := range filter.Types {
+			args = append(args, eventType)
+		}
+	}
+
+	if filter.Project != nil {
+		if filter.Global {
+			where = append(where, "(projects.name = ? OR events.project_id IS NULL)")
+		} else {
+			where = append(where, "projects.name = ?")
+		}
+
+		args = append(args, *filter.Project)
+	}
+
+	if len(where) > 0 {
+		q += " WHERE " + strings.Join(where, " AND ")
+	}
+
+	q += " ORDER BY events.timestamp, events.id"
+
+	stmt, err := c.tx.Prepare(q)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	// Result slice.
+	objects := make([]Event, 0)
+
+	// Dest function for scanning a row.
+	dest := func(i int) []interface{} {
+		objects = append(objects, Event{})
+		return []interface{}{
+			&objects[i].ID,
+			&objects[i].Node,
+			&objects[i].Project,
+			&objects[i].Type,
+			&objects[i].Timestamp,
+			&objects[i].Metadata,
+		}
+	}
+
+	// Select.
+	err = query.SelectObjects(stmt, dest, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to fetch events")
+	}
+
+	return objects, nil
+}
+
+// DeleteEventsBefore deletes all the events recorded before the given time.
+func (c *ClusterTx) DeleteEventsBefore(t time.Time) error {
+	stmt := c.stmt(eventDeleteBefore)
+
+	_, err := stmt.Exec(t)
+	if err != nil {
+		return errors.Wrap(err, "Failed to delete expired events")
+	}
+
+	return nil
+}
@@ -0,0 +1,83 @@
+//go:build linux && cgo && !agent
+// +build linux,cgo,!agent
+
+package db
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/lxc/lxd/lxd/db/query"
+	"github.com/lxc/lxd/shared/api"
+)
+
+// IdmapAllocation is the range of host uids/gids allocated to an instance with an isolated idmap.
+type IdmapAllocation struct {
+	InstanceID int64
+	Project    string
+	Instance   string
+	Node       string
+	Base       int64
+	Size       int64
+}
+
+// ToAPI converts the database IdmapAllocation struct to an api.IdmapAllocation entry.
+func (a *IdmapAllocation) ToAPI() api.IdmapAllocation {
+	return api.IdmapAllocation{
+		Project:  a.Project,
+		Instance: a.Instance,
+		Location: a.Node,
+		Base:     a.Base,
+		Size:     a.Size,
+	}
+}
+
+// GetIdmapAllocations returns the idmap allocations of all the instances of the cluster.
+func (c *ClusterTx) GetIdmapAllocations() ([]IdmapAllocation, error) {
+	allocations := []IdmapAllocation{}
+
+	stmt, err := c.tx.Prepare(`
+SELECT idmap_allocations.instance_id, projects.name, instances.name, nodes.name, idmap_allocations.base, idmap_allocations.size
+  FROM idmap_allocations
+  JOIN instances ON instances.id = idmap_allocations.instance_id
+  JOIN projects ON projects.id = instances.project_id
+  JOIN nodes ON nodes.id = instances.node_id
+  ORDER BY idmap_allocations.base`)
+	if err != nil {
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	dest := func(i int) []interface{} {
+		allocations = append(allocations, IdmapAllocation{})
+		return []interface{}{&allocations[i].InstanceID, &allocations[i].Project, &allocations[i].Instance, &allocations[i].Node, &allocations[i].Base, &allocations[i].Size}
+	}
+
+	err = query.SelectObjects(stmt, dest)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to fetch idmap allocations")
+	}
+
+	return allocations, nil
+}
+
+// SetIdmapAllocation records the range of host uids/gids allocated to the instance with the given ID,
+// replacing any previous allocation.
+func (c *ClusterTx) SetIdmapAllocation(instanceID int64, base int64, size int64) error {
+	_, err := c.tx.Exec("INSERT OR REPLACE INTO idmap_allocations (instance_id, base, size) VALUES (?, ?, ?)", instanceID, base, size)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to record idmap allocation of instance %d", instanceID)
+	}
+
+	return nil
+}
+
+// DeleteIdmapAllocation releases the range of host uids/gids allocated to the instance with the given ID, if any.
+func (c *ClusterTx) DeleteIdmapAllocation(instanceID int64) error {
+	_, err := c.tx.Exec("DELETE FROM idmap_allocations WHERE instance_id = ?", instanceID)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to delete idmap allocation of instance %d", instanceID)
+	}
+
+	return nil
+}
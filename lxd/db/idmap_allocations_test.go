@@ -0,0 +1,50 @@
+//go:build linux && cgo && !agent
+// +build linux,cgo,!agent
+
+package db_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lxc/lxd/lxd/db"
+)
+
+func TestIdmapAllocations(t *testing.T) {
+	tx, cleanup := db.NewTestClusterTx(t)
+	defer cleanup()
+
+	nodeID2, err := tx.CreateNode("node2", "1.2.3.4:666")
+	require.NoError(t, err)
+
+	addContainer(t, tx, 1, "c1")
+	addContainer(t, tx, nodeID2, "c2")
+
+	c1 := getContainerID(t, tx, "c1")
+	c2 := getContainerID(t, tx, "c2")
+
+	require.NoError(t, tx.SetIdmapAllocation(c2, 1065536, 65536))
+	require.NoError(t, tx.SetIdmapAllocation(c1, 1000000, 65536))
+
+	// Allocations are replaced.
+	require.NoError(t, tx.SetIdmapAllocation(c1, 1131072, 65536))
+
+	allocations, err := tx.GetIdmapAllocations()
+	require.NoError(t, err)
+	require.Len(t, allocations, 2)
+	assert.Equal(t, "c2", allocations[0].Instance)
+	assert.Equal(t, "node2", allocations[0].Node)
+	assert.Equal(t, int64(1065536), allocations[0].Base)
+	assert.Equal(t, "c1", allocations[1].Instance)
+	assert.Equal(t, "default", allocations[1].Project)
+	assert.Equal(t, int64(1131072), allocations[1].Base)
+
+	require.NoError(t, tx.DeleteIdmapAllocation(c1))
+	require.NoError(t, tx.DeleteIdmapAllocation(c1))
+
+	allocations, err = tx.GetIdmapAllocations()
+	require.NoError(t, err)
+	require.Len(t, allocations, 1)
+}
@@ -0,0 +1,153 @@
+//go:build linux && cgo && !agent
+// +build linux,cgo,!agent
+
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+
+	"github.com/lxc/lxd/lxd/db/query"
+	"github.com/lxc/lxd/shared/api"
+)
+
+// InstanceTemplate is a template of a project, from which fully configured instances can be created.
+type InstanceTemplate struct {
+	ID          int64
+	Project     string
+	Name        string
+	Description string
+
+	// JSON encoded api.InstanceTemplatePut, without the description.
+	Definition string
+}
+
+// ToAPI converts the database InstanceTemplate struct to an api.InstanceTemplate entry.
+func (t *InstanceTemplate) ToAPI() (*api.InstanceTemplate, error) {
+	resp := api.InstanceTemplate{
+		Name: t.Name,
+	}
+
+	err := json.Unmarshal([]byte(t.Definition), &resp.InstanceTemplatePut)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to parse definition of instance template %q", t.Name)
+	}
+
+	resp.Description = t.Description
+
+	return &resp, nil
+}
+
+// InstanceTemplateDefinition returns the definition to store for an instance template.
+func InstanceTemplateDefinition(put api.InstanceTemplatePut) (string, error) {
+	put.Description = ""
+
+	buf, err := json.Marshal(put)
+	if err != nil {
+		return "", err
+	}
+
+	return string(buf), nil
+}
+
+// GetInstanceTemplates returns all the instance templates of the given project.
+func (c *ClusterTx) GetInstanceTemplates(project string) ([]InstanceTemplate, error) {
+	return c.getInstanceTemplates("WHERE projects.name = ?", project)
+}
+
+// GetInstanceTemplate returns the instance template of the given project with the given name.
+func (c *ClusterTx) GetInstanceTemplate(project string, name string) (*InstanceTemplate, error) {
+	templates, err := c.getInstanceTemplates("WHERE projects.name = ? AND instance_templates.name = ?", project, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(templates) == 0 {
+		return nil, ErrNoSuchObject
+	}
+
+	return &templates[0], nil
+}
+
+func (c *ClusterTx) getInstanceTemplates(where string, args ...interface{}) ([]InstanceTemplate, error) {
+	templates := []InstanceTemplate{}
+
+	stmt, err := c.tx.Prepare(`
+SELECT instance_templates.id, projects.name, instance_templates.name, instance_templates.description, instance_templates.definition
+  FROM instance_templates
+  JOIN projects ON projects.id = instance_templates.project_id
+  ` + where + `
+  ORDER BY projects.name, instance_templates.name`)
+	if err != nil {
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	dest := func(i int) []interface{} {
+		templates = append(templates, InstanceTemplate{})
+		return []interface{}{&templates[i].ID, &templates[i].Project, &templates[i].Name, &templates[i].Description, &templates[i].Definition}
+	}
+
+	err = query.SelectObjects(stmt, dest, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to fetch instance templates")
+	}
+
+	return templates, nil
+}
+
+// CreateInstanceTemplate adds a new instance template to the given project.
+func (c *ClusterTx) CreateInstanceTemplate(project string, template InstanceTemplate) (int64, error) {
+	projectID, err := c.GetProjectID(project)
+	if err != nil {
+		return -1, err
+	}
+
+	result, err := c.tx.Exec("INSERT INTO instance_templates (project_id, name, description, definition) VALUES (?, ?, ?, ?)",
+		projectID, template.Name, template.Description, template.Definition)
+	if err != nil {
+		return -1, errors.Wrapf(err, "Failed to create instance template %q", template.Name)
+	}
+
+	return result.LastInsertId()
+}
+
+// UpdateInstanceTemplate updates the description and the definition of an instance template.
+func (c *ClusterTx) UpdateInstanceTemplate(project string, name string, description string, definition string) error {
+	result, err := c.tx.Exec(`
+UPDATE instance_templates SET description = ?, definition = ?
+  WHERE project_id = (SELECT id FROM projects WHERE name = ?) AND name = ?`, description, definition, project, name)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to update instance template %q", name)
+	}
+
+	return instanceTemplateRowsAffected(result)
+}
+
+// DeleteInstanceTemplate deletes the instance template of the given project with the given name.
+func (c *ClusterTx) DeleteInstanceTemplate(project string, name string) error {
+	result, err := c.tx.Exec(`
+DELETE FROM instance_templates
+  WHERE project_id = (SELECT id FROM projects WHERE name = ?) AND name = ?`, project, name)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to delete instance template %q", name)
+	}
+
+	return instanceTemplateRowsAffected(result)
+}
+
+func instanceTemplateRowsAffected(result sql.Result) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return ErrNoSuchObject
+	}
+
+	return nil
+}
@@ -0,0 +1,54 @@
+//go:build linux && cgo && !agent
+// +build linux,cgo,!agent
+
+package db_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/shared/api"
+)
+
+func TestInstanceTemplates(t *testing.T) {
+	tx, cleanup := db.NewTestClusterTx(t)
+	defer cleanup()
+
+	definition, err := db.InstanceTemplateDefinition(api.InstanceTemplatePut{
+		Description: "Web server",
+		Config:      map[string]string{"environment.ENV": "${var:env}"},
+	})
+	require.NoError(t, err)
+
+	_, err = tx.CreateInstanceTemplate("default", db.InstanceTemplate{Name: "webserver", Description: "Web server", Definition: definition})
+	require.NoError(t, err)
+
+	template, err := tx.GetInstanceTemplate("default", "webserver")
+	require.NoError(t, err)
+	assert.Equal(t, "default", template.Project)
+
+	apiTemplate, err := template.ToAPI()
+	require.NoError(t, err)
+	assert.Equal(t, "Web server", apiTemplate.Description)
+	assert.Equal(t, "${var:env}", apiTemplate.Config["environment.ENV"])
+
+	err = tx.UpdateInstanceTemplate("default", "webserver", "Nginx", definition)
+	require.NoError(t, err)
+
+	templates, err := tx.GetInstanceTemplates("default")
+	require.NoError(t, err)
+	require.Len(t, templates, 1)
+	assert.Equal(t, "Nginx", templates[0].Description)
+
+	err = tx.DeleteInstanceTemplate("default", "webserver")
+	require.NoError(t, err)
+
+	_, err = tx.GetInstanceTemplate("default", "webserver")
+	assert.Equal(t, db.ErrNoSuchObject, err)
+
+	err = tx.DeleteInstanceTemplate("default", "webserver")
+	assert.Equal(t, db.ErrNoSuchObject, err)
+}
@@ -0,0 +1,67 @@
+//go:build linux && cgo && !agent
+// +build linux,cgo,!agent
+
+package db_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/project"
+	"github.com/lxc/lxd/shared/api"
+)
+
+func TestNetworkLoadBalancers(t *testing.T) {
+	cluster, cleanup := db.NewTestCluster(t)
+	defer cleanup()
+
+	networkID, err := cluster.CreateNetwork(project.Default, "ovn0", "", db.NetworkTypeOVN, nil)
+	require.NoError(t, err)
+
+	_, err = cluster.CreateNetworkLoadBalancer(networkID, false, &api.NetworkLoadBalancersPost{
+		ListenAddress: "192.0.2.1",
+		NetworkLoadBalancerPut: api.NetworkLoadBalancerPut{
+			Description: "Web",
+			Config:      map[string]string{"user.foo": "bar"},
+			Backends: []api.NetworkLoadBalancerBackend{
+				{Name: "c1", TargetAddress: "198.51.100.2", TargetPort: "8080"},
+			},
+			Ports: []api.NetworkLoadBalancerPort{
+				{Protocol: "tcp", ListenPort: "80", TargetBackend: []string{"c1"}},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	loadBalancerID, loadBalancer, err := cluster.GetNetworkLoadBalancer(networkID, false, "192.0.2.1")
+	require.NoError(t, err)
+	assert.Equal(t, "Web", loadBalancer.Description)
+	assert.Equal(t, "bar", loadBalancer.Config["user.foo"])
+	require.Len(t, loadBalancer.Backends, 1)
+	assert.Equal(t, "198.51.100.2", loadBalancer.Backends[0].TargetAddress)
+	require.Len(t, loadBalancer.Ports, 1)
+	assert.Equal(t, []string{"c1"}, loadBalancer.Ports[0].TargetBackend)
+
+	put := loadBalancer.Writable()
+	put.Backends = nil
+	put.Ports = nil
+	err = cluster.UpdateNetworkLoadBalancer(networkID, loadBalancerID, &put)
+	require.NoError(t, err)
+
+	loadBalancers, err := cluster.GetNetworkLoadBalancers(networkID, false)
+	require.NoError(t, err)
+	require.Len(t, loadBalancers, 1)
+	assert.Len(t, loadBalancers[loadBalancerID].Backends, 0)
+	assert.Len(t, loadBalancers[loadBalancerID].Ports, 0)
+
+	err = cluster.DeleteNetworkLoadBalancer(networkID, loadBalancerID)
+	require.NoError(t, err)
+
+	_, _, err = cluster.GetNetworkLoadBalancer(networkID, false, "192.0.2.1")
+	_, matched := api.StatusErrorMatch(err, http.StatusNotFound)
+	assert.True(t, matched)
+}
@@ -0,0 +1,184 @@
+//go:build linux && cgo && !agent
+// +build linux,cgo,!agent
+
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/lxc/lxd/lxd/db/cluster"
+	"github.com/lxc/lxd/lxd/db/query"
+	"github.com/lxc/lxd/shared/api"
+)
+
+var operationHistoryUpsert = cluster.RegisterStmt(`
+INSERT INTO operations_history (uuid, node_id, project_id, type, status_code, state, updated_at)
+  VALUES (?, ?, (SELECT id FROM projects WHERE name = ?), ?, ?, ?, ?)
+  ON CONFLICT (uuid) DO UPDATE SET status_code=excluded.status_code, state=excluded.state, updated_at=excluded.updated_at
+`)
+
+var operationHistoryDeleteBefore = cluster.RegisterStmt(`
+DELETE FROM operations_history WHERE updated_at < ? AND status_code NOT IN (?, ?, ?)
+`)
+
+// InterruptedOperation is an operation which was still in progress when the local member stopped.
+type InterruptedOperation struct {
+	Operation   api.Operation
+	ProjectName string
+	Type        OperationType
+}
+
+// UpsertOperationHistory records the current state of an operation of the given project and type running on
+// the local member.
+func (c *ClusterTx) UpsertOperationHistory(projectName string, opType OperationType, op api.Operation) error {
+	state, err := json.Marshal(op)
+	if err != nil {
+		return err
+	}
+
+	stmt := c.stmt(operationHistoryUpsert)
+	_, err = stmt.Exec(op.ID, c.nodeID, projectName, opType, op.StatusCode, string(state), time.Now())
+	if err != nil {
+		return errors.Wrapf(err, "Failed to record state of operation %q", op.ID)
+	}
+
+	return nil
+}
+
+// GetOperationHistory returns the last recorded state of the operation with the given UUID, along with the
+// name of its project (empty if unknown).
+func (c *ClusterTx) GetOperationHistory(uuid string) (*api.Operation, string, error) {
+	sql := `
+SELECT operations_history.state, coalesce(projects.name, '')
+  FROM operations_history
+  LEFT JOIN projects ON projects.id = operations_history.project_id
+  WHERE operations_history.uuid = ?`
+
+	type history struct {
+		state       string
+		projectName string
+	}
+
+	histories := []history{}
+	dest := func(i int) []interface{} {
+		histories = append(histories, history{})
+		return []interface{}{&histories[i].state, &histories[i].projectName}
+	}
+
+	stmt, err := c.tx.Prepare(sql)
+	if err != nil {
+		return nil, "", err
+	}
+	defer stmt.Close()
+
+	err = query.SelectObjects(stmt, dest, uuid)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "Failed to fetch state of operation %q", uuid)
+	}
+
+	switch len(histories) {
+	case 0:
+		return nil, "", ErrNoSuchObject
+	case 1:
+		op := api.Operation{}
+		err = json.Unmarshal([]byte(histories[0].state), &op)
+		if err != nil {
+			return nil, "", errors.Wrapf(err, "Failed to parse state of operation %q", uuid)
+		}
+
+		return &op, histories[0].projectName, nil
+	default:
+		return nil, "", fmt.Errorf("More than one operation matches")
+	}
+}
+
+// GetInterruptedOperations returns the operations of the given member which weren't done yet. This is used at
+// startup, as any operation still in progress at that point was interrupted by the restart.
+func (c *ClusterTx) GetInterruptedOperations(nodeID int64) ([]InterruptedOperation, error) {
+	sql := `
+SELECT operations_history.state, coalesce(projects.name, ''), operations_history.type
+  FROM operations_history
+  LEFT JOIN projects ON projects.id = operations_history.project_id
+  WHERE operations_history.node_id = ? AND operations_history.status_code IN (?, ?, ?)`
+
+	type history struct {
+		state       string
+		projectName string
+		opType      OperationType
+	}
+
+	histories := []history{}
+	dest := func(i int) []interface{} {
+		histories = append(histories, history{})
+		return []interface{}{&histories[i].state, &histories[i].projectName, &histories[i].opType}
+	}
+
+	stmt, err := c.tx.Prepare(sql)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	err = query.SelectObjects(stmt, dest, nodeID, api.Pending, api.Running, api.Cancelling)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to fetch interrupted operations")
+	}
+
+	ops := make([]InterruptedOperation, 0, len(histories))
+	for _, h := range histories {
+		op := InterruptedOperation{ProjectName: h.projectName, Type: h.opType}
+		err = json.Unmarshal([]byte(h.state), &op.Operation)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to parse interrupted operation")
+		}
+
+		ops = append(ops, op)
+	}
+
+	return ops, nil
+}
+
+// FailInterruptedOperations marks the operations of the given member which weren't done yet as failed.
+func (c *ClusterTx) FailInterruptedOperations(nodeID int64) error {
+	interrupted, err := c.GetInterruptedOperations(nodeID)
+	if err != nil {
+		return err
+	}
+
+	for _, interruptedOp := range interrupted {
+		op := interruptedOp.Operation
+		op.Status = api.Failure.String()
+		op.StatusCode = api.Failure
+		op.Err = "Operation interrupted by LXD restart"
+		op.MayCancel = false
+		op.UpdatedAt = time.Now()
+
+		newState, err := json.Marshal(op)
+		if err != nil {
+			return err
+		}
+
+		_, err = c.tx.Exec("UPDATE operations_history SET status_code = ?, state = ?, updated_at = ? WHERE uuid = ?", op.StatusCode, string(newState), op.UpdatedAt, op.ID)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to mark operation %q as failed", op.ID)
+		}
+	}
+
+	return nil
+}
+
+// DeleteOperationsHistoryBefore deletes the recorded operations which are done and were last updated
+// before the given time.
+func (c *ClusterTx) DeleteOperationsHistoryBefore(t time.Time) error {
+	stmt := c.stmt(operationHistoryDeleteBefore)
+	_, err := stmt.Exec(t, api.Pending, api.Running, api.Cancelling)
+	if err != nil {
+		return errors.Wrap(err, "Failed to delete expired operations history")
+	}
+
+	return nil
+}
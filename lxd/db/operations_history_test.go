@@ -0,0 +1,51 @@
+//go:build linux && cgo && !agent
+// +build linux,cgo,!agent
+
+package db_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/shared/api"
+)
+
+// Record operations, then fail those which were interrupted.
+func TestInterruptedOperations(t *testing.T) {
+	tx, cleanup := db.NewTestClusterTx(t)
+	defer cleanup()
+
+	nodeID := tx.GetNodeID()
+
+	running := api.Operation{ID: "abcd", StatusCode: api.Running, Resources: map[string][]string{"backups": {"/1.0/backups/backup0"}}}
+	err := tx.UpsertOperationHistory("default", db.OperationBackupCreate, running)
+	require.NoError(t, err)
+
+	done := api.Operation{ID: "efgh", StatusCode: api.Success}
+	err = tx.UpsertOperationHistory("default", db.OperationImageDownload, done)
+	require.NoError(t, err)
+
+	interrupted, err := tx.GetInterruptedOperations(nodeID)
+	require.NoError(t, err)
+	require.Len(t, interrupted, 1)
+	assert.Equal(t, "abcd", interrupted[0].Operation.ID)
+	assert.Equal(t, "default", interrupted[0].ProjectName)
+	assert.Equal(t, db.OperationBackupCreate, interrupted[0].Type)
+	assert.Equal(t, running.Resources, interrupted[0].Operation.Resources)
+
+	err = tx.FailInterruptedOperations(nodeID)
+	require.NoError(t, err)
+
+	interrupted, err = tx.GetInterruptedOperations(nodeID)
+	require.NoError(t, err)
+	assert.Len(t, interrupted, 0)
+
+	op, projectName, err := tx.GetOperationHistory("abcd")
+	require.NoError(t, err)
+	assert.Equal(t, "default", projectName)
+	assert.Equal(t, api.Failure, op.StatusCode)
+	assert.Equal(t, "Operation interrupted by LXD restart", op.Err)
+}
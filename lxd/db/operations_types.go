@@ -65,6 +65,14 @@ const (
 	OperationVolumeSnapshotRename
 	OperationClusterMemberEvacuate
 	OperationClusterMemberRestore
+	OperationEventsExpire
+	OperationOperationsHistoryExpire
+	OperationCertificateAddToken
+	OperationCertificateRevocationListUpdate
+	OperationInstanceRebuild
+	OperationInstanceFlatten
+	OperationInstanceConvert
+	OperationProjectImport
 )
 
 // Description return a human-readable description of the operation type.
@@ -178,6 +186,22 @@ func (t OperationType) Description() string {
 		return "Evacuating cluster member"
 	case OperationClusterMemberRestore:
 		return "Restoring cluster member"
+	case OperationEventsExpire:
+		return "Cleaning up expired events"
+	case OperationOperationsHistoryExpire:
+		return "Cleaning up operations history"
+	case OperationCertificateAddToken:
+		return "Certificate add token"
+	case OperationCertificateRevocationListUpdate:
+		return "Updating certificate revocation list"
+	case OperationInstanceRebuild:
+		return "Rebuilding instance"
+	case OperationInstanceFlatten:
+		return "Flattening instance"
+	case OperationInstanceConvert:
+		return "Converting instance"
+	case OperationProjectImport:
+		return "Importing project"
 	default:
 		return "Executing operation"
 	}
@@ -233,6 +257,12 @@ func (t OperationType) Permission() string {
 		return "manage-containers"
 	case OperationSnapshotRestore:
 		return "manage-containers"
+	case OperationInstanceRebuild:
+		return "manage-containers"
+	case OperationInstanceFlatten:
+		return "manage-containers"
+	case OperationInstanceConvert:
+		return "manage-containers"
 
 	case OperationImageDownload:
 		return "manage-images"
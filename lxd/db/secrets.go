@@ -0,0 +1,150 @@
+//go:build linux && cgo && !agent
+// +build linux,cgo,!agent
+
+package db
+
+import (
+	"database/sql"
+
+	"github.com/pkg/errors"
+
+	"github.com/lxc/lxd/lxd/db/query"
+	"github.com/lxc/lxd/shared/api"
+)
+
+// Secret is a secret of a project, whose value can be referenced from config values.
+type Secret struct {
+	ID          int64
+	Project     string
+	Name        string
+	Description string
+
+	// Encrypted value of the secret.
+	Value string
+}
+
+// ToAPI converts the database Secret struct to an api.Secret entry, without its value.
+func (s *Secret) ToAPI() api.Secret {
+	resp := api.Secret{
+		Name: s.Name,
+	}
+
+	resp.Description = s.Description
+
+	return resp
+}
+
+// GetSecrets returns all the secrets of the given project.
+func (c *ClusterTx) GetSecrets(project string) ([]Secret, error) {
+	return c.getSecrets("WHERE projects.name = ?", project)
+}
+
+// GetAllSecrets returns the secrets of all projects.
+func (c *ClusterTx) GetAllSecrets() ([]Secret, error) {
+	return c.getSecrets("")
+}
+
+// GetSecret returns the secret of the given project with the given name.
+func (c *ClusterTx) GetSecret(project string, name string) (*Secret, error) {
+	secrets, err := c.getSecrets("WHERE projects.name = ? AND secrets.name = ?", project, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(secrets) == 0 {
+		return nil, ErrNoSuchObject
+	}
+
+	return &secrets[0], nil
+}
+
+func (c *ClusterTx) getSecrets(where string, args ...interface{}) ([]Secret, error) {
+	secrets := []Secret{}
+
+	stmt, err := c.tx.Prepare(`
+SELECT secrets.id, projects.name, secrets.name, secrets.description, secrets.value
+  FROM secrets
+  JOIN projects ON projects.id = secrets.project_id
+  ` + where + `
+  ORDER BY projects.name, secrets.name`)
+	if err != nil {
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	dest := func(i int) []interface{} {
+		secrets = append(secrets, Secret{})
+		return []interface{}{&secrets[i].ID, &secrets[i].Project, &secrets[i].Name, &secrets[i].Description, &secrets[i].Value}
+	}
+
+	err = query.SelectObjects(stmt, dest, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to fetch secrets")
+	}
+
+	return secrets, nil
+}
+
+// CreateSecret adds a new secret to the given project.
+func (c *ClusterTx) CreateSecret(project string, secret Secret) (int64, error) {
+	projectID, err := c.GetProjectID(project)
+	if err != nil {
+		return -1, err
+	}
+
+	result, err := c.tx.Exec("INSERT INTO secrets (project_id, name, description, value) VALUES (?, ?, ?, ?)",
+		projectID, secret.Name, secret.Description, secret.Value)
+	if err != nil {
+		return -1, errors.Wrapf(err, "Failed to create secret %q", secret.Name)
+	}
+
+	return result.LastInsertId()
+}
+
+// UpdateSecret updates the description and the encrypted value of a secret.
+func (c *ClusterTx) UpdateSecret(project string, name string, description string, value string) error {
+	result, err := c.tx.Exec(`
+UPDATE secrets SET description = ?, value = ?
+  WHERE project_id = (SELECT id FROM projects WHERE name = ?) AND name = ?`, description, value, project, name)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to update secret %q", name)
+	}
+
+	return secretRowsAffected(result)
+}
+
+// UpdateSecretValue updates the encrypted value of the secret with the given ID.
+func (c *ClusterTx) UpdateSecretValue(id int64, value string) error {
+	result, err := c.tx.Exec("UPDATE secrets SET value = ? WHERE id = ?", value, id)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to update secret %d", id)
+	}
+
+	return secretRowsAffected(result)
+}
+
+// DeleteSecret deletes the secret of the given project with the given name.
+func (c *ClusterTx) DeleteSecret(project string, name string) error {
+	result, err := c.tx.Exec(`
+DELETE FROM secrets
+  WHERE project_id = (SELECT id FROM projects WHERE name = ?) AND name = ?`, project, name)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to delete secret %q", name)
+	}
+
+	return secretRowsAffected(result)
+}
+
+func secretRowsAffected(result sql.Result) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return ErrNoSuchObject
+	}
+
+	return nil
+}
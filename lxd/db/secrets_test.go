@@ -0,0 +1,52 @@
+//go:build linux && cgo && !agent
+// +build linux,cgo,!agent
+
+package db_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lxc/lxd/lxd/db"
+)
+
+func TestSecrets(t *testing.T) {
+	tx, cleanup := db.NewTestClusterTx(t)
+	defer cleanup()
+
+	_, err := tx.CreateSecret("default", db.Secret{Name: "db-password", Value: "encrypted"})
+	require.NoError(t, err)
+
+	secret, err := tx.GetSecret("default", "db-password")
+	require.NoError(t, err)
+	assert.Equal(t, "default", secret.Project)
+	assert.Equal(t, "encrypted", secret.Value)
+
+	err = tx.UpdateSecret("default", "db-password", "Database password", "reencrypted")
+	require.NoError(t, err)
+
+	secrets, err := tx.GetSecrets("default")
+	require.NoError(t, err)
+	require.Len(t, secrets, 1)
+	assert.Equal(t, "Database password", secrets[0].Description)
+	assert.Equal(t, "reencrypted", secrets[0].Value)
+
+	err = tx.UpdateSecretValue(secrets[0].ID, "rotated")
+	require.NoError(t, err)
+
+	secrets, err = tx.GetAllSecrets()
+	require.NoError(t, err)
+	require.Len(t, secrets, 1)
+	assert.Equal(t, "rotated", secrets[0].Value)
+
+	err = tx.DeleteSecret("default", "db-password")
+	require.NoError(t, err)
+
+	_, err = tx.GetSecret("default", "db-password")
+	assert.Equal(t, db.ErrNoSuchObject, err)
+
+	err = tx.DeleteSecret("default", "db-password")
+	assert.Equal(t, db.ErrNoSuchObject, err)
+}
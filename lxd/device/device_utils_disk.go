@@ -15,6 +15,7 @@ import (
 	storageDrivers "github.com/lxc/lxd/lxd/storage/drivers"
 	"github.com/lxc/lxd/lxd/storage/filesystem"
 	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/idmap"
 	"github.com/lxc/lxd/shared/osarch"
 	"github.com/lxc/lxd/shared/subprocess"
 )
@@ -238,9 +239,11 @@ func diskCephfsOptions(clusterName string, userName string, fsName string, fsPat
 }
 
 // DiskVMVirtiofsdStart starts a new virtiofsd process.
+// If an idmap is supplied, the host IDs of the share are translated so the instance sees the IDs of the map's
+// namespace side.
 // Returns UnsupportedError error if the host system or instance does not support virtiosfd, returns normal error
 // type if process cannot be started for other reasons.
-func DiskVMVirtiofsdStart(inst instance.Instance, socketPath string, pidPath string, logPath string, sharePath string) error {
+func DiskVMVirtiofsdStart(inst instance.Instance, socketPath string, pidPath string, logPath string, sharePath string, idmapSet *idmap.IdmapSet) error {
 	revert := revert.New()
 	defer revert.Fail()
 
@@ -269,8 +272,30 @@ func DiskVMVirtiofsdStart(inst instance.Instance, socketPath string, pidPath str
 		return UnsupportedError{"Stateful migration unsupported"}
 	}
 
+	args := []string{fmt.Sprintf("--socket-path=%s", socketPath), "-o", fmt.Sprintf("source=%s", sharePath)}
+
+	// Translate the IDs through the user namespace virtiofsd sandboxes itself in.
+	if idmapSet != nil && len(idmapSet.Idmap) > 0 {
+		help, _ := exec.Command(cmd, "--help").CombinedOutput()
+		if !strings.Contains(string(help), "--uid-map") {
+			return UnsupportedError{"Idmapped shares unsupported by virtiofsd"}
+		}
+
+		for _, entry := range idmapSet.Idmap {
+			idMap := fmt.Sprintf(":%d:%d:%d:", entry.Nsid, entry.Hostid, entry.Maprange)
+
+			if entry.Isuid {
+				args = append(args, fmt.Sprintf("--uid-map=%s", idMap))
+			}
+
+			if entry.Isgid {
+				args = append(args, fmt.Sprintf("--gid-map=%s", idMap))
+			}
+		}
+	}
+
 	// Start the virtiofsd process in non-daemon mode.
-	proc, err := subprocess.NewProcess(cmd, []string{fmt.Sprintf("--socket-path=%s", socketPath), "-o", fmt.Sprintf("source=%s", sharePath)}, logPath, logPath)
+	proc, err := subprocess.NewProcess(cmd, args, logPath, logPath)
 	if err != nil {
 		return err
 	}
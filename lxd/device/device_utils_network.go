@@ -478,15 +478,24 @@ func networkSetupHostVethLimits(m deviceConfig.Device) error {
 		}
 	}
 
-	if m["limits.egress"] != "" {
+	if m["limits.egress"] != "" || m["limits.priority"] != "" {
 		qdisc = &ip.Qdisc{Dev: veth, Handle: "ffff:0", Ingress: true}
 		err := qdisc.Add()
 		if err != nil {
 			return fmt.Errorf("Failed to create ingress tc qdisc: %s", err)
 		}
 
-		police := &ip.ActionPolice{Rate: fmt.Sprintf("%dbit", egressInt), Burst: "1024k", Mtu: "64kb", Drop: true}
-		filter := &ip.U32Filter{Filter: ip.Filter{Dev: veth, Parent: "ffff:0", Protocol: "all"}, Value: "0", Mask: "0", Actions: []ip.Action{police}}
+		// Traffic leaving the instance enters the host side interface, so mark and police it there.
+		actions := []ip.Action{}
+		if m["limits.priority"] != "" {
+			actions = append(actions, &ip.ActionSkbEdit{Priority: m["limits.priority"]})
+		}
+
+		if m["limits.egress"] != "" {
+			actions = append(actions, &ip.ActionPolice{Rate: fmt.Sprintf("%dbit", egressInt), Burst: "1024k", Mtu: "64kb", Drop: true})
+		}
+
+		filter := &ip.U32Filter{Filter: ip.Filter{Dev: veth, Parent: "ffff:0", Protocol: "all"}, Value: "0", Mask: "0", Actions: actions}
 		err = filter.Add()
 		if err != nil {
 			return fmt.Errorf("Failed to create ingress tc filter: %s", err)
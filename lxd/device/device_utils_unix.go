@@ -46,6 +46,46 @@ func unixDeviceAttributes(path string) (string, uint32, uint32, error) {
 	return dType, major, minor, nil
 }
 
+// unixDeviceProperties returns the kernel and udev properties of the host device with the given type and
+// major and minor numbers.
+func unixDeviceProperties(dType string, major uint32, minor uint32) map[string]string {
+	properties := map[string]string{}
+
+	sysType := "char"
+	if dType == "b" {
+		sysType = "block"
+	}
+
+	sysPath := fmt.Sprintf("/sys/dev/%s/%d:%d", sysType, major, minor)
+	subsystem, err := os.Readlink(filepath.Join(sysPath, "subsystem"))
+	if err == nil {
+		properties["SUBSYSTEM"] = filepath.Base(subsystem)
+	}
+
+	readProperties := func(path string, prefix string) {
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return
+		}
+
+		for _, line := range strings.Split(string(content), "\n") {
+			if !strings.HasPrefix(line, prefix) {
+				continue
+			}
+
+			fields := strings.SplitN(strings.TrimPrefix(line, prefix), "=", 2)
+			if len(fields) == 2 {
+				properties[fields[0]] = fields[1]
+			}
+		}
+	}
+
+	readProperties(filepath.Join(sysPath, "uevent"), "")
+	readProperties(fmt.Sprintf("/run/udev/data/%s%d:%d", dType, major, minor), "E:")
+
+	return properties
+}
+
 // unixDeviceModeOct converts a string unix octal mode to an int.
 func unixDeviceModeOct(strmode string) (int, error) {
 	i, err := strconv.ParseInt(strmode, 8, 32)
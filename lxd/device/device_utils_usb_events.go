@@ -20,6 +20,7 @@ type USBEvent struct {
 
 	Vendor  string
 	Product string
+	Serial  string
 
 	Path        string
 	Major       uint32
@@ -98,7 +99,7 @@ func USBRunHandlers(state *state.State, event *USBEvent) {
 }
 
 // USBNewEvent instantiates a new USBEvent struct.
-func USBNewEvent(action string, vendor string, product string, major string, minor string, busnum string, devnum string, devname string, ueventParts []string, ueventLen int) (USBEvent, error) {
+func USBNewEvent(action string, vendor string, product string, serial string, major string, minor string, busnum string, devnum string, devname string, ueventParts []string, ueventLen int) (USBEvent, error) {
 	majorInt, err := strconv.ParseUint(major, 10, 32)
 	if err != nil {
 		return USBEvent{}, err
@@ -109,19 +110,18 @@ func USBNewEvent(action string, vendor string, product string, major string, min
 		return USBEvent{}, err
 	}
 
-	busnumInt := 0
-	devnumInt := 0
+	busnumInt, err := strconv.Atoi(busnum)
+	if err != nil {
+		return USBEvent{}, err
+	}
+
+	devnumInt, err := strconv.Atoi(devnum)
+	if err != nil {
+		return USBEvent{}, err
+	}
+
 	path := devname
 	if devname == "" {
-		busnumInt, err = strconv.Atoi(busnum)
-		if err != nil {
-			return USBEvent{}, err
-		}
-
-		devnumInt, err = strconv.Atoi(devnum)
-		if err != nil {
-			return USBEvent{}, err
-		}
 		path = fmt.Sprintf("/dev/bus/usb/%03d/%03d", busnumInt, devnumInt)
 	} else {
 		if !filepath.IsAbs(devname) {
@@ -133,6 +133,7 @@ func USBNewEvent(action string, vendor string, product string, major string, min
 		action,
 		vendor,
 		product,
+		serial,
 		path,
 		uint32(majorInt),
 		uint32(minorInt),
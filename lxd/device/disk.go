@@ -22,6 +22,8 @@ import (
 	"github.com/lxc/lxd/lxd/instance/instancetype"
 	"github.com/lxc/lxd/lxd/project"
 	"github.com/lxc/lxd/lxd/revert"
+	"github.com/lxc/lxd/lxd/secrets"
+	"github.com/lxc/lxd/lxd/state"
 	storagePools "github.com/lxc/lxd/lxd/storage"
 	storageDrivers "github.com/lxc/lxd/lxd/storage/drivers"
 	"github.com/lxc/lxd/lxd/storage/filesystem"
@@ -54,6 +56,26 @@ type disk struct {
 	deviceCommon
 }
 
+// CanHotPlug returns whether the device can be managed whilst the instance is running.
+// Returns true for containers. For virtual machines, only the disks which are attached as drives (block
+// volumes, block devices and image files) can be, as directory shares are mounted by the agent at boot.
+func (d *disk) CanHotPlug() bool {
+	if d.inst.Type() == instancetype.Container {
+		return true
+	}
+
+	if d.config["path"] == "/" || strings.HasPrefix(d.config["source"], "cephfs:") {
+		return false
+	}
+
+	if d.config["pool"] != "" {
+		// Custom filesystem volumes require a path, block volumes cannot have one.
+		return d.config["path"] == ""
+	}
+
+	return d.config["source"] == diskSourceCloudInit || !shared.IsDir(shared.HostPath(d.config["source"]))
+}
+
 // CanMigrate returns whether the device can be migrated to any other cluster member.
 func (d *disk) CanMigrate() bool {
 	// Root disk is always migratable.
@@ -478,7 +500,8 @@ func (d *disk) startContainer() (*deviceConfig.RunConfig, error) {
 		var poolVolSrcPath string
 		if d.config["pool"] != "" {
 			var err error
-			poolVolSrcPath, err = d.mountPoolVolume(revert)
+			var idmapped bool
+			poolVolSrcPath, idmapped, err = d.mountPoolVolume(revert)
 			if err != nil {
 				if !isRequired {
 					d.logger.Warn(err.Error())
@@ -487,6 +510,10 @@ func (d *disk) startContainer() (*deviceConfig.RunConfig, error) {
 
 				return nil, err
 			}
+
+			if idmapped {
+				ownerShift = deviceConfig.MountOwnerShiftDynamic
+			}
 		}
 
 		// Mount the source in the instance devices directory.
@@ -536,6 +563,11 @@ func (d *disk) startVM() (*deviceConfig.RunConfig, error) {
 	runConf := deviceConfig.RunConfig{}
 	isRequired := d.isRequired(d.config)
 
+	limits, err := d.generateVMLimits()
+	if err != nil {
+		return nil, err
+	}
+
 	if shared.IsRootDiskDevice(d.config) {
 		// Handle previous requests for setting new quotas.
 		err := d.applyDeferredQuota()
@@ -547,6 +579,7 @@ func (d *disk) startVM() (*deviceConfig.RunConfig, error) {
 			{
 				TargetPath: d.config["path"], // Indicator used that this is the root device.
 				DevName:    d.name,
+				Limits:     limits,
 			},
 		}
 
@@ -591,6 +624,7 @@ func (d *disk) startVM() (*deviceConfig.RunConfig, error) {
 				{
 					DevPath: fmt.Sprintf("rbd:%s/%s:%s", optEscaper.Replace(poolName), optEscaper.Replace(volumeName), strings.Join(opts, ":")),
 					DevName: d.name,
+					Limits:  limits,
 				},
 			}
 		} else {
@@ -601,7 +635,7 @@ func (d *disk) startVM() (*deviceConfig.RunConfig, error) {
 			// if the volume is a filesystem volume type (if it is a block volume the srcPath will
 			// be returned as the path to the block device).
 			if d.config["pool"] != "" {
-				srcPath, err = d.mountPoolVolume(revert)
+				srcPath, _, err = d.mountPoolVolume(revert)
 				if err != nil {
 					if !isRequired {
 						logger.Warn(err.Error())
@@ -616,6 +650,7 @@ func (d *disk) startVM() (*deviceConfig.RunConfig, error) {
 			mount := deviceConfig.MountEntryItem{
 				DevPath: srcPath,
 				DevName: d.name,
+				Limits:  limits,
 			}
 
 			readonly := shared.IsTrue(d.config["readonly"])
@@ -715,7 +750,12 @@ func (d *disk) startVM() (*deviceConfig.RunConfig, error) {
 					sockPath, pidPath := d.vmVirtiofsdPaths()
 					logPath := filepath.Join(d.inst.LogPath(), fmt.Sprintf("disk.%s.log", d.name))
 
-					err = DiskVMVirtiofsdStart(d.inst, sockPath, pidPath, logPath, srcPath)
+					idmapSet, err := d.vmShareIdmap()
+					if err != nil {
+						return err
+					}
+
+					err = DiskVMVirtiofsdStart(d.inst, sockPath, pidPath, logPath, srcPath, idmapSet)
 					if err != nil {
 						var errUnsupported UnsupportedError
 						if errors.As(err, &errUnsupported) {
@@ -785,10 +825,6 @@ func (d *disk) postStart() error {
 
 // Update applies configuration changes to a started device.
 func (d *disk) Update(oldDevices deviceConfig.Devices, isRunning bool) error {
-	if d.inst.Type() == instancetype.VM && !shared.IsRootDiskDevice(d.config) {
-		return fmt.Errorf("Non-root disks not supported for VMs")
-	}
-
 	if shared.IsRootDiskDevice(d.config) {
 		// Make sure we have a valid root disk device (and only one).
 		expandedDevices := d.inst.ExpandedDevices()
@@ -845,15 +881,35 @@ func (d *disk) Update(oldDevices deviceConfig.Devices, isRunning bool) error {
 		}
 	}
 
-	// Only apply IO limits if instance is container and is running.
-	if isRunning && d.inst.Type() == instancetype.Container {
+	// Only apply IO limits if instance is running.
+	if isRunning {
 		runConf := deviceConfig.RunConfig{}
-		err := d.generateLimits(&runConf)
-		if err != nil {
-			return err
+
+		if d.inst.Type() == instancetype.Container {
+			err := d.generateLimits(&runConf)
+			if err != nil {
+				return err
+			}
+		} else {
+			// Directory shares have no drive to throttle.
+			if !shared.IsRootDiskDevice(d.config) && !d.CanHotPlug() {
+				return nil
+			}
+
+			limits, err := d.generateVMLimits()
+			if err != nil {
+				return err
+			}
+
+			runConf.Mounts = []deviceConfig.MountEntryItem{
+				{
+					DevName: d.name,
+					Limits:  limits,
+				},
+			}
 		}
 
-		err = d.inst.DeviceEventHandler(&runConf)
+		err := d.inst.DeviceEventHandler(&runConf)
 		if err != nil {
 			return err
 		}
@@ -921,6 +977,28 @@ func (d *disk) applyQuota(unmount bool) error {
 	return nil
 }
 
+// generateVMLimits returns the I/O limits to apply to the drive of the disk when used by a VM.
+func (d *disk) generateVMLimits() (*deviceConfig.DiskLimits, error) {
+	readLimit := d.config["limits.read"]
+	writeLimit := d.config["limits.write"]
+	if d.config["limits.max"] != "" {
+		readLimit = d.config["limits.max"]
+		writeLimit = d.config["limits.max"]
+	}
+
+	readBps, readIops, writeBps, writeIops, err := d.parseDiskLimit(readLimit, writeLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &deviceConfig.DiskLimits{
+		ReadBytes:  readBps,
+		ReadIOps:   readIops,
+		WriteBytes: writeBps,
+		WriteIOps:  writeIops,
+	}, nil
+}
+
 // generateLimits adds a set of cgroup rules to apply specified limits to the supplied RunConfig.
 func (d *disk) generateLimits(runConf *deviceConfig.RunConfig) error {
 	// Disk throttle limits.
@@ -1003,7 +1081,8 @@ func (w *cgroupWriter) Set(version cgroup.Backend, controller string, key string
 
 // mountPoolVolume mounts the pool volume specified in d.config["source"] from pool specified in d.config["pool"]
 // and return the mount path. If the instance type is container volume will be shifted if needed.
-func (d *disk) mountPoolVolume(revert *revert.Reverter) (string, error) {
+// Returns whether the volume must be mapped to the container through an idmapped mount.
+func (d *disk) mountPoolVolume(revert *revert.Reverter) (string, bool, error) {
 	// Deal with mounting storage volumes created via the storage api. Extract the name of the storage volume
 	// that we are supposed to attach. We assume that the only syntactically valid ways of specifying a
 	// storage volume are:
@@ -1012,7 +1091,7 @@ func (d *disk) mountPoolVolume(revert *revert.Reverter) (string, error) {
 	// Currently, <type> must either be empty or "custom".
 	// We do not yet support instance mounts.
 	if filepath.IsAbs(d.config["source"]) {
-		return "", fmt.Errorf(`When the "pool" property is set "source" must specify the name of a volume, not a path`)
+		return "", false, fmt.Errorf(`When the "pool" property is set "source" must specify the name of a volume, not a path`)
 	}
 
 	volumeTypeName := ""
@@ -1030,7 +1109,7 @@ func (d *disk) mountPoolVolume(revert *revert.Reverter) (string, error) {
 	// Check volume type name is custom.
 	switch volumeTypeName {
 	case db.StoragePoolVolumeTypeNameContainer:
-		return "", fmt.Errorf("Using instance storage volumes is not supported")
+		return "", false, fmt.Errorf("Using instance storage volumes is not supported")
 	case "":
 		// We simply received the name of a storage volume.
 		volumeTypeName = db.StoragePoolVolumeTypeNameCustom
@@ -1038,15 +1117,15 @@ func (d *disk) mountPoolVolume(revert *revert.Reverter) (string, error) {
 	case db.StoragePoolVolumeTypeNameCustom:
 		break
 	case db.StoragePoolVolumeTypeNameImage:
-		return "", fmt.Errorf("Using image storage volumes is not supported")
+		return "", false, fmt.Errorf("Using image storage volumes is not supported")
 	default:
-		return "", fmt.Errorf("Unknown storage type prefix %q found", volumeTypeName)
+		return "", false, fmt.Errorf("Unknown storage type prefix %q found", volumeTypeName)
 	}
 
 	// Only custom volumes can be attached currently.
 	storageProjectName, err := project.StorageVolumeProject(d.state.Cluster, d.inst.Project(), db.StoragePoolVolumeTypeCustom)
 	if err != nil {
-		return "", err
+		return "", false, err
 	}
 
 	volStorageName := project.StorageVolume(storageProjectName, volumeName)
@@ -1054,39 +1133,100 @@ func (d *disk) mountPoolVolume(revert *revert.Reverter) (string, error) {
 
 	pool, err := storagePools.GetPoolByName(d.state, d.config["pool"])
 	if err != nil {
-		return "", err
+		return "", false, err
 	}
 
 	err = pool.MountCustomVolume(storageProjectName, volumeName, nil)
 	if err != nil {
-		return "", errors.Wrapf(err, "Failed mounting storage volume %q of type %q on storage pool %q", volumeName, volumeTypeName, pool.Name())
+		return "", false, errors.Wrapf(err, "Failed mounting storage volume %q of type %q on storage pool %q", volumeName, volumeTypeName, pool.Name())
 	}
 	revert.Add(func() { pool.UnmountCustomVolume(storageProjectName, volumeName, nil) })
 
 	_, vol, err := d.state.Cluster.GetLocalStoragePoolVolume(storageProjectName, volumeName, db.StoragePoolVolumeTypeCustom, pool.ID())
 	if err != nil {
-		return "", errors.Wrapf(err, "Failed to fetch local storage volume record")
+		return "", false, errors.Wrapf(err, "Failed to fetch local storage volume record")
 	}
 
+	idmapped := false
 	if d.inst.Type() == instancetype.Container {
 		if vol.ContentType == db.StoragePoolVolumeContentTypeNameFS {
+			idmapped = d.storagePoolVolumeIdmapped(vol.Config, srcPath)
+		}
+
+		if idmapped {
+			d.logger.Debug("Using an idmapped mount for storage volume", log.Ctx{"volume": volumeName})
+		} else if vol.ContentType == db.StoragePoolVolumeContentTypeNameFS {
 			err = d.storagePoolVolumeAttachShift(storageProjectName, pool.Name(), volumeName, db.StoragePoolVolumeTypeCustom, srcPath)
 			if err != nil {
-				return "", errors.Wrapf(err, "Failed shifting storage volume %q of type %q on storage pool %q", volumeName, volumeTypeName, pool.Name())
+				return "", false, errors.Wrapf(err, "Failed shifting storage volume %q of type %q on storage pool %q", volumeName, volumeTypeName, pool.Name())
 			}
 		} else {
-			return "", fmt.Errorf("Only filesystem volumes are supported for containers")
+			return "", false, fmt.Errorf("Only filesystem volumes are supported for containers")
 		}
 	}
 
 	if vol.ContentType == db.StoragePoolVolumeContentTypeNameBlock {
 		srcPath, err = pool.GetCustomVolumeDisk(storageProjectName, volumeName)
 		if err != nil {
-			return "", errors.Wrapf(err, "Failed to get disk path")
+			return "", false, errors.Wrapf(err, "Failed to get disk path")
 		}
 	}
 
-	return srcPath, nil
+	return srcPath, idmapped, nil
+}
+
+// storagePoolVolumeIdmapped returns whether a custom filesystem volume can be mapped to the unprivileged
+// container through an idmapped mount rather than having its files shifted on disk. Volumes which were
+// already shifted on disk keep being shifted.
+func (d *disk) storagePoolVolumeIdmapped(volConfig map[string]string, srcPath string) bool {
+	if shared.IsTrue(volConfig["security.unmapped"]) || shared.IsTrue(volConfig["security.shifted"]) {
+		return false
+	}
+
+	if !shared.StringInSlice(volConfig["volatile.idmap.last"], []string{"", "[]"}) {
+		return false
+	}
+
+	c := d.inst.(instance.Container)
+	if c.IsPrivileged() {
+		return false
+	}
+
+	return c.IdmappedStorage(srcPath) == idmap.IdmapStorageIdmapped
+}
+
+// vmShareIdmap returns the idmap a custom volume shared with a VM was shifted to for use by containers, if
+// any, so the VM can see the unshifted IDs.
+func (d *disk) vmShareIdmap() (*idmap.IdmapSet, error) {
+	if d.config["pool"] == "" {
+		return nil, nil
+	}
+
+	storageProjectName, err := project.StorageVolumeProject(d.state.Cluster, d.inst.Project(), db.StoragePoolVolumeTypeCustom)
+	if err != nil {
+		return nil, err
+	}
+
+	poolID, err := d.state.Cluster.GetStoragePoolID(d.config["pool"])
+	if err != nil {
+		return nil, err
+	}
+
+	volumeName := d.config["source"]
+	if strings.HasPrefix(volumeName, fmt.Sprintf("%s/", db.StoragePoolVolumeTypeNameCustom)) {
+		volumeName = strings.TrimPrefix(volumeName, fmt.Sprintf("%s/", db.StoragePoolVolumeTypeNameCustom))
+	}
+
+	_, vol, err := d.state.Cluster.GetLocalStoragePoolVolume(storageProjectName, volumeName, db.StoragePoolVolumeTypeCustom, poolID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to fetch local storage volume record")
+	}
+
+	if vol.Config["volatile.idmap.last"] == "" {
+		return nil, nil
+	}
+
+	return idmap.JSONUnmarshal(vol.Config["volatile.idmap.last"])
 }
 
 // createDevice creates a disk device mount on host.
@@ -1829,20 +1969,39 @@ func (d *disk) getParentBlocks(path string) ([]string, error) {
 // Returns the path to the ISO.
 func (d *disk) generateVMConfigDrive() (string, error) {
 	scratchDir := filepath.Join(d.inst.DevicesPath(), storageDrivers.PathNameEncode(d.name))
+	isoPath := filepath.Join(d.inst.Path(), "config.iso")
+
+	err := DiskVMCloudInitSeed(d.state, d.inst, scratchDir, isoPath)
+	if err != nil {
+		return "", err
+	}
+
+	return isoPath, nil
+}
 
+// DiskVMCloudInitSeed generates a cloud-init NoCloud seed ISO at isoPath from the cloud-init keys of the
+// instance, using scratchDir to prepare its content.
+func DiskVMCloudInitSeed(s *state.State, inst instance.Instance, scratchDir string, isoPath string) error {
 	// Check we have the mkisofs tool available.
 	mkisofsPath, err := exec.LookPath("mkisofs")
 	if err != nil {
-		return "", err
+		return err
 	}
 
 	// Create config drive dir.
 	err = os.MkdirAll(scratchDir, 0100)
 	if err != nil {
-		return "", err
+		return err
 	}
+	defer os.RemoveAll(scratchDir)
 
-	instanceConfig := d.inst.ExpandedConfig()
+	// Expand the references to secrets in the cloud-init keys.
+	instanceConfig, err := secrets.ExpandConfig(s, inst.Project(), inst.ExpandedConfig(), func(key string) bool {
+		return shared.StringInSlice(key, []string{"user.user-data", "user.vendor-data", "user.network-config", "user.meta-data"})
+	})
+	if err != nil {
+		return err
+	}
 
 	// Use an empty vendor-data file if no custom vendor-data supplied.
 	vendorData := instanceConfig["user.vendor-data"]
@@ -1852,7 +2011,7 @@ func (d *disk) generateVMConfigDrive() (string, error) {
 
 	err = ioutil.WriteFile(filepath.Join(scratchDir, "vendor-data"), []byte(vendorData), 0400)
 	if err != nil {
-		return "", err
+		return err
 	}
 
 	// Use an empty user-data file if no custom user-data supplied.
@@ -1863,7 +2022,7 @@ func (d *disk) generateVMConfigDrive() (string, error) {
 
 	err = ioutil.WriteFile(filepath.Join(scratchDir, "user-data"), []byte(userData), 0400)
 	if err != nil {
-		return "", err
+		return err
 	}
 
 	// Include a network-config file if the user configured it.
@@ -1871,7 +2030,7 @@ func (d *disk) generateVMConfigDrive() (string, error) {
 	if networkConfig != "" {
 		err = ioutil.WriteFile(filepath.Join(scratchDir, "network-config"), []byte(networkConfig), 0400)
 		if err != nil {
-			return "", err
+			return err
 		}
 	}
 
@@ -1879,27 +2038,23 @@ func (d *disk) generateVMConfigDrive() (string, error) {
 	metaData := fmt.Sprintf(`instance-id: %s
 local-hostname: %s
 %s
-`, d.inst.Name(), d.inst.Name(), instanceConfig["user.meta-data"])
+`, inst.Name(), inst.Name(), instanceConfig["user.meta-data"])
 
 	err = ioutil.WriteFile(filepath.Join(scratchDir, "meta-data"), []byte(metaData), 0400)
 	if err != nil {
-		return "", err
+		return err
 	}
 
 	// Finally convert the config drive dir into an ISO file. The cidata label is important
 	// as this is what cloud-init uses to detect, mount the drive and run the cloud-init
 	// templates on first boot. The vendor-data template then modifies the system so that the
 	// config drive is mounted and the agent is started on subsequent boots.
-	isoPath := filepath.Join(d.inst.Path(), "config.iso")
 	_, err = shared.RunCommand(mkisofsPath, "-J", "-R", "-V", "cidata", "-o", isoPath, scratchDir)
 	if err != nil {
-		return "", err
+		return err
 	}
 
-	// Remove the config drive folder.
-	os.RemoveAll(scratchDir)
-
-	return isoPath, nil
+	return nil
 }
 
 // cephCreds returns cluster name and user name to use for ceph disks.
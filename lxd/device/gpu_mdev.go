@@ -14,6 +14,7 @@ import (
 	"github.com/lxc/lxd/lxd/instance"
 	"github.com/lxc/lxd/lxd/instance/instancetype"
 	"github.com/lxc/lxd/lxd/resources"
+	"github.com/lxc/lxd/lxd/revert"
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/logger"
 )
@@ -41,10 +42,13 @@ func (d *gpuMdev) Stop() (*deviceConfig.RunConfig, error) {
 	return &runConf, nil
 }
 
-// startVM detects the requested GPU devices and related virtual functions and rebinds them to the vfio-pci driver.
+// startVM detects the requested GPU devices and related virtual functions and creates the virtual GPU on them.
 func (d *gpuMdev) startVM() (*deviceConfig.RunConfig, error) {
 	runConf := deviceConfig.RunConfig{}
 
+	revert := revert.New()
+	defer revert.Fail()
+
 	// Get any existing UUID.
 	v := d.volatileGet()
 	mdevUUID := v["vgpu.uuid"]
@@ -55,7 +59,9 @@ func (d *gpuMdev) startVM() (*deviceConfig.RunConfig, error) {
 		return nil, err
 	}
 
-	var pciAddress string
+	// Look for the first matching GPU (or virtual function) with an available mdev of the requested profile.
+	pciAddress := ""
+	mdevFound := false
 	for _, gpu := range gpus.Cards {
 		// Skip any cards that don't match the vendorid, pci, productid or DRM ID settings (if specified).
 		if (d.config["vendorid"] != "" && gpu.VendorID != d.config["vendorid"]) ||
@@ -65,73 +71,66 @@ func (d *gpuMdev) startVM() (*deviceConfig.RunConfig, error) {
 			continue
 		}
 
-		if pciAddress != "" {
-			return nil, fmt.Errorf("VMs cannot match multiple GPUs per device")
+		// Re-use the GPU holding the existing vGPU if still present.
+		if mdevUUID != "" && shared.PathExists(fmt.Sprintf("/sys/bus/pci/devices/%s/%s", gpu.PCIAddress, mdevUUID)) {
+			pciAddress = gpu.PCIAddress
+			mdevFound = true
+			break
 		}
 
-		pciAddress = gpu.PCIAddress
-
 		// Look for the requested mdev profile on the GPU itself.
-		mdevFound := false
-		mdevAvailable := false
-		for k, v := range gpu.Mdev {
-			if d.config["mdev"] == k {
-				mdevFound = true
-				if v.Available > 0 {
-					mdevAvailable = true
-				}
+		mdev, ok := gpu.Mdev[d.config["mdev"]]
+		if ok {
+			mdevFound = true
+			if mdev.Available > 0 {
+				pciAddress = gpu.PCIAddress
 				break
 			}
 		}
 
 		// If no mdev found on the GPU and SR-IOV is present, look on the VFs.
-		if !mdevFound && gpu.SRIOV != nil {
+		if !ok && gpu.SRIOV != nil {
 			for _, vf := range gpu.SRIOV.VFs {
-				for k, v := range vf.Mdev {
-					if d.config["mdev"] == k {
-						mdevFound = true
-						if v.Available > 0 {
-							mdevAvailable = true
-
-							// Replace the PCI address with that of the VF.
-							pciAddress = vf.PCIAddress
-						}
-
-						break
-					}
+				mdev, ok := vf.Mdev[d.config["mdev"]]
+				if !ok {
+					continue
 				}
 
-				if mdevAvailable {
+				mdevFound = true
+				if mdev.Available > 0 {
+					pciAddress = vf.PCIAddress
 					break
 				}
 			}
-		}
 
-		if !mdevFound {
-			return nil, fmt.Errorf("Invalid mdev profile %q", d.config["mdev"])
+			if pciAddress != "" {
+				break
+			}
 		}
+	}
 
-		if !mdevAvailable {
-			return nil, fmt.Errorf("No available mdev for profile %q", d.config["mdev"])
-		}
+	if !mdevFound {
+		return nil, fmt.Errorf("Invalid mdev profile %q", d.config["mdev"])
+	}
 
-		// Create the vGPU.
-		if mdevUUID == "" || !shared.PathExists(fmt.Sprintf("/sys/bus/pci/devices/%s/%s", pciAddress, mdevUUID)) {
-			mdevUUID = uuid.New()
+	if pciAddress == "" {
+		return nil, fmt.Errorf("No available mdev for profile %q", d.config["mdev"])
+	}
 
-			err = ioutil.WriteFile(filepath.Join(fmt.Sprintf("/sys/bus/pci/devices/%s/mdev_supported_types/%s/create", pciAddress, d.config["mdev"])), []byte(mdevUUID), 200)
-			if err != nil {
-				if os.IsNotExist(err) {
-					return nil, fmt.Errorf("The requested profile %q does not exist", d.config["mdev"])
-				}
+	// Create the vGPU.
+	if mdevUUID == "" || !shared.PathExists(fmt.Sprintf("/sys/bus/pci/devices/%s/%s", pciAddress, mdevUUID)) {
+		mdevUUID = uuid.New()
 
-				return nil, errors.Wrapf(err, "Failed to create virtual gpu %q", mdevUUID)
+		err = ioutil.WriteFile(filepath.Join(fmt.Sprintf("/sys/bus/pci/devices/%s/mdev_supported_types/%s/create", pciAddress, d.config["mdev"])), []byte(mdevUUID), 0200)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil, fmt.Errorf("The requested profile %q does not exist", d.config["mdev"])
 			}
+
+			return nil, errors.Wrapf(err, "Failed to create virtual gpu %q", mdevUUID)
 		}
-	}
 
-	if pciAddress == "" {
-		return nil, fmt.Errorf("Failed to detect requested GPU device")
+		revert.Add(func() { gpuMdevRemove(mdevUUID) })
 	}
 
 	// Get PCI information about the GPU device.
@@ -159,6 +158,7 @@ func (d *gpuMdev) startVM() (*deviceConfig.RunConfig, error) {
 		return nil, err
 	}
 
+	revert.Success()
 	return &runConf, nil
 }
 
@@ -173,19 +173,25 @@ func (d *gpuMdev) postStop() error {
 	v := d.volatileGet()
 
 	if v["vgpu.uuid"] != "" {
-		path := fmt.Sprintf("/sys/bus/mdev/devices/%s", v["vgpu.uuid"])
-
-		if shared.PathExists(path) {
-			err := ioutil.WriteFile(filepath.Join(path, "remove"), []byte("1\n"), 0200)
-			if err != nil {
-				logger.Debugf("Failed to remove vgpu %q", v["vgpu.uuid"])
-			}
+		err := gpuMdevRemove(v["vgpu.uuid"])
+		if err != nil {
+			logger.Debugf("Failed to remove vgpu %q", v["vgpu.uuid"])
 		}
 	}
 
 	return nil
 }
 
+// gpuMdevRemove removes the virtual GPU with the given UUID if it exists.
+func gpuMdevRemove(mdevUUID string) error {
+	path := fmt.Sprintf("/sys/bus/mdev/devices/%s", mdevUUID)
+	if !shared.PathExists(path) {
+		return nil
+	}
+
+	return ioutil.WriteFile(filepath.Join(path, "remove"), []byte("1\n"), 0200)
+}
+
 // validateConfig checks the supplied config for correctness.
 func (d *gpuMdev) validateConfig(instConf instance.ConfigReader) error {
 	if !instanceSupported(instConf.Type(), instancetype.VM) {
@@ -71,6 +71,7 @@ func (d *nicBridged) validateConfig(instConf instance.ConfigReader) error {
 		"limits.ingress",
 		"limits.egress",
 		"limits.max",
+		"limits.priority",
 		"ipv4.address",
 		"ipv6.address",
 		"ipv4.routes",
@@ -418,7 +419,7 @@ func (d *nicBridged) UpdatableFields(oldDevice Type) []string {
 		return []string{}
 	}
 
-	return []string{"limits.ingress", "limits.egress", "limits.max", "ipv4.routes", "ipv6.routes", "ipv4.routes.external", "ipv6.routes.external", "ipv4.address", "ipv6.address", "security.mac_filtering", "security.ipv4_filtering", "security.ipv6_filtering"}
+	return []string{"limits.ingress", "limits.egress", "limits.max", "limits.priority", "ipv4.routes", "ipv6.routes", "ipv4.routes.external", "ipv6.routes.external", "ipv4.address", "ipv6.address", "security.mac_filtering", "security.ipv4_filtering", "security.ipv6_filtering"}
 }
 
 // Add is run when a device is added to a non-snapshot instance whether or not the instance is running.
@@ -445,6 +446,15 @@ func (d *nicBridged) Start() (*deviceConfig.RunConfig, error) {
 	saveData := make(map[string]string)
 	saveData["host_name"] = d.config["host_name"]
 
+	// If no MTU is set and the parent is a managed network, use the bridge's MTU so that instances
+	// connected to networks with a reduced MTU (such as those using the fan or tunnels) match it.
+	if d.config["mtu"] == "" && d.network != nil {
+		mtu, err := network.GetDevMTU(d.config["parent"])
+		if err == nil {
+			d.config["mtu"] = fmt.Sprintf("%d", mtu)
+		}
+	}
+
 	var peerName string
 
 	// Create veth pair and configure the peer end with custom hwaddr and mtu if supplied.
@@ -55,7 +55,7 @@ func (d *nicOVN) UpdatableFields(oldDevice Type) []string {
 		return []string{}
 	}
 
-	return []string{"security.acls"}
+	return []string{"security.acls", "limits.ingress", "limits.egress", "limits.max", "limits.priority"}
 }
 
 // getIntegrationBridgeName returns the OVS integration bridge to use.
@@ -90,6 +90,10 @@ func (d *nicOVN) validateConfig(instConf instance.ConfigReader) error {
 		"ipv4.routes.external",
 		"ipv6.routes.external",
 		"boot.priority",
+		"limits.ingress",
+		"limits.egress",
+		"limits.max",
+		"limits.priority",
 		"security.acls",
 		"security.acls.default.ingress.action",
 		"security.acls.default.egress.action",
@@ -406,6 +410,14 @@ func (d *nicOVN) Update(oldDevices deviceConfig.Devices, isRunning bool) error {
 		}
 	}
 
+	// Apply host-side limits.
+	if isRunning {
+		err := networkSetupHostVethLimits(d.config)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Apply any changes needed when assigned ACLs change.
 	if d.config["security.acls"] != oldConfig["security.acls"] {
 		// Work out which ACLs have been removed and remove logical port from those groups.
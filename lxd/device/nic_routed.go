@@ -2,6 +2,7 @@ package device
 
 import (
 	"fmt"
+	"net"
 	"os"
 	"strings"
 
@@ -37,7 +38,7 @@ func (d *nicRouted) UpdatableFields(oldDevice Type) []string {
 		return []string{}
 	}
 
-	return []string{"limits.ingress", "limits.egress", "limits.max"}
+	return []string{"limits.ingress", "limits.egress", "limits.max", "limits.priority"}
 }
 
 // validateConfig checks the supplied config for correctness.
@@ -62,19 +63,24 @@ func (d *nicRouted) validateConfig(instConf instance.ConfigReader) error {
 		"limits.ingress",
 		"limits.egress",
 		"limits.max",
+		"limits.priority",
 		"ipv4.gateway",
 		"ipv6.gateway",
 		"ipv4.host_address",
 		"ipv6.host_address",
 		"ipv4.host_table",
 		"ipv6.host_table",
+		"ipv4.routes",
+		"ipv6.routes",
 		"gvrp",
+		"bgp.advertise",
 	}
 
 	rules := nicValidationRules(requiredFields, optionalFields, instConf)
 	rules["ipv4.address"] = validate.Optional(validate.IsNetworkAddressV4List)
 	rules["ipv6.address"] = validate.Optional(validate.IsNetworkAddressV6List)
 	rules["gvrp"] = validate.Optional(validate.IsBool)
+	rules["bgp.advertise"] = validate.Optional(validate.IsBool)
 
 	err = d.config.Validate(rules)
 	if err != nil {
@@ -97,6 +103,13 @@ func (d *nicRouted) validateConfig(instConf instance.ConfigReader) error {
 		}
 	}
 
+	// Host routes are installed via the instance's first address so require one of the same family.
+	for _, keyPrefix := range []string{"ipv4", "ipv6"} {
+		if d.config[fmt.Sprintf("%s.routes", keyPrefix)] != "" && d.config[fmt.Sprintf("%s.address", keyPrefix)] == "" {
+			return fmt.Errorf("%s.routes requires %s.address to be set", keyPrefix, keyPrefix)
+		}
+	}
+
 	return nil
 }
 
@@ -411,6 +424,11 @@ func (d *nicRouted) postStart() error {
 				}
 			}
 		}
+
+		err = d.addHostRoutes(ip.FamilyV4, d.config["ipv4.address"], d.config["ipv4.routes"], d.config["ipv4.host_table"])
+		if err != nil {
+			return err
+		}
 	}
 
 	if d.config["ipv6.address"] != "" {
@@ -446,11 +464,63 @@ func (d *nicRouted) postStart() error {
 				}
 			}
 		}
+
+		err = d.addHostRoutes(ip.FamilyV6, d.config["ipv6.address"], d.config["ipv6.routes"], d.config["ipv6.host_table"])
+		if err != nil {
+			return err
+		}
+	}
+
+	if shared.IsTrue(d.config["bgp.advertise"]) {
+		err = d.bgpAdvertise()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// bgpAdvertise adds the instance's addresses (as /32 and /128 prefixes) and routes to the BGP server using
+// the host as the next-hop.
+func (d *nicRouted) bgpAdvertise() error {
+	prefixes := []string{}
+	for _, addr := range util.SplitNTrimSpace(d.config["ipv4.address"], ",", -1, true) {
+		prefixes = append(prefixes, fmt.Sprintf("%s/32", addr))
+	}
+
+	for _, addr := range util.SplitNTrimSpace(d.config["ipv6.address"], ",", -1, true) {
+		prefixes = append(prefixes, fmt.Sprintf("%s/128", addr))
+	}
+
+	prefixes = append(prefixes, util.SplitNTrimSpace(d.config["ipv4.routes"], ",", -1, true)...)
+	prefixes = append(prefixes, util.SplitNTrimSpace(d.config["ipv6.routes"], ",", -1, true)...)
+
+	for _, prefix := range prefixes {
+		_, prefixNet, err := net.ParseCIDR(prefix)
+		if err != nil {
+			return err
+		}
+
+		nexthop := net.IPv4zero
+		if prefixNet.IP.To4() == nil {
+			nexthop = net.IPv6zero
+		}
+
+		err = d.state.BGP.AddPrefix(*prefixNet, nexthop, d.bgpOwner())
+		if err != nil {
+			return errors.Wrapf(err, "Failed advertising prefix %q", prefix)
+		}
 	}
 
 	return nil
 }
 
+// bgpOwner returns the owner name used for the BGP prefixes of this device.
+func (d *nicRouted) bgpOwner() string {
+	return fmt.Sprintf("instance_%d_%s", d.inst.ID(), d.name)
+}
+
 // Stop is run when the device is removed from the instance.
 func (d *nicRouted) Stop() (*deviceConfig.RunConfig, error) {
 	runConf := deviceConfig.RunConfig{
@@ -510,6 +580,12 @@ func (d *nicRouted) postStop() error {
 		errs = append(errs, err)
 	}
 
+	// Withdraw any BGP advertised prefixes.
+	err = d.state.BGP.RemovePrefixByOwner(d.bgpOwner())
+	if err != nil {
+		errs = append(errs, err)
+	}
+
 	if len(errs) > 0 {
 		return fmt.Errorf("%v", errs)
 	}
@@ -517,6 +593,41 @@ func (d *nicRouted) postStop() error {
 	return nil
 }
 
+// addHostRoutes adds the static routes for the instance to the host, using the instance's first address of the
+// family as the next-hop. Routes are added to the main routing table and to the custom host table if specified.
+// The routes are removed automatically when the host-side veth interface is removed.
+func (d *nicRouted) addHostRoutes(family string, addresses string, routes string, hostTable string) error {
+	if routes == "" {
+		return nil
+	}
+
+	via := strings.TrimSpace(strings.Split(addresses, ",")[0])
+
+	tables := []string{""}
+	if hostTable != "" {
+		tables = append(tables, hostTable)
+	}
+
+	for _, route := range util.SplitNTrimSpace(routes, ",", -1, true) {
+		for _, table := range tables {
+			r := &ip.Route{
+				DevName: d.config["host_name"],
+				Route:   route,
+				Table:   table,
+				Family:  family,
+				Via:     via,
+			}
+
+			err := r.Add()
+			if err != nil {
+				return errors.Wrapf(err, "Failed adding host route %q", route)
+			}
+		}
+	}
+
+	return nil
+}
+
 func (d *nicRouted) ipv4HostAddress() string {
 	if d.config["ipv4.host_address"] != "" {
 		return d.config["ipv4.host_address"]
@@ -17,6 +17,7 @@ import (
 	"github.com/lxc/lxd/lxd/util"
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/units"
 )
 
 type nicSRIOV struct {
@@ -33,6 +34,17 @@ func (d *nicSRIOV) CanMigrate() bool {
 	return d.config["network"] != ""
 }
 
+// UpdatableFields returns a list of fields that can be updated without triggering a device remove & add.
+func (d *nicSRIOV) UpdatableFields(oldDevice Type) []string {
+	// Check old and new device types match.
+	_, match := oldDevice.(*nicSRIOV)
+	if !match {
+		return []string{}
+	}
+
+	return []string{"limits.egress"}
+}
+
 // validateConfig checks the supplied config for correctness.
 func (d *nicSRIOV) validateConfig(instConf instance.ConfigReader) error {
 	if !instanceSupported(instConf.Type(), instancetype.Container, instancetype.VM) {
@@ -46,6 +58,7 @@ func (d *nicSRIOV) validateConfig(instConf instance.ConfigReader) error {
 		"parent",
 		"hwaddr",
 		"vlan",
+		"limits.egress",
 		"security.mac_filtering",
 		"maas.subnet.ipv4",
 		"maas.subnet.ipv6",
@@ -333,6 +346,14 @@ func (d *nicSRIOV) setupSriovParent(vfDevice string, vfID int, volatile map[stri
 		}
 	}
 
+	// Setup VF transmit rate limit if specified.
+	if d.config["limits.egress"] != "" {
+		err = d.setupSriovLimits(volatile["last_state.vf.id"])
+		if err != nil {
+			return vfPCIDev, 0, err
+		}
+	}
+
 	// Setup VF MAC spoofing protection if specified.
 	// The ordering of this section is very important, as Intel cards require a very specific
 	// order of setup to allow LXD to set custom MACs when using spoof check mode.
@@ -424,6 +445,41 @@ func (d *nicSRIOV) setupSriovParent(vfDevice string, vfID int, volatile map[stri
 	return vfPCIDev, pciIOMMUGroup, nil
 }
 
+// Update applies configuration changes to a started device.
+func (d *nicSRIOV) Update(oldDevices deviceConfig.Devices, isRunning bool) error {
+	if !isRunning {
+		return nil
+	}
+
+	return d.setupSriovLimits(d.volatileGet()["last_state.vf.id"])
+}
+
+// setupSriovLimits applies the transmit rate limit (traffic leaving the instance) to the VF on the parent device.
+func (d *nicSRIOV) setupSriovLimits(vfID string) error {
+	if vfID == "" {
+		return nil
+	}
+
+	// VF rate limits are set in Mbps (with 0 meaning unlimited), round up so small limits are still applied.
+	rate := int64(0)
+	if d.config["limits.egress"] != "" {
+		egress, err := units.ParseBitSizeString(d.config["limits.egress"])
+		if err != nil {
+			return err
+		}
+
+		rate = (egress + 999999) / 1000000
+	}
+
+	link := &ip.Link{Name: d.config["parent"]}
+	err := link.SetVfRate(vfID, fmt.Sprintf("%d", rate))
+	if err != nil {
+		return errors.Wrapf(err, "Failed setting VF transmit rate")
+	}
+
+	return nil
+}
+
 // networkGetVirtFuncInfo returns info about an SR-IOV virtual function from the ip tool.
 func (d *nicSRIOV) networkGetVirtFuncInfo(devName string, vfID int) (ip.VirtFuncInfo, error) {
 	link := &ip.Link{Name: devName}
@@ -476,6 +532,15 @@ func (d *nicSRIOV) restoreSriovParent(volatile map[string]string) error {
 		}
 	}
 
+	// Reset VF transmit rate limit if specified.
+	if d.config["limits.egress"] != "" {
+		link := &ip.Link{Name: d.config["parent"]}
+		err := link.SetVfRate(volatile["last_state.vf.id"], "0")
+		if err != nil {
+			return err
+		}
+	}
+
 	// Reset VF MAC spoofing protection if recorded. Do this first before resetting the MAC
 	// to avoid any issues with zero MACs refusing to be set whilst spoof check is on.
 	if volatile["last_state.vf.spoofcheck"] != "" {
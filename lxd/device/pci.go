@@ -2,7 +2,9 @@ package device
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/pkg/errors"
 
@@ -10,6 +12,7 @@ import (
 	pcidev "github.com/lxc/lxd/lxd/device/pci"
 	"github.com/lxc/lxd/lxd/instance"
 	"github.com/lxc/lxd/lxd/instance/instancetype"
+	"github.com/lxc/lxd/lxd/project"
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/validate"
 )
@@ -20,7 +23,7 @@ type pci struct {
 
 // validateConfig checks the supplied config for correctness.
 func (d *pci) validateConfig(instConf instance.ConfigReader) error {
-	if !instanceSupported(instConf.Type(), instancetype.VM) {
+	if !instanceSupported(instConf.Type(), instancetype.Container, instancetype.VM) {
 		return ErrUnsupportedDevType
 	}
 
@@ -44,7 +47,85 @@ func (d *pci) validateEnvironment() error {
 		return fmt.Errorf("PCI devices cannot be used when migration.stateful is enabled")
 	}
 
-	return validatePCIDevice(d.config["address"])
+	err := validatePCIDevice(d.config["address"])
+	if err != nil {
+		return err
+	}
+
+	return d.validateIOMMUGroup()
+}
+
+// validateIOMMUGroup checks that the PCI device can be passed through VFIO. VFIO hands over whole IOMMU
+// groups, so the other devices of the group must either be passed to the instance too, or be neither in use
+// by the host nor passed to another running instance.
+func (d *pci) validateIOMMUGroup() error {
+	groupDevices, err := pcidev.DeviceIOMMUGroupDevices(d.config["address"])
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("PCI device %q isn't in an IOMMU group, check that the IOMMU is enabled", d.config["address"])
+		}
+
+		return errors.Wrapf(err, "Failed to get IOMMU group of PCI device %q", d.config["address"])
+	}
+
+	// Get the PCI devices passed to the instance.
+	instanceAddresses := []string{}
+	for _, dev := range d.inst.ExpandedDevices() {
+		if dev["type"] == "pci" {
+			instanceAddresses = append(instanceAddresses, pcidev.NormaliseAddress(dev["address"]))
+		}
+	}
+
+	// Get the PCI devices passed to the other running instances.
+	instances, err := instance.LoadNodeAll(d.state, instancetype.Any)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to load instances")
+	}
+
+	usedBy := map[string]string{}
+	for _, inst := range instances {
+		if (inst.Name() == d.inst.Name() && inst.Project() == d.inst.Project()) || !inst.IsRunning() {
+			continue
+		}
+
+		for _, dev := range inst.ExpandedDevices() {
+			if dev["type"] == "pci" {
+				usedBy[pcidev.NormaliseAddress(dev["address"])] = project.Instance(inst.Project(), inst.Name())
+			}
+		}
+	}
+
+	return pciValidateIOMMUGroup(d.config["address"], groupDevices, instanceAddresses, usedBy)
+}
+
+// pciValidateIOMMUGroup checks the devices of the IOMMU group of a PCI device against the addresses of the
+// PCI devices passed to the instance and the ones passed to other instances (usedBy maps them to the
+// instance using them).
+func pciValidateIOMMUGroup(address string, groupDevices []pcidev.Device, instanceAddresses []string, usedBy map[string]string) error {
+	inUse := []string{}
+	for _, groupDev := range groupDevices {
+		if shared.StringInSlice(groupDev.SlotName, instanceAddresses) {
+			continue
+		}
+
+		// Devices bound to vfio-pci may be passed to another instance.
+		if usedBy[groupDev.SlotName] != "" {
+			inUse = append(inUse, fmt.Sprintf("%s (instance %s)", groupDev.SlotName, usedBy[groupDev.SlotName]))
+			continue
+		}
+
+		if shared.StringInSlice(groupDev.Driver, []string{"", "vfio-pci", "pci-stub", "pcieport"}) {
+			continue
+		}
+
+		inUse = append(inUse, fmt.Sprintf("%s (%s)", groupDev.SlotName, groupDev.Driver))
+	}
+
+	if len(inUse) > 0 {
+		return fmt.Errorf("PCI device %q shares its IOMMU group with devices in use: %s", address, strings.Join(inUse, ", "))
+	}
+
+	return nil
 }
 
 // Start is run when the device is added to the instance.
@@ -68,16 +149,32 @@ func (d *pci) Start() (*deviceConfig.RunConfig, error) {
 	saveData["last_state.pci.slot.name"] = pciDev.SlotName
 	saveData["last_state.pci.driver"] = pciDev.Driver
 
+	pciIOMMUGroup, err := pcidev.DeviceIOMMUGroup(pciDev.SlotName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to get IOMMU group of PCI device %q", pciAddress)
+	}
+
 	err = pcidev.DeviceDriverOverride(pciDev, "vfio-pci")
 	if err != nil {
 		return nil, errors.Wrapf(err, "Failed to override IOMMU group driver")
 	}
 
-	runConf.PCIDevice = append(runConf.PCIDevice,
-		[]deviceConfig.RunConfigItem{
-			{Key: "devName", Value: d.name},
-			{Key: "pciSlotName", Value: saveData["last_state.pci.slot.name"]},
-		}...)
+	if d.inst.Type() == instancetype.Container {
+		// Pass the VFIO container and group devices for use by userspace drivers.
+		for _, path := range []string{"/dev/vfio/vfio", fmt.Sprintf("/dev/vfio/%d", pciIOMMUGroup)} {
+			err = unixDeviceSetup(d.state, d.inst.DevicesPath(), "unix", d.name, deviceConfig.Device{"type": "unix-char", "path": path}, true, &runConf)
+			if err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		runConf.PCIDevice = append(runConf.PCIDevice,
+			[]deviceConfig.RunConfigItem{
+				{Key: "devName", Value: d.name},
+				{Key: "pciSlotName", Value: saveData["last_state.pci.slot.name"]},
+				{Key: "pciIOMMUGroup", Value: fmt.Sprintf("%d", pciIOMMUGroup)},
+			}...)
+	}
 
 	err = d.volatileSet(saveData)
 	if err != nil {
@@ -93,6 +190,13 @@ func (d *pci) Stop() (*deviceConfig.RunConfig, error) {
 		PostHooks: []func() error{d.postStop},
 	}
 
+	if d.inst.Type() == instancetype.Container {
+		err := unixDeviceRemove(d.inst.DevicesPath(), "unix", d.name, "", &runConf)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return &runConf, nil
 }
 
@@ -105,6 +209,14 @@ func (d *pci) postStop() error {
 
 	v := d.volatileGet()
 
+	if d.inst.Type() == instancetype.Container {
+		// Remove host files for this device.
+		err := unixDeviceDeleteFiles(d.state, d.inst.DevicesPath(), "unix", d.name, "")
+		if err != nil {
+			return errors.Wrapf(err, "Failed to delete files for device %q", d.name)
+		}
+	}
+
 	// Unbind from vfio-pci and bind back to host driver.
 	if v["last_state.pci.slot.name"] != "" {
 		pciDev := pcidev.Device{
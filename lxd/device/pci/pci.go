@@ -196,3 +196,31 @@ func DeviceIOMMUGroup(slotName string) (uint64, error) {
 
 	return iommuGroup, nil
 }
+
+// DeviceIOMMUGroupDevices returns the PCI devices in the same IOMMU group as a PCI device, including itself.
+func DeviceIOMMUGroupDevices(slotName string) ([]Device, error) {
+	return deviceIOMMUGroupDevices("/sys/bus/pci/devices", slotName)
+}
+
+// deviceIOMMUGroupDevices returns the PCI devices in the same IOMMU group as a PCI device, using the given
+// sysfs PCI devices directory.
+func deviceIOMMUGroupDevices(devicesPath string, slotName string) ([]Device, error) {
+	iommuGroupPath := filepath.Join(devicesPath, slotName, "iommu_group", "devices")
+
+	ents, err := ioutil.ReadDir(iommuGroupPath)
+	if err != nil {
+		return nil, err
+	}
+
+	devices := make([]Device, 0, len(ents))
+	for _, ent := range ents {
+		dev, err := ParseUeventFile(filepath.Join(iommuGroupPath, ent.Name(), "uevent"))
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to get PCI device info for %q", ent.Name())
+		}
+
+		devices = append(devices, dev)
+	}
+
+	return devices, nil
+}
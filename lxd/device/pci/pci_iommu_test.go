@@ -0,0 +1,54 @@
+package pci
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// makeIOMMUGroup creates a fake sysfs layout with the given devices (slot name to driver) in an IOMMU group.
+func makeIOMMUGroup(t *testing.T, root string, group string, devices map[string]string) {
+	groupPath := filepath.Join(root, "kernel", "iommu_groups", group, "devices")
+	require.NoError(t, os.MkdirAll(groupPath, 0755))
+
+	for slotName, driver := range devices {
+		devicePath := filepath.Join(root, "bus", "pci", "devices", slotName)
+		require.NoError(t, os.MkdirAll(devicePath, 0755))
+
+		uevent := "PCI_SLOT_NAME=" + slotName + "\n"
+		if driver != "" {
+			uevent = "DRIVER=" + driver + "\n" + uevent
+		}
+
+		require.NoError(t, ioutil.WriteFile(filepath.Join(devicePath, "uevent"), []byte(uevent), 0644))
+		require.NoError(t, os.Symlink(filepath.Join(root, "kernel", "iommu_groups", group), filepath.Join(devicePath, "iommu_group")))
+		require.NoError(t, os.Symlink(devicePath, filepath.Join(groupPath, slotName)))
+	}
+}
+
+func TestDeviceIOMMUGroupDevices(t *testing.T) {
+	root := t.TempDir()
+	makeIOMMUGroup(t, root, "1", map[string]string{"0000:01:00.0": "nvidia", "0000:01:00.1": "snd_hda_intel"})
+	makeIOMMUGroup(t, root, "2", map[string]string{"0000:02:00.0": "vfio-pci"})
+
+	devicesPath := filepath.Join(root, "bus", "pci", "devices")
+
+	devices, err := deviceIOMMUGroupDevices(devicesPath, "0000:01:00.1")
+	require.NoError(t, err)
+	assert.Equal(t, []Device{
+		{SlotName: "0000:01:00.0", Driver: "nvidia"},
+		{SlotName: "0000:01:00.1", Driver: "snd_hda_intel"},
+	}, devices)
+
+	devices, err = deviceIOMMUGroupDevices(devicesPath, "0000:02:00.0")
+	require.NoError(t, err)
+	assert.Equal(t, []Device{{SlotName: "0000:02:00.0", Driver: "vfio-pci"}}, devices)
+
+	// Devices without an IOMMU group.
+	_, err = deviceIOMMUGroupDevices(devicesPath, "0000:03:00.0")
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,36 @@
+package device
+
+import (
+	"fmt"
+
+	pcidev "github.com/lxc/lxd/lxd/device/pci"
+)
+
+func Example_pciValidateIOMMUGroup() {
+	group := []pcidev.Device{
+		{SlotName: "0000:01:00.0", Driver: "vfio-pci"},
+		{SlotName: "0000:01:00.1", Driver: "snd_hda_intel"},
+		{SlotName: "0000:00:01.0", Driver: "pcieport"},
+	}
+
+	tests := []struct {
+		address           string
+		instanceAddresses []string
+		usedBy            map[string]string
+	}{
+		// Both functions passed to the instance.
+		{"0000:01:00.0", []string{"0000:01:00.0", "0000:01:00.1"}, nil},
+		// The audio function is used by the host.
+		{"0000:01:00.0", []string{"0000:01:00.0"}, nil},
+		// The GPU is passed to another instance.
+		{"0000:01:00.1", []string{"0000:01:00.1"}, map[string]string{"0000:01:00.0": "vm1"}},
+	}
+
+	for _, test := range tests {
+		fmt.Println(pciValidateIOMMUGroup(test.address, group, test.instanceAddresses, test.usedBy))
+	}
+
+	// Output: <nil>
+	// PCI device "0000:01:00.0" shares its IOMMU group with devices in use: 0000:01:00.1 (snd_hda_intel)
+	// PCI device "0000:01:00.1" shares its IOMMU group with devices in use: 0000:01:00.0 (instance vm1)
+}
@@ -24,6 +24,7 @@ import (
 	"github.com/lxc/lxd/lxd/ip"
 	"github.com/lxc/lxd/lxd/network"
 	"github.com/lxc/lxd/lxd/project"
+	"github.com/lxc/lxd/lxd/secrets"
 	"github.com/lxc/lxd/lxd/warnings"
 	"github.com/lxc/lxd/shared"
 	log "github.com/lxc/lxd/shared/log15"
@@ -49,6 +50,7 @@ type proxyProcInfo struct {
 	securityUID    string
 	securityGID    string
 	proxyProtocol  string
+	tokenFd        string
 	inheritFds     []*os.File
 }
 
@@ -84,6 +86,7 @@ func (d *proxy) validateConfig(instConf instance.ConfigReader) error {
 		"security.uid":   validate.Optional(unixValidUserID),
 		"security.gid":   validate.Optional(unixValidUserID),
 		"proxy_protocol": validate.Optional(validate.IsBool),
+		"security.token": validate.Optional(),
 	}
 
 	err := d.config.Validate(rules)
@@ -91,10 +94,6 @@ func (d *proxy) validateConfig(instConf instance.ConfigReader) error {
 		return err
 	}
 
-	if instConf.Type() == instancetype.VM && !shared.IsTrue(d.config["nat"]) {
-		return fmt.Errorf("Only NAT mode is supported for proxies on VM instances")
-	}
-
 	listenAddr, err := ProxyParseAddr(d.config["listen"])
 	if err != nil {
 		return err
@@ -110,16 +109,30 @@ func (d *proxy) validateConfig(instConf instance.ConfigReader) error {
 		return fmt.Errorf("Mismatch between listen port(s) and connect port(s) count")
 	}
 
-	if shared.IsTrue(d.config["proxy_protocol"]) && (!strings.HasPrefix(d.config["connect"], "tcp") || shared.IsTrue(d.config["nat"])) {
+	useNAT := proxyUsesNAT(instConf.Type(), d.config)
+
+	// Without NAT rules, VM proxies run on the host and reach the instance over its network.
+	if instConf.Type() == instancetype.VM && !useNAT {
+		err = proxyValidateVMAddrs(d.config["bind"], listenAddr, connectAddr)
+		if err != nil {
+			return err
+		}
+	}
+
+	if shared.IsTrue(d.config["proxy_protocol"]) && (!strings.HasPrefix(d.config["connect"], "tcp") || useNAT) {
 		return fmt.Errorf("The PROXY header can only be sent to tcp servers in non-nat mode")
 	}
 
+	if d.config["security.token"] != "" && (listenAddr.ConnType == "udp" || useNAT) {
+		return fmt.Errorf("Token authentication is only supported by tcp and unix listeners in non-nat mode")
+	}
+
 	if (!strings.HasPrefix(d.config["listen"], "unix:") || strings.HasPrefix(d.config["listen"], "unix:@")) &&
 		(d.config["uid"] != "" || d.config["gid"] != "" || d.config["mode"] != "") {
 		return fmt.Errorf("Only proxy devices for non-abstract unix sockets can carry uid, gid, or mode properties")
 	}
 
-	if shared.IsTrue(d.config["nat"]) {
+	if useNAT {
 		if d.inst != nil {
 			projectName := d.inst.Project()
 			if projectName != project.Default {
@@ -173,6 +186,47 @@ func (d *proxy) validateConfig(instConf instance.ConfigReader) error {
 	return nil
 }
 
+// proxyUsesNAT returns whether the proxy is implemented with NAT rules rather than a forkproxy process.
+// NAT rules can neither handle unix sockets nor send the PROXY header, so on virtual machines, such proxies
+// get a forkproxy process on the host even in NAT mode.
+func proxyUsesNAT(instType instancetype.Type, config deviceConfig.Device) bool {
+	if !shared.IsTrue(config["nat"]) {
+		return false
+	}
+
+	if instType != instancetype.VM {
+		return true
+	}
+
+	if strings.HasPrefix(config["listen"], "unix:") || strings.HasPrefix(config["connect"], "unix:") {
+		return false
+	}
+
+	return !shared.IsTrue(config["proxy_protocol"])
+}
+
+// proxyValidateVMAddrs checks the addresses of a virtual machine proxy run by a forkproxy process on the
+// host. Both ends are on the host, so the instance side must be a tcp or udp address on the instance's
+// network: the address of the instance to connect to for host-bound proxies, or the host address which
+// the instance connects to for instance-bound proxies.
+func proxyValidateVMAddrs(bind string, listenAddr *deviceConfig.ProxyAddress, connectAddr *deviceConfig.ProxyAddress) error {
+	instanceAddr := connectAddr
+	if shared.StringInSlice(bind, []string{"instance", "guest", "container"}) {
+		instanceAddr = listenAddr
+	}
+
+	if instanceAddr.ConnType == "unix" {
+		return fmt.Errorf("The instance side of proxies on VM instances must be a tcp or udp address")
+	}
+
+	address := net.ParseIP(instanceAddr.Address)
+	if address == nil || address.IsLoopback() || address.IsUnspecified() {
+		return fmt.Errorf("The instance side of proxies on VM instances must be a specific address on the instance's network")
+	}
+
+	return nil
+}
+
 // validateEnvironment checks the runtime environment for correctness.
 func (d *proxy) validateEnvironment() error {
 	if d.name == "" {
@@ -193,7 +247,7 @@ func (d *proxy) Start() (*deviceConfig.RunConfig, error) {
 	runConf := deviceConfig.RunConfig{}
 	runConf.PostHooks = []func() error{
 		func() error {
-			if shared.IsTrue(d.config["nat"]) {
+			if proxyUsesNAT(d.inst.Type(), d.config) {
 				err = d.setupNAT()
 				if err != nil {
 					return fmt.Errorf("Failed to start device %q: %w", d.name, err)
@@ -234,6 +288,7 @@ func (d *proxy) Start() (*deviceConfig.RunConfig, error) {
 				proxyValues.securityGID,
 				proxyValues.securityUID,
 				proxyValues.proxyProtocol,
+				proxyValues.tokenFd,
 			}
 
 			p, err := subprocess.NewProcess(command, forkproxyargs, logPath, logPath)
@@ -468,27 +523,33 @@ func (d *proxy) rewriteHostAddr(addr string) string {
 }
 
 func (d *proxy) setupProxyProcInfo() (*proxyProcInfo, error) {
-	cname := project.Instance(d.inst.Project(), d.inst.Name())
-	cc, err := liblxc.NewContainer(cname, d.state.OS.LxcPath)
-	if err != nil {
-		return nil, err
-	}
-	defer cc.Release()
-
-	containerPid := strconv.Itoa(cc.InitPid())
 	lxdPid := strconv.Itoa(os.Getpid())
 
+	// VM proxies run entirely on the host, connecting to the instance over its network.
+	containerPid := lxdPid
 	containerPidFd := -1
 	lxdPidFd := -1
 	var inheritFd []*os.File
-	if d.state.OS.PidFds {
-		cPidFd, err := cc.InitPidFd()
-		if err == nil {
-			dPidFd, err := shared.PidFdOpen(os.Getpid(), 0)
+
+	if d.inst.Type() == instancetype.Container {
+		cname := project.Instance(d.inst.Project(), d.inst.Name())
+		cc, err := liblxc.NewContainer(cname, d.state.OS.LxcPath)
+		if err != nil {
+			return nil, err
+		}
+		defer cc.Release()
+
+		containerPid = strconv.Itoa(cc.InitPid())
+
+		if d.state.OS.PidFds {
+			cPidFd, err := cc.InitPidFd()
 			if err == nil {
-				inheritFd = []*os.File{cPidFd, dPidFd}
-				containerPidFd = 3
-				lxdPidFd = 4
+				dPidFd, err := shared.PidFdOpen(os.Getpid(), 0)
+				if err == nil {
+					inheritFd = []*os.File{cPidFd, dPidFd}
+					containerPidFd = 3
+					lxdPidFd = 4
+				}
 			}
 		}
 	}
@@ -524,6 +585,35 @@ func (d *proxy) setupProxyProcInfo() (*proxyProcInfo, error) {
 		listenAddrMode = d.config["mode"]
 	}
 
+	// Pass the token through a pipe so that it doesn't appear in the command line of the process.
+	tokenFd := ""
+	if d.config["security.token"] != "" {
+		token, err := secrets.Expand(d.state, d.inst.Project(), d.config["security.token"])
+		if err != nil {
+			return nil, err
+		}
+
+		// Tokens are sent on their own line and have to fit in the pipe buffer.
+		if token == "" || len(token) > 4096 || strings.ContainsAny(token, "\r\n") {
+			return nil, fmt.Errorf("The token must be a single line of at most 4096 bytes")
+		}
+
+		tokenReader, tokenWriter, err := os.Pipe()
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = tokenWriter.WriteString(token)
+		tokenWriter.Close()
+		if err != nil {
+			tokenReader.Close()
+			return nil, err
+		}
+
+		inheritFd = append(inheritFd, tokenReader)
+		tokenFd = fmt.Sprintf("%d", 2+len(inheritFd))
+	}
+
 	p := &proxyProcInfo{
 		listenPid:      listenPid,
 		listenPidFd:    listenPidFd,
@@ -537,6 +627,7 @@ func (d *proxy) setupProxyProcInfo() (*proxyProcInfo, error) {
 		securityGID:    d.config["security.gid"],
 		securityUID:    d.config["security.uid"],
 		proxyProtocol:  d.config["proxy_protocol"],
+		tokenFd:        tokenFd,
 		inheritFds:     inheritFd,
 	}
 
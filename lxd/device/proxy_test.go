@@ -0,0 +1,61 @@
+package device
+
+import (
+	"fmt"
+
+	deviceConfig "github.com/lxc/lxd/lxd/device/config"
+	"github.com/lxc/lxd/lxd/instance/instancetype"
+)
+
+func Example_proxyUsesNAT() {
+	tests := []deviceConfig.Device{
+		{"listen": "tcp:10.0.0.1:80", "connect": "tcp:10.0.0.2:80"},
+		{"listen": "tcp:10.0.0.1:80", "connect": "tcp:10.0.0.2:80", "nat": "true"},
+		{"listen": "udp:10.0.0.1:53", "connect": "udp:10.0.0.2:53", "nat": "true"},
+		{"listen": "unix:/run/web.sock", "connect": "tcp:10.0.0.2:80", "nat": "true"},
+		{"listen": "tcp:10.0.0.1:80", "connect": "unix:/run/web.sock", "nat": "true", "bind": "instance"},
+		{"listen": "tcp:10.0.0.1:80", "connect": "tcp:10.0.0.2:80", "nat": "true", "proxy_protocol": "true"},
+	}
+
+	for _, config := range tests {
+		fmt.Printf("%s -> %s: container %t, vm %t\n", config["listen"], config["connect"], proxyUsesNAT(instancetype.Container, config), proxyUsesNAT(instancetype.VM, config))
+	}
+
+	// Output: tcp:10.0.0.1:80 -> tcp:10.0.0.2:80: container false, vm false
+	// tcp:10.0.0.1:80 -> tcp:10.0.0.2:80: container true, vm true
+	// udp:10.0.0.1:53 -> udp:10.0.0.2:53: container true, vm true
+	// unix:/run/web.sock -> tcp:10.0.0.2:80: container true, vm false
+	// tcp:10.0.0.1:80 -> unix:/run/web.sock: container true, vm false
+	// tcp:10.0.0.1:80 -> tcp:10.0.0.2:80: container true, vm false
+}
+
+func Example_proxyValidateVMAddrs() {
+	tests := []struct {
+		bind    string
+		listen  string
+		connect string
+	}{
+		{"host", "tcp:0.0.0.0:80", "tcp:10.0.0.2:80"},
+		{"host", "udp:0.0.0.0:53", "udp:10.0.0.2:53"},
+		{"", "unix:/run/web.sock", "tcp:10.0.0.2:80"},
+		{"host", "tcp:0.0.0.0:80", "tcp:127.0.0.1:80"},
+		{"host", "tcp:0.0.0.0:80", "unix:/run/web.sock"},
+		{"instance", "tcp:10.0.0.1:8080", "unix:/run/web.sock"},
+		{"instance", "tcp:0.0.0.0:8080", "unix:/run/web.sock"},
+	}
+
+	for _, test := range tests {
+		listenAddr, _ := ProxyParseAddr(test.listen)
+		connectAddr, _ := ProxyParseAddr(test.connect)
+		err := proxyValidateVMAddrs(test.bind, listenAddr, connectAddr)
+		fmt.Printf("%q %s -> %s: %t\n", test.bind, test.listen, test.connect, err == nil)
+	}
+
+	// Output: "host" tcp:0.0.0.0:80 -> tcp:10.0.0.2:80: true
+	// "host" udp:0.0.0.0:53 -> udp:10.0.0.2:53: true
+	// "" unix:/run/web.sock -> tcp:10.0.0.2:80: true
+	// "host" tcp:0.0.0.0:80 -> tcp:127.0.0.1:80: false
+	// "host" tcp:0.0.0.0:80 -> unix:/run/web.sock: false
+	// "instance" tcp:10.0.0.1:8080 -> unix:/run/web.sock: true
+	// "instance" tcp:0.0.0.0:8080 -> unix:/run/web.sock: false
+}
@@ -187,7 +187,12 @@ func (d *tpm) startVM() (*deviceConfig.RunConfig, error) {
 		},
 	}
 
-	proc, err := subprocess.NewProcess("swtpm", []string{"socket", "--tpm2", "--tpmstate", fmt.Sprintf("dir=%s", tpmDevPath), "--ctrl", fmt.Sprintf("type=unixio,path=%s", socketPath)}, "", "")
+	logPath := filepath.Join(d.inst.LogPath(), fmt.Sprintf("tpm.%s.log", d.name))
+
+	// Remove old socket if needed.
+	os.Remove(socketPath)
+
+	proc, err := subprocess.NewProcess("swtpm", []string{"socket", "--tpm2", "--tpmstate", fmt.Sprintf("dir=%s", tpmDevPath), "--ctrl", fmt.Sprintf("type=unixio,path=%s", socketPath), "--terminate"}, logPath, logPath)
 	if err != nil {
 		return nil, err
 	}
@@ -210,6 +215,17 @@ func (d *tpm) startVM() (*deviceConfig.RunConfig, error) {
 		return nil, errors.Wrapf(err, "Failed to save swtpm state for device %q", d.name)
 	}
 
+	// Wait for the socket to exist so QEMU doesn't race its creation.
+	waitDuration := time.Second * time.Duration(10)
+	waitUntil := time.Now().Add(waitDuration)
+	for !shared.PathExists(socketPath) {
+		if time.Now().After(waitUntil) {
+			return nil, fmt.Errorf("swtpm failed to bind socket after %v", waitDuration)
+		}
+
+		time.Sleep(50 * time.Millisecond)
+	}
+
 	revert.Success()
 
 	return &runConf, nil
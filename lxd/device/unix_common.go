@@ -3,11 +3,14 @@ package device
 import (
 	"fmt"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	deviceConfig "github.com/lxc/lxd/lxd/device/config"
 	"github.com/lxc/lxd/lxd/instance"
 	"github.com/lxc/lxd/lxd/instance/instancetype"
+	"github.com/lxc/lxd/lxd/project"
+	"github.com/lxc/lxd/lxd/state"
 	storageDrivers "github.com/lxc/lxd/lxd/storage/drivers"
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/validate"
@@ -69,6 +72,17 @@ func (d *unixCommon) validateConfig(instConf instance.ConfigReader) error {
 	return nil
 }
 
+// unixCheckAllowed checks that the restrictions of the project allow attaching the host device with the given
+// type and major and minor numbers.
+func unixCheckAllowed(s *state.State, projectName string, deviceType string, dType string, major uint32, minor uint32) error {
+	p, err := s.Cluster.GetProject(projectName)
+	if err != nil {
+		return fmt.Errorf("Failed loading project %q: %w", projectName, err)
+	}
+
+	return project.CheckUnixDeviceAllowed(p, deviceType, unixDeviceProperties(dType, major, minor))
+}
+
 // Register is run after the device is started or when LXD starts.
 func (d *unixCommon) Register() error {
 	// Don't register for hot plug events if the device is required.
@@ -82,6 +96,7 @@ func (d *unixCommon) Register() error {
 	devConfig := d.config
 	deviceName := d.name
 	state := d.state
+	projectName := d.inst.Project()
 
 	// Handler for when a Unix event occurs.
 	f := func(e UnixEvent) (*deviceConfig.RunConfig, error) {
@@ -105,7 +120,7 @@ func (d *unixCommon) Register() error {
 			}
 
 			// Get the file type and ensure it matches what the user was expecting.
-			dType, _, _, err := unixDeviceAttributes(e.Path)
+			dType, major, minor, err := unixDeviceAttributes(e.Path)
 			if err != nil {
 				return nil, err
 			}
@@ -114,6 +129,11 @@ func (d *unixCommon) Register() error {
 				return nil, fmt.Errorf("Path specified is not a %s device", d.config["type"])
 			}
 
+			err = unixCheckAllowed(state, projectName, devConfig["type"], dType, major, minor)
+			if err != nil {
+				return nil, err
+			}
+
 			err = unixDeviceSetup(state, devicesPath, "unix", deviceName, devConfig, true, &runConf)
 			if err != nil {
 				return nil, err
@@ -160,13 +180,18 @@ func (d *unixCommon) Start() (*deviceConfig.RunConfig, error) {
 	srcPath := unixDeviceSourcePath(d.config)
 
 	// If device file already exists on system, proceed to add it whether its required or not.
-	dType, _, _, err := unixDeviceAttributes(srcPath)
+	dType, major, minor, err := unixDeviceAttributes(srcPath)
 	if err == nil {
 		// Ensure device type matches what the device config is expecting.
 		if !unixIsOurDeviceType(d.config, dType) {
 			return nil, fmt.Errorf("Path specified is not a %s device", d.config["type"])
 		}
 
+		err = unixCheckAllowed(d.state, d.inst.Project(), d.config["type"], dType, major, minor)
+		if err != nil {
+			return nil, err
+		}
+
 		err = unixDeviceSetup(d.state, d.inst.DevicesPath(), "unix", d.name, d.config, true, &runConf)
 		if err != nil {
 			return nil, err
@@ -175,7 +200,27 @@ func (d *unixCommon) Start() (*deviceConfig.RunConfig, error) {
 		// If the device file doesn't exist on the system, but major & minor numbers have
 		// been provided in the config then we can go ahead and create the device anyway.
 		if d.config["major"] != "" && d.config["minor"] != "" {
-			err := unixDeviceSetup(d.state, d.inst.DevicesPath(), "unix", d.name, d.config, true, &runConf)
+			dType := "c"
+			if d.config["type"] == "unix-block" {
+				dType = "b"
+			}
+
+			major, err := strconv.ParseUint(d.config["major"], 10, 32)
+			if err != nil {
+				return nil, err
+			}
+
+			minor, err := strconv.ParseUint(d.config["minor"], 10, 32)
+			if err != nil {
+				return nil, err
+			}
+
+			err = unixCheckAllowed(d.state, d.inst.Project(), d.config["type"], dType, uint32(major), uint32(minor))
+			if err != nil {
+				return nil, err
+			}
+
+			err = unixDeviceSetup(d.state, d.inst.DevicesPath(), "unix", d.name, d.config, true, &runConf)
 			if err != nil {
 				return nil, err
 			}
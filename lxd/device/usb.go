@@ -27,13 +27,29 @@ func usbIsOurDevice(config deviceConfig.Device, usb *USBEvent) bool {
 		return false
 	}
 
+	// The serial number isn't known on removal, in which case removing a host device which isn't ours is a
+	// no-op.
+	if config["serial"] != "" && config["serial"] != usb.Serial && usb.Action != "remove" {
+		return false
+	}
+
 	return true
 }
 
+// usbVMDeviceName returns the name used for the host USB device in a VM, as several host devices can match.
+func usbVMDeviceName(deviceName string, usb *USBEvent) string {
+	return fmt.Sprintf("%s-%03d-%03d", deviceName, usb.BusNum, usb.DevNum)
+}
+
 type usb struct {
 	deviceCommon
 }
 
+// CanHotPlug returns whether the device can be managed whilst the instance is running. Returns true.
+func (d *usb) CanHotPlug() bool {
+	return true
+}
+
 // isRequired indicates whether the device config requires this device to start OK.
 func (d *usb) isRequired() bool {
 	// Defaults to not required.
@@ -57,6 +73,7 @@ func (d *usb) validateConfig(instConf instance.ConfigReader) error {
 	rules := map[string]func(string) error{
 		"vendorid":  validate.Optional(validate.IsDeviceID),
 		"productid": validate.Optional(validate.IsDeviceID),
+		"serial":    validate.IsAny,
 		"uid":       unixValidUserID,
 		"gid":       unixValidUserID,
 		"mode":      unixValidOctalFileMode,
@@ -79,6 +96,7 @@ func (d *usb) Register() error {
 	devConfig := d.config
 	deviceName := d.name
 	state := d.state
+	instType := d.inst.Type()
 
 	// Handler for when a USB event occurs.
 	f := func(e USBEvent) (*deviceConfig.RunConfig, error) {
@@ -88,6 +106,21 @@ func (d *usb) Register() error {
 
 		runConf := deviceConfig.RunConfig{}
 
+		// VMs get the host device attached or detached through QEMU.
+		if instType == instancetype.VM {
+			if e.Action != "add" && e.Action != "remove" {
+				return nil, nil
+			}
+
+			runConf.USBDevice = append(runConf.USBDevice, []deviceConfig.RunConfigItem{
+				{Key: "devName", Value: usbVMDeviceName(deviceName, &e)},
+				{Key: "hostDevice", Value: e.Path},
+				{Key: "action", Value: e.Action},
+			}...)
+
+			return &runConf, nil
+		}
+
 		if e.Action == "add" {
 			err := unixDeviceSetupCharNum(state, devicesPath, "unix", deviceName, devConfig, e.Major, e.Minor, e.Path, false, &runConf)
 			if err != nil {
@@ -176,8 +209,8 @@ func (d *usb) startVM() (*deviceConfig.RunConfig, error) {
 		}
 
 		runConf.USBDevice = append(runConf.USBDevice, []deviceConfig.RunConfigItem{
-			{Key: "devName", Value: d.name},
-			{Key: "hostDevice", Value: fmt.Sprintf("/dev/bus/usb/%03d/%03d", usb.BusNum, usb.DevNum)},
+			{Key: "devName", Value: usbVMDeviceName(d.name, &usb)},
+			{Key: "hostDevice", Value: usb.Path},
 		}...)
 	}
 
@@ -194,14 +227,31 @@ func (d *usb) Stop() (*deviceConfig.RunConfig, error) {
 		PostHooks: []func() error{d.postStop},
 	}
 
-	if d.inst.Type() == instancetype.Container {
-		// Unregister any USB event handlers for this device.
-		usbUnregisterHandler(d.inst, d.name)
+	// Unregister any USB event handlers for this device.
+	usbUnregisterHandler(d.inst, d.name)
 
+	if d.inst.Type() == instancetype.Container {
 		err := unixDeviceRemove(d.inst.DevicesPath(), "unix", d.name, "", &runConf)
 		if err != nil {
 			return nil, err
 		}
+	} else if d.inst.Type() == instancetype.VM {
+		usbs, err := d.loadUsb()
+		if err != nil {
+			return nil, err
+		}
+
+		// List the host devices which may be attached so they can be detached.
+		for _, usb := range usbs {
+			if !usbIsOurDevice(d.config, &usb) {
+				continue
+			}
+
+			runConf.USBDevice = append(runConf.USBDevice, []deviceConfig.RunConfigItem{
+				{Key: "devName", Value: usbVMDeviceName(d.name, &usb)},
+				{Key: "hostDevice", Value: usb.Path},
+			}...)
+		}
 	}
 
 	return &runConf, nil
@@ -247,10 +297,17 @@ func (d *usb) loadUsb() ([]USBEvent, error) {
 			return []USBEvent{}, fmt.Errorf("invalid device value %s", values["dev"])
 		}
 
+		// Not all devices have a serial number.
+		serial, err := ioutil.ReadFile(path.Join(usbDevPath, ent.Name(), "serial"))
+		if err != nil {
+			serial = []byte{}
+		}
+
 		usb, err := USBNewEvent(
 			"add",
 			values["idVendor"],
 			values["idProduct"],
+			strings.TrimSpace(string(serial)),
 			parts[0],
 			parts[1],
 			values["busnum"],
@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path"
 	"path/filepath"
@@ -214,6 +215,16 @@ func deviceNetlinkListener() (chan []string, chan []string, chan device.USBEvent
 					return strings.Repeat("0", l-len(s)) + s
 				}
 
+				// The serial number isn't part of the uevent, so read it from sysfs while the
+				// device is still present (it won't be available on removal).
+				serial := ""
+				if props["ACTION"] == "add" {
+					content, err := ioutil.ReadFile(filepath.Join("/sys", props["DEVPATH"], "serial"))
+					if err == nil {
+						serial = strings.TrimSpace(string(content))
+					}
+				}
+
 				usb, err := device.USBNewEvent(
 					props["ACTION"],
 					/* udev doesn't zero pad these, while
@@ -222,6 +233,7 @@ func deviceNetlinkListener() (chan []string, chan []string, chan device.USBEvent
 					 */
 					zeroPad(parts[0], 4),
 					zeroPad(parts[1], 4),
+					serial,
 					major,
 					minor,
 					busnum,
@@ -378,21 +390,52 @@ func deviceTaskBalance(s *state.State) {
 
 	fixedInstances := map[int64][]instance.Instance{}
 	balancedInstances := map[instance.Instance]int{}
+	nodeInstances := map[instance.Instance][]int64{}
 	for _, c := range instances {
+		if !c.IsRunning() {
+			continue
+		}
+
 		conf := c.ExpandedConfig()
+
+		// Restrict the instance to the CPUs of its NUMA nodes.
+		var nodeCpus []int64
+		if conf["limits.cpu.nodes"] != "" {
+			nodeCpus, err = resources.GetNUMANodeCPUs(conf["limits.cpu.nodes"])
+			if err != nil {
+				logger.Error("Problem getting NUMA node CPUs", log.Ctx{"name": c.Name(), "err": err})
+				continue
+			}
+
+			usable := []int64{}
+			for _, nr := range nodeCpus {
+				if shared.Int64InSlice(nr, cpus) {
+					usable = append(usable, nr)
+				}
+			}
+
+			nodeCpus = usable
+		}
+
 		cpulimit, ok := conf["limits.cpu"]
 		if !ok || cpulimit == "" {
 			cpulimit = effectiveCpus
-		}
 
-		if !c.IsRunning() {
-			continue
+			if nodeCpus != nil {
+				cpulimit = fmt.Sprintf("%d", len(nodeCpus))
+			}
 		}
 
 		count, err := strconv.Atoi(cpulimit)
 		if err == nil {
 			// Load-balance
-			count = min(count, len(cpus))
+			if nodeCpus != nil {
+				count = min(count, len(nodeCpus))
+				nodeInstances[c] = nodeCpus
+			} else {
+				count = min(count, len(cpus))
+			}
+
 			balancedInstances[c] = count
 		} else {
 			// Pinned
@@ -458,6 +501,12 @@ func deviceTaskBalance(s *state.State) {
 			if count == 0 {
 				break
 			}
+
+			nodeCpus, isNode := nodeInstances[ctn]
+			if isNode && !shared.Int64InSlice(cpu.id, nodeCpus) {
+				continue
+			}
+
 			count -= 1
 
 			id := cpu.strId
@@ -22,12 +22,15 @@ import (
 	"github.com/lxc/lxd/lxd/instance"
 	"github.com/lxc/lxd/lxd/instance/instancetype"
 	"github.com/lxc/lxd/lxd/project"
+	"github.com/lxc/lxd/lxd/secrets"
 	"github.com/lxc/lxd/lxd/state"
 	"github.com/lxc/lxd/lxd/ucred"
 	"github.com/lxc/lxd/lxd/util"
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/logger"
 	"github.com/lxc/lxd/shared/version"
+
+	log "github.com/lxc/lxd/shared/log15"
 )
 
 // DevLxdServer creates an http.Server capable of handling requests against the
@@ -82,6 +85,12 @@ var devlxdConfigKeyGet = devLxdHandler{"/1.0/config/{key}", func(d *Daemon, c in
 		return &devLxdResponse{"not found", http.StatusNotFound, "raw"}
 	}
 
+	value, err := secrets.Expand(d.State(), c.Project(), value)
+	if err != nil {
+		logger.Warn("Failed to expand secrets in config key", log.Ctx{"instance": c.Name(), "project": c.Project(), "key": key, "err": err})
+		return &devLxdResponse{"internal server error", http.StatusInternalServerError, "raw"}
+	}
+
 	return okResponse(value, "raw")
 }}
 
@@ -103,7 +112,12 @@ var devlxdImageExport = devLxdHandler{"/1.0/images/{fingerprint}/export", func(d
 }}
 
 var devlxdMetadataGet = devLxdHandler{"/1.0/meta-data", func(d *Daemon, c instance.Instance, w http.ResponseWriter, r *http.Request) *devLxdResponse {
-	value := c.ExpandedConfig()["user.meta-data"]
+	value, err := secrets.Expand(d.State(), c.Project(), c.ExpandedConfig()["user.meta-data"])
+	if err != nil {
+		logger.Warn("Failed to expand secrets in config key", log.Ctx{"instance": c.Name(), "project": c.Project(), "key": "user.meta-data", "err": err})
+		return &devLxdResponse{"internal server error", http.StatusInternalServerError, "raw"}
+	}
+
 	return okResponse(fmt.Sprintf("#cloud-config\ninstance-id: %s\nlocal-hostname: %s\n%s", c.Name(), c.Name(), value), "raw")
 }}
 
@@ -126,6 +140,8 @@ var devlxdEventsGet = devLxdHandler{"/1.0/events", func(d *Daemon, c instance.In
 
 	logger.Debugf("New container event listener for '%s': %s", project.Instance(c.Project(), c.Name()), listener.ID())
 
+	d.devlxdEvents.Start(listener)
+
 	// Create a cancellable context from the request context. Once the request has been upgraded
 	// to a websocket the request's context doesn't appear to be cancelled when the client
 	// disconnects (even though its documented as such). But we wrap the request's context here
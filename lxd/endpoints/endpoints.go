@@ -58,6 +58,11 @@ type Config struct {
 	//
 	// It can be updated after the endpoints are up using PprofUpdateAddress().
 	DebugAddress string
+
+	// Additional HTTPS listeners, each serving a scope of config.RestServer.
+	//
+	// They can be updated after the endpoints are up using ListenersUpdate().
+	Listeners []Listener
 }
 
 // Up brings up all applicable LXD endpoints and starts accepting HTTP
@@ -70,10 +75,9 @@ type Config struct {
 // ----------------------------
 //
 // If socket-based activation is detected, look for a unix socket among the
-// inherited file descriptors and use it for the local endpoint (or if no such
-// file descriptor exists, don't bring up the local endpoint at all).
+// inherited file descriptors and use it for the local endpoint.
 //
-// If no socket-based activation is detected, create a unix socket using the
+// If no such file descriptor exists, create a unix socket using the
 // default <lxd-var-dir>/unix.socket path. The file mode of this socket will be set
 // to 660, the file owner will be set to the process' UID, and the file group
 // will be set to the process GID, or to the GID of the system group name
@@ -93,8 +97,8 @@ type Config struct {
 // inherited file descriptors and use it for the network endpoint.
 //
 // If a network address was set via config.NetworkAddress, then close any listener
-// that was detected via socket-based activation and create a new network
-// socket bound to the given address.
+// that was detected via socket-based activation and isn't bound to that address,
+// and create a new network socket bound to the given address.
 //
 // The network endpoint socket will use TLS encryption, using the certificate
 // keypair and CA passed via config.Cert.
@@ -104,6 +108,13 @@ type Config struct {
 //
 // If a network address was set via config.ClusterAddress, then attach
 // config.RestServer to it.
+//
+// additional listeners (TCP sockets with TLS)
+// -------------------------------------------
+//
+// For each listener in config.Listeners, attach the part of config.RestServer
+// matching its scope to its address, using its own TLS keypair if set and
+// config.Cert otherwise.
 func Up(config *Config) (*Endpoints, error) {
 	if config.Dir == "" {
 		return nil, fmt.Errorf("No directory configured")
@@ -140,12 +151,13 @@ func Up(config *Config) (*Endpoints, error) {
 // the relevant HTTP handlers to them. When LXD shuts down they close all
 // sockets.
 type Endpoints struct {
-	tomb      *tomb.Tomb            // Controls the HTTP servers shutdown.
-	mu        sync.RWMutex          // Serialize access to internal state.
-	listeners map[kind]net.Listener // Activer listeners by endpoint type.
-	servers   map[kind]*http.Server // HTTP servers by endpoint type.
-	cert      *shared.CertInfo      // Keypair and CA to use for TLS.
-	inherited map[kind]bool         // Store whether the listener came through socket activation
+	tomb      *tomb.Tomb                // Controls the HTTP servers shutdown.
+	mu        sync.RWMutex              // Serialize access to internal state.
+	listeners map[kind]net.Listener     // Activer listeners by endpoint type.
+	servers   map[kind]*http.Server     // HTTP servers by endpoint type.
+	cert      *shared.CertInfo          // Keypair and CA to use for TLS.
+	extra     map[string]*extraListener // Additional listeners by name.
+	inherited map[kind]bool             // Store whether the listener came through socket activation
 
 	systemdListenFDsStart int // First socket activation FD, for tests.
 }
@@ -163,6 +175,7 @@ func (e *Endpoints) up(config *Config) error {
 		pprof:   pprofCreateServer(),
 	}
 	e.cert = config.Cert
+	e.extra = map[string]*extraListener{}
 	e.inherited = map[kind]bool{}
 
 	var err error
@@ -176,7 +189,10 @@ func (e *Endpoints) up(config *Config) error {
 		}
 	} else {
 		e.listeners = map[kind]net.Listener{}
+	}
 
+	// Create the unix socket ourselves unless systemd passed us one (it may only have passed the TCP one).
+	if e.listeners[local] == nil {
 		e.listeners[local], err = localCreateListener(config.UnixSocket, config.LocalUnixSocketGroup)
 		if err != nil {
 			return fmt.Errorf("local endpoint: %v", err)
@@ -190,18 +206,29 @@ func (e *Endpoints) up(config *Config) error {
 	}
 
 	if config.NetworkAddress != "" {
+		// Keep the inherited TCP socket if it's bound to the configured address, so that connections
+		// queued by systemd while we were (re)starting aren't dropped.
+		keepInherited := false
 		listener, ok := e.listeners[network]
 		if ok {
-			logger.Infof("Replacing inherited TCP socket with configured one")
-			listener.Close()
-			e.inherited[network] = false
+			listenerAddress := listener.Addr().String()
+			if util.IsAddressCovered(listenerAddress, config.NetworkAddress) && util.IsAddressCovered(config.NetworkAddress, listenerAddress) {
+				logger.Infof("Using inherited TCP socket for configured address %q", config.NetworkAddress)
+				keepInherited = true
+			} else {
+				logger.Infof("Replacing inherited TCP socket with configured one")
+				listener.Close()
+				e.inherited[network] = false
+			}
 		}
 
 		// Errors here are not fatal and are just logged (unless we're clustered, see below).
 		var networkAddressErr error
 		attempts := 0
 	againHttps:
-		e.listeners[network], networkAddressErr = networkCreateListener(config.NetworkAddress, e.cert)
+		if !keepInherited {
+			e.listeners[network], networkAddressErr = networkCreateListener(config.NetworkAddress, e.cert)
+		}
 
 		isCovered := util.IsAddressCovered(config.ClusterAddress, config.NetworkAddress)
 		if config.ClusterAddress != "" {
@@ -274,6 +301,16 @@ func (e *Endpoints) up(config *Config) error {
 		e.serve(pprof)
 	}
 
+	if len(config.Listeners) > 0 {
+		logger.Infof("Starting additional HTTPS listeners:")
+		for _, listener := range config.Listeners {
+			err = e.startExtraListener(listener)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
 	logger.Infof("Starting /dev/lxd handler:")
 	e.serve(devlxd)
 
@@ -326,6 +363,16 @@ func (e *Endpoints) Down() error {
 		}
 	}
 
+	if len(e.extra) > 0 {
+		logger.Infof("Stopping additional HTTPS listeners:")
+		for name := range e.extra {
+			err := e.closeExtraListener(name)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
 	if e.tomb != nil {
 		e.tomb.Kill(nil)
 		e.tomb.Wait()
@@ -0,0 +1,171 @@
+package endpoints
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/shared"
+	log "github.com/lxc/lxd/shared/log15"
+	"github.com/lxc/lxd/shared/logger"
+	tomb "gopkg.in/tomb.v2"
+)
+
+// Scopes of the REST API that can be served by additional listeners.
+const (
+	ListenerScopeAll     = "all"     // The whole REST API.
+	ListenerScopeMetrics = "metrics" // Only the /1.0/metrics endpoint.
+)
+
+// Listener describes an additional HTTPS listener serving (part of) the REST API.
+type Listener struct {
+	Name    string           // Unique name of the listener.
+	Scope   string           // Part of the REST API served by the listener.
+	Address string           // Address to bind to.
+	Cert    *shared.CertInfo // TLS keypair of the listener, the network one if nil.
+}
+
+// An additional listener which is up and running.
+type extraListener struct {
+	config   Listener
+	listener *networkListener
+}
+
+// listenerCreateServer returns a server handling the given scope of the REST API.
+func listenerCreateServer(restServer *http.Server, scope string) *http.Server {
+	if scope == ListenerScopeAll {
+		return &http.Server{Handler: restServer.Handler}
+	}
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/1.0/metrics" && !strings.HasPrefix(r.URL.Path, "/1.0/metrics/") {
+			w.Header().Set("Content-Type", "application/json")
+			response.Forbidden(fmt.Errorf("Only the metrics API is available on this address")).Render(w)
+			return
+		}
+
+		restServer.Handler.ServeHTTP(w, r)
+	})
+
+	return &http.Server{Handler: handler}
+}
+
+// ListenerAddress returns the network address of the additional listener with
+// the given name, or an empty string if there's no such listener.
+func (e *Endpoints) ListenerAddress(name string) string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	extra := e.extra[name]
+	if extra == nil {
+		return ""
+	}
+
+	return extra.listener.Addr().String()
+}
+
+// ListenersUpdate replaces the additional listeners with the given ones.
+//
+// Listeners whose address or scope changed are restarted, the ones whose
+// keypair changed keep running and only new requests use the new keypair.
+func (e *Endpoints) ListenersUpdate(listeners []Listener) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, config := range listeners {
+		if config.Scope != ListenerScopeAll && config.Scope != ListenerScopeMetrics {
+			return fmt.Errorf("Invalid scope %q for listener %q", config.Scope, config.Name)
+		}
+	}
+
+	// Close the listeners which are gone or need to be restarted.
+	wanted := make(map[string]Listener, len(listeners))
+	for _, config := range listeners {
+		wanted[config.Name] = config
+	}
+
+	for name, extra := range e.extra {
+		config, ok := wanted[name]
+		if ok && config.Address == extra.config.Address && config.Scope == extra.config.Scope {
+			continue
+		}
+
+		e.closeExtraListener(name)
+	}
+
+	// Start the new listeners and update the keypair of the existing ones.
+	var errs []string
+	for _, config := range listeners {
+		extra := e.extra[config.Name]
+		if extra != nil {
+			extra.config = config
+			extra.listener.Config(e.listenerCertificate(config))
+			continue
+		}
+
+		err := e.startExtraListener(config)
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("Failed to start listeners: %s", strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
+// startExtraListener binds the given listener and serves its scope of the REST
+// API on it. The caller must hold the lock.
+func (e *Endpoints) startExtraListener(config Listener) error {
+	listener, err := networkCreateListener(config.Address, e.listenerCertificate(config))
+	if err != nil {
+		return fmt.Errorf("%s: %w", config.Name, err)
+	}
+
+	extra := &extraListener{config: config, listener: listener.(*networkListener)}
+	e.extra[config.Name] = extra
+
+	logger.Info(" - binding HTTPS listener", log.Ctx{"name": config.Name, "scope": config.Scope, "socket": listener.Addr()})
+
+	// Defer the creation of the tomb, so Down() doesn't wait on it unless
+	// we actually have spawned at least a server.
+	if e.tomb == nil {
+		e.tomb = &tomb.Tomb{}
+	}
+
+	server := listenerCreateServer(e.servers[network], config.Scope)
+	e.tomb.Go(func() error {
+		server.Serve(listener)
+		return nil
+	})
+
+	return nil
+}
+
+// closeExtraListener stops the additional listener with the given name. The
+// caller must hold the lock.
+func (e *Endpoints) closeExtraListener(name string) error {
+	extra := e.extra[name]
+	if extra == nil {
+		return nil
+	}
+
+	delete(e.extra, name)
+
+	logger.Info(" - closing HTTPS listener", log.Ctx{"name": name, "socket": extra.listener.Addr()})
+
+	return extra.listener.Close()
+}
+
+// listenerCertificate returns the keypair to use for the given listener. The
+// caller must hold the lock.
+func (e *Endpoints) listenerCertificate(config Listener) *shared.CertInfo {
+	if config.Cert != nil {
+		return config.Cert
+	}
+
+	return e.cert
+}
@@ -0,0 +1,124 @@
+package endpoints_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/lxc/lxd/lxd/endpoints"
+	"github.com/lxc/lxd/shared"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Additional listeners only serve the scope of the API they're configured with.
+func TestEndpoints_ListenersScope(t *testing.T) {
+	endpoints, config, cleanup := newEndpoints(t)
+	defer cleanup()
+
+	config.Listeners = []listener{
+		newListener("metrics", scopeMetrics, nil),
+		newListener("full", scopeAll, nil),
+	}
+	require.NoError(t, endpoints.Up(config))
+
+	address := endpoints.ListenerAddress("metrics")
+	assert.Equal(t, http.StatusOK, httpStatusOverTLSSocket(t, address, config.Cert, "/1.0/metrics"))
+	assert.Equal(t, http.StatusForbidden, httpStatusOverTLSSocket(t, address, config.Cert, "/1.0/instances"))
+
+	address = endpoints.ListenerAddress("full")
+	assert.Equal(t, http.StatusOK, httpStatusOverTLSSocket(t, address, config.Cert, "/1.0/metrics"))
+	assert.Equal(t, http.StatusOK, httpStatusOverTLSSocket(t, address, config.Cert, "/1.0/instances"))
+}
+
+// Each additional listener can use its own TLS keypair, the others follow the
+// network keypair.
+func TestEndpoints_ListenersCert(t *testing.T) {
+	endpoints, config, cleanup := newEndpoints(t)
+	defer cleanup()
+
+	altCert := shared.TestingAltKeyPair()
+	config.NetworkAddress = "127.0.0.1:0"
+	config.Listeners = []listener{
+		newListener("own", scopeMetrics, altCert),
+		newListener("shared", scopeMetrics, nil),
+	}
+	require.NoError(t, endpoints.Up(config))
+
+	assert.NoError(t, httpGetOverTLSSocket(endpoints.ListenerAddress("own"), altCert))
+	assert.Error(t, httpGetOverTLSSocket(endpoints.ListenerAddress("own"), config.Cert))
+	assert.NoError(t, httpGetOverTLSSocket(endpoints.ListenerAddress("shared"), config.Cert))
+
+	// Updating the network keypair only affects the listeners without their own.
+	endpoints.NetworkUpdateCert(altCert)
+	assert.NoError(t, httpGetOverTLSSocket(endpoints.ListenerAddress("shared"), altCert))
+	assert.NoError(t, httpGetOverTLSSocket(endpoints.ListenerAddress("own"), altCert))
+
+	// The keypair of a listener can be changed without restarting it.
+	address := endpoints.ListenerAddress("own")
+	config.Listeners[0].Cert = config.Cert
+	require.NoError(t, endpoints.ListenersUpdate(config.Listeners))
+	assert.Equal(t, address, endpoints.ListenerAddress("own"))
+	assert.NoError(t, httpGetOverTLSSocket(address, config.Cert))
+}
+
+// Updating the listeners starts the new ones, restarts the ones whose address
+// or scope changed and stops the ones which are gone.
+func TestEndpoints_ListenersUpdate(t *testing.T) {
+	endpoints, config, cleanup := newEndpoints(t)
+	defer cleanup()
+
+	config.Listeners = []listener{
+		newListener("kept", scopeMetrics, nil),
+		newListener("changed", scopeMetrics, nil),
+		newListener("removed", scopeMetrics, nil),
+	}
+	require.NoError(t, endpoints.Up(config))
+
+	kept := endpoints.ListenerAddress("kept")
+	changed := endpoints.ListenerAddress("changed")
+	removed := endpoints.ListenerAddress("removed")
+
+	err := endpoints.ListenersUpdate([]listener{
+		newListener("kept", scopeMetrics, nil),
+		newListener("changed", scopeAll, nil),
+		newListener("added", scopeMetrics, nil),
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, kept, endpoints.ListenerAddress("kept"))
+	assert.NotEqual(t, changed, endpoints.ListenerAddress("changed"))
+	assert.Equal(t, "", endpoints.ListenerAddress("removed"))
+	assert.NotEqual(t, "", endpoints.ListenerAddress("added"))
+
+	assert.Error(t, httpGetOverTLSSocket(removed, config.Cert))
+	assert.Equal(t, http.StatusOK, httpStatusOverTLSSocket(t, endpoints.ListenerAddress("changed"), config.Cert, "/1.0/instances"))
+
+	require.NoError(t, endpoints.ListenersUpdate(nil))
+	assert.Equal(t, "", endpoints.ListenerAddress("kept"))
+	assert.Error(t, httpGetOverTLSSocket(kept, config.Cert))
+}
+
+// Scopes of the additional listeners, as the package name is shadowed in tests.
+const (
+	scopeAll     = endpoints.ListenerScopeAll
+	scopeMetrics = endpoints.ListenerScopeMetrics
+)
+
+type listener = endpoints.Listener
+
+// Return an additional listener bound to a random local port.
+func newListener(name string, scope string, cert *shared.CertInfo) listener {
+	return listener{Name: name, Scope: scope, Address: "127.0.0.1:0", Cert: cert}
+}
+
+// Perform an HTTP GET of the given path over TLS and return the status code.
+func httpStatusOverTLSSocket(t *testing.T, addr string, cert *shared.CertInfo, path string) int {
+	tlsConfig, _ := shared.GetTLSConfigMem("", "", "", string(cert.PublicKey()), false)
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}}
+	resp, err := client.Get(fmt.Sprintf("https://%s%s", addr, path))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	return resp.StatusCode
+}
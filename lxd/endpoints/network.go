@@ -149,6 +149,13 @@ func (e *Endpoints) NetworkUpdateCert(cert *shared.CertInfo) {
 	if ok {
 		listener.(*networkListener).Config(cert)
 	}
+
+	// Update the additional listeners too, unless they have their own keypair.
+	for _, extra := range e.extra {
+		if extra.config.Cert == nil {
+			extra.listener.Config(cert)
+		}
+	}
 }
 
 // NetworkUpdateTrustedProxy updates the https trusted proxy used by the network
@@ -62,6 +62,29 @@ func TestEndpoints_NetworkSocketBasedActivation(t *testing.T) {
 	assert.NoError(t, httpGetOverTLSSocket(endpoints.NetworkAddressAndCert()))
 }
 
+// If socket-based activation is detected and the inherited socket is bound to
+// the configured network address, it's kept rather than replaced.
+func TestEndpoints_NetworkSocketBasedActivationMatchingAddress(t *testing.T) {
+	endpoints, config, cleanup := newEndpoints(t)
+	defer cleanup()
+
+	listener := newTCPListener(t)
+	defer listener.Close()
+
+	file, err := listener.File()
+	require.NoError(t, err)
+
+	setupSocketBasedActivation(endpoints, file)
+
+	config.NetworkAddress = listener.Addr().String()
+	require.NoError(t, endpoints.Up(config))
+
+	assertNoSocketBasedActivation(t)
+
+	assert.Equal(t, listener.Addr().String(), endpoints.NetworkAddress())
+	assert.NoError(t, httpGetOverTLSSocket(endpoints.NetworkAddressAndCert()))
+}
+
 // When the network address is updated, any previous network socket gets
 // closed.
 func TestEndpoints_NetworkUpdateAddress(t *testing.T) {
@@ -2,20 +2,39 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+
+	"github.com/lxc/lxd/lxd/cluster"
 	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/events"
+	"github.com/lxc/lxd/lxd/logshipping"
+	"github.com/lxc/lxd/lxd/operations"
 	"github.com/lxc/lxd/lxd/rbac"
 	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/lxd/task"
+	"github.com/lxc/lxd/lxd/util"
 	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	log "github.com/lxc/lxd/shared/log15"
 	"github.com/lxc/lxd/shared/logger"
 )
 
 var eventTypes = []string{"logging", "operation", "lifecycle"}
 var privilegedEventTypes = []string{"logging"}
 
+// Event types and logging levels which get recorded in the database.
+var eventsHistoryTypes = []string{"logging", "lifecycle"}
+var eventsHistoryLogLevels = []string{"warn", "eror", "crit"}
+
 var eventsCmd = APIEndpoint{
 	Path: "events",
 
@@ -35,33 +54,99 @@ func (r *eventsServe) String() string {
 	return "event handler"
 }
 
-func eventsSocket(d *Daemon, r *http.Request, w http.ResponseWriter) error {
-	projectName := projectParam(r)
+// eventsTypesParam returns the event types requested by the client, checking that they are valid and that
+// the client is allowed to see them.
+func eventsTypesParam(r *http.Request) ([]string, response.Response) {
 	types := strings.Split(r.FormValue("type"), ",")
 	if len(types) == 1 && types[0] == "" {
-		types = []string{}
-		for _, entry := range eventTypes {
-			if !rbac.UserIsAdmin(r) && shared.StringInSlice(entry, privilegedEventTypes) {
-				continue
-			}
+		types = eventsDefaultTypes(r)
+	}
+
+	resp := eventsValidateTypes(r, types)
+	if resp != nil {
+		return nil, resp
+	}
+
+	return types, nil
+}
 
-			types = append(types, entry)
+// eventsDefaultTypes returns all the event types the client is allowed to see.
+func eventsDefaultTypes(r *http.Request) []string {
+	types := []string{}
+	for _, entry := range eventTypes {
+		if !rbac.UserIsAdmin(r) && shared.StringInSlice(entry, privilegedEventTypes) {
+			continue
 		}
+
+		types = append(types, entry)
 	}
 
-	// Validate event types.
+	return types
+}
+
+// eventsValidateTypes checks that the event types are valid and that the client is allowed to see them.
+func eventsValidateTypes(r *http.Request, types []string) response.Response {
 	for _, entry := range types {
 		if !shared.StringInSlice(entry, eventTypes) {
-			response.BadRequest(fmt.Errorf("'%s' isn't a supported event type", entry)).Render(w)
-			return nil
+			return response.BadRequest(fmt.Errorf("'%s' isn't a supported event type", entry))
 		}
 	}
 
 	if shared.StringInSlice("logging", types) && !rbac.UserIsAdmin(r) {
-		response.Forbidden(nil).Render(w)
+		return response.Forbidden(nil)
+	}
+
+	return nil
+}
+
+// eventsProjectParam returns the project whose events are requested by the client ("*" for all projects),
+// checking that the client is allowed to see them.
+func eventsProjectParam(r *http.Request) (string, response.Response) {
+	projectName := projectParam(r)
+	if projectName == "*" {
+		if !rbac.UserIsAdmin(r) {
+			return "", response.Forbidden(nil)
+		}
+	} else if !rbac.UserHasPermission(r, projectName, "view") {
+		return "", response.Forbidden(nil)
+	}
+
+	return projectName, nil
+}
+
+// eventsResourcesParam returns the resources (API URLs) whose events are requested by the client.
+func eventsResourcesParam(r *http.Request) []string {
+	resources := []string{}
+	if r.FormValue("resource") != "" {
+		resources = util.SplitNTrimSpace(r.FormValue("resource"), ",", -1, true)
+	}
+
+	return resources
+}
+
+func eventsSocket(d *Daemon, r *http.Request, w http.ResponseWriter) error {
+	projectName, resp := eventsProjectParam(r)
+	if resp != nil {
+		resp.Render(w)
 		return nil
 	}
 
+	types, resp := eventsTypesParam(r)
+	if resp != nil {
+		resp.Render(w)
+		return nil
+	}
+
+	var after uint64
+	if r.FormValue("after") != "" {
+		var err error
+		after, err = strconv.ParseUint(r.FormValue("after"), 10, 64)
+		if err != nil {
+			response.BadRequest(errors.Wrapf(err, "Invalid after value %q", r.FormValue("after"))).Render(w)
+			return nil
+		}
+	}
+
 	// Upgrade the connection to websocket
 	c, err := shared.WebsocketUpgrader.Upgrade(w, r, nil)
 	if err != nil {
@@ -89,8 +174,16 @@ func eventsSocket(d *Daemon, r *http.Request, w http.ResponseWriter) error {
 		return err
 	}
 
+	listener.SetFilter(types, eventsResourcesParam(r))
 	logger.Debugf("New event listener: %s", listener.ID())
 
+	// Replay the events a reconnecting client missed, before the ones sent from now on.
+	if after > 0 {
+		d.events.Replay(listener, after)
+	}
+
+	d.events.Start(listener)
+
 	// Create a cancellable context from the request context. Once the request has been upgraded
 	// to a websocket the request's context doesn't appear to be cancelled when the client
 	// disconnects (even though its documented as such). But we wrap the request's context here
@@ -99,16 +192,35 @@ func eventsSocket(d *Daemon, r *http.Request, w http.ResponseWriter) error {
 
 	// Instead of relying on the request's context to be cancelled when the client connection
 	// is closed (see above), we instead enter into a repeat read loop of the connection in
-	// order to detect when the client connection is closed. This should be fine as for the
-	// events route there is no expectation to read any useful data from the client.
+	// order to detect when the client connection is closed. The only messages expected from
+	// the client are filters replacing the ones the listener was set up with.
 	go func() {
 		for {
-			_, _, err := c.NextReader()
+			_, reader, err := c.NextReader()
 			if err != nil {
 				// Client read error (likely premature close), so cancel context.
 				cancel()
 				return
 			}
+
+			filter := api.EventFilter{}
+			err = json.NewDecoder(reader).Decode(&filter)
+			if err != nil {
+				logger.Debug("Ignoring invalid event filter", log.Ctx{"listener": listener.ID(), "err": err})
+				continue
+			}
+
+			filterTypes := filter.Types
+			if len(filterTypes) == 0 {
+				filterTypes = eventsDefaultTypes(r)
+			}
+
+			if eventsValidateTypes(r, filterTypes) != nil {
+				logger.Debug("Ignoring event filter with disallowed types", log.Ctx{"listener": listener.ID(), "types": filterTypes})
+				continue
+			}
+
+			listener.SetFilter(filterTypes, filter.Resources)
 		}
 	}()
 
@@ -124,6 +236,10 @@ func eventsSocket(d *Daemon, r *http.Request, w http.ResponseWriter) error {
 //
 // Connects to the event API using websocket.
 //
+// Requests which aren't websocket upgrades instead get the list of recorded
+// lifecycle and logging events, optionally starting from the time given with
+// the `since` parameter.
+//
 // ---
 // produces:
 //   - application/json
@@ -138,6 +254,21 @@ func eventsSocket(d *Daemon, r *http.Request, w http.ResponseWriter) error {
 //     description: Event type(s), comma separated (valid types are logging, operation or lifecycle)
 //     type: string
 //     example: logging,lifecycle
+//   - in: query
+//     name: resource
+//     description: Resource(s) (API URLs), comma separated, to get events for
+//     type: string
+//     example: /1.0/instances/c1
+//   - in: query
+//     name: since
+//     description: Only return recorded events which occurred at or after this time (RFC3339)
+//     type: string
+//     example: 2021-03-14T00:00:00Z
+//   - in: query
+//     name: after
+//     description: Replay the recent events with a sequence number greater than this one before streaming new events
+//     type: integer
+//     example: 42
 // responses:
 //   "200":
 //     description: Websocket message (JSON)
@@ -148,5 +279,240 @@ func eventsSocket(d *Daemon, r *http.Request, w http.ResponseWriter) error {
 //   "500":
 //     $ref: "#/responses/InternalServerError"
 func eventsGet(d *Daemon, r *http.Request) response.Response {
+	if !websocket.IsWebSocketUpgrade(r) {
+		return eventsHistoryGet(d, r)
+	}
+
 	return &eventsServe{req: r, d: d}
 }
+
+// eventsHistoryGet returns the recorded events matching the request filters.
+func eventsHistoryGet(d *Daemon, r *http.Request) response.Response {
+	projectName, resp := eventsProjectParam(r)
+	if resp != nil {
+		return resp
+	}
+
+	types, resp := eventsTypesParam(r)
+	if resp != nil {
+		return resp
+	}
+
+	// Events not tied to any project (cluster, certificate or logging events) are only shown to admins.
+	filter := db.EventFilter{
+		Types:  types,
+		Global: rbac.UserIsAdmin(r),
+	}
+
+	if projectName != "*" {
+		filter.Project = &projectName
+	}
+
+	since := r.FormValue("since")
+	if since != "" {
+		var err error
+		filter.Since, err = time.Parse(time.RFC3339, since)
+		if err != nil {
+			return response.BadRequest(errors.Wrapf(err, "Invalid since value %q", since))
+		}
+	}
+
+	var dbEvents []db.Event
+	err := d.cluster.Transaction(func(tx *db.ClusterTx) error {
+		var err error
+		dbEvents, err = tx.GetEvents(filter)
+		return err
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	resources := eventsResourcesParam(r)
+
+	result := make([]api.Event, 0, len(dbEvents))
+	for _, dbEvent := range dbEvents {
+		event := api.Event{
+			Type:      dbEvent.Type,
+			Timestamp: dbEvent.Timestamp,
+			Metadata:  json.RawMessage(dbEvent.Metadata),
+			Location:  dbEvent.Node,
+		}
+
+		if !events.MatchesResources(event, resources) {
+			continue
+		}
+
+		result = append(result, event)
+	}
+
+	return response.SyncResponse(true, result)
+}
+
+// eventsHistoryHandler returns an event server history handler which queues the events that should be
+// kept for later retrieval and records them in the database in the background.
+func eventsHistoryHandler(d *Daemon) func(group string, event api.Event) {
+	queue := make(chan db.Event, 1024)
+
+	go func() {
+		for {
+			select {
+			case <-d.ctx.Done():
+				return
+			case dbEvent := <-queue:
+				err := d.cluster.Transaction(func(tx *db.ClusterTx) error {
+					return tx.CreateEvent(dbEvent)
+				})
+				if err != nil {
+					// Only log at debug level, as those log messages aren't recorded themselves.
+					logger.Debug("Failed recording event", log.Ctx{"type": dbEvent.Type, "err": err})
+				}
+			}
+		}
+	}()
+
+	return func(group string, event api.Event) {
+		if !shared.StringInSlice(event.Type, eventsHistoryTypes) {
+			return
+		}
+
+		if event.Type == "logging" {
+			logEntry := api.EventLogging{}
+			err := json.Unmarshal(event.Metadata, &logEntry)
+			if err != nil || !shared.StringInSlice(logEntry.Level, eventsHistoryLogLevels) {
+				return
+			}
+		}
+
+		dbEvent := db.Event{
+			Project:   group,
+			Type:      event.Type,
+			Timestamp: event.Timestamp,
+			Metadata:  string(event.Metadata),
+		}
+
+		// Never block the sender, drop the event if the database can't keep up.
+		select {
+		case queue <- dbEvent:
+		default:
+		}
+	}
+}
+
+// eventsShippingHandler returns an event server handler which ships the lifecycle events and the log
+// messages at or above the given level to a remote server.
+func eventsShippingHandler(d *Daemon, client logshipping.Client, level log.Lvl) func(group string, event api.Event) {
+	var location string
+	err := d.cluster.Transaction(func(tx *db.ClusterTx) error {
+		var err error
+		location, err = tx.GetLocalNodeName()
+		return err
+	})
+	if err != nil {
+		logger.Warn("Failed to get local member name", log.Ctx{"err": err})
+	}
+
+	return func(group string, event api.Event) {
+		entry := logshipping.Entry{
+			Timestamp: event.Timestamp,
+			Labels:    map[string]string{"location": location, "type": event.Type},
+		}
+
+		switch event.Type {
+		case "logging":
+			logEntry := api.EventLogging{}
+			err := json.Unmarshal(event.Metadata, &logEntry)
+			if err != nil {
+				return
+			}
+
+			lvl, err := log.LvlFromString(logEntry.Level)
+			if err != nil || lvl > level {
+				return
+			}
+
+			entry.Level = logEntry.Level
+			entry.Message = logEntry.Message
+
+			ctx := make([]string, 0, len(logEntry.Context))
+			for k, v := range logEntry.Context {
+				// Tag the entries related to a project or instance.
+				if k == "project" || k == "instance" {
+					entry.Labels[k] = v
+					continue
+				}
+
+				ctx = append(ctx, fmt.Sprintf("%s=%q", k, v))
+			}
+
+			if len(ctx) > 0 {
+				sort.Strings(ctx)
+				entry.Message = fmt.Sprintf("%s %s", entry.Message, strings.Join(ctx, " "))
+			}
+		case "lifecycle":
+			lifecycleEntry := api.EventLifecycle{}
+			err := json.Unmarshal(event.Metadata, &lifecycleEntry)
+			if err != nil {
+				return
+			}
+
+			entry.Level = log.LvlInfo.String()
+			entry.Message = fmt.Sprintf("%s %s", lifecycleEntry.Action, lifecycleEntry.Source)
+
+			if lifecycleEntry.Requestor != nil && lifecycleEntry.Requestor.Username != "" {
+				entry.Message = fmt.Sprintf("%s requestor=%q", entry.Message, lifecycleEntry.Requestor.Username)
+			}
+
+			if group != "" {
+				entry.Labels["project"] = group
+			}
+
+			path := strings.SplitN(lifecycleEntry.Source, "?", 2)[0]
+			if strings.HasPrefix(path, "/1.0/instances/") {
+				entry.Labels["instance"] = strings.SplitN(strings.TrimPrefix(path, "/1.0/instances/"), "/", 2)[0]
+			}
+		default:
+			return
+		}
+
+		client.Send(entry)
+	}
+}
+
+func pruneExpiredEventsTask(d *Daemon) (task.Func, task.Schedule) {
+	f := func(ctx context.Context) {
+		opRun := func(op *operations.Operation) error {
+			return pruneExpiredEvents(ctx, d)
+		}
+
+		op, err := operations.OperationCreate(d.State(), "", operations.OperationClassTask, db.OperationEventsExpire, nil, nil, opRun, nil, nil, nil)
+		if err != nil {
+			logger.Error("Failed to start expired events operation", log.Ctx{"err": err})
+			return
+		}
+
+		logger.Info("Pruning expired events")
+		_, err = op.Run()
+		if err != nil {
+			logger.Error("Failed to expire events", log.Ctx{"err": err})
+		}
+		logger.Info("Done pruning expired events")
+	}
+
+	return f, task.Daily()
+}
+
+func pruneExpiredEvents(ctx context.Context, d *Daemon) error {
+	err := d.cluster.Transaction(func(tx *db.ClusterTx) error {
+		config, err := cluster.ConfigLoad(tx)
+		if err != nil {
+			return err
+		}
+
+		return tx.DeleteEventsBefore(time.Now().Add(-config.EventsExpiry()))
+	})
+	if err != nil {
+		return errors.Wrap(err, "Failed to delete expired events")
+	}
+
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
@@ -15,6 +16,20 @@ import (
 	"github.com/lxc/lxd/shared/logger"
 )
 
+// replayBufferSize is the number of recent events kept to be replayed to reconnecting listeners.
+const replayBufferSize = 1024
+
+// listenerQueueSize is the number of events which can be waiting to be sent to a listener. Listeners falling
+// further behind are disconnected, clients can then reconnect and get the events they missed replayed.
+const listenerQueueSize = replayBufferSize
+
+// replayEntry is an event kept to be replayed to reconnecting listeners.
+type replayEntry struct {
+	group     string
+	event     api.Event
+	isForward bool
+}
+
 // Server represents an instance of an event server.
 type Server struct {
 	debug   bool
@@ -22,6 +37,15 @@ type Server struct {
 
 	listeners map[string]*Listener
 	lock      sync.Mutex
+
+	handlers map[string]func(group string, event api.Event)
+
+	// Returns the name of the local cluster member, recorded in lifecycle events.
+	location func() string
+
+	// Sequence number of the last event and recent events which can be replayed.
+	sequence uint64
+	replay   []replayEntry
 }
 
 // NewServer returns a new event server.
@@ -30,6 +54,7 @@ func NewServer(debug bool, verbose bool) *Server {
 		debug:     debug,
 		verbose:   verbose,
 		listeners: map[string]*Listener{},
+		handlers:  map[string]func(group string, event api.Event){},
 	}
 
 	return server
@@ -45,6 +70,7 @@ func (s *Server) AddListener(group string, connection *websocket.Conn, messageTy
 		noForward:    noForward,
 		active:       make(chan bool, 1),
 		id:           uuid.New(),
+		pending:      make(chan struct{}, 1),
 	}
 
 	s.lock.Lock()
@@ -54,13 +80,50 @@ func (s *Server) AddListener(group string, connection *websocket.Conn, messageTy
 		return nil, fmt.Errorf("A listener with id '%s' already exists", listener.id)
 	}
 
+	listener.startSequence = s.sequence
 	s.listeners[listener.id] = listener
 
 	return listener, nil
 }
 
-// SendLifecycle broadcasts a lifecycle event.
+// SetHandler sets a named function to be called with every event sent by this server, so that it can
+// be recorded or shipped elsewhere. Events forwarded from other cluster members are not passed to it.
+// Setting a nil handler removes it.
+func (s *Server) SetHandler(name string, handler func(group string, event api.Event)) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if handler == nil {
+		delete(s.handlers, name)
+		return
+	}
+
+	s.handlers[name] = handler
+}
+
+// SetLocation sets the function returning the name of the local cluster member, which is recorded in the
+// lifecycle events sent by this server.
+func (s *Server) SetLocation(location func() string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.location = location
+}
+
+// SendLifecycle broadcasts a lifecycle event, recording the project and the cluster member it relates to.
 func (s *Server) SendLifecycle(group string, event api.EventLifecycle) {
+	if event.Project == "" {
+		event.Project = group
+	}
+
+	s.lock.Lock()
+	location := s.location
+	s.lock.Unlock()
+
+	if event.Location == "" && location != nil {
+		event.Location = location()
+	}
+
 	s.Send(group, "lifecycle", event)
 }
 
@@ -76,6 +139,17 @@ func (s *Server) Send(group, eventType string, eventMessage interface{}) error {
 		Metadata:  encodedMessage,
 	}
 
+	s.lock.Lock()
+	handlers := make([]func(group string, event api.Event), 0, len(s.handlers))
+	for _, handler := range s.handlers {
+		handlers = append(handlers, handler)
+	}
+	s.lock.Unlock()
+
+	for _, handler := range handlers {
+		handler(group, event)
+	}
+
 	return s.broadcast(group, event, false)
 }
 
@@ -104,50 +178,51 @@ func (s *Server) Forward(id int64, event api.Event) {
 	}
 }
 
-func (s *Server) broadcast(group string, event api.Event, isForward bool) error {
-	s.lock.Lock()
-	listeners := s.listeners
-	for _, listener := range listeners {
-		if group != "" && listener.group != "*" && group != listener.group {
-			continue
-		}
+// EventsLostAction is the action of the lifecycle event sent first when replaying events to a listener
+// which missed events that can't be replayed anymore.
+const EventsLostAction = "events-lost"
 
-		if isForward && listener.noForward {
-			continue
-		}
+// Replay queues for the listener the events which were sent after the given sequence number and before
+// the listener was added, so that a client reconnecting doesn't miss any event. Only the most recent
+// events are kept. If older ones were missed (or the sequence restarted along with the daemon), an
+// "events-lost" lifecycle event is sent first, whatever the event types the listener is interested in.
+// Must be called before the listener is started.
+func (s *Server) Replay(listener *Listener, after uint64) {
+	events := s.replayEvents(listener, after)
 
-		if !shared.StringInSlice(event.Type, listener.messageTypes) {
-			continue
-		}
+	listener.queueLock.Lock()
+	defer listener.queueLock.Unlock()
 
-		go func(listener *Listener, event api.Event) {
-			// Check that the listener still exists
-			if listener == nil {
-				return
-			}
+	replayed := make([]queuedEvent, 0, len(events)+len(listener.queue))
+	for _, event := range events {
+		replayed = append(replayed, queuedEvent{event: event, replayed: true})
+	}
 
-			// Ensure there is only a single even going out at the time
-			listener.lock.Lock()
-			defer listener.lock.Unlock()
+	listener.queue = append(replayed, listener.queue...)
+}
 
-			// Make sure we're not done already
-			if listener.done {
-				return
-			}
+// Start starts sending the queued events to the listener, in sequence order, and the ones broadcast later.
+func (s *Server) Start(listener *Listener) {
+	go s.send(listener)
 
-			// Set the Location to the expected serverName
-			if event.Location == "" {
-				eventCopy := api.Event{}
-				err := shared.DeepCopy(&event, &eventCopy)
-				if err != nil {
-					return
-				}
-				eventCopy.Location = listener.location
+	listener.wake()
+}
 
-				event = eventCopy
+// send sends the events queued for the listener, one at a time, until the connection fails.
+func (s *Server) send(listener *Listener) {
+	for range listener.pending {
+		for {
+			listener.queueLock.Lock()
+			if len(listener.queue) == 0 {
+				listener.queueLock.Unlock()
+				break
 			}
 
-			err := listener.connection.WriteJSON(event)
+			next := listener.queue[0]
+			listener.queue = listener.queue[1:]
+			listener.queueLock.Unlock()
+
+			err := s.sendEvent(listener, next)
 			if err != nil {
 				// Remove the listener from the list
 				s.lock.Lock()
@@ -156,22 +231,157 @@ func (s *Server) broadcast(group string, event api.Event, isForward bool) error
 
 				// Disconnect the listener
 				listener.connection.Close()
+
+				listener.lock.Lock()
 				listener.active <- false
 				listener.done = true
+				listener.lock.Unlock()
+
 				logger.Debugf("Disconnected event listener: %s", listener.id)
+
+				return
 			}
-		}(listener, event)
+		}
+	}
+}
+
+// sendEvent sends an event to the listener if it's interested in it. Replayed events were already filtered.
+func (s *Server) sendEvent(listener *Listener, next queuedEvent) error {
+	// Ensure there is only a single event going out at the time
+	listener.lock.Lock()
+	defer listener.lock.Unlock()
+
+	// Make sure we're not done already
+	if listener.done {
+		return nil
+	}
+
+	event := next.event
+	if !next.replayed {
+		// Check that the listener is interested in the event
+		if !shared.StringInSlice(event.Type, listener.messageTypes) || !MatchesResources(event, listener.resources) {
+			return nil
+		}
+
+		// Set the Location to the expected serverName
+		if event.Location == "" {
+			eventCopy := api.Event{}
+			err := shared.DeepCopy(&event, &eventCopy)
+			if err != nil {
+				return nil
+			}
+			eventCopy.Location = listener.location
+
+			event = eventCopy
+		}
+	}
+
+	return listener.connection.WriteJSON(event)
+}
+
+// replayEvents returns the events to replay to the listener, preceded by an "events-lost" event if some
+// of the events it missed can't be replayed.
+func (s *Server) replayEvents(listener *Listener, after uint64) []api.Event {
+	s.lock.Lock()
+	entries := []replayEntry{}
+	for _, entry := range s.replay {
+		if entry.event.Sequence > after && entry.event.Sequence <= listener.startSequence {
+			entries = append(entries, entry)
+		}
+	}
+
+	lost := after > listener.startSequence || (len(s.replay) > 0 && s.replay[0].event.Sequence > after+1)
+	s.lock.Unlock()
+
+	events := []api.Event{}
+
+	if lost {
+		metadata, _ := json.Marshal(api.EventLifecycle{
+			Action:   EventsLostAction,
+			Source:   "/1.0/events",
+			Context:  map[string]interface{}{"after": after},
+			Location: listener.location,
+		})
+
+		events = append(events, api.Event{
+			Type:      "lifecycle",
+			Timestamp: time.Now(),
+			Metadata:  metadata,
+			Location:  listener.location,
+		})
+	}
+
+	for _, entry := range entries {
+		if entry.group != "" && listener.group != "*" && entry.group != listener.group {
+			continue
+		}
+
+		if entry.isForward && listener.noForward {
+			continue
+		}
+
+		event := entry.event
+		if !shared.StringInSlice(event.Type, listener.messageTypes) || !MatchesResources(event, listener.resources) {
+			continue
+		}
+
+		if event.Location == "" {
+			event.Location = listener.location
+		}
+
+		events = append(events, event)
+	}
+
+	return events
+}
+
+func (s *Server) broadcast(group string, event api.Event, isForward bool) error {
+	s.lock.Lock()
+
+	// Number the event and keep it for listeners reconnecting later.
+	s.sequence++
+	event.Sequence = s.sequence
+
+	s.replay = append(s.replay, replayEntry{group: group, event: event, isForward: isForward})
+	if len(s.replay) > replayBufferSize {
+		s.replay = s.replay[len(s.replay)-replayBufferSize:]
+	}
+
+	listeners := s.listeners
+	for _, listener := range listeners {
+		if group != "" && listener.group != "*" && group != listener.group {
+			continue
+		}
+
+		if isForward && listener.noForward {
+			continue
+		}
+
+		// Queue the event while holding the server lock, so that the listener gets it in sequence order.
+		if !listener.enqueue(event) {
+			// Disconnect the listener which can't keep up, its sender fails once the connection is closed.
+			delete(s.listeners, listener.id)
+			listener.connection.Close()
+			logger.Debugf("Disconnected event listener falling behind: %s", listener.id)
+		}
 	}
 	s.lock.Unlock()
 
 	return nil
 }
 
+// queuedEvent is an event waiting to be sent to a listener.
+type queuedEvent struct {
+	event    api.Event
+	replayed bool
+}
+
 // Listener describes an event listener.
 type Listener struct {
 	group        string
 	connection   *websocket.Conn
 	messageTypes []string
+	resources    []string
 	active       chan bool
 	id           string
 	lock         sync.Mutex
@@ -182,6 +392,38 @@ type Listener struct {
 	// nodes. It only used by listeners created internally by LXD nodes
 	// connecting to other LXD nodes to get their local events only.
 	noForward bool
+
+	// Sequence number of the last event sent before the listener was added.
+	startSequence uint64
+
+	// Events waiting to be sent, in sequence order, and the channel waking up the sender once some are queued.
+	queue     []queuedEvent
+	queueLock sync.Mutex
+	pending   chan struct{}
+}
+
+// enqueue queues an event to be sent to the listener. It returns false if the listener has too many events
+// waiting already.
+func (e *Listener) enqueue(event api.Event) bool {
+	e.queueLock.Lock()
+	defer e.queueLock.Unlock()
+
+	if len(e.queue) >= listenerQueueSize {
+		return false
+	}
+
+	e.queue = append(e.queue, queuedEvent{event: event})
+	e.wake()
+
+	return true
+}
+
+// wake wakes up the sender of the listener, if it isn't already going to check the queue.
+func (e *Listener) wake() {
+	select {
+	case e.pending <- struct{}{}:
+	default:
+	}
 }
 
 // MessageTypes returns a list of message types the listener will be notified of.
@@ -189,6 +431,15 @@ func (e *Listener) MessageTypes() []string {
 	return e.messageTypes
 }
 
+// SetFilter replaces the message types and resources (API URLs) the listener will be notified of.
+// An empty list of resources means events for all resources.
+func (e *Listener) SetFilter(messageTypes []string, resources []string) {
+	e.lock.Lock()
+	e.messageTypes = messageTypes
+	e.resources = resources
+	e.lock.Unlock()
+}
+
 // IsDone returns true if the listener is done.
 func (e *Listener) IsDone() bool {
 	return e.done
@@ -227,3 +478,49 @@ func (e *Listener) Deactivate() {
 	e.active <- false
 	e.done = true
 }
+
+// MatchesResources returns whether the event relates to one of the resources (API URLs) or any resource
+// below them. Lifecycle events are matched on their source and operation events on their resources.
+// Other events don't relate to any resource and only match an empty list of resources.
+func MatchesResources(event api.Event, resources []string) bool {
+	if len(resources) == 0 {
+		return true
+	}
+
+	var urls []string
+
+	switch event.Type {
+	case "lifecycle":
+		lifecycle := api.EventLifecycle{}
+		err := json.Unmarshal(event.Metadata, &lifecycle)
+		if err != nil {
+			return false
+		}
+
+		urls = append(urls, lifecycle.Source)
+	case "operation":
+		op := api.Operation{}
+		err := json.Unmarshal(event.Metadata, &op)
+		if err != nil {
+			return false
+		}
+
+		for _, entries := range op.Resources {
+			urls = append(urls, entries...)
+		}
+	}
+
+	for _, u := range urls {
+		// Ignore the query string (project) as the listener's project has already been matched.
+		u = strings.SplitN(u, "?", 2)[0]
+
+		for _, resource := range resources {
+			resource = strings.TrimSuffix(resource, "/")
+			if u == resource || strings.HasPrefix(u, resource+"/") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,138 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lxc/lxd/shared/api"
+)
+
+func ExampleMatchesResources() {
+	lifecycle, _ := json.Marshal(api.EventLifecycle{Action: "instance-started", Source: "/1.0/instances/c1?project=foo"})
+	snapshot, _ := json.Marshal(api.EventLifecycle{Action: "instance-snapshot-created", Source: "/1.0/instances/c1/snapshots/snap0"})
+	op, _ := json.Marshal(api.Operation{Resources: map[string][]string{"instances": {"/1.0/instances/c2"}}})
+	logging, _ := json.Marshal(api.EventLogging{Message: "Hello", Level: "info"})
+
+	events := []api.Event{
+		{Type: "lifecycle", Metadata: lifecycle},
+		{Type: "lifecycle", Metadata: snapshot},
+		{Type: "operation", Metadata: op},
+		{Type: "logging", Metadata: logging},
+	}
+
+	for _, event := range events {
+		fmt.Printf("%s: %v %v %v\n", event.Type,
+			MatchesResources(event, nil),
+			MatchesResources(event, []string{"/1.0/instances/c1"}),
+			MatchesResources(event, []string{"/1.0/instances/c", "/1.0/instances/c2/"}))
+	}
+
+	// Output: lifecycle: true true false
+	// lifecycle: true true false
+	// operation: true false true
+	// logging: true false false
+}
+
+func ExampleServer_SendLifecycle() {
+	server := NewServer(false, false)
+	server.SetLocation(func() string { return "lxd01" })
+	server.SetHandler("example", func(group string, event api.Event) {
+		fmt.Printf("%s: %s\n", group, event.Metadata)
+	})
+
+	server.SendLifecycle("foo", api.EventLifecycle{Action: "instance-started", Source: "/1.0/instances/c1?project=foo"})
+
+	// Output: foo: {"action":"instance-started","source":"/1.0/instances/c1?project=foo","project":"foo","location":"lxd01"}
+}
+
+func ExampleServer_replayEvents() {
+	server := NewServer(false, false)
+	for i := 0; i < replayBufferSize+10; i++ {
+		server.Send("default", "lifecycle", api.EventLifecycle{Action: "instance-started", Source: fmt.Sprintf("/1.0/instances/c%d", i)})
+	}
+
+	listener := &Listener{group: "default", messageTypes: []string{"lifecycle"}, location: "lxd01", startSequence: server.sequence}
+
+	// The requested events are still in the buffer.
+	fmt.Println(len(server.replayEvents(listener, server.sequence-5)))
+
+	// Some of the requested events were dropped.
+	events := server.replayEvents(listener, 5)
+	fmt.Printf("%d %s %s\n", len(events), events[0].Type, events[0].Metadata)
+
+	// The sequence restarted.
+	events = server.replayEvents(listener, server.sequence+5)
+	fmt.Printf("%d %s\n", len(events), events[0].Type)
+
+	// Output: 5
+	// 1025 lifecycle {"action":"events-lost","source":"/1.0/events","context":{"after":5},"location":"lxd01"}
+	// 1 lifecycle
+}
+
+// Test that the events are delivered to listeners in sequence order, after the replayed ones.
+func TestServer_Ordering(t *testing.T) {
+	server := NewServer(false, false)
+	for i := 0; i < 10; i++ {
+		server.Send("default", "lifecycle", api.EventLifecycle{Action: "instance-started", Source: fmt.Sprintf("/1.0/instances/r%d", i)})
+	}
+
+	ready := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upgrader := websocket.Upgrader{}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		listener, err := server.AddListener("default", conn, []string{"lifecycle"}, "lxd01", false)
+		if err != nil {
+			return
+		}
+
+		server.Replay(listener, 5)
+		server.Start(listener)
+		close(ready)
+
+		listener.Wait(r.Context())
+	}))
+	defer ts.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http"), nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	<-ready
+
+	count := 500
+	done := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		go func() {
+			for j := 0; j < count/4; j++ {
+				server.Send("default", "lifecycle", api.EventLifecycle{Action: "instance-started", Source: "/1.0/instances/c1"})
+			}
+
+			done <- struct{}{}
+		}()
+	}
+
+	for i := 0; i < 4; i++ {
+		<-done
+	}
+
+	// The replayed events come first, then all the others in sequence order.
+	for i := uint64(6); i <= uint64(10+count); i++ {
+		event := api.Event{}
+		require.NoError(t, conn.ReadJSON(&event))
+		assert.Equal(t, i, event.Sequence)
+		assert.Equal(t, "lxd01", event.Location)
+	}
+}
@@ -18,6 +18,7 @@ func TestParse_Error(t *testing.T) {
 		"foo eq bar and":         "unterminated compound clause",
 		"foo eq \"bar egg\" and": "unterminated compound clause",
 		"foo eq bar xxx":         "invalid clause composition",
+		"foo is bar":             "invalid operator \"is\"",
 	}
 	for s, message := range cases {
 		t.Run(s, func(t *testing.T) {
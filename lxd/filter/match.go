@@ -1,12 +1,17 @@
 package filter
 
+import (
+	"fmt"
+	"time"
+)
+
 // Match returns true if the given object matches the given filter.
 func Match(obj interface{}, clauses []Clause) bool {
 	match := true
 
 	for _, clause := range clauses {
 		value := ValueOf(obj, clause.Field)
-		clauseMatch := value == clause.Value
+		clauseMatch := value != nil && valueString(value) == clause.Value
 
 		if clause.Operator == "ne" {
 			clauseMatch = !clauseMatch
@@ -29,3 +34,15 @@ func Match(obj interface{}, clauses []Clause) bool {
 
 	return match
 }
+
+// valueString returns the string representation of a field value to compare against a clause value.
+func valueString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case time.Time:
+		return v.Format(time.RFC3339)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
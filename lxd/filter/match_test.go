@@ -40,6 +40,10 @@ func TestMatch_Instance(t *testing.T) {
 		"config.image.os eq BusyBox and expanded_devices.root.path eq /": true,
 		"name eq c2 or status eq Running":                                true,
 		"name eq c2 or name eq c3":                                       false,
+		"stateful eq false":                                              true,
+		"created_at eq 2020-01-29T11:10:32Z":                             true,
+		"name ne c1":                                                     false,
+		"unknown eq foo":                                                 false,
 	}
 	for s := range cases {
 		t.Run(s, func(t *testing.T) {
@@ -47,6 +51,10 @@ func TestMatch_Instance(t *testing.T) {
 			require.NoError(t, err)
 			match := filter.Match(instance, f)
 			assert.Equal(t, cases[s], match)
+
+			// Pointers to objects are matched the same way.
+			match = filter.Match(&instance, f)
+			assert.Equal(t, cases[s], match)
 		})
 	}
 
@@ -8,6 +8,19 @@ import (
 // ValueOf returns the value of the given field.
 func ValueOf(obj interface{}, field string) interface{} {
 	value := reflect.ValueOf(obj)
+	if !value.IsValid() {
+		return nil
+	}
+
+	// Look through pointers.
+	if value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return nil
+		}
+
+		return ValueOf(value.Elem().Interface(), field)
+	}
+
 	typ := value.Type()
 	parts := strings.Split(field, ".")
 
@@ -16,6 +29,10 @@ func ValueOf(obj interface{}, field string) interface{} {
 
 	var parent interface{}
 
+	if value.Kind() != reflect.Map && value.Kind() != reflect.Struct {
+		return nil
+	}
+
 	if value.Kind() == reflect.Map {
 		switch reflect.TypeOf(obj).Elem().Kind() {
 		case reflect.String:
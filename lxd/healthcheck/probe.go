@@ -0,0 +1,39 @@
+package healthcheck
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+// Probe checks that the TCP (host:port) or HTTP (URL) endpoint at address responds within the timeout.
+// HTTP endpoints must respond with a status code below 400.
+func Probe(probeType string, address string, timeout time.Duration) error {
+	switch probeType {
+	case "tcp":
+		conn, err := net.DialTimeout("tcp", address, timeout)
+		if err != nil {
+			return err
+		}
+
+		return conn.Close()
+	case "http":
+		client := &http.Client{Timeout: timeout}
+
+		resp, err := client.Get(address)
+		if err != nil {
+			return err
+		}
+
+		defer resp.Body.Close()
+
+		if resp.StatusCode >= http.StatusBadRequest {
+			return fmt.Errorf("Unexpected HTTP status %q", resp.Status)
+		}
+
+		return nil
+	default:
+		return fmt.Errorf("Unknown probe type %q", probeType)
+	}
+}
@@ -0,0 +1,34 @@
+package healthcheck
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProbe(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/broken" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	assert.NoError(t, Probe("http", server.URL, time.Second))
+	assert.EqualError(t, Probe("http", server.URL+"/broken", time.Second), `Unexpected HTTP status "503 Service Unavailable"`)
+	assert.NoError(t, Probe("tcp", server.Listener.Addr().String(), time.Second))
+
+	// A closed port fails the TCP probe.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := listener.Addr().String()
+	listener.Close()
+	assert.Error(t, Probe("tcp", address, time.Second))
+
+	assert.EqualError(t, Probe("udp", address, time.Second), `Unknown probe type "udp"`)
+}
@@ -18,6 +18,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -249,12 +250,12 @@ func imgPostInstanceInfo(d *Daemon, r *http.Request, req api.ImagesPost, op *ope
 
 				if totalSize > 0 {
 					percent = value
-					processed = totalSize * (percent / 100.0)
+					processed = totalSize * percent / 100
 				} else {
 					processed = value
 				}
 
-				shared.SetProgressMetadata(metadata, "create_image_from_container_pack", "Image pack", percent, processed, speed)
+				shared.SetProgressMetadata(metadata, "create_image_from_container_pack", "Image pack", percent, processed, totalSize, speed)
 				op.UpdateMetadata(metadata)
 			},
 			Length: totalSize,
@@ -1149,10 +1150,19 @@ func getImageMetadata(fname string) (*api.ImageMetadata, string, error) {
 	return &result, imageType, nil
 }
 
-func doImagesGet(d *Daemon, recursion bool, project string, public bool, clauses []filter.Clause) (interface{}, error) {
+func doImagesGet(d *Daemon, recursion bool, project string, public bool, clauses []filter.Clause, pagination *util.Pagination) (interface{}, int, error) {
 	results, err := d.cluster.GetImagesFingerprints(project, public)
 	if err != nil {
-		return []string{}, err
+		return []string{}, -1, err
+	}
+
+	sort.Strings(results)
+	total := len(results)
+
+	// Without a filter, only the requested page of images needs loading.
+	if clauses == nil {
+		start, end := pagination.Range(total)
+		results = results[start:end]
 	}
 
 	resultString := []string{}
@@ -1176,6 +1186,13 @@ func doImagesGet(d *Daemon, recursion bool, project string, public bool, clauses
 		}
 	}
 
+	// Apply the requested page to the filtered images.
+	if clauses != nil {
+		total = len(resultMap)
+		start, end := pagination.Range(total)
+		resultMap = resultMap[start:end]
+	}
+
 	if !recursion {
 		if clauses != nil {
 			for _, image := range resultMap {
@@ -1183,10 +1200,10 @@ func doImagesGet(d *Daemon, recursion bool, project string, public bool, clauses
 				resultString = append(resultString, url)
 			}
 		}
-		return resultString, nil
+		return resultString, total, nil
 	}
 
-	return resultMap, nil
+	return resultMap, total, nil
 }
 
 // swagger:operation GET /1.0/images?public images images_get_untrusted
@@ -1209,6 +1226,16 @@ func doImagesGet(d *Daemon, recursion bool, project string, public bool, clauses
 //     description: Collection filter
 //     type: string
 //     example: default
+//   - in: query
+//     name: limit
+//     description: Maximum number of entries to return
+//     type: integer
+//     example: 100
+//   - in: query
+//     name: offset
+//     description: Number of entries to skip
+//     type: integer
+//     example: 200
 // responses:
 //   "200":
 //     description: API endpoints
@@ -1263,6 +1290,21 @@ func doImagesGet(d *Daemon, recursion bool, project string, public bool, clauses
 //     description: Collection filter
 //     type: string
 //     example: default
+//   - in: query
+//     name: limit
+//     description: Maximum number of entries to return
+//     type: integer
+//     example: 100
+//   - in: query
+//     name: offset
+//     description: Number of entries to skip
+//     type: integer
+//     example: 200
+//   - in: query
+//     name: fields
+//     description: Comma separated list of fields to return
+//     type: string
+//     example: name,status
 // responses:
 //   "200":
 //     description: API endpoints
@@ -1312,6 +1354,16 @@ func doImagesGet(d *Daemon, recursion bool, project string, public bool, clauses
 //     description: Collection filter
 //     type: string
 //     example: default
+//   - in: query
+//     name: limit
+//     description: Maximum number of entries to return
+//     type: integer
+//     example: 100
+//   - in: query
+//     name: offset
+//     description: Number of entries to skip
+//     type: integer
+//     example: 200
 // responses:
 //   "200":
 //     description: API endpoints
@@ -1366,6 +1418,21 @@ func doImagesGet(d *Daemon, recursion bool, project string, public bool, clauses
 //     description: Collection filter
 //     type: string
 //     example: default
+//   - in: query
+//     name: limit
+//     description: Maximum number of entries to return
+//     type: integer
+//     example: 100
+//   - in: query
+//     name: offset
+//     description: Number of entries to skip
+//     type: integer
+//     example: 200
+//   - in: query
+//     name: fields
+//     description: Comma separated list of fields to return
+//     type: string
+//     example: name,status
 // responses:
 //   "200":
 //     description: API endpoints
@@ -1408,11 +1475,24 @@ func imagesGet(d *Daemon, r *http.Request) response.Response {
 		}
 	}
 
-	result, err := doImagesGet(d, util.IsRecursionRequest(r), projectName, public, clauses)
+	pagination, err := util.PaginationFromRequest(r)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	result, total, err := doImagesGet(d, util.IsRecursionRequest(r), projectName, public, clauses, pagination)
 	if err != nil {
 		return response.SmartError(err)
 	}
-	return response.SyncResponse(true, result)
+
+	if util.IsRecursionRequest(r) {
+		result, err = util.SelectFields(result, util.FieldsFromRequest(r))
+		if err != nil {
+			return response.SmartError(err)
+		}
+	}
+
+	return response.SyncResponseHeaders(true, result, pagination.Headers(total))
 }
 
 func autoUpdateImagesTask(d *Daemon) (task.Func, task.Schedule) {
@@ -2432,6 +2512,11 @@ func imageValidSecret(d *Daemon, r *http.Request, projectName string, fingerprin
 //     description: Project name
 //     type: string
 //     example: default
+//   - in: query
+//     name: fields
+//     description: Comma separated list of fields to return
+//     type: string
+//     example: name,status
 // responses:
 //   "200":
 //     description: Image
@@ -2478,7 +2563,13 @@ func imageGet(d *Daemon, r *http.Request) response.Response {
 	}
 
 	etag := []interface{}{info.Public, info.AutoUpdate, info.Properties}
-	return response.SyncResponseETag(true, info, etag)
+
+	result, err := util.SelectFields(info, util.FieldsFromRequest(r))
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponseETag(true, result, etag)
 }
 
 // swagger:operation PUT /1.0/images/{fingerprint} images image_put
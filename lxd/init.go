@@ -435,3 +435,95 @@ func initDataClusterApply(d lxd.InstanceServer, config *initDataCluster) error {
 
 	return nil
 }
+
+// Helper to describe the node-specific entities of a LXD instance in the preseed format, so that they
+// can be replayed with 'lxd init --preseed' to rebuild an identical server.
+//
+// The GET /1.0/preseed API builds the same structure from the database in preseedDump.
+func initDataNodeDump(d lxd.InstanceServer) (*api.InitPreseed, error) {
+	currentServer, _, err := d.GetServer()
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to retrieve current server configuration")
+	}
+
+	config := api.InitPreseed{}
+	config.Config = map[string]interface{}{}
+	for k, v := range currentServer.Config {
+		// Hidden keys (such as passwords) can't be retrieved, only whether they're set.
+		_, hidden := v.(bool)
+		if hidden {
+			continue
+		}
+
+		config.Config[k] = v
+	}
+
+	// Only retrieve networks in the default project as projects are created after the networks when
+	// applying a preseed.
+	networks, err := d.UseProject(project.Default).GetNetworks()
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to retrieve current server network configuration for project %q", project.Default)
+	}
+
+	for _, network := range networks {
+		// Only list managed networks.
+		if !network.Managed {
+			continue
+		}
+
+		networksPost := api.InitNetworksProjectPost{}
+		networksPost.Config = network.Config
+		networksPost.Description = network.Description
+		networksPost.Name = network.Name
+		networksPost.Type = network.Type
+		networksPost.Project = project.Default
+
+		config.Networks = append(config.Networks, networksPost)
+	}
+
+	storagePools, err := d.GetStoragePools()
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to retrieve current server configuration")
+	}
+
+	for _, storagePool := range storagePools {
+		storagePoolsPost := api.StoragePoolsPost{}
+		storagePoolsPost.Config = storagePool.Config
+		storagePoolsPost.Description = storagePool.Description
+		storagePoolsPost.Name = storagePool.Name
+		storagePoolsPost.Driver = storagePool.Driver
+
+		config.StoragePools = append(config.StoragePools, storagePoolsPost)
+	}
+
+	profiles, err := d.UseProject(project.Default).GetProfiles()
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to retrieve current server configuration")
+	}
+
+	for _, profile := range profiles {
+		profilesPost := api.ProfilesPost{}
+		profilesPost.Config = profile.Config
+		profilesPost.Description = profile.Description
+		profilesPost.Devices = profile.Devices
+		profilesPost.Name = profile.Name
+
+		config.Profiles = append(config.Profiles, profilesPost)
+	}
+
+	projects, err := d.GetProjects()
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to retrieve current server configuration")
+	}
+
+	for _, project := range projects {
+		projectsPost := api.ProjectsPost{}
+		projectsPost.Config = project.Config
+		projectsPost.Description = project.Description
+		projectsPost.Name = project.Name
+
+		config.Projects = append(config.Projects, projectsPost)
+	}
+
+	return &config, nil
+}
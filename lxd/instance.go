@@ -22,6 +22,7 @@ import (
 	storagePools "github.com/lxc/lxd/lxd/storage"
 	"github.com/lxc/lxd/lxd/task"
 	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
 	log "github.com/lxc/lxd/shared/log15"
 	"github.com/lxc/lxd/shared/logger"
 )
@@ -79,6 +80,72 @@ func instanceImageTransfer(d *Daemon, r *http.Request, projectName string, hash
 	return nil
 }
 
+// instanceImageTransferFromCluster transfers an image from one of the other online cluster members which have
+// it. The members are tried in turn so that a single unreachable member doesn't prevent the transfer.
+func instanceImageTransferFromCluster(d *Daemon, r *http.Request, projectName string, hash string) error {
+	addresses, err := d.cluster.GetNodesWithImage(hash)
+	if err != nil {
+		return errors.Wrapf(err, "Failed getting cluster members with image %q", hash)
+	}
+
+	var localAddress string
+	err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
+		var err error
+		localAddress, err = tx.GetLocalNodeAddress()
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	err = fmt.Errorf("Image not available on any other online cluster member")
+	for _, address := range addresses {
+		if address == localAddress {
+			continue
+		}
+
+		err = instanceImageTransfer(d, r, projectName, hash, address)
+		if err == nil {
+			return nil
+		}
+
+		logger.Warn("Failed transferring image from cluster member", log.Ctx{"fingerprint": hash, "address": address, "err": err})
+	}
+
+	return err
+}
+
+// instanceImageEnsureLocal transfers the image from another cluster member if it isn't available locally.
+func instanceImageEnsureLocal(d *Daemon, r *http.Request, projectName string, img *api.Image) error {
+	// Check if the image is available locally or it's on another node.
+	nodeAddress, err := d.cluster.LocateImage(img.Fingerprint)
+	if err != nil {
+		return errors.Wrapf(err, "Locate image %q in the cluster", img.Fingerprint)
+	}
+
+	if nodeAddress == "" {
+		return nil
+	}
+
+	// Ensure we are the only ones operating on this image.
+	unlock := d.imageDownloadLock(img.Fingerprint)
+	defer unlock()
+
+	// The image is available from other members, let's try to import it.
+	err = instanceImageTransferFromCluster(d, r, projectName, img.Fingerprint)
+	if err != nil {
+		return errors.Wrapf(err, "Failed transferring image %q", img.Fingerprint)
+	}
+
+	// As the image record already exists in the project, just add the node ID to the image.
+	err = d.cluster.AddImageToLocalNode(projectName, img.Fingerprint)
+	if err != nil {
+		return errors.Wrapf(err, "Failed adding transferred image %q to local cluster member", img.Fingerprint)
+	}
+
+	return nil
+}
+
 // instanceCreateFromImage creates an instance from a rootfs image.
 func instanceCreateFromImage(d *Daemon, r *http.Request, args db.InstanceArgs, hash string, op *operations.Operation) (instance.Instance, error) {
 	revert := revert.New()
@@ -107,31 +174,9 @@ func instanceCreateFromImage(d *Daemon, r *http.Request, args db.InstanceArgs, h
 		return nil, fmt.Errorf("Requested image's type '%s' doesn't match instance type '%s'", imgType, args.Type)
 	}
 
-	// Check if the image is available locally or it's on another node.
-	nodeAddress, err := s.Cluster.LocateImage(hash)
+	err = instanceImageEnsureLocal(d, r, args.Project, img)
 	if err != nil {
-		return nil, errors.Wrapf(err, "Locate image %q in the cluster", hash)
-	}
-
-	if nodeAddress != "" {
-		// Ensure we are the only ones operating on this image.
-		unlock := d.imageDownloadLock(img.Fingerprint)
-
-		// The image is available from another node, let's try to import it.
-		err = instanceImageTransfer(d, r, args.Project, img.Fingerprint, nodeAddress)
-		if err != nil {
-			unlock()
-			return nil, errors.Wrapf(err, "Failed transferring image %q from %q", img.Fingerprint, nodeAddress)
-		}
-
-		// As the image record already exists in the project, just add the node ID to the image.
-		err = d.cluster.AddImageToLocalNode(args.Project, img.Fingerprint)
-		if err != nil {
-			unlock()
-			return nil, errors.Wrapf(err, "Failed adding transferred image %q to local cluster member", img.Fingerprint)
-		}
-
-		unlock()
+		return nil, err
 	}
 
 	// Set the "image.*" keys.
@@ -1,9 +1,16 @@
 package drivers
 
 import (
+	"bytes"
+	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -20,6 +27,7 @@ import (
 	"github.com/lxc/lxd/lxd/instance"
 	"github.com/lxc/lxd/lxd/instance/instancetype"
 	"github.com/lxc/lxd/lxd/instance/operationlock"
+	"github.com/lxc/lxd/lxd/lifecycle"
 	"github.com/lxc/lxd/lxd/maas"
 	"github.com/lxc/lxd/lxd/operations"
 	"github.com/lxc/lxd/lxd/project"
@@ -35,6 +43,15 @@ import (
 // ErrInstanceIsStopped indicates that the instance is stopped.
 var ErrInstanceIsStopped error = fmt.Errorf("The instance is already stopped")
 
+// autorestartResetInterval is how long an instance must run for its automatic restart count to be reset.
+const autorestartResetInterval = 10 * time.Minute
+
+// autorestartMaxDelay is the maximum delay before an automatic restart.
+const autorestartMaxDelay = 5 * time.Minute
+
+// lifecycleHookTimeout is how long a lifecycle hook can run for.
+const lifecycleHookTimeout = 30 * time.Second
+
 // common provides structure common to all instance types.
 type common struct {
 	op    *operations.Operation
@@ -310,6 +327,12 @@ func (d *common) DevicesPath() string {
 	return shared.VarPath("devices", name)
 }
 
+// ExecRecordingsPath returns the instance's exec session recordings path.
+func (d *common) ExecRecordingsPath() string {
+	name := project.Instance(d.project, d.name)
+	return shared.VarPath("recordings", name)
+}
+
 // LogPath returns the instance's log path.
 func (d *common) LogPath() string {
 	name := project.Instance(d.project, d.name)
@@ -890,6 +913,218 @@ func (d *common) onStopOperationSetup(target string) (*operationlock.InstanceOpe
 	return op, instanceInitiated, nil
 }
 
+// autorestartCount returns the number of consecutive automatic restarts of the instance.
+func (d *common) autorestartCount() int64 {
+	count, _ := strconv.ParseInt(d.localConfig["volatile.restart_count"], 10, 64)
+	return count
+}
+
+// healthState returns the result of the health check of the instance, empty if none is configured or the
+// instance isn't running.
+func (d *common) healthState(statusCode api.StatusCode) string {
+	if d.expandedConfig["healthcheck.type"] == "" || !d.isRunningStatusCode(statusCode) {
+		return ""
+	}
+
+	if d.localConfig["volatile.health"] == "" {
+		return "starting"
+	}
+
+	return d.localConfig["volatile.health"]
+}
+
+// autorestartNext returns the delay before the next automatic restart of the instance and the restart
+// count to record for it. The count is reset if the instance ran long enough, and the delay doubles with
+// each consecutive restart. Returns an error once boot.autorestart.max_retries is reached.
+func (d *common) autorestartNext() (time.Duration, int64, error) {
+	count := d.autorestartCount()
+
+	// The count only covers restarts in quick succession.
+	if time.Since(d.lastUsedDate) > autorestartResetInterval {
+		count = 0
+	}
+
+	maxRetries := int64(10)
+	if d.expandedConfig["boot.autorestart.max_retries"] != "" {
+		maxRetries, _ = strconv.ParseInt(d.expandedConfig["boot.autorestart.max_retries"], 10, 64)
+	}
+
+	if count >= maxRetries {
+		return 0, count, fmt.Errorf("Too many automatic restarts (%d)", count)
+	}
+
+	delay := time.Second
+	if d.expandedConfig["boot.autorestart.delay"] != "" {
+		seconds, _ := strconv.ParseInt(d.expandedConfig["boot.autorestart.delay"], 10, 64)
+		delay = time.Duration(seconds) * time.Second
+	}
+
+	for i := int64(0); i < count && delay < autorestartMaxDelay; i++ {
+		delay *= 2
+	}
+
+	if delay > autorestartMaxDelay {
+		delay = autorestartMaxDelay
+	}
+
+	return delay, count + 1, nil
+}
+
+// autorestartStart records the restart count and starts the instance after the delay.
+func (d *common) autorestartStart(inst instance.Instance, delay time.Duration, count int64) error {
+	err := d.VolatileSet(map[string]string{"volatile.restart_count": strconv.FormatInt(count, 10)})
+	if err != nil {
+		return errors.Wrap(err, "Failed recording automatic restart count")
+	}
+
+	time.Sleep(delay)
+
+	if inst.IsRunning() {
+		return nil
+	}
+
+	d.logger.Info("Automatically restarting instance", log.Ctx{"restarts": count})
+	err = inst.Start(false)
+	if err != nil {
+		return errors.Wrap(err, "Failed automatically restarting instance")
+	}
+
+	d.state.Events.SendLifecycle(d.project, lifecycle.InstanceRestarted.Event(inst, nil))
+
+	return nil
+}
+
+// onStopAutorestart is called from the stop hooks to restart the instance if it stopped abnormally (as
+// opposed to being stopped through LXD or shut down cleanly from inside) and boot.autorestart is enabled,
+// once the stop operation is done.
+// Any other stop resets the restart count.
+func (d *common) onStopAutorestart(inst instance.Instance, op *operationlock.InstanceOperation, abnormal bool) {
+	if !abnormal || d.ephemeral || !shared.IsTrue(d.expandedConfig["boot.autorestart"]) {
+		if d.autorestartCount() > 0 {
+			err := d.VolatileSet(map[string]string{"volatile.restart_count": ""})
+			if err != nil {
+				d.logger.Warn("Failed resetting automatic restart count", log.Ctx{"err": err})
+			}
+		}
+
+		return
+	}
+
+	delay, count, err := d.autorestartNext()
+	if err != nil {
+		d.logger.Warn("Not restarting instance", log.Ctx{"err": err})
+		return
+	}
+
+	go func() {
+		// Wait for the stop to be fully processed.
+		op.Wait()
+
+		err := d.autorestartStart(inst, delay, count)
+		if err != nil {
+			d.logger.Error("Failed automatic restart", log.Ctx{"err": err})
+		}
+	}()
+}
+
+// autorestartCommon stops the running instance and restarts it as allowed by its automatic restart
+// policy, as if it had stopped on its own.
+func (d *common) autorestartCommon(inst instance.Instance) error {
+	if d.ephemeral {
+		return fmt.Errorf("Ephemeral instances can't be automatically restarted")
+	}
+
+	delay, count, err := d.autorestartNext()
+	if err != nil {
+		return err
+	}
+
+	// Stopping through LXD resets the count, so it gets recorded afterwards.
+	err = inst.Stop(false)
+	if err != nil {
+		return err
+	}
+
+	return d.autorestartStart(inst, delay, count)
+}
+
+// lifecycleHookRun runs the host-side command or webhook configured in the "hooks.<hook>" key, if any.
+// Commands get the details of the instance in their environment, webhooks get them as a JSON body.
+func (d *common) lifecycleHookRun(hook string) error {
+	target := d.expandedConfig[fmt.Sprintf("hooks.%s", hook)]
+	if target == "" {
+		return nil
+	}
+
+	d.logger.Debug("Running lifecycle hook", log.Ctx{"hook": hook, "target": target})
+
+	ctx, cancel := context.WithTimeout(context.Background(), lifecycleHookTimeout)
+	defer cancel()
+
+	if strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://") {
+		body, err := json.Marshal(map[string]string{
+			"hook":     hook,
+			"instance": d.name,
+			"project":  d.project,
+			"type":     d.dbType.String(),
+			"location": d.node,
+		})
+		if err != nil {
+			return err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, "POST", target, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return errors.Wrapf(err, "Failed calling %q hook", hook)
+		}
+
+		resp.Body.Close()
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return fmt.Errorf("Failed calling %q hook: %s", hook, resp.Status)
+		}
+
+		return nil
+	}
+
+	cmd := exec.CommandContext(ctx, target)
+	cmd.Env = append(os.Environ(),
+		fmt.Sprintf("LXD_HOOK=%s", hook),
+		fmt.Sprintf("LXD_INSTANCE_NAME=%s", d.name),
+		fmt.Sprintf("LXD_INSTANCE_PROJECT=%s", d.project),
+		fmt.Sprintf("LXD_INSTANCE_TYPE=%s", d.dbType.String()),
+		fmt.Sprintf("LXD_INSTANCE_LOCATION=%s", d.node),
+	)
+
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return errors.Wrapf(err, "Failed running %q hook (%s)", hook, strings.TrimSpace(string(out)))
+	}
+
+	return nil
+}
+
+// lifecycleHookRunAsync runs a lifecycle hook in the background, only logging failures.
+func (d *common) lifecycleHookRunAsync(hook string) {
+	if d.expandedConfig[fmt.Sprintf("hooks.%s", hook)] == "" {
+		return
+	}
+
+	go func() {
+		err := d.lifecycleHookRun(hook)
+		if err != nil {
+			d.logger.Warn("Failed running lifecycle hook", log.Ctx{"hook": hook, "err": err})
+		}
+	}()
+}
+
 // warningsDelete deletes any persistent warnings for the instance.
 func (d *common) warningsDelete() error {
 	err := d.state.Cluster.Transaction(func(tx *db.ClusterTx) error {
@@ -2,6 +2,7 @@ package drivers
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -39,7 +40,9 @@ import (
 	"github.com/lxc/lxd/lxd/network"
 	"github.com/lxc/lxd/lxd/project"
 	"github.com/lxc/lxd/lxd/revert"
+	"github.com/lxc/lxd/lxd/resources"
 	"github.com/lxc/lxd/lxd/seccomp"
+	"github.com/lxc/lxd/lxd/secrets"
 	"github.com/lxc/lxd/lxd/state"
 	storagePools "github.com/lxc/lxd/lxd/storage"
 	storageDrivers "github.com/lxc/lxd/lxd/storage/drivers"
@@ -277,13 +280,18 @@ func lxcCreate(s *state.State, args db.InstanceArgs, volumeConfig map[string]str
 		s.Cluster.RemoveStoragePoolVolume(args.Project, args.Name, db.StoragePoolVolumeTypeContainer, d.storagePool.ID())
 	})
 
-	// Setup initial idmap config
+	// Setup initial idmap config (snapshots don't get an allocation of their own)
 	var idmap *idmap.IdmapSet
 	base := int64(0)
 	if !d.IsPrivileged() {
+		instanceID := d.id
+		if d.IsSnapshot() {
+			instanceID = -1
+		}
+
 		idmap, base, err = findIdmap(
 			s,
-			args.Name,
+			instanceID,
 			d.expandedConfig["security.idmap.isolated"],
 			d.expandedConfig["security.idmap.base"],
 			d.expandedConfig["security.idmap.size"],
@@ -489,7 +497,11 @@ func idmapSize(state *state.State, isolatedStr string, size string) (int64, erro
 
 var idmapLock sync.Mutex
 
-func findIdmap(state *state.State, cName string, isolatedStr string, configBase string, configSize string, rawIdmap string) (*idmap.IdmapSet, int64, error) {
+// findIdmap returns the idmap to use for the instance with the given ID and the base of its range of host
+// uids/gids. The ranges of the instances with an isolated idmap are allocated in the cluster database, so that
+// they don't overlap across all the instances of the cluster. An instance ID of -1 finds a free range without
+// allocating it.
+func findIdmap(state *state.State, instanceID int, isolatedStr string, configBase string, configSize string, rawIdmap string) (*idmap.IdmapSet, int64, error) {
 	isolated := false
 	if shared.IsTrue(isolatedStr) {
 		isolated = true
@@ -501,6 +513,13 @@ func findIdmap(state *state.State, cName string, isolatedStr string, configBase
 	}
 
 	if !isolated {
+		if instanceID >= 0 {
+			err := releaseIdmap(state, instanceID)
+			if err != nil {
+				return nil, 0, err
+			}
+		}
+
 		newIdmapset := idmap.IdmapSet{Idmap: make([]idmap.IdmapEntry, len(state.OS.IdmapSet.Idmap))}
 		copy(newIdmapset.Idmap, state.OS.IdmapSet.Idmap)
 
@@ -535,67 +554,61 @@ func findIdmap(state *state.State, cName string, isolatedStr string, configBase
 		return set, nil
 	}
 
-	if configBase != "" {
-		offset, err := strconv.ParseInt(configBase, 10, 64)
-		if err != nil {
-			return nil, 0, err
-		}
-
-		set, err := mkIdmap(offset, size)
-		if err != nil && err == idmap.ErrHostIdIsSubId {
-			return nil, 0, err
-		}
-
-		return set, offset, nil
-	}
-
 	idmapLock.Lock()
 	defer idmapLock.Unlock()
 
-	cts, err := instance.LoadNodeAll(state, instancetype.Container)
-	if err != nil {
-		return nil, 0, err
-	}
-
-	offset := state.OS.IdmapSet.Idmap[0].Hostid + 65536
-
-	mapentries := idmap.ByHostid{}
-	for _, container := range cts {
-		if container.Type() != instancetype.Container {
-			continue
-		}
-
-		name := container.Name()
-
-		/* Don't change our map Just Because. */
-		if name == cName {
-			continue
-		}
+	var offset int64
+	err = state.Cluster.Transaction(func(tx *db.ClusterTx) error {
+		if configBase != "" {
+			offset, err = strconv.ParseInt(configBase, 10, 64)
+			if err != nil {
+				return err
+			}
+		} else {
+			allocations, err := tx.GetIdmapAllocations()
+			if err != nil {
+				return err
+			}
 
-		if container.IsPrivileged() {
-			continue
-		}
+			/* Don't change our map Just Because. */
+			mapentries := idmap.ByHostid{}
+			for _, allocation := range allocations {
+				if allocation.InstanceID == int64(instanceID) {
+					continue
+				}
 
-		if !shared.IsTrue(container.ExpandedConfig()["security.idmap.isolated"]) {
-			continue
-		}
+				mapentries = append(mapentries, &idmap.IdmapEntry{Hostid: allocation.Base, Maprange: allocation.Size})
+			}
 
-		cBase := int64(0)
-		if container.ExpandedConfig()["volatile.idmap.base"] != "" {
-			cBase, err = strconv.ParseInt(container.ExpandedConfig()["volatile.idmap.base"], 10, 64)
+			offset, err = idmapFindBase(state.OS.IdmapSet.Idmap[0], mapentries, size)
 			if err != nil {
-				return nil, 0, err
+				return err
 			}
 		}
 
-		cSize, err := idmapSize(state, container.ExpandedConfig()["security.idmap.isolated"], container.ExpandedConfig()["security.idmap.size"])
-		if err != nil {
-			return nil, 0, err
+		if instanceID < 0 {
+			return nil
 		}
 
-		mapentries = append(mapentries, &idmap.IdmapEntry{Hostid: int64(cBase), Maprange: cSize})
+		return tx.SetIdmapAllocation(int64(instanceID), offset, size)
+	})
+	if err != nil {
+		return nil, 0, err
 	}
 
+	set, err := mkIdmap(offset, size)
+	if err != nil && err == idmap.ErrHostIdIsSubId {
+		return nil, 0, err
+	}
+
+	return set, offset, nil
+}
+
+// idmapFindBase returns the base of the first free range of the given size in the host's range of uids/gids,
+// after its first 65536 ids, given the ranges already allocated.
+func idmapFindBase(hostRange idmap.IdmapEntry, mapentries idmap.ByHostid, size int64) (int64, error) {
+	offset := hostRange.Hostid + 65536
+
 	sort.Sort(mapentries)
 
 	for i := range mapentries {
@@ -605,12 +618,7 @@ func findIdmap(state *state.State, cName string, isolatedStr string, configBase
 				continue
 			}
 
-			set, err := mkIdmap(offset, size)
-			if err != nil && err == idmap.ErrHostIdIsSubId {
-				return nil, 0, err
-			}
-
-			return set, offset, nil
+			return offset, nil
 		}
 
 		if mapentries[i-1].Hostid+mapentries[i-1].Maprange > offset {
@@ -620,26 +628,23 @@ func findIdmap(state *state.State, cName string, isolatedStr string, configBase
 
 		offset = mapentries[i-1].Hostid + mapentries[i-1].Maprange
 		if offset+size < mapentries[i].Hostid {
-			set, err := mkIdmap(offset, size)
-			if err != nil && err == idmap.ErrHostIdIsSubId {
-				return nil, 0, err
-			}
-
-			return set, offset, nil
+			return offset, nil
 		}
 		offset = mapentries[i].Hostid + mapentries[i].Maprange
 	}
 
-	if offset+size < state.OS.IdmapSet.Idmap[0].Hostid+state.OS.IdmapSet.Idmap[0].Maprange {
-		set, err := mkIdmap(offset, size)
-		if err != nil && err == idmap.ErrHostIdIsSubId {
-			return nil, 0, err
-		}
-
-		return set, offset, nil
+	if offset+size < hostRange.Hostid+hostRange.Maprange {
+		return offset, nil
 	}
 
-	return nil, 0, fmt.Errorf("Not enough uid/gid available for the container")
+	return 0, fmt.Errorf("Not enough uid/gid available for the container")
+}
+
+// releaseIdmap releases the range of host uids/gids allocated to the instance with the given ID, if any.
+func releaseIdmap(state *state.State, instanceID int) error {
+	return state.Cluster.Transaction(func(tx *db.ClusterTx) error {
+		return tx.DeleteIdmapAllocation(int64(instanceID))
+	})
 }
 
 func (d *lxc) init() error {
@@ -715,13 +720,23 @@ func (d *lxc) initLXC(config bool) error {
 	}
 
 	if util.RuntimeLiblxcVersionAtLeast(3, 0, 0) {
-		// Default size log buffer
-		err = lxcSetConfigItem(cc, "lxc.console.buffer.size", "auto")
+		// Size of the log buffer (defaults to the liblxc default size).
+		consoleBufferSize := "auto"
+		if d.expandedConfig["console.buffer.size"] != "" {
+			size, err := instance.ConsoleBufferSize(d.expandedConfig)
+			if err != nil {
+				return err
+			}
+
+			consoleBufferSize = fmt.Sprintf("%d", size)
+		}
+
+		err = lxcSetConfigItem(cc, "lxc.console.buffer.size", consoleBufferSize)
 		if err != nil {
 			return err
 		}
 
-		err = lxcSetConfigItem(cc, "lxc.console.size", "auto")
+		err = lxcSetConfigItem(cc, "lxc.console.size", consoleBufferSize)
 		if err != nil {
 			return err
 		}
@@ -994,6 +1009,23 @@ func (d *lxc) initLXC(config bool) error {
 		}
 	}
 
+	// Setup SELinux
+	if d.state.OS.SELinuxAvailable {
+		if d.expandedConfig["security.selinux.context"] != "" {
+			err := lxcSetConfigItem(cc, "lxc.selinux.context", d.expandedConfig["security.selinux.context"])
+			if err != nil {
+				return err
+			}
+		}
+
+		if d.expandedConfig["security.selinux.context.keyring"] != "" {
+			err := lxcSetConfigItem(cc, "lxc.selinux.context.keyring", d.expandedConfig["security.selinux.context.keyring"])
+			if err != nil {
+				return err
+			}
+		}
+	}
+
 	// Setup Seccomp if necessary
 	if seccomp.InstanceNeedsPolicy(d) {
 		err = lxcSetConfigItem(cc, "lxc.seccomp.profile", seccomp.ProfilePath(d))
@@ -1028,8 +1060,37 @@ func (d *lxc) initLXC(config bool) error {
 		}
 	}
 
+	// Setup the offsets of the clocks in the time namespace.
+	for _, clock := range []string{"boot", "monotonic"} {
+		value := d.expandedConfig[fmt.Sprintf("time.offset.%s", clock)]
+		if value == "" {
+			continue
+		}
+
+		if !d.state.OS.TimeNamespace {
+			return fmt.Errorf("Clock offsets require time namespace support")
+		}
+
+		offset, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+
+		err = lxcSetConfigItem(cc, fmt.Sprintf("lxc.time.offset.%s", clock), fmt.Sprintf("%dns", offset.Nanoseconds()))
+		if err != nil {
+			return err
+		}
+	}
+
 	// Setup environment
-	for k, v := range d.expandedConfig {
+	environment, err := secrets.ExpandConfig(d.state, d.Project(), d.expandedConfig, func(key string) bool {
+		return strings.HasPrefix(key, "environment.")
+	})
+	if err != nil {
+		return err
+	}
+
+	for k, v := range environment {
 		if strings.HasPrefix(k, "environment.") {
 			err = lxcSetConfigItem(cc, "lxc.environment", fmt.Sprintf("%s=%s", strings.TrimPrefix(k, "environment."), v))
 			if err != nil {
@@ -1202,6 +1263,19 @@ func (d *lxc) initLXC(config bool) error {
 		}
 	}
 
+	// NUMA nodes
+	cpuNodes := d.expandedConfig["limits.cpu.nodes"]
+	if cpuNodes != "" {
+		if !d.state.OS.CGInfo.Supports(cgroup.CPUSet, cg) {
+			return fmt.Errorf("Cannot apply limits.cpu.nodes as cpuset cgroup controller is missing")
+		}
+
+		err = cg.SetCpusetMems(cpuNodes)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Disk priority limits.
 	diskPriority := d.ExpandedConfig()["limits.disk.priority"]
 	if diskPriority != "" {
@@ -1950,6 +2024,29 @@ func (d *lxc) handleIdmappedStorage() (idmap.IdmapStorageType, *idmap.IdmapSet,
 }
 
 // Start functions
+// selinuxLabelRootfs labels the root filesystem with security.selinux.context.file, if it wasn't
+// already labelled with it.
+func (d *lxc) selinuxLabelRootfs() error {
+	selinuxKeys := []string{"security.selinux.context", "security.selinux.context.file", "security.selinux.context.keyring"}
+	for _, key := range selinuxKeys {
+		if d.expandedConfig[key] != "" && !d.state.OS.SELinuxAvailable {
+			return fmt.Errorf("%s is set but SELinux isn't available on this system", key)
+		}
+	}
+
+	fileContext := d.expandedConfig["security.selinux.context.file"]
+	if fileContext == "" || d.localConfig["volatile.selinux.file"] == fileContext {
+		return nil
+	}
+
+	_, err := shared.RunCommand("chcon", "-R", fileContext, d.RootfsPath())
+	if err != nil {
+		return err
+	}
+
+	return d.VolatileSet(map[string]string{"volatile.selinux.file": fileContext})
+}
+
 func (d *lxc) startCommon() (string, []func() error, error) {
 	revert := revert.New()
 	defer revert.Fail()
@@ -2011,6 +2108,12 @@ func (d *lxc) startCommon() (string, []func() error, error) {
 		}
 	}
 
+	// Label the root filesystem for SELinux.
+	err = d.selinuxLabelRootfs()
+	if err != nil {
+		return "", nil, errors.Wrap(err, "Failed to label the root filesystem for SELinux")
+	}
+
 	// Generate the Seccomp profile
 	if err := seccomp.CreateProfile(d.state, d); err != nil {
 		return "", nil, err
@@ -2367,6 +2470,12 @@ func (d *lxc) Start(stateful bool) error {
 		return err
 	}
 
+	err = d.lifecycleHookRun("pre-start")
+	if err != nil {
+		op.Done(err)
+		return err
+	}
+
 	// Run the shared start code
 	configPath, postStartHooks, err := d.startCommon()
 	if err != nil {
@@ -2429,6 +2538,8 @@ func (d *lxc) Start(stateful bool) error {
 			return err
 		}
 
+		d.lifecycleHookRunAsync("post-start")
+
 		if op.Action() == "start" {
 			d.logger.Info("Started container", ctxMap)
 			d.state.Events.SendLifecycle(d.project, lifecycle.InstanceStarted.Event(d, nil))
@@ -2505,6 +2616,8 @@ func (d *lxc) Start(stateful bool) error {
 		return err
 	}
 
+	d.lifecycleHookRunAsync("post-start")
+
 	if op.Action() == "start" {
 		d.logger.Info("Started container", ctxMap)
 		d.state.Events.SendLifecycle(d.project, lifecycle.InstanceStarted.Event(d, nil))
@@ -2968,6 +3081,8 @@ func (d *lxc) onStop(args map[string]string) error {
 			d.state.Events.SendLifecycle(d.project, lifecycle.InstanceShutdown.Event(d, nil))
 		}
 
+		d.lifecycleHookRunAsync("post-stop")
+
 		// Reboot the container
 		if target == "reboot" {
 			// Start the container again
@@ -2981,6 +3096,10 @@ func (d *lxc) onStop(args map[string]string) error {
 			return
 		}
 
+		// Restart the container if it stopped on its own. LXC doesn't report how the init process exited,
+		// so a shutdown from inside can't be told apart from a crash.
+		d.onStopAutorestart(d, op, instanceInitiated && target == "stop")
+
 		// Trigger a rebalance
 		cgroup.TaskSchedulerTrigger("container", d.name, "stopped")
 
@@ -3292,6 +3411,9 @@ func (d *lxc) renderState(statusCode api.StatusCode) (*api.InstanceState, error)
 		status.Processes = d.processesState()
 	}
 
+	status.Restarts = d.autorestartCount()
+	status.Health = d.healthState(statusCode)
+
 	status.Disk = d.diskState()
 
 	return &status, nil
@@ -3635,6 +3757,12 @@ func (d *lxc) Delete(force bool) error {
 			}
 		}
 
+		// Remove the exec session recordings.
+		err = os.RemoveAll(d.ExecRecordingsPath())
+		if err != nil {
+			return errors.Wrap(err, "Failed to remove exec session recordings")
+		}
+
 		// Clean things up.
 		d.cleanup()
 	}
@@ -3769,6 +3897,15 @@ func (d *lxc) Rename(newName string, applyTemplateTrigger bool) error {
 		}
 	}
 
+	// Rename the exec session recordings.
+	if !d.IsSnapshot() && shared.PathExists(d.ExecRecordingsPath()) {
+		err := os.Rename(d.ExecRecordingsPath(), shared.VarPath("recordings", project.Instance(d.Project(), newName)))
+		if err != nil {
+			d.logger.Error("Failed renaming container", ctxMap)
+			return errors.Wrap(err, "Failed renaming container exec session recordings")
+		}
+	}
+
 	// Rename the MAAS entry.
 	if !d.IsSnapshot() {
 		err = d.maasRename(d, newName)
@@ -4110,7 +4247,7 @@ func (d *lxc) Update(args db.InstanceArgs, userRequested bool) error {
 	}
 
 	// If apparmor changed, re-validate the apparmor profile (even if not running).
-	if shared.StringInSlice("raw.apparmor", changedConfig) || shared.StringInSlice("security.nesting", changedConfig) {
+	if apparmor.InstanceRawChanged(changedConfig) || shared.StringInSlice("security.nesting", changedConfig) {
 		err = apparmor.InstanceValidate(d.state, d)
 		if err != nil {
 			return errors.Wrap(err, "Parse AppArmor profile")
@@ -4124,7 +4261,7 @@ func (d *lxc) Update(args db.InstanceArgs, userRequested bool) error {
 			// update the idmap
 			idmap, base, err = findIdmap(
 				d.state,
-				d.Name(),
+				d.id,
 				d.expandedConfig["security.idmap.isolated"],
 				d.expandedConfig["security.idmap.base"],
 				d.expandedConfig["security.idmap.size"],
@@ -4133,6 +4270,11 @@ func (d *lxc) Update(args db.InstanceArgs, userRequested bool) error {
 			if err != nil {
 				return errors.Wrap(err, "Failed to get ID map")
 			}
+		} else {
+			err = releaseIdmap(d.state, d.id)
+			if err != nil {
+				return errors.Wrap(err, "Failed to release ID map")
+			}
 		}
 
 		var jsonIdmap string
@@ -4182,7 +4324,7 @@ func (d *lxc) Update(args db.InstanceArgs, userRequested bool) error {
 		for _, key := range changedConfig {
 			value := d.expandedConfig[key]
 
-			if key == "raw.apparmor" || key == "security.nesting" {
+			if key == "raw.apparmor" || strings.HasPrefix(key, "raw.apparmor.") || key == "security.nesting" {
 				// Update the AppArmor profile
 				err = apparmor.InstanceLoad(d.state, d)
 				if err != nil {
@@ -4396,6 +4538,34 @@ func (d *lxc) Update(args db.InstanceArgs, userRequested bool) error {
 					return err
 				}
 			} else if key == "limits.cpu" {
+				// Trigger a scheduler re-run
+				cgroup.TaskSchedulerTrigger("container", d.name, "changed")
+			} else if key == "limits.cpu.nodes" {
+				// Skip if no cpuset CGroup
+				if !d.state.OS.CGInfo.Supports(cgroup.CPUSet, cg) {
+					continue
+				}
+
+				// Default to all the host's NUMA nodes
+				if value == "" {
+					nodes, err := resources.GetNUMANodes()
+					if err != nil {
+						return err
+					}
+
+					nodeIDs := []string{}
+					for node := range nodes {
+						nodeIDs = append(nodeIDs, fmt.Sprintf("%d", node))
+					}
+
+					value = strings.Join(nodeIDs, ",")
+				}
+
+				err = cg.SetCpusetMems(value)
+				if err != nil {
+					return err
+				}
+
 				// Trigger a scheduler re-run
 				cgroup.TaskSchedulerTrigger("container", d.name, "changed")
 			} else if key == "limits.cpu.priority" || key == "limits.cpu.allowance" {
@@ -5531,6 +5701,97 @@ func (d *lxc) FilePush(fileType string, srcpath string, dstpath string, uid int6
 	return nil
 }
 
+// fileTree runs a forkfile tar subcommand against path, streaming the tar data through stdin or stdout.
+func (d *lxc) fileTree(command string, path string, stdin io.Reader, stdout io.Writer) error {
+	// Check for ongoing operations (that may involve shifting).
+	operationlock.Get(d.id).Wait()
+
+	// Shift ownership ourselves when not attaching to the user namespace of a running container.
+	idmapJSON := "[]"
+	if !d.IsRunning() {
+		idmapset, err := d.DiskIdmap()
+		if err != nil {
+			return err
+		}
+
+		if idmapset != nil {
+			idmapJSON, err = idmap.JSONMarshal(idmapset)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// Setup container storage if needed
+	_, err := d.mount()
+	if err != nil {
+		return err
+	}
+	defer d.unmount()
+
+	pidFdNr, pidFd := d.inheritInitPidFd()
+	if pidFdNr >= 0 {
+		defer pidFd.Close()
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.Command(
+		d.state.OS.ExecPath,
+		"forkfile",
+		command,
+		d.RootfsPath(),
+		fmt.Sprintf("%d", d.InitPID()),
+		fmt.Sprintf("%d", pidFdNr),
+		path,
+		idmapJSON,
+	)
+
+	if pidFd != nil {
+		cmd.ExtraFiles = []*os.File{pidFd}
+	}
+
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = &stderr
+
+	err = cmd.Run()
+	if err != nil {
+		errStr := strings.TrimSpace(stderr.String())
+		errStr = strings.TrimPrefix(errStr, "Error: ")
+		if errStr == "" {
+			return err
+		}
+
+		return fmt.Errorf("%s", errStr)
+	}
+
+	return nil
+}
+
+// FileTreePull writes the file tree at srcPath in the instance to target as a tar stream.
+func (d *lxc) FileTreePull(srcPath string, target io.Writer) error {
+	err := d.fileTree("tar-pull", srcPath, nil, target)
+	if err != nil {
+		return err
+	}
+
+	d.state.Events.SendLifecycle(d.project, lifecycle.InstanceFileRetrieved.Event(d, log.Ctx{"file-source": srcPath, "format": "tar"}))
+
+	return nil
+}
+
+// FileTreePush extracts the tar stream read from source into dstPath in the instance.
+func (d *lxc) FileTreePush(source io.Reader, dstPath string) error {
+	err := d.fileTree("tar-push", dstPath, source, nil)
+	if err != nil {
+		return err
+	}
+
+	d.state.Events.SendLifecycle(d.project, lifecycle.InstanceFilePushed.Event(d, log.Ctx{"file-destination": dstPath, "format": "tar"}))
+
+	return nil
+}
+
 // FileRemove removes a file inside the instance.
 func (d *lxc) FileRemove(path string) error {
 	// Check for ongoing operations (that may involve shifting).
@@ -6616,6 +6877,35 @@ func (d *lxc) IsPrivileged() bool {
 	return shared.IsTrue(d.expandedConfig["security.privileged"])
 }
 
+// HealthProbe runs a TCP or HTTP probe from inside the container's network namespace.
+func (d *lxc) HealthProbe(probeType string, address string, timeout time.Duration) error {
+	pid := d.InitPID()
+	if pid <= 0 {
+		return fmt.Errorf("Container isn't running")
+	}
+
+	_, err := shared.RunCommand(
+		d.state.OS.ExecPath,
+		"forknet",
+		"probe",
+		"--",
+		fmt.Sprintf("/proc/%d/ns/net", pid),
+		probeType,
+		address,
+		fmt.Sprintf("%d", int64(timeout/time.Second)),
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Autorestart stops the container and restarts it as allowed by its automatic restart policy.
+func (d *lxc) Autorestart() error {
+	return d.autorestartCommon(d)
+}
+
 // IsRunning returns if instance is running.
 func (d *lxc) IsRunning() bool {
 	return d.isRunningStatusCode(d.statusCode())
@@ -45,6 +45,7 @@ import (
 	"github.com/lxc/lxd/lxd/project"
 	"github.com/lxc/lxd/lxd/resources"
 	"github.com/lxc/lxd/lxd/revert"
+	"github.com/lxc/lxd/lxd/secrets"
 	"github.com/lxc/lxd/lxd/state"
 	storagePools "github.com/lxc/lxd/lxd/storage"
 	storageDrivers "github.com/lxc/lxd/lxd/storage/drivers"
@@ -74,6 +75,12 @@ const qemuSerialChardevName = "qemu_serial-chardev"
 // qemuDefaultMemSize is the default memory size for VMs if not limit specified.
 const qemuDefaultMemSize = "1GiB"
 
+// qemuMemoryHotplugSlots is the number of DIMM slots available for hotplugging memory into VMs.
+const qemuMemoryHotplugSlots = 8
+
+// qemuMemoryHotplugAlignMB is the alignment of hotplugged memory (the Linux memory block size).
+const qemuMemoryHotplugAlignMB = 128
+
 // qemuPCIDeviceIDStart is the first PCI slot used for user configurable devices.
 const qemuPCIDeviceIDStart = 4
 
@@ -83,6 +90,76 @@ const qemuDeviceIDPrefix = "dev-lxd_"
 // qemuNetDevIDPrefix used as part of the name given QEMU netdevs generated from user added devices.
 const qemuNetDevIDPrefix = "lxd_"
 
+// qemuCloudInitSeedDevName is the name of the drive of the generated cloud-init seed.
+const qemuCloudInitSeedDevName = "cloud-init.seed"
+
+// lxdAgentServiceUnit is the systemd unit starting the LXD agent in the guest.
+const lxdAgentServiceUnit = `[Unit]
+Description=LXD - agent
+Documentation=https://linuxcontainers.org/lxd
+ConditionPathExists=/dev/virtio-ports/org.linuxcontainers.lxd
+Before=cloud-init.target cloud-init.service cloud-init-local.service
+DefaultDependencies=no
+
+[Service]
+Type=notify
+WorkingDirectory=-/run/lxd_agent
+ExecStartPre=/lib/systemd/lxd-agent-setup
+ExecStart=/run/lxd_agent/lxd-agent
+Restart=on-failure
+RestartSec=5s
+StartLimitInterval=60
+StartLimitBurst=10
+
+[Install]
+WantedBy=multi-user.target
+`
+
+// lxdAgentSetupScript copies the LXD agent and its configuration from the config drive.
+const lxdAgentSetupScript = `#!/bin/sh
+set -eu
+PREFIX="/run/lxd_agent"
+
+# Functions.
+mount_virtiofs() {
+    mount -t virtiofs config "${PREFIX}/.mnt" >/dev/null 2>&1
+}
+
+mount_9p() {
+    /sbin/modprobe 9pnet_virtio >/dev/null 2>&1 || true
+    /bin/mount -t 9p config "${PREFIX}/.mnt" -o access=0,trans=virtio >/dev/null 2>&1
+}
+
+fail() {
+    umount -l "${PREFIX}" >/dev/null 2>&1 || true
+    rmdir "${PREFIX}" >/dev/null 2>&1 || true
+    echo "${1}"
+    exit 1
+}
+
+# Setup the mount target.
+umount -l "${PREFIX}" >/dev/null 2>&1 || true
+mkdir -p "${PREFIX}"
+mount -t tmpfs tmpfs "${PREFIX}" -o mode=0700,size=50M
+mkdir -p "${PREFIX}/.mnt"
+
+# Try virtiofs first.
+mount_virtiofs || mount_9p || fail "Couldn't mount virtiofs or 9p, failing."
+
+# Copy the data.
+cp -Ra "${PREFIX}/.mnt/"* "${PREFIX}"
+
+# Unmount the temporary mount.
+umount "${PREFIX}/.mnt"
+rmdir "${PREFIX}/.mnt"
+
+# Fix up permissions.
+chown -R root:root "${PREFIX}"
+`
+
+// lxdAgentRules is the udev rule starting the LXD agent once its virtio port shows up.
+const lxdAgentRules = `ACTION=="add", SYMLINK=="virtio-ports/org.linuxcontainers.lxd", TAG+="systemd", ACTION=="add", RUN+="/bin/systemctl start lxd-agent.service"`
+
 var errQemuAgentOffline = fmt.Errorf("LXD VM agent isn't currently running")
 
 var vmConsole = map[int]bool{}
@@ -415,7 +492,7 @@ func (d *qemu) getMonitorEventHandler() func(event string, data map[string]inter
 	logger := d.logger
 
 	return func(event string, data map[string]interface{}) {
-		if !shared.StringInSlice(event, []string{"SHUTDOWN", "RESET"}) {
+		if !shared.StringInSlice(event, []string{"SHUTDOWN", "RESET", "GUEST_PANICKED"}) {
 			return // Don't bother loading the instance from DB if we aren't going to handle the event.
 		}
 
@@ -442,17 +519,27 @@ func (d *qemu) getMonitorEventHandler() func(event string, data map[string]inter
 		} else if event == "SHUTDOWN" {
 			logger.Debug("Instance stopped")
 
+			// Anything but a shutdown requested by the guest OS (such as a host signal) is abnormal.
 			target := "stop"
 			entry, ok := data["reason"]
 			if ok && entry == "guest-reset" {
 				target = "reboot"
 			}
 
-			err = inst.(*qemu).onStop(target)
+			err = inst.(*qemu).onStop(target, ok && entry != "guest-shutdown")
 			if err != nil {
 				logger.Error("Failed to cleanly stop instance", log.Ctx{"err": err})
 				return
 			}
+		} else if event == "GUEST_PANICKED" {
+			// The guest is left paused by QEMU, so stop it and handle it as a crash.
+			logger.Warn("Instance guest panicked")
+
+			err = inst.(*qemu).crashStop()
+			if err != nil {
+				logger.Error("Failed to stop panicked instance", log.Ctx{"err": err})
+				return
+			}
 		}
 	}
 }
@@ -610,8 +697,9 @@ func (d *qemu) pidWait(timeout time.Duration, op *operationlock.InstanceOperatio
 	return true
 }
 
-// onStop is run when the instance stops.
-func (d *qemu) onStop(target string) error {
+// onStop is run when the instance stops. The abnormal argument indicates whether the guest stopped without
+// being asked to (crash or external signal), which triggers an automatic restart if enabled.
+func (d *qemu) onStop(target string, abnormal bool) error {
 	d.logger.Debug("onStop hook started", log.Ctx{"target": target})
 	defer d.logger.Debug("onStop hook finished", log.Ctx{"target": target})
 
@@ -655,6 +743,8 @@ func (d *qemu) onStop(target string) error {
 		return err
 	}
 
+	d.lifecycleHookRunAsync("post-stop")
+
 	if target == "reboot" {
 		// Reset timeout to 30s.
 		op.Reset()
@@ -682,6 +772,9 @@ func (d *qemu) onStop(target string) error {
 		d.state.Events.SendLifecycle(d.project, lifecycle.InstanceShutdown.Event(d, nil))
 	}
 
+	// Restart the VM if it stopped abnormally.
+	d.onStopAutorestart(d, op, instanceInitiated && target == "stop" && abnormal)
+
 	op.Done(nil)
 	return nil
 }
@@ -948,6 +1041,12 @@ func (d *qemu) Start(stateful bool) error {
 	}
 	defer op.Done(nil)
 
+	err = d.lifecycleHookRun("pre-start")
+	if err != nil {
+		op.Done(err)
+		return err
+	}
+
 	// Ensure the correct vhost_vsock kernel module is loaded before establishing the vsock.
 	err = util.LoadModule("vhost_vsock")
 	if err != nil {
@@ -1006,6 +1105,17 @@ func (d *qemu) Start(stateful bool) error {
 		volatileSet["volatile.uuid"] = instUUID
 	}
 
+	// Select the NUMA nodes to place the instance on (do this before UpdateBackupFile() call).
+	cpuNodes, err := d.selectCPUNodes()
+	if err != nil {
+		op.Done(err)
+		return err
+	}
+
+	if cpuNodes != d.localConfig["volatile.cpu.nodes"] {
+		volatileSet["volatile.cpu.nodes"] = cpuNodes
+	}
+
 	// Apply any volatile changes that need to be made.
 	err = d.VolatileSet(volatileSet)
 	if err != nil {
@@ -1113,7 +1223,7 @@ func (d *qemu) Start(stateful bool) error {
 	// This is used by the lxd-agent in preference to 9p (due to its improved performance) and in scenarios
 	// where 9p isn't available in the VM guest OS.
 	configSockPath, configPIDPath := d.configVirtiofsdPaths()
-	err = device.DiskVMVirtiofsdStart(d, configSockPath, configPIDPath, "", configMntPath)
+	err = device.DiskVMVirtiofsdStart(d, configSockPath, configPIDPath, "", configMntPath, nil)
 	if err != nil {
 		var errUnsupported device.UnsupportedError
 		if errors.As(err, &errUnsupported) {
@@ -1142,6 +1252,20 @@ func (d *qemu) Start(stateful bool) error {
 		return err
 	}
 
+	// Drop the console output of previous runs which doesn't fit in the scrollback buffer anymore.
+	err = d.trimConsoleBuffer()
+	if err != nil {
+		op.Done(err)
+		return err
+	}
+
+	// Get the CPU model, exposing the virtualization extensions as requested.
+	cpuType, err := d.cpuType()
+	if err != nil {
+		op.Done(err)
+		return err
+	}
+
 	// Define a set of files to open and pass their file descriptors to qemu command.
 	fdFiles := make([]string, 0)
 
@@ -1166,7 +1290,7 @@ func (d *qemu) Start(stateful bool) error {
 		"-name", d.Name(),
 		"-uuid", instUUID,
 		"-daemonize",
-		"-cpu", "host",
+		"-cpu", cpuType,
 		"-nographic",
 		"-serial", "chardev:console",
 		"-nodefaults",
@@ -1401,6 +1525,13 @@ func (d *qemu) Start(stateful bool) error {
 		}
 	}
 
+	// Restrict the vCPUs to the selected NUMA nodes.
+	err = d.setCPUNodesAffinity(monitor)
+	if err != nil {
+		op.Done(err)
+		return err
+	}
+
 	// Run monitor hooks from devices.
 	for _, monHook := range monHooks {
 		err = monHook(monitor)
@@ -1486,6 +1617,8 @@ func (d *qemu) Start(stateful bool) error {
 		return err
 	}
 
+	d.lifecycleHookRunAsync("post-start")
+
 	if op.Action() == "start" {
 		d.state.Events.SendLifecycle(d.project, lifecycle.InstanceStarted.Event(d, nil))
 	}
@@ -1521,11 +1654,7 @@ func (d *qemu) setupNvram() error {
 	}
 	defer d.unmount()
 
-	srcOvmfFile := filepath.Join(d.ovmfPath(), "OVMF_VARS.fd")
-	if d.expandedConfig["security.secureboot"] == "" || shared.IsTrue(d.expandedConfig["security.secureboot"]) {
-		srcOvmfFile = filepath.Join(d.ovmfPath(), "OVMF_VARS.ms.fd")
-	}
-
+	srcOvmfFile := d.nvramTemplatePath()
 	missingEFIFirmwareErr := fmt.Errorf("Required EFI firmware settings file missing %q", srcOvmfFile)
 
 	if !shared.PathExists(srcOvmfFile) {
@@ -1550,6 +1679,127 @@ func (d *qemu) setupNvram() error {
 	return nil
 }
 
+// cpuType returns the CPU model of the instance. When limits.cpu.nested is set, the CPU virtualization
+// extensions are either exposed, which requires nested KVM to be enabled on the host, or hidden.
+func (d *qemu) cpuType() (string, error) {
+	nesting := d.expandedConfig["limits.cpu.nested"]
+	if nesting == "" {
+		return "host", nil
+	}
+
+	if !shared.IsTrue(nesting) {
+		if d.architecture == osarch.ARCH_64BIT_INTEL_X86 {
+			return "host,-vmx,-svm", nil
+		}
+
+		return "host", nil
+	}
+
+	var paramPaths []string
+	switch d.architecture {
+	case osarch.ARCH_64BIT_INTEL_X86:
+		paramPaths = []string{"/sys/module/kvm_intel/parameters/nested", "/sys/module/kvm_amd/parameters/nested"}
+	case osarch.ARCH_64BIT_S390_BIG_ENDIAN:
+		paramPaths = []string{"/sys/module/kvm/parameters/nested"}
+	default:
+		return "", fmt.Errorf("Nested virtualization isn't supported on this architecture")
+	}
+
+	for _, paramPath := range paramPaths {
+		content, err := ioutil.ReadFile(paramPath)
+		if err != nil {
+			continue
+		}
+
+		if shared.StringInSlice(strings.TrimSpace(string(content)), []string{"Y", "1"}) {
+			return "host", nil
+		}
+	}
+
+	return "", fmt.Errorf("Nested virtualization requires nested KVM to be enabled on the host")
+}
+
+// nvramTemplatePath returns the path to the firmware settings the NVRAM is created from, which have the
+// Microsoft keys enrolled when secure boot is enabled.
+func (d *qemu) nvramTemplatePath() string {
+	if d.expandedConfig["security.secureboot"] == "" || shared.IsTrue(d.expandedConfig["security.secureboot"]) {
+		return filepath.Join(d.ovmfPath(), "OVMF_VARS.ms.fd")
+	}
+
+	return filepath.Join(d.ovmfPath(), "OVMF_VARS.fd")
+}
+
+// NVRAMGet returns the content of the UEFI NVRAM of the instance.
+func (d *qemu) NVRAMGet() ([]byte, error) {
+	_, err := d.mount()
+	if err != nil {
+		return nil, err
+	}
+	defer d.unmount()
+
+	data, err := ioutil.ReadFile(d.nvramPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, api.StatusErrorf(http.StatusNotFound, "The instance has no NVRAM")
+		}
+
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// NVRAMSet replaces the UEFI NVRAM of the stopped instance, such as with one having custom secure boot keys
+// enrolled.
+func (d *qemu) NVRAMSet(data []byte) error {
+	if d.IsRunning() {
+		return api.StatusErrorf(http.StatusBadRequest, "The NVRAM can only be replaced while the instance is stopped")
+	}
+
+	if !shared.IntInSlice(d.architecture, []int{osarch.ARCH_64BIT_INTEL_X86, osarch.ARCH_64BIT_ARMV8_LITTLE_ENDIAN}) {
+		return api.StatusErrorf(http.StatusBadRequest, "The instance architecture doesn't use UEFI")
+	}
+
+	// The firmware requires the NVRAM to have the size of its settings template.
+	st, err := os.Stat(d.nvramTemplatePath())
+	if err != nil {
+		return errors.Wrapf(err, "Failed checking EFI firmware settings file")
+	}
+
+	if int64(len(data)) != st.Size() {
+		return api.StatusErrorf(http.StatusBadRequest, "The NVRAM must be %d bytes long", st.Size())
+	}
+
+	_, err = d.mount()
+	if err != nil {
+		return err
+	}
+	defer d.unmount()
+
+	tmpPath := fmt.Sprintf("%s.tmp", d.nvramPath())
+	err = ioutil.WriteFile(tmpPath, data, 0600)
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(tmpPath, d.nvramPath())
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	return nil
+}
+
+// NVRAMReset re-creates the UEFI NVRAM of the stopped instance from the firmware defaults.
+func (d *qemu) NVRAMReset() error {
+	if d.IsRunning() {
+		return api.StatusErrorf(http.StatusBadRequest, "The NVRAM can only be reset while the instance is stopped")
+	}
+
+	return d.setupNvram()
+}
+
 func (d *qemu) qemuArchConfig(arch int) (string, string, error) {
 	if arch == osarch.ARCH_64BIT_INTEL_X86 {
 		path, err := exec.LookPath("qemu-system-x86_64")
@@ -1681,6 +1931,22 @@ func (d *qemu) deviceStart(deviceName string, rawConfig deviceConfig.Device, ins
 				}
 			}
 
+			// Attach disk if requested.
+			if len(runConf.Mounts) > 0 {
+				err = d.deviceAttachDisk(deviceName, runConf.Mounts)
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			// Attach USB device if requested.
+			if len(runConf.USBDevice) > 0 {
+				err = d.deviceAttachUSB(runConf.USBDevice)
+				if err != nil {
+					return nil, err
+				}
+			}
+
 			// If running, run post start hooks now (if not running LXD will run them
 			// once the instance is started).
 			err = d.runHooks(runConf.PostHooks)
@@ -1794,13 +2060,21 @@ func (d *qemu) deviceStop(deviceName string, rawConfig deviceConfig.Device, inst
 	}
 
 	if runConf != nil {
-		if runConf != nil {
-			// Detach NIC from running instance.
-			if rawConfig["type"] == "nic" && instanceRunning {
+		if instanceRunning {
+			switch rawConfig["type"] {
+			case "nic":
+				// Detach NIC from running instance.
 				err = d.deviceDetachNIC(deviceName)
-				if err != nil {
-					return err
-				}
+			case "disk":
+				// Detach disk from running instance.
+				err = d.deviceDetachDisk(deviceName)
+			case "usb":
+				// Detach USB device from running instance.
+				err = d.deviceDetachUSB(deviceName, runConf.USBDevice)
+			}
+
+			if err != nil {
+				return err
 			}
 		}
 
@@ -1881,93 +2155,300 @@ func (d *qemu) deviceDetachNIC(deviceName string) error {
 	return nil
 }
 
-func (d *qemu) monitorPath() string {
-	return filepath.Join(d.LogPath(), "qemu.monitor")
-}
-
-func (d *qemu) nvramPath() string {
-	return filepath.Join(d.Path(), "qemu.nvram")
-}
-
-func (d *qemu) spicePath() string {
-	return filepath.Join(d.LogPath(), "qemu.spice")
-}
-
-func (d *qemu) spiceCmdlineConfig() string {
-	return fmt.Sprintf("unix=on,disable-ticketing=on,addr=%s", d.spicePath())
-}
-
-// generateConfigShare generates the config share directory that will be exported to the VM via
-// a 9P share. Due to the unknown size of templates inside the images this directory is created
-// inside the VM's config volume so that it can be restricted by quota.
-// Requires the instance be mounted before calling this function.
-func (d *qemu) generateConfigShare() error {
-	configDrivePath := filepath.Join(d.Path(), "config")
-
-	// Create config drive dir if doesn't exist, if it does exist, leave it around so we don't regenerate all
-	// files causing unnecessary config drive snapshot usage.
-	err := os.MkdirAll(configDrivePath, 0500)
+// deviceAttachDisk live attaches the drives of a disk device to the instance. As QEMU runs unprivileged
+// and confined, the drives are opened by LXD and passed to QEMU as file descriptors.
+func (d *qemu) deviceAttachDisk(deviceName string, drives []deviceConfig.MountEntryItem) error {
+	monitor, err := qmp.Connect(d.monitorPath(), qemuSerialChardevName, d.getMonitorEventHandler())
 	if err != nil {
 		return err
 	}
 
-	// Generate the cloud-init config.
-	err = os.MkdirAll(filepath.Join(configDrivePath, "cloud-init"), 0500)
-	if err != nil {
-		return err
-	}
+	for _, driveConf := range drives {
+		if driveConf.TargetPath == "/" {
+			return fmt.Errorf("The root disk cannot be attached to a running instance")
+		}
 
-	if d.ExpandedConfig()["user.user-data"] != "" {
-		err = ioutil.WriteFile(filepath.Join(configDrivePath, "cloud-init", "user-data"), []byte(d.ExpandedConfig()["user.user-data"]), 0400)
-		if err != nil {
-			return err
+		if driveConf.FSType == "9p" {
+			return fmt.Errorf("Directory disks cannot be attached to a running instance")
 		}
-	} else {
-		err = ioutil.WriteFile(filepath.Join(configDrivePath, "cloud-init", "user-data"), []byte("#cloud-config\n"), 0400)
-		if err != nil {
-			return err
+
+		if strings.HasPrefix(driveConf.DevPath, "rbd:") {
+			return fmt.Errorf("Ceph RBD disks cannot be attached to a running instance")
 		}
-	}
 
-	if d.ExpandedConfig()["user.vendor-data"] != "" {
-		err = ioutil.WriteFile(filepath.Join(configDrivePath, "cloud-init", "vendor-data"), []byte(d.ExpandedConfig()["user.vendor-data"]), 0400)
+		aioMode, cacheMode, media, err := d.driveIOModes(driveConf)
 		if err != nil {
 			return err
 		}
-	} else {
-		err = ioutil.WriteFile(filepath.Join(configDrivePath, "cloud-init", "vendor-data"), []byte("#cloud-config\n"), 0400)
-		if err != nil {
-			return err
+
+		readonly := shared.StringInSlice("ro", driveConf.Opts)
+
+		flags := unix.O_RDWR
+		if readonly {
+			flags = unix.O_RDONLY
 		}
-	}
 
-	if d.ExpandedConfig()["user.network-config"] != "" {
-		err = ioutil.WriteFile(filepath.Join(configDrivePath, "cloud-init", "network-config"), []byte(d.ExpandedConfig()["user.network-config"]), 0400)
-		if err != nil {
-			return err
+		if cacheMode == "none" {
+			flags |= unix.O_DIRECT
 		}
-	} else {
-		os.Remove(filepath.Join(configDrivePath, "cloud-init", "network-config"))
-	}
 
-	// Append any user.meta-data to our predefined meta-data config.
-	err = ioutil.WriteFile(filepath.Join(configDrivePath, "cloud-init", "meta-data"), []byte(fmt.Sprintf("instance-id: %s\nlocal-hostname: %s\n%s\n", d.Name(), d.Name(), d.ExpandedConfig()["user.meta-data"])), 0400)
-	if err != nil {
-		return err
-	}
+		f, err := os.OpenFile(driveConf.DevPath, flags, 0)
+		if err != nil {
+			return errors.Wrapf(err, "Failed opening %q", driveConf.DevPath)
+		}
 
-	// Add the VM agent.
-	lxdAgentSrcPath, err := exec.LookPath("lxd-agent")
-	if err != nil {
-		d.logger.Warn("lxd-agent not found, skipping its inclusion in the VM config drive", log.Ctx{"err": err})
-	} else {
-		// Install agent into config drive dir if found.
-		lxdAgentSrcPath, err = filepath.EvalSymlinks(lxdAgentSrcPath)
+		fdSetID, err := monitor.AddFdSet(driveConf.DevName, f)
+		f.Close()
 		if err != nil {
 			return err
 		}
 
-		lxdAgentSrcInfo, err := os.Stat(lxdAgentSrcPath)
+		fileDriver := "file"
+		if shared.IsBlockdevPath(driveConf.DevPath) {
+			fileDriver = "host_device"
+		}
+
+		blockDev := map[string]interface{}{
+			"driver":    "raw",
+			"node-name": fmt.Sprintf("%s%s", qemuNetDevIDPrefix, driveConf.DevName),
+			"read-only": readonly,
+			"discard":   "unmap",
+			"cache": map[string]interface{}{
+				"direct":   cacheMode == "none",
+				"no-flush": cacheMode == "unsafe",
+			},
+			"file": map[string]interface{}{
+				"driver":   fileDriver,
+				"filename": fmt.Sprintf("/dev/fdset/%d", fdSetID),
+				"aio":      aioMode,
+				"locking":  "off",
+			},
+		}
+
+		// Leave the SCSI ID to QEMU so it doesn't conflict with the ones of the boot time drives.
+		qemuDev := map[string]string{
+			"id":      fmt.Sprintf("%s%s", qemuDeviceIDPrefix, driveConf.DevName),
+			"driver":  "scsi-hd",
+			"bus":     "qemu_scsi.0",
+			"channel": "0",
+			"drive":   fmt.Sprintf("%s%s", qemuNetDevIDPrefix, driveConf.DevName),
+		}
+
+		if media == "cdrom" {
+			qemuDev["driver"] = "scsi-cd"
+		}
+
+		err = monitor.AddBlockDevice(blockDev, qemuDev)
+
+		// The opened file descriptor is kept by QEMU until the block device is removed.
+		monitor.RemoveFdSet(fdSetID)
+
+		if err != nil {
+			return err
+		}
+
+		if driveConf.Limits != nil {
+			err = monitor.SetBlockThrottle(qemuDev["id"], driveConf.Limits.ReadBytes, driveConf.Limits.WriteBytes, driveConf.Limits.ReadIOps, driveConf.Limits.WriteIOps)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// deviceDetachDisk detaches a disk device from a running instance.
+func (d *qemu) deviceDetachDisk(deviceName string) error {
+	monitor, err := qmp.Connect(d.monitorPath(), qemuSerialChardevName, d.getMonitorEventHandler())
+	if err != nil {
+		return err
+	}
+
+	err = monitor.RemoveDevice(fmt.Sprintf("%s%s", qemuDeviceIDPrefix, deviceName))
+	if err != nil {
+		return err
+	}
+
+	return monitor.RemoveBlockDevice(fmt.Sprintf("%s%s", qemuNetDevIDPrefix, deviceName))
+}
+
+// deviceAttachUSB live attaches a USB device to the instance, passing the host device to QEMU as a file
+// descriptor.
+func (d *qemu) deviceAttachUSB(usbConfig []deviceConfig.RunConfigItem) error {
+	monitor, err := qmp.Connect(d.monitorPath(), qemuSerialChardevName, d.getMonitorEventHandler())
+	if err != nil {
+		return err
+	}
+
+	for _, usbDev := range qemuUSBDevices(usbConfig) {
+		err := func() error {
+			f, err := os.OpenFile(usbDev["hostDevice"], os.O_RDWR, 0)
+			if err != nil {
+				return errors.Wrapf(err, "Failed opening %q", usbDev["hostDevice"])
+			}
+
+			defer f.Close()
+
+			fdSetID, err := monitor.AddFdSet(usbDev["devName"], f)
+			if err != nil {
+				return err
+			}
+
+			defer monitor.RemoveFdSet(fdSetID)
+
+			return monitor.AddDevice(map[string]string{
+				"id":         fmt.Sprintf("%s%s", qemuDeviceIDPrefix, usbDev["devName"]),
+				"driver":     "usb-host",
+				"bus":        "qemu_usb.0",
+				"hostdevice": fmt.Sprintf("/dev/fdset/%d", fdSetID),
+			})
+		}()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deviceDetachUSB detaches the host USB devices of a USB device from a running instance.
+func (d *qemu) deviceDetachUSB(deviceName string, usbConfig []deviceConfig.RunConfigItem) error {
+	monitor, err := qmp.Connect(d.monitorPath(), qemuSerialChardevName, d.getMonitorEventHandler())
+	if err != nil {
+		return err
+	}
+
+	// Devices attached by older versions are named after the LXD device.
+	err = monitor.RemoveDevice(fmt.Sprintf("%s%s", qemuDeviceIDPrefix, deviceName))
+	if err != nil {
+		return err
+	}
+
+	for _, usbDev := range qemuUSBDevices(usbConfig) {
+		err = monitor.RemoveDevice(fmt.Sprintf("%s%s", qemuDeviceIDPrefix, usbDev["devName"]))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// qemuUSBDevices splits the USB device run config items into the settings of each host device, each one
+// starting with its "devName" item.
+func qemuUSBDevices(usbConfig []deviceConfig.RunConfigItem) []map[string]string {
+	usbDevs := []map[string]string{}
+	for _, usbItem := range usbConfig {
+		if usbItem.Key == "devName" {
+			usbDevs = append(usbDevs, map[string]string{})
+		}
+
+		if len(usbDevs) == 0 {
+			continue
+		}
+
+		usbDevs[len(usbDevs)-1][usbItem.Key] = usbItem.Value
+	}
+
+	return usbDevs
+}
+
+func (d *qemu) monitorPath() string {
+	return filepath.Join(d.LogPath(), "qemu.monitor")
+}
+
+func (d *qemu) nvramPath() string {
+	return filepath.Join(d.Path(), "qemu.nvram")
+}
+
+func (d *qemu) spicePath() string {
+	return filepath.Join(d.LogPath(), "qemu.spice")
+}
+
+func (d *qemu) spiceCmdlineConfig() string {
+	return fmt.Sprintf("unix=on,disable-ticketing=on,addr=%s", d.spicePath())
+}
+
+// generateConfigShare generates the config share directory that will be exported to the VM via
+// a 9P share. Due to the unknown size of templates inside the images this directory is created
+// inside the VM's config volume so that it can be restricted by quota.
+// Requires the instance be mounted before calling this function.
+func (d *qemu) generateConfigShare() error {
+	configDrivePath := filepath.Join(d.Path(), "config")
+
+	// Create config drive dir if doesn't exist, if it does exist, leave it around so we don't regenerate all
+	// files causing unnecessary config drive snapshot usage.
+	err := os.MkdirAll(configDrivePath, 0500)
+	if err != nil {
+		return err
+	}
+
+	// Generate the cloud-init config.
+	err = os.MkdirAll(filepath.Join(configDrivePath, "cloud-init"), 0500)
+	if err != nil {
+		return err
+	}
+
+	// Expand the references to secrets in the cloud-init keys.
+	cloudInitConfig, err := secrets.ExpandConfig(d.state, d.Project(), d.ExpandedConfig(), func(key string) bool {
+		return shared.StringInSlice(key, []string{"user.user-data", "user.vendor-data", "user.network-config", "user.meta-data"})
+	})
+	if err != nil {
+		return err
+	}
+
+	if cloudInitConfig["user.user-data"] != "" {
+		err = ioutil.WriteFile(filepath.Join(configDrivePath, "cloud-init", "user-data"), []byte(cloudInitConfig["user.user-data"]), 0400)
+		if err != nil {
+			return err
+		}
+	} else {
+		err = ioutil.WriteFile(filepath.Join(configDrivePath, "cloud-init", "user-data"), []byte("#cloud-config\n"), 0400)
+		if err != nil {
+			return err
+		}
+	}
+
+	if cloudInitConfig["user.vendor-data"] != "" {
+		err = ioutil.WriteFile(filepath.Join(configDrivePath, "cloud-init", "vendor-data"), []byte(cloudInitConfig["user.vendor-data"]), 0400)
+		if err != nil {
+			return err
+		}
+	} else {
+		err = ioutil.WriteFile(filepath.Join(configDrivePath, "cloud-init", "vendor-data"), []byte("#cloud-config\n"), 0400)
+		if err != nil {
+			return err
+		}
+	}
+
+	if cloudInitConfig["user.network-config"] != "" {
+		err = ioutil.WriteFile(filepath.Join(configDrivePath, "cloud-init", "network-config"), []byte(cloudInitConfig["user.network-config"]), 0400)
+		if err != nil {
+			return err
+		}
+	} else {
+		os.Remove(filepath.Join(configDrivePath, "cloud-init", "network-config"))
+	}
+
+	// Append any user.meta-data to our predefined meta-data config.
+	err = ioutil.WriteFile(filepath.Join(configDrivePath, "cloud-init", "meta-data"), []byte(fmt.Sprintf("instance-id: %s\nlocal-hostname: %s\n%s\n", d.Name(), d.Name(), cloudInitConfig["user.meta-data"])), 0400)
+	if err != nil {
+		return err
+	}
+
+	// Add the VM agent.
+	lxdAgentSrcPath, err := exec.LookPath("lxd-agent")
+	if err != nil {
+		d.logger.Warn("lxd-agent not found, skipping its inclusion in the VM config drive", log.Ctx{"err": err})
+	} else {
+		// Install agent into config drive dir if found.
+		lxdAgentSrcPath, err = filepath.EvalSymlinks(lxdAgentSrcPath)
+		if err != nil {
+			return err
+		}
+
+		lxdAgentSrcInfo, err := os.Stat(lxdAgentSrcPath)
 		if err != nil {
 			return errors.Wrapf(err, "Failed getting info for lxd-agent source %q", lxdAgentSrcPath)
 		}
@@ -2041,73 +2522,11 @@ func (d *qemu) generateConfigShare() error {
 		return err
 	}
 
-	lxdAgentServiceUnit := `[Unit]
-Description=LXD - agent
-Documentation=https://linuxcontainers.org/lxd
-ConditionPathExists=/dev/virtio-ports/org.linuxcontainers.lxd
-Before=cloud-init.target cloud-init.service cloud-init-local.service
-DefaultDependencies=no
-
-[Service]
-Type=notify
-WorkingDirectory=-/run/lxd_agent
-ExecStartPre=/lib/systemd/lxd-agent-setup
-ExecStart=/run/lxd_agent/lxd-agent
-Restart=on-failure
-RestartSec=5s
-StartLimitInterval=60
-StartLimitBurst=10
-
-[Install]
-WantedBy=multi-user.target
-`
-
 	err = ioutil.WriteFile(filepath.Join(configDrivePath, "systemd", "lxd-agent.service"), []byte(lxdAgentServiceUnit), 0400)
 	if err != nil {
 		return err
 	}
 
-	lxdAgentSetupScript := `#!/bin/sh
-set -eu
-PREFIX="/run/lxd_agent"
-
-# Functions.
-mount_virtiofs() {
-    mount -t virtiofs config "${PREFIX}/.mnt" >/dev/null 2>&1
-}
-
-mount_9p() {
-    /sbin/modprobe 9pnet_virtio >/dev/null 2>&1 || true
-    /bin/mount -t 9p config "${PREFIX}/.mnt" -o access=0,trans=virtio >/dev/null 2>&1
-}
-
-fail() {
-    umount -l "${PREFIX}" >/dev/null 2>&1 || true
-    rmdir "${PREFIX}" >/dev/null 2>&1 || true
-    echo "${1}"
-    exit 1
-}
-
-# Setup the mount target.
-umount -l "${PREFIX}" >/dev/null 2>&1 || true
-mkdir -p "${PREFIX}"
-mount -t tmpfs tmpfs "${PREFIX}" -o mode=0700,size=50M
-mkdir -p "${PREFIX}/.mnt"
-
-# Try virtiofs first.
-mount_virtiofs || mount_9p || fail "Couldn't mount virtiofs or 9p, failing."
-
-# Copy the data.
-cp -Ra "${PREFIX}/.mnt/"* "${PREFIX}"
-
-# Unmount the temporary mount.
-umount "${PREFIX}/.mnt"
-rmdir "${PREFIX}/.mnt"
-
-# Fix up permissions.
-chown -R root:root "${PREFIX}"
-`
-
 	err = ioutil.WriteFile(filepath.Join(configDrivePath, "systemd", "lxd-agent-setup"), []byte(lxdAgentSetupScript), 0500)
 	if err != nil {
 		return err
@@ -2119,7 +2538,6 @@ chown -R root:root "${PREFIX}"
 		return err
 	}
 
-	lxdAgentRules := `ACTION=="add", SYMLINK=="virtio-ports/org.linuxcontainers.lxd", TAG+="systemd", ACTION=="add", RUN+="/bin/systemctl start lxd-agent.service"`
 	err = ioutil.WriteFile(filepath.Join(configDrivePath, "udev", "99-lxd-agent.rules"), []byte(lxdAgentRules), 0400)
 	if err != nil {
 		return err
@@ -2209,6 +2627,99 @@ echo "To start it now, unmount this filesystem and run: systemctl start lxd-agen
 	return nil
 }
 
+// InstallVMAgent installs and enables the LXD agent units in the root filesystem of a systemd based guest,
+// as the install script of the config drive would do from within the guest.
+func InstallVMAgent(rootPath string) error {
+	if !shared.PathExists(filepath.Join(rootPath, "lib", "systemd", "system")) {
+		return fmt.Errorf("The LXD agent can only be installed on systemd systems")
+	}
+
+	err := ioutil.WriteFile(filepath.Join(rootPath, "lib", "systemd", "system", "lxd-agent.service"), []byte(lxdAgentServiceUnit), 0644)
+	if err != nil {
+		return err
+	}
+
+	err = ioutil.WriteFile(filepath.Join(rootPath, "lib", "systemd", "lxd-agent-setup"), []byte(lxdAgentSetupScript), 0755)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Join(rootPath, "lib", "udev", "rules.d"), 0755)
+	if err != nil {
+		return err
+	}
+
+	err = ioutil.WriteFile(filepath.Join(rootPath, "lib", "udev", "rules.d", "99-lxd-agent.rules"), []byte(lxdAgentRules), 0644)
+	if err != nil {
+		return err
+	}
+
+	wantsPath := filepath.Join(rootPath, "etc", "systemd", "system", "multi-user.target.wants")
+	err = os.MkdirAll(wantsPath, 0755)
+	if err != nil {
+		return err
+	}
+
+	err = os.Symlink("/lib/systemd/system/lxd-agent.service", filepath.Join(wantsPath, "lxd-agent.service"))
+	if err != nil && !os.IsExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+// generateCloudInitSeed generates a cloud-init NoCloud seed when the instance's image doesn't get its
+// cloud-init configuration from LXD, returning the path to the seed or an empty string if not needed.
+// Requires the instance be mounted before calling this function.
+func (d *qemu) generateCloudInitSeed() (string, error) {
+	seedPath := filepath.Join(d.Path(), "cloud-init-seed.iso")
+	os.Remove(seedPath)
+
+	// Skip if a cloud-init config drive is already attached.
+	for _, dev := range d.expandedDevices {
+		if dev["type"] == "disk" && dev["source"] == "cloud-init:config" {
+			return "", nil
+		}
+	}
+
+	// Skip instances not created from an image.
+	fname := filepath.Join(d.Path(), "metadata.yaml")
+	if !shared.PathExists(fname) {
+		return "", nil
+	}
+
+	content, err := ioutil.ReadFile(fname)
+	if err != nil {
+		return "", errors.Wrap(err, "Failed to read metadata")
+	}
+
+	metadata := new(api.ImageMetadata)
+	err = yaml.Unmarshal(content, &metadata)
+	if err != nil {
+		return "", errors.Wrapf(err, "Could not parse %s", fname)
+	}
+
+	// Images supporting LXD provide the cloud-init configuration through templates of the seed files.
+	for tplPath := range metadata.Templates {
+		if strings.HasPrefix(tplPath, "/var/lib/cloud/seed/") {
+			return "", nil
+		}
+	}
+
+	_, err = exec.LookPath("mkisofs")
+	if err != nil {
+		d.logger.Warn("mkisofs not found, skipping the cloud-init seed", log.Ctx{"err": err})
+		return "", nil
+	}
+
+	err = device.DiskVMCloudInitSeed(d.state, d, filepath.Join(d.DevicesPath(), "cloud-init-seed"), seedPath)
+	if err != nil {
+		return "", err
+	}
+
+	return seedPath, nil
+}
+
 func (d *qemu) templateApplyNow(trigger instance.TemplateTrigger, path string) error {
 	// If there's no metadata, just return.
 	fname := filepath.Join(d.Path(), "metadata.yaml")
@@ -2370,7 +2881,8 @@ func (d *qemu) generateQemuConfigFile(mountInfo *storagePools.MountInfo, busName
 	var monHooks []monitorHook
 
 	err := qemuBase.Execute(sb, map[string]interface{}{
-		"architecture": d.architectureName,
+		"architecture":   d.architectureName,
+		"consoleLogPath": d.ConsoleBufferLogPath(),
 	})
 	if err != nil {
 		return "", nil, err
@@ -2641,6 +3153,23 @@ func (d *qemu) generateQemuConfigFile(mountInfo *storagePools.MountInfo, busName
 
 	}
 
+	// Attach a cloud-init seed for images which can't otherwise get their configuration.
+	cloudInitSeedPath, err := d.generateCloudInitSeed()
+	if err != nil {
+		return "", nil, errors.Wrapf(err, "Failed generating cloud-init seed")
+	}
+
+	if cloudInitSeedPath != "" {
+		err = d.addDriveConfig(sb, bootIndexes, deviceConfig.MountEntryItem{
+			DevName: qemuCloudInitSeedDevName,
+			DevPath: cloudInitSeedPath,
+			FSType:  "iso9660",
+		})
+		if err != nil {
+			return "", nil, err
+		}
+	}
+
 	// Allocate 4 PCI slots for hotplug devices.
 	for i := 0; i < 4; i++ {
 		bus.allocate(busFunctionGroupNone)
@@ -2685,6 +3214,25 @@ func (d *qemu) addCPUMemoryConfig(sb *strings.Builder) (int, error) {
 		ctx["cpuCores"] = cpuCount
 		ctx["cpuThreads"] = 1
 		hostNodes = []uint64{0}
+
+		// Bind the memory to the selected NUMA nodes.
+		if d.localConfig["volatile.cpu.nodes"] != "" {
+			memHostNodes, err := resources.ParseCpuset(d.localConfig["volatile.cpu.nodes"])
+			if err != nil {
+				return -1, err
+			}
+
+			ctx["memHostNodes"] = memHostNodes
+		}
+
+		// Allow hotplugging CPUs up to the number of host CPUs.
+		if d.architecture == osarch.ARCH_64BIT_INTEL_X86 {
+			cpus, err := resources.GetCPU()
+			if err == nil && int(cpus.Total) > cpuCount {
+				ctx["cpuMaxCount"] = int(cpus.Total)
+				ctx["cpuCores"] = int(cpus.Total)
+			}
+		}
 	} else {
 		// Expand to a set of CPU identifiers and get the pinning map.
 		nrSockets, nrCores, nrThreads, vcpus, numaNodes, err := d.cpuTopology(cpus)
@@ -2765,10 +3313,21 @@ func (d *qemu) addCPUMemoryConfig(sb *strings.Builder) (int, error) {
 	memSizeBytes = nodeMemory * int64(len(hostNodes))
 	ctx["memory"] = nodeMemory
 
+	// Allow hotplugging memory up to the host memory size (not supported for huge pages).
+	memMaxSizeBytes := int64(0)
+	if d.architecture == osarch.ARCH_64BIT_INTEL_X86 && ctx["hugepages"] == "" {
+		memory, err := resources.GetMemory()
+		if err == nil && int64(memory.Total/1024/1024) > memSizeBytes {
+			memMaxSizeBytes = int64(memory.Total / 1024 / 1024)
+		}
+	}
+
 	if sb != nil {
 		err = qemuMemory.Execute(sb, map[string]interface{}{
-			"architecture": d.architectureName,
-			"memSizeBytes": memSizeBytes,
+			"architecture":    d.architectureName,
+			"memSizeBytes":    memSizeBytes,
+			"memMaxSizeBytes": memMaxSizeBytes,
+			"memSlots":        qemuMemoryHotplugSlots,
 		})
 
 		if err != nil {
@@ -2812,6 +3371,7 @@ func (d *qemu) addRootDriveConfig(sb *strings.Builder, mountInfo *storagePools.M
 	driveConf := deviceConfig.MountEntryItem{
 		DevName: rootDriveConf.DevName,
 		DevPath: mountInfo.DiskPath,
+		Limits:  rootDriveConf.Limits,
 	}
 
 	// If the storage pool is on ZFS and backed by a loop file and we can't use DirectIO, then resort to
@@ -2903,15 +3463,13 @@ func (d *qemu) addDriveDirConfig(sb *strings.Builder, bus *qemuBus, fdFiles *[]s
 	})
 }
 
-// addDriveConfig adds the qemu config required for adding a supplementary drive.
-func (d *qemu) addDriveConfig(sb *strings.Builder, bootIndexes map[string]int, driveConf deviceConfig.MountEntryItem) error {
+// driveIOModes returns the AIO mode, cache mode and media type to use for a drive.
+func (d *qemu) driveIOModes(driveConf deviceConfig.MountEntryItem) (string, string, string, error) {
 	// Use native kernel async IO and O_DIRECT by default.
 	aioMode := "native"
 	cacheMode := "none" // Bypass host cache, use O_DIRECT semantics.
 	media := "disk"
 
-	readonly := shared.StringInSlice("ro", driveConf.Opts)
-
 	// If drive config indicates we need to use unsafe I/O then use it.
 	if shared.StringInSlice(qemuUnsafeIO, driveConf.Opts) {
 		d.logger.Warn("Using unsafe cache I/O", log.Ctx{"DevPath": driveConf.DevPath})
@@ -2921,7 +3479,7 @@ func (d *qemu) addDriveConfig(sb *strings.Builder, bootIndexes map[string]int, d
 		// Disk dev path is a file, check whether it is located on a ZFS filesystem.
 		fsType, err := filesystem.Detect(driveConf.DevPath)
 		if err != nil {
-			return errors.Wrapf(err, "Failed detecting filesystem type of %q", driveConf.DevPath)
+			return "", "", "", errors.Wrapf(err, "Failed detecting filesystem type of %q", driveConf.DevPath)
 		}
 
 		// If backing FS is ZFS or BTRFS, avoid using direct I/O and use host page cache only.
@@ -2942,6 +3500,18 @@ func (d *qemu) addDriveConfig(sb *strings.Builder, bootIndexes map[string]int, d
 		}
 	}
 
+	return aioMode, cacheMode, media, nil
+}
+
+// addDriveConfig adds the qemu config required for adding a supplementary drive.
+func (d *qemu) addDriveConfig(sb *strings.Builder, bootIndexes map[string]int, driveConf deviceConfig.MountEntryItem) error {
+	aioMode, cacheMode, media, err := d.driveIOModes(driveConf)
+	if err != nil {
+		return err
+	}
+
+	readonly := shared.StringInSlice("ro", driveConf.Opts)
+
 	if !strings.HasPrefix(driveConf.DevPath, "rbd:") {
 		// Add path to external devPaths. This way, the path will be included in the apparmor profile.
 		d.devPaths = append(d.devPaths, driveConf.DevPath)
@@ -2956,6 +3526,7 @@ func (d *qemu) addDriveConfig(sb *strings.Builder, bootIndexes map[string]int, d
 		"media":     media,
 		"shared":    driveConf.TargetPath != "/" && !strings.HasPrefix(driveConf.DevPath, "rbd:"),
 		"readonly":  readonly,
+		"limits":    driveConf.Limits,
 	})
 }
 
@@ -3115,12 +3686,23 @@ func (d *qemu) addNetDevConfig(cpuCount int, busName string, qemuDev map[string]
 
 // addPCIDevConfig adds the qemu config required for adding a raw PCI device.
 func (d *qemu) addPCIDevConfig(sb *strings.Builder, bus *qemuBus, pciConfig []deviceConfig.RunConfigItem) error {
-	var devName, pciSlotName string
+	var devName, pciSlotName, pciIOMMUGroup string
 	for _, pciItem := range pciConfig {
 		if pciItem.Key == "devName" {
 			devName = pciItem.Value
 		} else if pciItem.Key == "pciSlotName" {
 			pciSlotName = pciItem.Value
+		} else if pciItem.Key == "pciIOMMUGroup" {
+			pciIOMMUGroup = pciItem.Value
+		}
+	}
+
+	// Let the unprivileged QEMU process access the VFIO group of the device.
+	if d.state.OS.UnprivUser != "" && pciIOMMUGroup != "" {
+		vfioGroupFile := fmt.Sprintf("/dev/vfio/%s", pciIOMMUGroup)
+		err := os.Chown(vfioGroupFile, int(d.state.OS.UnprivUID), -1)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to chown vfio group device %q", vfioGroupFile)
 		}
 	}
 
@@ -3245,29 +3827,21 @@ func (d *qemu) addGPUDevConfig(sb *strings.Builder, bus *qemuBus, gpuConfig []de
 }
 
 func (d *qemu) addUSBDeviceConfig(sb *strings.Builder, bus *qemuBus, usbConfig []deviceConfig.RunConfigItem) error {
-	var devName, hostDevice string
-
-	for _, usbItem := range usbConfig {
-		if usbItem.Key == "devName" {
-			devName = usbItem.Value
-		} else if usbItem.Key == "hostDevice" {
-			hostDevice = usbItem.Value
+	for _, usbDev := range qemuUSBDevices(usbConfig) {
+		tplFields := map[string]interface{}{
+			"hostDevice": usbDev["hostDevice"],
+			"devName":    usbDev["devName"],
 		}
-	}
 
-	tplFields := map[string]interface{}{
-		"hostDevice": hostDevice,
-		"devName":    devName,
-	}
+		err := qemuUSBDev.Execute(sb, tplFields)
+		if err != nil {
+			return err
+		}
 
-	err := qemuUSBDev.Execute(sb, tplFields)
-	if err != nil {
-		return err
+		// Add path to external devPaths. This way, the path will be included in the apparmor profile.
+		d.devPaths = append(d.devPaths, usbDev["hostDevice"])
 	}
 
-	// Add path to external devPaths. This way, the path will be included in the apparmor profile.
-	d.devPaths = append(d.devPaths, hostDevice)
-
 	return nil
 }
 
@@ -3342,7 +3916,7 @@ func (d *qemu) forceStop() error {
 		}
 
 		// Wait for QEMU process to exit and perform device cleanup.
-		err = d.onStop("stop")
+		err = d.onStop("stop", false)
 		if err != nil {
 			return err
 		}
@@ -3353,6 +3927,22 @@ func (d *qemu) forceStop() error {
 	return nil
 }
 
+// crashStop kills the QEMU process of a guest which crashed and performs the stop cleanup, restarting the
+// instance if boot.autorestart is enabled.
+func (d *qemu) crashStop() error {
+	pid, _ := d.pid()
+	if pid <= 0 {
+		return nil
+	}
+
+	err := d.killQemuProcess(pid)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to stop VM process %d", pid)
+	}
+
+	return d.onStop("stop", true)
+}
+
 // Stop the VM.
 func (d *qemu) Stop(stateful bool) error {
 	d.logger.Debug("Stop started", log.Ctx{"stateful": stateful})
@@ -3732,6 +4322,15 @@ func (d *qemu) Rename(newName string, applyTemplateTrigger bool) error {
 		}
 	}
 
+	// Rename the exec session recordings.
+	if !d.IsSnapshot() && shared.PathExists(d.ExecRecordingsPath()) {
+		err := os.Rename(d.ExecRecordingsPath(), shared.VarPath("recordings", project.Instance(d.Project(), newName)))
+		if err != nil {
+			d.logger.Error("Failed renaming instance", ctxMap)
+			return errors.Wrap(err, "Failed renaming instance exec session recordings")
+		}
+	}
+
 	// Rename the MAAS entry.
 	if !d.IsSnapshot() {
 		err = d.maasRename(d, newName)
@@ -3985,7 +4584,7 @@ func (d *qemu) Update(args db.InstanceArgs, userRequested bool) error {
 	}
 
 	// If apparmor changed, re-validate the apparmor profile (even if not running).
-	if shared.StringInSlice("raw.apparmor", changedConfig) {
+	if apparmor.InstanceRawChanged(changedConfig) {
 		err = apparmor.InstanceValidate(d.state, d)
 		if err != nil {
 			return errors.Wrap(err, "Parse AppArmor profile")
@@ -4002,7 +4601,7 @@ func (d *qemu) Update(args db.InstanceArgs, userRequested bool) error {
 
 	if isRunning {
 		// Only certain keys can be changed on a running VM.
-		liveUpdateKeys := []string{"limits.memory"}
+		liveUpdateKeys := []string{"limits.cpu", "limits.memory"}
 
 		// Check only keys that support live update have changed.
 		for _, key := range changedConfig {
@@ -4015,7 +4614,12 @@ func (d *qemu) Update(args db.InstanceArgs, userRequested bool) error {
 		for _, key := range changedConfig {
 			value := d.expandedConfig[key]
 
-			if key == "limits.memory" {
+			if key == "limits.cpu" {
+				err = d.updateCPULimit(value)
+				if err != nil {
+					return errors.Wrapf(err, "Failed updating CPU limit")
+				}
+			} else if key == "limits.memory" {
 				err = d.updateMemoryLimit(value)
 				if err != nil {
 					if err != nil {
@@ -4155,42 +4759,156 @@ func (d *qemu) updateMemoryLimit(newLimit string) error {
 	}
 	curSizeMB := curSizeBytes / 1024 / 1024
 
-	if curSizeMB == newSizeMB {
-		return nil
-	} else if baseSizeMB < newSizeMB {
-		return fmt.Errorf("Cannot increase memory size beyond boot time size when VM is running (Boot time size %dMiB, new size %dMiB)", baseSizeMB, newSizeMB)
+	if curSizeMB == newSizeMB {
+		return nil
+	} else if baseSizeMB < newSizeMB {
+		if d.architecture != osarch.ARCH_64BIT_INTEL_X86 {
+			return fmt.Errorf("Cannot increase memory size beyond boot time size when VM is running (Boot time size %dMiB, new size %dMiB)", baseSizeMB, newSizeMB)
+		}
+
+		// Hotplug the missing memory, aligned on the guest memory block size. The balloon takes care of
+		// any excess.
+		plugSizeMB := newSizeMB - baseSizeMB
+		if plugSizeMB%qemuMemoryHotplugAlignMB != 0 {
+			plugSizeMB += qemuMemoryHotplugAlignMB - plugSizeMB%qemuMemoryHotplugAlignMB
+		}
+
+		memID := uuid.New()
+		err = monitor.AddMemory(fmt.Sprintf("qemu_mem-%s", memID), fmt.Sprintf("qemu_dimm-%s", memID), plugSizeMB*1024*1024)
+		if err != nil {
+			return errors.Wrapf(err, "Failed hotplugging %dMiB of memory", plugSizeMB)
+		}
+
+		d.agentOnlineHotplugged()
+	}
+
+	// Set effective memory size.
+	err = monitor.SetMemoryBalloonSizeBytes(newSizeBytes)
+	if err != nil {
+		return err
+	}
+
+	// Changing the memory balloon can take time, so poll the effectice size to check it has shrunk within 1%
+	// of the target size, which we then take as success (it may still continue to shrink closer to target).
+	for i := 0; i < 10; i++ {
+		curSizeBytes, err = monitor.GetMemoryBalloonSizeBytes()
+		if err != nil {
+			return err
+		}
+		curSizeMB = curSizeBytes / 1024 / 1024
+
+		var diff int64
+		if curSizeMB < newSizeMB {
+			diff = newSizeMB - curSizeMB
+		} else {
+			diff = curSizeMB - newSizeMB
+		}
+
+		if diff <= (newSizeMB / 100) {
+			return nil // We reached to within 1% of our target size.
+		}
+
+		time.Sleep(500 * time.Millisecond)
+	}
+
+	return fmt.Errorf("Failed setting memory to %dMiB (currently %dMiB) as it was taking too long", newSizeMB, curSizeMB)
+}
+
+// updateCPULimit hotplugs vCPUs into the running VM until it has as many as the new limit.
+func (d *qemu) updateCPULimit(newLimit string) error {
+	if newLimit == "" {
+		newLimit = "1"
+	}
+
+	newCount, err := strconv.Atoi(newLimit)
+	if err != nil {
+		return fmt.Errorf("Cannot change CPU pinning when VM is running")
+	}
+
+	// Connect to the monitor.
+	monitor, err := qmp.Connect(d.monitorPath(), qemuSerialChardevName, d.getMonitorEventHandler())
+	if err != nil {
+		return err // The VM isn't running as no monitor socket available.
+	}
+
+	cpus, err := monitor.QueryHotpluggableCPUs()
+	if err != nil {
+		return err
+	}
+
+	curCount := 0
+	for _, cpu := range cpus {
+		if cpu.QOMPath != "" {
+			curCount++
+		}
+	}
+
+	if newCount == curCount {
+		return nil
+	} else if newCount < curCount {
+		return fmt.Errorf("Cannot decrease CPU count when VM is running (Current count %d, new count %d)", curCount, newCount)
+	} else if newCount > len(cpus) {
+		return fmt.Errorf("Cannot increase CPU count beyond %d when VM is running (New count %d)", len(cpus), newCount)
+	}
+
+	for _, cpu := range cpus {
+		if curCount == newCount {
+			break
+		}
+
+		if cpu.QOMPath != "" {
+			continue
+		}
+
+		qemuDev := map[string]string{
+			"id":     fmt.Sprintf("qemu_cpu%d", curCount),
+			"driver": cpu.Type,
+		}
+
+		for prop, value := range cpu.Props {
+			qemuDev[prop] = strconv.Itoa(value)
+		}
+
+		err = monitor.AddDevice(qemuDev)
+		if err != nil {
+			return errors.Wrapf(err, "Failed hotplugging CPU")
+		}
+
+		curCount++
 	}
 
-	// Set effective memory size.
-	err = monitor.SetMemoryBalloonSizeBytes(newSizeBytes)
+	// Restrict the new vCPUs to the selected NUMA nodes.
+	err = d.setCPUNodesAffinity(monitor)
 	if err != nil {
 		return err
 	}
 
-	// Changing the memory balloon can take time, so poll the effectice size to check it has shrunk within 1%
-	// of the target size, which we then take as success (it may still continue to shrink closer to target).
-	for i := 0; i < 10; i++ {
-		curSizeBytes, err = monitor.GetMemoryBalloonSizeBytes()
-		if err != nil {
-			return err
-		}
-		curSizeMB = curSizeBytes / 1024 / 1024
+	d.agentOnlineHotplugged()
 
-		var diff int64
-		if curSizeMB < newSizeMB {
-			diff = newSizeMB - curSizeMB
-		} else {
-			diff = curSizeMB - newSizeMB
-		}
+	return nil
+}
 
-		if diff <= (newSizeMB / 100) {
-			return nil // We reached to within 1% of our target size.
-		}
+// agentOnlineHotplugged asks the agent to bring the hotplugged CPUs and memory online in the guest, which
+// not all guests do on their own.
+func (d *qemu) agentOnlineHotplugged() {
+	client, err := d.getAgentClient()
+	if err != nil {
+		d.logger.Warn("Failed getting lxd-agent client to online hotplugged resources", log.Ctx{"err": err})
+		return
+	}
 
-		time.Sleep(500 * time.Millisecond)
+	agent, err := lxd.ConnectLXDHTTP(nil, client)
+	if err != nil {
+		d.logger.Warn("Failed connecting to lxd-agent to online hotplugged resources", log.Ctx{"err": err})
+		return
 	}
 
-	return fmt.Errorf("Failed setting memory to %dMiB (currently %dMiB) as it was taking too long", newSizeMB, curSizeMB)
+	defer agent.Disconnect()
+
+	_, _, err = agent.RawQuery("POST", "/1.0/hotplug", nil, "")
+	if err != nil {
+		d.logger.Warn("Failed onlining hotplugged resources", log.Ctx{"err": err})
+	}
 }
 
 func (d *qemu) updateDevices(removeDevices deviceConfig.Devices, addDevices deviceConfig.Devices, updateDevices deviceConfig.Devices, oldExpandedDevices deviceConfig.Devices, instanceRunning bool, userRequested bool) error {
@@ -4472,6 +5190,12 @@ func (d *qemu) Delete(force bool) error {
 			}
 		}
 
+		// Remove the exec session recordings.
+		err = os.RemoveAll(d.ExecRecordingsPath())
+		if err != nil {
+			return errors.Wrap(err, "Failed to remove exec session recordings")
+		}
+
 		// Clean things up.
 		d.cleanup()
 	}
@@ -4801,9 +5525,30 @@ func (d *qemu) CGroup() (*cgroup.CGroup, error) {
 	return nil, instance.ErrNotImplemented
 }
 
-// FileExists is not implemented for VMs.
+// FileExists returns whether a file exists inside the instance.
 func (d *qemu) FileExists(path string) error {
-	return instance.ErrNotImplemented
+	client, err := d.getAgentClient()
+	if err != nil {
+		return err
+	}
+
+	agent, err := lxd.ConnectLXDHTTP(nil, client)
+	if err != nil {
+		d.logger.Error("Failed to connect to lxd-agent", log.Ctx{"err": err})
+		return fmt.Errorf("Failed to connect to lxd-agent")
+	}
+	defer agent.Disconnect()
+
+	content, _, err := agent.GetInstanceFile("", path)
+	if err != nil {
+		return err
+	}
+
+	if content != nil {
+		content.Close()
+	}
+
+	return nil
 }
 
 // FilePull retrieves a file from the instance.
@@ -4827,29 +5572,36 @@ func (d *qemu) FilePull(srcPath string, dstPath string) (int64, int64, os.FileMo
 
 	switch resp.Type {
 	case "file", "symlink":
-		data, err := ioutil.ReadAll(content)
+		defer content.Close()
+
+		f, err := os.OpenFile(dstPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, os.FileMode(resp.Mode))
 		if err != nil {
 			return 0, 0, 0, "", nil, err
 		}
+		defer f.Close()
 
-		err = ioutil.WriteFile(dstPath, data, os.FileMode(resp.Mode))
+		_, err = io.Copy(f, content)
 		if err != nil {
 			return 0, 0, 0, "", nil, err
 		}
 
-		err = os.Lchown(dstPath, int(resp.UID), int(resp.GID))
+		err = f.Close()
 		if err != nil {
 			return 0, 0, 0, "", nil, err
 		}
 
-		return resp.UID, resp.GID, os.FileMode(resp.Mode), resp.Type, nil, nil
+		err = os.Lchown(dstPath, int(resp.UID), int(resp.GID))
+		if err != nil {
+			return 0, 0, 0, "", nil, err
+		}
 	case "directory":
-		return resp.UID, resp.GID, os.FileMode(resp.Mode), resp.Type, resp.Entries, nil
+	default:
+		return 0, 0, 0, "", nil, fmt.Errorf("bad file type %s", resp.Type)
 	}
 
 	d.state.Events.SendLifecycle(d.project, lifecycle.InstanceFileRetrieved.Event(d, log.Ctx{"file-source": srcPath, "file-destination": dstPath}))
 
-	return 0, 0, 0, "", nil, fmt.Errorf("bad file type %s", resp.Type)
+	return resp.UID, resp.GID, os.FileMode(resp.Mode), resp.Type, resp.Entries, nil
 }
 
 // FilePush pushes a file into the instance.
@@ -4883,12 +5635,8 @@ func (d *qemu) FilePush(fileType string, srcPath string, dstPath string, uid int
 
 		args.Content = f
 	} else if fileType == "symlink" {
-		symlinkTarget, err := os.Readlink(dstPath)
-		if err != nil {
-			return err
-		}
-
-		args.Content = bytes.NewReader([]byte(symlinkTarget))
+		// The source path is the target of the symlink.
+		args.Content = bytes.NewReader([]byte(srcPath))
 	}
 
 	err = agent.CreateInstanceFile("", dstPath, args)
@@ -4901,6 +5649,60 @@ func (d *qemu) FilePush(fileType string, srcPath string, dstPath string, uid int
 	return nil
 }
 
+// FileTreePull writes the file tree at srcPath in the instance to target as a tar stream.
+func (d *qemu) FileTreePull(srcPath string, target io.Writer) error {
+	client, err := d.getAgentClient()
+	if err != nil {
+		return err
+	}
+
+	agent, err := lxd.ConnectLXDHTTP(nil, client)
+	if err != nil {
+		d.logger.Error("Failed to connect to lxd-agent", log.Ctx{"err": err})
+		return fmt.Errorf("Failed to connect to lxd-agent")
+	}
+	defer agent.Disconnect()
+
+	content, err := agent.GetInstanceFileTree("", srcPath)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	_, err = io.Copy(target, content)
+	if err != nil {
+		return err
+	}
+
+	d.state.Events.SendLifecycle(d.project, lifecycle.InstanceFileRetrieved.Event(d, log.Ctx{"file-source": srcPath, "format": "tar"}))
+
+	return nil
+}
+
+// FileTreePush extracts the tar stream read from source into dstPath in the instance.
+func (d *qemu) FileTreePush(source io.Reader, dstPath string) error {
+	client, err := d.getAgentClient()
+	if err != nil {
+		return err
+	}
+
+	agent, err := lxd.ConnectLXDHTTP(nil, client)
+	if err != nil {
+		d.logger.Error("Failed to connect to lxd-agent", log.Ctx{"err": err})
+		return fmt.Errorf("Failed to connect to lxd-agent")
+	}
+	defer agent.Disconnect()
+
+	err = agent.CreateInstanceFileTree("", dstPath, source)
+	if err != nil {
+		return err
+	}
+
+	d.state.Events.SendLifecycle(d.project, lifecycle.InstanceFilePushed.Event(d, log.Ctx{"file-destination": dstPath, "format": "tar"}))
+
+	return nil
+}
+
 // FileRemove removes a file from the instance.
 func (d *qemu) FileRemove(path string) error {
 	// Connect to the agent.
@@ -4979,6 +5781,22 @@ func (d *qemu) console() (*os.File, chan error, error) {
 	return console, chDisconnect, nil
 }
 
+// trimConsoleBuffer truncates the console log file, which QEMU appends to, to the size of the scrollback buffer.
+// While the instance runs, the file is also trimmed periodically by LXD with instance.ConsoleBufferTrim.
+func (d *qemu) trimConsoleBuffer() error {
+	size, err := instance.ConsoleBufferSize(d.expandedConfig)
+	if err != nil {
+		return err
+	}
+
+	buf, err := instance.ConsoleBufferRead(d.ConsoleBufferLogPath(), size)
+	if err != nil {
+		return errors.Wrapf(err, "Failed reading console log")
+	}
+
+	return ioutil.WriteFile(d.ConsoleBufferLogPath(), buf, 0600)
+}
+
 func (d *qemu) vga() (*os.File, chan error, error) {
 	// Open the spice socket
 	conn, err := net.Dial("unix", d.spicePath())
@@ -5275,6 +6093,8 @@ func (d *qemu) renderState(statusCode api.StatusCode) (*api.InstanceState, error
 	status.Pid = int64(pid)
 	status.Status = statusCode.String()
 	status.StatusCode = statusCode
+	status.Restarts = d.autorestartCount()
+	status.Health = d.healthState(statusCode)
 	status.Disk, err = d.diskState()
 	if err != nil && errors.Cause(err) != storageDrivers.ErrNotSupported {
 		d.logger.Warn("Error getting disk usage", log.Ctx{"err": err})
@@ -5343,6 +6163,38 @@ func (d *qemu) agentGetState() (*api.InstanceState, error) {
 	return status, nil
 }
 
+// HealthProbe runs a TCP or HTTP probe from inside the VM through the agent.
+func (d *qemu) HealthProbe(probeType string, address string, timeout time.Duration) error {
+	client, err := d.getAgentClient()
+	if err != nil {
+		return err
+	}
+
+	agent, err := lxd.ConnectLXDHTTP(nil, client)
+	if err != nil {
+		return errors.Wrapf(err, "Failed connecting to agent")
+	}
+	defer agent.Disconnect()
+
+	probe := api.InstanceHealthProbe{
+		Type:    probeType,
+		Address: address,
+		Timeout: int64(timeout / time.Second),
+	}
+
+	_, _, err = agent.RawQuery("POST", "/1.0/healthcheck", probe, "")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Autorestart stops the VM and restarts it as allowed by its automatic restart policy.
+func (d *qemu) Autorestart() error {
+	return d.autorestartCommon(d)
+}
+
 // IsRunning returns whether or not the instance is running.
 func (d *qemu) IsRunning() bool {
 	return d.isRunningStatusCode(d.statusCode())
@@ -5360,7 +6212,56 @@ func (d *qemu) IsMigratable() bool {
 
 // DeviceEventHandler handles events occurring on the instance's devices.
 func (d *qemu) DeviceEventHandler(runConf *deviceConfig.RunConfig) error {
-	return fmt.Errorf("DeviceEventHandler Not implemented")
+	if !d.IsRunning() || runConf == nil {
+		return nil
+	}
+
+	// Attach or detach host USB devices as they're plugged in or removed.
+	for _, usbDev := range qemuUSBDevices(runConf.USBDevice) {
+		usbConfig := []deviceConfig.RunConfigItem{
+			{Key: "devName", Value: usbDev["devName"]},
+			{Key: "hostDevice", Value: usbDev["hostDevice"]},
+		}
+
+		if usbDev["action"] == "remove" {
+			monitor, err := qmp.Connect(d.monitorPath(), qemuSerialChardevName, d.getMonitorEventHandler())
+			if err != nil {
+				return err
+			}
+
+			err = monitor.RemoveDevice(fmt.Sprintf("%s%s", qemuDeviceIDPrefix, usbDev["devName"]))
+			if err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		err := d.deviceAttachUSB(usbConfig)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Apply disk I/O limit changes.
+	for _, mount := range runConf.Mounts {
+		if mount.Limits == nil {
+			continue
+		}
+
+		monitor, err := qmp.Connect(d.monitorPath(), qemuSerialChardevName, d.getMonitorEventHandler())
+		if err != nil {
+			return err
+		}
+
+		deviceID := fmt.Sprintf("%s%s", qemuDeviceIDPrefix, mount.DevName)
+		err = monitor.SetBlockThrottle(deviceID, mount.Limits.ReadBytes, mount.Limits.WriteBytes, mount.Limits.ReadIOps, mount.Limits.WriteIOps)
+		if err != nil {
+			return errors.Wrapf(err, "Failed applying limits for disk device %q", mount.DevName)
+		}
+	}
+
+	return nil
 }
 
 // vsockID returns the vsock context ID, 3 being the first ID that can be used.
@@ -5507,6 +6408,138 @@ func (d *qemu) UpdateBackupFile() error {
 	return pool.UpdateInstanceBackupFile(d, nil)
 }
 
+// selectCPUNodes returns the NUMA nodes that a VM using a number of CPUs should be bound to, based on
+// limits.cpu.nodes. A single node that can fit all of the vCPUs and memory is preferred in order to avoid
+// splitting the VM across nodes, otherwise all the requested nodes are used.
+func (d *qemu) selectCPUNodes() (string, error) {
+	nodes := d.expandedConfig["limits.cpu.nodes"]
+	if nodes == "" {
+		return "", nil
+	}
+
+	nodeIDs, err := resources.ParseCpuset(nodes)
+	if err != nil {
+		return "", errors.Wrapf(err, "Failed parsing limits.cpu.nodes")
+	}
+
+	nodeCPUs, err := resources.GetNUMANodeCPUs(nodes)
+	if err != nil {
+		return "", err
+	}
+
+	cpus := d.expandedConfig["limits.cpu"]
+	if cpus == "" {
+		cpus = "1"
+	}
+
+	cpuCount, err := strconv.Atoi(cpus)
+	if err != nil {
+		// Pinned VMs get their NUMA layout from the pinned CPUs, so just check they're part of the nodes.
+		pins, err := resources.ParseCpuset(cpus)
+		if err != nil {
+			return "", err
+		}
+
+		for _, pin := range pins {
+			if !shared.Int64InSlice(pin, nodeCPUs) {
+				return "", fmt.Errorf("CPU %d in limits.cpu isn't part of the NUMA nodes in limits.cpu.nodes", pin)
+			}
+		}
+
+		return "", nil
+	}
+
+	memSize := d.expandedConfig["limits.memory"]
+	if memSize == "" {
+		memSize = qemuDefaultMemSize
+	}
+
+	memSizeBytes, err := units.ParseByteSizeString(memSize)
+	if err != nil {
+		return "", fmt.Errorf("limits.memory invalid: %v", err)
+	}
+
+	hugepages := shared.IsTrue(d.expandedConfig["limits.memory.hugepages"])
+
+	memory, err := resources.GetMemory()
+	if err != nil {
+		return "", err
+	}
+
+	// Without NUMA support there is nothing to bind to.
+	if len(memory.Nodes) == 0 {
+		return "", nil
+	}
+
+	// Look for the requested node with the most free memory that can fit the whole VM.
+	bestNode := ""
+	bestFree := uint64(0)
+	totalFree := uint64(0)
+	for _, node := range memory.Nodes {
+		if !shared.Int64InSlice(int64(node.NUMANode), nodeIDs) {
+			continue
+		}
+
+		free := node.Total - node.Used
+		if hugepages {
+			free = node.HugepagesTotal - node.HugepagesUsed
+		}
+
+		totalFree += free
+
+		if len(node.CPUs) < cpuCount || free < uint64(memSizeBytes) {
+			continue
+		}
+
+		if bestNode == "" || free > bestFree {
+			bestNode = fmt.Sprintf("%d", node.NUMANode)
+			bestFree = free
+		}
+	}
+
+	if bestNode != "" {
+		return bestNode, nil
+	}
+
+	if hugepages && totalFree < uint64(memSizeBytes) {
+		return "", fmt.Errorf("Not enough free huge pages on NUMA nodes %q", nodes)
+	}
+
+	return nodes, nil
+}
+
+// setCPUNodesAffinity restricts the vCPU threads of a VM using a number of CPUs to its NUMA nodes.
+func (d *qemu) setCPUNodesAffinity(monitor *qmp.Monitor) error {
+	nodes := d.localConfig["volatile.cpu.nodes"]
+	if nodes == "" {
+		return nil
+	}
+
+	nodeCPUs, err := resources.GetNUMANodeCPUs(nodes)
+	if err != nil {
+		return err
+	}
+
+	set := unix.CPUSet{}
+	for _, cpu := range nodeCPUs {
+		set.Set(int(cpu))
+	}
+
+	pids, err := monitor.GetCPUs()
+	if err != nil {
+		return err
+	}
+
+	for _, pid := range pids {
+		err := unix.SchedSetaffinity(pid, &set)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // cpuTopology takes a user cpu range and returns the number of sockets, cores and threads to configure
 // as well as a map of vcpu to threadid for pinning and a map of numa nodes to vcpus for NUMA layout.
 func (d *qemu) cpuTopology(limit string) (int, int, int, map[uint64]uint64, map[uint64][]uint64, error) {
@@ -43,12 +43,18 @@ strict = "on"
 # Console
 [chardev "console"]
 backend = "pty"
+logfile = "{{.consoleLogPath}}"
+logappend = "on"
 `))
 
 var qemuMemory = template.Must(template.New("qemuMemory").Parse(`
 # Memory
 [memory]
 size = "{{.memSizeBytes}}M"
+{{- if .memMaxSizeBytes}}
+maxmem = "{{.memMaxSizeBytes}}M"
+slots = "{{.memSlots}}"
+{{- end}}
 `))
 
 var qemuSerial = template.Must(template.New("qemuSerial").Parse(`
@@ -237,6 +243,9 @@ var qemuCPU = template.Must(template.New("qemuCPU").Parse(`
 # CPU
 [smp-opts]
 cpus = "{{.cpuCount}}"
+{{- if .cpuMaxCount}}
+maxcpus = "{{.cpuMaxCount}}"
+{{- end}}
 sockets = "{{.cpuSockets}}"
 cores = "{{.cpuCores}}"
 threads = "{{.cpuThreads}}"
@@ -276,6 +285,12 @@ qom-type = "memory-backend-memfd"
 {{- end }}
 size = "{{$memory}}M"
 share = "on"
+{{- if .memHostNodes}}
+{{range .memHostNodes -}}
+host-nodes = "{{.}}"
+{{end -}}
+policy = "bind"
+{{- end}}
 
 [numa]
 type = "node"
@@ -438,6 +453,20 @@ readonly = "on"
 {{- else}}
 readonly = "off"
 {{- end}}
+{{- if .limits}}
+{{- if .limits.ReadBytes}}
+throttling.bps-read = "{{.limits.ReadBytes}}"
+{{- end}}
+{{- if .limits.WriteBytes}}
+throttling.bps-write = "{{.limits.WriteBytes}}"
+{{- end}}
+{{- if .limits.ReadIOps}}
+throttling.iops-read = "{{.limits.ReadIOps}}"
+{{- end}}
+{{- if .limits.WriteIOps}}
+throttling.iops-write = "{{.limits.WriteIOps}}"
+{{- end}}
+{{- end}}
 
 [device "dev-lxd_{{.devName}}"]
 {{- if eq .media "disk" }}
@@ -208,12 +208,13 @@ func (m *Monitor) GetCPUs() ([]int, error) {
 	return pids, nil
 }
 
-// GetMemorySizeBytes returns the current size of the base memory in bytes.
+// GetMemorySizeBytes returns the current size of the base and hotplugged memory in bytes.
 func (m *Monitor) GetMemorySizeBytes() (int64, error) {
 	// Prepare the response.
 	var resp struct {
 		Return struct {
-			BaseMemory int64 `json:"base-memory"`
+			BaseMemory    int64 `json:"base-memory"`
+			PluggedMemory int64 `json:"plugged-memory"`
 		} `json:"return"`
 	}
 
@@ -222,7 +223,7 @@ func (m *Monitor) GetMemorySizeBytes() (int64, error) {
 		return -1, err
 	}
 
-	return resp.Return.BaseMemory, nil
+	return resp.Return.BaseMemory + resp.Return.PluggedMemory, nil
 }
 
 // GetMemoryBalloonSizeBytes returns effective size of the memory in bytes (considering the current balloon size).
@@ -247,6 +248,55 @@ func (m *Monitor) SetMemoryBalloonSizeBytes(sizeBytes int64) error {
 	return m.run("balloon", fmt.Sprintf("{'value': %d}", sizeBytes), nil)
 }
 
+// HotpluggableCPU represents a CPU slot of the VM.
+type HotpluggableCPU struct {
+	Type    string         `json:"type"`
+	QOMPath string         `json:"qom-path"`
+	Props   map[string]int `json:"props"`
+}
+
+// QueryHotpluggableCPUs returns the CPU slots of the VM (the ones in use having a QOM path).
+func (m *Monitor) QueryHotpluggableCPUs() ([]HotpluggableCPU, error) {
+	// Prepare the response.
+	var resp struct {
+		Return []HotpluggableCPU `json:"return"`
+	}
+
+	err := m.run("query-hotpluggable-cpus", "", &resp)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed querying hotpluggable CPUs")
+	}
+
+	return resp.Return, nil
+}
+
+// AddMemory adds a memory backend of the given size and the DIMM device using it.
+func (m *Monitor) AddMemory(memDevID string, dimmID string, sizeBytes int64) error {
+	revert := revert.New()
+	defer revert.Fail()
+
+	err := m.run("object-add", fmt.Sprintf("{'qom-type': 'memory-backend-memfd', 'id': '%s', 'size': %d, 'share': true}", memDevID, sizeBytes), nil)
+	if err != nil {
+		return errors.Wrapf(err, "Failed adding memory backend")
+	}
+
+	revert.Add(func() {
+		m.run("object-del", fmt.Sprintf("{'id': '%s'}", memDevID), nil)
+	})
+
+	err = m.AddDevice(map[string]string{
+		"id":     dimmID,
+		"driver": "pc-dimm",
+		"memdev": memDevID,
+	})
+	if err != nil {
+		return err
+	}
+
+	revert.Success()
+	return nil
+}
+
 // AddNIC adds a NIC device.
 func (m *Monitor) AddNIC(netDev map[string]interface{}, device map[string]string) error {
 	revert := revert.New()
@@ -338,6 +388,156 @@ func (m *Monitor) RemoveNIC(netDevID string, deviceID string) error {
 	return nil
 }
 
+// AddFdSet adds a new file descriptor to a new FD set, which QEMU can then open as /dev/fdset/<id>.
+func (m *Monitor) AddFdSet(name string, file *os.File) (int, error) {
+	// Check if disconnected
+	if m.disconnected {
+		return -1, ErrMonitorDisconnect
+	}
+
+	out, err := m.qmp.RunWithFile([]byte(fmt.Sprintf("{'execute': 'add-fd', 'arguments': {'opaque': '%s'}}", name)), file)
+	if err != nil {
+		// Confirm the daemon didn't die.
+		errPing := m.ping()
+		if errPing != nil {
+			return -1, errPing
+		}
+
+		return -1, errors.Wrapf(err, "Failed adding file descriptor")
+	}
+
+	var resp struct {
+		Return struct {
+			FdSetID int `json:"fdset-id"`
+		} `json:"return"`
+	}
+
+	err = json.Unmarshal(out, &resp)
+	if err != nil {
+		return -1, ErrMonitorBadReturn
+	}
+
+	return resp.Return.FdSetID, nil
+}
+
+// RemoveFdSet removes an FD set. The file descriptors still in use by QEMU are closed once released.
+func (m *Monitor) RemoveFdSet(fdSetID int) error {
+	err := m.run("remove-fd", fmt.Sprintf("{'fdset-id': %d}", fdSetID), nil)
+	if err != nil {
+		return errors.Wrapf(err, "Failed removing file descriptor set")
+	}
+
+	return nil
+}
+
+// AddDevice adds a device.
+func (m *Monitor) AddDevice(device map[string]string) error {
+	args, err := json.Marshal(device)
+	if err != nil {
+		return err
+	}
+
+	err = m.run("device_add", string(args), nil)
+	if err != nil {
+		return errors.Wrapf(err, "Failed adding device")
+	}
+
+	return nil
+}
+
+// RemoveDevice removes a device.
+func (m *Monitor) RemoveDevice(deviceID string) error {
+	args, err := json.Marshal(map[string]string{"id": deviceID})
+	if err != nil {
+		return err
+	}
+
+	err = m.run("device_del", string(args), nil)
+
+	// If the device has already been removed then all good.
+	if err != nil && !strings.Contains(err.Error(), "not found") {
+		return errors.Wrapf(err, "Failed removing device")
+	}
+
+	return nil
+}
+
+// AddBlockDevice adds a block device and the device using it.
+func (m *Monitor) AddBlockDevice(blockDev map[string]interface{}, device map[string]string) error {
+	revert := revert.New()
+	defer revert.Fail()
+
+	args, err := json.Marshal(blockDev)
+	if err != nil {
+		return err
+	}
+
+	err = m.run("blockdev-add", string(args), nil)
+	if err != nil {
+		return errors.Wrapf(err, "Failed adding block device")
+	}
+
+	revert.Add(func() {
+		m.RemoveBlockDevice(fmt.Sprintf("%v", blockDev["node-name"]))
+	})
+
+	err = m.AddDevice(device)
+	if err != nil {
+		return err
+	}
+
+	revert.Success()
+	return nil
+}
+
+// RemoveBlockDevice removes a block device (once the device using it has been removed).
+func (m *Monitor) RemoveBlockDevice(blockDevName string) error {
+	args, err := json.Marshal(map[string]string{"node-name": blockDevName})
+	if err != nil {
+		return err
+	}
+
+	err = m.run("blockdev-del", string(args), nil)
+
+	// Block devices added through the command line are removed along with their device.
+	if err != nil && !strings.Contains(err.Error(), "Failed to find node") {
+		return errors.Wrapf(err, "Failed removing block device")
+	}
+
+	return nil
+}
+
+// SetBlockThrottle applies I/O limits to the drive used by a device (zero meaning unlimited).
+func (m *Monitor) SetBlockThrottle(deviceID string, bytesRead int64, bytesWrite int64, iopsRead int64, iopsWrite int64) error {
+	var args struct {
+		ID         string `json:"id"`
+		Bytes      int64  `json:"bps"`
+		BytesRead  int64  `json:"bps_rd"`
+		BytesWrite int64  `json:"bps_wr"`
+		IOPs       int64  `json:"iops"`
+		IOPsRead   int64  `json:"iops_rd"`
+		IOPsWrite  int64  `json:"iops_wr"`
+	}
+
+	args.ID = deviceID
+	args.BytesRead = bytesRead
+	args.BytesWrite = bytesWrite
+	args.IOPsRead = iopsRead
+	args.IOPsWrite = iopsWrite
+
+	data, err := json.Marshal(args)
+	if err != nil {
+		return err
+	}
+
+	err = m.run("block_set_io_throttle", string(data), nil)
+	if err != nil {
+		return errors.Wrapf(err, "Failed applying block device limits")
+	}
+
+	return nil
+}
+
 // Reset VM.
 func (m *Monitor) Reset() error {
 	err := m.run("system_reset", "", nil)
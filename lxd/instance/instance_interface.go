@@ -99,6 +99,8 @@ type Instance interface {
 	FilePull(srcpath string, dstpath string) (int64, int64, os.FileMode, string, []string, error)
 	FilePush(fileType string, srcpath string, dstpath string, uid int64, gid int64, mode int, write string) error
 	FileRemove(path string) error
+	FileTreePull(srcPath string, target io.Writer) error
+	FileTreePush(source io.Reader, dstPath string) error
 
 	// Console - Allocate and run a console tty or a spice Unix socket.
 	Console(protocol string) (*os.File, chan error, error)
@@ -109,6 +111,8 @@ type Instance interface {
 	RenderFull() (*api.InstanceFull, interface{}, error)
 	RenderState() (*api.InstanceState, error)
 	IsRunning() bool
+	HealthProbe(probeType string, address string, timeout time.Duration) error
+	Autorestart() error
 	IsFrozen() bool
 	IsEphemeral() bool
 	IsSnapshot() bool
@@ -140,6 +144,7 @@ type Instance interface {
 	LogFilePath() string
 	ConsoleBufferLogPath() string
 	LogPath() string
+	ExecRecordingsPath() string
 	DevicesPath() string
 
 	// Storage.
@@ -168,6 +173,15 @@ type Container interface {
 	IdmappedStorage(path string) idmap.IdmapStorageType
 }
 
+// VM interface is for VM specific functions.
+type VM interface {
+	Instance
+
+	NVRAMGet() ([]byte, error)
+	NVRAMSet(data []byte) error
+	NVRAMReset() error
+}
+
 // CriuMigrationArgs arguments for CRIU migration.
 type CriuMigrationArgs struct {
 	Cmd          uint
@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"crypto/rand"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"math/big"
 	"os"
 	"strconv"
@@ -15,6 +17,7 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/lxc/lxd/client"
+	"github.com/lxc/lxd/lxd/apparmor"
 	"github.com/lxc/lxd/lxd/backup"
 	"github.com/lxc/lxd/lxd/db"
 	deviceConfig "github.com/lxc/lxd/lxd/device/config"
@@ -32,6 +35,7 @@ import (
 	log "github.com/lxc/lxd/shared/log15"
 	"github.com/lxc/lxd/shared/logger"
 	"github.com/lxc/lxd/shared/osarch"
+	"github.com/lxc/lxd/shared/units"
 	"github.com/lxc/lxd/shared/version"
 )
 
@@ -216,6 +220,12 @@ func validConfigKey(os *sys.OS, key string, value string, instanceType instancet
 	if key == "raw.lxc" {
 		return lxcValidConfig(value)
 	}
+	if strings.HasPrefix(key, "raw.apparmor.") {
+		err = apparmor.ValidateRaw(value)
+		if err != nil {
+			return fmt.Errorf("Invalid %s: %w", key, err)
+		}
+	}
 	if key == "security.syscalls.deny_compat" || key == "security.syscalls.blacklist_compat" {
 		for _, arch := range os.Architectures {
 			if arch == osarch.ARCH_64BIT_INTEL_X86 ||
@@ -1219,3 +1229,94 @@ func IsSameLogicalInstance(inst Instance, dbInst *db.Instance) bool {
 
 	return false
 }
+
+// ConsoleBufferSizeDefault is the default size of the console scrollback buffer of instances.
+const ConsoleBufferSizeDefault = 128 * 1024
+
+// ConsoleBufferSize returns the size of the console scrollback buffer from the instance's expanded config,
+// rounded up to a multiple of the page size.
+func ConsoleBufferSize(config map[string]string) (int64, error) {
+	size := int64(ConsoleBufferSizeDefault)
+	if config["console.buffer.size"] != "" {
+		var err error
+		size, err = units.ParseByteSizeString(config["console.buffer.size"])
+		if err != nil {
+			return -1, errors.Wrapf(err, "Invalid console.buffer.size")
+		}
+	}
+
+	pageSize := int64(os.Getpagesize())
+	if size%pageSize != 0 || size == 0 {
+		size = (size/pageSize + 1) * pageSize
+	}
+
+	return size, nil
+}
+
+// ConsoleBufferTrim drops the start of the console log file, which QEMU appends to, once it exceeds twice
+// the size of the scrollback buffer, only keeping the last size bytes. The file is rewritten in place as
+// QEMU keeps it open.
+func ConsoleBufferTrim(path string, size int64) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+
+		return err
+	}
+
+	if info.Size() <= 2*size {
+		return nil
+	}
+
+	buf, err := ConsoleBufferRead(path, size)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	_, err = file.WriteAt(buf, 0)
+	if err != nil {
+		return err
+	}
+
+	return file.Truncate(int64(len(buf)))
+}
+
+// ConsoleBufferRead returns at most the last size bytes of the console log file.
+func ConsoleBufferRead(path string, size int64) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []byte{}, nil
+		}
+
+		return nil, err
+	}
+
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	offset := info.Size() - size
+	if offset < 0 {
+		offset = 0
+	}
+
+	_, err = file.Seek(offset, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+
+	return ioutil.ReadAll(io.LimitReader(file, size))
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -8,6 +9,7 @@ import (
 	"os"
 	"strconv"
 	"sync"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/gorilla/websocket"
@@ -20,9 +22,11 @@ import (
 	"github.com/lxc/lxd/lxd/instance/instancetype"
 	"github.com/lxc/lxd/lxd/operations"
 	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/lxd/task"
 	"github.com/lxc/lxd/lxd/util"
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/api"
+	log "github.com/lxc/lxd/shared/log15"
 	"github.com/lxc/lxd/shared/logger"
 	"github.com/lxc/lxd/shared/termios"
 )
@@ -57,6 +61,9 @@ type consoleWs struct {
 
 	// channel type (either console or vga)
 	protocol string
+
+	// read-only observers of the console output
+	observers *sessionObservers
 }
 
 func (s *consoleWs) Metadata() interface{} {
@@ -69,6 +76,10 @@ func (s *consoleWs) Metadata() interface{} {
 		}
 	}
 
+	if s.observers != nil {
+		fds["observer"] = s.observers.secret
+	}
+
 	return shared.Jmap{"fds": fds}
 }
 
@@ -89,6 +100,10 @@ func (s *consoleWs) connectConsole(op *operations.Operation, r *http.Request, w
 		return fmt.Errorf("missing secret")
 	}
 
+	if s.observers != nil && secret == s.observers.secret {
+		return s.observers.connect(r, w)
+	}
+
 	for fd, fdSecret := range s.fds {
 		if secret == fdSecret {
 			conn, err := shared.WebsocketUpgrader.Upgrade(w, r, nil)
@@ -279,7 +294,8 @@ func (s *consoleWs) doConsole(op *operations.Operation) error {
 		s.connsLock.Unlock()
 
 		logger.Debugf("Started mirroring websocket")
-		readDone, writeDone := shared.WebsocketConsoleMirror(conn, console, console)
+		terminal := s.observers.wrap(console)
+		readDone, writeDone := shared.WebsocketConsoleMirror(conn, terminal, terminal)
 
 		<-readDone
 		logger.Debugf("Finished mirroring console to websocket")
@@ -314,6 +330,9 @@ func (s *consoleWs) doConsole(op *operations.Operation) error {
 	s.connsLock.Unlock()
 	ctrlConn.Close()
 
+	// Disconnect the observers.
+	s.observers.close()
+
 	// Indicate to the control socket go routine to end if not already.
 	close(s.controlConnected)
 	return nil
@@ -373,6 +392,9 @@ func (s *consoleWs) doVGA(op *operations.Operation) error {
 //
 // The returned operation metadata will contain two websockets, one for data and one for control.
 //
+// For text consoles, an additional "observer" websocket can be connected to any number of times
+// to follow the console output read-only, without disturbing the session.
+//
 // ---
 // consumes:
 //   - application/json
@@ -485,6 +507,13 @@ func instanceConsolePost(d *Daemon, r *http.Request) response.Response {
 	ws.height = post.Height
 	ws.protocol = post.Type
 
+	if ws.protocol == instance.ConsoleTypeConsole {
+		ws.observers, err = newSessionObservers()
+		if err != nil {
+			return response.InternalError(err)
+		}
+	}
+
 	resources := map[string][]string{}
 	resources["instances"] = []string{ws.instance.Name()}
 
@@ -504,7 +533,9 @@ func instanceConsolePost(d *Daemon, r *http.Request) response.Response {
 //
 // Get console log
 //
-// Gets the console log for the instance.
+// Gets the console log for the instance, that is the recent console output
+// kept in the scrollback buffer (`console.buffer.size`), including the output
+// from before the instance last stopped.
 //
 // ---
 // produces:
@@ -515,6 +546,11 @@ func instanceConsolePost(d *Daemon, r *http.Request) response.Response {
 //     description: Project name
 //     type: string
 //     example: default
+//   - in: query
+//     name: log
+//     description: Return the scrollback buffer (default)
+//     type: integer
+//     example: 1
 // responses:
 //   "200":
 //      description: Raw console log
@@ -549,17 +585,29 @@ func instanceConsoleLogGet(d *Daemon, r *http.Request) response.Response {
 		return resp
 	}
 
-	if !util.RuntimeLiblxcVersionAtLeast(3, 0, 0) {
-		return response.BadRequest(fmt.Errorf("Querying the console buffer requires liblxc >= 3.0"))
-	}
-
 	inst, err := instance.LoadByProjectAndName(d.State(), projectName, name)
 	if err != nil {
 		return response.SmartError(err)
 	}
 
-	if inst.Type() != instancetype.Container {
-		return response.SmartError(fmt.Errorf("Instance is not container type"))
+	if inst.Type() == instancetype.VM {
+		// QEMU appends the console output to the log file, only return what fits in the buffer.
+		size, err := instance.ConsoleBufferSize(inst.ExpandedConfig())
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		buf, err := instance.ConsoleBufferRead(inst.ConsoleBufferLogPath(), size)
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		ent := response.FileResponseEntry{Buffer: buf}
+		return response.FileResponse(r, []response.FileResponseEntry{ent}, nil, false)
+	}
+
+	if !util.RuntimeLiblxcVersionAtLeast(3, 0, 0) {
+		return response.BadRequest(fmt.Errorf("Querying the console buffer requires liblxc >= 3.0"))
 	}
 
 	c := inst.(instance.Container)
@@ -626,10 +674,6 @@ func instanceConsoleLogGet(d *Daemon, r *http.Request) response.Response {
 //   "500":
 //     $ref: "#/responses/InternalServerError"
 func instanceConsoleLogDelete(d *Daemon, r *http.Request) response.Response {
-	if !util.RuntimeLiblxcVersionAtLeast(3, 0, 0) {
-		return response.BadRequest(fmt.Errorf("Clearing the console buffer requires liblxc >= 3.0"))
-	}
-
 	name := mux.Vars(r)["name"]
 	projectName := projectParam(r)
 
@@ -638,12 +682,6 @@ func instanceConsoleLogDelete(d *Daemon, r *http.Request) response.Response {
 		return response.SmartError(err)
 	}
 
-	if inst.Type() != instancetype.Container {
-		return response.SmartError(fmt.Errorf("Instance is not container type"))
-	}
-
-	c := inst.(instance.Container)
-
 	truncateConsoleLogFile := func(path string) error {
 		// Check that this is a regular file. We don't want to try and unlink
 		// /dev/stderr or /dev/null or something.
@@ -663,6 +701,21 @@ func instanceConsoleLogDelete(d *Daemon, r *http.Request) response.Response {
 		return os.Truncate(path, 0)
 	}
 
+	if inst.Type() == instancetype.VM {
+		// QEMU opens the log file in append mode, so it can be truncated while running.
+		if !shared.PathExists(inst.ConsoleBufferLogPath()) {
+			return response.EmptySyncResponse
+		}
+
+		return response.SmartError(truncateConsoleLogFile(inst.ConsoleBufferLogPath()))
+	}
+
+	if !util.RuntimeLiblxcVersionAtLeast(3, 0, 0) {
+		return response.BadRequest(fmt.Errorf("Clearing the console buffer requires liblxc >= 3.0"))
+	}
+
+	c := inst.(instance.Container)
+
 	if !inst.IsRunning() {
 		consoleLogpath := c.ConsoleBufferLogPath()
 		return response.SmartError(truncateConsoleLogFile(consoleLogpath))
@@ -692,3 +745,35 @@ func instanceConsoleLogDelete(d *Daemon, r *http.Request) response.Response {
 
 	return response.SmartError(nil)
 }
+
+// instanceConsoleBufferTrimTask keeps the console log files of the local running virtual machines, which
+// QEMU appends to, within twice the size of their scrollback buffer.
+func instanceConsoleBufferTrimTask(d *Daemon) (task.Func, task.Schedule) {
+	f := func(ctx context.Context) {
+		s := d.State()
+
+		instances, err := instance.LoadNodeAll(s, instancetype.VM)
+		if err != nil {
+			logger.Error("Failed loading instances for console log trimming", log.Ctx{"err": err})
+			return
+		}
+
+		for _, inst := range instances {
+			if !inst.IsRunning() {
+				continue
+			}
+
+			size, err := instance.ConsoleBufferSize(inst.ExpandedConfig())
+			if err != nil {
+				continue
+			}
+
+			err = instance.ConsoleBufferTrim(inst.ConsoleBufferLogPath(), size)
+			if err != nil {
+				logger.Warn("Failed trimming console log", log.Ctx{"project": inst.Project(), "instance": inst.Name(), "err": err})
+			}
+		}
+	}
+
+	return f, task.Every(10 * time.Second)
+}
@@ -0,0 +1,567 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/instance"
+	"github.com/lxc/lxd/lxd/instance/drivers"
+	"github.com/lxc/lxd/lxd/instance/instancetype"
+	"github.com/lxc/lxd/lxd/instance/operationlock"
+	"github.com/lxc/lxd/lxd/lifecycle"
+	"github.com/lxc/lxd/lxd/operations"
+	"github.com/lxc/lxd/lxd/project"
+	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/lxd/revert"
+	"github.com/lxc/lxd/lxd/rsync"
+	storagePools "github.com/lxc/lxd/lxd/storage"
+	storageDrivers "github.com/lxc/lxd/lxd/storage/drivers"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	log "github.com/lxc/lxd/shared/log15"
+	"github.com/lxc/lxd/shared/logger"
+	"github.com/lxc/lxd/shared/osarch"
+)
+
+// instanceConvertESPSize is the size of the EFI system partition of converted virtual machines.
+const instanceConvertESPSize = "+100M"
+
+// instanceConvertBootloaders maps the supported architectures to the systemd-boot binary and to the
+// fallback path the firmware boots from.
+var instanceConvertBootloaders = map[int][]string{
+	osarch.ARCH_64BIT_INTEL_X86:           {"systemd-bootx64.efi", "BOOTX64.EFI"},
+	osarch.ARCH_64BIT_ARMV8_LITTLE_ENDIAN: {"systemd-bootaa64.efi", "BOOTAA64.EFI"},
+}
+
+// swagger:operation POST /1.0/instances/{name}/convert instances instance_convert_post
+//
+// Convert the instance
+//
+// Converts a container into a virtual machine, or a virtual machine into a container,
+// keeping the configuration, devices and attached volumes of the instance.
+//
+// The instance must be stopped and must not have any snapshot.
+//
+// ---
+// consumes:
+//   - application/json
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+//   - in: body
+//     name: instance
+//     description: Convert request
+//     required: true
+//     schema:
+//       $ref: "#/definitions/InstanceConvertPost"
+// responses:
+//   "202":
+//     $ref: "#/responses/Operation"
+//   "400":
+//     $ref: "#/responses/BadRequest"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "404":
+//     $ref: "#/responses/NotFound"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func instanceConvertPost(d *Daemon, r *http.Request) response.Response {
+	instanceType, err := urlInstanceTypeDetect(r)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	projectName := projectParam(r)
+	name := mux.Vars(r)["name"]
+
+	if shared.IsSnapshot(name) {
+		return response.BadRequest(fmt.Errorf("Snapshots can't be converted"))
+	}
+
+	err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
+		return project.AllowInstanceConversion(tx, projectName)
+	})
+	if err != nil {
+		return response.Forbidden(err)
+	}
+
+	// Handle requests targeted to an instance on a different node.
+	resp, err := forwardedResponseIfInstanceIsRemote(d, r, projectName, name, instanceType)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if resp != nil {
+		return resp
+	}
+
+	req := api.InstanceConvertPost{}
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	newType, err := instancetype.New(string(req.Type))
+	if err != nil || newType == instancetype.Any {
+		return response.BadRequest(fmt.Errorf("Invalid instance type %q", req.Type))
+	}
+
+	inst, err := instance.LoadByProjectAndName(d.State(), projectName, name)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if inst.Type() == newType {
+		return response.BadRequest(fmt.Errorf("Instance is already of type %q", req.Type))
+	}
+
+	if inst.IsRunning() {
+		return response.BadRequest(fmt.Errorf("Instance must be stopped to be converted"))
+	}
+
+	snapshots, err := inst.Snapshots()
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if len(snapshots) > 0 {
+		return response.BadRequest(fmt.Errorf("Instances with snapshots can't be converted"))
+	}
+
+	if newType == instancetype.VM && instanceConvertBootloaders[inst.Architecture()] == nil {
+		return response.BadRequest(fmt.Errorf("Instances of this architecture can't be converted to virtual machines"))
+	}
+
+	run := func(op *operations.Operation) error {
+		return instanceConvert(d, inst, newType, op)
+	}
+
+	resources := map[string][]string{}
+	resources["instances"] = []string{name}
+
+	op, err := operations.OperationCreate(d.State(), projectName, operations.OperationClassTask, db.OperationInstanceConvert, resources, nil, run, nil, nil, r)
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	return operations.OperationResponse(op)
+}
+
+// instanceConvert converts the stopped instance to the given type. The converted instance is created
+// alongside under a temporary name, filled from the root volume of the instance and then replaces it.
+func instanceConvert(d *Daemon, inst instance.Instance, newType instancetype.Type, op *operations.Operation) error {
+	instOp, err := operationlock.Create(inst.ID(), "convert", false, false)
+	if err != nil {
+		return errors.Wrap(err, "Failed creating instance convert operation")
+	}
+
+	defer func() { instOp.Done(err) }()
+
+	revert := revert.New()
+	defer revert.Fail()
+
+	args := db.InstanceArgs{
+		Architecture: inst.Architecture(),
+		Description:  inst.Description(),
+		Config:       instanceConvertConfig(inst.LocalConfig(), newType),
+		Devices:      inst.LocalDevices(),
+		Ephemeral:    inst.IsEphemeral(),
+		Name:         fmt.Sprintf("convert-%s", strings.Split(uuid.New(), "-")[0]),
+		Profiles:     inst.Profiles(),
+		Project:      inst.Project(),
+		Type:         newType,
+	}
+
+	newInst, err := instanceCreateAsEmpty(d, args)
+	if err != nil {
+		return errors.Wrapf(err, "Failed creating the converted instance")
+	}
+
+	revert.Add(func() { newInst.Delete(true) })
+
+	pool, err := storagePools.GetPoolByInstance(d.State(), inst)
+	if err != nil {
+		return errors.Wrap(err, "Failed loading instance storage pool")
+	}
+
+	newPool, err := storagePools.GetPoolByInstance(d.State(), newInst)
+	if err != nil {
+		return errors.Wrap(err, "Failed loading converted instance storage pool")
+	}
+
+	mountInfo, err := pool.MountInstance(inst, op)
+	if err != nil {
+		return errors.Wrap(err, "Failed mounting instance root volume")
+	}
+
+	revert.Add(func() { pool.UnmountInstance(inst, op) })
+
+	newMountInfo, err := newPool.MountInstance(newInst, op)
+	if err != nil {
+		return errors.Wrap(err, "Failed mounting converted instance root volume")
+	}
+
+	revert.Add(func() { newPool.UnmountInstance(newInst, op) })
+
+	if newType == instancetype.VM {
+		err = instanceConvertToVM(inst, newMountInfo.DiskPath)
+	} else {
+		err = instanceConvertToContainer(mountInfo.DiskPath, newInst.RootfsPath())
+	}
+
+	if err != nil {
+		return err
+	}
+
+	_, err = newPool.UnmountInstance(newInst, op)
+	if err != nil {
+		return errors.Wrap(err, "Failed unmounting converted instance root volume")
+	}
+
+	_, err = pool.UnmountInstance(inst, op)
+	if err != nil {
+		return errors.Wrap(err, "Failed unmounting instance root volume")
+	}
+
+	// Replace the instance by the converted one, keeping the original aside until the converted
+	// one took its name.
+	name := inst.Name()
+	convertName := newInst.Name()
+	oldName := fmt.Sprintf("%s-old", convertName)
+
+	err = inst.Rename(oldName, false)
+	if err != nil {
+		return errors.Wrap(err, "Failed renaming the original instance")
+	}
+
+	revert.Add(func() { inst.Rename(name, false) })
+
+	err = newInst.Rename(name, false)
+	if err != nil {
+		return errors.Wrapf(err, "Failed renaming the converted instance %q", convertName)
+	}
+
+	revert.Add(func() { newInst.Rename(convertName, false) })
+
+	err = inst.Delete(true)
+	if err != nil {
+		return errors.Wrap(err, "Failed deleting the original instance")
+	}
+
+	revert.Success()
+
+	d.State().Events.SendLifecycle(newInst.Project(), lifecycle.InstanceConverted.Event(newInst, map[string]interface{}{"type": newType.String()}))
+
+	return nil
+}
+
+// instanceConvertConfig returns the local config of an instance converted to the given type, dropping the
+// keys which don't apply to it and the volatile keys tied to the previous instance (except for the MAC
+// addresses).
+func instanceConvertConfig(config map[string]string, newType instancetype.Type) map[string]string {
+	newConfig := map[string]string{}
+	for k, v := range config {
+		if strings.HasPrefix(k, shared.ConfigVolatilePrefix) && k != "volatile.base_image" && !strings.HasSuffix(k, ".hwaddr") {
+			continue
+		}
+
+		_, err := shared.ConfigKeyChecker(k, newType)
+		if err != nil {
+			logger.Warn("Dropping config key not applicable to the converted instance", log.Ctx{"key": k})
+			continue
+		}
+
+		newConfig[k] = v
+	}
+
+	// The converted VMs boot through an unsigned systemd-boot.
+	if newType == instancetype.VM {
+		newConfig["security.secureboot"] = "false"
+	}
+
+	return newConfig
+}
+
+// instanceConvertToVM builds a bootable disk out of the root filesystem of the container, made of an EFI
+// system partition holding systemd-boot and the kernel, and of an ext4 root partition. The kernel is taken
+// from the container if one is installed in it, or is otherwise injected from the host.
+func instanceConvertToVM(inst instance.Instance, diskPath string) error {
+	rootfsPath := inst.RootfsPath()
+	bootloader := instanceConvertBootloaders[inst.Architecture()]
+
+	kernelPath, initrdPath, modulesPath, err := instanceConvertFindKernel(inst)
+	if err != nil {
+		return err
+	}
+
+	bootloaderPath := ""
+	for _, path := range []string{filepath.Join(rootfsPath, "usr", "lib", "systemd", "boot", "efi", bootloader[0]), filepath.Join("/usr/lib/systemd/boot/efi", bootloader[0])} {
+		if shared.PathExists(path) {
+			bootloaderPath = path
+			break
+		}
+	}
+
+	if bootloaderPath == "" {
+		return fmt.Errorf("Couldn't find %q in the container nor on the host", bootloader[0])
+	}
+
+	// Partition the disk.
+	rootUUID := uuid.New()
+	_, err = shared.RunCommand("sgdisk", "--zap-all", diskPath)
+	if err != nil {
+		return errors.Wrap(err, "Failed wiping the disk")
+	}
+
+	_, err = shared.RunCommand("sgdisk", "-n", fmt.Sprintf("1:0:%s", instanceConvertESPSize), "-t", "1:ef00", "-n", "2:0:0", "-t", "2:8300", "-u", fmt.Sprintf("2:%s", rootUUID), diskPath)
+	if err != nil {
+		return errors.Wrap(err, "Failed partitioning the disk")
+	}
+
+	loopPath, err := instanceConvertLoopSetup(diskPath, false)
+	if err != nil {
+		return err
+	}
+
+	defer shared.RunCommand("losetup", "-d", loopPath)
+
+	espPath := fmt.Sprintf("%sp1", loopPath)
+	rootPath := fmt.Sprintf("%sp2", loopPath)
+
+	_, err = shared.RunCommand("mkfs.vfat", "-F", "32", "-n", "EFI", espPath)
+	if err != nil {
+		return errors.Wrap(err, "Failed formatting the EFI system partition")
+	}
+
+	_, err = shared.RunCommand("mkfs.ext4", "-q", "-L", "root", rootPath)
+	if err != nil {
+		return errors.Wrap(err, "Failed formatting the root partition")
+	}
+
+	// Copy the root filesystem.
+	mountPath, err := ioutil.TempDir("", "lxd_convert_")
+	if err != nil {
+		return err
+	}
+
+	defer os.RemoveAll(mountPath)
+
+	err = storageDrivers.TryMount(rootPath, mountPath, "ext4", 0, "")
+	if err != nil {
+		return err
+	}
+
+	defer storageDrivers.TryUnmount(mountPath, 0)
+
+	_, err = rsync.LocalCopy(rootfsPath, mountPath, "", true)
+	if err != nil {
+		return errors.Wrap(err, "Failed copying the root filesystem")
+	}
+
+	// The root filesystem of the VM isn't shifted.
+	ct, ok := inst.(instance.Container)
+	if ok {
+		diskIdmap, err := ct.DiskIdmap()
+		if err != nil {
+			return err
+		}
+
+		if diskIdmap != nil {
+			err = diskIdmap.UnshiftRootfs(mountPath, nil)
+			if err != nil {
+				return errors.Wrap(err, "Failed unshifting the root filesystem")
+			}
+		}
+	}
+
+	if modulesPath != "" {
+		_, err = rsync.LocalCopy(modulesPath, filepath.Join(mountPath, "lib", "modules", filepath.Base(modulesPath)), "", false)
+		if err != nil {
+			return errors.Wrap(err, "Failed copying the kernel modules")
+		}
+	}
+
+	err = ioutil.WriteFile(filepath.Join(mountPath, "etc", "fstab"), []byte(fmt.Sprintf("PARTUUID=%s / ext4 defaults 0 1\nLABEL=EFI /boot/efi vfat defaults 0 2\n", rootUUID)), 0644)
+	if err != nil {
+		return err
+	}
+
+	err = drivers.InstallVMAgent(mountPath)
+	if err != nil {
+		return err
+	}
+
+	// Set up the EFI system partition.
+	efiPath := filepath.Join(mountPath, "boot", "efi")
+	err = os.MkdirAll(efiPath, 0755)
+	if err != nil {
+		return err
+	}
+
+	err = storageDrivers.TryMount(espPath, efiPath, "vfat", 0, "")
+	if err != nil {
+		return err
+	}
+
+	defer storageDrivers.TryUnmount(efiPath, 0)
+
+	for _, dir := range []string{filepath.Join("EFI", "BOOT"), filepath.Join("loader", "entries")} {
+		err = os.MkdirAll(filepath.Join(efiPath, dir), 0755)
+		if err != nil {
+			return err
+		}
+	}
+
+	files := map[string]string{
+		bootloaderPath: filepath.Join(efiPath, "EFI", "BOOT", bootloader[1]),
+		kernelPath:     filepath.Join(efiPath, "vmlinuz"),
+		initrdPath:     filepath.Join(efiPath, "initrd.img"),
+	}
+
+	for src, dst := range files {
+		err = shared.FileCopy(src, dst)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = ioutil.WriteFile(filepath.Join(efiPath, "loader", "loader.conf"), []byte("default lxd.conf\ntimeout 0\n"), 0644)
+	if err != nil {
+		return err
+	}
+
+	entry := fmt.Sprintf("title %s\nlinux /vmlinuz\ninitrd /initrd.img\noptions root=PARTUUID=%s rw console=tty1 console=ttyS0\n", inst.Name(), rootUUID)
+	err = ioutil.WriteFile(filepath.Join(efiPath, "loader", "entries", "lxd.conf"), []byte(entry), 0644)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// instanceConvertFindKernel returns the paths of the kernel and initrd to boot the converted container
+// with. The most recent kernel installed in the container is used, falling back to the running kernel of
+// the host, in which case the path of its modules to inject into the container is returned too.
+func instanceConvertFindKernel(inst instance.Instance) (string, string, string, error) {
+	kernels, err := filepath.Glob(filepath.Join(inst.RootfsPath(), "boot", "vmlinuz-*"))
+	if err != nil {
+		return "", "", "", err
+	}
+
+	sort.Sort(sort.Reverse(sort.StringSlice(kernels)))
+	for _, kernel := range kernels {
+		initrd := filepath.Join(filepath.Dir(kernel), strings.Replace(filepath.Base(kernel), "vmlinuz-", "initrd.img-", 1))
+		if shared.PathExists(initrd) {
+			return kernel, initrd, "", nil
+		}
+	}
+
+	hostArch, err := osarch.ArchitectureGetLocalID()
+	if err != nil {
+		return "", "", "", err
+	}
+
+	if hostArch != inst.Architecture() {
+		return "", "", "", fmt.Errorf("No kernel installed in the container and the host kernel is of a different architecture")
+	}
+
+	content, err := ioutil.ReadFile("/proc/sys/kernel/osrelease")
+	if err != nil {
+		return "", "", "", err
+	}
+
+	release := strings.TrimSpace(string(content))
+	kernel := filepath.Join("/boot", fmt.Sprintf("vmlinuz-%s", release))
+	initrd := filepath.Join("/boot", fmt.Sprintf("initrd.img-%s", release))
+	modules := filepath.Join("/lib/modules", release)
+
+	for _, path := range []string{kernel, initrd, modules} {
+		if !shared.PathExists(path) {
+			return "", "", "", fmt.Errorf("No kernel installed in the container and %q is missing on the host", path)
+		}
+	}
+
+	return kernel, initrd, modules, nil
+}
+
+// instanceConvertToContainer copies the root filesystem of the virtual machine, found as the first ext
+// partition of its disk holding an /etc/os-release, into the root filesystem of the container.
+// The partitions are controlled by the guest, so they're never mounted on the host: they're checked
+// with e2fsck and read with debugfs instead.
+func instanceConvertToContainer(diskPath string, rootfsPath string) error {
+	loopPath, err := instanceConvertLoopSetup(diskPath, true)
+	if err != nil {
+		return err
+	}
+
+	defer shared.RunCommand("losetup", "-d", loopPath)
+
+	partitions, err := filepath.Glob(fmt.Sprintf("%sp*", loopPath))
+	if err != nil {
+		return err
+	}
+
+	for _, partition := range partitions {
+		fsType, err := shared.RunCommand("blkid", "-o", "value", "-s", "TYPE", partition)
+		if err != nil {
+			continue
+		}
+
+		if !shared.StringInSlice(strings.TrimSpace(fsType), []string{"ext2", "ext3", "ext4"}) {
+			continue
+		}
+
+		out, err := shared.RunCommand("debugfs", "-R", "stat /etc/os-release", partition)
+		if err != nil || !strings.HasPrefix(strings.TrimSpace(out), "Inode:") {
+			continue
+		}
+
+		// Refuse inconsistent filesystems, such as directories with duplicate entries which could
+		// have the copy follow a symlink out of the root filesystem.
+		_, err = shared.RunCommand("e2fsck", "-f", "-n", partition)
+		if err != nil {
+			return errors.Wrap(err, "The root filesystem of the virtual machine is inconsistent")
+		}
+
+		_, err = shared.RunCommand("debugfs", "-R", fmt.Sprintf("rdump / %s", rootfsPath), partition)
+		if err != nil {
+			return errors.Wrap(err, "Failed copying the root filesystem")
+		}
+
+		// The filesystems of the disk aren't available to the container.
+		return ioutil.WriteFile(filepath.Join(rootfsPath, "etc", "fstab"), []byte(""), 0644)
+	}
+
+	return fmt.Errorf("Couldn't find an ext root filesystem on the disk of the virtual machine")
+}
+
+// instanceConvertLoopSetup attaches the disk to a loop device with partition scanning and returns its path.
+func instanceConvertLoopSetup(diskPath string, readonly bool) (string, error) {
+	args := []string{"-P", "-f", "--show"}
+	if readonly {
+		args = append(args, "-r")
+	}
+
+	loopPath, err := shared.RunCommand("losetup", append(args, diskPath)...)
+	if err != nil {
+		return "", errors.Wrap(err, "Failed attaching the disk to a loop device")
+	}
+
+	return strings.TrimSpace(loopPath), nil
+}
@@ -1,7 +1,6 @@
 package main
 
 import (
-	"fmt"
 	"net/http"
 
 	"github.com/gorilla/mux"
@@ -10,6 +9,7 @@ import (
 	"github.com/lxc/lxd/lxd/instance/instancetype"
 	"github.com/lxc/lxd/lxd/operations"
 	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/shared/api"
 )
 
 // swagger:operation DELETE /1.0/instances/{name} instances instance_delete
@@ -61,7 +61,7 @@ func instanceDelete(d *Daemon, r *http.Request) response.Response {
 	}
 
 	if inst.IsRunning() {
-		return response.BadRequest(fmt.Errorf("Instance is running"))
+		return response.BadRequest(api.StatusErrorTypef(http.StatusBadRequest, api.ErrInstanceRunning, "Instance is running"))
 	}
 
 	rmct := func(op *operations.Operation) error {
@@ -3,6 +3,7 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"os"
@@ -10,6 +11,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/gorilla/websocket"
@@ -21,6 +23,7 @@ import (
 	"github.com/lxc/lxd/lxd/instance/instancetype"
 	"github.com/lxc/lxd/lxd/operations"
 	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/lxd/secrets"
 	"github.com/lxc/lxd/lxd/state"
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/api"
@@ -46,6 +49,8 @@ type execWs struct {
 	fds                  map[int]string
 	devptsFd             *os.File
 	s                    *state.State
+	record               bool
+	observers            *sessionObservers
 }
 
 func (s *execWs) Metadata() interface{} {
@@ -58,6 +63,8 @@ func (s *execWs) Metadata() interface{} {
 		}
 	}
 
+	fds["observer"] = s.observers.secret
+
 	return shared.Jmap{
 		"fds":         fds,
 		"command":     s.req.Command,
@@ -72,6 +79,10 @@ func (s *execWs) Connect(op *operations.Operation, r *http.Request, w http.Respo
 		return fmt.Errorf("missing secret")
 	}
 
+	if secret == s.observers.secret {
+		return s.observers.connect(r, w)
+	}
+
 	for fd, fdSecret := range s.fds {
 		if secret == fdSecret {
 			conn, err := shared.WebsocketUpgrader.Upgrade(w, r, nil)
@@ -196,6 +207,8 @@ func (s *execWs) Do(op *operations.Operation) error {
 			pty.Close()
 		}
 
+		s.observers.close()
+
 		metadata := shared.Jmap{"return": cmdResult}
 		err = op.UpdateMetadata(metadata)
 		if err != nil {
@@ -205,14 +218,30 @@ func (s *execWs) Do(op *operations.Operation) error {
 		return cmdErr
 	}
 
+	// Refuse to run the command if its session can't be recorded as required.
+	var recorder *execRecorder
+	if s.req.Interactive && s.record {
+		recorder, err = newExecRecorder(filepath.Join(s.instance.ExecRecordingsPath(), fmt.Sprintf("%s.cast", op.ID())), s.req)
+		if err != nil {
+			return finisher(-1, fmt.Errorf("Failed to start recording the exec session: %w", err))
+		}
+	}
+
 	cmd, err := s.instance.Exec(s.req, stdin, stdout, stderr)
 	if err != nil {
+		if recorder != nil {
+			recorder.Close()
+		}
+
 		return finisher(-1, err)
 	}
 
 	logger := logging.AddContext(logger.Log, log.Ctx{"instance": s.instance.Name(), "PID": cmd.PID()})
 	logger.Debug("Instance process started")
 
+	stopTimeout := instanceExecTimeout(cmd, s.req.Timeout)
+	defer stopTimeout()
+
 	// Now that process has started, we can start the mirroring of the process channels and websockets.
 	if s.req.Interactive {
 		wgEOF.Add(1)
@@ -290,6 +319,10 @@ func (s *execWs) Do(op *operations.Operation) error {
 						logger.Debug("Failed to set window size", log.Ctx{"err": err, "width": winchWidth, "height": winchHeight})
 						continue
 					}
+
+					if recorder != nil {
+						recorder.event("r", fmt.Sprintf("%dx%d", winchWidth, winchHeight))
+					}
 				} else if command.Command == "signal" {
 					err := cmd.Signal(unix.Signal(command.Signal))
 					if err != nil {
@@ -307,10 +340,21 @@ func (s *execWs) Do(op *operations.Operation) error {
 
 			logger.Debug("Started mirroring websocket")
 			defer logger.Debug("Finished mirroring websocket")
-			readDone, writeDone := netutils.WebsocketExecMirror(conn, ptys[0], ptys[0], attachedChildIsDead, int(ptys[0].Fd()))
+			var terminal io.ReadWriteCloser = ptys[0]
+			if recorder != nil {
+				terminal = recorder.wrap(ptys[0])
+			}
+
+			terminal = s.observers.wrap(terminal)
+
+			readDone, writeDone := netutils.WebsocketExecMirror(conn, terminal, terminal, attachedChildIsDead, int(ptys[0].Fd()))
 
 			<-readDone
 			<-writeDone
+			if recorder != nil {
+				recorder.Close()
+			}
+
 			conn.Close()
 			wgEOF.Done()
 		}()
@@ -330,7 +374,8 @@ func (s *execWs) Do(op *operations.Operation) error {
 					conn := s.conns[i]
 					s.connsLock.Unlock()
 
-					<-shared.WebsocketSendStream(conn, ptys[i], -1)
+					// Observers get both stdout and stderr.
+					<-shared.WebsocketSendStream(conn, io.TeeReader(ptys[i], s.observers), -1)
 					ptys[i].Close()
 					wgEOF.Done()
 				}
@@ -422,6 +467,9 @@ func (s *execWs) Do(op *operations.Operation) error {
 // An additional "control" socket is always added on top which can be used for out of band communication with LXD.
 // This allows sending signals and window sizing information through.
 //
+// Finally, an "observer" websocket can be connected to any number of times to follow the output
+// of the command read-only, without disturbing the session.
+//
 // ---
 // consumes:
 //   - application/json
@@ -506,7 +554,14 @@ func instanceExecPost(d *Daemon, r *http.Request) response.Response {
 	}
 
 	// Override any environment variable settings from the instance if not manually specified in post.
-	for k, v := range inst.ExpandedConfig() {
+	environment, err := secrets.ExpandConfig(d.State(), inst.Project(), inst.ExpandedConfig(), func(key string) bool {
+		return strings.HasPrefix(key, "environment.")
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	for k, v := range environment {
 		if strings.HasPrefix(k, "environment.") {
 			envKey := strings.TrimPrefix(k, "environment.")
 			if _, found := post.Environment[envKey]; !found {
@@ -524,6 +579,14 @@ func instanceExecPost(d *Daemon, r *http.Request) response.Response {
 		}
 	}
 
+	// Resolve the user and group names inside the instance.
+	if post.UserName != "" || post.GroupName != "" {
+		err = instanceExecResolveNames(inst, &post)
+		if err != nil {
+			return response.SmartError(err)
+		}
+	}
+
 	// If running as root, set some env variables.
 	if post.User == 0 {
 		// Set default value for HOME.
@@ -583,9 +646,21 @@ func instanceExecPost(d *Daemon, r *http.Request) response.Response {
 			}
 		}
 
+		ws.observers, err = newSessionObservers()
+		if err != nil {
+			return response.InternalError(err)
+		}
+
 		ws.instance = inst
 		ws.req = post
 
+		if post.Interactive {
+			ws.record, err = execRecordingEnabled(d.State(), inst)
+			if err != nil {
+				return response.SmartError(err)
+			}
+		}
+
 		resources := map[string][]string{}
 		resources["instances"] = []string{ws.instance.Name()}
 
@@ -624,7 +699,9 @@ func instanceExecPost(d *Daemon, r *http.Request) response.Response {
 				return err
 			}
 
+			stopTimeout := instanceExecTimeout(cmd, post.Timeout)
 			exitCode, err := cmd.Wait()
+			stopTimeout()
 			if err != nil {
 				return err
 			}
@@ -641,7 +718,9 @@ func instanceExecPost(d *Daemon, r *http.Request) response.Response {
 				return err
 			}
 
+			stopTimeout := instanceExecTimeout(cmd, post.Timeout)
 			exitCode, err := cmd.Wait()
+			stopTimeout()
 			if err != nil {
 				return err
 			}
@@ -671,3 +750,129 @@ func instanceExecPost(d *Daemon, r *http.Request) response.Response {
 
 	return operations.OperationResponse(op)
 }
+
+// instanceExecTimeout kills the command once the timeout (in seconds) expires.
+// The returned function cancels the timeout and must be called once the command exited.
+func instanceExecTimeout(cmd instance.Cmd, timeout int) func() {
+	if timeout <= 0 {
+		return func() {}
+	}
+
+	timer := time.AfterFunc(time.Duration(timeout)*time.Second, func() {
+		logger.Debug("Killing command which reached its timeout", log.Ctx{"PID": cmd.PID(), "timeout": timeout})
+
+		err := cmd.Signal(unix.SIGKILL)
+		if err != nil {
+			logger.Debug("Failed to send SIGKILL signal", log.Ctx{"err": err})
+		}
+	})
+
+	return func() { timer.Stop() }
+}
+
+// instanceExecReadFile returns the content of a file from the instance, going through the same path as file pulls.
+func instanceExecReadFile(inst instance.Instance, path string) ([]byte, error) {
+	temp, err := ioutil.TempFile("", "lxd_exec_")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(temp.Name())
+	defer temp.Close()
+
+	_, _, _, fileType, _, err := inst.FilePull(path, temp.Name())
+	if err != nil {
+		return nil, err
+	}
+
+	if fileType != "file" {
+		return nil, fmt.Errorf("%q isn't a file", path)
+	}
+
+	return ioutil.ReadAll(temp)
+}
+
+// instanceExecResolveNames sets the user and group of the exec request from their names, using the
+// passwd and group databases of the instance. A user name alone also selects its primary group and
+// provides the default HOME and USER.
+func instanceExecResolveNames(inst instance.Instance, post *api.InstanceExecPost) error {
+	if post.UserName != "" {
+		content, err := instanceExecReadFile(inst, "/etc/passwd")
+		if err != nil {
+			return fmt.Errorf("Failed to read the users of the instance: %w", err)
+		}
+
+		found := false
+		for _, line := range strings.Split(string(content), "\n") {
+			// name:password:uid:gid:gecos:home:shell
+			fields := strings.Split(line, ":")
+			if len(fields) < 7 || fields[0] != post.UserName {
+				continue
+			}
+
+			uid, err := strconv.ParseUint(fields[2], 10, 32)
+			if err != nil {
+				return fmt.Errorf("Invalid uid for user %q: %w", post.UserName, err)
+			}
+
+			gid, err := strconv.ParseUint(fields[3], 10, 32)
+			if err != nil {
+				return fmt.Errorf("Invalid gid for user %q: %w", post.UserName, err)
+			}
+
+			post.User = uint32(uid)
+			post.Group = uint32(gid)
+
+			_, ok := post.Environment["HOME"]
+			if !ok && fields[5] != "" {
+				post.Environment["HOME"] = fields[5]
+			}
+
+			_, ok = post.Environment["USER"]
+			if !ok {
+				post.Environment["USER"] = post.UserName
+			}
+
+			found = true
+			break
+		}
+
+		if !found {
+			return api.StatusErrorf(http.StatusBadRequest, "User %q not found in the instance", post.UserName)
+		}
+	}
+
+	if post.GroupName != "" {
+		content, err := instanceExecReadFile(inst, "/etc/group")
+		if err != nil {
+			return fmt.Errorf("Failed to read the groups of the instance: %w", err)
+		}
+
+		found := false
+		for _, line := range strings.Split(string(content), "\n") {
+			// name:password:gid:members
+			fields := strings.Split(line, ":")
+			if len(fields) < 4 || fields[0] != post.GroupName {
+				continue
+			}
+
+			gid, err := strconv.ParseUint(fields[2], 10, 32)
+			if err != nil {
+				return fmt.Errorf("Invalid gid for group %q: %w", post.GroupName, err)
+			}
+
+			post.Group = uint32(gid)
+			found = true
+			break
+		}
+
+		if !found {
+			return api.StatusErrorf(http.StatusBadRequest, "Group %q not found in the instance", post.GroupName)
+		}
+	}
+
+	// The drivers only deal with numeric ids.
+	post.UserName = ""
+	post.GroupName = ""
+
+	return nil
+}
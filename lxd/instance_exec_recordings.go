@@ -0,0 +1,445 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/instance"
+	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/lxd/state"
+	"github.com/lxc/lxd/lxd/util"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/version"
+)
+
+var instanceExecRecordingsCmd = APIEndpoint{
+	Name: "instanceExecRecordings",
+	Path: "instances/{name}/recordings",
+	Aliases: []APIEndpointAlias{
+		{Name: "containerExecRecordings", Path: "containers/{name}/recordings"},
+		{Name: "vmExecRecordings", Path: "virtual-machines/{name}/recordings"},
+	},
+
+	Get: APIEndpointAction{Handler: instanceExecRecordingsGet},
+}
+
+var instanceExecRecordingCmd = APIEndpoint{
+	Name: "instanceExecRecording",
+	Path: "instances/{name}/recordings/{recording}",
+	Aliases: []APIEndpointAlias{
+		{Name: "containerExecRecording", Path: "containers/{name}/recordings/{recording}"},
+		{Name: "vmExecRecording", Path: "virtual-machines/{name}/recordings/{recording}"},
+	},
+
+	Get: APIEndpointAction{Handler: instanceExecRecordingGet},
+}
+
+// execRecordingHeader is the header of an asciicast v2 recording.
+type execRecordingHeader struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp"`
+	Command   string            `json:"command,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+
+	// LXD specific, as the asciicast command is a single string.
+	LXDCommand []string `json:"x_lxd_command,omitempty"`
+}
+
+// execRecorder records an interactive exec session in the asciicast v2 format.
+type execRecorder struct {
+	file  *os.File
+	start time.Time
+	lock  sync.Mutex
+}
+
+// execRecordingEnabled returns whether the interactive exec sessions of the instance are to be recorded.
+func execRecordingEnabled(s *state.State, inst instance.Instance) (bool, error) {
+	if shared.IsTrue(inst.ExpandedConfig()["security.exec_recording"]) {
+		return true, nil
+	}
+
+	var enabled bool
+	err := s.Cluster.Transaction(func(tx *db.ClusterTx) error {
+		p, err := tx.GetProject(inst.Project())
+		if err != nil {
+			return err
+		}
+
+		enabled = shared.IsTrue(p.Config["security.exec_recording"])
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return enabled, nil
+}
+
+// newExecRecorder creates the recording file of the exec session and writes its header.
+func newExecRecorder(path string, req api.InstanceExecPost) (*execRecorder, error) {
+	err := os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &execRecorder{file: file, start: time.Now()}
+
+	header := execRecordingHeader{
+		Version:    2,
+		Width:      req.Width,
+		Height:     req.Height,
+		Timestamp:  r.start.Unix(),
+		Command:    strings.Join(req.Command, " "),
+		LXDCommand: req.Command,
+	}
+
+	if req.Environment["TERM"] != "" {
+		header.Env = map[string]string{"TERM": req.Environment["TERM"]}
+	}
+
+	err = r.write(header)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (r *execRecorder) write(entry interface{}) error {
+	buf, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.file.Write(append(buf, '\n'))
+	return err
+}
+
+// event records an event ("o" for output, "i" for input or "r" for resize) of the session.
+func (r *execRecorder) event(eventType string, data string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.write([]interface{}{time.Since(r.start).Seconds(), eventType, data})
+}
+
+// Close closes the recording file.
+func (r *execRecorder) Close() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.file.Close()
+}
+
+// wrap returns a ReadWriteCloser recording what gets read from the terminal as output and what gets
+// written to it as input.
+func (r *execRecorder) wrap(terminal io.ReadWriteCloser) io.ReadWriteCloser {
+	return &execRecordedTerminal{ReadWriteCloser: terminal, recorder: r}
+}
+
+type execRecordedTerminal struct {
+	io.ReadWriteCloser
+	recorder *execRecorder
+}
+
+func (t *execRecordedTerminal) Read(p []byte) (int, error) {
+	n, err := t.ReadWriteCloser.Read(p)
+	if n > 0 {
+		t.recorder.event("o", string(p[:n]))
+	}
+
+	return n, err
+}
+
+func (t *execRecordedTerminal) Write(p []byte) (int, error) {
+	t.recorder.event("i", string(p))
+
+	return t.ReadWriteCloser.Write(p)
+}
+
+// execRecordingLoad returns the details of a recording, read from its header.
+func execRecordingLoad(path string) (*api.InstanceExecRecording, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	line, err := bufio.NewReader(file).ReadBytes('\n')
+	if err != nil {
+		return nil, err
+	}
+
+	header := execRecordingHeader{}
+	err = json.Unmarshal(line, &header)
+	if err != nil {
+		return nil, err
+	}
+
+	return &api.InstanceExecRecording{
+		Name:      filepath.Base(path),
+		Command:   header.LXDCommand,
+		CreatedAt: time.Unix(header.Timestamp, 0),
+		Size:      info.Size(),
+	}, nil
+}
+
+// validExecRecordingName checks that the recording name doesn't allow escaping the recordings directory.
+func validExecRecordingName(name string) bool {
+	return strings.HasSuffix(name, ".cast") && !strings.Contains(name, "/") && !strings.HasPrefix(name, ".")
+}
+
+// swagger:operation GET /1.0/instances/{name}/recordings instances instance_recordings_get
+//
+// Get the exec session recordings
+//
+// Returns a list of recordings of interactive exec sessions (URLs).
+//
+// ---
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+// responses:
+//   "200":
+//     description: API endpoints
+//     schema:
+//       type: object
+//       description: Sync response
+//       properties:
+//         type:
+//           type: string
+//           description: Response type
+//           example: sync
+//         status:
+//           type: string
+//           description: Status description
+//           example: Success
+//         status_code:
+//           type: integer
+//           description: Status code
+//           example: 200
+//         metadata:
+//           type: array
+//           description: List of endpoints
+//           items:
+//             type: string
+//           example: |-
+//             [
+//               "/1.0/instances/foo/recordings/0bd85d97-c2ce-4ef8-a0b3-f4e5e2a1c2be.cast"
+//             ]
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "404":
+//     $ref: "#/responses/NotFound"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+
+// swagger:operation GET /1.0/instances/{name}/recordings?recursion=1 instances instance_recordings_get_recursion1
+//
+// Get the exec session recordings
+//
+// Returns a list of recordings of interactive exec sessions (structs).
+//
+// ---
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+// responses:
+//   "200":
+//     description: API endpoints
+//     schema:
+//       type: object
+//       description: Sync response
+//       properties:
+//         type:
+//           type: string
+//           description: Response type
+//           example: sync
+//         status:
+//           type: string
+//           description: Status description
+//           example: Success
+//         status_code:
+//           type: integer
+//           description: Status code
+//           example: 200
+//         metadata:
+//           type: array
+//           description: List of recordings
+//           items:
+//             $ref: "#/definitions/InstanceExecRecording"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "404":
+//     $ref: "#/responses/NotFound"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func instanceExecRecordingsGet(d *Daemon, r *http.Request) response.Response {
+	instanceType, err := urlInstanceTypeDetect(r)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	projectName := projectParam(r)
+	name := mux.Vars(r)["name"]
+
+	// Handle requests targeted to an instance on a different node.
+	resp, err := forwardedResponseIfInstanceIsRemote(d, r, projectName, name, instanceType)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if resp != nil {
+		return resp
+	}
+
+	inst, err := instance.LoadByProjectAndName(d.State(), projectName, name)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	recursion := util.IsRecursionRequest(r)
+
+	urls := []string{}
+	recordings := []*api.InstanceExecRecording{}
+
+	entries, err := ioutil.ReadDir(inst.ExecRecordingsPath())
+	if err != nil && !os.IsNotExist(err) {
+		return response.InternalError(err)
+	}
+
+	for _, entry := range entries {
+		if !validExecRecordingName(entry.Name()) {
+			continue
+		}
+
+		if !recursion {
+			urls = append(urls, fmt.Sprintf("/%s/instances/%s/recordings/%s", version.APIVersion, inst.Name(), entry.Name()))
+			continue
+		}
+
+		recording, err := execRecordingLoad(filepath.Join(inst.ExecRecordingsPath(), entry.Name()))
+		if err != nil {
+			return response.InternalError(err)
+		}
+
+		recordings = append(recordings, recording)
+	}
+
+	if !recursion {
+		return response.SyncResponse(true, urls)
+	}
+
+	return response.SyncResponse(true, recordings)
+}
+
+// swagger:operation GET /1.0/instances/{name}/recordings/{recording} instances instance_recording_get
+//
+// Get the exec session recording
+//
+// Gets the recording of an interactive exec session, in the asciicast v2 format.
+//
+// ---
+// produces:
+//   - application/json
+//   - application/octet-stream
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+// responses:
+//   "200":
+//     description: Raw recording
+//     content:
+//       application/octet-stream:
+//         schema:
+//           type: string
+//           example: some-text
+//   "400":
+//     $ref: "#/responses/BadRequest"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "404":
+//     $ref: "#/responses/NotFound"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func instanceExecRecordingGet(d *Daemon, r *http.Request) response.Response {
+	instanceType, err := urlInstanceTypeDetect(r)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	projectName := projectParam(r)
+	name := mux.Vars(r)["name"]
+	recording := mux.Vars(r)["recording"]
+
+	if !validExecRecordingName(recording) {
+		return response.BadRequest(fmt.Errorf("Invalid recording name %q", recording))
+	}
+
+	// Handle requests targeted to an instance on a different node.
+	resp, err := forwardedResponseIfInstanceIsRemote(d, r, projectName, name, instanceType)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if resp != nil {
+		return resp
+	}
+
+	inst, err := instance.LoadByProjectAndName(d.State(), projectName, name)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	path := filepath.Join(inst.ExecRecordingsPath(), recording)
+	if !shared.PathExists(path) {
+		return response.NotFound(fmt.Errorf("Recording %q not found", recording))
+	}
+
+	ent := response.FileResponseEntry{
+		Path:     path,
+		Filename: recording,
+	}
+
+	return response.FileResponse(r, []response.FileResponseEntry{ent}, nil, false)
+}
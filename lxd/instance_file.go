@@ -1,18 +1,22 @@
 package main
 
 import (
+	"archive/tar"
+	"crypto/sha256"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/gorilla/mux"
 
 	"github.com/lxc/lxd/lxd/instance"
 	"github.com/lxc/lxd/lxd/response"
 	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
 )
 
 func instanceFileHandler(d *Daemon, r *http.Request) response.Response {
@@ -42,10 +46,29 @@ func instanceFileHandler(d *Daemon, r *http.Request) response.Response {
 		return response.BadRequest(fmt.Errorf("Missing path argument"))
 	}
 
+	format := r.FormValue("format")
+	if !shared.StringInSlice(format, []string{"", "tar", "manifest"}) {
+		return response.BadRequest(fmt.Errorf("Invalid format %q", format))
+	}
+
+	if format == "manifest" && r.Method != "GET" {
+		return response.BadRequest(fmt.Errorf("The manifest format can only be retrieved"))
+	}
+
 	switch r.Method {
 	case "GET":
+		if format == "tar" {
+			return instanceFileTreeGet(c, path)
+		} else if format == "manifest" {
+			return instanceFileManifestGet(c, path)
+		}
+
 		return instanceFileGet(c, path, r)
 	case "POST":
+		if format == "tar" {
+			return instanceFileTreePost(c, path, r)
+		}
+
 		return instanceFilePost(c, path, r)
 	case "DELETE":
 		return instanceFileDelete(c, path, r)
@@ -60,10 +83,17 @@ func instanceFileHandler(d *Daemon, r *http.Request) response.Response {
 //
 // Gets the file content. If it's a directory, a json list of files will be returned instead.
 //
+// With the tar format, the whole tree found at the path is returned as a tar stream
+// preserving ownership, permissions and extended attributes.
+//
+// With the manifest format, a json list describing every entry of the tree found at the path
+// is returned instead, including the checksum of the files, so that clients can tell what changed.
+//
 // ---
 // produces:
 //   - application/json
 //   - application/octet-stream
+//   - application/x-tar
 // parameters:
 //   - in: query
 //     name: path
@@ -71,6 +101,11 @@ func instanceFileHandler(d *Daemon, r *http.Request) response.Response {
 //     type: string
 //     example: default
 //   - in: query
+//     name: format
+//     description: Transfer format (tar to pull a whole tree, manifest to describe it)
+//     type: string
+//     example: tar
+//   - in: query
 //     name: project
 //     description: Project name
 //     type: string
@@ -169,9 +204,13 @@ func instanceFileGet(c instance.Instance, path string, r *http.Request) response
 //
 // Creates a new file in the instance.
 //
+// With the tar format, the request body is a tar stream which is extracted into the directory at the path,
+// restoring ownership, permissions and extended attributes. The file headers are then ignored.
+//
 // ---
 // consumes:
 //   - application/octet-stream
+//   - application/x-tar
 // produces:
 //   - application/json
 // parameters:
@@ -181,6 +220,11 @@ func instanceFileGet(c instance.Instance, path string, r *http.Request) response
 //     type: string
 //     example: default
 //   - in: query
+//     name: format
+//     description: Transfer format (tar to push a whole tree)
+//     type: string
+//     example: tar
+//   - in: query
 //     name: project
 //     description: Project name
 //     type: string
@@ -282,6 +326,96 @@ func instanceFilePost(c instance.Instance, path string, r *http.Request) respons
 	}
 }
 
+func instanceFileTreeGet(c instance.Instance, path string) response.Response {
+	return response.StreamResponse("application/x-tar", func(w io.Writer) error {
+		return c.FileTreePull(path, w)
+	})
+}
+
+// instanceFileManifestGet describes the tree at path by reading it as a tar stream on the server side.
+func instanceFileManifestGet(c instance.Instance, path string) response.Response {
+	reader, writer := io.Pipe()
+	defer reader.Close()
+
+	go func() {
+		writer.CloseWithError(c.FileTreePull(path, writer))
+	}()
+
+	entries := []api.InstanceFileManifestEntry{}
+	files := map[string]*api.InstanceFileManifestEntry{}
+
+	tr := tar.NewReader(reader)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		// Entries are named relative to the parent of the path, make them relative to the path itself.
+		name := strings.TrimSuffix(hdr.Name, "/")
+		relPath := "."
+		fields := strings.SplitN(name, "/", 2)
+		if len(fields) == 2 {
+			relPath = fields[1]
+		}
+
+		entry := api.InstanceFileManifestEntry{
+			Path:       relPath,
+			Mode:       hdr.Mode & 07777,
+			UID:        int64(hdr.Uid),
+			GID:        int64(hdr.Gid),
+			ModifiedAt: hdr.ModTime,
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeReg:
+			hash := sha256.New()
+			size, err := io.Copy(hash, tr)
+			if err != nil {
+				return response.SmartError(err)
+			}
+
+			entry.Type = "file"
+			entry.Size = size
+			entry.Checksum = fmt.Sprintf("%x", hash.Sum(nil))
+			files[hdr.Name] = &entry
+		case tar.TypeLink:
+			// Hard links carry the content of their first entry.
+			first, ok := files[hdr.Linkname]
+			if !ok {
+				return response.InternalError(fmt.Errorf("Hard link %q to unknown entry %q", hdr.Name, hdr.Linkname))
+			}
+
+			entry = *first
+			entry.Path = relPath
+		case tar.TypeDir:
+			entry.Type = "directory"
+		case tar.TypeSymlink:
+			entry.Type = "symlink"
+			entry.Target = hdr.Linkname
+		default:
+			entry.Type = "other"
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return response.SyncResponse(true, entries)
+}
+
+func instanceFileTreePost(c instance.Instance, path string, r *http.Request) response.Response {
+	err := c.FileTreePush(r.Body, path)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.EmptySyncResponse
+}
+
 // swagger:operation DELETE /1.0/instances/{name}/files instances instance_files_delete
 //
 // Delete a file
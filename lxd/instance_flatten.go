@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+
+	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/instance"
+	"github.com/lxc/lxd/lxd/instance/operationlock"
+	"github.com/lxc/lxd/lxd/operations"
+	"github.com/lxc/lxd/lxd/response"
+	storagePools "github.com/lxc/lxd/lxd/storage"
+	"github.com/lxc/lxd/shared"
+)
+
+// swagger:operation POST /1.0/instances/{name}/flatten instances instance_flatten_post
+//
+// Flatten the instance
+//
+// Breaks the dependency of an instance created as a clone on its source
+// by giving it its own copy of the data it shares with it.
+//
+// The instance must be stopped.
+//
+// ---
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+// responses:
+//   "202":
+//     $ref: "#/responses/Operation"
+//   "400":
+//     $ref: "#/responses/BadRequest"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "404":
+//     $ref: "#/responses/NotFound"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func instanceFlattenPost(d *Daemon, r *http.Request) response.Response {
+	instanceType, err := urlInstanceTypeDetect(r)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	projectName := projectParam(r)
+	name := mux.Vars(r)["name"]
+
+	if shared.IsSnapshot(name) {
+		return response.BadRequest(fmt.Errorf("Snapshots can't be flattened"))
+	}
+
+	// Handle requests targeted to an instance on a different node.
+	resp, err := forwardedResponseIfInstanceIsRemote(d, r, projectName, name, instanceType)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if resp != nil {
+		return resp
+	}
+
+	inst, err := instance.LoadByProjectAndName(d.State(), projectName, name)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if inst.IsRunning() {
+		return response.BadRequest(fmt.Errorf("Instance must be stopped to be flattened"))
+	}
+
+	if inst.LocalConfig()["volatile.clone_source"] == "" {
+		return response.BadRequest(fmt.Errorf("Instance isn't a clone"))
+	}
+
+	run := func(op *operations.Operation) error {
+		inst.SetOperation(op)
+
+		return instanceFlatten(d, inst, op)
+	}
+
+	resources := map[string][]string{}
+	resources["instances"] = []string{name}
+
+	op, err := operations.OperationCreate(d.State(), projectName, operations.OperationClassTask, db.OperationInstanceFlatten, resources, nil, run, nil, nil, r)
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	return operations.OperationResponse(op)
+}
+
+// instanceFlatten makes the root volume of the stopped clone independent of its source.
+func instanceFlatten(d *Daemon, inst instance.Instance, op *operations.Operation) error {
+	instOp, err := operationlock.Create(inst.ID(), "flatten", false, false)
+	if err != nil {
+		return errors.Wrap(err, "Failed creating instance flatten operation")
+	}
+
+	defer func() { instOp.Done(err) }()
+
+	pool, err := storagePools.GetPoolByInstance(d.State(), inst)
+	if err != nil {
+		return errors.Wrap(err, "Failed loading instance storage pool")
+	}
+
+	err = pool.FlattenInstance(inst, op)
+	if err != nil {
+		return errors.Wrap(err, "Failed flattening instance root volume")
+	}
+
+	err = inst.VolatileSet(map[string]string{"volatile.clone_source": ""})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/lxc/lxd/lxd/instance"
 	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/lxd/util"
 )
 
 // swagger:operation GET /1.0/instances/{name} instances instance_get
@@ -23,6 +24,11 @@ import (
 //     description: Project name
 //     type: string
 //     example: default
+//   - in: query
+//     name: fields
+//     description: Comma separated list of fields to return
+//     type: string
+//     example: name,status
 // responses:
 //   "200":
 //     description: Instance
@@ -76,5 +82,10 @@ func instanceGet(d *Daemon, r *http.Request) response.Response {
 		return response.SmartError(err)
 	}
 
-	return response.SyncResponseETag(true, state, etag)
+	result, err := util.SelectFields(state, util.FieldsFromRequest(r))
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponseETag(true, result, etag)
 }
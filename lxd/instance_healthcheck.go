@@ -0,0 +1,217 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/lxc/lxd/lxd/instance"
+	"github.com/lxc/lxd/lxd/instance/instancetype"
+	"github.com/lxc/lxd/lxd/state"
+	"github.com/lxc/lxd/lxd/task"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	log "github.com/lxc/lxd/shared/log15"
+	"github.com/lxc/lxd/shared/logger"
+	"github.com/lxc/lxd/shared/logging"
+)
+
+// instanceHealth tracks the health checks of a running instance.
+type instanceHealth struct {
+	lastCheck time.Time
+	failures  int64
+	checking  bool
+}
+
+var instanceHealthLock sync.Mutex
+var instanceHealthChecks = map[int]*instanceHealth{}
+
+// healthcheckConfigInt returns the integer value of a healthcheck.* key, or the default if unset.
+func healthcheckConfigInt(config map[string]string, key string, defaultValue int64) int64 {
+	value, err := strconv.ParseInt(config[key], 10, 64)
+	if err != nil {
+		return defaultValue
+	}
+
+	return value
+}
+
+func instanceHealthChecksTask(d *Daemon) (task.Func, task.Schedule) {
+	f := func(ctx context.Context) {
+		instanceHealthChecksRun(d.State())
+	}
+
+	return f, task.Every(5 * time.Second)
+}
+
+// instanceHealthChecksRun starts the health checks which are due on the local running instances.
+func instanceHealthChecksRun(s *state.State) {
+	instances, err := instance.LoadNodeAll(s, instancetype.Any)
+	if err != nil {
+		logger.Error("Failed loading instances for health checks", log.Ctx{"err": err})
+		return
+	}
+
+	instanceHealthLock.Lock()
+	defer instanceHealthLock.Unlock()
+
+	checked := map[int]bool{}
+	for _, inst := range instances {
+		config := inst.ExpandedConfig()
+		if config["healthcheck.type"] == "" || !inst.IsRunning() {
+			if inst.LocalConfig()["volatile.health"] != "" {
+				err := inst.VolatileSet(map[string]string{"volatile.health": ""})
+				if err != nil {
+					logger.Warn("Failed clearing instance health", log.Ctx{"project": inst.Project(), "instance": inst.Name(), "err": err})
+				}
+			}
+
+			continue
+		}
+
+		checked[inst.ID()] = true
+
+		health, found := instanceHealthChecks[inst.ID()]
+		if !found {
+			// Give the instance a full interval to start before the first check, and don't keep the
+			// result from its previous run.
+			health = &instanceHealth{lastCheck: time.Now()}
+			instanceHealthChecks[inst.ID()] = health
+
+			if inst.LocalConfig()["volatile.health"] != "" {
+				err := inst.VolatileSet(map[string]string{"volatile.health": ""})
+				if err != nil {
+					logger.Warn("Failed clearing instance health", log.Ctx{"project": inst.Project(), "instance": inst.Name(), "err": err})
+				}
+			}
+
+			continue
+		}
+
+		interval := time.Duration(healthcheckConfigInt(config, "healthcheck.interval", 30)) * time.Second
+		if health.checking || time.Since(health.lastCheck) < interval {
+			continue
+		}
+
+		health.checking = true
+		health.lastCheck = time.Now()
+
+		go instanceHealthCheck(inst, health)
+	}
+
+	// Forget about the instances which aren't running anymore.
+	for id := range instanceHealthChecks {
+		if !checked[id] {
+			delete(instanceHealthChecks, id)
+		}
+	}
+}
+
+// instanceHealthCheck runs the health check of the instance and records its result. The instance is
+// considered unhealthy after healthcheck.retries consecutive failures, in which case it gets restarted if
+// healthcheck.restart is enabled.
+func instanceHealthCheck(inst instance.Instance, health *instanceHealth) {
+	config := inst.ExpandedConfig()
+	timeout := time.Duration(healthcheckConfigInt(config, "healthcheck.timeout", 5)) * time.Second
+	retries := healthcheckConfigInt(config, "healthcheck.retries", 3)
+
+	var err error
+	switch config["healthcheck.type"] {
+	case "exec":
+		err = instanceHealthExec(inst, config["healthcheck.command"], timeout)
+	default:
+		err = inst.HealthProbe(config["healthcheck.type"], config["healthcheck.address"], timeout)
+	}
+
+	instanceHealthLock.Lock()
+	health.checking = false
+	if err != nil {
+		health.failures++
+	} else {
+		health.failures = 0
+	}
+
+	failures := health.failures
+	if failures >= retries {
+		health.failures = 0
+	}
+	instanceHealthLock.Unlock()
+
+	instLogger := logging.AddContext(logger.Log, log.Ctx{"project": inst.Project(), "instance": inst.Name()})
+
+	status := "healthy"
+	if err != nil {
+		instLogger.Debug("Instance health check failed", log.Ctx{"err": err, "failures": failures})
+
+		if failures < retries {
+			return
+		}
+
+		status = "unhealthy"
+	}
+
+	if inst.LocalConfig()["volatile.health"] != status {
+		if status == "unhealthy" {
+			instLogger.Warn("Instance is unhealthy", log.Ctx{"err": err})
+		}
+
+		err := inst.VolatileSet(map[string]string{"volatile.health": status})
+		if err != nil {
+			instLogger.Warn("Failed recording instance health", log.Ctx{"err": err})
+		}
+	}
+
+	if status == "unhealthy" && shared.IsTrue(config["healthcheck.restart"]) {
+		err := inst.Autorestart()
+		if err != nil {
+			instLogger.Error("Failed restarting unhealthy instance", log.Ctx{"err": err})
+		}
+	}
+}
+
+// instanceHealthExec runs the health check command inside the instance, which must exit successfully
+// within the timeout.
+func instanceHealthExec(inst instance.Instance, command string, timeout time.Duration) error {
+	req := api.InstanceExecPost{
+		Command: []string{"/bin/sh", "-c", command},
+		Environment: map[string]string{
+			"PATH": "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin",
+			"HOME": "/root",
+			"USER": "root",
+			"LANG": "C.UTF-8",
+		},
+	}
+
+	cmd, err := inst.Exec(req, nil, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	var exitCode int
+	chDone := make(chan error, 1)
+	go func() {
+		var err error
+		exitCode, err = cmd.Wait()
+		chDone <- err
+	}()
+
+	select {
+	case err = <-chDone:
+		if err != nil {
+			return err
+		}
+	case <-time.After(timeout):
+		cmd.Signal(unix.SIGKILL)
+		return fmt.Errorf("Health check command timed out")
+	}
+
+	if exitCode != 0 {
+		return fmt.Errorf("Health check command failed with exit code %d", exitCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/lxc/lxd/lxd/instance"
+	"github.com/lxc/lxd/lxd/instance/instancetype"
+	"github.com/lxc/lxd/lxd/lifecycle"
+	"github.com/lxc/lxd/lxd/request"
+	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/shared"
+)
+
+// instanceNVRAMMaxSize is the maximum size of an uploaded NVRAM, well above that of the firmware settings.
+const instanceNVRAMMaxSize = 64 * 1024 * 1024
+
+// instanceNVRAMLoad loads the virtual machine targeted by the request, returning a response if the request
+// must be forwarded to another member or has failed.
+func instanceNVRAMLoad(d *Daemon, r *http.Request) (instance.VM, response.Response) {
+	instanceType, err := urlInstanceTypeDetect(r)
+	if err != nil {
+		return nil, response.SmartError(err)
+	}
+
+	projectName := projectParam(r)
+	name := mux.Vars(r)["name"]
+
+	if shared.IsSnapshot(name) {
+		return nil, response.BadRequest(fmt.Errorf("The NVRAM of snapshots can't be accessed"))
+	}
+
+	// Handle requests targeted to an instance on a different node.
+	resp, err := forwardedResponseIfInstanceIsRemote(d, r, projectName, name, instanceType)
+	if err != nil {
+		return nil, response.SmartError(err)
+	}
+
+	if resp != nil {
+		return nil, resp
+	}
+
+	inst, err := instance.LoadByProjectAndName(d.State(), projectName, name)
+	if err != nil {
+		return nil, response.SmartError(err)
+	}
+
+	if inst.Type() != instancetype.VM {
+		return nil, response.BadRequest(fmt.Errorf("Only virtual machines have an NVRAM"))
+	}
+
+	return inst.(instance.VM), nil
+}
+
+// swagger:operation GET /1.0/instances/{name}/nvram instances instance_nvram_get
+//
+// Get the NVRAM
+//
+// Downloads the UEFI NVRAM of the virtual machine, which holds the firmware
+// settings such as the boot entries and the enrolled secure boot keys.
+//
+// ---
+// produces:
+//   - application/octet-stream
+//   - application/json
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+// responses:
+//   "200":
+//      description: Raw NVRAM
+//      content:
+//        application/octet-stream:
+//          schema:
+//            type: string
+//            example: raw-nvram
+//   "400":
+//     $ref: "#/responses/BadRequest"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "404":
+//     $ref: "#/responses/NotFound"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func instanceNVRAMGet(d *Daemon, r *http.Request) response.Response {
+	vm, resp := instanceNVRAMLoad(d, r)
+	if resp != nil {
+		return resp
+	}
+
+	data, err := vm.NVRAMGet()
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	d.State().Events.SendLifecycle(vm.Project(), lifecycle.InstanceNVRAMRetrieved.Event(vm, request.CreateRequestor(r), nil))
+
+	ent := response.FileResponseEntry{Buffer: data, Filename: "qemu.nvram"}
+	return response.FileResponse(r, []response.FileResponseEntry{ent}, nil, false)
+}
+
+// swagger:operation PUT /1.0/instances/{name}/nvram instances instance_nvram_put
+//
+// Replace the NVRAM
+//
+// Replaces the UEFI NVRAM of the virtual machine, such as with one having
+// custom secure boot keys enrolled. It must have the size of the firmware
+// settings used by the server.
+//
+// The instance must be stopped.
+//
+// ---
+// consumes:
+//   - application/octet-stream
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+//   - in: body
+//     name: raw_nvram
+//     description: Raw NVRAM
+//     required: true
+// responses:
+//   "200":
+//     $ref: "#/responses/EmptySyncResponse"
+//   "400":
+//     $ref: "#/responses/BadRequest"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "404":
+//     $ref: "#/responses/NotFound"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func instanceNVRAMPut(d *Daemon, r *http.Request) response.Response {
+	vm, resp := instanceNVRAMLoad(d, r)
+	if resp != nil {
+		return resp
+	}
+
+	data, err := ioutil.ReadAll(http.MaxBytesReader(nil, r.Body, instanceNVRAMMaxSize))
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	err = vm.NVRAMSet(data)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	d.State().Events.SendLifecycle(vm.Project(), lifecycle.InstanceNVRAMUpdated.Event(vm, request.CreateRequestor(r), nil))
+
+	return response.EmptySyncResponse
+}
+
+// swagger:operation DELETE /1.0/instances/{name}/nvram instances instance_nvram_delete
+//
+// Reset the NVRAM
+//
+// Re-creates the UEFI NVRAM of the virtual machine from the firmware defaults,
+// following `security.secureboot`.
+//
+// The instance must be stopped.
+//
+// ---
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+// responses:
+//   "200":
+//     $ref: "#/responses/EmptySyncResponse"
+//   "400":
+//     $ref: "#/responses/BadRequest"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "404":
+//     $ref: "#/responses/NotFound"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func instanceNVRAMDelete(d *Daemon, r *http.Request) response.Response {
+	vm, resp := instanceNVRAMLoad(d, r)
+	if resp != nil {
+		return resp
+	}
+
+	err := vm.NVRAMReset()
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	d.State().Events.SendLifecycle(vm.Project(), lifecycle.InstanceNVRAMReset.Event(vm, request.CreateRequestor(r), nil))
+
+	return response.EmptySyncResponse
+}
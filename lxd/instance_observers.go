@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/logger"
+)
+
+// sessionObserverBacklog is the number of output messages queued for an observer before it gets disconnected.
+const sessionObserverBacklog = 1024
+
+// sessionObservers fans out the output of an interactive console or exec session to read-only websockets.
+// Observers never send input to the session and slow observers are disconnected rather than slowing it down.
+type sessionObservers struct {
+	secret string
+	lock   sync.Mutex
+	conns  map[*websocket.Conn]chan []byte
+	closed bool
+}
+
+func newSessionObservers() (*sessionObservers, error) {
+	secret, err := shared.RandomCryptoString()
+	if err != nil {
+		return nil, err
+	}
+
+	return &sessionObservers{secret: secret, conns: map[*websocket.Conn]chan []byte{}}, nil
+}
+
+// connect upgrades the request to a websocket and registers it as a new observer.
+func (o *sessionObservers) connect(r *http.Request, w http.ResponseWriter) error {
+	conn, err := shared.WebsocketUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return err
+	}
+
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	if o.closed {
+		conn.Close()
+		return fmt.Errorf("Session has already finished")
+	}
+
+	ch := make(chan []byte, sessionObserverBacklog)
+	o.conns[conn] = ch
+
+	// Forward the output until the observer is removed.
+	go func() {
+		failed := false
+		for buf := range ch {
+			if failed {
+				continue
+			}
+
+			err := conn.WriteMessage(websocket.BinaryMessage, buf)
+			if err != nil {
+				logger.Debugf("Failed writing to session observer: %v", err)
+				failed = true
+				o.remove(conn)
+			}
+		}
+
+		closeMsg := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+		conn.WriteMessage(websocket.CloseMessage, closeMsg)
+		conn.Close()
+	}()
+
+	// Discard anything sent by the observer and detect disconnections.
+	go func() {
+		for {
+			_, _, err := conn.NextReader()
+			if err != nil {
+				o.remove(conn)
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+// remove disconnects an observer.
+func (o *sessionObservers) remove(conn *websocket.Conn) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	ch, ok := o.conns[conn]
+	if ok {
+		delete(o.conns, conn)
+		close(ch)
+	}
+}
+
+// Write sends a copy of data to all the observers, disconnecting those which can't keep up.
+// It never fails so that it can be used alongside the session's own output.
+func (o *sessionObservers) Write(data []byte) (int, error) {
+	buf := make([]byte, len(data))
+	copy(buf, data)
+
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	for conn, ch := range o.conns {
+		select {
+		case ch <- buf:
+		default:
+			logger.Debugf("Disconnecting session observer which can't keep up")
+			delete(o.conns, conn)
+			close(ch)
+		}
+	}
+
+	return len(data), nil
+}
+
+// close disconnects all the observers and refuses new ones.
+func (o *sessionObservers) close() {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	o.closed = true
+	for conn, ch := range o.conns {
+		delete(o.conns, conn)
+		close(ch)
+	}
+}
+
+// wrap returns a ReadWriteCloser copying what gets read from the terminal to the observers.
+func (o *sessionObservers) wrap(terminal io.ReadWriteCloser) io.ReadWriteCloser {
+	return &observedTerminal{ReadWriteCloser: terminal, observers: o}
+}
+
+type observedTerminal struct {
+	io.ReadWriteCloser
+	observers *sessionObservers
+}
+
+func (t *observedTerminal) Read(p []byte) (int, error) {
+	n, err := t.ReadWriteCloser.Read(p)
+	if n > 0 {
+		t.observers.Write(p[:n])
+	}
+
+	return n, err
+}
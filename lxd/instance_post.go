@@ -18,6 +18,7 @@ import (
 	"github.com/lxc/lxd/lxd/migration"
 	"github.com/lxc/lxd/lxd/operations"
 	"github.com/lxc/lxd/lxd/project"
+	"github.com/lxc/lxd/lxd/rbac"
 	"github.com/lxc/lxd/lxd/response"
 	storagePools "github.com/lxc/lxd/lxd/storage"
 	"github.com/lxc/lxd/shared"
@@ -214,11 +215,26 @@ func instancePost(d *Daemon, r *http.Request) response.Response {
 	}
 
 	if req.Migration {
-		// Server-side pool migration.
-		if req.Pool != "" {
+		// Server-side pool or project migration.
+		if req.Pool != "" || req.Project != "" {
+			if req.Project == projectName {
+				req.Project = ""
+			}
+
+			if req.Project != "" {
+				if !rbac.UserHasPermission(r, req.Project, "manage-containers") {
+					return response.Forbidden(nil)
+				}
+
+				err = instancePostProjectCheck(d, inst, req.Name, req.Project)
+				if err != nil {
+					return response.SmartError(err)
+				}
+			}
+
 			// Setup the instance move operation.
 			run := func(op *operations.Operation) error {
-				return instancePostPoolMigration(d, inst, req.Name, req.InstanceOnly, req.Pool, op)
+				return instancePostMigration(d, inst, req.Name, req.InstanceOnly, req.Pool, req.Project, op)
 			}
 
 			resources := map[string][]string{}
@@ -244,7 +260,7 @@ func instancePost(d *Daemon, r *http.Request) response.Response {
 
 			// Check whether the instance is running.
 			if !sourceNodeOffline && inst.IsRunning() {
-				return response.BadRequest(fmt.Errorf("Instance is running"))
+				return response.BadRequest(api.StatusErrorTypef(http.StatusBadRequest, api.ErrInstanceRunning, "Instance is running"))
 			}
 
 			run := func(op *operations.Operation) error {
@@ -263,6 +279,9 @@ func instancePost(d *Daemon, r *http.Request) response.Response {
 				return response.InternalError(err)
 			}
 
+			// This only coordinates the move, the migration operations it waits on are limited themselves.
+			op.SetUnlimited()
+
 			return operations.OperationResponse(op)
 		}
 
@@ -284,7 +303,7 @@ func instancePost(d *Daemon, r *http.Request) response.Response {
 		}
 
 		cancel := func(op *operations.Operation) error {
-			ws.disconnect()
+			ws.Cancel()
 			return nil
 		}
 
@@ -295,7 +314,7 @@ func instancePost(d *Daemon, r *http.Request) response.Response {
 				return response.InternalError(err)
 			}
 
-			op, err := operations.OperationCreate(d.State(), projectName, operations.OperationClassTask, db.OperationInstanceMigrate, resources, nil, run, nil, nil, r)
+			op, err := operations.OperationCreate(d.State(), projectName, operations.OperationClassTask, db.OperationInstanceMigrate, resources, nil, run, cancel, nil, r)
 			if err != nil {
 				return response.InternalError(err)
 			}
@@ -338,13 +357,72 @@ func instancePost(d *Daemon, r *http.Request) response.Response {
 }
 
 // Move an instance to another pool.
-func instancePostPoolMigration(d *Daemon, inst instance.Instance, newName string, instanceOnly bool, newPool string, op *operations.Operation) error {
+// instancePostProjectCheck validates that an instance can be moved to another project, making sure that the name
+// is available, that all of its profiles exist in the target project and that the project limits allow it.
+func instancePostProjectCheck(d *Daemon, inst instance.Instance, newName string, newProject string) error {
+	if newName == "" {
+		newName = inst.Name()
+	}
+
+	// Profiles are remapped to the ones with the same name in the target project.
+	profiles, err := d.cluster.GetProfileNames(newProject)
+	if err != nil {
+		return err
+	}
+
+	for _, profile := range inst.Profiles() {
+		if !shared.StringInSlice(profile, profiles) {
+			return api.StatusErrorf(http.StatusBadRequest, "Profile %q doesn't exist in project %q", profile, newProject)
+		}
+	}
+
+	return d.cluster.Transaction(func(tx *db.ClusterTx) error {
+		exists, err := tx.ProjectExists(newProject)
+		if err != nil {
+			return err
+		}
+
+		if !exists {
+			return api.StatusErrorf(http.StatusNotFound, "Project %q not found", newProject)
+		}
+
+		_, err = tx.GetInstanceID(newProject, newName)
+		if err == nil {
+			return api.StatusErrorf(http.StatusConflict, "Name %q already in use in project %q", newName, newProject)
+		}
+
+		req := api.InstancesPost{
+			Name: newName,
+			Type: api.InstanceType(inst.Type().String()),
+			InstancePut: api.InstancePut{
+				Config:   inst.LocalConfig(),
+				Devices:  inst.LocalDevices().CloneNative(),
+				Profiles: inst.Profiles(),
+			},
+			Source: api.InstanceSource{Type: "copy"},
+		}
+
+		return project.AllowInstanceCreation(tx, newProject, req)
+	})
+}
+
+// instancePostMigration moves an instance to another storage pool and/or project on the same server by
+// copying it and deleting the original.
+func instancePostMigration(d *Daemon, inst instance.Instance, newName string, instanceOnly bool, newPool string, newProject string, op *operations.Operation) error {
 	if inst.IsRunning() {
-		return fmt.Errorf("Instance must not be running to move between pools")
+		return fmt.Errorf("Instance must not be running to move between pools or projects")
 	}
 
 	if inst.IsSnapshot() {
-		return fmt.Errorf("Instance snapshots cannot be moved between pools")
+		return fmt.Errorf("Instance snapshots cannot be moved between pools or projects")
+	}
+
+	if newName == "" {
+		newName = inst.Name()
+	}
+
+	if newProject == "" {
+		newProject = inst.Project()
 	}
 
 	// Copy config from instance to avoid modifying it.
@@ -361,8 +439,10 @@ func instancePostPoolMigration(d *Daemon, inst instance.Instance, newName string
 
 	// Copy device config from instance, and update target instance root disk device with the new pool name.
 	localDevices := inst.LocalDevices().Clone()
-	rootDev["pool"] = newPool
-	localDevices[rootDevKey] = rootDev
+	if newPool != "" {
+		rootDev["pool"] = newPool
+		localDevices[rootDevKey] = rootDev
+	}
 
 	// Specify the target instance config with the new name and modified root disk config.
 	args := db.InstanceArgs{
@@ -370,7 +450,7 @@ func instancePostPoolMigration(d *Daemon, inst instance.Instance, newName string
 		BaseImage:    localConfig["volatile.base_image"],
 		Config:       localConfig,
 		Devices:      localDevices,
-		Project:      inst.Project(),
+		Project:      newProject,
 		Type:         inst.Type(),
 		Architecture: inst.Architecture(),
 		Description:  inst.Description(),
@@ -379,11 +459,12 @@ func instancePostPoolMigration(d *Daemon, inst instance.Instance, newName string
 		Stateful:     inst.IsStateful(),
 	}
 
-	// If we are moving the instance to a new pool but keeping the same instace name, then we need to create
-	// the copy of the instance on the new pool with a temporary name that is different from the source to
-	// avoid conflicts. Then after the source instance has been deleted we will rename the new instance back
-	// to the original name.
-	if newName == inst.Name() {
+	// If we are moving the instance to a new pool but keeping the same instace name and project, then we
+	// need to create the copy of the instance on the new pool with a temporary name that is different from
+	// the source to avoid conflicts. Then after the source instance has been deleted we will rename the new
+	// instance back to the original name.
+	tempName := newName == inst.Name() && newProject == inst.Project()
+	if tempName {
 		args.Name = instance.MoveTemporaryName(inst)
 	}
 
@@ -405,7 +486,7 @@ func instancePostPoolMigration(d *Daemon, inst instance.Instance, newName string
 	}
 
 	// Rename copy from temporary name to original name if needed.
-	if newName == inst.Name() {
+	if tempName {
 		err = targetInst.Rename(newName, false) // Don't apply templates when moving.
 		if err != nil {
 			return err
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+
+	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/instance"
+	"github.com/lxc/lxd/lxd/instance/instancetype"
+	"github.com/lxc/lxd/lxd/instance/operationlock"
+	"github.com/lxc/lxd/lxd/lifecycle"
+	"github.com/lxc/lxd/lxd/operations"
+	"github.com/lxc/lxd/lxd/response"
+	storagePools "github.com/lxc/lxd/lxd/storage"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+)
+
+// swagger:operation POST /1.0/instances/{name}/rebuild instances instance_rebuild_post
+//
+// Rebuild the instance
+//
+// Wipes the root volume of the instance and re-creates it from an image (or
+// empty), keeping the configuration, devices and attached volumes of the instance.
+//
+// The instance must be stopped and must not have any snapshot.
+//
+// ---
+// consumes:
+//   - application/json
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+//   - in: body
+//     name: instance
+//     description: Rebuild request
+//     required: true
+//     schema:
+//       $ref: "#/definitions/InstanceRebuildPost"
+// responses:
+//   "202":
+//     $ref: "#/responses/Operation"
+//   "400":
+//     $ref: "#/responses/BadRequest"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "404":
+//     $ref: "#/responses/NotFound"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func instanceRebuildPost(d *Daemon, r *http.Request) response.Response {
+	instanceType, err := urlInstanceTypeDetect(r)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	projectName := projectParam(r)
+	name := mux.Vars(r)["name"]
+
+	if shared.IsSnapshot(name) {
+		return response.BadRequest(fmt.Errorf("Snapshots can't be rebuilt"))
+	}
+
+	// Handle requests targeted to an instance on a different node.
+	resp, err := forwardedResponseIfInstanceIsRemote(d, r, projectName, name, instanceType)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if resp != nil {
+		return resp
+	}
+
+	req := api.InstanceRebuildPost{}
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	inst, err := instance.LoadByProjectAndName(d.State(), projectName, name)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if inst.IsRunning() {
+		return response.BadRequest(fmt.Errorf("Instance must be stopped to be rebuilt"))
+	}
+
+	snapshots, err := inst.Snapshots()
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if len(snapshots) > 0 {
+		return response.BadRequest(fmt.Errorf("Instances with snapshots can't be rebuilt"))
+	}
+
+	var hash string
+	switch req.Source.Type {
+	case "image":
+		hash, err = instance.ResolveImage(d.State(), projectName, req.Source)
+		if err != nil {
+			return response.BadRequest(err)
+		}
+	case "none":
+	default:
+		return response.BadRequest(fmt.Errorf("Unknown source type %q", req.Source.Type))
+	}
+
+	run := func(op *operations.Operation) error {
+		var err error
+		var img *api.Image
+		if hash != "" {
+			img, err = instanceImageFetch(d, r, op, projectName, req.Source, hash, api.InstanceType(inst.Type().String()))
+			if err != nil {
+				return err
+			}
+
+			imgType, err := instancetype.New(img.Type)
+			if err != nil {
+				return err
+			}
+
+			if imgType != inst.Type() {
+				return fmt.Errorf("Requested image's type %q doesn't match instance type %q", imgType, inst.Type())
+			}
+
+			err = instanceImageEnsureLocal(d, r, projectName, img)
+			if err != nil {
+				return err
+			}
+		}
+
+		inst.SetOperation(op)
+
+		return instanceRebuild(d, inst, img, op)
+	}
+
+	resources := map[string][]string{}
+	resources["instances"] = []string{name}
+
+	op, err := operations.OperationCreate(d.State(), projectName, operations.OperationClassTask, db.OperationInstanceRebuild, resources, nil, run, nil, nil, r)
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	return operations.OperationResponse(op)
+}
+
+// instanceRebuild re-creates the root volume of the stopped instance from the given image, or empty if
+// img is nil, and points the instance config at the new image.
+func instanceRebuild(d *Daemon, inst instance.Instance, img *api.Image, op *operations.Operation) error {
+	instOp, err := operationlock.Create(inst.ID(), "rebuild", false, false)
+	if err != nil {
+		return errors.Wrap(err, "Failed creating instance rebuild operation")
+	}
+
+	defer func() { instOp.Done(err) }()
+
+	pool, err := storagePools.GetPoolByInstance(d.State(), inst)
+	if err != nil {
+		return errors.Wrap(err, "Failed loading instance storage pool")
+	}
+
+	fingerprint := ""
+	if img != nil {
+		fingerprint = img.Fingerprint
+	}
+
+	// The old root volume is restored if the new one can't be created.
+	err = pool.RebuildInstance(inst, fingerprint, op)
+	if err != nil {
+		return errors.Wrap(err, "Failed re-creating instance root volume")
+	}
+
+	// Replace the "image.*" keys and the base image.
+	config := inst.LocalConfig()
+	for k := range config {
+		if strings.HasPrefix(k, "image.") {
+			delete(config, k)
+		}
+	}
+
+	delete(config, "volatile.base_image")
+	if img != nil {
+		for k, v := range img.Properties {
+			config[fmt.Sprintf("image.%s", k)] = v
+		}
+
+		config["volatile.base_image"] = img.Fingerprint
+	}
+
+	// The new root volume isn't shifted yet.
+	if inst.Type() == instancetype.Container {
+		config["volatile.last_state.idmap"] = "[]"
+	}
+
+	args := db.InstanceArgs{
+		Architecture: inst.Architecture(),
+		Description:  inst.Description(),
+		Config:       config,
+		Devices:      inst.LocalDevices(),
+		Ephemeral:    inst.IsEphemeral(),
+		Profiles:     inst.Profiles(),
+		Project:      inst.Project(),
+		Type:         inst.Type(),
+		Snapshot:     inst.IsSnapshot(),
+	}
+
+	err = inst.Update(args, false)
+	if err != nil {
+		return err
+	}
+
+	if img != nil {
+		err = d.cluster.UpdateImageLastUseDate(img.Fingerprint, time.Now().UTC())
+		if err != nil {
+			return errors.Wrap(err, "Error updating image last use date")
+		}
+	}
+
+	ctx := map[string]interface{}{}
+	if img != nil {
+		ctx["image"] = img.Fingerprint
+	}
+
+	d.State().Events.SendLifecycle(inst.Project(), lifecycle.InstanceRebuilt.Event(inst, ctx))
+
+	return nil
+}
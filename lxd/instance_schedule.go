@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/lxc/lxd/lxd/instance"
+	"github.com/lxc/lxd/lxd/instance/instancetype"
+	"github.com/lxc/lxd/lxd/state"
+	"github.com/lxc/lxd/lxd/task"
+	log "github.com/lxc/lxd/shared/log15"
+	"github.com/lxc/lxd/shared/logger"
+)
+
+func instanceSchedulesTask(d *Daemon) (task.Func, task.Schedule) {
+	f := func(ctx context.Context) {
+		instanceSchedulesRun(d.State())
+	}
+
+	first := true
+	schedule := func() (time.Duration, error) {
+		interval := time.Minute
+
+		if first {
+			first = false
+			return interval, task.ErrSkip
+		}
+
+		return interval, nil
+	}
+
+	return f, schedule
+}
+
+// instanceSchedulesRun starts and stops the local instances whose schedule.start or schedule.stop is now.
+func instanceSchedulesRun(s *state.State) {
+	instances, err := instance.LoadNodeAll(s, instancetype.Any)
+	if err != nil {
+		logger.Error("Failed loading instances for scheduled start and stop", log.Ctx{"err": err})
+		return
+	}
+
+	for _, inst := range instances {
+		config := inst.ExpandedConfig()
+
+		if config["schedule.stop"] != "" && snapshotIsScheduledNow(config["schedule.stop"], int64(inst.ID())) {
+			if inst.IsRunning() {
+				go instanceScheduledStop(inst)
+			}
+
+			continue
+		}
+
+		if config["schedule.start"] != "" && snapshotIsScheduledNow(config["schedule.start"], int64(inst.ID())) {
+			if !inst.IsRunning() && !s.Cluster.LocalNodeIsEvacuated() {
+				go instanceScheduledStart(inst)
+			}
+		}
+	}
+}
+
+// instanceScheduledStart starts the instance on schedule.
+func instanceScheduledStart(inst instance.Instance) {
+	logger.Info("Starting instance on schedule", log.Ctx{"project": inst.Project(), "instance": inst.Name()})
+
+	err := inst.Start(false)
+	if err != nil {
+		logger.Error("Failed starting instance on schedule", log.Ctx{"project": inst.Project(), "instance": inst.Name(), "err": err})
+	}
+}
+
+// instanceScheduledStop cleanly shuts the instance down on schedule, stopping it if it doesn't within its
+// boot.host_shutdown_timeout.
+func instanceScheduledStop(inst instance.Instance) {
+	logger.Info("Stopping instance on schedule", log.Ctx{"project": inst.Project(), "instance": inst.Name()})
+
+	timeout, err := strconv.Atoi(inst.ExpandedConfig()["boot.host_shutdown_timeout"])
+	if err != nil {
+		timeout = 30
+	}
+
+	err = inst.Shutdown(time.Duration(timeout) * time.Second)
+	if err != nil {
+		err = inst.Stop(false)
+		if err != nil {
+			logger.Error("Failed stopping instance on schedule", log.Ctx{"project": inst.Project(), "instance": inst.Name(), "err": err})
+		}
+	}
+}
@@ -0,0 +1,722 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/lifecycle"
+	"github.com/lxc/lxd/lxd/project"
+	"github.com/lxc/lxd/lxd/request"
+	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/lxd/util"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/version"
+)
+
+var instanceTemplatesCmd = APIEndpoint{
+	Path: "instance-templates",
+
+	Get:  APIEndpointAction{Handler: instanceTemplatesGet, AccessHandler: allowProjectPermission("instance-templates", "view")},
+	Post: APIEndpointAction{Handler: instanceTemplatesPost, AccessHandler: allowProjectPermission("instance-templates", "manage-containers")},
+}
+
+var instanceTemplateCmd = APIEndpoint{
+	Path: "instance-templates/{name}",
+
+	Delete: APIEndpointAction{Handler: instanceTemplateDelete, AccessHandler: allowProjectPermission("instance-templates", "manage-containers")},
+	Get:    APIEndpointAction{Handler: instanceTemplateGet, AccessHandler: allowProjectPermission("instance-templates", "view")},
+	Patch:  APIEndpointAction{Handler: instanceTemplatePatch, AccessHandler: allowProjectPermission("instance-templates", "manage-containers")},
+	Put:    APIEndpointAction{Handler: instanceTemplatePut, AccessHandler: allowProjectPermission("instance-templates", "manage-containers")},
+}
+
+var instanceTemplateInstancesCmd = APIEndpoint{
+	Path: "instance-templates/{name}/instances",
+
+	Post: APIEndpointAction{Handler: instanceTemplateInstancesPost, AccessHandler: allowProjectPermission("instance-templates", "manage-containers")},
+}
+
+// instanceTemplateNameRegexp matches the valid instance template and variable names.
+var instanceTemplateNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// instanceTemplateVariableRegexp matches the references to variables in the values of instance templates.
+var instanceTemplateVariableRegexp = regexp.MustCompile(`\$\{var:([^}]*)\}`)
+
+// instanceTemplateURL returns the URL of an instance template.
+func instanceTemplateURL(projectName string, name string) string {
+	u := fmt.Sprintf("/%s/instance-templates/%s", version.APIVersion, url.PathEscape(name))
+	if projectName != project.Default {
+		u = fmt.Sprintf("%s?project=%s", u, url.QueryEscape(projectName))
+	}
+
+	return u
+}
+
+// instanceTemplateExpand replaces the references to variables in all the values of the template which may contain
+// some. The expand function is called with the name of each referenced variable.
+func instanceTemplateExpand(put *api.InstanceTemplatePut, expand func(name string) (string, error)) error {
+	var err error
+	replace := func(value string) string {
+		return instanceTemplateVariableRegexp.ReplaceAllStringFunc(value, func(ref string) string {
+			value, expandErr := expand(instanceTemplateVariableRegexp.FindStringSubmatch(ref)[1])
+			if expandErr != nil && err == nil {
+				err = expandErr
+			}
+
+			return value
+		})
+	}
+
+	put.Source.Alias = replace(put.Source.Alias)
+	put.Source.Fingerprint = replace(put.Source.Fingerprint)
+	put.Source.Server = replace(put.Source.Server)
+
+	for i := range put.Profiles {
+		put.Profiles[i] = replace(put.Profiles[i])
+	}
+
+	for k, v := range put.Config {
+		put.Config[k] = replace(v)
+	}
+
+	for _, dev := range put.Devices {
+		for k, v := range dev {
+			dev[k] = replace(v)
+		}
+	}
+
+	return err
+}
+
+// instanceTemplateValidate validates the definition of an instance template.
+func instanceTemplateValidate(put api.InstanceTemplatePut) error {
+	if !shared.StringInSlice(put.Source.Type, []string{"image", "none"}) {
+		return fmt.Errorf("Invalid source type %q, instance templates only support image and none sources", put.Source.Type)
+	}
+
+	if !shared.StringInSlice(string(put.Type), []string{"", string(api.InstanceTypeContainer), string(api.InstanceTypeVM)}) {
+		return fmt.Errorf("Invalid instance type %q", put.Type)
+	}
+
+	for name := range put.Variables {
+		if !instanceTemplateNameRegexp.MatchString(name) {
+			return fmt.Errorf("Invalid variable name %q", name)
+		}
+	}
+
+	// Check that only declared variables are referenced, on a copy as expanding modifies the values.
+	buf, err := json.Marshal(put)
+	if err != nil {
+		return err
+	}
+
+	check := api.InstanceTemplatePut{}
+	err = json.Unmarshal(buf, &check)
+	if err != nil {
+		return err
+	}
+
+	return instanceTemplateExpand(&check, func(name string) (string, error) {
+		_, found := put.Variables[name]
+		if !found {
+			return "", fmt.Errorf("Undeclared variable %q referenced", name)
+		}
+
+		return "", nil
+	})
+}
+
+// instanceTemplateRender returns the request creating an instance from the template with the given variables.
+func instanceTemplateRender(template *api.InstanceTemplate, req api.InstanceTemplateInstancesPost) (*api.InstancesPost, error) {
+	values := map[string]string{}
+	for name, variable := range template.Variables {
+		value, found := req.Variables[name]
+		if !found {
+			if variable.Required {
+				return nil, fmt.Errorf("Missing value for required variable %q", name)
+			}
+
+			value = variable.Default
+		}
+
+		values[name] = value
+	}
+
+	unknown := []string{}
+	for name := range req.Variables {
+		_, found := template.Variables[name]
+		if !found {
+			unknown = append(unknown, name)
+		}
+	}
+
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("Unknown variables: %s", strings.Join(unknown, ", "))
+	}
+
+	// The template was loaded from the database for this request, so can be modified.
+	put := template.InstanceTemplatePut
+	err := instanceTemplateExpand(&put, func(name string) (string, error) {
+		value, found := values[name]
+		if !found {
+			return "", fmt.Errorf("Undeclared variable %q referenced", name)
+		}
+
+		return value, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	post := &api.InstancesPost{
+		Name:   req.Name,
+		Source: put.Source,
+		Type:   put.Type,
+		InstancePut: api.InstancePut{
+			Config:    put.Config,
+			Devices:   put.Devices,
+			Ephemeral: req.Ephemeral,
+			Profiles:  put.Profiles,
+		},
+	}
+
+	return post, nil
+}
+
+// swagger:operation GET /1.0/instance-templates instance-templates instance_templates_get
+//
+// Get the instance templates
+//
+// Returns a list of instance templates (URLs).
+//
+// ---
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+// responses:
+//   "200":
+//     description: API endpoints
+//     schema:
+//       type: object
+//       description: Sync response
+//       properties:
+//         type:
+//           type: string
+//           description: Response type
+//           example: sync
+//         status:
+//           type: string
+//           description: Status description
+//           example: Success
+//         status_code:
+//           type: integer
+//           description: Status code
+//           example: 200
+//         metadata:
+//           type: array
+//           description: List of endpoints
+//           items:
+//             type: string
+//           example: |-
+//             [
+//               "/1.0/instance-templates/webserver",
+//               "/1.0/instance-templates/database"
+//             ]
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+
+// swagger:operation GET /1.0/instance-templates?recursion=1 instance-templates instance_templates_get_recursion1
+//
+// Get the instance templates
+//
+// Returns a list of instance templates (structs).
+//
+// ---
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+// responses:
+//   "200":
+//     description: API endpoints
+//     schema:
+//       type: object
+//       description: Sync response
+//       properties:
+//         type:
+//           type: string
+//           description: Response type
+//           example: sync
+//         status:
+//           type: string
+//           description: Status description
+//           example: Success
+//         status_code:
+//           type: integer
+//           description: Status code
+//           example: 200
+//         metadata:
+//           type: array
+//           description: List of instance templates
+//           items:
+//             $ref: "#/definitions/InstanceTemplate"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func instanceTemplatesGet(d *Daemon, r *http.Request) response.Response {
+	projectName := projectParam(r)
+	recursion := util.IsRecursionRequest(r)
+
+	var dbTemplates []db.InstanceTemplate
+	err := d.cluster.Transaction(func(tx *db.ClusterTx) error {
+		var err error
+		dbTemplates, err = tx.GetInstanceTemplates(projectName)
+		return err
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if recursion {
+		result := make([]*api.InstanceTemplate, 0, len(dbTemplates))
+		for _, template := range dbTemplates {
+			apiTemplate, err := template.ToAPI()
+			if err != nil {
+				return response.InternalError(err)
+			}
+
+			result = append(result, apiTemplate)
+		}
+
+		return response.SyncResponse(true, result)
+	}
+
+	urls := make([]string, 0, len(dbTemplates))
+	for _, template := range dbTemplates {
+		urls = append(urls, instanceTemplateURL(projectName, template.Name))
+	}
+
+	return response.SyncResponse(true, urls)
+}
+
+// swagger:operation POST /1.0/instance-templates instance-templates instance_templates_post
+//
+// Add an instance template
+//
+// Creates a new instance template, capturing the image, configuration and
+// devices of instances to create from it. Values may reference the variables
+// declared by the template as `${var:NAME}`.
+//
+// ---
+// consumes:
+//   - application/json
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+//   - in: body
+//     name: template
+//     description: Instance template
+//     required: true
+//     schema:
+//       $ref: "#/definitions/InstanceTemplatesPost"
+// responses:
+//   "200":
+//     $ref: "#/responses/EmptySyncResponse"
+//   "400":
+//     $ref: "#/responses/BadRequest"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func instanceTemplatesPost(d *Daemon, r *http.Request) response.Response {
+	projectName := projectParam(r)
+
+	req := api.InstanceTemplatesPost{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	if !instanceTemplateNameRegexp.MatchString(req.Name) {
+		return response.BadRequest(fmt.Errorf("Invalid instance template name %q", req.Name))
+	}
+
+	err = instanceTemplateValidate(req.InstanceTemplatePut)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	definition, err := db.InstanceTemplateDefinition(req.InstanceTemplatePut)
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
+		_, err := tx.GetInstanceTemplate(projectName, req.Name)
+		if err == nil {
+			return api.StatusErrorf(http.StatusConflict, "Instance template %q already exists", req.Name)
+		} else if err != db.ErrNoSuchObject {
+			return err
+		}
+
+		_, err = tx.CreateInstanceTemplate(projectName, db.InstanceTemplate{Name: req.Name, Description: req.Description, Definition: definition})
+		return err
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	d.State().Events.SendLifecycle(projectName, lifecycle.InstanceTemplateCreated.Event(req.Name, projectName, request.CreateRequestor(r), nil))
+
+	return response.SyncResponseLocation(true, nil, instanceTemplateURL(projectName, req.Name))
+}
+
+// swagger:operation GET /1.0/instance-templates/{name} instance-templates instance_template_get
+//
+// Get the instance template
+//
+// Gets a specific instance template.
+//
+// ---
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+// responses:
+//   "200":
+//     description: Instance template
+//     schema:
+//       type: object
+//       description: Sync response
+//       properties:
+//         type:
+//           type: string
+//           description: Response type
+//           example: sync
+//         status:
+//           type: string
+//           description: Status description
+//           example: Success
+//         status_code:
+//           type: integer
+//           description: Status code
+//           example: 200
+//         metadata:
+//           $ref: "#/definitions/InstanceTemplate"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "404":
+//     $ref: "#/responses/NotFound"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func instanceTemplateGet(d *Daemon, r *http.Request) response.Response {
+	projectName := projectParam(r)
+
+	name, err := url.PathUnescape(mux.Vars(r)["name"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	var template *db.InstanceTemplate
+	err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
+		template, err = tx.GetInstanceTemplate(projectName, name)
+		return err
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	resp, err := template.ToAPI()
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	return response.SyncResponseETag(true, resp, resp.Writable())
+}
+
+// swagger:operation PATCH /1.0/instance-templates/{name} instance-templates instance_template_patch
+//
+// Partially update the instance template
+//
+// Updates a subset of the instance template's fields.
+//
+// ---
+// consumes:
+//   - application/json
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+//   - in: body
+//     name: template
+//     description: Instance template
+//     required: true
+//     schema:
+//       $ref: "#/definitions/InstanceTemplatePut"
+// responses:
+//   "200":
+//     $ref: "#/responses/EmptySyncResponse"
+//   "400":
+//     $ref: "#/responses/BadRequest"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "412":
+//     $ref: "#/responses/PreconditionFailed"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func instanceTemplatePatch(d *Daemon, r *http.Request) response.Response {
+	return instanceTemplateUpdate(d, r, true)
+}
+
+// swagger:operation PUT /1.0/instance-templates/{name} instance-templates instance_template_put
+//
+// Update the instance template
+//
+// Updates the entire instance template.
+//
+// ---
+// consumes:
+//   - application/json
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+//   - in: body
+//     name: template
+//     description: Instance template
+//     required: true
+//     schema:
+//       $ref: "#/definitions/InstanceTemplatePut"
+// responses:
+//   "200":
+//     $ref: "#/responses/EmptySyncResponse"
+//   "400":
+//     $ref: "#/responses/BadRequest"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "412":
+//     $ref: "#/responses/PreconditionFailed"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func instanceTemplatePut(d *Daemon, r *http.Request) response.Response {
+	return instanceTemplateUpdate(d, r, false)
+}
+
+func instanceTemplateUpdate(d *Daemon, r *http.Request, patch bool) response.Response {
+	projectName := projectParam(r)
+
+	name, err := url.PathUnescape(mux.Vars(r)["name"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	var template *db.InstanceTemplate
+	err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
+		template, err = tx.GetInstanceTemplate(projectName, name)
+		return err
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	current, err := template.ToAPI()
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	err = util.EtagCheck(r, current.Writable())
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	// For PATCH, the fields missing from the request are kept as they are.
+	req := api.InstanceTemplatePut{}
+	if patch {
+		req = current.Writable()
+	}
+
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	err = instanceTemplateValidate(req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	definition, err := db.InstanceTemplateDefinition(req)
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
+		return tx.UpdateInstanceTemplate(projectName, name, req.Description, definition)
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	d.State().Events.SendLifecycle(projectName, lifecycle.InstanceTemplateUpdated.Event(name, projectName, request.CreateRequestor(r), nil))
+
+	return response.EmptySyncResponse
+}
+
+// swagger:operation DELETE /1.0/instance-templates/{name} instance-templates instance_template_delete
+//
+// Delete the instance template
+//
+// Removes the instance template. Instances created from it are left as is.
+//
+// ---
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+// responses:
+//   "200":
+//     $ref: "#/responses/EmptySyncResponse"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "404":
+//     $ref: "#/responses/NotFound"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func instanceTemplateDelete(d *Daemon, r *http.Request) response.Response {
+	projectName := projectParam(r)
+
+	name, err := url.PathUnescape(mux.Vars(r)["name"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
+		return tx.DeleteInstanceTemplate(projectName, name)
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	d.State().Events.SendLifecycle(projectName, lifecycle.InstanceTemplateDeleted.Event(name, projectName, request.CreateRequestor(r), nil))
+
+	return response.EmptySyncResponse
+}
+
+// swagger:operation POST /1.0/instance-templates/{name}/instances instance-templates instance_template_instances_post
+//
+// Create an instance from the template
+//
+// Creates a new instance from the image, configuration and devices of the
+// template, with its variables replaced by the provided values or their
+// defaults.
+//
+// ---
+// consumes:
+//   - application/json
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+//   - in: query
+//     name: target
+//     description: Cluster member
+//     type: string
+//     example: default
+//   - in: body
+//     name: instance
+//     description: Instance
+//     required: true
+//     schema:
+//       $ref: "#/definitions/InstanceTemplateInstancesPost"
+// responses:
+//   "202":
+//     $ref: "#/responses/Operation"
+//   "400":
+//     $ref: "#/responses/BadRequest"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "404":
+//     $ref: "#/responses/NotFound"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func instanceTemplateInstancesPost(d *Daemon, r *http.Request) response.Response {
+	projectName := projectParam(r)
+
+	name, err := url.PathUnescape(mux.Vars(r)["name"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	req := api.InstanceTemplateInstancesPost{}
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	var template *db.InstanceTemplate
+	err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
+		template, err = tx.GetInstanceTemplate(projectName, name)
+		return err
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	apiTemplate, err := template.ToAPI()
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	post, err := instanceTemplateRender(apiTemplate, req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	return instancesCreate(d, r, projectName, *post)
+}
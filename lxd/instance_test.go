@@ -298,6 +298,42 @@ func (suite *containerTestSuite) TestContainer_Rename() {
 	suite.Req.Equal(shared.VarPath("containers", "testFoo2"), c.Path())
 }
 
+func (suite *containerTestSuite) TestContainer_Rebuild() {
+	args := db.InstanceArgs{
+		Type:      instancetype.Container,
+		Ephemeral: false,
+		Config: map[string]string{
+			"image.os":            "Ubuntu",
+			"volatile.base_image": "abc",
+			"limits.cpu":          "2",
+		},
+		Name: "testFoo",
+	}
+
+	c, op, err := instance.CreateInternal(suite.d.State(), args, true, nil, revert.New())
+	suite.Req.Nil(err)
+	op.Done(nil)
+	defer c.Delete(true)
+
+	suite.Req.Nil(instanceRebuild(suite.d, c, nil, nil), "Failed to rebuild the container.")
+
+	c, err = instance.LoadByProjectAndName(suite.d.State(), project.Default, "testFoo")
+	suite.Req.Nil(err)
+
+	config := c.LocalConfig()
+	suite.Req.NotContains(config, "image.os")
+	suite.Req.NotContains(config, "volatile.base_image")
+	suite.Req.Equal("2", config["limits.cpu"])
+	suite.Req.Equal("[]", config["volatile.last_state.idmap"])
+
+	// The root volume record is kept.
+	poolID, err := suite.d.cluster.GetStoragePoolID(lxdTestSuiteDefaultStoragePool)
+	suite.Req.Nil(err)
+
+	_, _, err = suite.d.cluster.GetLocalStoragePoolVolume(project.Default, "testFoo", db.StoragePoolVolumeTypeContainer, poolID)
+	suite.Req.Nil(err)
+}
+
 func (suite *containerTestSuite) TestContainer_findIdmap_isolated() {
 	c1, op, err := instance.CreateInternal(suite.d.State(), db.InstanceArgs{
 		Type: instancetype.Container,
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/lxc/lxd/lxd/cluster"
+	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/instance"
+	"github.com/lxc/lxd/lxd/instance/instancetype"
+	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/lxd/state"
+	"github.com/lxc/lxd/lxd/task"
+	"github.com/lxc/lxd/shared/api"
+	log "github.com/lxc/lxd/shared/log15"
+	"github.com/lxc/lxd/shared/logger"
+)
+
+// instanceUsageSamples is the number of usage samples kept for each instance (a day worth with the default
+// interval).
+const instanceUsageSamples = 1440
+
+// instanceUsageHistory is the ring buffer of usage samples of an instance.
+type instanceUsageHistory struct {
+	samples []api.InstanceUsageSample
+	next    int
+}
+
+var instanceUsageLock sync.Mutex
+var instanceUsageHistories = map[int]*instanceUsageHistory{}
+var instanceUsageInterval time.Duration
+
+// add records a sample, replacing the oldest one once the buffer is full.
+func (h *instanceUsageHistory) add(sample api.InstanceUsageSample) {
+	if len(h.samples) < instanceUsageSamples {
+		h.samples = append(h.samples, sample)
+		return
+	}
+
+	h.samples[h.next] = sample
+	h.next = (h.next + 1) % instanceUsageSamples
+}
+
+// since returns the samples taken after the given time, oldest first.
+func (h *instanceUsageHistory) since(start time.Time) []api.InstanceUsageSample {
+	samples := []api.InstanceUsageSample{}
+	for i := range h.samples {
+		sample := h.samples[(h.next+i)%len(h.samples)]
+		if sample.Timestamp.After(start) {
+			samples = append(samples, sample)
+		}
+	}
+
+	return samples
+}
+
+func instanceUsageTask(d *Daemon) (task.Func, task.Schedule) {
+	f := func(ctx context.Context) {
+		instanceUsageSample(d.State())
+	}
+
+	schedule := func() (time.Duration, error) {
+		var interval time.Duration
+		err := d.cluster.Transaction(func(tx *db.ClusterTx) error {
+			config, err := cluster.ConfigLoad(tx)
+			if err != nil {
+				return err
+			}
+
+			interval = config.InstancesUsageInterval()
+			return nil
+		})
+		if err != nil {
+			return time.Minute, err
+		}
+
+		instanceUsageLock.Lock()
+		instanceUsageInterval = interval
+		instanceUsageLock.Unlock()
+
+		// Check again later whether sampling got enabled.
+		if interval == 0 {
+			return time.Minute, task.ErrSkip
+		}
+
+		return interval, nil
+	}
+
+	return f, schedule
+}
+
+// instanceUsageSampleFromState returns a usage sample of the provided instance state.
+func instanceUsageSampleFromState(instState *api.InstanceState) api.InstanceUsageSample {
+	sample := api.InstanceUsageSample{
+		Timestamp:   time.Now(),
+		CPUUsage:    instState.CPU.Usage,
+		MemoryUsage: instState.Memory.Usage,
+	}
+
+	for _, disk := range instState.Disk {
+		sample.DiskUsage += disk.Usage
+	}
+
+	for _, network := range instState.Network {
+		sample.NetworkBytesReceived += network.Counters.BytesReceived
+		sample.NetworkBytesSent += network.Counters.BytesSent
+	}
+
+	return sample
+}
+
+// instanceUsageSample records the current resource usage of the local running instances.
+func instanceUsageSample(s *state.State) {
+	instances, err := instance.LoadNodeAll(s, instancetype.Any)
+	if err != nil {
+		logger.Error("Failed loading instances for usage sampling", log.Ctx{"err": err})
+		return
+	}
+
+	samples := map[int]*api.InstanceUsageSample{}
+	for _, inst := range instances {
+		if !inst.IsRunning() {
+			continue
+		}
+
+		instState, err := inst.RenderState()
+		if err != nil {
+			logger.Debug("Failed getting instance state for usage sampling", log.Ctx{"project": inst.Project(), "instance": inst.Name(), "err": err})
+			continue
+		}
+
+		sample := instanceUsageSampleFromState(instState)
+		samples[inst.ID()] = &sample
+	}
+
+	instanceUsageLock.Lock()
+	defer instanceUsageLock.Unlock()
+
+	// Keep the history of stopped instances, but forget about the ones which aren't on this member anymore.
+	ids := map[int]bool{}
+	for _, inst := range instances {
+		ids[inst.ID()] = true
+	}
+
+	for id := range instanceUsageHistories {
+		if !ids[id] {
+			delete(instanceUsageHistories, id)
+		}
+	}
+
+	for id, sample := range samples {
+		history, found := instanceUsageHistories[id]
+		if !found {
+			history = &instanceUsageHistory{}
+			instanceUsageHistories[id] = history
+		}
+
+		history.add(*sample)
+	}
+}
+
+// swagger:operation GET /1.0/instances/{name}/metrics instances instance_metrics_get
+//
+// Get the resource usage history
+//
+// Gets the resource usage samples recorded for the instance by the server
+// it's running on over the requested period.
+//
+// ---
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+//   - in: query
+//     name: period
+//     description: Period to return the samples of (defaults to 1h)
+//     type: string
+//     example: 6h
+// responses:
+//   "200":
+//     description: Resource usage history
+//     schema:
+//       type: object
+//       description: Sync response
+//       properties:
+//         type:
+//           type: string
+//           description: Response type
+//           example: sync
+//         status:
+//           type: string
+//           description: Status description
+//           example: Success
+//         status_code:
+//           type: integer
+//           description: Status code
+//           example: 200
+//         metadata:
+//           $ref: "#/definitions/InstanceUsage"
+//   "400":
+//     $ref: "#/responses/BadRequest"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "404":
+//     $ref: "#/responses/NotFound"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func instanceMetricsGet(d *Daemon, r *http.Request) response.Response {
+	instanceType, err := urlInstanceTypeDetect(r)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	projectName := projectParam(r)
+	name := mux.Vars(r)["name"]
+
+	period := time.Hour
+	if r.FormValue("period") != "" {
+		period, err = time.ParseDuration(r.FormValue("period"))
+		if err != nil || period <= 0 {
+			return response.BadRequest(fmt.Errorf("Invalid period %q", r.FormValue("period")))
+		}
+	}
+
+	// Handle requests targeted to an instance on a different node.
+	resp, err := forwardedResponseIfInstanceIsRemote(d, r, projectName, name, instanceType)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if resp != nil {
+		return resp
+	}
+
+	inst, err := instance.LoadByProjectAndName(d.State(), projectName, name)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	instanceUsageLock.Lock()
+	defer instanceUsageLock.Unlock()
+
+	usage := api.InstanceUsage{
+		Interval: int64(instanceUsageInterval / time.Second),
+		Samples:  []api.InstanceUsageSample{},
+	}
+
+	history, found := instanceUsageHistories[inst.ID()]
+	if found {
+		usage.Samples = history.since(time.Now().Add(-period))
+	}
+
+	return response.SyncResponse(true, usage)
+}
@@ -9,8 +9,9 @@ import (
 	"sync"
 	"time"
 
+	"github.com/lxc/lxd/lxd/cluster"
 	"github.com/lxc/lxd/lxd/db"
-	"github.com/lxc/lxd/lxd/db/cluster"
+	dbCluster "github.com/lxc/lxd/lxd/db/cluster"
 	"github.com/lxc/lxd/lxd/instance"
 	"github.com/lxc/lxd/lxd/instance/instancetype"
 	"github.com/lxc/lxd/lxd/project"
@@ -50,6 +51,39 @@ var instanceCmd = APIEndpoint{
 	Patch:  APIEndpointAction{Handler: instancePatch, AccessHandler: allowProjectPermission("containers", "manage-containers")},
 }
 
+var instanceConvertCmd = APIEndpoint{
+	Name: "instanceConvert",
+	Path: "instances/{name}/convert",
+	Aliases: []APIEndpointAlias{
+		{Name: "containerConvert", Path: "containers/{name}/convert"},
+		{Name: "vmConvert", Path: "virtual-machines/{name}/convert"},
+	},
+
+	Post: APIEndpointAction{Handler: instanceConvertPost, AccessHandler: allowProjectPermission("containers", "manage-containers")},
+}
+
+var instanceFlattenCmd = APIEndpoint{
+	Name: "instanceFlatten",
+	Path: "instances/{name}/flatten",
+	Aliases: []APIEndpointAlias{
+		{Name: "containerFlatten", Path: "containers/{name}/flatten"},
+		{Name: "vmFlatten", Path: "virtual-machines/{name}/flatten"},
+	},
+
+	Post: APIEndpointAction{Handler: instanceFlattenPost, AccessHandler: allowProjectPermission("containers", "manage-containers")},
+}
+
+var instanceRebuildCmd = APIEndpoint{
+	Name: "instanceRebuild",
+	Path: "instances/{name}/rebuild",
+	Aliases: []APIEndpointAlias{
+		{Name: "containerRebuild", Path: "containers/{name}/rebuild"},
+		{Name: "vmRebuild", Path: "virtual-machines/{name}/rebuild"},
+	},
+
+	Post: APIEndpointAction{Handler: instanceRebuildPost, AccessHandler: allowProjectPermission("containers", "manage-containers")},
+}
+
 var instanceStateCmd = APIEndpoint{
 	Name: "instanceState",
 	Path: "instances/{name}/state",
@@ -115,6 +149,19 @@ var instanceConsoleCmd = APIEndpoint{
 	Delete: APIEndpointAction{Handler: instanceConsoleLogDelete, AccessHandler: allowProjectPermission("containers", "operate-containers")},
 }
 
+var instanceNVRAMCmd = APIEndpoint{
+	Name: "instanceNVRAM",
+	Path: "instances/{name}/nvram",
+	Aliases: []APIEndpointAlias{
+		{Name: "containerNVRAM", Path: "containers/{name}/nvram"},
+		{Name: "vmNVRAM", Path: "virtual-machines/{name}/nvram"},
+	},
+
+	Get:    APIEndpointAction{Handler: instanceNVRAMGet, AccessHandler: allowProjectPermission("containers", "view")},
+	Put:    APIEndpointAction{Handler: instanceNVRAMPut, AccessHandler: allowProjectPermission("containers", "manage-containers")},
+	Delete: APIEndpointAction{Handler: instanceNVRAMDelete, AccessHandler: allowProjectPermission("containers", "manage-containers")},
+}
+
 var instanceExecCmd = APIEndpoint{
 	Name: "instanceExec",
 	Path: "instances/{name}/exec",
@@ -126,6 +173,17 @@ var instanceExecCmd = APIEndpoint{
 	Post: APIEndpointAction{Handler: instanceExecPost, AccessHandler: allowProjectPermission("containers", "operate-containers")},
 }
 
+var instanceMetricsCmd = APIEndpoint{
+	Name: "instanceMetrics",
+	Path: "instances/{name}/metrics",
+	Aliases: []APIEndpointAlias{
+		{Name: "containerMetrics", Path: "containers/{name}/metrics"},
+		{Name: "vmMetrics", Path: "virtual-machines/{name}/metrics"},
+	},
+
+	Get: APIEndpointAction{Handler: instanceMetricsGet, AccessHandler: allowProjectPermission("containers", "view")},
+}
+
 var instanceMetadataCmd = APIEndpoint{
 	Name: "instanceMetadata",
 	Path: "instances/{name}/metadata",
@@ -188,6 +246,9 @@ var instanceBackupExportCmd = APIEndpoint{
 	Get: APIEndpointAction{Handler: instanceBackupExportGet, AccessHandler: allowProjectPermission("containers", "view")},
 }
 
+// instanceDependencyTimeout is how long to wait for a dependency to be ready before starting an instance anyway.
+const instanceDependencyTimeout = 5 * time.Minute
+
 type containerAutostartList []instance.Instance
 
 func (slice containerAutostartList) Len() int {
@@ -211,6 +272,96 @@ func (slice containerAutostartList) Swap(i, j int) {
 	slice[i], slice[j] = slice[j], slice[i]
 }
 
+// instanceDependencies returns the names of the instances listed in boot.depends_on.
+func instanceDependencies(inst instance.Instance) []string {
+	dependencies := []string{}
+	for _, name := range strings.Split(inst.ExpandedConfig()["boot.depends_on"], ",") {
+		name = strings.TrimSpace(name)
+		if name != "" {
+			dependencies = append(dependencies, name)
+		}
+	}
+
+	return dependencies
+}
+
+// instancesAutostartOrder returns the instances to auto-start in the order they should be started in.
+// Each instance comes after the instances it depends on, which are included even when not configured
+// to auto-start. Otherwise the order of the supplied list is kept.
+func instancesAutostartOrder(instances []instance.Instance) []instance.Instance {
+	byName := make(map[string]instance.Instance, len(instances))
+	for _, inst := range instances {
+		byName[project.Instance(inst.Project(), inst.Name())] = inst
+	}
+
+	ordered := []instance.Instance{}
+	visiting := map[string]bool{}
+	visited := map[string]bool{}
+
+	var visit func(inst instance.Instance)
+	visit = func(inst instance.Instance) {
+		key := project.Instance(inst.Project(), inst.Name())
+		if visited[key] {
+			return
+		}
+
+		if visiting[key] {
+			logger.Warn("Ignoring circular instance dependency", log.Ctx{"project": inst.Project(), "instance": inst.Name()})
+			return
+		}
+
+		visiting[key] = true
+		for _, name := range instanceDependencies(inst) {
+			dependency, found := byName[project.Instance(inst.Project(), name)]
+			if !found {
+				logger.Warn("Ignoring dependency on instance not found on this server", log.Ctx{"project": inst.Project(), "instance": inst.Name(), "dependency": name})
+				continue
+			}
+
+			visit(dependency)
+		}
+
+		visiting[key] = false
+		visited[key] = true
+		ordered = append(ordered, inst)
+	}
+
+	for _, inst := range instances {
+		// Only restart instances configured to auto-start or that were previously running.
+		config := inst.ExpandedConfig()
+		autoStart := config["boot.autostart"]
+		if shared.IsTrue(autoStart) || (autoStart == "" && config["volatile.last_state.power"] == "RUNNING") {
+			visit(inst)
+		}
+	}
+
+	return ordered
+}
+
+// instanceWaitReady waits until the instance is running and, for virtual machines, until its agent is
+// reachable. Returns false if the instance isn't ready after the timeout.
+func instanceWaitReady(inst instance.Instance, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for {
+		if inst.IsRunning() {
+			if inst.Type() != instancetype.VM {
+				return true
+			}
+
+			state, err := inst.RenderState()
+			if err == nil && state.Processes != -1 {
+				return true
+			}
+		}
+
+		if time.Now().After(deadline) {
+			return false
+		}
+
+		time.Sleep(time.Second)
+	}
+}
+
 func instancesRestart(s *state.State) error {
 	// Get all the instances
 	result, err := instance.LoadNodeAll(s, instancetype.Any)
@@ -226,22 +377,20 @@ func instancesRestart(s *state.State) error {
 
 	sort.Sort(containerAutostartList(instances))
 
+	byName := make(map[string]instance.Instance, len(instances))
+	for _, inst := range instances {
+		byName[project.Instance(inst.Project(), inst.Name())] = inst
+	}
+
 	maxAttempts := 3
+	failed := map[string]bool{}
 
 	// Restart the instances
-	for _, inst := range instances {
+	for _, inst := range instancesAutostartOrder(instances) {
 		// Get the instance config.
 		config := inst.ExpandedConfig()
-		lastState := config["volatile.last_state.power"]
-		autoStart := config["boot.autostart"]
 		autoStartDelay := config["boot.autostart.delay"]
 
-		// Only restart instances configured to auto-start or that were previously running.
-		start := shared.IsTrue(autoStart) || (autoStart == "" && lastState == "RUNNING")
-		if !start {
-			continue
-		}
-
 		// If already running, we're done.
 		if inst.IsRunning() {
 			continue
@@ -249,34 +398,55 @@ func instancesRestart(s *state.State) error {
 
 		instLogger := logging.AddContext(logger.Log, log.Ctx{"project": inst.Project(), "instance": inst.Name()})
 
-		// Try to start the instance.
+		// Wait for the instances it depends on to be ready.
 		var err error
-		var attempt = 0
-		for {
-			attempt++
-			err = inst.Start(false)
-			if err != nil {
-				instLogger.Warn("Failed auto start instance attempt", log.Ctx{"attempt": attempt, "maxAttempts": maxAttempts, "err": err})
-
-				if attempt >= maxAttempts {
-					break
-				}
-
-				time.Sleep(5 * time.Second)
-			} else {
-				// Resolve any previous warning.
-				warnErr := warnings.ResolveWarningsByLocalNodeAndProjectAndTypeAndEntity(s.Cluster, inst.Project(), db.WarningInstanceAutostartFailure, cluster.TypeInstance, inst.ID())
-				if warnErr != nil {
-					instLogger.Warn("Failed to resolve instance autostart failure warning", log.Ctx{"err": warnErr})
-				}
+		for _, name := range instanceDependencies(inst) {
+			dependency, found := byName[project.Instance(inst.Project(), name)]
+			if !found {
+				continue
+			}
 
+			if failed[project.Instance(inst.Project(), name)] {
+				err = fmt.Errorf("Dependency %q failed to start", name)
 				break
 			}
+
+			if !instanceWaitReady(dependency, instanceDependencyTimeout) {
+				instLogger.Warn("Dependency isn't ready, starting instance anyway", log.Ctx{"dependency": name})
+			}
+		}
+
+		// Try to start the instance.
+		if err == nil {
+			var attempt = 0
+			for {
+				attempt++
+				err = inst.Start(inst.IsStateful())
+				if err != nil {
+					instLogger.Warn("Failed auto start instance attempt", log.Ctx{"attempt": attempt, "maxAttempts": maxAttempts, "err": err})
+
+					if attempt >= maxAttempts {
+						break
+					}
+
+					time.Sleep(5 * time.Second)
+				} else {
+					// Resolve any previous warning.
+					warnErr := warnings.ResolveWarningsByLocalNodeAndProjectAndTypeAndEntity(s.Cluster, inst.Project(), db.WarningInstanceAutostartFailure, dbCluster.TypeInstance, inst.ID())
+					if warnErr != nil {
+						instLogger.Warn("Failed to resolve instance autostart failure warning", log.Ctx{"err": warnErr})
+					}
+
+					break
+				}
+			}
 		}
 
 		if err != nil {
+			failed[project.Instance(inst.Project(), inst.Name())] = true
+
 			// If unable to start after 3 tries, record a warning.
-			warnErr := s.Cluster.UpsertWarningLocalNode(inst.Project(), cluster.TypeInstance, inst.ID(), db.WarningInstanceAutostartFailure, fmt.Sprintf("%v", err))
+			warnErr := s.Cluster.UpsertWarningLocalNode(inst.Project(), dbCluster.TypeInstance, inst.ID(), db.WarningInstanceAutostartFailure, fmt.Sprintf("%v", err))
 			if warnErr != nil {
 				instLogger.Warn("Failed to create instance autostart failure warning", log.Ctx{"err": warnErr})
 			}
@@ -346,9 +516,38 @@ func instancesOnDisk() (map[string][]string, error) {
 	return containers, nil
 }
 
-func instancesShutdown(s *state.State) error {
+// instancesShutdownAction returns the configured action to take on running instances when LXD shuts down,
+// falling back to stopping them if the database can't be reached.
+func instancesShutdownAction(s *state.State) string {
+	action := "stop"
+
+	err := s.Cluster.Transaction(func(tx *db.ClusterTx) error {
+		config, err := cluster.ConfigLoad(tx)
+		if err != nil {
+			return err
+		}
+
+		action = config.InstancesShutdownAction()
+		return nil
+	})
+	if err != nil {
+		logger.Warn("Failed getting instances shutdown action, stopping instances", log.Ctx{"err": err})
+		return "stop"
+	}
+
+	return action
+}
+
+// instancesShutdown stops the local instances according to action (stop, stateful-stop or leave-running).
+// If timeout is set, the clean shutdown of the instances is cut short so that they're all stopped by then.
+func instancesShutdown(s *state.State, action string, timeout time.Duration) error {
 	var wg sync.WaitGroup
 
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+
 	dbAvailable := true
 
 	// Get all the instances
@@ -410,8 +609,11 @@ func instancesShutdown(s *state.State) error {
 		// Record the current state
 		lastState := c.State()
 
+		// Containers keep running on their own, so can be left alone if requested.
+		leaveRunning := action == "leave-running" && c.Type() == instancetype.Container
+
 		// Stop the container
-		if lastState != "ERROR" && lastState != "STOPPED" {
+		if lastState != "ERROR" && lastState != "STOPPED" && !leaveRunning {
 			// Determinate how long to wait for the instance to shutdown cleanly
 			var timeoutSeconds int
 			value, ok := c.ExpandedConfig()["boot.host_shutdown_timeout"]
@@ -421,11 +623,36 @@ func instancesShutdown(s *state.State) error {
 				timeoutSeconds = 30
 			}
 
+			shutdownTimeout := time.Second * time.Duration(timeoutSeconds)
+			if !deadline.IsZero() {
+				remaining := time.Until(deadline)
+				if remaining < shutdownTimeout {
+					shutdownTimeout = remaining
+				}
+			}
+
+			// Save the state of virtual machines supporting it rather than shutting them down.
+			stateful := action == "stateful-stop" && c.Type() == instancetype.VM && shared.IsTrue(c.ExpandedConfig()["migration.stateful"])
+
 			// Stop the instance
 			wg.Add(1)
 			go func(c instance.Instance, lastState string) {
-				c.Shutdown(time.Second * time.Duration(timeoutSeconds))
-				c.Stop(false)
+				if stateful {
+					err := c.Stop(true)
+					if err != nil {
+						logger.Warn("Failed stateful stop of instance, shutting it down", log.Ctx{"project": c.Project(), "instance": c.Name(), "err": err})
+						stateful = false
+					}
+				}
+
+				if !stateful {
+					if shutdownTimeout > 0 {
+						c.Shutdown(shutdownTimeout)
+					}
+
+					c.Stop(false)
+				}
+
 				c.VolatileSet(map[string]string{"volatile.last_state.power": lastState})
 
 				wg.Done()
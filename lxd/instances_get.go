@@ -19,6 +19,7 @@ import (
 	"github.com/lxc/lxd/lxd/instance"
 	"github.com/lxc/lxd/lxd/instance/instancetype"
 	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/lxd/util"
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/api"
 	"github.com/lxc/lxd/shared/logger"
@@ -64,6 +65,16 @@ func urlInstanceTypeDetect(r *http.Request) (instancetype.Type, error) {
 //     description: Collection filter
 //     type: string
 //     example: default
+//   - in: query
+//     name: limit
+//     description: Maximum number of entries to return
+//     type: integer
+//     example: 100
+//   - in: query
+//     name: offset
+//     description: Number of entries to skip
+//     type: integer
+//     example: 200
 // responses:
 //   "200":
 //     description: API endpoints
@@ -118,6 +129,21 @@ func urlInstanceTypeDetect(r *http.Request) (instancetype.Type, error) {
 //     description: Collection filter
 //     type: string
 //     example: default
+//   - in: query
+//     name: limit
+//     description: Maximum number of entries to return
+//     type: integer
+//     example: 100
+//   - in: query
+//     name: offset
+//     description: Number of entries to skip
+//     type: integer
+//     example: 200
+//   - in: query
+//     name: fields
+//     description: Comma separated list of fields to return
+//     type: string
+//     example: name,status
 // responses:
 //   "200":
 //     description: API endpoints
@@ -171,6 +197,21 @@ func urlInstanceTypeDetect(r *http.Request) (instancetype.Type, error) {
 //     description: Collection filter
 //     type: string
 //     example: default
+//   - in: query
+//     name: limit
+//     description: Maximum number of entries to return
+//     type: integer
+//     example: 100
+//   - in: query
+//     name: offset
+//     description: Number of entries to skip
+//     type: integer
+//     example: 200
+//   - in: query
+//     name: fields
+//     description: Comma separated list of fields to return
+//     type: string
+//     example: name,status
 // responses:
 //   "200":
 //     description: API endpoints
@@ -200,10 +241,22 @@ func urlInstanceTypeDetect(r *http.Request) (instancetype.Type, error) {
 //   "500":
 //     $ref: "#/responses/InternalServerError"
 func instancesGet(d *Daemon, r *http.Request) response.Response {
+	pagination, err := util.PaginationFromRequest(r)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
 	for i := 0; i < 100; i++ {
-		result, err := doInstancesGet(d, r)
+		result, total, err := doInstancesGet(d, r, pagination)
 		if err == nil {
-			return response.SyncResponse(true, result)
+			if util.IsRecursionRequest(r) {
+				result, err = util.SelectFields(result, util.FieldsFromRequest(r))
+				if err != nil {
+					return response.SmartError(err)
+				}
+			}
+
+			return response.SyncResponseHeaders(true, result, pagination.Headers(total))
 		}
 		if !query.IsRetriableError(err) {
 			logger.Debugf("DBERR: containersGet: error %q", err)
@@ -219,7 +272,8 @@ func instancesGet(d *Daemon, r *http.Request) response.Response {
 	return response.InternalError(fmt.Errorf("DB is locked"))
 }
 
-func doInstancesGet(d *Daemon, r *http.Request) (interface{}, error) {
+// doInstancesGet returns the instances matching the request and, when paginated, the total number of them.
+func doInstancesGet(d *Daemon, r *http.Request, pagination *util.Pagination) (interface{}, int, error) {
 	resultString := []string{}
 	resultList := []*api.Instance{}
 	resultFullList := []*api.InstanceFull{}
@@ -227,7 +281,7 @@ func doInstancesGet(d *Daemon, r *http.Request) (interface{}, error) {
 
 	instanceType, err := urlInstanceTypeDetect(r)
 	if err != nil {
-		return nil, err
+		return nil, -1, err
 	}
 
 	// Parse the recursion field
@@ -244,7 +298,7 @@ func doInstancesGet(d *Daemon, r *http.Request) (interface{}, error) {
 	if filterStr != "" {
 		clauses, err = filter.Parse(filterStr)
 		if err != nil {
-			return nil, errors.Wrap(err, "Invalid filter")
+			return nil, -1, errors.Wrap(err, "Invalid filter")
 		}
 	}
 
@@ -270,7 +324,37 @@ func doInstancesGet(d *Daemon, r *http.Request) (interface{}, error) {
 		return nil
 	})
 	if err != nil {
-		return []string{}, err
+		return []string{}, -1, err
+	}
+
+	// Without a filter, only the requested page of instances needs loading.
+	total := -1
+	if pagination != nil && clauses == nil && !isClusterNotification(r) {
+		names := []string{}
+		for _, instanceNames := range result {
+			names = append(names, instanceNames...)
+		}
+
+		sort.Strings(names)
+		total = len(names)
+		start, end := pagination.Range(total)
+		names = names[start:end]
+
+		for address, instanceNames := range result {
+			pageNames := []string{}
+			for _, instanceName := range instanceNames {
+				if shared.StringInSlice(instanceName, names) {
+					pageNames = append(pageNames, instanceName)
+				}
+			}
+
+			if len(pageNames) == 0 {
+				delete(result, address)
+				continue
+			}
+
+			result[address] = pageNames
+		}
 	}
 
 	// Get the local instances
@@ -279,7 +363,7 @@ func doInstancesGet(d *Daemon, r *http.Request) (interface{}, error) {
 	if mustLoadObjects {
 		insts, err := instanceLoadNodeProjectAll(d.State(), projectName, instanceType)
 		if err != nil {
-			return nil, err
+			return nil, -1, err
 		}
 
 		for _, inst := range insts {
@@ -358,6 +442,11 @@ func doInstancesGet(d *Daemon, r *http.Request) (interface{}, error) {
 					}
 
 					for _, c := range cs {
+						// Skip the instances which aren't part of the requested page.
+						if total != -1 && !shared.StringInSlice(c.Name, containers) {
+							continue
+						}
+
 						resultListAppend(c.Name, c, nil)
 					}
 
@@ -374,6 +463,11 @@ func doInstancesGet(d *Daemon, r *http.Request) (interface{}, error) {
 				}
 
 				for _, c := range cs {
+					// Skip the instances which aren't part of the requested page.
+					if total != -1 && !shared.StringInSlice(c.Name, containers) {
+						continue
+					}
+
 					resultFullListAppend(c.Name, c, nil)
 				}
 			}(address, instanceNames)
@@ -446,6 +540,16 @@ func doInstancesGet(d *Daemon, r *http.Request) (interface{}, error) {
 	}
 	wg.Wait()
 
+	// Return the requested page when it couldn't be selected before loading the instances.
+	paginate := func(count int) (int, int) {
+		if pagination == nil || total != -1 {
+			return 0, count
+		}
+
+		total = count
+		return pagination.Range(count)
+	}
+
 	if recursion == 0 {
 		if clauses != nil {
 			for _, container := range instance.Filter(resultList, clauses) {
@@ -459,7 +563,14 @@ func doInstancesGet(d *Daemon, r *http.Request) (interface{}, error) {
 				resultString = append(resultString, url)
 			}
 		}
-		return resultString, nil
+
+		if pagination != nil {
+			sort.Strings(resultString)
+			start, end := paginate(len(resultString))
+			resultString = resultString[start:end]
+		}
+
+		return resultString, total, nil
 	}
 
 	if recursion == 1 {
@@ -470,7 +581,9 @@ func doInstancesGet(d *Daemon, r *http.Request) (interface{}, error) {
 		if clauses != nil {
 			resultList = instance.Filter(resultList, clauses)
 		}
-		return resultList, nil
+
+		start, end := paginate(len(resultList))
+		return resultList[start:end], total, nil
 	}
 
 	// Sort the result list by name.
@@ -481,7 +594,9 @@ func doInstancesGet(d *Daemon, r *http.Request) (interface{}, error) {
 	if clauses != nil {
 		resultFullList = instance.FilterFull(resultFullList, clauses)
 	}
-	return resultFullList, nil
+
+	start, end := paginate(len(resultFullList))
+	return resultFullList[start:end], total, nil
 }
 
 // Fetch information about the containers on the given remote node, using the
@@ -35,6 +35,63 @@ import (
 	"github.com/lxc/lxd/shared/osarch"
 )
 
+// instanceImageFetch returns the image with the given hash resolved from the source, downloading it from
+// the source server if needed.
+func instanceImageFetch(d *Daemon, r *http.Request, op *operations.Operation, projectName string, source api.InstanceSource, hash string, instanceType api.InstanceType) (*api.Image, error) {
+	if source.Server == "" {
+		_, info, err := d.cluster.GetImage(hash, db.ImageFilter{Project: &projectName})
+		if err != nil {
+			return nil, err
+		}
+
+		return info, nil
+	}
+
+	var autoUpdate bool
+	p, err := d.cluster.GetProject(projectName)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.Config["images.auto_update_cached"] != "" {
+		autoUpdate = shared.IsTrue(p.Config["images.auto_update_cached"])
+	} else {
+		autoUpdate, err = cluster.ConfigGetBool(d.cluster, "images.auto_update_cached")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Detect image type based on instance type requested.
+	imgType := "container"
+	if instanceType == "virtual-machine" {
+		imgType = "virtual-machine"
+	}
+
+	var budget int64
+	err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
+		budget, err = project.GetImageSpaceBudget(tx, projectName)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return d.ImageDownload(r, op, &ImageDownloadArgs{
+		Server:       source.Server,
+		Protocol:     source.Protocol,
+		Certificate:  source.Certificate,
+		Secret:       source.Secret,
+		Alias:        hash,
+		SetCached:    true,
+		Type:         imgType,
+		AutoUpdate:   autoUpdate,
+		PreferCached: true,
+		ProjectName:  projectName,
+		Budget:       budget,
+	})
+}
+
 func createFromImage(d *Daemon, r *http.Request, projectName string, req *api.InstancesPost) response.Response {
 	if d.cluster.LocalNodeIsEvacuated() {
 		return response.Forbidden(fmt.Errorf("Node is evacuated"))
@@ -67,59 +124,9 @@ func createFromImage(d *Daemon, r *http.Request, projectName string, req *api.In
 			return err
 		}
 
-		var info *api.Image
-		if req.Source.Server != "" {
-			var autoUpdate bool
-			p, err := d.cluster.GetProject(projectName)
-			if err != nil {
-				return err
-			}
-
-			if p.Config["images.auto_update_cached"] != "" {
-				autoUpdate = shared.IsTrue(p.Config["images.auto_update_cached"])
-			} else {
-				autoUpdate, err = cluster.ConfigGetBool(d.cluster, "images.auto_update_cached")
-				if err != nil {
-					return err
-				}
-			}
-
-			// Detect image type based on instance type requested.
-			imgType := "container"
-			if req.Type == "virtual-machine" {
-				imgType = "virtual-machine"
-			}
-
-			var budget int64
-			err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
-				budget, err = project.GetImageSpaceBudget(tx, projectName)
-				return err
-			})
-			if err != nil {
-				return err
-			}
-
-			info, err = d.ImageDownload(r, op, &ImageDownloadArgs{
-				Server:       req.Source.Server,
-				Protocol:     req.Source.Protocol,
-				Certificate:  req.Source.Certificate,
-				Secret:       req.Source.Secret,
-				Alias:        hash,
-				SetCached:    true,
-				Type:         imgType,
-				AutoUpdate:   autoUpdate,
-				PreferCached: true,
-				ProjectName:  projectName,
-				Budget:       budget,
-			})
-			if err != nil {
-				return err
-			}
-		} else {
-			_, info, err = d.cluster.GetImage(hash, db.ImageFilter{Project: &projectName})
-			if err != nil {
-				return err
-			}
+		info, err := instanceImageFetch(d, r, op, projectName, req.Source, hash, req.Type)
+		if err != nil {
+			return err
 		}
 
 		args.Architecture, err = osarch.ArchitectureId(info.Architecture)
@@ -225,6 +232,22 @@ func createFromMigration(d *Daemon, r *http.Request, projectName string, req *ap
 		return response.BadRequest(fmt.Errorf("Instance type not supported %q", req.Type))
 	}
 
+	// Apply the storage pool mapping to the disk devices.
+	for srcPool, dstPool := range req.Source.PoolMap {
+		_, err := d.cluster.GetStoragePoolID(dstPool)
+		if err != nil {
+			if err == db.ErrNoSuchObject {
+				return response.BadRequest(fmt.Errorf("Storage pool %q mapped from %q not found", dstPool, srcPool))
+			}
+
+			return response.SmartError(err)
+		}
+	}
+
+	for _, dev := range req.Devices {
+		migrationPoolMapDevice(req.Source.PoolMap, dev)
+	}
+
 	// Prepare the instance creation request.
 	args := db.InstanceArgs{
 		Project:      projectName,
@@ -258,7 +281,7 @@ func createFromMigration(d *Daemon, r *http.Request, projectName string, req *ap
 	}
 
 	if storagePool == "" {
-		return response.BadRequest(fmt.Errorf("Can't find a storage pool for the instance to use"))
+		return response.BadRequest(fmt.Errorf("Can't find a storage pool for the instance to use, a pool mapping may be needed"))
 	}
 
 	if localRootDiskDeviceKey == "" && storagePoolProfile == "" {
@@ -356,6 +379,7 @@ func createFromMigration(d *Daemon, r *http.Request, projectName string, req *ap
 		Push:         push,
 		Live:         req.Source.Live,
 		InstanceOnly: instanceOnly,
+		PoolMap:      req.Source.PoolMap,
 		Refresh:      req.Source.Refresh,
 	}
 
@@ -385,6 +409,12 @@ func createFromMigration(d *Daemon, r *http.Request, projectName string, req *ap
 		return nil
 	}
 
+	// Cancelling the migration makes it fail, which reverts the creation of the instance.
+	cancel := func(op *operations.Operation) error {
+		sink.Cancel()
+		return nil
+	}
+
 	resources := map[string][]string{}
 	resources["instances"] = []string{req.Name}
 
@@ -394,12 +424,12 @@ func createFromMigration(d *Daemon, r *http.Request, projectName string, req *ap
 
 	var op *operations.Operation
 	if push {
-		op, err = operations.OperationCreate(d.State(), projectName, operations.OperationClassWebsocket, db.OperationInstanceCreate, resources, sink.Metadata(), run, nil, sink.Connect, r)
+		op, err = operations.OperationCreate(d.State(), projectName, operations.OperationClassWebsocket, db.OperationInstanceCreate, resources, sink.Metadata(), run, cancel, sink.Connect, r)
 		if err != nil {
 			return response.InternalError(err)
 		}
 	} else {
-		op, err = operations.OperationCreate(d.State(), projectName, operations.OperationClassTask, db.OperationInstanceCreate, resources, nil, run, nil, nil, r)
+		op, err = operations.OperationCreate(d.State(), projectName, operations.OperationClassTask, db.OperationInstanceCreate, resources, nil, run, cancel, nil, r)
 		if err != nil {
 			return response.InternalError(err)
 		}
@@ -418,6 +448,12 @@ func createFromCopy(d *Daemon, r *http.Request, projectName string, req *api.Ins
 		return response.BadRequest(fmt.Errorf("Must specify a source instance"))
 	}
 
+	// Clones share their data with the source through the storage driver.
+	clone := req.Source.Type == "clone"
+	if clone && req.Source.Refresh {
+		return response.BadRequest(fmt.Errorf("Clones cannot be refreshed"))
+	}
+
 	sourceProject := req.Source.Project
 	if sourceProject == "" {
 		sourceProject = projectName
@@ -457,6 +493,10 @@ func createFromCopy(d *Daemon, r *http.Request, projectName string, req *api.Ins
 			}
 
 			if sourcePoolName != destPoolName {
+				if clone {
+					return response.BadRequest(fmt.Errorf("Clones must be created in the same storage pool as the source"))
+				}
+
 				// Redirect to migration
 				return clusterCopyContainerInternal(d, r, source, projectName, req)
 			}
@@ -468,6 +508,10 @@ func createFromCopy(d *Daemon, r *http.Request, projectName string, req *api.Ins
 			}
 
 			if pool.Driver != "ceph" {
+				if clone {
+					return response.BadRequest(fmt.Errorf("Clones must be created on the same cluster member as the source"))
+				}
+
 				// Redirect to migration
 				return clusterCopyContainerInternal(d, r, source, projectName, req)
 			}
@@ -547,6 +591,35 @@ func createFromCopy(d *Daemon, r *http.Request, projectName string, req *api.Ins
 		return response.BadRequest(fmt.Errorf("Instance type should not be specified or should match source type"))
 	}
 
+	if clone {
+		if source.IsSnapshot() {
+			return response.BadRequest(fmt.Errorf("Clones cannot be created from snapshots"))
+		}
+
+		_, rootDevice, err := shared.GetRootDiskDevice(source.ExpandedDevices().CloneNative())
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		destPoolName, _, _, _, resp := instanceFindStoragePool(d, targetProject, req)
+		if resp != nil {
+			return resp
+		}
+
+		if destPoolName != rootDevice["pool"] {
+			return response.BadRequest(fmt.Errorf("Clones must be created in the same storage pool as the source"))
+		}
+
+		pool, err := storagePools.GetPoolByName(d.State(), destPoolName)
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		if !pool.Driver().Info().CloneCopy {
+			return response.BadRequest(fmt.Errorf("Storage pool %q doesn't support copy-on-write clones", destPoolName))
+		}
+	}
+
 	args := db.InstanceArgs{
 		Project:      targetProject,
 		Architecture: source.Architecture(),
@@ -562,16 +635,25 @@ func createFromCopy(d *Daemon, r *http.Request, projectName string, req *api.Ins
 	}
 
 	run := func(op *operations.Operation) error {
-		_, err := instanceCreateAsCopy(d.State(), instanceCreateAsCopyOpts{
+		inst, err := instanceCreateAsCopy(d.State(), instanceCreateAsCopyOpts{
 			sourceInstance:       source,
 			targetInstance:       args,
-			instanceOnly:         req.Source.InstanceOnly || req.Source.ContainerOnly,
+			instanceOnly:         clone || req.Source.InstanceOnly || req.Source.ContainerOnly,
 			refresh:              req.Source.Refresh,
 			applyTemplateTrigger: true,
 		}, op)
 		if err != nil {
 			return err
 		}
+
+		// Record the source of the clone until it gets flattened.
+		if clone {
+			err = inst.VolatileSet(map[string]string{"volatile.clone_source": fmt.Sprintf("%s/%s", source.Project(), source.Name())})
+			if err != nil {
+				return err
+			}
+		}
+
 		return nil
 	}
 
@@ -594,10 +676,46 @@ func createFromBackup(d *Daemon, r *http.Request, projectName string, data io.Re
 	revert := revert.New()
 	defer revert.Fail()
 
+	// Store uploaded backup data into a temporary file.
+	backupFile, err := backupFileTemp(data)
+	if err != nil {
+		return response.InternalError(err)
+	}
+	revert.Add(func() { backupFile.Close() })
+
+	bInfo, restore, err := instanceCreateFromBackup(d, projectName, backupFile, pool, instanceName)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	run := func(op *operations.Operation) error {
+		defer backupFile.Close()
+
+		return restore(op)
+	}
+
+	resources := map[string][]string{}
+	resources["instances"] = []string{bInfo.Name}
+	resources["containers"] = resources["instances"]
+
+	op, err := operations.OperationCreate(d.State(), bInfo.Project, operations.OperationClassTask, db.OperationBackupRestore, resources, nil, run, nil, nil, r)
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	revert.Success()
+	return operations.OperationResponse(op)
+}
+
+// backupFileTemp stores backup data into an unlinked temporary file, converting squashfs backups to tarballs.
+func backupFileTemp(data io.Reader) (*os.File, error) {
+	revert := revert.New()
+	defer revert.Fail()
+
 	// Create temporary file to store uploaded backup data.
 	backupFile, err := ioutil.TempFile(shared.VarPath("backups"), fmt.Sprintf("%s_", backup.WorkingDirPrefix))
 	if err != nil {
-		return response.InternalError(err)
+		return nil, err
 	}
 	defer os.Remove(backupFile.Name())
 	revert.Add(func() { backupFile.Close() })
@@ -605,14 +723,14 @@ func createFromBackup(d *Daemon, r *http.Request, projectName string, data io.Re
 	// Stream uploaded backup data into temporary file.
 	_, err = io.Copy(backupFile, data)
 	if err != nil {
-		return response.InternalError(err)
+		return nil, err
 	}
 
 	// Detect squashfs compression and convert to tarball.
 	backupFile.Seek(0, 0)
 	_, algo, decomArgs, err := shared.DetectCompressionFile(backupFile)
 	if err != nil {
-		return response.InternalError(err)
+		return nil, err
 	}
 
 	if algo == ".squashfs" {
@@ -622,30 +740,39 @@ func createFromBackup(d *Daemon, r *http.Request, projectName string, data io.Re
 		// Create temporary file to store the decompressed tarball in.
 		tarFile, err := ioutil.TempFile(shared.VarPath("backups"), fmt.Sprintf("%s_decompress_", backup.WorkingDirPrefix))
 		if err != nil {
-			return response.InternalError(err)
+			return nil, err
 		}
 		defer os.Remove(tarFile.Name())
+		revert.Add(func() { tarFile.Close() })
 
 		// Decompress to tarData temporary file.
 		err = shared.RunCommandWithFds(nil, tarFile, decomArgs[0], decomArgs[1:]...)
 		if err != nil {
-			return response.InternalError(err)
+			return nil, err
 		}
 
 		// We don't need the original squashfs file anymore.
 		backupFile.Close()
-		os.Remove(backupFile.Name())
 
 		// Replace the backup file handle with the handle to the tar file.
 		backupFile = tarFile
 	}
 
+	backupFile.Seek(0, 0)
+
+	revert.Success()
+	return backupFile, nil
+}
+
+// instanceCreateFromBackup loads the backup information from a backup file and returns a function restoring the
+// instance from it. The backup file must be kept open until that function returns.
+func instanceCreateFromBackup(d *Daemon, projectName string, backupFile *os.File, pool string, instanceName string) (*backup.Info, func(op *operations.Operation) error, error) {
 	// Parse the backup information.
 	backupFile.Seek(0, 0)
 	logger.Debug("Reading backup file info")
 	bInfo, err := backup.GetInfo(backupFile)
 	if err != nil {
-		return response.BadRequest(err)
+		return nil, nil, api.StatusErrorf(http.StatusBadRequest, "%v", err)
 	}
 	bInfo.Project = projectName
 
@@ -676,32 +803,29 @@ func createFromBackup(d *Daemon, r *http.Request, projectName string, data io.Re
 		// the backup.yaml) or the pool has been specified directly from the user restoring
 		// the backup then we cannot proceed so return an error.
 		if *bInfo.OptimizedStorage || pool != "" {
-			return response.InternalError(errors.Wrap(err, "Storage pool not found"))
+			return nil, nil, errors.Wrap(err, "Storage pool not found")
 		}
 
 		// Otherwise try and restore to the project's default profile pool.
 		_, profile, err := d.State().Cluster.GetProfile(bInfo.Project, "default")
 		if err != nil {
-			return response.InternalError(errors.Wrap(err, "Failed to get default profile"))
+			return nil, nil, errors.Wrap(err, "Failed to get default profile")
 		}
 
 		_, v, err := shared.GetRootDiskDevice(profile.Devices)
 		if err != nil {
-			return response.InternalError(errors.Wrap(err, "Failed to get root disk device"))
+			return nil, nil, errors.Wrap(err, "Failed to get root disk device")
 		}
 
 		// Use the default-profile's root pool.
 		bInfo.Pool = v["pool"]
 	} else if err != nil {
-		return response.InternalError(err)
+		return nil, nil, err
 	}
 
-	// Copy reverter so far so we can use it inside run after this function has finished.
-	runRevert := revert.Clone()
-
 	run := func(op *operations.Operation) error {
-		defer backupFile.Close()
-		defer runRevert.Fail()
+		revert := revert.New()
+		defer revert.Fail()
 
 		pool, err := storagePools.GetPoolByName(d.State(), bInfo.Pool)
 		if err != nil {
@@ -722,7 +846,7 @@ func createFromBackup(d *Daemon, r *http.Request, projectName string, data io.Re
 		if err != nil {
 			return errors.Wrap(err, "Create instance from backup")
 		}
-		runRevert.Add(revertHook)
+		revert.Add(revertHook)
 
 		err = internalImportFromBackup(d, bInfo.Project, bInfo.Name, true, instanceName != "")
 		if err != nil {
@@ -735,7 +859,7 @@ func createFromBackup(d *Daemon, r *http.Request, projectName string, data io.Re
 		}
 
 		// Clean up created instance if the post hook fails below.
-		runRevert.Add(func() { inst.Delete(true) })
+		revert.Add(func() { inst.Delete(true) })
 
 		// Run the storage post hook to perform any final actions now that the instance has been created
 		// in the database (this normally includes unmounting volumes that were mounted).
@@ -746,21 +870,11 @@ func createFromBackup(d *Daemon, r *http.Request, projectName string, data io.Re
 			}
 		}
 
-		runRevert.Success()
+		revert.Success()
 		return nil
 	}
 
-	resources := map[string][]string{}
-	resources["instances"] = []string{bInfo.Name}
-	resources["containers"] = resources["instances"]
-
-	op, err := operations.OperationCreate(d.State(), bInfo.Project, operations.OperationClassTask, db.OperationBackupRestore, resources, nil, run, nil, nil, r)
-	if err != nil {
-		return response.InternalError(err)
-	}
-
-	revert.Success()
-	return operations.OperationResponse(op)
+	return bInfo, run, nil
 }
 
 // swagger:operation POST /1.0/instances instances instances_post
@@ -833,13 +947,41 @@ func instancesPost(d *Daemon, r *http.Request) response.Response {
 		req.Type = api.InstanceType(urlType.String())
 	}
 
+	return instancesCreate(d, r, targetProject, req)
+}
+
+// instancesCreate places the new instance on a cluster member, forwarding the request if needed, and creates it
+// there.
+func instancesCreate(d *Daemon, r *http.Request, targetProject string, req api.InstancesPost) response.Response {
 	targetNode := queryParam(r, "target")
-	err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
+	err := d.cluster.Transaction(func(tx *db.ClusterTx) error {
 		return project.CheckClusterTargetRestriction(tx, r, targetProject, targetNode)
 	})
 	if err != nil {
 		return response.SmartError(err)
 	}
+
+	// Clones are created on the same node as their source unless targeted elsewhere.
+	if targetNode == "" && req.Source.Type == "clone" && req.Source.Source != "" && !strings.Contains(req.Source.Source, shared.SnapshotDelimiter) {
+		sourceProject := req.Source.Project
+		if sourceProject == "" {
+			sourceProject = targetProject
+		}
+
+		err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
+			source, err := instance.LoadInstanceDatabaseObject(tx, sourceProject, req.Source.Source)
+			if err != nil {
+				return err
+			}
+
+			targetNode = source.Node
+			return nil
+		})
+		if err != nil {
+			return response.SmartError(err)
+		}
+	}
+
 	if targetNode == "" {
 		// If no target node was specified, pick the node with the
 		// least number of containers. If there's just one node, or if
@@ -948,7 +1090,7 @@ func instancesPost(d *Daemon, r *http.Request) response.Response {
 	err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
 		if req.Type == "" {
 			switch req.Source.Type {
-			case "copy":
+			case "copy", "clone":
 				if req.Source.Source == "" {
 					return fmt.Errorf("Must specify a source instance")
 				}
@@ -1007,7 +1149,7 @@ func instancesPost(d *Daemon, r *http.Request) response.Response {
 		return createFromNone(d, r, targetProject, &req)
 	case "migration":
 		return createFromMigration(d, r, targetProject, &req)
-	case "copy":
+	case "copy", "clone":
 		return createFromCopy(d, r, targetProject, &req)
 	default:
 		return response.BadRequest(fmt.Errorf("Unknown source type %s", req.Source.Type))
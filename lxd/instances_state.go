@@ -0,0 +1,300 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/lxc/lxd/lxd/cluster"
+	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/instance"
+	"github.com/lxc/lxd/lxd/operations"
+	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+)
+
+// instancesStateMaxWorkers is the maximum number of instances whose state is changed concurrently on a member.
+const instancesStateMaxWorkers = 10
+
+var instancesStateCmd = APIEndpoint{
+	Name: "instancesState",
+	Path: "instances/state",
+
+	Put: APIEndpointAction{Handler: instancesStatePut, AccessHandler: allowProjectPermission("containers", "operate-containers")},
+}
+
+// swagger:operation PUT /1.0/instances/state instances instances_state_put
+//
+// Bulk instance state change
+//
+// Changes the running state of the selected instances, across all the cluster members.
+//
+// The result of the change of each instance is reported in the `results`
+// field of the operation metadata.
+//
+// ---
+// consumes:
+//   - application/json
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+//   - in: body
+//     name: state
+//     description: Instances and state change
+//     required: true
+//     schema:
+//       $ref: "#/definitions/InstancesStatePut"
+// responses:
+//   "200":
+//     $ref: "#/responses/Operation"
+//   "400":
+//     $ref: "#/responses/BadRequest"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "404":
+//     $ref: "#/responses/NotFound"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func instancesStatePut(d *Daemon, r *http.Request) response.Response {
+	projectName := projectParam(r)
+
+	// Don't mess with instances while in setup mode.
+	<-d.readyChan
+
+	req := api.InstancesStatePut{}
+	req.State.Timeout = -1
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	// Require an explicit selection so that an empty request doesn't change every instance.
+	if req.All && (len(req.Instances) > 0 || len(req.Selector) > 0) {
+		return response.BadRequest(fmt.Errorf("Instances and selector can't be used along with all"))
+	}
+
+	if !req.All && len(req.Instances) == 0 && len(req.Selector) == 0 {
+		return response.BadRequest(fmt.Errorf("No instances selected, set instances, selector or all"))
+	}
+
+	opType, err := instanceActionToOptype(req.State.Action)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	// Find the selected instances, grouped by cluster member.
+	members := map[string][]string{}
+	names := []string{}
+	err = d.cluster.InstanceList(&db.InstanceFilter{Project: &projectName}, func(inst db.Instance, p db.Project, profiles []api.Profile) error {
+		if len(req.Instances) > 0 && !shared.StringInSlice(inst.Name, req.Instances) {
+			return nil
+		}
+
+		expandedConfig := db.ExpandInstanceConfig(inst.Config, profiles)
+		for key, value := range req.Selector {
+			if expandedConfig[key] != value {
+				return nil
+			}
+		}
+
+		members[inst.Node] = append(members[inst.Node], inst.Name)
+		names = append(names, inst.Name)
+
+		return nil
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	for _, name := range req.Instances {
+		if !shared.StringInSlice(name, names) {
+			return response.NotFound(fmt.Errorf("Instance %q not found", name))
+		}
+	}
+
+	do := func(op *operations.Operation) error {
+		results := []api.InstanceStateResult{}
+		resultsLock := sync.Mutex{}
+
+		addResults := func(memberResults ...api.InstanceStateResult) {
+			resultsLock.Lock()
+			defer resultsLock.Unlock()
+
+			results = append(results, memberResults...)
+			sort.Slice(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+			_ = op.UpdateMetadata(map[string]interface{}{"results": append([]api.InstanceStateResult{}, results...)})
+		}
+
+		var localName string
+		var nodes []db.NodeInfo
+		err := d.cluster.Transaction(func(tx *db.ClusterTx) error {
+			var err error
+			localName, err = tx.GetLocalNodeName()
+			if err != nil {
+				return err
+			}
+
+			nodes, err = tx.GetNodes()
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		addresses := map[string]string{}
+		for _, node := range nodes {
+			addresses[node.Name] = node.Address
+		}
+
+		wg := sync.WaitGroup{}
+		for member, memberNames := range members {
+			wg.Add(1)
+			go func(member string, memberNames []string) {
+				defer wg.Done()
+
+				if member == localName || addresses[member] == "" {
+					addResults(instancesStateLocal(d, op, projectName, member, memberNames, req.State)...)
+					return
+				}
+
+				addResults(instancesStateRemote(d, r, projectName, member, addresses[member], memberNames, req.State)...)
+			}(member, memberNames)
+		}
+
+		wg.Wait()
+
+		failures := map[string]error{}
+		for _, result := range results {
+			if result.Error != "" {
+				failures[result.Name] = fmt.Errorf("%s", result.Error)
+			}
+		}
+
+		return coalesceErrors(true, failures)
+	}
+
+	resources := map[string][]string{}
+	resources["instances"] = names
+	op, err := operations.OperationCreate(d.State(), projectName, operations.OperationClassTask, opType, resources, nil, do, nil, nil, r)
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	return operations.OperationResponse(op)
+}
+
+// instancesStateLocal changes the state of the given instances of this cluster member.
+func instancesStateLocal(d *Daemon, op *operations.Operation, projectName string, member string, names []string, req api.InstanceStatePut) []api.InstanceStateResult {
+	results := make([]api.InstanceStateResult, len(names))
+	for i, name := range names {
+		results[i] = api.InstanceStateResult{Name: name, Location: member}
+	}
+
+	// Process the instances with a bounded number of workers.
+	workers := instancesStateMaxWorkers
+	if len(results) < workers {
+		workers = len(results)
+	}
+
+	jobs := make(chan *api.InstanceStateResult)
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for result := range jobs {
+				instanceStateLocal(d, op, projectName, result, req)
+			}
+		}()
+	}
+
+	for i := range results {
+		jobs <- &results[i]
+	}
+
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+// instanceStateLocal changes the state of a local instance, recording the outcome in result.
+func instanceStateLocal(d *Daemon, op *operations.Operation, projectName string, result *api.InstanceStateResult, req api.InstanceStatePut) {
+	inst, err := instance.LoadByProjectAndName(d.State(), projectName, result.Name)
+	if err != nil {
+		result.Error = err.Error()
+		return
+	}
+
+	switch shared.InstanceAction(req.Action) {
+	case shared.Start:
+		result.Skipped = inst.IsRunning()
+	case shared.Unfreeze:
+		result.Skipped = !inst.IsFrozen()
+	case shared.Freeze:
+		result.Skipped = !inst.IsRunning() || inst.IsFrozen()
+	default:
+		result.Skipped = !inst.IsRunning()
+	}
+
+	if result.Skipped {
+		return
+	}
+
+	inst.SetOperation(op)
+	err = doInstanceStatePut(inst, req)
+	if err != nil {
+		result.Error = err.Error()
+	}
+}
+
+// instancesStateRemote changes the state of the given instances of another cluster member.
+func instancesStateRemote(d *Daemon, r *http.Request, projectName string, member string, address string, names []string, req api.InstanceStatePut) []api.InstanceStateResult {
+	failed := func(err error) []api.InstanceStateResult {
+		results := make([]api.InstanceStateResult, 0, len(names))
+		for _, name := range names {
+			results = append(results, api.InstanceStateResult{Name: name, Location: member, Error: err.Error()})
+		}
+
+		return results
+	}
+
+	client, err := cluster.Connect(address, d.endpoints.NetworkCert(), d.serverCert(), r, true)
+	if err != nil {
+		return failed(err)
+	}
+
+	op, err := client.UseProject(projectName).UpdateInstancesState(api.InstancesStatePut{Instances: names, State: req}, "")
+	if err != nil {
+		return failed(err)
+	}
+
+	// The per-instance results are reported even if some of the changes failed.
+	_ = op.Wait()
+
+	results := []api.InstanceStateResult{}
+	resultsJSON, err := json.Marshal(op.Get().Metadata["results"])
+	if err == nil {
+		err = json.Unmarshal(resultsJSON, &results)
+	}
+
+	if err != nil || len(results) == 0 {
+		if op.Get().Err != "" {
+			return failed(fmt.Errorf("%s", op.Get().Err))
+		}
+
+		return failed(fmt.Errorf("No results returned by cluster member %q", member))
+	}
+
+	return results
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test that bulk state changes without an explicit selection of instances are rejected.
+func TestInstancesStatePut_Selection(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{
+			name: "Empty body",
+			body: `{}`,
+		},
+		{
+			name: "No selection",
+			body: `{"state": {"action": "stop"}}`,
+		},
+		{
+			name: "Empty selection",
+			body: `{"instances": [], "selector": {}, "state": {"action": "stop"}}`,
+		},
+		{
+			name: "All with instances",
+			body: `{"all": true, "instances": ["c1"], "state": {"action": "stop"}}`,
+		},
+		{
+			name: "All with selector",
+			body: `{"all": true, "selector": {"user.role": "web"}, "state": {"action": "stop"}}`,
+		},
+	}
+
+	d := &Daemon{readyChan: make(chan struct{})}
+	close(d.readyChan)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest("PUT", "/1.0/instances/state", strings.NewReader(test.body))
+			w := httptest.NewRecorder()
+
+			err := instancesStatePut(d, r).Render(w)
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lxc/lxd/lxd/instance"
+)
+
+// autostartInstance is a stub instance only providing what the auto-start ordering looks at.
+type autostartInstance struct {
+	instance.Instance
+
+	project string
+	name    string
+	config  map[string]string
+}
+
+func (i *autostartInstance) Project() string {
+	return i.project
+}
+
+func (i *autostartInstance) Name() string {
+	return i.name
+}
+
+func (i *autostartInstance) ExpandedConfig() map[string]string {
+	return i.config
+}
+
+// Test the order in which instances are auto-started.
+func TestInstancesAutostartOrder(t *testing.T) {
+	tests := []struct {
+		name      string
+		instances []*autostartInstance
+		expected  []string
+	}{
+		{
+			name: "Priority",
+			instances: []*autostartInstance{
+				{name: "c1", config: map[string]string{"boot.autostart": "true", "boot.autostart.priority": "1"}},
+				{name: "c2", config: map[string]string{"boot.autostart": "true", "boot.autostart.priority": "10"}},
+				{name: "c3", config: map[string]string{"boot.autostart": "true", "boot.autostart.priority": "5"}},
+			},
+			expected: []string{"c2", "c3", "c1"},
+		},
+		{
+			name: "Priority ties ordered by name",
+			instances: []*autostartInstance{
+				{name: "c3", config: map[string]string{"boot.autostart": "true", "boot.autostart.priority": "5"}},
+				{name: "c1", config: map[string]string{"boot.autostart": "true", "boot.autostart.priority": "5"}},
+				{name: "c2", config: map[string]string{"boot.autostart": "true", "boot.autostart.priority": "5"}},
+				{name: "c0", config: map[string]string{"boot.autostart": "true", "boot.autostart.priority": "1"}},
+			},
+			expected: []string{"c1", "c2", "c3", "c0"},
+		},
+		{
+			name: "Only auto-started or previously running instances",
+			instances: []*autostartInstance{
+				{name: "c1", config: map[string]string{"boot.autostart": "true"}},
+				{name: "c2", config: map[string]string{"boot.autostart": "false", "volatile.last_state.power": "RUNNING"}},
+				{name: "c3", config: map[string]string{"volatile.last_state.power": "RUNNING"}},
+				{name: "c4", config: map[string]string{"volatile.last_state.power": "STOPPED"}},
+			},
+			expected: []string{"c1", "c3"},
+		},
+		{
+			name: "Dependencies first",
+			instances: []*autostartInstance{
+				{name: "app", config: map[string]string{"boot.autostart": "true", "boot.autostart.priority": "10", "boot.depends_on": "db, cache"}},
+				{name: "cache", config: map[string]string{"boot.autostart": "true"}},
+				{name: "db", config: map[string]string{"boot.autostart": "true", "boot.depends_on": "storage"}},
+				{name: "storage", config: map[string]string{"boot.autostart": "true"}},
+			},
+			expected: []string{"storage", "db", "cache", "app"},
+		},
+		{
+			name: "Dependencies not configured to auto-start",
+			instances: []*autostartInstance{
+				{name: "app", config: map[string]string{"boot.autostart": "true", "boot.depends_on": "db"}},
+				{name: "db", config: map[string]string{"boot.autostart": "false"}},
+			},
+			expected: []string{"db", "app"},
+		},
+		{
+			name: "Missing dependencies",
+			instances: []*autostartInstance{
+				{name: "app", config: map[string]string{"boot.autostart": "true", "boot.depends_on": "db"}},
+				{project: "other", name: "db", config: map[string]string{"boot.autostart": "true"}},
+			},
+			expected: []string{"app", "other_db"},
+		},
+		{
+			name: "Dependencies within the project",
+			instances: []*autostartInstance{
+				{project: "other", name: "db", config: map[string]string{"boot.autostart": "true"}},
+				{project: "other", name: "app", config: map[string]string{"boot.autostart": "true", "boot.autostart.priority": "10", "boot.depends_on": "db"}},
+				{name: "db", config: map[string]string{"boot.autostart": "true"}},
+			},
+			expected: []string{"other_db", "other_app", "db"},
+		},
+		{
+			name: "Self dependency",
+			instances: []*autostartInstance{
+				{name: "c1", config: map[string]string{"boot.autostart": "true", "boot.depends_on": "c1"}},
+			},
+			expected: []string{"c1"},
+		},
+		{
+			name: "Cycle",
+			instances: []*autostartInstance{
+				{name: "c1", config: map[string]string{"boot.autostart": "true", "boot.depends_on": "c2"}},
+				{name: "c2", config: map[string]string{"boot.autostart": "true", "boot.depends_on": "c3"}},
+				{name: "c3", config: map[string]string{"boot.autostart": "true", "boot.depends_on": "c1"}},
+				{name: "c4", config: map[string]string{"boot.autostart": "true", "boot.depends_on": "c2"}},
+			},
+			expected: []string{"c3", "c2", "c1", "c4"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			instances := []instance.Instance{}
+			for _, inst := range test.instances {
+				if inst.project == "" {
+					inst.project = "default"
+				}
+
+				instances = append(instances, inst)
+			}
+
+			sort.Sort(containerAutostartList(instances))
+
+			names := []string{}
+			for _, inst := range instancesAutostartOrder(instances) {
+				if inst.Project() == "default" {
+					names = append(names, inst.Name())
+				} else {
+					names = append(names, inst.Project()+"_"+inst.Name())
+				}
+			}
+
+			assert.Equal(t, test.expected, names)
+		})
+	}
+}
@@ -38,6 +38,21 @@ func (a *ActionPolice) AddAction() []string {
 	return result
 }
 
+// ActionSkbEdit represents an action of 'skbedit' type
+type ActionSkbEdit struct {
+	Priority string
+}
+
+// AddAction generates a part of command specific for 'skbedit' action
+func (a *ActionSkbEdit) AddAction() []string {
+	result := []string{"action", "skbedit"}
+	if a.Priority != "" {
+		result = append(result, "priority", a.Priority)
+	}
+
+	return result
+}
+
 // Filter represents filter object
 type Filter struct {
 	Dev      string
@@ -143,6 +143,15 @@ func (l *Link) SetVfSpoofchk(vf string, mode string) error {
 	return nil
 }
 
+// SetVfRate sets the maximum transmit rate (in Mbps) of the virtual function, 0 disables the limit.
+func (l *Link) SetVfRate(vf string, maxTxRate string) error {
+	_, err := shared.TryRunCommand("ip", "link", "set", "dev", l.Name, "vf", vf, "max_tx_rate", maxTxRate)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
 // VirtFuncInfo holds information about vf.
 type VirtFuncInfo struct {
 	VF         int              `json:"vf"`
@@ -0,0 +1,32 @@
+package lifecycle
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/lxc/lxd/shared/api"
+)
+
+// AuthTokenAction represents a lifecycle event action for API tokens.
+type AuthTokenAction string
+
+// All supported lifecycle events for API tokens.
+const (
+	AuthTokenCreated = AuthTokenAction("created")
+	AuthTokenDeleted = AuthTokenAction("deleted")
+	AuthTokenUpdated = AuthTokenAction("updated")
+)
+
+// Event creates the lifecycle event for an action on an API token.
+func (a AuthTokenAction) Event(name string, requestor *api.EventLifecycleRequestor, ctx map[string]interface{}) api.EventLifecycle {
+	eventType := fmt.Sprintf("auth-token-%s", a)
+
+	u := fmt.Sprintf("/1.0/auth/tokens/%s", url.PathEscape(name))
+
+	return api.EventLifecycle{
+		Action:    eventType,
+		Source:    u,
+		Context:   ctx,
+		Requestor: requestor,
+	}
+}
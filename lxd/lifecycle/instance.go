@@ -16,6 +16,12 @@ type instance interface {
 	Operation() *operations.Operation
 }
 
+// Instances whose profiles can be recorded in their lifecycle events.
+type instanceWithProfiles interface {
+	instance
+	Profiles() []string
+}
+
 // InstanceAction represents a lifecycle event action for instances.
 type InstanceAction string
 
@@ -29,6 +35,8 @@ const (
 	InstancePaused           = InstanceAction("paused")
 	InstanceResumed          = InstanceAction("resumed")
 	InstanceRestored         = InstanceAction("restored")
+	InstanceRebuilt          = InstanceAction("rebuilt")
+	InstanceConverted        = InstanceAction("converted")
 	InstanceDeleted          = InstanceAction("deleted")
 	InstanceRenamed          = InstanceAction("renamed")
 	InstanceUpdated          = InstanceAction("updated")
@@ -55,6 +63,18 @@ func (a InstanceAction) Event(inst instance, ctx map[string]interface{}) api.Eve
 		requestor = inst.Operation().Requestor()
 	}
 
+	// Record the profiles of the instance, without modifying the caller's context.
+	withProfiles, ok := inst.(instanceWithProfiles)
+	if ok {
+		eventCtx := make(map[string]interface{}, len(ctx)+1)
+		for k, v := range ctx {
+			eventCtx[k] = v
+		}
+
+		eventCtx["profiles"] = withProfiles.Profiles()
+		ctx = eventCtx
+	}
+
 	return api.EventLifecycle{
 		Action:    eventType,
 		Source:    u,
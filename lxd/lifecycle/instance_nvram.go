@@ -0,0 +1,36 @@
+package lifecycle
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/lxc/lxd/lxd/project"
+	"github.com/lxc/lxd/shared/api"
+)
+
+// InstanceNVRAMAction represents a lifecycle event action for the NVRAM of an instance.
+type InstanceNVRAMAction string
+
+// All supported lifecycle events for the NVRAM of an instance.
+const (
+	InstanceNVRAMRetrieved = InstanceNVRAMAction("retrieved")
+	InstanceNVRAMUpdated   = InstanceNVRAMAction("updated")
+	InstanceNVRAMReset     = InstanceNVRAMAction("reset")
+)
+
+// Event creates the lifecycle event for an action on the NVRAM of an instance.
+func (a InstanceNVRAMAction) Event(inst instance, requestor *api.EventLifecycleRequestor, ctx map[string]interface{}) api.EventLifecycle {
+	eventType := fmt.Sprintf("instance-nvram-%s", a)
+	u := fmt.Sprintf("/1.0/instances/%s/nvram", url.PathEscape(inst.Name()))
+
+	if inst.Project() != project.Default {
+		u = fmt.Sprintf("%s?project=%s", u, url.QueryEscape(inst.Project()))
+	}
+
+	return api.EventLifecycle{
+		Action:    eventType,
+		Source:    u,
+		Context:   ctx,
+		Requestor: requestor,
+	}
+}
@@ -0,0 +1,36 @@
+package lifecycle
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/lxc/lxd/lxd/project"
+	"github.com/lxc/lxd/shared/api"
+)
+
+// InstanceTemplateAction represents a lifecycle event action for instance templates.
+type InstanceTemplateAction string
+
+// All supported lifecycle events for instance templates.
+const (
+	InstanceTemplateCreated = InstanceTemplateAction("created")
+	InstanceTemplateDeleted = InstanceTemplateAction("deleted")
+	InstanceTemplateUpdated = InstanceTemplateAction("updated")
+)
+
+// Event creates the lifecycle event for an action on an instance template.
+func (a InstanceTemplateAction) Event(name string, projectName string, requestor *api.EventLifecycleRequestor, ctx map[string]interface{}) api.EventLifecycle {
+	eventType := fmt.Sprintf("instance-template-%s", a)
+
+	u := fmt.Sprintf("/1.0/instance templates/%s", url.PathEscape(name))
+	if projectName != project.Default {
+		u = fmt.Sprintf("%s?project=%s", u, url.QueryEscape(projectName))
+	}
+
+	return api.EventLifecycle{
+		Action:    eventType,
+		Source:    u,
+		Context:   ctx,
+		Requestor: requestor,
+	}
+}
@@ -0,0 +1,36 @@
+package lifecycle
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/lxc/lxd/lxd/project"
+	"github.com/lxc/lxd/shared/api"
+)
+
+// SecretAction represents a lifecycle event action for secrets.
+type SecretAction string
+
+// All supported lifecycle events for secrets.
+const (
+	SecretCreated = SecretAction("created")
+	SecretDeleted = SecretAction("deleted")
+	SecretUpdated = SecretAction("updated")
+)
+
+// Event creates the lifecycle event for an action on a secret.
+func (a SecretAction) Event(name string, projectName string, requestor *api.EventLifecycleRequestor, ctx map[string]interface{}) api.EventLifecycle {
+	eventType := fmt.Sprintf("secret-%s", a)
+
+	u := fmt.Sprintf("/1.0/secrets/%s", url.PathEscape(name))
+	if projectName != project.Default {
+		u = fmt.Sprintf("%s?project=%s", u, url.QueryEscape(projectName))
+	}
+
+	return api.EventLifecycle{
+		Action:    eventType,
+		Source:    u,
+		Context:   ctx,
+		Requestor: requestor,
+	}
+}
@@ -0,0 +1,31 @@
+package lifecycle
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/lxc/lxd/shared/api"
+)
+
+// TaskAction represents a lifecycle event action for background tasks.
+type TaskAction string
+
+// All supported lifecycle events for background tasks.
+const (
+	TaskTriggered = TaskAction("triggered")
+	TaskPaused    = TaskAction("paused")
+	TaskResumed   = TaskAction("resumed")
+)
+
+// Event creates the lifecycle event for an action on a background task.
+func (a TaskAction) Event(name string, requestor *api.EventLifecycleRequestor, ctx map[string]interface{}) api.EventLifecycle {
+	eventType := fmt.Sprintf("task-%s", a)
+	u := fmt.Sprintf("/1.0/tasks/%s", url.PathEscape(name))
+
+	return api.EventLifecycle{
+		Action:    eventType,
+		Source:    u,
+		Context:   ctx,
+		Requestor: requestor,
+	}
+}
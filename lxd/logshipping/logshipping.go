@@ -0,0 +1,22 @@
+package logshipping
+
+import (
+	"time"
+)
+
+// Entry is a log entry to be shipped to a remote endpoint.
+type Entry struct {
+	Timestamp time.Time
+	Level     string // As rendered by log15 (dbug, info, warn, eror or crit).
+	Message   string
+	Labels    map[string]string
+}
+
+// Client ships log entries to a remote endpoint.
+type Client interface {
+	// Send queues an entry for shipping. It never blocks, dropping the entry if the queue is full.
+	Send(entry Entry)
+
+	// Stop stops the client, discarding the entries not shipped yet.
+	Stop()
+}
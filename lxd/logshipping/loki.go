@@ -0,0 +1,177 @@
+package logshipping
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// lokiBatchSize is the maximum number of entries pushed at once.
+const lokiBatchSize = 100
+
+// lokiBatchWait is the maximum time an entry waits before being pushed.
+const lokiBatchWait = time.Second
+
+// LokiClient ships log entries to a Loki server using its push API.
+type LokiClient struct {
+	client   *http.Client
+	url      string
+	username string
+	password string
+
+	entries chan Entry
+	cancel  context.CancelFunc
+}
+
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+type lokiPush struct {
+	Streams []lokiStream `json:"streams"`
+}
+
+// NewLokiClient returns a client shipping log entries to the Loki server at the given URL.
+func NewLokiClient(serverURL string, username string, password string) (*LokiClient, error) {
+	u, err := url.Parse(serverURL)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid Loki URL %q: %w", serverURL, err)
+	}
+
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/loki/api/v1/push"
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	c := &LokiClient{
+		client:   &http.Client{Timeout: 10 * time.Second},
+		url:      u.String(),
+		username: username,
+		password: password,
+		entries:  make(chan Entry, 1024),
+		cancel:   cancel,
+	}
+
+	go c.run(ctx)
+
+	return c, nil
+}
+
+// Send queues an entry for shipping.
+func (c *LokiClient) Send(entry Entry) {
+	select {
+	case c.entries <- entry:
+	default:
+	}
+}
+
+// Stop stops the client.
+func (c *LokiClient) Stop() {
+	c.cancel()
+}
+
+func (c *LokiClient) run(ctx context.Context) {
+	batch := []Entry{}
+
+	ticker := time.NewTicker(lokiBatchWait)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case entry := <-c.entries:
+			batch = append(batch, entry)
+			if len(batch) < lokiBatchSize {
+				continue
+			}
+		case <-ticker.C:
+			if len(batch) == 0 {
+				continue
+			}
+		}
+
+		// Entries failing to be pushed are dropped, there is nowhere to log the failure to without
+		// generating more entries.
+		_ = c.push(ctx, batch)
+		batch = []Entry{}
+	}
+}
+
+func (c *LokiClient) push(ctx context.Context, entries []Entry) error {
+	body, err := json.Marshal(lokiPushBody(entries))
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("Unexpected response from Loki: %s", resp.Status)
+	}
+
+	return nil
+}
+
+// lokiPushBody groups the entries into streams of entries sharing the same labels.
+func lokiPushBody(entries []Entry) lokiPush {
+	streams := map[string]*lokiStream{}
+	keys := []string{}
+
+	for _, entry := range entries {
+		labels := map[string]string{"level": entry.Level}
+		for k, v := range entry.Labels {
+			labels[k] = v
+		}
+
+		key := lokiStreamKey(labels)
+		stream, ok := streams[key]
+		if !ok {
+			stream = &lokiStream{Stream: labels, Values: [][2]string{}}
+			streams[key] = stream
+			keys = append(keys, key)
+		}
+
+		stream.Values = append(stream.Values, [2]string{strconv.FormatInt(entry.Timestamp.UnixNano(), 10), entry.Message})
+	}
+
+	push := lokiPush{Streams: make([]lokiStream, 0, len(keys))}
+	for _, key := range keys {
+		push.Streams = append(push.Streams, *streams[key])
+	}
+
+	return push
+}
+
+func lokiStreamKey(labels map[string]string) string {
+	parts := make([]string, 0, len(labels))
+	for k, v := range labels {
+		parts = append(parts, fmt.Sprintf("%s=%q", k, v))
+	}
+
+	sort.Strings(parts)
+
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,63 @@
+package logshipping
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLokiPushBody(t *testing.T) {
+	ts := time.Unix(1600000000, 0)
+
+	push := lokiPushBody([]Entry{
+		{Timestamp: ts, Level: "info", Message: "first", Labels: map[string]string{"project": "default"}},
+		{Timestamp: ts, Level: "warn", Message: "second", Labels: map[string]string{"project": "default"}},
+		{Timestamp: ts.Add(time.Second), Level: "info", Message: "third", Labels: map[string]string{"project": "default"}},
+	})
+
+	require.Len(t, push.Streams, 2)
+	assert.Equal(t, map[string]string{"level": "info", "project": "default"}, push.Streams[0].Stream)
+	assert.Equal(t, [][2]string{{"1600000000000000000", "first"}, {"1600000001000000000", "third"}}, push.Streams[0].Values)
+	assert.Equal(t, map[string]string{"level": "warn", "project": "default"}, push.Streams[1].Stream)
+	assert.Equal(t, [][2]string{{"1600000000000000000", "second"}}, push.Streams[1].Values)
+}
+
+func TestLokiClient(t *testing.T) {
+	pushes := make(chan lokiPush, 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/loki/api/v1/push", r.URL.Path)
+
+		username, password, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "user", username)
+		assert.Equal(t, "pass", password)
+
+		push := lokiPush{}
+		err := json.NewDecoder(r.Body).Decode(&push)
+		assert.NoError(t, err)
+
+		w.WriteHeader(http.StatusNoContent)
+		pushes <- push
+	}))
+	defer server.Close()
+
+	client, err := NewLokiClient(server.URL, "user", "pass")
+	require.NoError(t, err)
+	defer client.Stop()
+
+	client.Send(Entry{Timestamp: time.Now(), Level: "info", Message: "hello"})
+
+	select {
+	case push := <-pushes:
+		require.Len(t, push.Streams, 1)
+		assert.Equal(t, "hello", push.Streams[0].Values[0][1])
+	case <-time.After(5 * time.Second):
+		t.Fatal("No push received")
+	}
+}
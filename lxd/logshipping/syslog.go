@@ -0,0 +1,173 @@
+package logshipping
+
+import (
+	"context"
+	"fmt"
+	"log/syslog"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Bounds of the delay between two attempts at connecting or writing to the syslog server.
+const syslogMinBackoff = time.Second
+const syslogMaxBackoff = time.Minute
+
+// SyslogClient ships log entries to a remote syslog server, reconnecting when the connection is lost.
+type SyslogClient struct {
+	network string
+	host    string
+	tag     string
+	writer  *syslog.Writer
+
+	entries    chan Entry
+	cancel     context.CancelFunc
+	minBackoff time.Duration
+}
+
+// NewSyslogClient returns a client shipping log entries to the syslog server at the given address, in
+// the form udp://HOST:PORT or tcp://HOST:PORT.
+// The connection is established in the background, so an unreachable server isn't an error.
+func NewSyslogClient(address string, tag string) (*SyslogClient, error) {
+	u, err := url.Parse(address)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid syslog address %q: %w", address, err)
+	}
+
+	if u.Scheme != "udp" && u.Scheme != "tcp" {
+		return nil, fmt.Errorf("Invalid syslog address %q: protocol must be udp or tcp", address)
+	}
+
+	return newSyslogClient(u.Scheme, u.Host, tag, syslogMinBackoff), nil
+}
+
+func newSyslogClient(network string, host string, tag string, minBackoff time.Duration) *SyslogClient {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	c := &SyslogClient{
+		network:    network,
+		host:       host,
+		tag:        tag,
+		entries:    make(chan Entry, 1024),
+		cancel:     cancel,
+		minBackoff: minBackoff,
+	}
+
+	go c.run(ctx)
+
+	return c
+}
+
+// Send queues an entry for shipping.
+func (c *SyslogClient) Send(entry Entry) {
+	select {
+	case c.entries <- entry:
+	default:
+	}
+}
+
+// Stop stops the client.
+func (c *SyslogClient) Stop() {
+	c.cancel()
+}
+
+func (c *SyslogClient) run(ctx context.Context) {
+	defer func() {
+		if c.writer != nil {
+			c.writer.Close()
+		}
+	}()
+
+	backoff := c.minBackoff
+	var pending *Entry
+
+	for {
+		if pending == nil {
+			select {
+			case <-ctx.Done():
+				return
+			case entry := <-c.entries:
+				pending = &entry
+			}
+		}
+
+		// Connect if needed and write the pending entry, keeping it until written. Meanwhile, the
+		// new entries are queued and dropped once the queue is full.
+		err := c.connect()
+		if err == nil {
+			err = c.write(*pending)
+			if err != nil {
+				c.writer.Close()
+				c.writer = nil
+			}
+		}
+
+		if err == nil {
+			pending = nil
+			backoff = c.minBackoff
+			continue
+		}
+
+		// Failures can't be logged without generating more entries, so just wait before retrying.
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > syslogMaxBackoff {
+			backoff = syslogMaxBackoff
+		}
+	}
+}
+
+// connect connects to the syslog server, unless already connected.
+func (c *SyslogClient) connect() error {
+	if c.writer != nil {
+		return nil
+	}
+
+	writer, err := syslog.Dial(c.network, c.host, syslog.LOG_INFO|syslog.LOG_DAEMON, c.tag)
+	if err != nil {
+		return fmt.Errorf("Failed to connect to syslog server %q: %w", c.host, err)
+	}
+
+	c.writer = writer
+
+	return nil
+}
+
+func (c *SyslogClient) write(entry Entry) error {
+	msg := syslogMessage(entry)
+
+	switch entry.Level {
+	case "crit":
+		return c.writer.Crit(msg)
+	case "eror":
+		return c.writer.Err(msg)
+	case "warn":
+		return c.writer.Warning(msg)
+	case "dbug":
+		return c.writer.Debug(msg)
+	default:
+		return c.writer.Info(msg)
+	}
+}
+
+// syslogMessage renders the entry message followed by its labels in logfmt style.
+func syslogMessage(entry Entry) string {
+	labels := make([]string, 0, len(entry.Labels))
+	for k, v := range entry.Labels {
+		labels = append(labels, fmt.Sprintf("%s=%q", k, v))
+	}
+
+	sort.Strings(labels)
+
+	if len(labels) == 0 {
+		return entry.Message
+	}
+
+	return fmt.Sprintf("%s %s", entry.Message, strings.Join(labels, " "))
+}
@@ -0,0 +1,47 @@
+package logshipping
+
+import (
+	"bufio"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyslogMessage(t *testing.T) {
+	entry := Entry{Message: "Started instance", Labels: map[string]string{"project": "default", "instance": "c1"}}
+	assert.Equal(t, `Started instance instance="c1" project="default"`, syslogMessage(entry))
+
+	entry = Entry{Message: "Started instance"}
+	assert.Equal(t, "Started instance", syslogMessage(entry))
+}
+
+// Entries sent while the server is unreachable are shipped once it comes back.
+func TestSyslogClient_Reconnect(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	address := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	client := newSyslogClient("tcp", address, "lxd", 10*time.Millisecond)
+	defer client.Stop()
+
+	client.Send(Entry{Level: "warn", Message: "Server unreachable"})
+	time.Sleep(50 * time.Millisecond)
+
+	listener, err = net.Listen("tcp", address)
+	require.NoError(t, err)
+	defer listener.Close()
+
+	conn, err := listener.Accept()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(10*time.Second)))
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	require.NoError(t, err)
+	assert.Contains(t, line, "Server unreachable")
+}
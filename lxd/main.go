@@ -1,6 +1,7 @@
 package main
 
 import (
+	"fmt"
 	"math/rand"
 	"os"
 	"time"
@@ -30,6 +31,7 @@ type cmdGlobal struct {
 	flagVersion bool
 
 	flagLogFile    string
+	flagLogFormat  string
 	flagLogDebug   bool
 	flagLogSyslog  bool
 	flagLogTrace   []string
@@ -64,7 +66,16 @@ func (c *cmdGlobal) Run(cmd *cobra.Command, args []string) error {
 		syslog = "lxd"
 	}
 
-	log, err := logging.GetLogger(syslog, c.flagLogFile, c.flagLogVerbose, c.flagLogDebug, events.NewEventHandler())
+	var log logger.Logger
+	switch c.flagLogFormat {
+	case "logfmt":
+		log, err = logging.GetLogger(syslog, c.flagLogFile, c.flagLogVerbose, c.flagLogDebug, events.NewEventHandler())
+	case "json":
+		log, err = logging.GetJSONLogger(syslog, c.flagLogFile, c.flagLogVerbose, c.flagLogDebug, events.NewEventHandler())
+	default:
+		return fmt.Errorf("Invalid log format %q", c.flagLogFormat)
+	}
+
 	if err != nil {
 		return err
 	}
@@ -101,6 +112,7 @@ func main() {
 	app.PersistentFlags().BoolVar(&globalCmd.flagVersion, "version", false, "Print version number")
 	app.PersistentFlags().BoolVarP(&globalCmd.flagHelp, "help", "h", false, "Print help")
 	app.PersistentFlags().StringVar(&globalCmd.flagLogFile, "logfile", "", "Path to the log file"+"``")
+	app.PersistentFlags().StringVar(&globalCmd.flagLogFormat, "logformat", "logfmt", "Format of the log messages (logfmt or json)"+"``")
 	app.PersistentFlags().BoolVar(&globalCmd.flagLogSyslog, "syslog", false, "Log to syslog")
 	app.PersistentFlags().StringArrayVar(&globalCmd.flagLogTrace, "trace", []string{}, "Log tracing targets"+"``")
 	app.PersistentFlags().BoolVarP(&globalCmd.flagLogDebug, "debug", "d", false, "Show all debug messages")
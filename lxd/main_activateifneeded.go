@@ -140,6 +140,13 @@ func (c *cmdActivateifneeded) Run(cmd *cobra.Command, args []string) error {
 			_, err := lxd.ConnectLXDUnix("", nil)
 			return err
 		}
+
+		// Check for scheduled instance starts
+		if config["schedule.start"] != "" {
+			logger.Debugf("Daemon has scheduled instance starts, activating...")
+			_, err := lxd.ConnectLXDUnix("", nil)
+			return err
+		}
 	}
 
 	// Check for scheduled volume snapshots
@@ -601,6 +601,10 @@ func canUseCloseRange() bool {
 	return bool(C.close_range_aware)
 }
 
+func canUseTimeNamespace() bool {
+	return shared.PathExists("/proc/self/ns/time")
+}
+
 func canUsePidFdSetns() bool {
 	return bool(C.pidfd_setns_aware)
 }
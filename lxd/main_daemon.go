@@ -11,6 +11,7 @@ import (
 	"golang.org/x/sys/unix"
 
 	"github.com/lxc/lxd/lxd/sys"
+	"github.com/lxc/lxd/shared"
 	log "github.com/lxc/lxd/shared/log15"
 	"github.com/lxc/lxd/shared/logger"
 )
@@ -19,7 +20,8 @@ type cmdDaemon struct {
 	global *cmdGlobal
 
 	// Common options
-	flagGroup string
+	flagGroup    string
+	flagRootless bool
 }
 
 func (c *cmdDaemon) Command() *cobra.Command {
@@ -34,9 +36,14 @@ func (c *cmdDaemon) Command() *cobra.Command {
 
   There are however a number of subcommands that let you interact directly with
   the local LXD daemon and which may not be performed through the REST API alone.
+
+  With --rootless, the daemon can be started by an unprivileged user. It then runs
+  in a user namespace mapped to the user's subordinate ids, with its data stored
+  in ~/.local/share/lxd unless LXD_DIR is set.
 `
 	cmd.RunE = c.Run
 	cmd.Flags().StringVar(&c.flagGroup, "group", "", "The group of users that will be allowed to talk to LXD"+"``")
+	cmd.Flags().BoolVar(&c.flagRootless, "rootless", false, "Run as an unprivileged user in a user namespace")
 
 	return cmd
 }
@@ -46,11 +53,27 @@ func (c *cmdDaemon) Run(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("unknown command \"%s\" for \"%s\"", args[0], cmd.CommandPath())
 	}
 
-	// Only root should run this
+	// Wait for the user namespace to be set up when re-executed rootless.
+	if os.Getenv(rootlessSyncEnv) != "" {
+		err := rootlessWait()
+		if err != nil {
+			return err
+		}
+	}
+
+	// Only root should run this, unless running rootless.
 	if os.Geteuid() != 0 {
+		if c.flagRootless {
+			return c.runRootless()
+		}
+
 		return fmt.Errorf("This must be run as root")
 	}
 
+	if c.flagRootless && !shared.RunningInUserNS() {
+		return fmt.Errorf("--rootless can only be used by an unprivileged user")
+	}
+
 	neededPrograms := []string{"ip", "rsync", "setfattr", "tar", "unsquashfs", "xz"}
 	for _, p := range neededPrograms {
 		_, err := exec.LookPath(p)
@@ -62,7 +85,10 @@ func (c *cmdDaemon) Run(cmd *cobra.Command, args []string) error {
 	conf := defaultDaemonConfig()
 	conf.Group = c.flagGroup
 	conf.Trace = c.global.flagLogTrace
+	conf.Rootless = c.flagRootless
+	conf.RootlessNetwork = c.flagRootless && rootlessNetwork()
 	d := newDaemon(conf, sys.DefaultOS())
+	d.os.Rootless = conf.Rootless
 
 	err := d.Init()
 	if err != nil {
@@ -75,8 +101,18 @@ func (c *cmdDaemon) Run(cmd *cobra.Command, args []string) error {
 	signal.Notify(ch, unix.SIGQUIT)
 	signal.Notify(ch, unix.SIGTERM)
 
-	chIgnore := make(chan os.Signal, 1)
-	signal.Notify(chIgnore, unix.SIGHUP)
+	// SIGHUP re-reads the server certificate from disk.
+	chReload := make(chan os.Signal, 1)
+	signal.Notify(chReload, unix.SIGHUP)
+	go func() {
+		for sig := range chReload {
+			logger.Infof("Received '%s signal', reloading the server certificate", sig)
+			err := d.reloadCertificate()
+			if err != nil {
+				logger.Error("Failed to reload the server certificate", log.Ctx{"err": err})
+			}
+		}
+	}()
 
 	s := d.State()
 
@@ -117,7 +153,7 @@ func (c *cmdDaemon) Run(cmd *cobra.Command, args []string) error {
 			logger.Infof("Received '%s signal', waiting for all operations to finish", sig)
 			cleanStop()
 
-			instancesShutdown(s)
+			instancesShutdown(s, instancesShutdownAction(s), 0)
 			networkShutdown(s)
 		} else if sig == unix.SIGTERM {
 			logger.Infof("Received '%s signal', waiting for all operations to finish", sig)
@@ -129,9 +165,19 @@ func (c *cmdDaemon) Run(cmd *cobra.Command, args []string) error {
 
 	case <-d.shutdownChan:
 		logger.Infof("Asked to shutdown by API, waiting for all operations to finish")
+		shutdownStart := time.Now()
 		cleanStop()
 
-		instancesShutdown(s)
+		// Leave the remaining time to the instances if a timeout was requested.
+		timeout := d.shutdownTimeout
+		if timeout > 0 {
+			timeout -= time.Since(shutdownStart)
+			if timeout <= 0 {
+				timeout = time.Nanosecond
+			}
+		}
+
+		instancesShutdown(s, instancesShutdownAction(s), timeout)
 		networkShutdown(s)
 	}
 
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/lxc/lxd/lxd/revert"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/idmap"
+	"github.com/lxc/lxd/shared/logger"
+)
+
+// rootlessSyncEnv is set in the environment of the daemon re-executed in a user namespace. Its file
+// descriptor 3 gets closed by the parent once the ID maps of the namespace are in place.
+const rootlessSyncEnv = "LXD_ROOTLESS_SYNC"
+
+// rootlessNetworkEnv is set in the environment of the daemon re-executed in a user namespace when it also
+// has its own network namespace, set up by slirp4netns.
+const rootlessNetworkEnv = "LXD_ROOTLESS_NETWORK"
+
+// rootlessNetwork returns whether the re-executed daemon has its own network namespace.
+func rootlessNetwork() bool {
+	network := os.Getenv(rootlessNetworkEnv) != ""
+	os.Unsetenv(rootlessNetworkEnv)
+
+	return network
+}
+
+// rootlessRestrictions returns the functionality which isn't available to the daemon, as reported in the
+// server environment.
+func rootlessRestrictions(config *DaemonConfig) []string {
+	if !config.Rootless {
+		return nil
+	}
+
+	restrictions := []string{"privileged_containers", "virtual_machines", "host_devices"}
+	if !config.RootlessNetwork {
+		restrictions = append(restrictions, "networks")
+	}
+
+	return restrictions
+}
+
+// rootlessWait blocks the re-executed daemon until its user namespace is ready.
+func rootlessWait() error {
+	os.Unsetenv(rootlessSyncEnv)
+
+	syncFile := os.NewFile(3, "rootless-sync")
+	defer syncFile.Close()
+
+	_, err := ioutil.ReadAll(syncFile)
+	if err != nil {
+		return fmt.Errorf("Failed to wait for the user namespace setup: %w", err)
+	}
+
+	if os.Geteuid() != 0 {
+		return fmt.Errorf("The user namespace wasn't mapped")
+	}
+
+	return nil
+}
+
+// rootlessDir returns the default LXD directory of an unprivileged user.
+func rootlessDir() (string, error) {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+
+	return filepath.Join(dataHome, "lxd"), nil
+}
+
+// rootlessIDMaps returns the newuidmap and newgidmap arguments mapping root to the current user and the
+// rest of the namespace to the user's subordinate ranges.
+func rootlessIDMaps() ([]string, []string, error) {
+	if !shared.PathExists("/etc/subuid") || !shared.PathExists("/etc/subgid") {
+		return nil, nil, fmt.Errorf("Running rootless requires subordinate ids in /etc/subuid and /etc/subgid")
+	}
+
+	idmapset, err := idmap.DefaultIdmapSet("", "")
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed to get the subordinate ids of the current user: %w", err)
+	}
+
+	return rootlessIDMapArgs(os.Getuid(), os.Getgid(), idmapset)
+}
+
+// rootlessIDMapArgs returns the newuidmap and newgidmap arguments mapping root to the given uid and gid,
+// and the rest of the namespace to the first uid and gid ranges of the subordinate ids.
+func rootlessIDMapArgs(uid int, gid int, idmapset *idmap.IdmapSet) ([]string, []string, error) {
+	uidMap := []string{"0", strconv.Itoa(uid), "1"}
+	gidMap := []string{"0", strconv.Itoa(gid), "1"}
+	for _, entry := range idmapset.Idmap {
+		idMap := []string{"1", strconv.FormatInt(entry.Hostid, 10), strconv.FormatInt(entry.Maprange, 10)}
+		if entry.Isuid && len(uidMap) == 3 {
+			uidMap = append(uidMap, idMap...)
+		}
+
+		if entry.Isgid && len(gidMap) == 3 {
+			gidMap = append(gidMap, idMap...)
+		}
+	}
+
+	if len(uidMap) == 3 || len(gidMap) == 3 {
+		return nil, nil, fmt.Errorf("No subordinate id range of at least 65536 ids found for the current user")
+	}
+
+	return uidMap, gidMap, nil
+}
+
+// runRootless re-executes the daemon as root of a new user namespace owned by the current user. When
+// slirp4netns is available, the daemon also gets its own network namespace with usermode networking.
+func (c *cmdDaemon) runRootless() error {
+	uidMap, gidMap, err := rootlessIDMaps()
+	if err != nil {
+		return err
+	}
+
+	for _, p := range []string{"newuidmap", "newgidmap"} {
+		_, err := exec.LookPath(p)
+		if err != nil {
+			return fmt.Errorf("Running rootless requires %q: %w", p, err)
+		}
+	}
+
+	if os.Getenv("LXD_DIR") == "" {
+		dir, err := rootlessDir()
+		if err != nil {
+			return err
+		}
+
+		err = os.MkdirAll(dir, 0711)
+		if err != nil {
+			return err
+		}
+
+		os.Setenv("LXD_DIR", dir)
+	}
+
+	cloneFlags := unix.CLONE_NEWUSER | unix.CLONE_NEWNS
+	slirp4netns, err := exec.LookPath("slirp4netns")
+	if err == nil {
+		cloneFlags |= unix.CLONE_NEWNET
+	} else {
+		logger.Warn("slirp4netns isn't available, networks won't be usable")
+	}
+
+	syncReader, syncWriter, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+
+	defer syncWriter.Close()
+
+	cmd := exec.Command("/proc/self/exe", os.Args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.ExtraFiles = []*os.File{syncReader}
+	cmd.Env = append(os.Environ(), rootlessSyncEnv+"=1")
+	if cloneFlags&unix.CLONE_NEWNET != 0 {
+		cmd.Env = append(cmd.Env, rootlessNetworkEnv+"=1")
+	}
+	cmd.SysProcAttr = &syscall.SysProcAttr{Cloneflags: uintptr(cloneFlags)}
+
+	err = cmd.Start()
+	syncReader.Close()
+	if err != nil {
+		return fmt.Errorf("Failed to start the daemon in a user namespace: %w", err)
+	}
+
+	revert := revert.New()
+	defer revert.Fail()
+
+	revert.Add(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+
+	pid := strconv.Itoa(cmd.Process.Pid)
+
+	out, err := shared.RunCommand("newuidmap", append([]string{pid}, uidMap...)...)
+	if err != nil {
+		return fmt.Errorf("Failed to map the user namespace uids: %s", out)
+	}
+
+	out, err = shared.RunCommand("newgidmap", append([]string{pid}, gidMap...)...)
+	if err != nil {
+		return fmt.Errorf("Failed to map the user namespace gids: %s", out)
+	}
+
+	// Provide usermode networking to the daemon's network namespace.
+	if cloneFlags&unix.CLONE_NEWNET != 0 {
+		readyReader, readyWriter, err := os.Pipe()
+		if err != nil {
+			return err
+		}
+
+		slirp := exec.Command(slirp4netns, "--configure", "--mtu=65520", "--disable-host-loopback", "--ready-fd=3", pid, "tap0")
+		slirp.ExtraFiles = []*os.File{readyWriter}
+
+		err = slirp.Start()
+		readyWriter.Close()
+		if err != nil {
+			readyReader.Close()
+			return fmt.Errorf("Failed to start slirp4netns: %w", err)
+		}
+
+		defer func() {
+			slirp.Process.Kill()
+			slirp.Wait()
+		}()
+
+		// Wait for the network namespace to be configured.
+		buf := make([]byte, 1)
+		_, err = readyReader.Read(buf)
+		readyReader.Close()
+		if err != nil {
+			return fmt.Errorf("Failed to wait for slirp4netns: %w", err)
+		}
+	}
+
+	// Let the daemon proceed.
+	syncWriter.Close()
+	revert.Success()
+
+	// Forward the signals handled by the daemon.
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, unix.SIGPWR, unix.SIGINT, unix.SIGQUIT, unix.SIGTERM, unix.SIGHUP)
+	go func() {
+		for sig := range ch {
+			cmd.Process.Signal(sig)
+		}
+	}()
+
+	err = cmd.Wait()
+	signal.Stop(ch)
+
+	return err
+}
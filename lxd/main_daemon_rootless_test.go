@@ -0,0 +1,58 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lxc/lxd/shared/idmap"
+)
+
+func TestRootlessDir(t *testing.T) {
+	defer os.Setenv("XDG_DATA_HOME", os.Getenv("XDG_DATA_HOME"))
+	defer os.Setenv("HOME", os.Getenv("HOME"))
+
+	os.Setenv("XDG_DATA_HOME", "/data")
+	dir, err := rootlessDir()
+	require.NoError(t, err)
+	assert.Equal(t, "/data/lxd", dir)
+
+	os.Setenv("XDG_DATA_HOME", "")
+	os.Setenv("HOME", "/home/user")
+	dir, err = rootlessDir()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("/home/user", ".local", "share", "lxd"), dir)
+}
+
+func TestRootlessIDMapArgs(t *testing.T) {
+	idmapset := &idmap.IdmapSet{Idmap: []idmap.IdmapEntry{
+		{Isuid: true, Hostid: 100000, Nsid: 0, Maprange: 65536},
+		{Isuid: true, Hostid: 300000, Nsid: 0, Maprange: 65536},
+		{Isgid: true, Hostid: 200000, Nsid: 0, Maprange: 65536},
+	}}
+
+	uidMap, gidMap, err := rootlessIDMapArgs(1000, 1001, idmapset)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"0", "1000", "1", "1", "100000", "65536"}, uidMap)
+	assert.Equal(t, []string{"0", "1001", "1", "1", "200000", "65536"}, gidMap)
+
+	idmapset.Idmap = idmapset.Idmap[:2]
+	_, _, err = rootlessIDMapArgs(1000, 1001, idmapset)
+	assert.Error(t, err)
+}
+
+func TestRootlessRestrictions(t *testing.T) {
+	assert.Nil(t, rootlessRestrictions(&DaemonConfig{}))
+	assert.Equal(t, []string{"privileged_containers", "virtual_machines", "host_devices", "networks"}, rootlessRestrictions(&DaemonConfig{Rootless: true}))
+	assert.Equal(t, []string{"privileged_containers", "virtual_machines", "host_devices"}, rootlessRestrictions(&DaemonConfig{Rootless: true, RootlessNetwork: true}))
+}
+
+func TestRootlessNetwork(t *testing.T) {
+	os.Setenv(rootlessNetworkEnv, "1")
+	assert.True(t, rootlessNetwork())
+	assert.Equal(t, "", os.Getenv(rootlessNetworkEnv))
+	assert.False(t, rootlessNetwork())
+}
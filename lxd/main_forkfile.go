@@ -2,8 +2,12 @@ package main
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/spf13/cobra"
+
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/idmap"
 )
 
 /*
@@ -405,6 +409,29 @@ void forkremovefile(char *rootfs, int pidfd, int ns_fd)
 	_exit(0);
 }
 
+// Attach to the container's filesystem and return to the Go code which handles the tar stream.
+void forkattachfile(char *rootfs, int pidfd, int ns_fd)
+{
+	if (ns_fd >= 0) {
+		attach_userns_fd(ns_fd);
+
+		if (!change_namespaces(pidfd, ns_fd, CLONE_NEWNS)) {
+			error("error: setns");
+			_exit(1);
+		}
+	} else {
+		if (chroot(rootfs) < 0) {
+			error("error: chroot");
+			_exit(1);
+		}
+
+		if (chdir("/") < 0) {
+			error("error: chdir");
+			_exit(1);
+		}
+	}
+}
+
 void forkfile(void)
 {
 	int ns_fd = -EBADF, pidfd = -EBADF;
@@ -449,6 +476,8 @@ void forkfile(void)
 		forkcheckfile(rootfs, pidfd, ns_fd);
 	} else if (strcmp(command, "remove") == 0) {
 		forkremovefile(rootfs, pidfd, ns_fd);
+	} else if (strcmp(command, "tar-pull") == 0 || strcmp(command, "tar-push") == 0) {
+		forkattachfile(rootfs, pidfd, ns_fd);
 	}
 }
 */
@@ -500,6 +529,20 @@ func (c *cmdForkfile) Command() *cobra.Command {
 	cmdRemove.RunE = c.Run
 	cmd.AddCommand(cmdRemove)
 
+	// tar-pull
+	cmdTarPull := &cobra.Command{}
+	cmdTarPull.Use = "tar-pull <rootfs> <PID> <PidFd> <path> <idmap>"
+	cmdTarPull.Args = cobra.ExactArgs(5)
+	cmdTarPull.RunE = c.RunTarPull
+	cmd.AddCommand(cmdTarPull)
+
+	// tar-push
+	cmdTarPush := &cobra.Command{}
+	cmdTarPush.Use = "tar-push <rootfs> <PID> <PidFd> <path> <idmap>"
+	cmdTarPush.Args = cobra.ExactArgs(5)
+	cmdTarPush.RunE = c.RunTarPush
+	cmd.AddCommand(cmdTarPush)
+
 	// Workaround for subcommand usage errors. See: https://github.com/spf13/cobra/issues/706
 	cmd.Args = cobra.NoArgs
 	cmd.Run = func(cmd *cobra.Command, args []string) { cmd.Usage() }
@@ -509,3 +552,35 @@ func (c *cmdForkfile) Command() *cobra.Command {
 func (c *cmdForkfile) Run(cmd *cobra.Command, args []string) error {
 	return fmt.Errorf("This command should have been intercepted in cgo")
 }
+
+// RunTarPull writes the tree at path as a tar stream on stdout.
+// The cgo code has already attached to the container's filesystem.
+func (c *cmdForkfile) RunTarPull(cmd *cobra.Command, args []string) error {
+	idmapset, err := idmap.JSONUnmarshal(args[4])
+	if err != nil {
+		return err
+	}
+
+	var idShift func(uid int64, gid int64) (int64, int64)
+	if idmapset != nil {
+		idShift = idmapset.ShiftFromNs
+	}
+
+	return shared.TarTree(os.Stdout, args[3], idShift)
+}
+
+// RunTarPush extracts the tar stream read from stdin into path.
+// The cgo code has already attached to the container's filesystem.
+func (c *cmdForkfile) RunTarPush(cmd *cobra.Command, args []string) error {
+	idmapset, err := idmap.JSONUnmarshal(args[4])
+	if err != nil {
+		return err
+	}
+
+	var idShift func(uid int64, gid int64) (int64, int64)
+	if idmapset != nil {
+		idShift = idmapset.ShiftIntoNs
+	}
+
+	return shared.UntarTree(os.Stdin, args[3], idShift)
+}
@@ -3,12 +3,15 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/spf13/cobra"
 
 	// Used by cgo
 	_ "github.com/lxc/lxd/lxd/include"
 
+	"github.com/lxc/lxd/lxd/healthcheck"
 	"github.com/lxc/lxd/lxd/ip"
 	"github.com/lxc/lxd/shared/netutils"
 )
@@ -72,6 +75,15 @@ void forkdonetdetach(char *file) {
 	// Jump back to Go for the rest
 }
 
+void forkdonetprobe(char *file) {
+	if (dosetns_file(file, "net") < 0) {
+		fprintf(stderr, "Failed setns to container network namespace: %s\n", strerror(errno));
+		_exit(1);
+	}
+
+	// Jump back to Go for the rest
+}
+
 void forknet(void)
 {
 	char *command = NULL;
@@ -115,6 +127,9 @@ void forknet(void)
 
 	if (strcmp(command, "detach") == 0)
 		forkdonetdetach(cur);
+
+	if (strcmp(command, "probe") == 0)
+		forkdonetprobe(cur);
 }
 */
 import "C"
@@ -150,6 +165,13 @@ func (c *cmdForknet) Command() *cobra.Command {
 	cmdDetach.RunE = c.RunDetach
 	cmd.AddCommand(cmdDetach)
 
+	// probe
+	cmdProbe := &cobra.Command{}
+	cmdProbe.Use = "probe <netns file> <type> <address> <timeout>"
+	cmdProbe.Args = cobra.ExactArgs(4)
+	cmdProbe.RunE = c.RunProbe
+	cmd.AddCommand(cmdProbe)
+
 	// Workaround for subcommand usage errors. See: https://github.com/spf13/cobra/issues/706
 	cmd.Args = cobra.NoArgs
 	cmd.Run = func(cmd *cobra.Command, args []string) { cmd.Usage() }
@@ -172,6 +194,15 @@ func (c *cmdForknet) RunInfo(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+func (c *cmdForknet) RunProbe(cmd *cobra.Command, args []string) error {
+	timeout, err := strconv.ParseInt(args[3], 10, 64)
+	if err != nil {
+		return err
+	}
+
+	return healthcheck.Probe(args[1], args[2], time.Duration(timeout)*time.Second)
+}
+
 func (c *cmdForknet) RunDetach(cmd *cobra.Command, args []string) error {
 	lxdPID := args[1]
 	ifName := args[2]
@@ -1,8 +1,11 @@
 package main
 
 import (
+	"bytes"
+	"crypto/subtle"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net"
 	"os"
 	"os/signal"
@@ -290,7 +293,7 @@ type udpSession struct {
 func (c *cmdForkproxy) Command() *cobra.Command {
 	// Main subcommand
 	cmd := &cobra.Command{}
-	cmd.Use = "forkproxy <listen PID> <listen PidFd> <listen address> <connect PID> <connect PidFd> <connect address> <log path> <pid path> <listen gid> <listen uid> <listen mode> <security gid> <security uid>"
+	cmd.Use = "forkproxy <listen PID> <listen PidFd> <listen address> <connect PID> <connect PidFd> <connect address> <log path> <pid path> <listen gid> <listen uid> <listen mode> <security gid> <security uid> <proxy protocol> <token fd>"
 	cmd.Short = "Setup network connection proxying"
 	cmd.Long = `Description:
   Setup network connection proxying
@@ -299,7 +302,7 @@ func (c *cmdForkproxy) Command() *cobra.Command {
   container, connecting one side to the host and the other to the
   container.
 `
-	cmd.Args = cobra.ExactArgs(12)
+	cmd.Args = cobra.ExactArgs(13)
 	cmd.RunE = c.Run
 	cmd.Hidden = true
 
@@ -316,7 +319,7 @@ func rearmUDPFd(epFd C.int, connFd C.int) {
 	}
 }
 
-func listenerInstance(epFd C.int, lAddr *deviceConfig.ProxyAddress, cAddr *deviceConfig.ProxyAddress, connFd C.int, lStruct *lStruct, proxy bool) error {
+func listenerInstance(epFd C.int, lAddr *deviceConfig.ProxyAddress, cAddr *deviceConfig.ProxyAddress, connFd C.int, lStruct *lStruct, proxy bool, token string) error {
 	// Single or multiple port -> single port
 	connectAddr := cAddr.Address
 	if cAddr.ConnType != "unix" {
@@ -361,6 +364,64 @@ func listenerInstance(epFd C.int, lAddr *deviceConfig.ProxyAddress, cAddr *devic
 		return err
 	}
 
+	if token != "" {
+		// Authenticate the client without holding up the other connections.
+		go func() {
+			err := proxyAuthenticate(srcConn, token)
+			if err != nil {
+				srcConn.Close()
+				fmt.Printf("Warning: Failed to authenticate client %q: %v\n", srcConn.RemoteAddr(), err)
+				return
+			}
+
+			err = proxyForward(srcConn, lAddr, cAddr, connectAddr, proxy)
+			if err != nil {
+				fmt.Printf("Warning: Failed to prepare new listener instance: %s\n", err)
+			}
+		}()
+
+		return nil
+	}
+
+	return proxyForward(srcConn, lAddr, cAddr, connectAddr, proxy)
+}
+
+// proxyAuthenticate checks the token sent by the client on its own line, ahead of the data to forward.
+func proxyAuthenticate(conn net.Conn, token string) error {
+	err := conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+	if err != nil {
+		return err
+	}
+
+	// Read a byte at a time to leave the data following the token to be forwarded.
+	line := make([]byte, 0, len(token)+1)
+	buf := make([]byte, 1)
+	for {
+		_, err := conn.Read(buf)
+		if err != nil {
+			return err
+		}
+
+		if buf[0] == '\n' {
+			break
+		}
+
+		if len(line) == cap(line) {
+			return fmt.Errorf("Invalid token")
+		}
+
+		line = append(line, buf[0])
+	}
+
+	if subtle.ConstantTimeCompare(bytes.TrimSuffix(line, []byte("\r")), []byte(token)) != 1 {
+		return fmt.Errorf("Invalid token")
+	}
+
+	return conn.SetReadDeadline(time.Time{})
+}
+
+// proxyForward connects to the target and relays the data of the client connection to it.
+func proxyForward(srcConn net.Conn, lAddr *deviceConfig.ProxyAddress, cAddr *deviceConfig.ProxyAddress, connectAddr string, proxy bool) error {
 	dstConn, err := net.Dial(cAddr.ConnType, connectAddr)
 	if err != nil {
 		srcConn.Close()
@@ -418,7 +479,7 @@ func (c *cmdForkproxy) Run(cmd *cobra.Command, args []string) error {
 	}
 
 	// Quick checks.
-	if len(args) != 12 {
+	if len(args) != 13 {
 		cmd.Help()
 
 		if len(args) == 0 {
@@ -596,6 +657,25 @@ func (c *cmdForkproxy) Run(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	// Read the token clients have to authenticate with, passed through a pipe to keep it out of the
+	// command line.
+	token := ""
+	if args[12] != "" {
+		tokenFd, err := strconv.Atoi(args[12])
+		if err != nil {
+			return err
+		}
+
+		tokenFile := os.NewFile(uintptr(tokenFd), "token")
+		data, err := ioutil.ReadAll(tokenFile)
+		tokenFile.Close()
+		if err != nil {
+			return fmt.Errorf("Failed reading the token: %w", err)
+		}
+
+		token = string(data)
+	}
+
 	// Drop privilege if requested
 	gid := uint64(0)
 	if args[9] != "" {
@@ -687,7 +767,7 @@ func (c *cmdForkproxy) Run(cmd *cobra.Command, args []string) error {
 				continue
 			}
 
-			err := listenerInstance(epFd, lAddr, cAddr, curFd, srcConn, args[11] == "true")
+			err := listenerInstance(epFd, lAddr, cAddr, curFd, srcConn, args[11] == "true", token)
 			if err != nil {
 				fmt.Printf("Warning: Failed to prepare new listener instance: %s\n", err)
 			}
@@ -35,6 +35,7 @@ type cmdInit struct {
 	flagMinimal bool
 	flagPreseed bool
 	flagDump    bool
+	flagDryRun  bool
 
 	flagNetworkAddress  string
 	flagNetworkPort     int
@@ -56,7 +57,7 @@ func (c *cmdInit) Command() *cobra.Command {
   init --auto [--network-address=IP] [--network-port=8443] [--storage-backend=dir]
               [--storage-create-device=DEVICE] [--storage-create-loop=SIZE]
               [--storage-pool=POOL] [--trust-password=PASSWORD]
-  init --preseed
+  init --preseed [--dry-run]
   init --dump
 `
 	cmd.RunE = c.Run
@@ -64,6 +65,7 @@ func (c *cmdInit) Command() *cobra.Command {
 	cmd.Flags().BoolVar(&c.flagMinimal, "minimal", false, "Minimal configuration (non-interactive)")
 	cmd.Flags().BoolVar(&c.flagPreseed, "preseed", false, "Pre-seed mode, expects YAML config from stdin")
 	cmd.Flags().BoolVar(&c.flagDump, "dump", false, "Dump YAML config to stdout")
+	cmd.Flags().BoolVar(&c.flagDryRun, "dry-run", false, "Validate the pre-seed without applying it")
 
 	cmd.Flags().StringVar(&c.flagNetworkAddress, "network-address", "", "Address to bind LXD to (default: none)"+"``")
 	cmd.Flags().IntVar(&c.flagNetworkPort, "network-port", -1, fmt.Sprintf("Port to bind LXD to (default: %d)"+"``", shared.HTTPSDefaultPort))
@@ -97,6 +99,10 @@ func (c *cmdInit) Run(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("Configuration flags require --auto")
 	}
 
+	if c.flagDryRun && !c.flagPreseed {
+		return fmt.Errorf("--dry-run requires --preseed")
+	}
+
 	if c.flagDump && (c.flagAuto || c.flagMinimal ||
 		c.flagPreseed || c.flagNetworkAddress != "" ||
 		c.flagNetworkPort != -1 || c.flagStorageBackend != "" ||
@@ -135,6 +141,10 @@ func (c *cmdInit) Run(cmd *cobra.Command, args []string) error {
 		if err != nil {
 			return err
 		}
+
+		if c.flagDryRun {
+			return c.ValidatePreseed(d, config)
+		}
 	}
 
 	// Auto mode
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"fmt"
 	"io/ioutil"
 	"os"
 
@@ -9,6 +10,7 @@ import (
 	"gopkg.in/yaml.v2"
 
 	"github.com/lxc/lxd/client"
+	"github.com/lxc/lxd/shared"
 )
 
 func (c *cmdInit) RunPreseed(cmd *cobra.Command, args []string, d lxd.InstanceServer) (*cmdInitData, error) {
@@ -27,3 +29,37 @@ func (c *cmdInit) RunPreseed(cmd *cobra.Command, args []string, d lxd.InstanceSe
 
 	return &config, nil
 }
+
+// ValidatePreseed asks LXD to validate the preseed without applying it and reports all the problems found.
+func (c *cmdInit) ValidatePreseed(d lxd.InstanceServer, config *cmdInitData) error {
+	problems := []string{}
+
+	if config.Cluster != nil && config.Cluster.ClusterCertificatePath != "" && !shared.PathExists(config.Cluster.ClusterCertificatePath) {
+		problems = append(problems, fmt.Sprintf("Cluster: Path %s doesn't exist", config.Cluster.ClusterCertificatePath))
+	}
+
+	resp, _, err := d.RawQuery("POST", "/internal/preseed/validate", config.Node, "")
+	if err != nil {
+		return errors.Wrap(err, "Failed validation request")
+	}
+
+	var res internalPreseedValidateResult
+	err = resp.MetadataAsStruct(&res)
+	if err != nil {
+		return errors.Wrap(err, "Failed parsing validation response")
+	}
+
+	problems = append(problems, res.Errors...)
+	if len(problems) > 0 {
+		fmt.Print("The following problems have been found in the preseed:\n")
+		for _, problem := range problems {
+			fmt.Printf(" - %s\n", problem)
+		}
+
+		return fmt.Errorf("Invalid preseed")
+	}
+
+	fmt.Println("The preseed is valid")
+
+	return nil
+}
@@ -29,8 +29,13 @@ func (c *cmdShutdown) Command() *cobra.Command {
   This will tell LXD to start a clean shutdown of all containers,
   followed by having itself shutdown and exit.
 
+  What happens to the running instances is controlled by the
+  instances.shutdown_action server configuration key.
+
   This can take quite a while as containers can take a long time to
   shutdown, especially if a non-standard timeout was configured for them.
+  When --timeout is passed, LXD cuts the clean shutdown of the instances
+  short so that it completes in time.
 `
 	cmd.RunE = c.Run
 	cmd.Flags().IntVarP(&c.flagTimeout, "timeout", "t", 0, "Number of seconds to wait before giving up"+"``")
@@ -51,6 +56,9 @@ func (c *cmdShutdown) Run(cmd *cobra.Command, args []string) error {
 
 	v := url.Values{}
 	v.Set("force", strconv.FormatBool(c.flagForce))
+	if c.flagTimeout > 0 {
+		v.Set("timeout", strconv.Itoa(c.flagTimeout))
+	}
 
 	_, _, err = d.RawQuery("PUT", fmt.Sprintf("/internal/shutdown?%s", v.Encode()), nil, "")
 	if err != nil && !strings.HasSuffix(err.Error(), ": EOF") {
@@ -0,0 +1,135 @@
+package metrics
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/lxc/lxd/shared/api"
+)
+
+// NewMetricSet returns a new MetricSet. The provided labels are added to all samples of the set.
+func NewMetricSet(labels map[string]string) *MetricSet {
+	out := MetricSet{set: make(map[MetricType][]Sample)}
+
+	if labels != nil {
+		out.labels = labels
+	} else {
+		out.labels = make(map[string]string)
+	}
+
+	return &out
+}
+
+// AddSamples adds samples of the type metricType to the MetricSet.
+func (m *MetricSet) AddSamples(metricType MetricType, samples ...Sample) {
+	for i := 0; i < len(samples); i++ {
+		if samples[i].Labels == nil {
+			samples[i].Labels = make(map[string]string)
+		}
+
+		// Add global labels to samples.
+		for labelName, labelValue := range m.labels {
+			samples[i].Labels[labelName] = labelValue
+		}
+	}
+
+	m.set[metricType] = append(m.set[metricType], samples...)
+}
+
+// Merge merges two MetricSets. Missing labels from m's samples are added to all samples in n.
+func (m *MetricSet) Merge(n *MetricSet) {
+	if n == nil {
+		return
+	}
+
+	for k := range n.set {
+		for _, sample := range n.set[k] {
+			// Copy the labels so the samples of n aren't modified.
+			labels := make(map[string]string, len(sample.Labels))
+			for labelName, labelValue := range sample.Labels {
+				labels[labelName] = labelValue
+			}
+
+			m.AddSamples(k, Sample{Value: sample.Value, Labels: labels})
+		}
+	}
+}
+
+// String returns the MetricSet in the Prometheus text exposition format.
+func (m *MetricSet) String() string {
+	var out strings.Builder
+
+	metricTypes := make([]MetricType, 0, len(m.set))
+	for metricType := range m.set {
+		metricTypes = append(metricTypes, metricType)
+	}
+
+	// Sort by metric type to ensure a stable output.
+	sort.Slice(metricTypes, func(i, j int) bool {
+		return metricTypes[i] < metricTypes[j]
+	})
+
+	for _, metricType := range metricTypes {
+		samples := m.set[metricType]
+		if len(samples) == 0 {
+			continue
+		}
+
+		name := MetricNames[metricType]
+
+		out.WriteString(fmt.Sprintf("%s\n", MetricHeaders[metricType]))
+		out.WriteString(fmt.Sprintf("# TYPE %s %s\n", name, MetricKinds[metricType]))
+
+		for _, sample := range samples {
+			labels := make([]string, 0, len(sample.Labels))
+			for labelName, labelValue := range sample.Labels {
+				labels = append(labels, fmt.Sprintf("%s=%q", labelName, labelValue))
+			}
+
+			sort.Strings(labels)
+
+			if len(labels) > 0 {
+				out.WriteString(fmt.Sprintf("%s{%s} %v\n", name, strings.Join(labels, ","), sample.Value))
+			} else {
+				out.WriteString(fmt.Sprintf("%s %v\n", name, sample.Value))
+			}
+		}
+
+		out.WriteString("\n")
+	}
+
+	return out.String()
+}
+
+// InstanceStateMetrics returns a MetricSet built from the supplied instance state.
+func InstanceStateMetrics(state *api.InstanceState, labels map[string]string) *MetricSet {
+	set := NewMetricSet(labels)
+
+	set.AddSamples(CPUSecondsTotal, Sample{Value: float64(state.CPU.Usage) / 1000000000})
+	set.AddSamples(MemoryUsageBytes, Sample{Value: float64(state.Memory.Usage)})
+	set.AddSamples(MemoryUsagePeakBytes, Sample{Value: float64(state.Memory.UsagePeak)})
+	set.AddSamples(MemorySwapUsageBytes, Sample{Value: float64(state.Memory.SwapUsage)})
+	set.AddSamples(ProcsTotal, Sample{Value: float64(state.Processes)})
+
+	for diskName, disk := range state.Disk {
+		set.AddSamples(DiskUsageBytes, Sample{Value: float64(disk.Usage), Labels: map[string]string{"device": diskName}})
+	}
+
+	for nicName, nic := range state.Network {
+		nicLabels := func() map[string]string {
+			return map[string]string{"device": nicName}
+		}
+
+		set.AddSamples(NetworkReceiveBytesTotal, Sample{Value: float64(nic.Counters.BytesReceived), Labels: nicLabels()})
+		set.AddSamples(NetworkReceiveDropTotal, Sample{Value: float64(nic.Counters.PacketsDroppedInbound), Labels: nicLabels()})
+		set.AddSamples(NetworkReceiveErrsTotal, Sample{Value: float64(nic.Counters.ErrorsReceived), Labels: nicLabels()})
+		set.AddSamples(NetworkReceivePacketsTotal, Sample{Value: float64(nic.Counters.PacketsReceived), Labels: nicLabels()})
+		set.AddSamples(NetworkTransmitBytesTotal, Sample{Value: float64(nic.Counters.BytesSent), Labels: nicLabels()})
+		set.AddSamples(NetworkTransmitDropTotal, Sample{Value: float64(nic.Counters.PacketsDroppedOutbound), Labels: nicLabels()})
+		set.AddSamples(NetworkTransmitErrsTotal, Sample{Value: float64(nic.Counters.ErrorsSent), Labels: nicLabels()})
+		set.AddSamples(NetworkTransmitPacketsTotal, Sample{Value: float64(nic.Counters.PacketsSent), Labels: nicLabels()})
+	}
+
+	return set
+}
@@ -0,0 +1,68 @@
+package metrics_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lxc/lxd/lxd/metrics"
+	"github.com/lxc/lxd/shared/api"
+)
+
+func TestMetricSet_String(t *testing.T) {
+	set := metrics.NewMetricSet(map[string]string{"project": "default", "name": "c1"})
+	set.AddSamples(metrics.MemoryUsageBytes, metrics.Sample{Value: 1024})
+	set.AddSamples(metrics.CPUSecondsTotal, metrics.Sample{Value: 2.5, Labels: map[string]string{"cpu": "0"}})
+
+	expected := `# HELP lxd_cpu_seconds_total The total number of CPU time used in seconds.
+# TYPE lxd_cpu_seconds_total counter
+lxd_cpu_seconds_total{cpu="0",name="c1",project="default"} 2.5
+
+# HELP lxd_memory_usage_bytes The memory used in bytes.
+# TYPE lxd_memory_usage_bytes gauge
+lxd_memory_usage_bytes{name="c1",project="default"} 1024
+
+`
+
+	assert.Equal(t, expected, set.String())
+}
+
+func TestMetricSet_Merge(t *testing.T) {
+	set := metrics.NewMetricSet(nil)
+	set.AddSamples(metrics.GoGoroutines, metrics.Sample{Value: 10})
+
+	other := metrics.NewMetricSet(map[string]string{"name": "c1"})
+	other.AddSamples(metrics.ProcsTotal, metrics.Sample{Value: 3})
+
+	set.Merge(other)
+	set.Merge(nil)
+
+	expected := `# HELP lxd_procs_total The number of running processes.
+# TYPE lxd_procs_total gauge
+lxd_procs_total{name="c1"} 3
+
+# HELP lxd_go_goroutines The number of goroutines in the daemon.
+# TYPE lxd_go_goroutines gauge
+lxd_go_goroutines 10
+
+`
+
+	assert.Equal(t, expected, set.String())
+}
+
+func TestInstanceStateMetrics(t *testing.T) {
+	state := &api.InstanceState{
+		CPU:    api.InstanceStateCPU{Usage: 3000000000},
+		Memory: api.InstanceStateMemory{Usage: 2048},
+		Network: map[string]api.InstanceStateNetwork{
+			"eth0": {Counters: api.InstanceStateNetworkCounters{BytesReceived: 100, BytesSent: 200}},
+		},
+	}
+
+	out := metrics.InstanceStateMetrics(state, map[string]string{"name": "c1"}).String()
+
+	assert.Contains(t, out, `lxd_cpu_seconds_total{name="c1"} 3`+"\n")
+	assert.Contains(t, out, `lxd_memory_usage_bytes{name="c1"} 2048`+"\n")
+	assert.Contains(t, out, `lxd_network_receive_bytes_total{device="eth0",name="c1"} 100`+"\n")
+	assert.Contains(t, out, `lxd_network_transmit_bytes_total{device="eth0",name="c1"} 200`+"\n")
+}
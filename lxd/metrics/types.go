@@ -0,0 +1,131 @@
+package metrics
+
+// MetricType is a numeric code identifying the metric.
+type MetricType int
+
+const (
+	// CPUSecondsTotal represents the total CPU seconds used.
+	CPUSecondsTotal MetricType = iota
+	// DiskUsageBytes represents the used disk space.
+	DiskUsageBytes
+	// MemoryUsageBytes represents the used memory.
+	MemoryUsageBytes
+	// MemoryUsagePeakBytes represents the peak memory usage.
+	MemoryUsagePeakBytes
+	// MemorySwapUsageBytes represents the used swap space.
+	MemorySwapUsageBytes
+	// NetworkReceiveBytesTotal represents the amount of received bytes on a given interface.
+	NetworkReceiveBytesTotal
+	// NetworkReceiveDropTotal represents the amount of received dropped bytes on a given interface.
+	NetworkReceiveDropTotal
+	// NetworkReceiveErrsTotal represents the amount of received errors on a given interface.
+	NetworkReceiveErrsTotal
+	// NetworkReceivePacketsTotal represents the amount of received packets on a given interface.
+	NetworkReceivePacketsTotal
+	// NetworkTransmitBytesTotal represents the amount of transmitted bytes on a given interface.
+	NetworkTransmitBytesTotal
+	// NetworkTransmitDropTotal represents the amount of transmitted dropped bytes on a given interface.
+	NetworkTransmitDropTotal
+	// NetworkTransmitErrsTotal represents the amount of transmitted errors on a given interface.
+	NetworkTransmitErrsTotal
+	// NetworkTransmitPacketsTotal represents the amount of transmitted packets on a given interface.
+	NetworkTransmitPacketsTotal
+	// ProcsTotal represents the number of running processes.
+	ProcsTotal
+	// InstancesTotal represents the number of instances by status.
+	InstancesTotal
+	// OperationsTotal represents the number of running operations.
+	OperationsTotal
+	// WarningsTotal represents the number of active warnings.
+	WarningsTotal
+	// GoGoroutines represents the number of goroutines in the daemon.
+	GoGoroutines
+	// GoHeapAllocBytes represents the bytes of allocated heap objects in the daemon.
+	GoHeapAllocBytes
+	// UptimeSeconds represents the daemon uptime.
+	UptimeSeconds
+)
+
+// MetricNames associates a metric type to its name.
+var MetricNames = map[MetricType]string{
+	CPUSecondsTotal:             "lxd_cpu_seconds_total",
+	DiskUsageBytes:              "lxd_disk_usage_bytes",
+	MemoryUsageBytes:            "lxd_memory_usage_bytes",
+	MemoryUsagePeakBytes:        "lxd_memory_usage_peak_bytes",
+	MemorySwapUsageBytes:        "lxd_memory_swap_usage_bytes",
+	NetworkReceiveBytesTotal:    "lxd_network_receive_bytes_total",
+	NetworkReceiveDropTotal:     "lxd_network_receive_drop_total",
+	NetworkReceiveErrsTotal:     "lxd_network_receive_errs_total",
+	NetworkReceivePacketsTotal:  "lxd_network_receive_packets_total",
+	NetworkTransmitBytesTotal:   "lxd_network_transmit_bytes_total",
+	NetworkTransmitDropTotal:    "lxd_network_transmit_drop_total",
+	NetworkTransmitErrsTotal:    "lxd_network_transmit_errs_total",
+	NetworkTransmitPacketsTotal: "lxd_network_transmit_packets_total",
+	ProcsTotal:                  "lxd_procs_total",
+	InstancesTotal:              "lxd_instances_total",
+	OperationsTotal:             "lxd_operations_total",
+	WarningsTotal:               "lxd_warnings_total",
+	GoGoroutines:                "lxd_go_goroutines",
+	GoHeapAllocBytes:            "lxd_go_heap_alloc_bytes",
+	UptimeSeconds:               "lxd_uptime_seconds",
+}
+
+// MetricHeaders represents the metric headers which contain help messages as specified by OpenMetrics.
+var MetricHeaders = map[MetricType]string{
+	CPUSecondsTotal:             "# HELP lxd_cpu_seconds_total The total number of CPU time used in seconds.",
+	DiskUsageBytes:              "# HELP lxd_disk_usage_bytes The disk space used in bytes.",
+	MemoryUsageBytes:            "# HELP lxd_memory_usage_bytes The memory used in bytes.",
+	MemoryUsagePeakBytes:        "# HELP lxd_memory_usage_peak_bytes The peak memory usage in bytes.",
+	MemorySwapUsageBytes:        "# HELP lxd_memory_swap_usage_bytes The swap space used in bytes.",
+	NetworkReceiveBytesTotal:    "# HELP lxd_network_receive_bytes_total The amount of received bytes on a given interface.",
+	NetworkReceiveDropTotal:     "# HELP lxd_network_receive_drop_total The amount of received dropped packets on a given interface.",
+	NetworkReceiveErrsTotal:     "# HELP lxd_network_receive_errs_total The amount of received errors on a given interface.",
+	NetworkReceivePacketsTotal:  "# HELP lxd_network_receive_packets_total The amount of received packets on a given interface.",
+	NetworkTransmitBytesTotal:   "# HELP lxd_network_transmit_bytes_total The amount of transmitted bytes on a given interface.",
+	NetworkTransmitDropTotal:    "# HELP lxd_network_transmit_drop_total The amount of transmitted dropped packets on a given interface.",
+	NetworkTransmitErrsTotal:    "# HELP lxd_network_transmit_errs_total The amount of transmitted errors on a given interface.",
+	NetworkTransmitPacketsTotal: "# HELP lxd_network_transmit_packets_total The amount of transmitted packets on a given interface.",
+	ProcsTotal:                  "# HELP lxd_procs_total The number of running processes.",
+	InstancesTotal:              "# HELP lxd_instances_total The number of instances on this member by status.",
+	OperationsTotal:             "# HELP lxd_operations_total The number of running operations on this member.",
+	WarningsTotal:               "# HELP lxd_warnings_total The number of active warnings.",
+	GoGoroutines:                "# HELP lxd_go_goroutines The number of goroutines in the daemon.",
+	GoHeapAllocBytes:            "# HELP lxd_go_heap_alloc_bytes The number of bytes of allocated heap objects in the daemon.",
+	UptimeSeconds:               "# HELP lxd_uptime_seconds The daemon uptime in seconds.",
+}
+
+// MetricKinds associates a metric type to its Prometheus kind (counter or gauge).
+var MetricKinds = map[MetricType]string{
+	CPUSecondsTotal:             "counter",
+	DiskUsageBytes:              "gauge",
+	MemoryUsageBytes:            "gauge",
+	MemoryUsagePeakBytes:        "gauge",
+	MemorySwapUsageBytes:        "gauge",
+	NetworkReceiveBytesTotal:    "counter",
+	NetworkReceiveDropTotal:     "counter",
+	NetworkReceiveErrsTotal:     "counter",
+	NetworkReceivePacketsTotal:  "counter",
+	NetworkTransmitBytesTotal:   "counter",
+	NetworkTransmitDropTotal:    "counter",
+	NetworkTransmitErrsTotal:    "counter",
+	NetworkTransmitPacketsTotal: "counter",
+	ProcsTotal:                  "gauge",
+	InstancesTotal:              "gauge",
+	OperationsTotal:             "gauge",
+	WarningsTotal:               "gauge",
+	GoGoroutines:                "gauge",
+	GoHeapAllocBytes:            "gauge",
+	UptimeSeconds:               "gauge",
+}
+
+// Sample represents a single sample of a metric.
+type Sample struct {
+	Value  float64
+	Labels map[string]string
+}
+
+// MetricSet represents a set of metrics.
+type MetricSet struct {
+	set    map[MetricType][]Sample
+	labels map[string]string
+}
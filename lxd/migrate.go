@@ -28,14 +28,14 @@ import (
 
 type migrationFields struct {
 	controlSecret string
-	controlConn   *websocket.Conn
+	controlConn   migration.Conn
 	controlLock   sync.Mutex
 
 	criuSecret string
 	criuConn   *websocket.Conn
 
 	fsSecret string
-	fsConn   *websocket.Conn
+	fsConn   migration.Conn
 
 	// container specific fields
 	live         bool
@@ -130,10 +130,54 @@ func (c *migrationFields) controlChannel() <-chan migration.MigrationControl {
 	return ch
 }
 
+// migrationAccept upgrades the request to the migration websocket stored in conn, making it resumable
+// if the peer asked for it. If the peer is reconnecting after a network interruption, the existing
+// connection is resumed and true is returned.
+func migrationAccept(conn *migration.Conn, r *http.Request, w http.ResponseWriter) (bool, error) {
+	var header http.Header
+	resumable := r.Header.Get(migration.ResumeHeader) != ""
+	if resumable {
+		header = http.Header{}
+		header.Set(migration.ResumeHeader, "1")
+	}
+
+	c, err := shared.WebsocketUpgrader.Upgrade(w, r, header)
+	if err != nil {
+		return false, err
+	}
+
+	existing, ok := (*conn).(*migration.ResumableConn)
+	if ok && resumable {
+		err = existing.Attach(c)
+		if err != nil {
+			c.Close()
+			return false, err
+		}
+
+		return true, nil
+	}
+
+	if resumable {
+		*conn = migration.NewResumableConn(c, nil)
+	} else {
+		*conn = c
+	}
+
+	return false, nil
+}
+
 type migrationSourceWs struct {
 	migrationFields
 
 	allConnected chan bool
+	cancelled    chan struct{}
+	cancelOnce   sync.Once
+}
+
+// Cancel aborts the migration by disconnecting from the target, causing both ends to roll back.
+func (s *migrationSourceWs) Cancel() {
+	s.cancelOnce.Do(func() { close(s.cancelled) })
+	s.disconnect()
 }
 
 func (s *migrationSourceWs) Metadata() interface{} {
@@ -155,15 +199,24 @@ func (s *migrationSourceWs) Connect(op *operations.Operation, r *http.Request, w
 		return fmt.Errorf("missing secret")
 	}
 
-	var conn **websocket.Conn
-
 	switch secret {
 	case s.controlSecret:
-		conn = &s.controlConn
+		resumed, err := migrationAccept(&s.controlConn, r, w)
+		if err != nil || resumed {
+			return err
+		}
 	case s.criuSecret:
-		conn = &s.criuConn
+		c, err := shared.WebsocketUpgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return err
+		}
+
+		s.criuConn = c
 	case s.fsSecret:
-		conn = &s.fsConn
+		resumed, err := migrationAccept(&s.fsConn, r, w)
+		if err != nil || resumed {
+			return err
+		}
 	default:
 		// If we didn't find the right secret, the user provided a bad
 		// one, which 403, not 404, since this operation actually
@@ -171,13 +224,6 @@ func (s *migrationSourceWs) Connect(op *operations.Operation, r *http.Request, w
 		return os.ErrPermission
 	}
 
-	c, err := shared.WebsocketUpgrader.Upgrade(w, r, nil)
-	if err != nil {
-		return err
-	}
-
-	*conn = c
-
 	if s.controlConn != nil && (!s.live || s.criuConn != nil) && s.fsConn != nil {
 		s.allConnected <- true
 	}
@@ -212,30 +258,25 @@ func (s *migrationSourceWs) ConnectTarget(certificate string, operation string,
 	}
 
 	for name, secret := range websockets {
-		var conn **websocket.Conn
+		query := url.Values{"secret": []string{secret}}
+
+		// The URL is a https URL to the operation, mangle to be a wss URL to the secret
+		wsUrl := fmt.Sprintf("wss://%s/websocket?%s", strings.TrimPrefix(operation, "https://"), query.Encode())
 
 		switch name {
 		case "control":
-			conn = &s.controlConn
+			s.controlConn, err = migration.DialResumable(&dialer, wsUrl)
 		case "fs":
-			conn = &s.fsConn
+			s.fsConn, err = migration.DialResumable(&dialer, wsUrl)
 		case "criu":
-			conn = &s.criuConn
+			s.criuConn, _, err = dialer.Dial(wsUrl, http.Header{})
 		default:
 			return fmt.Errorf("Unknown secret provided: %s", name)
 		}
 
-		query := url.Values{"secret": []string{secret}}
-
-		// The URL is a https URL to the operation, mangle to be a wss URL to the secret
-		wsUrl := fmt.Sprintf("wss://%s/websocket?%s", strings.TrimPrefix(operation, "https://"), query.Encode())
-
-		wsConn, _, err := dialer.Dial(wsUrl, http.Header{})
 		if err != nil {
 			return err
 		}
-
-		*conn = wsConn
 	}
 
 	s.allConnected <- true
@@ -254,8 +295,18 @@ type migrationSink struct {
 	url          string
 	dialer       websocket.Dialer
 	allConnected chan bool
+	cancelled    chan struct{}
+	cancelOnce   sync.Once
 	push         bool
 	refresh      bool
+	poolMap      map[string]string
+}
+
+// Cancel aborts the migration by disconnecting from the source, causing both ends to roll back.
+func (c *migrationSink) Cancel() {
+	c.cancelOnce.Do(func() { close(c.cancelled) })
+	c.src.disconnect()
+	c.dest.disconnect()
 }
 
 type MigrationSinkArgs struct {
@@ -274,6 +325,7 @@ type MigrationSinkArgs struct {
 	Snapshots    []*migration.Snapshot
 
 	// Storage specific fields
+	PoolMap    map[string]string
 	VolumeOnly bool
 	VolumeSize int64
 
@@ -281,13 +333,15 @@ type MigrationSinkArgs struct {
 	RsyncFeatures []string
 }
 
-func (c *migrationSink) connectWithSecret(secret string) (*websocket.Conn, error) {
+func (c *migrationSink) websocketURL(secret string) string {
 	query := url.Values{"secret": []string{secret}}
 
 	// The URL is a https URL to the operation, mangle to be a wss URL to the secret
-	wsUrl := fmt.Sprintf("wss://%s/websocket?%s", strings.TrimPrefix(c.url, "https://"), query.Encode())
+	return fmt.Sprintf("wss://%s/websocket?%s", strings.TrimPrefix(c.url, "https://"), query.Encode())
+}
 
-	conn, _, err := c.dialer.Dial(wsUrl, http.Header{})
+func (c *migrationSink) connectWithSecret(secret string) (*websocket.Conn, error) {
+	conn, _, err := c.dialer.Dial(c.websocketURL(secret), http.Header{})
 	if err != nil {
 		return nil, err
 	}
@@ -295,6 +349,12 @@ func (c *migrationSink) connectWithSecret(secret string) (*websocket.Conn, error
 	return conn, err
 }
 
+// connectResumable connects to the source's websocket for secret, resuming it after network
+// interruptions if the source supports it.
+func (c *migrationSink) connectResumable(secret string) (migration.Conn, error) {
+	return migration.DialResumable(&c.dialer, c.websocketURL(secret))
+}
+
 func (s *migrationSink) Metadata() interface{} {
 	secrets := shared.Jmap{
 		"control": s.dest.controlSecret,
@@ -314,28 +374,30 @@ func (s *migrationSink) Connect(op *operations.Operation, r *http.Request, w htt
 		return fmt.Errorf("missing secret")
 	}
 
-	var conn **websocket.Conn
-
 	switch secret {
 	case s.dest.controlSecret:
-		conn = &s.dest.controlConn
+		resumed, err := migrationAccept(&s.dest.controlConn, r, w)
+		if err != nil || resumed {
+			return err
+		}
 	case s.dest.criuSecret:
-		conn = &s.dest.criuConn
+		c, err := shared.WebsocketUpgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return err
+		}
+
+		s.dest.criuConn = c
 	case s.dest.fsSecret:
-		conn = &s.dest.fsConn
+		resumed, err := migrationAccept(&s.dest.fsConn, r, w)
+		if err != nil || resumed {
+			return err
+		}
 	default:
 		/* If we didn't find the right secret, the user provided a bad one,
 		 * which 403, not 404, since this operation actually exists */
 		return os.ErrPermission
 	}
 
-	c, err := shared.WebsocketUpgrader.Upgrade(w, r, nil)
-	if err != nil {
-		return err
-	}
-
-	*conn = c
-
 	if s.dest.controlConn != nil && (!s.dest.live || s.dest.criuConn != nil) && s.dest.fsConn != nil {
 		s.allConnected <- true
 	}
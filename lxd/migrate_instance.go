@@ -10,6 +10,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/gorilla/websocket"
@@ -30,11 +31,16 @@ import (
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/api"
 	"github.com/lxc/lxd/shared/idmap"
+	log "github.com/lxc/lxd/shared/log15"
 	"github.com/lxc/lxd/shared/logger"
 )
 
 func newMigrationSource(inst instance.Instance, stateful bool, instanceOnly bool) (*migrationSourceWs, error) {
-	ret := migrationSourceWs{migrationFields{instance: inst}, make(chan bool, 1)}
+	ret := migrationSourceWs{
+		migrationFields: migrationFields{instance: inst},
+		allConnected:    make(chan bool, 1),
+		cancelled:       make(chan struct{}),
+	}
 	ret.instanceOnly = instanceOnly
 
 	var err error
@@ -243,13 +249,30 @@ type preDumpLoopArgs struct {
 	dumpDir       string
 	final         bool
 	rsyncFeatures []string
+	iteration     int
+	lastWritten   uint64
+}
+
+// preDumpLoopResult is the outcome of a pre-copy iteration.
+type preDumpLoopResult struct {
+	// Whether this was the last pre-dump.
+	final bool
+
+	// Whether the pre-dump succeeded, otherwise its directory can't be used as the parent of the next dump.
+	dumped bool
+
+	// Number of memory pages written by the pre-dump (dirtied since the previous one).
+	written uint64
 }
 
 // The function preDumpLoop is the main logic behind the pre-copy migration.
 // This function contains the actual pre-dump, the corresponding rsync
 // transfer and it tells the outer loop to abort if the threshold
-// of memory pages transferred by pre-dumping has been reached.
-func (s *migrationSourceWs) preDumpLoop(state *state.State, args *preDumpLoopArgs) (bool, error) {
+// of memory pages transferred by pre-dumping has been reached, if the
+// final dump is expected to fit in the downtime target or if the
+// pre-copy doesn't converge. Should the pre-dump fail, the migration
+// falls back to stop-and-copy of the memory not transferred yet.
+func (s *migrationSourceWs) preDumpLoop(state *state.State, migrateOp *operations.Operation, args *preDumpLoopArgs) (*preDumpLoopResult, error) {
 	// Do a CRIU pre-dump
 	criuMigrationArgs := instance.CriuMigrationArgs{
 		Cmd:          liblxc.MIGRATE_PRE_DUMP,
@@ -263,56 +286,104 @@ func (s *migrationSourceWs) preDumpLoop(state *state.State, args *preDumpLoopArg
 
 	logger.Debugf("Doing another pre-dump in %s", args.preDumpDir)
 
-	final := args.final
+	result := &preDumpLoopResult{final: args.final, dumped: true}
 
 	if s.instance.Type() != instancetype.Container {
-		return false, fmt.Errorf("Instance is not container type")
+		return result, fmt.Errorf("Instance is not container type")
 	}
 
 	err := s.instance.Migrate(&criuMigrationArgs)
 	if err != nil {
-		return final, err
+		// The receiving side still expects a transfer and a header, so tell it this is the last
+		// pre-dump and let the final dump transfer the rest.
+		logger.Warn("CRIU pre-dump failed, falling back to stop-and-copy", log.Ctx{"instance": s.instance.Name(), "err": err})
+		os.RemoveAll(filepath.Join(args.checkpointDir, args.dumpDir))
+		result.final = true
+		result.dumped = false
 	}
 
 	// Send the pre-dump.
 	ctName, _, _ := shared.InstanceGetParentAndSnapshotName(s.instance.Name())
+	start := time.Now()
 	err = rsync.Send(ctName, shared.AddSlash(args.checkpointDir), &shared.WebsocketIO{Conn: s.criuConn}, nil, args.rsyncFeatures, args.bwlimit, state.OS.ExecPath)
 	if err != nil {
-		return final, err
+		return result, err
 	}
 
-	// Read the CRIU's 'stats-dump' file
-	dumpPath := shared.AddSlash(args.checkpointDir)
-	dumpPath += shared.AddSlash(args.dumpDir)
-	written, skippedParent, err := readCriuStatsDump(dumpPath)
-	if err != nil {
-		return final, err
-	}
+	elapsed := time.Since(start)
 
-	logger.Debugf("CRIU pages written %d", written)
-	logger.Debugf("CRIU pages skipped %d", skippedParent)
+	if result.dumped {
+		// Read the CRIU's 'stats-dump' file
+		dumpPath := shared.AddSlash(args.checkpointDir)
+		dumpPath += shared.AddSlash(args.dumpDir)
+		written, skippedParent, err := readCriuStatsDump(dumpPath)
+		if err != nil {
+			return result, err
+		}
 
-	totalPages := written + skippedParent
+		logger.Debugf("CRIU pages written %d", written)
+		logger.Debugf("CRIU pages skipped %d", skippedParent)
 
-	percentageSkipped := int(100 - ((100 * written) / totalPages))
+		result.written = written
+		totalPages := written + skippedParent
 
-	logger.Debugf("CRIU pages skipped percentage %d%%", percentageSkipped)
+		percentageSkipped := int(100 - ((100 * written) / totalPages))
 
-	// threshold is the percentage of memory pages that needs
-	// to be pre-copied for the pre-copy migration to stop.
-	var threshold int
-	tmp := s.instance.ExpandedConfig()["migration.incremental.memory.goal"]
-	if tmp != "" {
-		threshold, _ = strconv.Atoi(tmp)
-	} else {
-		// defaults to 70%
-		threshold = 70
-	}
+		logger.Debugf("CRIU pages skipped percentage %d%%", percentageSkipped)
 
-	if percentageSkipped > threshold {
-		logger.Debugf("Memory pages skipped (%d%%) due to pre-copy is larger than threshold (%d%%)", percentageSkipped, threshold)
-		logger.Debugf("This was the last pre-dump; next dump is the final dump")
-		final = true
+		// threshold is the percentage of memory pages that needs
+		// to be pre-copied for the pre-copy migration to stop.
+		var threshold int
+		tmp := s.instance.ExpandedConfig()["migration.incremental.memory.goal"]
+		if tmp != "" {
+			threshold, _ = strconv.Atoi(tmp)
+		} else {
+			// defaults to 70%
+			threshold = 70
+		}
+
+		// The pages written by the final dump will be those dirtied during this iteration, which
+		// at a similar dirtying rate is about as many as were written by this pre-dump.
+		writtenBytes := written * uint64(os.Getpagesize())
+		var rate uint64
+		if elapsed > 0 {
+			rate = uint64(float64(writtenBytes) / elapsed.Seconds())
+		}
+
+		if percentageSkipped > threshold {
+			logger.Debugf("Memory pages skipped (%d%%) due to pre-copy is larger than threshold (%d%%)", percentageSkipped, threshold)
+			logger.Debugf("This was the last pre-dump; next dump is the final dump")
+			result.final = true
+		}
+
+		tmp = s.instance.ExpandedConfig()["migration.incremental.memory.downtime"]
+		if tmp != "" {
+			downtime, _ := strconv.Atoi(tmp)
+			if elapsed <= time.Duration(downtime)*time.Millisecond {
+				logger.Debugf("Estimated downtime (%v) is within the target (%dms)", elapsed, downtime)
+				result.final = true
+			}
+		}
+
+		// Memory is dirtied faster than it's transferred, more iterations won't help.
+		if args.lastWritten > 0 && written >= args.lastWritten {
+			logger.Debugf("Pre-copy isn't converging (%d pages written, %d previously), falling back to stop-and-copy", written, args.lastWritten)
+			result.final = true
+		}
+
+		if migrateOp != nil {
+			meta := migrateOp.Metadata()
+			if meta == nil {
+				meta = make(map[string]interface{})
+			}
+
+			meta["live_migration_iteration"] = args.iteration
+			meta["live_migration_dirty_pages"] = written
+			meta["live_migration_skipped_pages"] = skippedParent
+			meta["live_migration_transfer_rate"] = rate
+			meta["live_migration_estimated_downtime"] = elapsed.Milliseconds()
+			migrateOp.UpdateMetadata(meta)
+		}
 	}
 
 	// If in pre-dump mode, the receiving side
@@ -320,27 +391,31 @@ func (s *migrationSourceWs) preDumpLoop(state *state.State, args *preDumpLoopArg
 	// last pre-dump
 	logger.Debugf("Sending another header")
 	sync := migration.MigrationSync{
-		FinalPreDump: proto.Bool(final),
+		FinalPreDump: proto.Bool(result.final),
 	}
 
 	data, err := proto.Marshal(&sync)
 
 	if err != nil {
-		return final, err
+		return result, err
 	}
 
 	err = s.criuConn.WriteMessage(websocket.BinaryMessage, data)
 	if err != nil {
 		s.sendControl(err)
-		return final, err
+		return result, err
 	}
 	logger.Debugf("Sending another header done")
 
-	return final, nil
+	return result, nil
 }
 
 func (s *migrationSourceWs) Do(state *state.State, migrateOp *operations.Operation) error {
-	<-s.allConnected
+	select {
+	case <-s.allConnected:
+	case <-s.cancelled:
+		return fmt.Errorf("Migration cancelled")
+	}
 
 	var poolMigrationTypes []migration.Type
 
@@ -486,6 +561,9 @@ func (s *migrationSourceWs) Do(state *state.State, migrateOp *operations.Operati
 		return abort(err)
 	}
 
+	fallbackReason := migration.FallbackReason(poolMigrationTypes[0].FSType, migrationTypes[0].FSType, nil)
+	migration.SetOperationMigrationType(migrateOp, s.instance.Name(), migrationTypes[0], fallbackReason)
+
 	sendSnapshotNames := snapshotNames
 
 	// If we are in refresh mode, only send the snapshots the target has asked for.
@@ -497,7 +575,8 @@ func (s *migrationSourceWs) Do(state *state.State, migrateOp *operations.Operati
 	volSourceArgs.MigrationType = migrationTypes[0]
 	volSourceArgs.Snapshots = sendSnapshotNames
 	volSourceArgs.TrackProgress = true
-	err = pool.MigrateInstance(s.instance, &shared.WebsocketIO{Conn: s.fsConn}, volSourceArgs, migrateOp)
+	volSourceArgs.Refresh = respHeader.GetRefresh()
+	err = pool.MigrateInstance(s.instance, migration.ConnIO(s.fsConn), volSourceArgs, migrateOp)
 	if err != nil {
 		return abort(err)
 	}
@@ -591,7 +670,10 @@ func (s *migrationSourceWs) Do(state *state.State, migrateOp *operations.Operati
 			if respHeader.GetPredump() {
 				logger.Debugf("The other side does support pre-copy")
 				final := false
+				iteration := 0
+				var lastWritten uint64
 				for !final {
+					iteration++
 					preDumpCounter++
 					if preDumpCounter < maxDumpIterations {
 						final = false
@@ -606,13 +688,21 @@ func (s *migrationSourceWs) Do(state *state.State, migrateOp *operations.Operati
 						dumpDir:       dumpDir,
 						final:         final,
 						rsyncFeatures: rsyncFeatures,
+						iteration:     iteration,
+						lastWritten:   lastWritten,
 					}
-					final, err = s.preDumpLoop(state, &loopArgs)
+					result, err := s.preDumpLoop(state, migrateOp, &loopArgs)
 					if err != nil {
 						os.RemoveAll(checkpointDir)
 						return abort(err)
 					}
-					preDumpDir = fmt.Sprintf("%03d", preDumpCounter)
+
+					final = result.final
+					lastWritten = result.written
+					if result.dumped {
+						preDumpDir = fmt.Sprintf("%03d", preDumpCounter)
+					}
+
 					preDumpCounter++
 				}
 			} else {
@@ -688,7 +778,7 @@ func (s *migrationSourceWs) Do(state *state.State, migrateOp *operations.Operati
 		volSourceArgs.FinalSync = true
 		volSourceArgs.Snapshots = nil
 
-		err = pool.MigrateInstance(s.instance, &shared.WebsocketIO{Conn: s.fsConn}, volSourceArgs, migrateOp)
+		err = pool.MigrateInstance(s.instance, migration.ConnIO(s.fsConn), volSourceArgs, migrateOp)
 		if err != nil {
 			return abort(err)
 		}
@@ -718,12 +808,14 @@ func (s *migrationSourceWs) Do(state *state.State, migrateOp *operations.Operati
 
 func newMigrationSink(args *MigrationSinkArgs) (*migrationSink, error) {
 	sink := migrationSink{
-		src:     migrationFields{instance: args.Instance, instanceOnly: args.InstanceOnly},
-		dest:    migrationFields{instanceOnly: args.InstanceOnly},
-		url:     args.Url,
-		dialer:  args.Dialer,
-		push:    args.Push,
-		refresh: args.Refresh,
+		src:       migrationFields{instance: args.Instance, instanceOnly: args.InstanceOnly},
+		dest:      migrationFields{instanceOnly: args.InstanceOnly},
+		url:       args.Url,
+		dialer:    args.Dialer,
+		cancelled: make(chan struct{}),
+		push:      args.Push,
+		refresh:   args.Refresh,
+		poolMap:   args.PoolMap,
 	}
 
 	if sink.push {
@@ -779,7 +871,17 @@ func (c *migrationSink) Do(state *state.State, revert *revert.Reverter, migrateO
 	var err error
 
 	if c.push {
-		<-c.allConnected
+		select {
+		case <-c.allConnected:
+		case <-c.cancelled:
+			return fmt.Errorf("Migration cancelled")
+		}
+	} else {
+		select {
+		case <-c.cancelled:
+			return fmt.Errorf("Migration cancelled")
+		default:
+		}
 	}
 
 	disconnector := c.src.disconnect
@@ -790,13 +892,13 @@ func (c *migrationSink) Do(state *state.State, revert *revert.Reverter, migrateO
 	if c.push {
 		defer disconnector()
 	} else {
-		c.src.controlConn, err = c.connectWithSecret(c.src.controlSecret)
+		c.src.controlConn, err = c.connectResumable(c.src.controlSecret)
 		if err != nil {
 			return err
 		}
 		defer c.src.disconnect()
 
-		c.src.fsConn, err = c.connectWithSecret(c.src.fsSecret)
+		c.src.fsConn, err = c.connectResumable(c.src.fsSecret)
 		if err != nil {
 			c.src.sendControl(err)
 			return err
@@ -809,6 +911,15 @@ func (c *migrationSink) Do(state *state.State, revert *revert.Reverter, migrateO
 				return err
 			}
 		}
+
+		// Catch cancellations which happened while connecting, as there was nothing to disconnect then.
+		select {
+		case <-c.cancelled:
+			err = fmt.Errorf("Migration cancelled")
+			c.src.sendControl(err)
+			return err
+		default:
+		}
 	}
 
 	receiver := c.src.recv
@@ -848,7 +959,7 @@ func (c *migrationSink) Do(state *state.State, revert *revert.Reverter, migrateO
 	}
 
 	// The function that will be executed to receive the sender's migration data.
-	var myTarget func(conn *websocket.Conn, op *operations.Operation, args MigrationSinkArgs) error
+	var myTarget func(conn migration.Conn, op *operations.Operation, args MigrationSinkArgs) error
 
 	pool, err := storagePools.GetPoolByInstance(state, c.src.instance)
 	if err != nil {
@@ -859,21 +970,34 @@ func (c *migrationSink) Do(state *state.State, revert *revert.Reverter, migrateO
 	// supported types and features. If a match is found the combined features list
 	// will be sent back to requester.
 	contentType := storagePools.InstanceContentType(c.src.instance)
-	respTypes, err := migration.MatchTypes(offerHeader, storagePools.FallbackMigrationType(contentType), pool.MigrationTypes(contentType, c.refresh))
+	poolMigrationTypes := pool.MigrationTypes(contentType, c.refresh)
+	respTypes, err := migration.MatchTypes(offerHeader, storagePools.FallbackMigrationType(contentType), poolMigrationTypes)
 	if err != nil {
 		return err
 	}
 
+	if c.refresh {
+		respTypes = migration.RefreshTypes(respTypes)
+		if len(respTypes) < 1 {
+			return fmt.Errorf("No matching migration types found for refresh")
+		}
+	}
+
+	fallbackReason := migration.FallbackReason(offerHeader.GetFs(), respTypes[0].FSType, migration.FSTypes(poolMigrationTypes))
+	migration.SetOperationMigrationType(migrateOp, c.src.instance.Name(), respTypes[0], fallbackReason)
+
 	// The migration header to be sent back to source with our target options.
 	// Convert response type to response header and copy snapshot info into it.
+	// Only the chosen type is sent back so that the source uses the same one.
 	respHeader := migration.TypesToHeader(respTypes...)
+	respHeader.OfferedTypes = nil
 	respHeader.SnapshotNames = offerHeader.SnapshotNames
 	respHeader.Snapshots = offerHeader.Snapshots
 	respHeader.Refresh = &c.refresh
 
 	// Translate the legacy MigrationSinkArgs to a VolumeTargetArgs suitable for use
 	// with the new storage layer.
-	myTarget = func(conn *websocket.Conn, op *operations.Operation, args MigrationSinkArgs) error {
+	myTarget = func(conn migration.Conn, op *operations.Operation, args MigrationSinkArgs) error {
 		volTargetArgs := migration.VolumeTargetArgs{
 			Name:          args.Instance.Name(),
 			MigrationType: respTypes[0],
@@ -913,6 +1037,10 @@ func (c *migrationSink) Do(state *state.State, revert *revert.Reverter, migrateO
 					if snapLocalRootDiskDeviceKey != "" {
 						snapArgs.Devices[snapLocalRootDiskDeviceKey]["pool"] = parentStoragePool
 					}
+
+					for _, dev := range snapArgs.Devices {
+						migrationPoolMapDevice(args.PoolMap, dev)
+					}
 				}
 
 				// Check if snapshot exists already and if not then create
@@ -930,7 +1058,7 @@ func (c *migrationSink) Do(state *state.State, revert *revert.Reverter, migrateO
 			}
 		}
 
-		err = pool.CreateInstanceFromMigration(args.Instance, &shared.WebsocketIO{Conn: conn}, volTargetArgs, op)
+		err = pool.CreateInstanceFromMigration(args.Instance, migration.ConnIO(conn), volTargetArgs, op)
 		if err != nil {
 			return err
 		}
@@ -1033,7 +1161,7 @@ func (c *migrationSink) Do(state *state.State, revert *revert.Reverter, migrateO
 				snapshots = offerHeader.Snapshots
 			}
 
-			var fsConn *websocket.Conn
+			var fsConn migration.Conn
 			if c.push {
 				fsConn = c.dest.fsConn
 			} else {
@@ -1059,6 +1187,7 @@ func (c *migrationSink) Do(state *state.State, revert *revert.Reverter, migrateO
 				InstanceOnly:  c.src.instanceOnly,
 				Idmap:         srcIdmap,
 				Live:          sendFinalFsDelta,
+				PoolMap:       c.poolMap,
 				Refresh:       c.refresh,
 				RsyncFeatures: rsyncFeatures,
 				Snapshots:     snapshots,
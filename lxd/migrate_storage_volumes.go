@@ -4,7 +4,6 @@ import (
 	"fmt"
 
 	"github.com/golang/protobuf/proto"
-	"github.com/gorilla/websocket"
 
 	"github.com/lxc/lxd/lxd/db"
 	"github.com/lxc/lxd/lxd/migration"
@@ -17,7 +16,10 @@ import (
 )
 
 func newStorageMigrationSource(volumeOnly bool) (*migrationSourceWs, error) {
-	ret := migrationSourceWs{migrationFields{}, make(chan bool, 1)}
+	ret := migrationSourceWs{
+		allConnected: make(chan bool, 1),
+		cancelled:    make(chan struct{}),
+	}
 	ret.volumeOnly = volumeOnly
 
 	var err error
@@ -123,6 +125,9 @@ func (s *migrationSourceWs) DoStorage(state *state.State, projectName string, po
 		return err
 	}
 
+	fallbackReason := migration.FallbackReason(poolMigrationTypes[0].FSType, migrationTypes[0].FSType, nil)
+	migration.SetOperationMigrationType(migrateOp, volName, migrationTypes[0], fallbackReason)
+
 	volSourceArgs := &migration.VolumeSourceArgs{
 		Name:          volName,
 		MigrationType: migrationTypes[0],
@@ -133,9 +138,10 @@ func (s *migrationSourceWs) DoStorage(state *state.State, projectName string, po
 
 	if respHeader.GetRefresh() {
 		volSourceArgs.Snapshots = respHeader.GetSnapshotNames()
+		volSourceArgs.Refresh = true
 	}
 
-	err = pool.MigrateCustomVolume(projectName, &shared.WebsocketIO{Conn: s.fsConn}, volSourceArgs, migrateOp)
+	err = pool.MigrateCustomVolume(projectName, migration.ConnIO(s.fsConn), volSourceArgs, migrateOp)
 	if err != nil {
 		go s.sendControl(err)
 		return err
@@ -159,12 +165,13 @@ func (s *migrationSourceWs) DoStorage(state *state.State, projectName string, po
 
 func newStorageMigrationSink(args *MigrationSinkArgs) (*migrationSink, error) {
 	sink := migrationSink{
-		src:     migrationFields{volumeOnly: args.VolumeOnly},
-		dest:    migrationFields{volumeOnly: args.VolumeOnly},
-		url:     args.Url,
-		dialer:  args.Dialer,
-		push:    args.Push,
-		refresh: args.Refresh,
+		src:       migrationFields{volumeOnly: args.VolumeOnly},
+		dest:      migrationFields{volumeOnly: args.VolumeOnly},
+		url:       args.Url,
+		dialer:    args.Dialer,
+		cancelled: make(chan struct{}),
+		push:      args.Push,
+		refresh:   args.Refresh,
 	}
 
 	if sink.push {
@@ -217,14 +224,14 @@ func (c *migrationSink) DoStorage(state *state.State, projectName string, poolNa
 	if c.push {
 		defer disconnector()
 	} else {
-		c.src.controlConn, err = c.connectWithSecret(c.src.controlSecret)
+		c.src.controlConn, err = c.connectResumable(c.src.controlSecret)
 		if err != nil {
 			logger.Errorf("Failed to connect migration sink control socket")
 			return err
 		}
 		defer c.src.disconnect()
 
-		c.src.fsConn, err = c.connectWithSecret(c.src.fsSecret)
+		c.src.fsConn, err = c.connectResumable(c.src.fsSecret)
 		if err != nil {
 			logger.Errorf("Failed to connect migration sink filesystem socket")
 			c.src.sendControl(err)
@@ -256,7 +263,7 @@ func (c *migrationSink) DoStorage(state *state.State, projectName string, poolNa
 	}
 
 	// The function that will be executed to receive the sender's migration data.
-	var myTarget func(conn *websocket.Conn, op *operations.Operation, args MigrationSinkArgs) error
+	var myTarget func(conn migration.Conn, op *operations.Operation, args MigrationSinkArgs) error
 
 	pool, err := storagePools.GetPoolByName(state, poolName)
 	if err != nil {
@@ -276,21 +283,34 @@ func (c *migrationSink) DoStorage(state *state.State, projectName string, poolNa
 	// Extract the source's migration type and then match it against our pool's
 	// supported types and features. If a match is found the combined features list
 	// will be sent back to requester.
-	respTypes, err := migration.MatchTypes(offerHeader, storagePools.FallbackMigrationType(contentType), pool.MigrationTypes(contentType, c.refresh))
+	poolMigrationTypes := pool.MigrationTypes(contentType, c.refresh)
+	respTypes, err := migration.MatchTypes(offerHeader, storagePools.FallbackMigrationType(contentType), poolMigrationTypes)
 	if err != nil {
 		return err
 	}
 
+	if c.refresh {
+		respTypes = migration.RefreshTypes(respTypes)
+		if len(respTypes) < 1 {
+			return fmt.Errorf("No matching migration types found for refresh")
+		}
+	}
+
+	fallbackReason := migration.FallbackReason(offerHeader.GetFs(), respTypes[0].FSType, migration.FSTypes(poolMigrationTypes))
+	migration.SetOperationMigrationType(op, req.Name, respTypes[0], fallbackReason)
+
 	// The migration header to be sent back to source with our target options.
 	// Convert response type to response header and copy snapshot info into it.
+	// Only the chosen type is sent back so that the source uses the same one.
 	respHeader := migration.TypesToHeader(respTypes...)
+	respHeader.OfferedTypes = nil
 	respHeader.SnapshotNames = offerHeader.SnapshotNames
 	respHeader.Snapshots = offerHeader.Snapshots
 	respHeader.Refresh = &c.refresh
 
 	// Translate the legacy MigrationSinkArgs to a VolumeTargetArgs suitable for use
 	// with the new storage layer.
-	myTarget = func(conn *websocket.Conn, op *operations.Operation, args MigrationSinkArgs) error {
+	myTarget = func(conn migration.Conn, op *operations.Operation, args MigrationSinkArgs) error {
 		volTargetArgs := migration.VolumeTargetArgs{
 			Name:          req.Name,
 			Config:        req.Config,
@@ -310,7 +330,7 @@ func (c *migrationSink) DoStorage(state *state.State, projectName string, poolNa
 			}
 		}
 
-		return pool.CreateCustomVolumeFromMigration(projectName, &shared.WebsocketIO{Conn: conn}, volTargetArgs, op)
+		return pool.CreateCustomVolumeFromMigration(projectName, migration.ConnIO(conn), volTargetArgs, op)
 	}
 
 	if c.refresh {
@@ -369,7 +389,7 @@ func (c *migrationSink) DoStorage(state *state.State, projectName string, poolNa
 		fsTransfer := make(chan error)
 
 		go func() {
-			var fsConn *websocket.Conn
+			var fsConn migration.Conn
 			if c.push {
 				fsConn = c.dest.fsConn
 			} else {
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrationSourceWs_Cancel(t *testing.T) {
+	s := &migrationSourceWs{allConnected: make(chan bool, 1), cancelled: make(chan struct{})}
+
+	// Cancelling is idempotent and works before the target connected.
+	s.Cancel()
+	s.Cancel()
+
+	err := s.Do(nil, nil)
+	assert.EqualError(t, err, "Migration cancelled")
+}
+
+func TestMigrationSink_Cancel(t *testing.T) {
+	for _, push := range []bool{true, false} {
+		c := &migrationSink{allConnected: make(chan bool, 1), cancelled: make(chan struct{}), push: push}
+		c.Cancel()
+		c.Cancel()
+
+		err := c.Do(nil, nil, nil)
+		assert.EqualError(t, err, "Migration cancelled", "push: %v", push)
+	}
+}
@@ -453,6 +453,7 @@ func (m *RsyncFeatures) GetBidirectional() bool {
 
 type ZfsFeatures struct {
 	Compress             *bool    `protobuf:"varint,1,opt,name=compress" json:"compress,omitempty"`
+	MigrationRefresh     *bool    `protobuf:"varint,2,opt,name=migration_refresh,json=migrationRefresh" json:"migration_refresh,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -490,6 +491,13 @@ func (m *ZfsFeatures) GetCompress() bool {
 	return false
 }
 
+func (m *ZfsFeatures) GetMigrationRefresh() bool {
+	if m != nil && m.MigrationRefresh != nil {
+		return *m.MigrationRefresh
+	}
+	return false
+}
+
 type BtrfsFeatures struct {
 	MigrationHeader      *bool    `protobuf:"varint,1,opt,name=migration_header,json=migrationHeader" json:"migration_header,omitempty"`
 	HeaderSubvolumes     *bool    `protobuf:"varint,2,opt,name=header_subvolumes,json=headerSubvolumes" json:"header_subvolumes,omitempty"`
@@ -538,20 +546,21 @@ func (m *BtrfsFeatures) GetHeaderSubvolumes() bool {
 }
 
 type MigrationHeader struct {
-	Fs                   *MigrationFSType `protobuf:"varint,1,req,name=fs,enum=migration.MigrationFSType" json:"fs,omitempty"`
-	Criu                 *CRIUType        `protobuf:"varint,2,opt,name=criu,enum=migration.CRIUType" json:"criu,omitempty"`
-	Idmap                []*IDMapType     `protobuf:"bytes,3,rep,name=idmap" json:"idmap,omitempty"`
-	SnapshotNames        []string         `protobuf:"bytes,4,rep,name=snapshotNames" json:"snapshotNames,omitempty"`
-	Snapshots            []*Snapshot      `protobuf:"bytes,5,rep,name=snapshots" json:"snapshots,omitempty"`
-	Predump              *bool            `protobuf:"varint,7,opt,name=predump" json:"predump,omitempty"`
-	RsyncFeatures        *RsyncFeatures   `protobuf:"bytes,8,opt,name=rsyncFeatures" json:"rsyncFeatures,omitempty"`
-	Refresh              *bool            `protobuf:"varint,9,opt,name=refresh" json:"refresh,omitempty"`
-	ZfsFeatures          *ZfsFeatures     `protobuf:"bytes,10,opt,name=zfsFeatures" json:"zfsFeatures,omitempty"`
-	VolumeSize           *int64           `protobuf:"varint,11,opt,name=volumeSize" json:"volumeSize,omitempty"`
-	BtrfsFeatures        *BtrfsFeatures   `protobuf:"bytes,12,opt,name=btrfsFeatures" json:"btrfsFeatures,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}         `json:"-"`
-	XXX_unrecognized     []byte           `json:"-"`
-	XXX_sizecache        int32            `json:"-"`
+	Fs                   *MigrationFSType  `protobuf:"varint,1,req,name=fs,enum=migration.MigrationFSType" json:"fs,omitempty"`
+	Criu                 *CRIUType         `protobuf:"varint,2,opt,name=criu,enum=migration.CRIUType" json:"criu,omitempty"`
+	Idmap                []*IDMapType      `protobuf:"bytes,3,rep,name=idmap" json:"idmap,omitempty"`
+	SnapshotNames        []string          `protobuf:"bytes,4,rep,name=snapshotNames" json:"snapshotNames,omitempty"`
+	Snapshots            []*Snapshot       `protobuf:"bytes,5,rep,name=snapshots" json:"snapshots,omitempty"`
+	Predump              *bool             `protobuf:"varint,7,opt,name=predump" json:"predump,omitempty"`
+	RsyncFeatures        *RsyncFeatures    `protobuf:"bytes,8,opt,name=rsyncFeatures" json:"rsyncFeatures,omitempty"`
+	Refresh              *bool             `protobuf:"varint,9,opt,name=refresh" json:"refresh,omitempty"`
+	ZfsFeatures          *ZfsFeatures      `protobuf:"bytes,10,opt,name=zfsFeatures" json:"zfsFeatures,omitempty"`
+	VolumeSize           *int64            `protobuf:"varint,11,opt,name=volumeSize" json:"volumeSize,omitempty"`
+	BtrfsFeatures        *BtrfsFeatures    `protobuf:"bytes,12,opt,name=btrfsFeatures" json:"btrfsFeatures,omitempty"`
+	OfferedTypes         []MigrationFSType `protobuf:"varint,13,rep,name=offeredTypes,enum=migration.MigrationFSType" json:"offeredTypes,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
 }
 
 func (m *MigrationHeader) Reset()         { *m = MigrationHeader{} }
@@ -656,6 +665,13 @@ func (m *MigrationHeader) GetBtrfsFeatures() *BtrfsFeatures {
 	return nil
 }
 
+func (m *MigrationHeader) GetOfferedTypes() []MigrationFSType {
+	if m != nil {
+		return m.OfferedTypes
+	}
+	return nil
+}
+
 type MigrationControl struct {
 	Success *bool `protobuf:"varint,1,req,name=success" json:"success,omitempty"`
 	// optional failure message if sending a failure
@@ -1004,77 +1020,79 @@ func init() {
 }
 
 var fileDescriptor_fe8772548dc4b615 = []byte{
-	// 1143 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x84, 0x55, 0x5f, 0x6f, 0xdc, 0x44,
-	0x10, 0xe7, 0xfe, 0x24, 0xb9, 0x1b, 0xdf, 0x25, 0xd7, 0x6d, 0x55, 0x59, 0x2d, 0x94, 0xe0, 0x16,
-	0x91, 0x06, 0xa9, 0x2d, 0x57, 0x21, 0xf5, 0x09, 0xa9, 0xb9, 0x10, 0x5a, 0xd1, 0xa6, 0xd1, 0x5e,
-	0x2b, 0x04, 0x2f, 0xd6, 0xc6, 0x1e, 0x5f, 0x56, 0xf5, 0x3f, 0xed, 0xda, 0x69, 0x2e, 0x2f, 0x88,
-	0x0f, 0xc3, 0x47, 0xe2, 0x7b, 0xf0, 0xca, 0x1b, 0xda, 0x59, 0xdb, 0xb1, 0x13, 0x24, 0xde, 0x76,
-	0x7e, 0xf3, 0xf3, 0xcc, 0xec, 0xcc, 0x6f, 0xc7, 0x70, 0x3f, 0xbe, 0x08, 0x9f, 0x26, 0x72, 0xa5,
-	0x44, 0x21, 0xb3, 0xb4, 0x3a, 0xe1, 0x93, 0x5c, 0x65, 0x45, 0xc6, 0xc6, 0x8d, 0xc3, 0xfb, 0x1d,
-	0xc6, 0xaf, 0x0f, 0xdf, 0x8a, 0xfc, 0xfd, 0x3a, 0x47, 0x76, 0x07, 0x36, 0xa4, 0x2e, 0x65, 0xe8,
-	0xf6, 0x76, 0xfb, 0x7b, 0x23, 0x6e, 0x0d, 0x8b, 0xae, 0x64, 0xe8, 0xf6, 0x6b, 0x74, 0x25, 0x43,
-	0x76, 0x17, 0x36, 0xcf, 0x32, 0x5d, 0xc8, 0xd0, 0x1d, 0xec, 0xf6, 0xf7, 0x36, 0x78, 0x65, 0x31,
-	0x06, 0xc3, 0x54, 0xcb, 0xd0, 0x1d, 0x12, 0x4a, 0x67, 0x76, 0x0f, 0x46, 0x89, 0xc8, 0x95, 0x48,
-	0x57, 0xe8, 0x6e, 0x10, 0xde, 0xd8, 0xde, 0x33, 0xd8, 0x5c, 0x64, 0x69, 0x24, 0x57, 0x6c, 0x06,
-	0x83, 0x8f, 0xb8, 0xa6, 0xdc, 0x63, 0x6e, 0x8e, 0x26, 0xf3, 0xb9, 0x88, 0x4b, 0xa4, 0xcc, 0x63,
-	0x6e, 0x0d, 0xef, 0x27, 0xd8, 0x3c, 0xc4, 0x73, 0x19, 0x20, 0xe5, 0x12, 0x09, 0x56, 0x9f, 0xd0,
-	0x99, 0x3d, 0x86, 0xcd, 0x80, 0xe2, 0xb9, 0xfd, 0xdd, 0xc1, 0x9e, 0x33, 0xbf, 0xf5, 0xa4, 0xb9,
-	0xec, 0x13, 0x9b, 0x88, 0x57, 0x04, 0xef, 0xef, 0x3e, 0x8c, 0x96, 0xa9, 0xc8, 0xf5, 0x59, 0x56,
-	0xfc, 0x67, 0xac, 0xe7, 0xe0, 0xc4, 0x59, 0x20, 0xe2, 0xc5, 0xff, 0x04, 0x6c, 0xb3, 0xcc, 0x65,
-	0x73, 0x95, 0x45, 0x32, 0x46, 0xed, 0x0e, 0x76, 0x07, 0x7b, 0x63, 0xde, 0xd8, 0xec, 0x73, 0x18,
-	0x63, 0x7e, 0x86, 0x09, 0x2a, 0x11, 0x53, 0x87, 0x46, 0xfc, 0x0a, 0x60, 0xdf, 0xc3, 0x84, 0x02,
-	0xd9, 0xdb, 0x69, 0x77, 0xe3, 0x46, 0x3e, 0xeb, 0xe1, 0x1d, 0x1a, 0xf3, 0x60, 0x22, 0x54, 0x70,
-	0x26, 0x0b, 0x0c, 0x8a, 0x52, 0xa1, 0xbb, 0x49, 0x1d, 0xee, 0x60, 0xa6, 0x28, 0x5d, 0x88, 0x02,
-	0xa3, 0x32, 0x76, 0xb7, 0x28, 0x6f, 0x63, 0xb3, 0x87, 0x30, 0x0d, 0x14, 0x52, 0x02, 0x3f, 0x14,
-	0x05, 0xba, 0xa3, 0xdd, 0xde, 0xde, 0x80, 0x4f, 0x6a, 0xf0, 0x50, 0x14, 0xc8, 0x1e, 0xc1, 0x76,
-	0x2c, 0x74, 0xe1, 0x97, 0x1a, 0x43, 0xcb, 0x1a, 0x5b, 0x96, 0x41, 0x3f, 0x68, 0x0c, 0x89, 0xf5,
-	0x25, 0x38, 0x78, 0x91, 0x4b, 0xb5, 0xb6, 0x14, 0x20, 0x0a, 0x58, 0xc8, 0x10, 0xbc, 0x3f, 0x7a,
-	0x30, 0x55, 0x7a, 0x9d, 0x06, 0x47, 0x28, 0x4c, 0x61, 0xda, 0xe8, 0xe8, 0x42, 0x14, 0x85, 0xd2,
-	0x6e, 0x6f, 0xb7, 0xb7, 0x37, 0xe2, 0x95, 0x65, 0xf0, 0x10, 0x63, 0x2c, 0xcc, 0xf0, 0x09, 0xb7,
-	0x96, 0xb9, 0x49, 0x90, 0x25, 0xb9, 0x42, 0x6d, 0xda, 0x6b, 0x3c, 0x8d, 0xcd, 0x1e, 0xc1, 0xf4,
-	0x54, 0x86, 0x52, 0x61, 0x60, 0xea, 0xa6, 0x16, 0x1b, 0x42, 0x17, 0xf4, 0x1e, 0x83, 0x73, 0x19,
-	0xe9, 0xa6, 0x80, 0x76, 0xc0, 0x5e, 0x37, 0xa0, 0xb7, 0x82, 0xe9, 0x69, 0xa1, 0x5a, 0xe4, 0xc7,
-	0x30, 0x6b, 0xa6, 0xe1, 0x9f, 0xa1, 0x08, 0x51, 0x55, 0x1f, 0xed, 0x34, 0xf8, 0x2b, 0x82, 0xd9,
-	0xb7, 0x70, 0xcb, 0x12, 0x7c, 0x5d, 0x9e, 0x9e, 0x67, 0x71, 0x99, 0xa0, 0xae, 0xee, 0x32, 0xb3,
-	0x8e, 0x65, 0x83, 0x7b, 0xff, 0x0c, 0x60, 0xe7, 0xed, 0xb5, 0x00, 0xfb, 0xd0, 0x8f, 0x34, 0xe9,
-	0x71, 0x7b, 0x7e, 0xaf, 0x25, 0x82, 0x86, 0x77, 0xb4, 0x34, 0xaf, 0x96, 0xf7, 0x23, 0xcd, 0xbe,
-	0x81, 0x61, 0xa0, 0x64, 0x49, 0xf1, 0xb7, 0xe7, 0xb7, 0xdb, 0x12, 0xe5, 0xaf, 0x3f, 0x10, 0x8d,
-	0x08, 0x6c, 0x1f, 0x36, 0x64, 0x98, 0x88, 0x9c, 0xa4, 0xe9, 0xcc, 0xef, 0xb4, 0x98, 0xcd, 0x1e,
-	0xe0, 0x96, 0x62, 0xda, 0xa9, 0xab, 0xe7, 0x71, 0x2c, 0x4c, 0xf5, 0x43, 0x92, 0x73, 0x17, 0x64,
-	0xdf, 0xc1, 0xb8, 0x06, 0x6a, 0xc9, 0xb6, 0xf3, 0xd7, 0x0f, 0x8c, 0x5f, 0xb1, 0x98, 0x0b, 0x5b,
-	0xb9, 0xc2, 0xb0, 0x4c, 0x72, 0x77, 0x8b, 0x1a, 0x52, 0x9b, 0xec, 0x87, 0x6b, 0xf2, 0x20, 0x2d,
-	0x3a, 0x73, 0xb7, 0x15, 0xb0, 0xe3, 0xe7, 0xd7, 0xd4, 0xe4, 0xc2, 0x96, 0xc2, 0x48, 0xa1, 0x3e,
-	0x23, 0x7d, 0x8e, 0x78, 0x6d, 0xb2, 0x17, 0x9d, 0xa9, 0x93, 0x34, 0x9d, 0xf9, 0xdd, 0x56, 0xdc,
-	0x96, 0x97, 0x77, 0x04, 0xf2, 0x00, 0xc0, 0x8e, 0x69, 0x29, 0x2f, 0xd1, 0x75, 0xac, 0xa6, 0xaf,
-	0x10, 0x53, 0x73, 0x47, 0x24, 0xee, 0xe4, 0x46, 0xcd, 0x1d, 0x3f, 0xef, 0xd2, 0xbd, 0x23, 0x98,
-	0x35, 0x23, 0x5d, 0x64, 0x69, 0xa1, 0xb2, 0xd8, 0xdc, 0x43, 0x97, 0x41, 0x60, 0x35, 0x69, 0x9e,
-	0x6b, 0x6d, 0x1a, 0x4f, 0x82, 0x5a, 0x8b, 0x95, 0x7d, 0x18, 0x63, 0x5e, 0x9b, 0xde, 0x73, 0x98,
-	0x36, 0x71, 0x96, 0xeb, 0x34, 0x30, 0x8b, 0x21, 0x92, 0xa9, 0x88, 0x4f, 0x14, 0x1e, 0x9a, 0x5e,
-	0xdb, 0x48, 0x1d, 0xcc, 0xfb, 0x73, 0x00, 0x33, 0xd3, 0x79, 0xdf, 0xac, 0x03, 0xed, 0x63, 0x5a,
-	0xa8, 0xb5, 0xd9, 0x08, 0x91, 0x42, 0xbc, 0x94, 0xe9, 0xca, 0x2f, 0x64, 0xb5, 0x14, 0xa7, 0x7c,
-	0x52, 0x83, 0xef, 0x65, 0x42, 0x6f, 0x3d, 0x52, 0xd9, 0x25, 0xa6, 0x96, 0xd2, 0x27, 0x0a, 0x58,
-	0x88, 0x08, 0x5f, 0xc1, 0x24, 0xc1, 0x84, 0x82, 0x13, 0x63, 0x40, 0x0c, 0xa7, 0xc2, 0x88, 0xf2,
-	0x10, 0xa6, 0x09, 0x26, 0x9f, 0x94, 0x2c, 0xd0, 0x72, 0x86, 0x36, 0x51, 0x0d, 0xd6, 0xa4, 0x5c,
-	0xac, 0x50, 0xfb, 0x3a, 0x10, 0x69, 0x8a, 0x21, 0xfd, 0x42, 0x86, 0x7c, 0x42, 0xe0, 0xd2, 0x62,
-	0xec, 0x19, 0xdc, 0xa9, 0x48, 0x1f, 0x65, 0x9e, 0x63, 0xe8, 0xe7, 0x42, 0x61, 0x5a, 0xd0, 0x32,
-	0x1c, 0x72, 0x66, 0xb9, 0xd6, 0x75, 0x42, 0x9e, 0xab, 0xb0, 0x26, 0x53, 0x81, 0x29, 0xed, 0xc5,
-	0x3a, 0xec, 0x2f, 0x16, 0x33, 0x24, 0xa9, 0x12, 0x91, 0xfb, 0x0a, 0x75, 0x16, 0x9f, 0xdb, 0xdd,
-	0x38, 0xe5, 0x13, 0x02, 0xb9, 0xc5, 0xd8, 0x17, 0x00, 0x36, 0x52, 0x2c, 0x2e, 0xd7, 0xee, 0x98,
-	0xc2, 0x8c, 0x09, 0x79, 0x23, 0x2e, 0xd7, 0xb5, 0xdb, 0xcf, 0x65, 0x5e, 0x09, 0xaf, 0x72, 0x9f,
-	0x18, 0xc0, 0x6c, 0xd6, 0xc6, 0xed, 0x9f, 0x96, 0x91, 0x26, 0x89, 0x55, 0x85, 0x18, 0xca, 0x41,
-	0x19, 0x69, 0xef, 0xaf, 0x1e, 0xdc, 0x56, 0xa8, 0x8b, 0x4c, 0x61, 0x67, 0x54, 0x5f, 0xdb, 0xaf,
-	0xb5, 0x6f, 0x76, 0x96, 0x50, 0x68, 0xff, 0xdd, 0x43, 0x6e, 0xef, 0xb6, 0xa8, 0x40, 0xb6, 0x0f,
-	0xb7, 0xba, 0xed, 0x09, 0xb2, 0x4f, 0x34, 0xb2, 0x21, 0xdf, 0x69, 0xf7, 0x66, 0x91, 0x7d, 0x32,
-	0x73, 0x8b, 0x32, 0xf5, 0xb1, 0x19, 0x7e, 0x35, 0xb7, 0x0a, 0xab, 0x47, 0x5b, 0x17, 0xd3, 0x1a,
-	0x9b, 0x53, 0x61, 0x44, 0x69, 0x0a, 0xab, 0x40, 0x33, 0xb6, 0x5e, 0x53, 0x18, 0xaf, 0x40, 0xef,
-	0x02, 0x9c, 0xf6, 0x75, 0x9e, 0xc2, 0x30, 0xb4, 0x52, 0x35, 0x4f, 0xe8, 0x7e, 0xeb, 0x09, 0x5d,
-	0x17, 0x29, 0x27, 0x22, 0x7b, 0x61, 0x1e, 0x3c, 0xc5, 0xa2, 0xe7, 0xe0, 0xcc, 0x1f, 0xb4, 0x57,
-	0xc5, 0xcd, 0x86, 0xf1, 0x9a, 0xbe, 0x7f, 0xdc, 0xda, 0xb8, 0x76, 0x93, 0xb2, 0x31, 0x6c, 0xf0,
-	0xe5, 0xaf, 0xc7, 0x8b, 0xd9, 0x67, 0xe6, 0x78, 0xf0, 0x9e, 0x1f, 0x2d, 0x67, 0x3d, 0xb6, 0x05,
-	0x83, 0xdf, 0x8e, 0x96, 0xb3, 0xbe, 0x39, 0xf0, 0x83, 0xc3, 0xd9, 0x80, 0xdd, 0x86, 0x9d, 0x83,
-	0x37, 0xef, 0x16, 0x3f, 0xfb, 0x2f, 0x8f, 0x0f, 0x7d, 0xfb, 0xc5, 0x70, 0xff, 0x29, 0x8c, 0xea,
-	0x5d, 0xcb, 0xb6, 0x01, 0xcc, 0xd9, 0x6f, 0x45, 0x3b, 0x79, 0xf5, 0xf2, 0xc3, 0x9b, 0x59, 0x8f,
-	0x8d, 0x60, 0x78, 0xfc, 0xee, 0xf8, 0xc7, 0x59, 0xff, 0xdf, 0x00, 0x00, 0x00, 0xff, 0xff, 0x77,
-	0x67, 0x3e, 0xf5, 0xa3, 0x09, 0x00, 0x00,
+	// 1169 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x84, 0x56, 0x5d, 0x6e, 0xdb, 0x46,
+	0x10, 0xae, 0x24, 0xda, 0x16, 0x87, 0x92, 0xad, 0x6c, 0x82, 0x80, 0x48, 0xda, 0x54, 0x65, 0x52,
+	0xd4, 0x71, 0x81, 0x24, 0x55, 0x50, 0x20, 0x4f, 0x01, 0x62, 0xb9, 0x6e, 0x82, 0x26, 0x8e, 0xb1,
+	0x4a, 0x5a, 0xb4, 0x2f, 0xc4, 0x9a, 0x1c, 0xca, 0x8b, 0xf0, 0x0f, 0xbb, 0xa4, 0x6d, 0xe9, 0xa5,
+	0xe8, 0x61, 0x7a, 0x88, 0x1e, 0xa4, 0xf7, 0xe8, 0x11, 0x8a, 0xdd, 0x25, 0x69, 0x52, 0x09, 0x90,
+	0xb7, 0x9d, 0x6f, 0x3e, 0x7e, 0x33, 0x3b, 0x33, 0x3b, 0x12, 0xdc, 0x8d, 0xaf, 0xc2, 0xc7, 0x09,
+	0x5f, 0x0a, 0x56, 0xf0, 0x2c, 0xad, 0x4e, 0xf8, 0x28, 0x17, 0x59, 0x91, 0x11, 0xbb, 0x71, 0x78,
+	0x7f, 0x82, 0xfd, 0xea, 0xe8, 0x0d, 0xcb, 0xdf, 0xad, 0x72, 0x24, 0xb7, 0x60, 0x8b, 0xcb, 0x92,
+	0x87, 0x6e, 0x6f, 0xda, 0xdf, 0x1f, 0x52, 0x63, 0x18, 0x74, 0xc9, 0x43, 0xb7, 0x5f, 0xa3, 0x4b,
+	0x1e, 0x92, 0xdb, 0xb0, 0x7d, 0x9e, 0xc9, 0x82, 0x87, 0xee, 0x60, 0xda, 0xdf, 0xdf, 0xa2, 0x95,
+	0x45, 0x08, 0x58, 0xa9, 0xe4, 0xa1, 0x6b, 0x69, 0x54, 0x9f, 0xc9, 0x1d, 0x18, 0x26, 0x2c, 0x17,
+	0x2c, 0x5d, 0xa2, 0xbb, 0xa5, 0xf1, 0xc6, 0xf6, 0x9e, 0xc0, 0xf6, 0x3c, 0x4b, 0x23, 0xbe, 0x24,
+	0x13, 0x18, 0x7c, 0xc0, 0x95, 0x8e, 0x6d, 0x53, 0x75, 0x54, 0x91, 0x2f, 0x58, 0x5c, 0xa2, 0x8e,
+	0x6c, 0x53, 0x63, 0x78, 0x3f, 0xc3, 0xf6, 0x11, 0x5e, 0xf0, 0x00, 0x75, 0x2c, 0x96, 0x60, 0xf5,
+	0x89, 0x3e, 0x93, 0x87, 0xb0, 0x1d, 0x68, 0x3d, 0xb7, 0x3f, 0x1d, 0xec, 0x3b, 0xb3, 0x1b, 0x8f,
+	0x9a, 0xcb, 0x3e, 0x32, 0x81, 0x68, 0x45, 0xf0, 0xfe, 0xeb, 0xc3, 0x70, 0x91, 0xb2, 0x5c, 0x9e,
+	0x67, 0xc5, 0x27, 0xb5, 0x9e, 0x82, 0x13, 0x67, 0x01, 0x8b, 0xe7, 0x9f, 0x11, 0x6c, 0xb3, 0xd4,
+	0x65, 0x73, 0x91, 0x45, 0x3c, 0x46, 0xe9, 0x0e, 0xa6, 0x83, 0x7d, 0x9b, 0x36, 0x36, 0xf9, 0x12,
+	0x6c, 0xcc, 0xcf, 0x31, 0x41, 0xc1, 0x62, 0x5d, 0xa1, 0x21, 0xbd, 0x06, 0xc8, 0x8f, 0x30, 0xd2,
+	0x42, 0xe6, 0x76, 0xd2, 0xdd, 0xfa, 0x28, 0x9e, 0xf1, 0xd0, 0x0e, 0x8d, 0x78, 0x30, 0x62, 0x22,
+	0x38, 0xe7, 0x05, 0x06, 0x45, 0x29, 0xd0, 0xdd, 0xd6, 0x15, 0xee, 0x60, 0x2a, 0x29, 0x59, 0xb0,
+	0x02, 0xa3, 0x32, 0x76, 0x77, 0x74, 0xdc, 0xc6, 0x26, 0xf7, 0x61, 0x1c, 0x08, 0xd4, 0x01, 0xfc,
+	0x90, 0x15, 0xe8, 0x0e, 0xa7, 0xbd, 0xfd, 0x01, 0x1d, 0xd5, 0xe0, 0x11, 0x2b, 0x90, 0x3c, 0x80,
+	0xdd, 0x98, 0xc9, 0xc2, 0x2f, 0x25, 0x86, 0x86, 0x65, 0x1b, 0x96, 0x42, 0xdf, 0x4b, 0x0c, 0x35,
+	0xeb, 0x6b, 0x70, 0xf0, 0x2a, 0xe7, 0x62, 0x65, 0x28, 0xa0, 0x29, 0x60, 0x20, 0x45, 0xf0, 0xfe,
+	0xea, 0xc1, 0x58, 0xc8, 0x55, 0x1a, 0x1c, 0x23, 0x53, 0x89, 0x49, 0x35, 0x47, 0x57, 0xac, 0x28,
+	0x84, 0x74, 0x7b, 0xd3, 0xde, 0xfe, 0x90, 0x56, 0x96, 0xc2, 0x43, 0x8c, 0xb1, 0x50, 0xcd, 0xd7,
+	0xb8, 0xb1, 0xd4, 0x4d, 0x82, 0x2c, 0xc9, 0x05, 0x4a, 0x55, 0x5e, 0xe5, 0x69, 0x6c, 0xf2, 0x00,
+	0xc6, 0x67, 0x3c, 0xe4, 0x02, 0x03, 0x95, 0xb7, 0x2e, 0xb1, 0x22, 0x74, 0x41, 0xef, 0x57, 0x70,
+	0xd6, 0x91, 0x6c, 0x12, 0x68, 0x0b, 0xf6, 0x36, 0x04, 0xbf, 0x87, 0x1b, 0x4d, 0xf1, 0x7d, 0x81,
+	0x91, 0x40, 0x79, 0x5e, 0xe5, 0x33, 0x69, 0x1c, 0xd4, 0xe0, 0xde, 0x12, 0xc6, 0x67, 0x85, 0x68,
+	0x29, 0x3f, 0x84, 0x6b, 0x92, 0x7f, 0x8e, 0x2c, 0x44, 0x51, 0x45, 0xd8, 0x6b, 0xf0, 0x97, 0x1a,
+	0x56, 0x81, 0x0c, 0xc1, 0x97, 0xe5, 0xd9, 0x45, 0x16, 0x97, 0x09, 0xca, 0x3a, 0x90, 0x71, 0x2c,
+	0x1a, 0xdc, 0xfb, 0xc7, 0x82, 0xbd, 0x37, 0x1b, 0x02, 0x07, 0xd0, 0x8f, 0xa4, 0x1e, 0xde, 0xdd,
+	0xd9, 0x9d, 0xd6, 0xc4, 0x34, 0xbc, 0xe3, 0x85, 0x7a, 0xe2, 0xb4, 0x1f, 0x49, 0xf2, 0x1d, 0x58,
+	0x81, 0xe0, 0xa5, 0xd6, 0xdf, 0x9d, 0xdd, 0x6c, 0xcf, 0x33, 0x7d, 0xf5, 0x5e, 0xd3, 0x34, 0x81,
+	0x1c, 0xc0, 0x16, 0x0f, 0x13, 0x96, 0xeb, 0x39, 0x76, 0x66, 0xb7, 0x5a, 0xcc, 0x66, 0x69, 0x50,
+	0x43, 0x51, 0xb5, 0x97, 0xd5, 0x5b, 0x3a, 0x61, 0x2a, 0x7b, 0x4b, 0xcf, 0x7e, 0x17, 0x24, 0x3f,
+	0x80, 0x5d, 0x03, 0xf5, 0x7c, 0xb7, 0xe3, 0xd7, 0xaf, 0x91, 0x5e, 0xb3, 0x88, 0x0b, 0x3b, 0xb9,
+	0xc0, 0xb0, 0x4c, 0x72, 0x77, 0x47, 0x17, 0xa4, 0x36, 0xc9, 0xf3, 0x8d, 0x59, 0xd2, 0x83, 0xeb,
+	0xcc, 0xdc, 0x96, 0x60, 0xc7, 0x4f, 0x37, 0x46, 0xcf, 0x85, 0x9d, 0xba, 0xa7, 0xb6, 0x51, 0xae,
+	0x4c, 0xf2, 0xac, 0x33, 0x22, 0x7a, 0x8e, 0x9d, 0xd9, 0xed, 0x96, 0x6e, 0xcb, 0x4b, 0x3b, 0xd3,
+	0x74, 0x0f, 0xc0, 0xb4, 0x69, 0xc1, 0xd7, 0xe8, 0x3a, 0xe6, 0x01, 0x5c, 0x23, 0xe4, 0xf9, 0xc6,
+	0x90, 0xb8, 0xa3, 0x8f, 0x72, 0xee, 0xf8, 0xe9, 0xc6, 0x4c, 0x3d, 0x87, 0x51, 0x16, 0x45, 0x28,
+	0x30, 0x54, 0xc5, 0x97, 0xee, 0x78, 0x3a, 0xf8, 0x4c, 0xc7, 0x3b, 0x7c, 0xef, 0x18, 0x26, 0x0d,
+	0x61, 0x9e, 0xa5, 0x85, 0xc8, 0x62, 0x55, 0x07, 0x59, 0x06, 0x81, 0x79, 0x00, 0x6a, 0x37, 0xd4,
+	0xa6, 0xf2, 0x24, 0x28, 0x25, 0x5b, 0x9a, 0x57, 0x68, 0xd3, 0xda, 0xf4, 0x9e, 0xc2, 0xb8, 0xd1,
+	0x59, 0xac, 0xd2, 0x40, 0x6d, 0xa1, 0x88, 0xa7, 0x2c, 0x3e, 0x15, 0x78, 0xa4, 0x7a, 0x65, 0x94,
+	0x3a, 0x98, 0xf7, 0xf7, 0x00, 0x26, 0xaa, 0x73, 0xbe, 0xda, 0x3d, 0xd2, 0xc7, 0xb4, 0x10, 0x2b,
+	0xb5, 0x7e, 0x22, 0x81, 0xb8, 0xe6, 0xe9, 0xd2, 0x2f, 0x78, 0xb5, 0x81, 0xc7, 0x74, 0x54, 0x83,
+	0xef, 0x78, 0xa2, 0x17, 0x4b, 0x24, 0xb2, 0x35, 0xa6, 0x86, 0xd2, 0xd7, 0x14, 0x30, 0x90, 0x26,
+	0x7c, 0x03, 0xa3, 0x04, 0x13, 0x2d, 0xae, 0x19, 0x03, 0xcd, 0x70, 0x2a, 0x4c, 0x53, 0xee, 0xc3,
+	0x38, 0xc1, 0xe4, 0x52, 0xf0, 0x02, 0x0d, 0xc7, 0x32, 0x81, 0x6a, 0xb0, 0x26, 0xe5, 0x6c, 0x89,
+	0xd2, 0x97, 0x01, 0x4b, 0x53, 0x0c, 0xf5, 0xef, 0x95, 0x45, 0x47, 0x1a, 0x5c, 0x18, 0x8c, 0x3c,
+	0x81, 0x5b, 0x15, 0xe9, 0x03, 0xcf, 0x73, 0x0c, 0xfd, 0x9c, 0x09, 0x4c, 0x0b, 0xbd, 0x79, 0x2d,
+	0x4a, 0x0c, 0xd7, 0xb8, 0x4e, 0xb5, 0xe7, 0x5a, 0x56, 0x45, 0x2a, 0x30, 0x75, 0x77, 0x5a, 0xb2,
+	0xbf, 0x19, 0x4c, 0x91, 0xb8, 0x48, 0x58, 0xee, 0x0b, 0x94, 0x59, 0x7c, 0x61, 0x16, 0xf1, 0x98,
+	0x8e, 0x34, 0x48, 0x0d, 0x46, 0xbe, 0x02, 0x30, 0x4a, 0x31, 0x5b, 0xaf, 0x5c, 0x5b, 0xcb, 0xd8,
+	0x1a, 0x79, 0xcd, 0xd6, 0xab, 0xda, 0xed, 0xe7, 0x3c, 0xaf, 0x06, 0xb7, 0x72, 0x9f, 0x2a, 0x40,
+	0xad, 0xf1, 0xc6, 0xed, 0x9f, 0x95, 0x91, 0xd4, 0x23, 0x5a, 0x25, 0xa2, 0x28, 0x87, 0x65, 0x24,
+	0xbd, 0x7f, 0x7b, 0x70, 0x53, 0xa0, 0x2c, 0x32, 0x81, 0x9d, 0x56, 0x7d, 0x6b, 0xbe, 0x96, 0xbe,
+	0x5a, 0x90, 0x4c, 0xa0, 0xf9, 0xa3, 0x60, 0x51, 0x73, 0xb7, 0x79, 0x05, 0x92, 0x03, 0xb8, 0xd1,
+	0x2d, 0x4f, 0x90, 0x5d, 0xea, 0x96, 0x59, 0x74, 0xaf, 0x5d, 0x9b, 0x79, 0x76, 0xa9, 0xfa, 0x16,
+	0x65, 0xe2, 0x43, 0xd3, 0xfc, 0xaa, 0x6f, 0x15, 0x56, 0xb7, 0xb6, 0x4e, 0xa6, 0xd5, 0x36, 0xa7,
+	0xc2, 0x34, 0xa5, 0x49, 0xac, 0x02, 0x55, 0xdb, 0x7a, 0x4d, 0x62, 0xb4, 0x02, 0xbd, 0x2b, 0x70,
+	0xda, 0xd7, 0x79, 0x0c, 0x56, 0x68, 0x46, 0x55, 0x3d, 0xc1, 0xbb, 0xad, 0x37, 0xb4, 0x39, 0xa4,
+	0x54, 0x13, 0xc9, 0x33, 0xb5, 0x30, 0xb4, 0x96, 0x7e, 0x0e, 0xce, 0xec, 0x5e, 0xeb, 0x9b, 0x4f,
+	0x14, 0x8c, 0xd6, 0xf4, 0x83, 0x13, 0xd8, 0xdb, 0x78, 0x97, 0xc4, 0x86, 0x2d, 0xba, 0xf8, 0xfd,
+	0x64, 0x3e, 0xf9, 0x42, 0x1d, 0x0f, 0xdf, 0xd1, 0xe3, 0xc5, 0xa4, 0x47, 0x76, 0x60, 0xf0, 0xc7,
+	0xf1, 0x62, 0xd2, 0x57, 0x07, 0x7a, 0x78, 0x34, 0x19, 0x90, 0x9b, 0xb0, 0x77, 0xf8, 0xfa, 0xed,
+	0xfc, 0x17, 0xff, 0xc5, 0xc9, 0x91, 0x6f, 0xbe, 0xb0, 0x0e, 0x1e, 0xc3, 0xb0, 0xde, 0xd5, 0x64,
+	0x17, 0x40, 0x9d, 0xfd, 0x96, 0xda, 0xe9, 0xcb, 0x17, 0xef, 0x5f, 0x4f, 0x7a, 0x64, 0x08, 0xd6,
+	0xc9, 0xdb, 0x93, 0x9f, 0x26, 0xfd, 0xff, 0x07, 0x00, 0x1d, 0x19, 0x38, 0x49, 0x10, 0x0a, 0x00,
+	0x00,
 }
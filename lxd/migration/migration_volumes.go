@@ -3,6 +3,7 @@ package migration
 import (
 	"fmt"
 	"io"
+	"strings"
 
 	"github.com/lxc/lxd/lxd/operations"
 	"github.com/lxc/lxd/shared"
@@ -27,6 +28,7 @@ type VolumeSourceArgs struct {
 	FinalSync     bool
 	Data          interface{} // Optional store to persist storage driver state between MultiSync phases.
 	ContentType   string
+	Refresh       bool
 }
 
 // VolumeTargetArgs represents the arguments needed to setup a volume migration sink.
@@ -45,7 +47,9 @@ type VolumeTargetArgs struct {
 
 // TypesToHeader converts one or more Types to a MigrationHeader. It uses the first type argument
 // supplied to indicate the preferred migration method and sets the MigrationHeader's Fs type
-// to that. If the preferred type is ZFS then it will also set the header's optional ZfsFeatures.
+// to that. All the types are listed in the header's OfferedTypes, in order of preference, so that
+// the farside can pick the best method both sides support. If ZFS or BTRFS types are present then
+// their optional features are set in the header's ZfsFeatures and BtrfsFeatures.
 // If the fallback Rsync type is present in any of the types even if it is not preferred, then its
 // optional features are added to the header's RsyncFeatures, allowing for fallback negotiation to
 // take place on the farside.
@@ -60,35 +64,42 @@ func TypesToHeader(types ...Type) *MigrationHeader {
 
 	header := MigrationHeader{Fs: &preferredType.FSType}
 
-	// Add ZFS features if preferred type is ZFS.
-	if preferredType.FSType == MigrationFSType_ZFS {
-		features := ZfsFeatures{
-			Compress: &missingFeature,
-		}
-		for _, feature := range preferredType.Features {
-			if feature == "compress" {
-				features.Compress = &hasFeature
-			}
-		}
+	for _, t := range types {
+		header.OfferedTypes = append(header.OfferedTypes, t.FSType)
 
-		header.ZfsFeatures = &features
-	}
+		// Add ZFS features.
+		if t.FSType == MigrationFSType_ZFS && header.ZfsFeatures == nil {
+			features := ZfsFeatures{
+				Compress:         &missingFeature,
+				MigrationRefresh: &missingFeature,
+			}
+			for _, feature := range t.Features {
+				if feature == "compress" {
+					features.Compress = &hasFeature
+				} else if feature == ZFSFeatureMigrationRefresh {
+					features.MigrationRefresh = &hasFeature
+				}
+			}
 
-	// Add BTRFS features if preferred type is BTRFS.
-	if preferredType.FSType == MigrationFSType_BTRFS {
-		features := BtrfsFeatures{
-			MigrationHeader:  &missingFeature,
-			HeaderSubvolumes: &missingFeature,
+			header.ZfsFeatures = &features
 		}
-		for _, feature := range preferredType.Features {
-			if feature == BTRFSFeatureMigrationHeader {
-				features.MigrationHeader = &hasFeature
-			} else if feature == BTRFSFeatureSubvolumes {
-				features.HeaderSubvolumes = &hasFeature
+
+		// Add BTRFS features.
+		if t.FSType == MigrationFSType_BTRFS && header.BtrfsFeatures == nil {
+			features := BtrfsFeatures{
+				MigrationHeader:  &missingFeature,
+				HeaderSubvolumes: &missingFeature,
+			}
+			for _, feature := range t.Features {
+				if feature == BTRFSFeatureMigrationHeader {
+					features.MigrationHeader = &hasFeature
+				} else if feature == BTRFSFeatureSubvolumes {
+					features.HeaderSubvolumes = &hasFeature
+				}
 			}
-		}
 
-		header.BtrfsFeatures = &features
+			header.BtrfsFeatures = &features
+		}
 	}
 
 	// Check all the types for an Rsync method, if found add its features to the header's RsyncFeatures list.
@@ -123,16 +134,52 @@ func TypesToHeader(types ...Type) *MigrationHeader {
 	return &header
 }
 
-// MatchTypes attempts to find matching migration transport types between an offered type sent from a remote
+// OfferedFSTypes returns the transport types offered in a migration header in order of preference.
+// Older servers only send their preferred type.
+func OfferedFSTypes(offer *MigrationHeader) []MigrationFSType {
+	offeredFSTypes := []MigrationFSType{offer.GetFs()}
+	for _, offeredType := range offer.GetOfferedTypes() {
+		if !fsTypeInSlice(offeredType, offeredFSTypes) {
+			offeredFSTypes = append(offeredFSTypes, offeredType)
+		}
+	}
+
+	return offeredFSTypes
+}
+
+// FSTypes returns the transport types of the given Types.
+func FSTypes(types []Type) []MigrationFSType {
+	fsTypes := make([]MigrationFSType, 0, len(types))
+	for _, t := range types {
+		fsTypes = append(fsTypes, t.FSType)
+	}
+
+	return fsTypes
+}
+
+func fsTypeInSlice(fsType MigrationFSType, list []MigrationFSType) bool {
+	for _, entry := range list {
+		if entry == fsType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MatchTypes attempts to find matching migration transport types between the types offered by a remote
 // source and the types supported by a local storage pool. If matches are found then one or more Types are
-// returned containing the method and the matching optional features present in both. The function also takes a
-// fallback type which is used as an additional offer type preference in case the preferred remote type is not
-// compatible with the local type available. It is expected that both sides of the migration will support the
-// fallback type for the volume's content type that is being migrated.
+// returned, in our order of preference, containing the method and the matching optional features present in
+// both. The function also takes a fallback type which is used as an additional offer type preference in case
+// none of the remote types are compatible with the local types available. It is expected that both sides of the
+// migration will support the fallback type for the volume's content type that is being migrated.
 func MatchTypes(offer *MigrationHeader, fallbackType MigrationFSType, ourTypes []Type) ([]Type, error) {
-	// Generate an offer types slice from the preferred type supplied from remote and the
+	// Generate an offer types slice from the types supplied from remote and the
 	// fallback type supplied based on the content type of the transfer.
-	offeredFSTypes := []MigrationFSType{offer.GetFs(), fallbackType}
+	offeredFSTypes := OfferedFSTypes(offer)
+	if !fsTypeInSlice(fallbackType, offeredFSTypes) {
+		offeredFSTypes = append(offeredFSTypes, fallbackType)
+	}
 
 	matchedTypes := []Type{}
 
@@ -193,6 +240,77 @@ func MatchTypes(offer *MigrationHeader, fallbackType MigrationFSType, ourTypes [
 	return matchedTypes, nil
 }
 
+// RefreshTypes filters the matched types down to those that can be used for a refresh. ZFS can only be used
+// when both sides support the migration_refresh feature, as older servers can only send whole volumes with
+// zfs send, which cannot be received into an existing volume.
+func RefreshTypes(types []Type) []Type {
+	refreshTypes := make([]Type, 0, len(types))
+	for _, t := range types {
+		if t.FSType == MigrationFSType_ZFS && !shared.StringInSlice(ZFSFeatureMigrationRefresh, t.Features) {
+			continue
+		}
+
+		refreshTypes = append(refreshTypes, t)
+	}
+
+	return refreshTypes
+}
+
+// FallbackReason returns why the chosen transport type was used rather than the type preferred by the source,
+// given the types the target accepts. An empty string is returned if the preferred type was chosen.
+// The source only learns which type the target picked, so it passes nil targetTypes.
+func FallbackReason(preferred MigrationFSType, chosen MigrationFSType, targetTypes []MigrationFSType) string {
+	if preferred == chosen {
+		return ""
+	}
+
+	if targetTypes == nil {
+		return fmt.Sprintf("%s wasn't selected by the target for this transfer", preferred)
+	}
+
+	if !fsTypeInSlice(preferred, targetTypes) {
+		targetTypeStrings := make([]string, 0, len(targetTypes))
+		for _, targetType := range targetTypes {
+			targetTypeStrings = append(targetTypeStrings, targetType.String())
+		}
+
+		return fmt.Sprintf("%s isn't supported by the target storage pool for this transfer (supported types: %s)", preferred, strings.Join(targetTypeStrings, ", "))
+	}
+
+	return fmt.Sprintf("%s can't be used for this transfer", preferred)
+}
+
+// SetOperationMigrationType records the transport type negotiated for a volume in the operation metadata,
+// along with the reason for not using the preferred type if any.
+func SetOperationMigrationType(op *operations.Operation, volName string, chosen Type, fallbackReason string) {
+	if op == nil {
+		return
+	}
+
+	meta := op.Metadata()
+	if meta == nil {
+		meta = make(map[string]interface{})
+	}
+
+	migrationTypes, ok := meta["migration_types"].(map[string]interface{})
+	if !ok {
+		migrationTypes = make(map[string]interface{})
+	}
+
+	info := map[string]interface{}{
+		"type":     chosen.FSType.String(),
+		"features": chosen.Features,
+	}
+
+	if fallbackReason != "" {
+		info["fallback_reason"] = fallbackReason
+	}
+
+	migrationTypes[volName] = info
+	meta["migration_types"] = migrationTypes
+	op.UpdateMetadata(meta)
+}
+
 func progressWrapperRender(op *operations.Operation, key string, description string, progressInt int64, speedInt int64) {
 	meta := op.Metadata()
 	if meta == nil {
@@ -205,7 +323,10 @@ func progressWrapperRender(op *operations.Operation, key string, description str
 	}
 
 	if meta[key] != progress {
+		shared.SetProgressMetadata(meta, strings.TrimSuffix(key, "_progress"), description, 0, progressInt, 0, speedInt)
 		meta[key] = progress
+		meta[key+"_bytes"] = progressInt
+		meta[key+"_speed"] = speedInt
 		op.UpdateMetadata(meta)
 	}
 }
@@ -0,0 +1,78 @@
+package migration
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test OfferedFSTypes
+func TestOfferedFSTypes(t *testing.T) {
+	// Test header from an older server only sending its preferred type.
+	fsType := MigrationFSType_ZFS
+	header := &MigrationHeader{Fs: &fsType}
+	assert.Equal(t, []MigrationFSType{MigrationFSType_ZFS}, OfferedFSTypes(header))
+
+	// Test header listing all offered types, with the preferred type not duplicated.
+	header = TypesToHeader(
+		Type{FSType: MigrationFSType_ZFS},
+		Type{FSType: MigrationFSType_RSYNC},
+	)
+	assert.Equal(t, []MigrationFSType{MigrationFSType_ZFS, MigrationFSType_RSYNC}, OfferedFSTypes(header))
+}
+
+// Test MatchTypes
+func TestMatchTypes(t *testing.T) {
+	offer := TypesToHeader(
+		Type{FSType: MigrationFSType_ZFS, Features: []string{"compress"}},
+		Type{FSType: MigrationFSType_BTRFS, Features: []string{BTRFSFeatureMigrationHeader}},
+		Type{FSType: MigrationFSType_RSYNC, Features: []string{"xattrs", "delete", "compress", "bidirectional"}},
+	)
+
+	// Test matching a type that isn't the offer's preferred type, in our order of preference.
+	ourTypes := []Type{
+		{FSType: MigrationFSType_BTRFS, Features: []string{BTRFSFeatureMigrationHeader, BTRFSFeatureSubvolumes}},
+		{FSType: MigrationFSType_RSYNC, Features: []string{"xattrs", "delete", "bidirectional"}},
+	}
+
+	types, err := MatchTypes(offer, MigrationFSType_RSYNC, ourTypes)
+	assert.NoError(t, err)
+	assert.Equal(t, []Type{
+		{FSType: MigrationFSType_BTRFS, Features: []string{BTRFSFeatureMigrationHeader}},
+		{FSType: MigrationFSType_RSYNC, Features: []string{"xattrs", "delete", "bidirectional"}},
+	}, types)
+
+	// Test falling back to the fallback type when it wasn't offered.
+	fsType := MigrationFSType_ZFS
+	offer = &MigrationHeader{Fs: &fsType}
+	ourTypes = []Type{{FSType: MigrationFSType_RSYNC, Features: []string{"xattrs", "delete", "compress", "bidirectional"}}}
+
+	types, err = MatchTypes(offer, MigrationFSType_RSYNC, ourTypes)
+	assert.NoError(t, err)
+	assert.Equal(t, []Type{{FSType: MigrationFSType_RSYNC, Features: []string{"xattrs", "delete", "compress"}}}, types)
+
+	// Test no matching type.
+	ourTypes = []Type{{FSType: MigrationFSType_BLOCK_AND_RSYNC}}
+
+	_, err = MatchTypes(offer, MigrationFSType_RSYNC, ourTypes)
+	assert.EqualError(t, err, "No matching migration types found. Offered types: [ZFS RSYNC], our types: [BLOCK_AND_RSYNC]")
+}
+
+// Test FallbackReason
+func TestFallbackReason(t *testing.T) {
+	// Test preferred type chosen.
+	reason := FallbackReason(MigrationFSType_ZFS, MigrationFSType_ZFS, []MigrationFSType{MigrationFSType_ZFS})
+	assert.Equal(t, "", reason)
+
+	// Test preferred type not supported by the target.
+	reason = FallbackReason(MigrationFSType_ZFS, MigrationFSType_RSYNC, []MigrationFSType{MigrationFSType_BTRFS, MigrationFSType_RSYNC})
+	assert.Equal(t, "ZFS isn't supported by the target storage pool for this transfer (supported types: BTRFS, RSYNC)", reason)
+
+	// Test preferred type supported by the target but not used.
+	reason = FallbackReason(MigrationFSType_ZFS, MigrationFSType_RSYNC, []MigrationFSType{MigrationFSType_ZFS, MigrationFSType_RSYNC})
+	assert.Equal(t, "ZFS can't be used for this transfer", reason)
+
+	// Test target types unknown to the source.
+	reason = FallbackReason(MigrationFSType_ZFS, MigrationFSType_RSYNC, nil)
+	assert.Equal(t, "ZFS wasn't selected by the target for this transfer", reason)
+}
@@ -0,0 +1,575 @@
+package migration
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/lxc/lxd/shared"
+)
+
+// ResumeHeader is the HTTP header through which both ends of a migration websocket agree on using a
+// resumable connection.
+const ResumeHeader = "X-LXD-Migration-Resume"
+
+// ResumeTimeout is how long an interrupted resumable connection waits to be re-established before failing.
+var ResumeTimeout = 2 * time.Minute
+
+// resumeKeepalive is how often an idle resumable connection sends an acknowledgement, so that a
+// connection broken without either end noticing is detected after three missed intervals.
+var resumeKeepalive = 10 * time.Second
+
+// resumeWindow is how many bytes of messages can be sent without the peer having consumed them.
+const resumeWindow = 8 * 1024 * 1024
+
+// resumeAckFrames is how many consumed messages are acknowledged at once while more are queued.
+const resumeAckFrames = 64
+
+const (
+	// frameData carries a message type byte followed by the message.
+	frameData byte = iota
+
+	// frameAck carries the number of messages consumed by the peer.
+	frameAck
+
+	// frameResume carries the number of messages received by the peer, and is the first frame on
+	// each connection.
+	frameResume
+)
+
+// Conn is a migration websocket, either a plain websocket or a ResumableConn.
+type Conn interface {
+	ReadMessage() (int, []byte, error)
+	WriteMessage(messageType int, data []byte) error
+	Close() error
+}
+
+// ConnIO returns a ReadWriteCloser over the messages of a migration websocket.
+func ConnIO(conn Conn) io.ReadWriteCloser {
+	ws, ok := conn.(*websocket.Conn)
+	if ok {
+		return &shared.WebsocketIO{Conn: ws}
+	}
+
+	return &connIO{conn: conn}
+}
+
+type message struct {
+	messageType int
+	data        []byte
+}
+
+// ResumableConn carries websocket messages over a series of websockets, resending whatever the peer
+// didn't receive when the websocket is replaced after a network interruption.
+//
+// The dialing end is given a function to dial a new websocket, the accepting end is given new websockets
+// through Attach.
+type ResumableConn struct {
+	dial func() (*websocket.Conn, error)
+
+	mu      sync.Mutex
+	changed chan struct{}
+
+	conn    *websocket.Conn
+	gen     int
+	resumed bool
+	err     error
+
+	closing    bool
+	sentClose  bool
+	peerClosed bool
+
+	// Messages sent and not yet consumed by the peer, the first being message number acked.
+	unacked      []message
+	unackedBytes int
+	acked        uint64
+	queued       uint64
+	next         uint64
+
+	// Messages received and not yet consumed.
+	received    []message
+	receivedNum uint64
+	consumed    uint64
+	ackSent     uint64
+}
+
+// NewResumableConn returns a resumable connection over the websocket conn. The dial function
+// re-establishes the websocket on the dialing end and is nil on the accepting end.
+func NewResumableConn(conn *websocket.Conn, dial func() (*websocket.Conn, error)) *ResumableConn {
+	c := &ResumableConn{
+		dial:    dial,
+		changed: make(chan struct{}),
+	}
+
+	c.mu.Lock()
+	c.attach(conn)
+	c.mu.Unlock()
+
+	return c
+}
+
+// DialResumable dials a migration websocket, returning a ResumableConn if the peer supports it.
+func DialResumable(dialer *websocket.Dialer, url string) (Conn, error) {
+	header := http.Header{}
+	header.Set(ResumeHeader, "1")
+
+	conn, resp, err := dialer.Dial(url, header)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Header.Get(ResumeHeader) == "" {
+		return conn, nil
+	}
+
+	redial := func() (*websocket.Conn, error) {
+		conn, _, err := dialer.Dial(url, header)
+		return conn, err
+	}
+
+	return NewResumableConn(conn, redial), nil
+}
+
+// Attach replaces the websocket of the accepting end with one dialed anew by the peer.
+func (c *ResumableConn) Attach(conn *websocket.Conn) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.err != nil {
+		return c.err
+	}
+
+	if c.conn != nil {
+		c.conn.Close()
+	}
+
+	c.attach(conn)
+
+	return nil
+}
+
+// attach starts using conn. Must be called with the lock held.
+func (c *ResumableConn) attach(conn *websocket.Conn) {
+	c.gen++
+	c.conn = conn
+	c.resumed = false
+	c.notify()
+
+	go c.reader(conn, c.gen)
+	go c.writer(conn, c.gen)
+}
+
+// notify wakes up everything waiting for a change. Must be called with the lock held.
+func (c *ResumableConn) notify() {
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+// broken handles an error on the websocket of generation gen.
+func (c *ResumableConn) broken(gen int, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if gen != c.gen || c.conn == nil {
+		return
+	}
+
+	c.conn.Close()
+	c.conn = nil
+	c.notify()
+
+	if c.err != nil {
+		return
+	}
+
+	if c.closing || c.peerClosed {
+		c.err = err
+		return
+	}
+
+	if c.dial == nil {
+		time.AfterFunc(ResumeTimeout, func() {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+
+			if c.gen == gen && c.err == nil {
+				c.err = fmt.Errorf("Migration connection wasn't resumed within %s: %w", ResumeTimeout, err)
+				c.notify()
+			}
+		})
+
+		return
+	}
+
+	go c.redial(gen, err)
+}
+
+// redial re-establishes the websocket on the dialing end.
+func (c *ResumableConn) redial(gen int, cause error) {
+	deadline := time.Now().Add(ResumeTimeout)
+
+	for {
+		conn, err := c.dial()
+
+		c.mu.Lock()
+		if c.gen != gen || c.err != nil || c.closing {
+			c.mu.Unlock()
+
+			if conn != nil {
+				conn.Close()
+			}
+
+			return
+		}
+
+		if err == nil {
+			c.attach(conn)
+			c.mu.Unlock()
+			return
+		}
+
+		if time.Now().After(deadline) {
+			c.err = fmt.Errorf("Migration connection couldn't be resumed within %s: %w", ResumeTimeout, cause)
+			c.notify()
+			c.mu.Unlock()
+			return
+		}
+
+		c.mu.Unlock()
+
+		time.Sleep(time.Second)
+	}
+}
+
+// reader receives the frames of the websocket of generation gen.
+func (c *ResumableConn) reader(conn *websocket.Conn, gen int) {
+	for {
+		conn.SetReadDeadline(time.Now().Add(3 * resumeKeepalive))
+
+		_, data, err := conn.ReadMessage()
+		if err == nil {
+			err = c.receive(gen, data)
+		}
+
+		if err != nil {
+			c.broken(gen, err)
+			return
+		}
+	}
+}
+
+// receive handles a frame received on the websocket of generation gen.
+func (c *ResumableConn) receive(gen int, data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if gen != c.gen {
+		return nil
+	}
+
+	if len(data) < 1 {
+		return fmt.Errorf("Empty migration frame")
+	}
+
+	switch data[0] {
+	case frameData:
+		if len(data) < 2 {
+			return fmt.Errorf("Invalid migration data frame")
+		}
+
+		msg := message{messageType: int(data[1]), data: data[2:]}
+		if msg.messageType == websocket.CloseMessage {
+			c.peerClosed = true
+		}
+
+		c.received = append(c.received, msg)
+		c.receivedNum++
+	case frameAck, frameResume:
+		if len(data) != 9 {
+			return fmt.Errorf("Invalid migration frame")
+		}
+
+		num := binary.BigEndian.Uint64(data[1:])
+		if num > c.queued || (data[0] == frameResume && num < c.acked) {
+			return fmt.Errorf("Invalid migration frame count %d", num)
+		}
+
+		for ; c.acked < num; c.acked++ {
+			c.unackedBytes -= len(c.unacked[0].data)
+			c.unacked = c.unacked[1:]
+		}
+
+		if data[0] == frameResume {
+			c.next = num
+			c.resumed = true
+		}
+	default:
+		return fmt.Errorf("Unknown migration frame type %d", data[0])
+	}
+
+	c.notify()
+
+	return nil
+}
+
+// writer sends the frames on the websocket of generation gen.
+func (c *ResumableConn) writer(conn *websocket.Conn, gen int) {
+	c.mu.Lock()
+	frame := c.countFrame(frameResume, c.receivedNum)
+	c.mu.Unlock()
+
+	err := conn.WriteMessage(websocket.BinaryMessage, frame)
+	if err != nil {
+		c.broken(gen, err)
+		return
+	}
+
+	keepalive := time.NewTicker(resumeKeepalive)
+	defer keepalive.Stop()
+
+	ping := false
+	for {
+		c.mu.Lock()
+		if gen != c.gen || c.conn == nil {
+			c.mu.Unlock()
+			return
+		}
+
+		var frames [][]byte
+		if ping || (c.consumed != c.ackSent && (c.consumed-c.ackSent >= resumeAckFrames || len(c.received) == 0)) {
+			frames = append(frames, c.countFrame(frameAck, c.consumed))
+			c.ackSent = c.consumed
+			ping = false
+		}
+
+		if c.next < c.acked {
+			c.next = c.acked
+		}
+
+		start, end := c.next, c.next
+		if c.resumed {
+			for ; end < c.queued; end++ {
+				msg := c.unacked[end-c.acked]
+				frames = append(frames, append([]byte{frameData, byte(msg.messageType)}, msg.data...))
+			}
+		}
+
+		changed := c.changed
+		c.mu.Unlock()
+
+		if len(frames) == 0 {
+			select {
+			case <-changed:
+			case <-keepalive.C:
+				ping = true
+			}
+
+			continue
+		}
+
+		for _, frame := range frames {
+			err := conn.WriteMessage(websocket.BinaryMessage, frame)
+			if err != nil {
+				c.broken(gen, err)
+				return
+			}
+		}
+
+		c.mu.Lock()
+		if gen == c.gen && c.next == start {
+			c.next = end
+			c.notify()
+		}
+
+		c.mu.Unlock()
+	}
+}
+
+// countFrame returns an acknowledgement or resume frame. Must be called with the lock held.
+func (c *ResumableConn) countFrame(frameType byte, num uint64) []byte {
+	frame := make([]byte, 9)
+	frame[0] = frameType
+	binary.BigEndian.PutUint64(frame[1:], num)
+
+	return frame
+}
+
+// ReadMessage returns the next message sent by the peer.
+func (c *ResumableConn) ReadMessage() (int, []byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for {
+		if len(c.received) > 0 {
+			msg := c.received[0]
+			if msg.messageType == websocket.CloseMessage {
+				return -1, nil, &websocket.CloseError{Code: websocket.CloseNormalClosure}
+			}
+
+			c.received = c.received[1:]
+			c.consumed++
+			c.notify()
+
+			return msg.messageType, msg.data, nil
+		}
+
+		if c.err != nil {
+			return -1, nil, c.err
+		}
+
+		if c.closing {
+			return -1, nil, websocket.ErrCloseSent
+		}
+
+		c.wait()
+	}
+}
+
+// WriteMessage queues a message for sending to the peer, waiting while too much sent data hasn't
+// been consumed by the peer yet.
+func (c *ResumableConn) WriteMessage(messageType int, data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for {
+		if c.err != nil {
+			return c.err
+		}
+
+		if c.closing || c.sentClose {
+			return websocket.ErrCloseSent
+		}
+
+		if len(c.unacked) == 0 || c.unackedBytes+len(data) <= resumeWindow {
+			break
+		}
+
+		c.wait()
+	}
+
+	c.queue(messageType, data)
+
+	return nil
+}
+
+// queue adds a message to be sent. Must be called with the lock held.
+func (c *ResumableConn) queue(messageType int, data []byte) {
+	msg := message{messageType: messageType, data: append([]byte{}, data...)}
+	if messageType == websocket.CloseMessage {
+		c.sentClose = true
+	}
+
+	c.unacked = append(c.unacked, msg)
+	c.unackedBytes += len(msg.data)
+	c.queued++
+	c.notify()
+}
+
+// wait releases the lock until something changes. Must be called with the lock held.
+func (c *ResumableConn) wait() {
+	changed := c.changed
+	c.mu.Unlock()
+	<-changed
+	c.mu.Lock()
+}
+
+// Close sends a close message to the peer, waits for a short while for the queued messages to be
+// sent and then closes the connection for good.
+func (c *ResumableConn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closing {
+		return nil
+	}
+
+	if c.err == nil && !c.sentClose {
+		c.queue(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	}
+
+	c.closing = true
+	c.notify()
+
+	timeout := time.NewTimer(10 * time.Second)
+	defer timeout.Stop()
+
+	expired := false
+	for !expired && c.err == nil && c.conn != nil && c.next < c.queued {
+		changed := c.changed
+		c.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-timeout.C:
+			expired = true
+		}
+
+		c.mu.Lock()
+	}
+
+	if c.conn != nil {
+		c.conn.Close()
+		c.conn = nil
+	}
+
+	if c.err == nil {
+		c.err = websocket.ErrCloseSent
+	}
+
+	c.notify()
+
+	return nil
+}
+
+// connIO is a ReadWriteCloser over the messages of a migration websocket, behaving like shared.WebsocketIO.
+type connIO struct {
+	conn Conn
+	data []byte
+	mu   sync.Mutex
+}
+
+func (w *connIO) Read(p []byte) (int, error) {
+	for len(w.data) == 0 {
+		mt, data, err := w.conn.ReadMessage()
+		if err != nil {
+			return -1, err
+		}
+
+		if mt == websocket.CloseMessage || mt == websocket.TextMessage {
+			return 0, io.EOF
+		}
+
+		w.data = data
+	}
+
+	n := copy(p, w.data)
+	w.data = w.data[n:]
+
+	return n, nil
+}
+
+func (w *connIO) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	err := w.conn.WriteMessage(websocket.BinaryMessage, p)
+	if err != nil {
+		return -1, err
+	}
+
+	return len(p), nil
+}
+
+// Close sends a message indicating the stream is finished, but it does not actually close the
+// connection.
+func (w *connIO) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.conn.WriteMessage(websocket.TextMessage, []byte{})
+}
@@ -0,0 +1,195 @@
+package migration
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// resumeServer accepts resumable migration websockets, attaching reconnections to the first connection.
+type resumeServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	conn     *ResumableConn
+	sockets  []*websocket.Conn
+	refuse   bool
+	accepted chan *ResumableConn
+}
+
+func newResumeServer() *resumeServer {
+	s := &resumeServer{accepted: make(chan *ResumableConn, 1)}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if s.refuse {
+			http.Error(w, "refused", http.StatusForbidden)
+			return
+		}
+
+		header := http.Header{}
+		header.Set(ResumeHeader, "1")
+		socket, err := (&websocket.Upgrader{}).Upgrade(w, r, header)
+		if err != nil {
+			return
+		}
+
+		s.sockets = append(s.sockets, socket)
+		if s.conn == nil {
+			s.conn = NewResumableConn(socket, nil)
+			s.accepted <- s.conn
+			return
+		}
+
+		s.conn.Attach(socket)
+	}))
+
+	return s
+}
+
+// interrupt breaks the websocket currently in use and waits for the peer to reconnect.
+func (s *resumeServer) interrupt() {
+	s.mu.Lock()
+	n := len(s.sockets)
+	s.sockets[n-1].UnderlyingConn().Close()
+	s.mu.Unlock()
+
+	for i := 0; i < 100; i++ {
+		s.mu.Lock()
+		reconnected := len(s.sockets) > n || s.refuse
+		s.mu.Unlock()
+
+		if reconnected {
+			return
+		}
+
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func (s *resumeServer) dial(t *testing.T) (*ResumableConn, *ResumableConn) {
+	client, err := DialResumable(&websocket.Dialer{}, "ws"+strings.TrimPrefix(s.URL, "http"))
+	require.NoError(t, err)
+	require.IsType(t, &ResumableConn{}, client)
+
+	return client.(*ResumableConn), <-s.accepted
+}
+
+// Test that messages arrive intact and in order in both directions when the connection is interrupted.
+func TestResumableConn(t *testing.T) {
+	s := newResumeServer()
+	defer s.Close()
+
+	client, server := s.dial(t)
+
+	go func() {
+		w := ConnIO(client)
+		for i := 0; i < 1000; i++ {
+			_, err := w.Write([]byte(fmt.Sprintf("message %d", i)))
+			if err != nil {
+				return
+			}
+		}
+
+		w.Close()
+	}()
+
+	// Echo the messages back, interrupting the connection a few times on the way.
+	go func() {
+		for i := 0; ; i++ {
+			mt, data, err := server.ReadMessage()
+			if err != nil {
+				return
+			}
+
+			if i%250 == 100 {
+				s.interrupt()
+			}
+
+			err = server.WriteMessage(mt, data)
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	for i := 0; i < 1000; i++ {
+		mt, data, err := client.ReadMessage()
+		require.NoError(t, err)
+		assert.Equal(t, websocket.BinaryMessage, mt)
+		assert.Equal(t, fmt.Sprintf("message %d", i), string(data))
+	}
+
+	// The end of the stream arrives as well.
+	data, err := ioutil.ReadAll(ConnIO(client))
+	assert.NoError(t, err)
+	assert.Empty(t, data)
+
+	s.mu.Lock()
+	assert.Len(t, s.sockets, 5)
+	s.mu.Unlock()
+
+	// Closing one end closes the other.
+	assert.NoError(t, client.Close())
+	_, _, err = server.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseNormalClosure))
+}
+
+// Test that an interrupted connection fails once it can't be resumed in time.
+func TestResumableConn_Timeout(t *testing.T) {
+	timeout := ResumeTimeout
+	ResumeTimeout = 500 * time.Millisecond
+	defer func() { ResumeTimeout = timeout }()
+
+	s := newResumeServer()
+	defer s.Close()
+
+	client, server := s.dial(t)
+
+	err := client.WriteMessage(websocket.BinaryMessage, []byte("before"))
+	require.NoError(t, err)
+
+	_, data, err := server.ReadMessage()
+	require.NoError(t, err)
+	assert.Equal(t, "before", string(data))
+
+	s.mu.Lock()
+	s.refuse = true
+	s.mu.Unlock()
+	s.interrupt()
+
+	_, _, err = client.ReadMessage()
+	assert.Error(t, err)
+
+	_, _, err = server.ReadMessage()
+	assert.Error(t, err)
+
+	err = client.WriteMessage(websocket.BinaryMessage, []byte("after"))
+	assert.Error(t, err)
+}
+
+// Test that a peer not supporting resumable connections gets a plain websocket.
+func TestDialResumable_Unsupported(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		socket, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		if err == nil {
+			socket.Close()
+		}
+	}))
+	defer srv.Close()
+
+	conn, err := DialResumable(&websocket.Dialer{}, "ws"+strings.TrimPrefix(srv.URL, "http"))
+	require.NoError(t, err)
+	assert.IsType(t, &websocket.Conn{}, conn)
+	conn.Close()
+}
@@ -6,6 +6,10 @@ const BTRFSFeatureMigrationHeader = "migration_header"
 // BTRFSFeatureSubvolumes indicates migration can send/recv subvolumes.
 const BTRFSFeatureSubvolumes = "header_subvolumes"
 
+// ZFSFeatureMigrationRefresh indicates refreshes can be done by sending snapshots incrementally from the most
+// recent snapshot both sides have in common.
+const ZFSFeatureMigrationRefresh = "migration_refresh"
+
 // GetRsyncFeaturesSlice returns a slice of strings representing the supported RSYNC features
 func (m *MigrationHeader) GetRsyncFeaturesSlice() []string {
 	features := []string{}
@@ -44,6 +48,10 @@ func (m *MigrationHeader) GetZfsFeaturesSlice() []string {
 		if m.ZfsFeatures.Compress != nil && *m.ZfsFeatures.Compress == true {
 			features = append(features, "compress")
 		}
+
+		if m.ZfsFeatures.MigrationRefresh != nil && *m.ZfsFeatures.MigrationRefresh == true {
+			features = append(features, ZFSFeatureMigrationRefresh)
+		}
 	}
 
 	return features
@@ -2,17 +2,14 @@ package migration
 
 import (
 	"fmt"
-	"io/ioutil"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/gorilla/websocket"
-
-	"github.com/lxc/lxd/shared"
 )
 
 // ProtoRecv gets a protobuf message from a websocket
-func ProtoRecv(ws *websocket.Conn, msg proto.Message) error {
-	mt, r, err := ws.NextReader()
+func ProtoRecv(ws Conn, msg proto.Message) error {
+	mt, buf, err := ws.ReadMessage()
 	if err != nil {
 		return err
 	}
@@ -21,11 +18,6 @@ func ProtoRecv(ws *websocket.Conn, msg proto.Message) error {
 		return fmt.Errorf("Only binary messages allowed")
 	}
 
-	buf, err := ioutil.ReadAll(r)
-	if err != nil {
-		return err
-	}
-
 	err = proto.Unmarshal(buf, msg)
 	if err != nil {
 		return err
@@ -35,19 +27,13 @@ func ProtoRecv(ws *websocket.Conn, msg proto.Message) error {
 }
 
 // ProtoSend sends a protobuf message over a websocket
-func ProtoSend(ws *websocket.Conn, msg proto.Message) error {
-	w, err := ws.NextWriter(websocket.BinaryMessage)
-	if err != nil {
-		return err
-	}
-	defer w.Close()
-
+func ProtoSend(ws Conn, msg proto.Message) error {
 	data, err := proto.Marshal(msg)
 	if err != nil {
 		return err
 	}
 
-	err = shared.WriteAll(w, data)
+	err = ws.WriteMessage(websocket.BinaryMessage, data)
 	if err != nil {
 		return err
 	}
@@ -56,7 +42,7 @@ func ProtoSend(ws *websocket.Conn, msg proto.Message) error {
 }
 
 // ProtoSendControl sends a migration control message over a websocket
-func ProtoSendControl(ws *websocket.Conn, err error) {
+func ProtoSendControl(ws Conn, err error) {
 	message := ""
 	if err != nil {
 		message = err.Error()
@@ -662,14 +662,8 @@ func (n *bridge) setup(oldConfig map[string]string) error {
 	mtu := ""
 	if n.config["bridge.mtu"] != "" {
 		mtu = n.config["bridge.mtu"]
-	} else if len(tunnels) > 0 {
-		mtu = "1400"
-	} else if n.config["bridge.mode"] == "fan" {
-		if n.config["fan.type"] == "ipip" {
-			mtu = "1480"
-		} else {
-			mtu = "1450"
-		}
+	} else {
+		mtu = n.autoMTU(tunnels)
 	}
 
 	// Attempt to add a dummy device to the bridge to force the MTU.
@@ -1208,35 +1202,6 @@ func (n *bridge) setup(oldConfig map[string]string) error {
 			fanAddress = fmt.Sprintf("%s/24", addr[0])
 		}
 
-		// Update the MTU based on overlay device (if available).
-		fanMtuInt, err := GetDevMTU(devName)
-		if err == nil {
-			// Apply overhead.
-			if n.config["fan.type"] == "ipip" {
-				fanMtuInt = fanMtuInt - 20
-			} else {
-				fanMtuInt = fanMtuInt - 50
-			}
-
-			// Apply changes.
-			fanMtu := fmt.Sprintf("%d", fanMtuInt)
-			if fanMtu != mtu {
-				mtu = fanMtu
-				if n.config["bridge.driver"] != "openvswitch" {
-					mtuLink := &ip.Link{Name: fmt.Sprintf("%s-mtu", n.name)}
-					err = mtuLink.SetMTU(mtu)
-					if err != nil {
-						return err
-					}
-				}
-
-				err = bridgeLink.SetMTU(mtu)
-				if err != nil {
-					return err
-				}
-			}
-		}
-
 		// Parse the host subnet.
 		_, hostSubnet, err := net.ParseCIDR(fmt.Sprintf("%s/24", addr[0]))
 		if err != nil {
@@ -1957,6 +1922,92 @@ func (n *bridge) HandleHeartbeat(heartbeatData *cluster.APIHeartbeat) error {
 	return nil
 }
 
+// autoMTU returns the MTU to use for the bridge when bridge.mtu isn't set. When the fan or tunnels are in use
+// this is the lowest MTU of the interfaces the encapsulated traffic is sent over, minus the encapsulation overhead.
+// Returns empty string if no encapsulation is in use.
+func (n *bridge) autoMTU(tunnels []string) string {
+	var mtu uint32
+
+	// applyOverhead lowers the MTU to accommodate an encapsulation overhead on top of the named device's MTU.
+	// If the device isn't known then fallbackMTU is used instead.
+	applyOverhead := func(devName string, overhead uint32, fallbackMTU uint32) {
+		devMTU, err := GetDevMTU(devName)
+		if devName == "" || err != nil || devMTU <= overhead {
+			devMTU = fallbackMTU + overhead
+		}
+
+		if mtu == 0 || devMTU-overhead < mtu {
+			mtu = devMTU - overhead
+		}
+	}
+
+	// deviceForAddress returns the name of the interface that has the specified address, if any.
+	deviceForAddress := func(address string) string {
+		ipAddr := net.ParseIP(address)
+		if ipAddr == nil {
+			return ""
+		}
+
+		ipNet := &net.IPNet{IP: ipAddr, Mask: net.CIDRMask(32, 32)}
+		if ipAddr.To4() == nil {
+			ipNet.Mask = net.CIDRMask(128, 128)
+		}
+
+		_, devName, _ := n.addressForSubnet(ipNet)
+		return devName
+	}
+
+	if n.config["bridge.mode"] == "fan" {
+		devName := ""
+		_, underlaySubnet, err := net.ParseCIDR(n.config["fan.underlay_subnet"])
+		if err == nil {
+			_, devName, _ = n.addressForSubnet(underlaySubnet)
+		}
+
+		if n.config["fan.type"] == "ipip" {
+			applyOverhead(devName, 20, 1480)
+		} else {
+			applyOverhead(devName, 50, 1450)
+		}
+	}
+
+	for _, tunnel := range tunnels {
+		getConfig := func(key string) string {
+			return n.config[fmt.Sprintf("tunnel.%s.%s", tunnel, key)]
+		}
+
+		// IPv6 underlays have an additional 20 bytes of header overhead.
+		var ipOverhead uint32 = 20
+		for _, key := range []string{"local", "remote", "group"} {
+			if strings.Contains(getConfig(key), ":") {
+				ipOverhead = 40
+			}
+		}
+
+		switch getConfig("protocol") {
+		case "gre":
+			// Gretap encapsulates the Ethernet frame inside of a GRE and IP header.
+			applyOverhead(deviceForAddress(getConfig("local")), ipOverhead+4+14, 1400)
+		case "vxlan":
+			devName := getConfig("interface")
+			if devName == "" && getConfig("local") != "" {
+				devName = deviceForAddress(getConfig("local"))
+			} else if devName == "" {
+				_, devName, _ = DefaultGatewaySubnetV4()
+			}
+
+			// VXLAN encapsulates the Ethernet frame inside of a VXLAN, UDP and IP header.
+			applyOverhead(devName, ipOverhead+8+8+14, 1400)
+		}
+	}
+
+	if mtu == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("%d", mtu)
+}
+
 func (n *bridge) getTunnels() []string {
 	tunnels := []string{}
 
@@ -0,0 +1,110 @@
+package network
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test autoMTU
+func TestBridgeAutoMTU(t *testing.T) {
+	loMTU, err := GetDevMTU("lo")
+	if err != nil {
+		t.Skipf("Loopback MTU not available: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		config map[string]string
+		mtu    string
+	}{
+		{
+			name:   "No encapsulation",
+			config: map[string]string{},
+			mtu:    "",
+		},
+		{
+			name: "VXLAN fan without underlay interface",
+			config: map[string]string{
+				"bridge.mode":         "fan",
+				"fan.underlay_subnet": "198.51.100.0/24",
+			},
+			mtu: "1450",
+		},
+		{
+			name: "IPIP fan without underlay interface",
+			config: map[string]string{
+				"bridge.mode":         "fan",
+				"fan.type":            "ipip",
+				"fan.underlay_subnet": "198.51.100.0/24",
+			},
+			mtu: "1480",
+		},
+		{
+			name: "GRE tunnel without underlay interface",
+			config: map[string]string{
+				"tunnel.foo.protocol": "gre",
+				"tunnel.foo.local":    "198.51.100.1",
+				"tunnel.foo.remote":   "198.51.100.2",
+			},
+			mtu: "1400",
+		},
+		{
+			name: "VXLAN tunnel over IPv4",
+			config: map[string]string{
+				"tunnel.foo.protocol":  "vxlan",
+				"tunnel.foo.interface": "lo",
+				"tunnel.foo.group":     "239.0.0.1",
+			},
+			mtu: fmt.Sprintf("%d", loMTU-50),
+		},
+		{
+			name: "VXLAN tunnel over IPv6",
+			config: map[string]string{
+				"tunnel.foo.protocol":  "vxlan",
+				"tunnel.foo.interface": "lo",
+				"tunnel.foo.group":     "ff05::1",
+			},
+			mtu: fmt.Sprintf("%d", loMTU-70),
+		},
+		{
+			name: "VXLAN tunnel over missing interface",
+			config: map[string]string{
+				"tunnel.foo.protocol":  "vxlan",
+				"tunnel.foo.interface": "lxdt-missing0",
+			},
+			mtu: "1400",
+		},
+		{
+			name: "Lowest MTU of several tunnels",
+			config: map[string]string{
+				"tunnel.foo.protocol":  "vxlan",
+				"tunnel.foo.interface": "lo",
+				"tunnel.bar.protocol":  "gre",
+				"tunnel.bar.local":     "198.51.100.1",
+				"tunnel.bar.remote":    "198.51.100.2",
+			},
+			mtu: "1400",
+		},
+		{
+			name: "Lowest MTU of fan and tunnel",
+			config: map[string]string{
+				"bridge.mode":          "fan",
+				"fan.underlay_subnet":  "198.51.100.0/24",
+				"tunnel.foo.protocol":  "vxlan",
+				"tunnel.foo.interface": "lxdt-missing0",
+			},
+			mtu: "1400",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			n := &bridge{}
+			n.config = test.config
+
+			assert.Equal(t, test.mtu, n.autoMTU(n.getTunnels()))
+		})
+	}
+}
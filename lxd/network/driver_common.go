@@ -29,6 +29,7 @@ type Info struct {
 	Projects           bool // Indicates if driver can be used in network enabled projects.
 	NodeSpecificConfig bool // Whether driver has cluster node specific config as a prerequisite for creation.
 	AddressForwards    bool // Indicates if driver supports address forwards.
+	LoadBalancers      bool // Indicates if driver supports load balancers.
 }
 
 // forwardPortMap represents a mapping of listen port(s) to target port(s) for a protocol/target address pair.
@@ -39,6 +40,19 @@ type forwardPortMap struct {
 	protocol      string
 }
 
+// loadBalancerTarget represents a backend target address and port(s) of a load balancer.
+type loadBalancerTarget struct {
+	address net.IP
+	ports   []uint64
+}
+
+// loadBalancerPortMap represents a mapping of listen port(s) to target backends for a protocol.
+type loadBalancerPortMap struct {
+	listenPorts []uint64
+	protocol    string
+	targets     []loadBalancerTarget
+}
+
 // externalSubnetUsage represents usage of a subnet by a network or NIC.
 type externalSubnetUsage struct {
 	subnet          net.IPNet
@@ -191,6 +205,7 @@ func (n *common) Info() Info {
 		Projects:           false,
 		NodeSpecificConfig: true,
 		AddressForwards:    false,
+		LoadBalancers:      false,
 	}
 }
 
@@ -856,6 +871,177 @@ func (n *common) ForwardDelete(listenAddress string, clientType request.ClientTy
 	return ErrNotImplemented
 }
 
+// loadBalancerValidate validates the load balancer request.
+func (n *common) loadBalancerValidate(listenAddress net.IP, loadBalancer *api.NetworkLoadBalancerPut) ([]*loadBalancerPortMap, error) {
+	if listenAddress == nil {
+		return nil, fmt.Errorf("Invalid listen address")
+	}
+
+	listenIsIP4 := listenAddress.To4() != nil
+
+	// For checking target addresses are within network's subnet.
+	netIPKey := "ipv4.address"
+	if !listenIsIP4 {
+		netIPKey = "ipv6.address"
+	}
+
+	netIPAddress := n.config[netIPKey]
+
+	var err error
+	var netSubnet *net.IPNet
+	if netIPAddress != "" {
+		_, netSubnet, err = net.ParseCIDR(n.config[netIPKey])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Look for any unknown config fields.
+	for k := range loadBalancer.Config {
+		// User keys are not validated.
+		if shared.IsUserConfig(k) {
+			continue
+		}
+
+		return nil, fmt.Errorf("Invalid option %q", k)
+	}
+
+	// Validate backends.
+	backendsMap := make(map[string]*loadBalancerTarget, len(loadBalancer.Backends))
+	for backendSpecID, backendSpec := range loadBalancer.Backends {
+		err = validate.IsURLSegmentSafe(backendSpec.Name)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid backend name in backend specification %d: %w", backendSpecID, err)
+		}
+
+		if backendSpec.Name == "" {
+			return nil, fmt.Errorf("Missing backend name in backend specification %d", backendSpecID)
+		}
+
+		if _, found := backendsMap[backendSpec.Name]; found {
+			return nil, fmt.Errorf("Duplicate backend name %q in backend specification %d", backendSpec.Name, backendSpecID)
+		}
+
+		targetAddress := net.ParseIP(backendSpec.TargetAddress)
+		if targetAddress == nil {
+			return nil, fmt.Errorf("Invalid target address in backend specification %d", backendSpecID)
+		}
+
+		targetIsIP4 := targetAddress.To4() != nil
+		if listenIsIP4 != targetIsIP4 {
+			return nil, fmt.Errorf("Cannot mix IP versions in listen address and backend specification %d target address", backendSpecID)
+		}
+
+		// Check target address is within network's subnet.
+		if netSubnet != nil && !SubnetContainsIP(netSubnet, targetAddress) {
+			return nil, fmt.Errorf("Target address is not within the network subnet in backend specification %d", backendSpecID)
+		}
+
+		target := loadBalancerTarget{
+			address: targetAddress,
+		}
+
+		// Check valid target port(s) supplied.
+		for _, pr := range util.SplitNTrimSpace(backendSpec.TargetPort, ",", -1, true) {
+			portFirst, portRange, err := ParsePortRange(pr)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid target port in backend specification %d", backendSpecID)
+			}
+
+			for i := int64(0); i < portRange; i++ {
+				target.ports = append(target.ports, uint64(portFirst+i))
+			}
+		}
+
+		backendsMap[backendSpec.Name] = &target
+	}
+
+	// Validate port rules.
+	validPortProcols := []string{"tcp", "udp"}
+
+	// Used to ensure that each listen port is only used once.
+	listenPorts := map[string]map[int64]struct{}{
+		"tcp": make(map[int64]struct{}),
+		"udp": make(map[int64]struct{}),
+	}
+
+	portMaps := make([]*loadBalancerPortMap, 0, len(loadBalancer.Ports))
+
+	for portSpecID, portSpec := range loadBalancer.Ports {
+		if !shared.StringInSlice(portSpec.Protocol, validPortProcols) {
+			return nil, fmt.Errorf("Invalid port protocol in port specification %d, protocol must be one of: %s", portSpecID, strings.Join(validPortProcols, ", "))
+		}
+
+		// Check valid listen port(s) supplied.
+		listenPortRanges := util.SplitNTrimSpace(portSpec.ListenPort, ",", -1, true)
+		if len(listenPortRanges) <= 0 {
+			return nil, fmt.Errorf("Missing listen port in port specification %d", portSpecID)
+		}
+
+		portMap := loadBalancerPortMap{
+			listenPorts: make([]uint64, 0),
+			protocol:    portSpec.Protocol,
+		}
+
+		for _, pr := range listenPortRanges {
+			portFirst, portRange, err := ParsePortRange(pr)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid listen port in port specification %d: %w", portSpecID, err)
+			}
+
+			for i := int64(0); i < portRange; i++ {
+				port := portFirst + i
+				if _, found := listenPorts[portSpec.Protocol][port]; found {
+					return nil, fmt.Errorf("Duplicate listen port %d for protocol %q in port specification %d", port, portSpec.Protocol, portSpecID)
+				}
+
+				listenPorts[portSpec.Protocol][port] = struct{}{}
+				portMap.listenPorts = append(portMap.listenPorts, uint64(port))
+			}
+		}
+
+		// Check each of the target backends exists and has a compatible number of target ports.
+		if len(portSpec.TargetBackend) <= 0 {
+			return nil, fmt.Errorf("Missing target backend in port specification %d", portSpecID)
+		}
+
+		for _, backendName := range portSpec.TargetBackend {
+			target, found := backendsMap[backendName]
+			if !found {
+				return nil, fmt.Errorf("Invalid target backend name %q in port specification %d", backendName, portSpecID)
+			}
+
+			// Only check if the target port count matches the listen port count if the target ports
+			// don't equal 1, because we allow many-to-one type mapping.
+			targetPortsLen := len(target.ports)
+			if targetPortsLen > 1 && len(portMap.listenPorts) != targetPortsLen {
+				return nil, fmt.Errorf("Mismatch of listen port(s) and target port(s) count for backend %q in port specification %d", backendName, portSpecID)
+			}
+
+			portMap.targets = append(portMap.targets, *target)
+		}
+
+		portMaps = append(portMaps, &portMap)
+	}
+
+	return portMaps, err
+}
+
+// LoadBalancerCreate returns ErrNotImplemented for drivers that do not support load balancers.
+func (n *common) LoadBalancerCreate(loadBalancer api.NetworkLoadBalancersPost, clientType request.ClientType) error {
+	return ErrNotImplemented
+}
+
+// LoadBalancerUpdate returns ErrNotImplemented for drivers that do not support load balancers.
+func (n *common) LoadBalancerUpdate(listenAddress string, newLoadBalancer api.NetworkLoadBalancerPut, clientType request.ClientType) error {
+	return ErrNotImplemented
+}
+
+// LoadBalancerDelete returns ErrNotImplemented for drivers that do not support load balancers.
+func (n *common) LoadBalancerDelete(listenAddress string, clientType request.ClientType) error {
+	return ErrNotImplemented
+}
+
 // forwardBGPSetupPrefixes exports external forward addresses as prefixes.
 func (n *common) forwardBGPSetupPrefixes() error {
 	// Retrieve network forwards before clearing existing prefixes, and separate them by IP family.
@@ -20,6 +20,7 @@ import (
 	"github.com/lxc/lxd/lxd/db"
 	dbCluster "github.com/lxc/lxd/lxd/db/cluster"
 	deviceConfig "github.com/lxc/lxd/lxd/device/config"
+	"github.com/lxc/lxd/lxd/dnsmasq/dhcpalloc"
 	"github.com/lxc/lxd/lxd/instance"
 	"github.com/lxc/lxd/lxd/ip"
 	"github.com/lxc/lxd/lxd/locking"
@@ -99,6 +100,7 @@ func (n *ovn) Info() Info {
 	info.Projects = true
 	info.NodeSpecificConfig = false
 	info.AddressForwards = true
+	info.LoadBalancers = true
 
 	return info
 }
@@ -195,6 +197,7 @@ func (n *ovn) getExternalSubnetInUse(uplinkNetworkName string) ([]externalSubnet
 	var err error
 	var projectNetworks map[string]map[int64]api.Network
 	var projectNetworksForwardsOnUplink map[string]map[int64][]string
+	var projectNetworksLoadBalancersOnUplink map[string]map[int64][]string
 
 	err = n.state.Cluster.Transaction(func(tx *db.ClusterTx) error {
 		// Get all managed networks across all projects.
@@ -209,6 +212,12 @@ func (n *ovn) getExternalSubnetInUse(uplinkNetworkName string) ([]externalSubnet
 			return errors.Wrapf(err, "Failed loading network forward listen addresses")
 		}
 
+		// Get all network load balancer listen addresses for all networks (of any type) connected to our uplink.
+		projectNetworksLoadBalancersOnUplink, err = tx.GetProjectNetworkLoadBalancerListenAddressesByUplink(uplinkNetworkName)
+		if err != nil {
+			return errors.Wrapf(err, "Failed loading network load balancer listen addresses")
+		}
+
 		return nil
 	})
 	if err != nil {
@@ -234,24 +243,26 @@ func (n *ovn) getExternalSubnetInUse(uplinkNetworkName string) ([]externalSubnet
 	externalSubnets = append(externalSubnets, ovnNetworkExternalSubnets...)
 	externalSubnets = append(externalSubnets, ovnNICExternalRoutes...)
 
-	// Add forward listen addresses to this list.
-	for projectName, networks := range projectNetworksForwardsOnUplink {
-		for networkID, listenAddresses := range networks {
-			for _, listenAddress := range listenAddresses {
-				// Convert listen address to subnet.
-				listenAddressNet, err := ParseIPToNet(listenAddress)
-				if err != nil {
-					return nil, fmt.Errorf("Invalid existing forward listen address %q", listenAddress)
-				}
+	// Add forward and load balancer listen addresses to this list.
+	for _, projectNetworksListenAddresses := range []map[string]map[int64][]string{projectNetworksForwardsOnUplink, projectNetworksLoadBalancersOnUplink} {
+		for projectName, networks := range projectNetworksListenAddresses {
+			for networkID, listenAddresses := range networks {
+				for _, listenAddress := range listenAddresses {
+					// Convert listen address to subnet.
+					listenAddressNet, err := ParseIPToNet(listenAddress)
+					if err != nil {
+						return nil, fmt.Errorf("Invalid existing listen address %q", listenAddress)
+					}
 
-				// Create an externalSubnetUsage for the listen address by using the network ID
-				// of the listen address to retrieve the already loaded network name from the
-				// projectNetworks map.
-				externalSubnets = append(externalSubnets, externalSubnetUsage{
-					subnet:         *listenAddressNet,
-					networkProject: projectName,
-					networkName:    projectNetworks[projectName][networkID].Name,
-				})
+					// Create an externalSubnetUsage for the listen address by using the network ID
+					// of the listen address to retrieve the already loaded network name from the
+					// projectNetworks map.
+					externalSubnets = append(externalSubnets, externalSubnetUsage{
+						subnet:         *listenAddressNet,
+						networkProject: projectName,
+						networkName:    projectNetworks[projectName][networkID].Name,
+					})
+				}
 			}
 		}
 	}
@@ -1502,37 +1513,135 @@ func (n *ovn) FillConfig(config map[string]string) error {
 	return nil
 }
 
-// populateAutoConfig replaces "auto" in config with generated values.
-func (n *ovn) populateAutoConfig(config map[string]string) error {
-	changedConfig := false
+// projectSubnetAllocations returns the subnets allocated to the network's project from its uplink network, along
+// with the external subnets already in use on that uplink. Returns nil allocations if the project doesn't restrict
+// the subnets of the uplink network.
+func (n *ovn) projectSubnetAllocations(config map[string]string) ([]*net.IPNet, []*net.IPNet, error) {
+	if n.state == nil {
+		return nil, nil, nil
+	}
 
-	if config["ipv4.address"] == "auto" {
-		subnet, err := randomSubnetV4()
-		if err != nil {
-			return err
+	p, err := n.state.Cluster.GetProject(n.project)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "Failed to load network restrictions from project %q", n.project)
+	}
+
+	if !shared.IsTrue(p.Config["restricted"]) || p.Config["restricted.networks.subnets"] == "" {
+		return nil, nil, nil
+	}
+
+	uplinkNetworkName, err := n.validateUplinkNetwork(p, config["network"])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	allocations, err := n.projectRestrictedSubnets(p, uplinkNetworkName)
+	if err != nil || len(allocations) == 0 {
+		return nil, nil, err
+	}
+
+	externalSubnetsInUse, err := n.getExternalSubnetInUse(uplinkNetworkName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	used := make([]*net.IPNet, 0, len(externalSubnetsInUse))
+	for i := range externalSubnetsInUse {
+		// Skip our own network.
+		if externalSubnetsInUse[i].networkProject == n.project && externalSubnetsInUse[i].networkName == n.name && externalSubnetsInUse[i].instanceDevice == "" {
+			continue
 		}
 
-		config["ipv4.address"] = subnet
+		used = append(used, &externalSubnetsInUse[i].subnet)
+	}
+
+	return allocations, used, nil
+}
+
+// allocateAutoSubnet returns the gateway address and subnet allocated to the network from the project's subnet
+// allocations of the given IP version, or an empty string if the project doesn't allocate subnets of that version.
+// The subnets are a /24 for IPv4 and a /64 for IPv6 (or the whole allocation if smaller).
+func (n *ovn) allocateAutoSubnet(allocations []*net.IPNet, used []*net.IPNet, ipVersion uint) (string, error) {
+	prefixLen := 64
+	maxPrefixLen := 64 // OVN requires at least a /64 for IPv6.
+	if ipVersion == 4 {
+		prefixLen = 24
+		maxPrefixLen = 30
+	}
 
-		if config["ipv4.nat"] == "" {
-			config["ipv4.nat"] = "true"
+	var versionAllocations []*net.IPNet
+	for _, allocation := range allocations {
+		ones, _ := allocation.Mask.Size()
+		if (allocation.IP.To4() != nil) == (ipVersion == 4) && ones <= maxPrefixLen {
+			versionAllocations = append(versionAllocations, allocation)
 		}
+	}
 
-		changedConfig = true
+	if len(versionAllocations) == 0 {
+		return "", nil
 	}
 
-	if config["ipv6.address"] == "auto" {
-		subnet, err := randomSubnetV6()
+	subnet := SubnetAllocate(versionAllocations, prefixLen, used)
+	if subnet == nil {
+		return "", fmt.Errorf("No free IPv%d subnet left in the subnets allocated to the project", ipVersion)
+	}
+
+	gateway := dhcpalloc.GetIP(subnet, 1)
+	ones, _ := subnet.Mask.Size()
+
+	return fmt.Sprintf("%s/%d", gateway.String(), ones), nil
+}
+
+// populateAutoConfig replaces "auto" in config with generated values. In projects restricting the subnets of the
+// uplink network, non-NATed addresses are allocated from those subnets.
+func (n *ovn) populateAutoConfig(config map[string]string) error {
+	changedConfig := false
+
+	var allocations, used []*net.IPNet
+	if (config["ipv4.address"] == "auto" && !shared.IsTrue(config["ipv4.nat"])) || (config["ipv6.address"] == "auto" && !shared.IsTrue(config["ipv6.nat"])) {
+		var err error
+		allocations, used, err = n.projectSubnetAllocations(config)
 		if err != nil {
 			return err
 		}
+	}
 
-		config["ipv6.address"] = subnet
+	for _, ipVersion := range []uint{4, 6} {
+		keyPrefix := fmt.Sprintf("ipv%d", ipVersion)
+		if config[keyPrefix+".address"] != "auto" {
+			continue
+		}
 
-		if config["ipv6.nat"] == "" {
-			config["ipv6.nat"] = "true"
+		var address string
+		var err error
+		if allocations != nil && !shared.IsTrue(config[keyPrefix+".nat"]) {
+			address, err = n.allocateAutoSubnet(allocations, used, ipVersion)
+			if err != nil {
+				return err
+			}
 		}
 
+		if address != "" {
+			if config[keyPrefix+".nat"] == "" {
+				config[keyPrefix+".nat"] = "false"
+			}
+		} else {
+			if ipVersion == 4 {
+				address, err = randomSubnetV4()
+			} else {
+				address, err = randomSubnetV6()
+			}
+
+			if err != nil {
+				return err
+			}
+
+			if config[keyPrefix+".nat"] == "" {
+				config[keyPrefix+".nat"] = "true"
+			}
+		}
+
+		config[keyPrefix+".address"] = address
 		changedConfig = true
 	}
 
@@ -2335,6 +2444,22 @@ func (n *ovn) Delete(clientType request.ClientType) error {
 		if err != nil {
 			return fmt.Errorf("Failed deleting network forwards: %w", err)
 		}
+
+		// Delete any network load balancers.
+		listenAddresses, err = n.state.Cluster.GetNetworkLoadBalancerListenAddresses(n.ID(), memberSpecific)
+		if err != nil {
+			return fmt.Errorf("Failed loading network load balancers: %w", err)
+		}
+
+		loadBalancers = make([]openvswitch.OVNLoadBalancer, 0, len(listenAddresses))
+		for _, listenAddress := range listenAddresses {
+			loadBalancers = append(loadBalancers, n.getLoadBalancerName(listenAddress))
+		}
+
+		err = client.LoadBalancerDelete(loadBalancers...)
+		if err != nil {
+			return fmt.Errorf("Failed deleting network load balancers: %w", err)
+		}
 	}
 
 	return n.common.delete(clientType)
@@ -3574,7 +3699,9 @@ func (n *ovn) forwardFlattenVIPs(listenAddress net.IP, defaultTargetAddress net.
 	if defaultTargetAddress != nil {
 		vips = append(vips, openvswitch.OVNLoadBalancerVIP{
 			ListenAddress: listenAddress,
-			TargetAddress: defaultTargetAddress,
+			Targets: []openvswitch.OVNLoadBalancerTarget{{
+				Address: defaultTargetAddress,
+			}},
 		})
 	}
 
@@ -3596,9 +3723,11 @@ func (n *ovn) forwardFlattenVIPs(listenAddress net.IP, defaultTargetAddress net.
 			vips = append(vips, openvswitch.OVNLoadBalancerVIP{
 				ListenAddress: listenAddress,
 				Protocol:      portMap.protocol,
-				TargetAddress: portMap.targetAddress,
 				ListenPort:    lp,
-				TargetPort:    targetPort,
+				Targets: []openvswitch.OVNLoadBalancerTarget{{
+					Address: portMap.targetAddress,
+					Port:    targetPort,
+				}},
 			})
 		}
 	}
@@ -3606,6 +3735,66 @@ func (n *ovn) forwardFlattenVIPs(listenAddress net.IP, defaultTargetAddress net.
 	return vips
 }
 
+// listenAddressValidate checks the listen address of a forward or load balancer is allowed by the uplink's
+// external routes and the project's restricted subnets, and isn't used by another OVN network or NIC.
+func (n *ovn) listenAddressValidate(listenAddressNet *net.IPNet) error {
+	// Load the project to get uplink network restrictions.
+	p, err := n.state.Cluster.GetProject(n.project)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to load network restrictions from project %q", n.project)
+	}
+
+	// Get uplink routes.
+	_, uplink, _, err := n.state.Cluster.GetNetworkInAnyState(project.Default, n.config["network"])
+	if err != nil {
+		return errors.Wrapf(err, "Failed to load uplink network %q", n.config["network"])
+	}
+
+	uplinkRoutes, err := n.uplinkRoutes(uplink)
+	if err != nil {
+		return err
+	}
+
+	// Get project restricted routes.
+	projectRestrictedSubnets, err := n.projectRestrictedSubnets(p, n.config["network"])
+	if err != nil {
+		return err
+	}
+
+	externalSubnetsInUse, err := n.getExternalSubnetInUse(n.config["network"])
+	if err != nil {
+		return err
+	}
+
+	// Check the listen address subnet is allowed within both the uplink's external routes and any
+	// project restricted subnets.
+	err = n.validateExternalSubnet(uplinkRoutes, projectRestrictedSubnets, listenAddressNet)
+	if err != nil {
+		return err
+	}
+
+	// Check the listen address subnet doesn't fall within any existing OVN network external subnets.
+	for _, externalSubnetUser := range externalSubnetsInUse {
+		// Skip our own network's SNAT address (as it can be used for NICs in the network).
+		if externalSubnetUser.networkSNAT && externalSubnetUser.networkProject == n.project && externalSubnetUser.networkName == n.name {
+			continue
+		}
+
+		// Skip our own network (but not NIC devices on our own network).
+		if externalSubnetUser.networkProject == n.project && externalSubnetUser.networkName == n.name && externalSubnetUser.instanceDevice == "" {
+			continue
+		}
+
+		if SubnetContains(&externalSubnetUser.subnet, listenAddressNet) || SubnetContains(listenAddressNet, &externalSubnetUser.subnet) {
+			// This error is purposefully vague so that it doesn't reveal any names of
+			// resources potentially outside of the network's project.
+			return fmt.Errorf("Listen address %q overlaps with another OVN network or NIC", listenAddressNet.String())
+		}
+	}
+
+	return nil
+}
+
 // ForwardCreate creates a network forward.
 func (n *ovn) ForwardCreate(forward api.NetworkForwardsPost, clientType request.ClientType) error {
 	revert := revert.New()
@@ -3614,12 +3803,17 @@ func (n *ovn) ForwardCreate(forward api.NetworkForwardsPost, clientType request.
 	if clientType == request.ClientTypeNormal {
 		memberSpecific := false // OVN doesn't support per-member forwards.
 
-		// Check if there is an existing forward using the same listen address.
+		// Check if there is an existing forward or load balancer using the same listen address.
 		_, _, err := n.state.Cluster.GetNetworkForward(n.ID(), memberSpecific, forward.ListenAddress)
 		if err == nil {
 			return api.StatusErrorf(http.StatusConflict, "A forward for that listen address already exists")
 		}
 
+		_, _, err = n.state.Cluster.GetNetworkLoadBalancer(n.ID(), memberSpecific, forward.ListenAddress)
+		if err == nil {
+			return api.StatusErrorf(http.StatusConflict, "A load balancer for that listen address already exists")
+		}
+
 		// Convert listen address to subnet so we can check its valid and can be used.
 		listenAddressNet, err := ParseIPToNet(forward.ListenAddress)
 		if err != nil {
@@ -3631,60 +3825,11 @@ func (n *ovn) ForwardCreate(forward api.NetworkForwardsPost, clientType request.
 			return err
 		}
 
-		// Load the project to get uplink network restrictions.
-		p, err := n.state.Cluster.GetProject(n.project)
-		if err != nil {
-			return errors.Wrapf(err, "Failed to load network restrictions from project %q", n.project)
-		}
-
-		// Get uplink routes.
-		_, uplink, _, err := n.state.Cluster.GetNetworkInAnyState(project.Default, n.config["network"])
-		if err != nil {
-			return errors.Wrapf(err, "Failed to load uplink network %q", n.config["network"])
-		}
-
-		uplinkRoutes, err := n.uplinkRoutes(uplink)
-		if err != nil {
-			return err
-		}
-
-		// Get project restricted routes.
-		projectRestrictedSubnets, err := n.projectRestrictedSubnets(p, n.config["network"])
-		if err != nil {
-			return err
-		}
-
-		externalSubnetsInUse, err := n.getExternalSubnetInUse(n.config["network"])
+		err = n.listenAddressValidate(listenAddressNet)
 		if err != nil {
 			return err
 		}
 
-		// Check the listen address subnet is allowed within both the uplink's external routes and any
-		// project restricted subnets.
-		err = n.validateExternalSubnet(uplinkRoutes, projectRestrictedSubnets, listenAddressNet)
-		if err != nil {
-			return err
-		}
-
-		// Check the listen address subnet doesn't fall within any existing OVN network external subnets.
-		for _, externalSubnetUser := range externalSubnetsInUse {
-			// Skip our own network's SNAT address (as it can be used for NICs in the network).
-			if externalSubnetUser.networkSNAT && externalSubnetUser.networkProject == n.project && externalSubnetUser.networkName == n.name {
-				continue
-			}
-
-			// Skip our own network (but not NIC devices on our own network).
-			if externalSubnetUser.networkProject == n.project && externalSubnetUser.networkName == n.name && externalSubnetUser.instanceDevice == "" {
-				continue
-			}
-
-			if SubnetContains(&externalSubnetUser.subnet, listenAddressNet) || SubnetContains(listenAddressNet, &externalSubnetUser.subnet) {
-				// This error is purposefully vague so that it doesn't reveal any names of
-				// resources potentially outside of the network's project.
-				return fmt.Errorf("Forward listen address %q overlaps with another OVN network or NIC", listenAddressNet.String())
-			}
-		}
-
 		client, err := openvswitch.NewOVN(n.state)
 		if err != nil {
 			return fmt.Errorf("Failed to get OVN client: %w", err)
@@ -3861,3 +4006,198 @@ func (n *ovn) ForwardDelete(listenAddress string, clientType request.ClientType)
 
 	return nil
 }
+
+// loadBalancerFlattenVIPs flattens port maps into format compatible with OVN load balancers.
+func (n *ovn) loadBalancerFlattenVIPs(listenAddress net.IP, portMaps []*loadBalancerPortMap) []openvswitch.OVNLoadBalancerVIP {
+	var vips []openvswitch.OVNLoadBalancerVIP
+
+	for _, portMap := range portMaps {
+		for i, lp := range portMap.listenPorts {
+			vip := openvswitch.OVNLoadBalancerVIP{
+				ListenAddress: listenAddress,
+				Protocol:      portMap.protocol,
+				ListenPort:    lp,
+			}
+
+			for _, target := range portMap.targets {
+				targetPort := lp // Default to using same port as listen port for target port.
+				targetPortsLen := len(target.ports)
+
+				if targetPortsLen == 1 {
+					// If a single target port is specified, forward all listen ports to it.
+					targetPort = target.ports[0]
+				} else if targetPortsLen > 1 {
+					// If more than 1 target port specified, use listen port index to get the
+					// target port to use.
+					targetPort = target.ports[i]
+				}
+
+				vip.Targets = append(vip.Targets, openvswitch.OVNLoadBalancerTarget{
+					Address: target.address,
+					Port:    targetPort,
+				})
+			}
+
+			vips = append(vips, vip)
+		}
+	}
+
+	return vips
+}
+
+// LoadBalancerCreate creates a network load balancer.
+func (n *ovn) LoadBalancerCreate(loadBalancer api.NetworkLoadBalancersPost, clientType request.ClientType) error {
+	revert := revert.New()
+	defer revert.Fail()
+
+	if clientType == request.ClientTypeNormal {
+		memberSpecific := false // OVN doesn't support per-member load balancers.
+
+		// Check if there is an existing load balancer or forward using the same listen address.
+		_, _, err := n.state.Cluster.GetNetworkLoadBalancer(n.ID(), memberSpecific, loadBalancer.ListenAddress)
+		if err == nil {
+			return api.StatusErrorf(http.StatusConflict, "A load balancer for that listen address already exists")
+		}
+
+		_, _, err = n.state.Cluster.GetNetworkForward(n.ID(), memberSpecific, loadBalancer.ListenAddress)
+		if err == nil {
+			return api.StatusErrorf(http.StatusConflict, "A forward for that listen address already exists")
+		}
+
+		// Convert listen address to subnet so we can check its valid and can be used.
+		listenAddressNet, err := ParseIPToNet(loadBalancer.ListenAddress)
+		if err != nil {
+			return errors.Wrapf(err, "Failed parsing %q", loadBalancer.ListenAddress)
+		}
+
+		portMaps, err := n.loadBalancerValidate(listenAddressNet.IP, &loadBalancer.NetworkLoadBalancerPut)
+		if err != nil {
+			return err
+		}
+
+		err = n.listenAddressValidate(listenAddressNet)
+		if err != nil {
+			return err
+		}
+
+		client, err := openvswitch.NewOVN(n.state)
+		if err != nil {
+			return fmt.Errorf("Failed to get OVN client: %w", err)
+		}
+
+		// Create load balancer DB record.
+		loadBalancerID, err := n.state.Cluster.CreateNetworkLoadBalancer(n.ID(), memberSpecific, &loadBalancer)
+		if err != nil {
+			return err
+		}
+
+		revert.Add(func() {
+			n.state.Cluster.DeleteNetworkLoadBalancer(n.ID(), loadBalancerID)
+			client.LoadBalancerDelete(n.getLoadBalancerName(loadBalancer.ListenAddress))
+		})
+
+		vips := n.loadBalancerFlattenVIPs(net.ParseIP(loadBalancer.ListenAddress), portMaps)
+
+		err = client.LoadBalancerApply(n.getLoadBalancerName(loadBalancer.ListenAddress), []openvswitch.OVNRouter{n.getRouterName()}, []openvswitch.OVNSwitch{n.getIntSwitchName()}, vips...)
+		if err != nil {
+			return fmt.Errorf("Failed applying OVN load balancer: %w", err)
+		}
+	}
+
+	revert.Success()
+	return nil
+}
+
+// LoadBalancerUpdate updates a network load balancer.
+func (n *ovn) LoadBalancerUpdate(listenAddress string, req api.NetworkLoadBalancerPut, clientType request.ClientType) error {
+	revert := revert.New()
+	defer revert.Fail()
+
+	if clientType == request.ClientTypeNormal {
+		memberSpecific := false // OVN doesn't support per-member load balancers.
+		curLoadBalancerID, curLoadBalancer, err := n.state.Cluster.GetNetworkLoadBalancer(n.ID(), memberSpecific, listenAddress)
+		if err != nil {
+			return err
+		}
+
+		portMaps, err := n.loadBalancerValidate(net.ParseIP(curLoadBalancer.ListenAddress), &req)
+		if err != nil {
+			return err
+		}
+
+		curLoadBalancerEtagHash, err := util.EtagHash(curLoadBalancer.Etag())
+		if err != nil {
+			return err
+		}
+
+		newLoadBalancer := api.NetworkLoadBalancer{
+			ListenAddress:          curLoadBalancer.ListenAddress,
+			NetworkLoadBalancerPut: req,
+		}
+
+		newLoadBalancerEtagHash, err := util.EtagHash(newLoadBalancer.Etag())
+		if err != nil {
+			return err
+		}
+
+		if curLoadBalancerEtagHash == newLoadBalancerEtagHash {
+			return nil // Nothing has changed.
+		}
+
+		client, err := openvswitch.NewOVN(n.state)
+		if err != nil {
+			return fmt.Errorf("Failed to get OVN client: %w", err)
+		}
+
+		vips := n.loadBalancerFlattenVIPs(net.ParseIP(newLoadBalancer.ListenAddress), portMaps)
+		err = client.LoadBalancerApply(n.getLoadBalancerName(newLoadBalancer.ListenAddress), []openvswitch.OVNRouter{n.getRouterName()}, []openvswitch.OVNSwitch{n.getIntSwitchName()}, vips...)
+		if err != nil {
+			return fmt.Errorf("Failed applying OVN load balancer: %w", err)
+		}
+
+		revert.Add(func() {
+			// Apply old settings to OVN on failure.
+			curPortMaps, err := n.loadBalancerValidate(net.ParseIP(curLoadBalancer.ListenAddress), &curLoadBalancer.NetworkLoadBalancerPut)
+			if err == nil {
+				vips := n.loadBalancerFlattenVIPs(net.ParseIP(curLoadBalancer.ListenAddress), curPortMaps)
+				client.LoadBalancerApply(n.getLoadBalancerName(curLoadBalancer.ListenAddress), []openvswitch.OVNRouter{n.getRouterName()}, []openvswitch.OVNSwitch{n.getIntSwitchName()}, vips...)
+			}
+		})
+
+		err = n.state.Cluster.UpdateNetworkLoadBalancer(n.ID(), curLoadBalancerID, &newLoadBalancer.NetworkLoadBalancerPut)
+		if err != nil {
+			return err
+		}
+	}
+
+	revert.Success()
+	return nil
+}
+
+// LoadBalancerDelete deletes a network load balancer.
+func (n *ovn) LoadBalancerDelete(listenAddress string, clientType request.ClientType) error {
+	if clientType == request.ClientTypeNormal {
+		memberSpecific := false // OVN doesn't support per-member load balancers.
+		loadBalancerID, loadBalancer, err := n.state.Cluster.GetNetworkLoadBalancer(n.ID(), memberSpecific, listenAddress)
+		if err != nil {
+			return err
+		}
+
+		client, err := openvswitch.NewOVN(n.state)
+		if err != nil {
+			return fmt.Errorf("Failed to get OVN client: %w", err)
+		}
+
+		err = client.LoadBalancerDelete(n.getLoadBalancerName(loadBalancer.ListenAddress))
+		if err != nil {
+			return fmt.Errorf("Failed deleting OVN load balancer: %w", err)
+		}
+
+		err = n.state.Cluster.DeleteNetworkLoadBalancer(n.ID(), loadBalancerID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
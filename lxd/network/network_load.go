@@ -2,6 +2,7 @@ package network
 
 import (
 	"github.com/lxc/lxd/lxd/state"
+	"github.com/lxc/lxd/shared/api"
 )
 
 var drivers = map[string]func() Network{
@@ -24,6 +25,37 @@ func LoadByType(driverType string) (Type, error) {
 	return n, nil
 }
 
+// Validate validates the name and configuration of a network that doesn't exist yet.
+func Validate(s *state.State, projectName string, req api.NetworksPost) error {
+	driverFunc, ok := drivers[req.Type]
+	if !ok {
+		return ErrUnknownDriver
+	}
+
+	n := driverFunc()
+	n.init(s, -1, projectName, &api.Network{Name: req.Name, Description: req.Description, Type: req.Type, Config: req.Config}, nil)
+
+	err := n.ValidateName(req.Name)
+	if err != nil {
+		return err
+	}
+
+	return n.Validate(req.Config)
+}
+
+// FillConfig populates the default configuration of a network that doesn't exist yet.
+func FillConfig(s *state.State, projectName string, req api.NetworksPost) error {
+	driverFunc, ok := drivers[req.Type]
+	if !ok {
+		return ErrUnknownDriver
+	}
+
+	n := driverFunc()
+	n.init(s, -1, projectName, &api.Network{Name: req.Name, Description: req.Description, Type: req.Type, Config: req.Config}, nil)
+
+	return n.FillConfig(req.Config)
+}
+
 // LoadByName loads an instantiated network from the database by project and name.
 func LoadByName(s *state.State, projectName string, name string) (Network, error) {
 	id, netInfo, netNodes, err := s.Cluster.GetNetworkInAnyState(projectName, name)
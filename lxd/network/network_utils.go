@@ -1178,6 +1178,63 @@ func SubnetContains(outerSubnet *net.IPNet, innerSubnet *net.IPNet) bool {
 	return true
 }
 
+// SubnetOverlaps returns true if the two subnets have any IP addresses in common.
+func SubnetOverlaps(subnet1 *net.IPNet, subnet2 *net.IPNet) bool {
+	return SubnetContains(subnet1, subnet2) || SubnetContains(subnet2, subnet1)
+}
+
+// SubnetAllocate returns the first subnet of the given prefix length within the allocated subnets which doesn't
+// overlap with any of the used subnets, or nil if there is none. Allocated subnets which are smaller than the
+// prefix length are used whole.
+func SubnetAllocate(allocated []*net.IPNet, prefixLen int, used []*net.IPNet) *net.IPNet {
+	for _, allocation := range allocated {
+		ones, bits := allocation.Mask.Size()
+		if prefixLen > bits {
+			continue
+		}
+
+		size := prefixLen
+		if ones > size {
+			size = ones
+		}
+
+		count := big.NewInt(1)
+		count.Lsh(count, uint(size-ones))
+
+		step := big.NewInt(1)
+		step.Lsh(step, uint(bits-size))
+
+		start := big.NewInt(0)
+		start.SetBytes(allocation.IP.Mask(allocation.Mask))
+
+		// Limit the number of candidates considered in large allocations.
+		for i := int64(0); i < 65536 && big.NewInt(i).Cmp(count) < 0; i++ {
+			ipBig := big.NewInt(i)
+			ipBig.Mul(ipBig, step)
+			ipBig.Add(ipBig, start)
+
+			ip := make(net.IP, bits/8)
+			ipBig.FillBytes(ip)
+
+			candidate := &net.IPNet{IP: ip, Mask: net.CIDRMask(size, bits)}
+
+			overlaps := false
+			for _, usedSubnet := range used {
+				if SubnetOverlaps(usedSubnet, candidate) {
+					overlaps = true
+					break
+				}
+			}
+
+			if !overlaps {
+				return candidate
+			}
+		}
+	}
+
+	return nil
+}
+
 // SubnetContainsIP returns true if outsetSubnet contains IP address.
 func SubnetContainsIP(outerSubnet *net.IPNet, ip net.IP) bool {
 	// Convert ip to ipNet.
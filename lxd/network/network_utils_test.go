@@ -137,3 +137,37 @@ func Example_ipRangesOverlap() {
 	// Range1: 10.1.1.8-10.1.1.9, Range2: 10.1.1.4, overlapped: false
 
 }
+
+func Example_subnetOverlaps() {
+	_, subnetA, _ := net.ParseCIDR("192.0.2.0/24")
+	_, subnetB, _ := net.ParseCIDR("192.0.2.128/25")
+	_, subnetC, _ := net.ParseCIDR("198.51.100.0/24")
+	_, subnetD, _ := net.ParseCIDR("2001:db8::/64")
+
+	fmt.Printf("%s, %s: %t\n", subnetA, subnetB, SubnetOverlaps(subnetA, subnetB))
+	fmt.Printf("%s, %s: %t\n", subnetB, subnetA, SubnetOverlaps(subnetB, subnetA))
+	fmt.Printf("%s, %s: %t\n", subnetA, subnetC, SubnetOverlaps(subnetA, subnetC))
+	fmt.Printf("%s, %s: %t\n", subnetA, subnetD, SubnetOverlaps(subnetA, subnetD))
+
+	// Output: 192.0.2.0/24, 192.0.2.128/25: true
+	// 192.0.2.128/25, 192.0.2.0/24: true
+	// 192.0.2.0/24, 198.51.100.0/24: false
+	// 192.0.2.0/24, 2001:db8::/64: false
+}
+
+func Example_subnetAllocate() {
+	_, allocatedA, _ := net.ParseCIDR("192.0.2.0/23")
+	_, allocatedB, _ := net.ParseCIDR("198.51.100.0/28")
+	_, usedA, _ := net.ParseCIDR("192.0.2.0/24")
+	_, usedB, _ := net.ParseCIDR("192.0.3.128/25")
+
+	fmt.Println(SubnetAllocate([]*net.IPNet{allocatedA, allocatedB}, 24, nil))
+	fmt.Println(SubnetAllocate([]*net.IPNet{allocatedA, allocatedB}, 24, []*net.IPNet{usedA}))
+	fmt.Println(SubnetAllocate([]*net.IPNet{allocatedA, allocatedB}, 24, []*net.IPNet{usedA, usedB}))
+	fmt.Println(SubnetAllocate([]*net.IPNet{allocatedA}, 24, []*net.IPNet{usedA, usedB}))
+
+	// Output: 192.0.2.0/24
+	// 192.0.3.0/24
+	// 198.51.100.0/28
+	// <nil>
+}
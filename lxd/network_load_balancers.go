@@ -0,0 +1,527 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/gorilla/mux"
+
+	clusterRequest "github.com/lxc/lxd/lxd/cluster/request"
+	"github.com/lxc/lxd/lxd/lifecycle"
+	"github.com/lxc/lxd/lxd/network"
+	"github.com/lxc/lxd/lxd/project"
+	"github.com/lxc/lxd/lxd/request"
+	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/lxd/util"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/version"
+)
+
+var networkLoadBalancersCmd = APIEndpoint{
+	Path: "networks/{networkName}/load-balancers",
+
+	Get:  APIEndpointAction{Handler: networkLoadBalancersGet, AccessHandler: allowProjectPermission("networks", "view")},
+	Post: APIEndpointAction{Handler: networkLoadBalancersPost, AccessHandler: allowProjectPermission("networks", "manage-networks")},
+}
+
+var networkLoadBalancerCmd = APIEndpoint{
+	Path: "networks/{networkName}/load-balancers/{listenAddress}",
+
+	Delete: APIEndpointAction{Handler: networkLoadBalancerDelete, AccessHandler: allowProjectPermission("networks", "manage-networks")},
+	Get:    APIEndpointAction{Handler: networkLoadBalancerGet, AccessHandler: allowProjectPermission("networks", "view")},
+	Put:    APIEndpointAction{Handler: networkLoadBalancerPut, AccessHandler: allowProjectPermission("networks", "manage-networks")},
+	Patch:  APIEndpointAction{Handler: networkLoadBalancerPut, AccessHandler: allowProjectPermission("networks", "manage-networks")},
+}
+
+// API endpoints
+
+// swagger:operation GET /1.0/networks/{networkName}/load-balancers network-load-balancers network_load_balancers_get
+//
+// Get the network load balancers
+//
+// Returns a list of network load balancers (URLs).
+//
+// ---
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+// responses:
+//   "200":
+//     description: API endpoints
+//     schema:
+//       type: object
+//       description: Sync response
+//       properties:
+//         type:
+//           type: string
+//           description: Response type
+//           example: sync
+//         status:
+//           type: string
+//           description: Status description
+//           example: Success
+//         status_code:
+//           type: integer
+//           description: Status code
+//           example: 200
+//         metadata:
+//           type: array
+//           description: List of endpoints
+//           items:
+//             type: string
+//           example: |-
+//             [
+//               "/1.0/networks/ovn0/load-balancers/192.0.2.1",
+//               "/1.0/networks/ovn0/load-balancers/192.0.2.2"
+//             ]
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+
+// swagger:operation GET /1.0/networks/{networkName}/load-balancers?recursion=1 network-load-balancers network_load_balancer_get_recursion1
+//
+// # Get the network load balancers
+//
+// Returns a list of network load balancers (structs).
+//
+// ---
+// produces:
+//   - application/json
+//
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+//
+// responses:
+//
+//	"200":
+//	  description: API endpoints
+//	  schema:
+//	    type: object
+//	    description: Sync response
+//	    properties:
+//	      type:
+//	        type: string
+//	        description: Response type
+//	        example: sync
+//	      status:
+//	        type: string
+//	        description: Status description
+//	        example: Success
+//	      status_code:
+//	        type: integer
+//	        description: Status code
+//	        example: 200
+//	      metadata:
+//	        type: array
+//	        description: List of network load balancers
+//	        items:
+//	          $ref: "#/definitions/NetworkLoadBalancer"
+//	"403":
+//	  $ref: "#/responses/Forbidden"
+//	"500":
+//	  $ref: "#/responses/InternalServerError"
+func networkLoadBalancersGet(d *Daemon, r *http.Request) response.Response {
+	projectName, _, err := project.NetworkProject(d.State().Cluster, projectParam(r))
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	n, err := network.LoadByName(d.State(), projectName, mux.Vars(r)["networkName"])
+	if err != nil {
+		return response.SmartError(fmt.Errorf("Failed loading network: %w", err))
+	}
+
+	if !n.Info().LoadBalancers {
+		return response.BadRequest(fmt.Errorf("Network driver %q does not support load balancers", n.Type()))
+	}
+
+	memberSpecific := false // Get load balancers for all cluster members.
+
+	if util.IsRecursionRequest(r) {
+		records, err := d.State().Cluster.GetNetworkLoadBalancers(n.ID(), memberSpecific)
+		if err != nil {
+			return response.SmartError(fmt.Errorf("Failed loading network load balancers: %w", err))
+		}
+
+		loadBalancers := make([]*api.NetworkLoadBalancer, 0, len(records))
+		for _, record := range records {
+			loadBalancers = append(loadBalancers, record)
+		}
+
+		return response.SyncResponse(true, loadBalancers)
+	}
+
+	listenAddresses, err := d.State().Cluster.GetNetworkLoadBalancerListenAddresses(n.ID(), memberSpecific)
+	if err != nil {
+		return response.SmartError(fmt.Errorf("Failed loading network load balancers: %w", err))
+	}
+
+	loadBalancerURLs := make([]string, 0, len(listenAddresses))
+	for _, listenAddress := range listenAddresses {
+		loadBalancerURLs = append(loadBalancerURLs, fmt.Sprintf("/%s/networks/%s/load-balancers/%s", version.APIVersion, url.PathEscape(n.Name()), url.PathEscape(listenAddress)))
+	}
+
+	return response.SyncResponse(true, loadBalancerURLs)
+}
+
+// swagger:operation POST /1.0/networks/{networkName}/load-balancers network-load-balancers network_load_balancers_post
+//
+// # Add a network load balancer
+//
+// Creates a new network load balancer.
+//
+// ---
+// consumes:
+//   - application/json
+//
+// produces:
+//   - application/json
+//
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+//   - in: body
+//     name: load-balancer
+//     description: Load balancer
+//     required: true
+//     schema:
+//     $ref: "#/definitions/NetworkLoadBalancersPost"
+//
+// responses:
+//
+//	"200":
+//	  $ref: "#/responses/EmptySyncResponse"
+//	"400":
+//	  $ref: "#/responses/BadRequest"
+//	"403":
+//	  $ref: "#/responses/Forbidden"
+//	"500":
+//	  $ref: "#/responses/InternalServerError"
+func networkLoadBalancersPost(d *Daemon, r *http.Request) response.Response {
+	resp := forwardedResponseIfTargetIsRemote(d, r)
+	if resp != nil {
+		return resp
+	}
+
+	projectName, _, err := project.NetworkProject(d.State().Cluster, projectParam(r))
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	// Parse the request into a record.
+	req := api.NetworkLoadBalancersPost{}
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	req.Normalise() // So we handle the request in normalised/canonical form.
+
+	n, err := network.LoadByName(d.State(), projectName, mux.Vars(r)["networkName"])
+	if err != nil {
+		return response.SmartError(fmt.Errorf("Failed loading network: %w", err))
+	}
+
+	if !n.Info().LoadBalancers {
+		return response.BadRequest(fmt.Errorf("Network driver %q does not support load balancers", n.Type()))
+	}
+
+	clientType := clusterRequest.UserAgentClientType(r.Header.Get("User-Agent"))
+
+	err = n.LoadBalancerCreate(req, clientType)
+	if err != nil {
+		return response.SmartError(fmt.Errorf("Failed creating load balancer: %w", err))
+	}
+
+	d.State().Events.SendLifecycle(projectName, lifecycle.NetworkLoadBalancerCreated.Event(n, req.ListenAddress, request.CreateRequestor(r), nil))
+
+	url := fmt.Sprintf("/%s/networks/%s/load-balancers/%s", version.APIVersion, url.PathEscape(n.Name()), url.PathEscape(req.ListenAddress))
+	return response.SyncResponseLocation(true, nil, url)
+}
+
+// swagger:operation DELETE /1.0/networks/{networkName}/load-balancers/{listenAddress} network-load-balancers network_load_balancer_delete
+//
+// # Delete the network load balancer
+//
+// Removes the network load balancer.
+//
+// ---
+// produces:
+//   - application/json
+//
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+//
+// responses:
+//
+//	"200":
+//	  $ref: "#/responses/EmptySyncResponse"
+//	"400":
+//	  $ref: "#/responses/BadRequest"
+//	"403":
+//	  $ref: "#/responses/Forbidden"
+//	"500":
+//	  $ref: "#/responses/InternalServerError"
+func networkLoadBalancerDelete(d *Daemon, r *http.Request) response.Response {
+	resp := forwardedResponseIfTargetIsRemote(d, r)
+	if resp != nil {
+		return resp
+	}
+
+	projectName, _, err := project.NetworkProject(d.State().Cluster, projectParam(r))
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	n, err := network.LoadByName(d.State(), projectName, mux.Vars(r)["networkName"])
+	if err != nil {
+		return response.SmartError(fmt.Errorf("Failed loading network: %w", err))
+	}
+
+	if !n.Info().LoadBalancers {
+		return response.BadRequest(fmt.Errorf("Network driver %q does not support load balancers", n.Type()))
+	}
+
+	listenAddress := mux.Vars(r)["listenAddress"]
+
+	clientType := clusterRequest.UserAgentClientType(r.Header.Get("User-Agent"))
+
+	err = n.LoadBalancerDelete(listenAddress, clientType)
+	if err != nil {
+		return response.SmartError(fmt.Errorf("Failed deleting load balancer: %w", err))
+	}
+
+	d.State().Events.SendLifecycle(projectName, lifecycle.NetworkLoadBalancerDeleted.Event(n, listenAddress, request.CreateRequestor(r), nil))
+
+	return response.EmptySyncResponse
+}
+
+// swagger:operation GET /1.0/networks/{networkName}/load-balancers/{listenAddress} network-load-balancers network_load_balancer_get
+//
+// # Get the network load balancer
+//
+// Gets a specific network load balancer.
+//
+// ---
+// produces:
+//   - application/json
+//
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+//
+// responses:
+//
+//	"200":
+//	  description: Load balancer
+//	  schema:
+//	    type: object
+//	    description: Sync response
+//	    properties:
+//	      type:
+//	        type: string
+//	        description: Response type
+//	        example: sync
+//	      status:
+//	        type: string
+//	        description: Status description
+//	        example: Success
+//	      status_code:
+//	        type: integer
+//	        description: Status code
+//	        example: 200
+//	      metadata:
+//	        $ref: "#/definitions/NetworkLoadBalancer"
+//	"403":
+//	  $ref: "#/responses/Forbidden"
+//	"500":
+//	  $ref: "#/responses/InternalServerError"
+func networkLoadBalancerGet(d *Daemon, r *http.Request) response.Response {
+	resp := forwardedResponseIfTargetIsRemote(d, r)
+	if resp != nil {
+		return resp
+	}
+
+	projectName, _, err := project.NetworkProject(d.State().Cluster, projectParam(r))
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	n, err := network.LoadByName(d.State(), projectName, mux.Vars(r)["networkName"])
+	if err != nil {
+		return response.SmartError(fmt.Errorf("Failed loading network: %w", err))
+	}
+
+	if !n.Info().LoadBalancers {
+		return response.BadRequest(fmt.Errorf("Network driver %q does not support load balancers", n.Type()))
+	}
+
+	listenAddress := mux.Vars(r)["listenAddress"]
+	targetMember := queryParam(r, "target")
+	memberSpecific := targetMember != ""
+
+	_, loadBalancer, err := d.State().Cluster.GetNetworkLoadBalancer(n.ID(), memberSpecific, listenAddress)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponseETag(true, loadBalancer, loadBalancer.Etag())
+}
+
+// swagger:operation PATCH /1.0/networks/{networkName}/load-balancers/{listenAddress} network-load-balancers network_load_balancer_patch
+//
+// Partially update the network load balancer
+//
+// Updates a subset of the network load balancer configuration.
+//
+// ---
+// consumes:
+//   - application/json
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+//   - in: body
+//     name: load-balancer
+//     description: Load balancer configuration
+//     required: true
+//     schema:
+//       $ref: "#/definitions/NetworkLoadBalancerPut"
+// responses:
+//   "200":
+//     $ref: "#/responses/EmptySyncResponse"
+//   "400":
+//     $ref: "#/responses/BadRequest"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "412":
+//     $ref: "#/responses/PreconditionFailed"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+
+// swagger:operation PUT /1.0/networks/{networkName}/load-balancers/{listenAddress} network-load-balancers network_load_balancer_put
+//
+// # Update the network load balancer
+//
+// Updates the entire network load balancer configuration.
+//
+// ---
+// consumes:
+//   - application/json
+//
+// produces:
+//   - application/json
+//
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+//   - in: body
+//     name: load-balancer
+//     description: Load balancer configuration
+//     required: true
+//     schema:
+//     $ref: "#/definitions/NetworkLoadBalancerPut"
+//
+// responses:
+//
+//	"200":
+//	  $ref: "#/responses/EmptySyncResponse"
+//	"400":
+//	  $ref: "#/responses/BadRequest"
+//	"403":
+//	  $ref: "#/responses/Forbidden"
+//	"412":
+//	  $ref: "#/responses/PreconditionFailed"
+//	"500":
+//	  $ref: "#/responses/InternalServerError"
+func networkLoadBalancerPut(d *Daemon, r *http.Request) response.Response {
+	resp := forwardedResponseIfTargetIsRemote(d, r)
+	if resp != nil {
+		return resp
+	}
+
+	projectName, _, err := project.NetworkProject(d.State().Cluster, projectParam(r))
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	n, err := network.LoadByName(d.State(), projectName, mux.Vars(r)["networkName"])
+	if err != nil {
+		return response.SmartError(fmt.Errorf("Failed loading network: %w", err))
+	}
+
+	if !n.Info().LoadBalancers {
+		return response.BadRequest(fmt.Errorf("Network driver %q does not support load balancers", n.Type()))
+	}
+
+	listenAddress := mux.Vars(r)["listenAddress"]
+
+	// Decode the request.
+	req := api.NetworkLoadBalancerPut{}
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	targetMember := queryParam(r, "target")
+	memberSpecific := targetMember != ""
+
+	if r.Method == http.MethodPatch {
+		_, loadBalancer, err := d.State().Cluster.GetNetworkLoadBalancer(n.ID(), memberSpecific, listenAddress)
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		// If load balancer being updated via "patch" method and backends or ports not specified, then merge
+		// existing backends or ports into load balancer.
+		if req.Backends == nil {
+			req.Backends = loadBalancer.Backends
+		}
+
+		if req.Ports == nil {
+			req.Ports = loadBalancer.Ports
+		}
+	}
+
+	req.Normalise() // So we handle the request in normalised/canonical form.
+
+	clientType := clusterRequest.UserAgentClientType(r.Header.Get("User-Agent"))
+
+	err = n.LoadBalancerUpdate(listenAddress, req, clientType)
+	if err != nil {
+		return response.SmartError(fmt.Errorf("Failed updating load balancer: %w", err))
+	}
+
+	d.State().Events.SendLifecycle(projectName, lifecycle.NetworkLoadBalancerUpdated.Event(n, listenAddress, request.CreateRequestor(r), nil))
+
+	return response.EmptySyncResponse
+}
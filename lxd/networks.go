@@ -276,56 +276,9 @@ func networksPost(d *Daemon, r *http.Request) response.Response {
 		return response.BadRequest(err)
 	}
 
-	// Quick checks.
-	if req.Name == "" {
-		return response.BadRequest(fmt.Errorf("No name provided"))
-	}
-
-	if req.Type == "" {
-		if projectName != project.Default {
-			req.Type = "ovn" // Only OVN networks are allowed inside network enabled projects.
-		} else {
-			req.Type = "bridge" // Default to bridge for non-network enabled projects.
-		}
-	}
-
-	if req.Config == nil {
-		req.Config = map[string]string{}
-	}
-
-	netType, err := network.LoadByType(req.Type)
+	netType, err := networksPostValidate(d, projectName, projectConfig, &req)
 	if err != nil {
-		return response.BadRequest(err)
-	}
-
-	err = netType.ValidateName(req.Name)
-	if err != nil {
-		return response.BadRequest(err)
-	}
-
-	netTypeInfo := netType.Info()
-	if projectName != project.Default && !netTypeInfo.Projects {
-		return response.BadRequest(fmt.Errorf("Network type does not support non-default projects"))
-	}
-
-	// Check if project has limits.network and if so check we are allowed to create another network.
-	if projectName != project.Default && projectConfig != nil && projectConfig["limits.networks"] != "" {
-		networksLimit, err := strconv.Atoi(projectConfig["limits.networks"])
-		if err != nil {
-			return response.InternalError(errors.Wrapf(err, "Invalid project limits.network value"))
-		}
-
-		networks, err := d.cluster.GetNetworks(projectName)
-		if err != nil {
-			return response.InternalError(errors.Wrapf(err, "Failed loading project's networks for limits check"))
-		}
-
-		// Only check network limits if the new network name doesn't exist already in networks list.
-		// If it does then this create request will either be for adding a target node to an existing
-		// pending network or it will fail anyway as it is a duplicate.
-		if !shared.StringInSlice(req.Name, networks) && len(networks) >= networksLimit {
-			return response.BadRequest(fmt.Errorf("Networks limit has been reached for project"))
-		}
+		return response.SmartError(err)
 	}
 
 	url := fmt.Sprintf("/%s/networks/%s", version.APIVersion, req.Name)
@@ -350,7 +303,7 @@ func networksPost(d *Daemon, r *http.Request) response.Response {
 
 	targetNode := queryParam(r, "target")
 	if targetNode != "" {
-		if !netTypeInfo.NodeSpecificConfig {
+		if !netType.Info().NodeSpecificConfig {
 			return response.BadRequest(fmt.Errorf("Network type %q does not support node specific config", netType.Type()))
 		}
 
@@ -375,16 +328,88 @@ func networksPost(d *Daemon, r *http.Request) response.Response {
 		return resp
 	}
 
+	err = networksCreate(d, r, projectName, req, netType, clientType)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return resp
+}
+
+// networksPostValidate checks a new network request, filling in the default network type, and returns the
+// network type to use.
+// The caller must hold networkCreateLock.
+func networksPostValidate(d *Daemon, projectName string, projectConfig map[string]string, req *api.NetworksPost) (network.Type, error) {
+	// Quick checks.
+	if req.Name == "" {
+		return nil, api.StatusErrorf(http.StatusBadRequest, "No name provided")
+	}
+
+	if req.Type == "" {
+		if projectName != project.Default {
+			req.Type = "ovn" // Only OVN networks are allowed inside network enabled projects.
+		} else {
+			req.Type = "bridge" // Default to bridge for non-network enabled projects.
+		}
+	}
+
+	if req.Config == nil {
+		req.Config = map[string]string{}
+	}
+
+	netType, err := network.LoadByType(req.Type)
+	if err != nil {
+		return nil, api.StatusErrorf(http.StatusBadRequest, "%v", err)
+	}
+
+	err = netType.ValidateName(req.Name)
+	if err != nil {
+		return nil, api.StatusErrorf(http.StatusBadRequest, "%v", err)
+	}
+
+	if projectName != project.Default && !netType.Info().Projects {
+		return nil, api.StatusErrorf(http.StatusBadRequest, "Network type does not support non-default projects")
+	}
+
+	// Check if project has limits.network and if so check we are allowed to create another network.
+	if projectName != project.Default && projectConfig != nil && projectConfig["limits.networks"] != "" {
+		networksLimit, err := strconv.Atoi(projectConfig["limits.networks"])
+		if err != nil {
+			return nil, errors.Wrapf(err, "Invalid project limits.network value")
+		}
+
+		networks, err := d.cluster.GetNetworks(projectName)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed loading project's networks for limits check")
+		}
+
+		// Only check network limits if the new network name doesn't exist already in networks list.
+		// If it does then this create request will either be for adding a target node to an existing
+		// pending network or it will fail anyway as it is a duplicate.
+		if !shared.StringInSlice(req.Name, networks) && len(networks) >= networksLimit {
+			return nil, api.StatusErrorf(http.StatusBadRequest, "Networks limit has been reached for project")
+		}
+	}
+
+	return netType, nil
+}
+
+// networksCreate creates a network on all cluster members, or finalizes a network previously defined on each
+// of them.
+// The caller must hold networkCreateLock.
+func networksCreate(d *Daemon, r *http.Request, projectName string, req api.NetworksPost, netType network.Type, clientType clusterRequest.ClientType) error {
+	netTypeInfo := netType.Info()
+
 	// Load existing pool if exists, if not don't fail.
 	_, netInfo, _, err := d.cluster.GetNetworkInAnyState(projectName, req.Name)
 	if err != nil && err != db.ErrNoSuchObject {
-		return response.InternalError(err)
+		return err
 	}
 
 	// Check if we're clustered.
 	count, err := cluster.Count(d.State())
 	if err != nil {
-		return response.SmartError(err)
+		return err
 	}
 
 	// No targetNode was specified and we're clustered or there is an existing partially created single node
@@ -411,54 +436,49 @@ func networksPost(d *Daemon, r *http.Request) response.Response {
 				return nil
 			})
 			if err != nil {
-				return response.SmartError(err)
+				return err
 			}
 		}
 
-		err = networksPostCluster(d, projectName, netInfo, req, clientType, netType)
-		if err != nil {
-			return response.SmartError(err)
-		}
-
-		return resp
+		return networksPostCluster(d, projectName, netInfo, req, clientType, netType)
 	}
 
 	// Non-clustered network creation.
 	if netInfo != nil {
-		return response.BadRequest(fmt.Errorf("The network already exists"))
+		return api.StatusErrorf(http.StatusBadRequest, "The network already exists")
 	}
 
 	revert := revert.New()
 	defer revert.Fail()
 
 	// Populate default config.
-	err = netType.FillConfig(req.Config)
+	err = network.FillConfig(d.State(), projectName, req)
 	if err != nil {
-		return response.SmartError(err)
+		return err
 	}
 
 	// Create the database entry.
 	_, err = d.cluster.CreateNetwork(projectName, req.Name, req.Description, netType.DBType(), req.Config)
 	if err != nil {
-		return response.SmartError(errors.Wrapf(err, "Error inserting %q into database", req.Name))
+		return errors.Wrapf(err, "Error inserting %q into database", req.Name)
 	}
 	revert.Add(func() { d.cluster.DeleteNetwork(projectName, req.Name) })
 
 	n, err := network.LoadByName(d.State(), projectName, req.Name)
 	if err != nil {
-		return response.SmartError(err)
+		return err
 	}
 
 	err = doNetworksCreate(d, n, clientType)
 	if err != nil {
-		return response.SmartError(err)
+		return err
 	}
 
 	requestor := request.CreateRequestor(r)
 	d.State().Events.SendLifecycle(projectName, lifecycle.NetworkCreated.Event(n, requestor, nil))
 
 	revert.Success()
-	return resp
+	return nil
 }
 
 // networkPartiallyCreated returns true of supplied network has properties that indicate it has had previous
@@ -505,6 +525,15 @@ func networksPostCluster(d *Daemon, projectName string, netInfo *api.Network, re
 		}
 	}
 
+	// Add default values if we are inserting global config for first time. This is done ahead of the
+	// transaction below as it may need to look up the other networks.
+	if netInfo == nil || !networkPartiallyCreated(netInfo) {
+		err := network.FillConfig(d.State(), projectName, req)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Check that the network is properly defined, get the node-specific configs and merge with global config.
 	var nodeConfigs map[string]map[string]string
 	err := d.cluster.Transaction(func(tx *db.ClusterTx) error {
@@ -530,12 +559,6 @@ func networksPostCluster(d *Daemon, projectName string, netInfo *api.Network, re
 			return err
 		}
 
-		// Add default values if we are inserting global config for first time.
-		err = netType.FillConfig(req.Config)
-		if err != nil {
-			return err
-		}
-
 		// Insert the global config keys.
 		err = tx.CreateNetworkConfig(networkID, 0, req.Config)
 		if err != nil {
@@ -1330,7 +1353,7 @@ func networkLeasesGet(d *Daemon, r *http.Request) response.Response {
 	}
 
 	leases := []api.NetworkLease{}
-	projectMacs := []string{}
+	projectMacs := map[string]string{} // Instance name keyed on MAC address.
 
 	// Get all static leases.
 	if !isClusterNotification(r) {
@@ -1369,7 +1392,7 @@ func networkLeasesGet(d *Daemon, r *http.Request) response.Response {
 
 				// Record the MAC.
 				if dev["hwaddr"] != "" {
-					projectMacs = append(projectMacs, dev["hwaddr"])
+					projectMacs[dev["hwaddr"]] = inst.Name()
 				}
 
 				// Add the lease.
@@ -1380,6 +1403,8 @@ func networkLeasesGet(d *Daemon, r *http.Request) response.Response {
 						Hwaddr:   dev["hwaddr"],
 						Type:     "static",
 						Location: inst.Location(),
+						Project:  inst.Project(),
+						Instance: inst.Name(),
 					})
 				}
 
@@ -1390,6 +1415,8 @@ func networkLeasesGet(d *Daemon, r *http.Request) response.Response {
 						Hwaddr:   dev["hwaddr"],
 						Type:     "static",
 						Location: inst.Location(),
+						Project:  inst.Project(),
+						Instance: inst.Name(),
 					})
 				}
 
@@ -1407,6 +1434,8 @@ func networkLeasesGet(d *Daemon, r *http.Request) response.Response {
 								Hwaddr:   dev["hwaddr"],
 								Type:     "dynamic",
 								Location: inst.Location(),
+								Project:  inst.Project(),
+								Instance: inst.Name(),
 							})
 						}
 					}
@@ -1496,11 +1525,17 @@ func networkLeasesGet(d *Daemon, r *http.Request) response.Response {
 			return response.SmartError(err)
 		}
 
-		// Filter based on project.
+		// Filter based on project and attribute the dynamic leases to their instances.
 		filteredLeases := []api.NetworkLease{}
 		for _, lease := range leases {
-			if lease.Hwaddr != "" && !shared.StringInSlice(lease.Hwaddr, projectMacs) {
-				continue
+			if lease.Hwaddr != "" {
+				instName, found := projectMacs[lease.Hwaddr]
+				if !found {
+					continue
+				}
+
+				lease.Project = instProjectName
+				lease.Instance = instName
 			}
 
 			filteredLeases = append(filteredLeases, lease)
@@ -1,12 +1,16 @@
 package node
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/pkg/errors"
 
 	"github.com/lxc/lxd/lxd/config"
 	"github.com/lxc/lxd/lxd/db"
 	"github.com/lxc/lxd/lxd/util"
 	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/units"
 	"github.com/lxc/lxd/shared/validate"
 )
 
@@ -76,6 +80,33 @@ func (c *Config) DebugAddress() string {
 	return debugAddress
 }
 
+// HTTPSListener is an additional HTTPS listener set through core.https_listeners.
+type HTTPSListener struct {
+	Name    string // Name of the listener, also used to look up its certificate.
+	Scope   string // Part of the API served by the listener ("all" or "metrics").
+	Address string // Address and port to listen on.
+}
+
+// HTTPSListeners returns the additional HTTPS listeners this LXD node should
+// expose (parts of) its API on.
+func (c *Config) HTTPSListeners() []HTTPSListener {
+	listeners, _ := httpsListenersParse(c.m.GetString("core.https_listeners"))
+	return listeners
+}
+
+// AuditLogRetention returns the size above which the audit log is rotated (0 means no limit) and the number
+// of rotated files to keep.
+func (c *Config) AuditLogRetention() (int64, int) {
+	maxSize, _ := units.ParseByteSizeString(c.m.GetString("core.audit_log_max_size"))
+
+	return maxSize, int(c.m.GetInt64("core.audit_log_max_files"))
+}
+
+// LogLevel returns the log level of the given subsystem, if overridden.
+func (c *Config) LogLevel(subsystem string) string {
+	return c.m.GetString(fmt.Sprintf("core.log_level_%s", subsystem))
+}
+
 // MAASMachine returns the MAAS machine this instance is associated with, if
 // any.
 func (c *Config) MAASMachine() string {
@@ -92,6 +123,11 @@ func (c *Config) StorageImagesVolume() string {
 	return c.m.GetString("storage.images_volume")
 }
 
+// StorageRecordingsVolume returns the name of the pool/volume to use for storing exec session recordings
+func (c *Config) StorageRecordingsVolume() string {
+	return c.m.GetString("storage.recordings_volume")
+}
+
 // Dump current configuration keys and their values. Keys with values matching
 // their defaults are omitted.
 func (c *Config) Dump() map[string]interface{} {
@@ -193,6 +229,78 @@ func DebugAddress(node *db.Node) (string, error) {
 	return config.DebugAddress(), nil
 }
 
+// HTTPSListeners is a convenience for loading the node configuration and
+// returning the parsed value of core.https_listeners.
+func HTTPSListeners(node *db.Node) ([]HTTPSListener, error) {
+	var config *Config
+	err := node.Transaction(func(tx *db.NodeTx) error {
+		var err error
+		config, err = ConfigLoad(tx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return config.HTTPSListeners(), nil
+}
+
+// httpsListenersParse parses a comma separated list of NAME:SCOPE:ADDRESS
+// listeners, the address being last so that it may contain colons.
+func httpsListenersParse(value string) ([]HTTPSListener, error) {
+	listeners := []HTTPSListener{}
+	names := map[string]bool{}
+
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		fields := strings.SplitN(entry, ":", 3)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("Invalid listener %q, expected NAME:SCOPE:ADDRESS", entry)
+		}
+
+		listener := HTTPSListener{Name: fields[0], Scope: fields[1], Address: fields[2]}
+
+		if listener.Name == "" || strings.Trim(listener.Name, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_") != "" {
+			return nil, fmt.Errorf("Invalid listener name %q, only letters, digits, dashes and underscores are allowed", listener.Name)
+		}
+
+		if names[listener.Name] {
+			return nil, fmt.Errorf("Duplicate listener name %q", listener.Name)
+		}
+
+		names[listener.Name] = true
+
+		defaultPort := shared.HTTPSDefaultPort
+		switch listener.Scope {
+		case "all":
+		case "metrics":
+			defaultPort = shared.HTTPSMetricsDefaultPort
+		default:
+			return nil, fmt.Errorf("Invalid scope %q for listener %q, expected \"all\" or \"metrics\"", listener.Scope, listener.Name)
+		}
+
+		err := validate.IsListenAddress(true, true, false)(listener.Address)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Invalid address for listener %q", listener.Name)
+		}
+
+		listener.Address = util.CanonicalNetworkAddress(listener.Address, defaultPort)
+		listeners = append(listeners, listener)
+	}
+
+	return listeners, nil
+}
+
+// validateHTTPSListeners checks the value of core.https_listeners.
+func validateHTTPSListeners(value string) error {
+	_, err := httpsListenersParse(value)
+	return err
+}
+
 func (c *Config) update(values map[string]interface{}) (map[string]string, error) {
 	changed, err := c.m.Change(values)
 	if err != nil {
@@ -209,6 +317,10 @@ func (c *Config) update(values map[string]interface{}) (map[string]string, error
 
 // ConfigSchema defines available server configuration keys.
 var ConfigSchema = config.Schema{
+	// Retention of the audit log
+	"core.audit_log_max_files": {Type: config.Int64, Default: "5", Validator: validate.Optional(validate.IsInRange(0, 1000))},
+	"core.audit_log_max_size":  {Default: "100MiB", Validator: validate.Optional(validate.IsSize)},
+
 	// Network address for this LXD server
 	"core.https_address": {Validator: validate.Optional(validate.IsListenAddress(true, true, false))},
 
@@ -224,10 +336,20 @@ var ConfigSchema = config.Schema{
 	// Network address for the debug server
 	"core.debug_address": {Validator: validate.Optional(validate.IsListenAddress(true, true, false))},
 
+	// Additional HTTPS listeners (NAME:SCOPE:ADDRESS)
+	"core.https_listeners": {Validator: validate.Optional(validateHTTPSListeners)},
+
+	// Log level overrides for the subsystems
+	"core.log_level_db":        {Validator: validate.Optional(validate.IsOneOf("debug", "info", "warn", "error"))},
+	"core.log_level_migration": {Validator: validate.Optional(validate.IsOneOf("debug", "info", "warn", "error"))},
+	"core.log_level_network":   {Validator: validate.Optional(validate.IsOneOf("debug", "info", "warn", "error"))},
+	"core.log_level_storage":   {Validator: validate.Optional(validate.IsOneOf("debug", "info", "warn", "error"))},
+
 	// MAAS machine this LXD instance is associated with
 	"maas.machine": {},
 
-	// Storage volumes to store backups/images on
-	"storage.backups_volume": {},
-	"storage.images_volume":  {},
+	// Storage volumes to store backups/images/exec recordings on
+	"storage.backups_volume":    {},
+	"storage.images_volume":     {},
+	"storage.recordings_volume": {},
 }
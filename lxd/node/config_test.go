@@ -142,3 +142,56 @@ func TestClusterAddress(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, "127.0.0.1:666", address)
 }
+
+// The core.https_listeners config key is parsed into listeners, using the
+// default port of their scope.
+func TestHTTPSListeners(t *testing.T) {
+	nodeDB, cleanup := db.NewTestNode(t)
+	defer cleanup()
+
+	listeners, err := node.HTTPSListeners(nodeDB)
+	require.NoError(t, err)
+	assert.Equal(t, []node.HTTPSListener{}, listeners)
+
+	err = nodeDB.Transaction(func(tx *db.NodeTx) error {
+		config, err := node.ConfigLoad(tx)
+		require.NoError(t, err)
+		_, err = config.Replace(map[string]interface{}{"core.https_listeners": "mgmt:metrics:10.0.0.1, local:all:[::1]:8444"})
+		require.NoError(t, err)
+		return nil
+	})
+	require.NoError(t, err)
+
+	listeners, err = node.HTTPSListeners(nodeDB)
+	require.NoError(t, err)
+	assert.Equal(t, []node.HTTPSListener{
+		{Name: "mgmt", Scope: "metrics", Address: "10.0.0.1:9100"},
+		{Name: "local", Scope: "all", Address: "[::1]:8444"},
+	}, listeners)
+}
+
+// Invalid core.https_listeners values are rejected.
+func TestHTTPSListeners_Invalid(t *testing.T) {
+	cases := map[string]string{
+		"no scope":        "mgmt:10.0.0.1",
+		"bad scope":       "mgmt:images:10.0.0.1",
+		"bad name":        "../mgmt:metrics:10.0.0.1",
+		"duplicate name":  "mgmt:metrics:10.0.0.1,mgmt:all:10.0.0.2",
+		"bad address":     "mgmt:metrics:10.0.0.1:foo:bar",
+		"empty name":      ":all:10.0.0.1",
+		"missing address": "mgmt:all",
+	}
+
+	for name, value := range cases {
+		t.Run(name, func(t *testing.T) {
+			tx, cleanup := db.NewTestNodeTx(t)
+			defer cleanup()
+
+			config, err := node.ConfigLoad(tx)
+			require.NoError(t, err)
+
+			_, err = config.Replace(map[string]interface{}{"core.https_listeners": value})
+			assert.Error(t, err)
+		})
+	}
+}
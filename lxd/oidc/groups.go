@@ -0,0 +1,98 @@
+package oidc
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lxc/lxd/lxd/rbac"
+	"github.com/lxc/lxd/shared"
+)
+
+// GroupsMapping maps the groups of OpenID Connect users to their access.
+type GroupsMapping struct {
+	// Groups whose members are server administrators.
+	Admin []string
+
+	// Role of the members of each group, per project.
+	Projects map[string]map[string]string
+}
+
+// ParseGroupsMapping parses a comma separated list of groups granted admin access and a comma
+// separated list of GROUP:PROJECT:ROLE entries.
+func ParseGroupsMapping(admin string, projects string) (*GroupsMapping, error) {
+	mapping := &GroupsMapping{Projects: map[string]map[string]string{}}
+
+	for _, group := range strings.Split(admin, ",") {
+		group = strings.TrimSpace(group)
+		if group != "" {
+			mapping.Admin = append(mapping.Admin, group)
+		}
+	}
+
+	for _, entry := range strings.Split(projects, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		// Group names may contain colons, so split from the end.
+		i := strings.LastIndex(entry, ":")
+		if i <= 0 {
+			return nil, fmt.Errorf("Invalid group mapping %q, must be GROUP:PROJECT:ROLE", entry)
+		}
+
+		j := strings.LastIndex(entry[:i], ":")
+		if j <= 0 {
+			return nil, fmt.Errorf("Invalid group mapping %q, must be GROUP:PROJECT:ROLE", entry)
+		}
+
+		group, project, role := entry[:j], entry[j+1:i], entry[i+1:]
+		if project == "" {
+			return nil, fmt.Errorf("Invalid group mapping %q, must be GROUP:PROJECT:ROLE", entry)
+		}
+
+		_, err := rbac.RolePermissions(role)
+		if err != nil {
+			return nil, err
+		}
+
+		if mapping.Projects[group] == nil {
+			mapping.Projects[group] = map[string]string{}
+		}
+
+		mapping.Projects[group][project] = role
+	}
+
+	return mapping, nil
+}
+
+// UserAccess returns the access granted to a user with the given claims.
+// Users who aren't in any of the mapped groups have no access.
+func (m *GroupsMapping) UserAccess(claims *Claims) (*rbac.UserAccess, error) {
+	ua := &rbac.UserAccess{Projects: map[string][]string{}}
+
+	for _, group := range claims.Groups {
+		if shared.StringInSlice(group, m.Admin) {
+			ua.Admin = true
+			ua.Projects = nil
+			return ua, nil
+		}
+	}
+
+	for _, group := range claims.Groups {
+		for project, role := range m.Projects[group] {
+			permissions, err := rbac.RolePermissions(role)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, permission := range permissions {
+				if !shared.StringInSlice(permission, ua.Projects[project]) {
+					ua.Projects[project] = append(ua.Projects[project], permission)
+				}
+			}
+		}
+	}
+
+	return ua, nil
+}
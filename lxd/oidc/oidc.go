@@ -0,0 +1,263 @@
+package oidc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+// keysRefreshInterval is the minimum time between two refreshes of the issuer's signing keys.
+const keysRefreshInterval = 30 * time.Second
+
+// clockSkew is the tolerance applied when checking the validity period of a token.
+const clockSkew = 30 * time.Second
+
+// Verifier validates OpenID Connect tokens issued by a given identity provider.
+type Verifier struct {
+	issuer      string
+	clientID    string
+	audience    string
+	groupsClaim string
+
+	client *http.Client
+
+	mu          sync.Mutex
+	jwksURL     string
+	keys        map[string]jose.JSONWebKey
+	keysUpdated time.Time
+}
+
+// Claims represents the identity found in a valid token.
+type Claims struct {
+	Subject string
+
+	// Email is only set if the provider verified the address.
+	Email string
+
+	Groups []string
+}
+
+// tokenClaims are the claims LXD reads from a token, on top of the registered ones.
+type tokenClaims struct {
+	jwt.Claims
+
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+}
+
+// Username returns the name identifying the user, their verified email address if known.
+func (c *Claims) Username() string {
+	if c.Email != "" {
+		return c.Email
+	}
+
+	return c.Subject
+}
+
+// NewVerifier returns a Verifier for tokens issued by the given issuer to the given client.
+// If audience is empty, tokens must be issued for the client ID.
+func NewVerifier(issuer string, clientID string, audience string, groupsClaim string) *Verifier {
+	return &Verifier{
+		issuer:      strings.TrimSuffix(issuer, "/"),
+		clientID:    clientID,
+		audience:    audience,
+		groupsClaim: groupsClaim,
+		client:      &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Issuer returns the URL of the identity provider.
+func (v *Verifier) Issuer() string {
+	return v.issuer
+}
+
+// ClientID returns the client ID that clients must use when logging in.
+func (v *Verifier) ClientID() string {
+	return v.clientID
+}
+
+// Audience returns the audience that clients must request tokens for, if any.
+func (v *Verifier) Audience() string {
+	return v.audience
+}
+
+// IsJWT returns whether the token looks like a signed JSON Web Token.
+func IsJWT(token string) bool {
+	_, err := jwt.ParseSigned(token)
+	return err == nil
+}
+
+// Verify checks the signature, issuer, audience and validity period of the token and returns its claims.
+func (v *Verifier) Verify(token string) (*Claims, error) {
+	tok, err := jwt.ParseSigned(token)
+	if err != nil {
+		return nil, fmt.Errorf("Malformed token: %w", err)
+	}
+
+	if len(tok.Headers) != 1 {
+		return nil, fmt.Errorf("Malformed token")
+	}
+
+	key, err := v.key(tok.Headers[0].KeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := tokenClaims{}
+	payload := map[string]interface{}{}
+	err = tok.Claims(key, &claims, &payload)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid token signature")
+	}
+
+	return v.claims(&claims, payload)
+}
+
+func (v *Verifier) claims(claims *tokenClaims, payload map[string]interface{}) (*Claims, error) {
+	if strings.TrimSuffix(claims.Issuer, "/") != v.issuer {
+		return nil, fmt.Errorf("Token issued by unexpected issuer %q", claims.Issuer)
+	}
+
+	audience := v.audience
+	if audience == "" {
+		audience = v.clientID
+	}
+
+	if claims.Expiry == nil {
+		return nil, fmt.Errorf("Token has no expiry")
+	}
+
+	err := claims.ValidateWithLeeway(jwt.Expected{Audience: jwt.Audience{audience}, Time: time.Now()}, clockSkew)
+	if err != nil {
+		switch {
+		case errors.Is(err, jwt.ErrInvalidAudience):
+			return nil, fmt.Errorf("Token not issued for audience %q", audience)
+		case errors.Is(err, jwt.ErrExpired):
+			return nil, fmt.Errorf("Token has expired")
+		case errors.Is(err, jwt.ErrNotValidYet), errors.Is(err, jwt.ErrIssuedInTheFuture):
+			return nil, fmt.Errorf("Token isn't valid yet")
+		}
+
+		return nil, err
+	}
+
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("Token has no subject")
+	}
+
+	result := &Claims{Subject: claims.Subject}
+
+	// Unverified addresses could be set to anything by the user, so they can't identify them.
+	if claims.EmailVerified {
+		result.Email = claims.Email
+	}
+
+	switch groups := payload[v.groupsClaim].(type) {
+	case string:
+		result.Groups = []string{groups}
+	case []interface{}:
+		for _, group := range groups {
+			name, ok := group.(string)
+			if ok {
+				result.Groups = append(result.Groups, name)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// key returns the signing key with the given ID, refreshing the issuer's keys if it isn't known.
+func (v *Verifier) key(keyID string) (*jose.JSONWebKey, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	key, ok := v.keys[keyID]
+	if ok {
+		return &key, nil
+	}
+
+	if time.Since(v.keysUpdated) < keysRefreshInterval {
+		return nil, fmt.Errorf("Unknown signing key %q", keyID)
+	}
+
+	err := v.refreshKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	key, ok = v.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("Unknown signing key %q", keyID)
+	}
+
+	return &key, nil
+}
+
+// refreshKeys fetches the signing keys of the issuer, discovering their location on first use.
+func (v *Verifier) refreshKeys() error {
+	v.keysUpdated = time.Now()
+
+	if v.jwksURL == "" {
+		discovery := struct {
+			Issuer  string `json:"issuer"`
+			JWKSURL string `json:"jwks_uri"`
+		}{}
+
+		err := v.getJSON(v.issuer+"/.well-known/openid-configuration", &discovery)
+		if err != nil {
+			return fmt.Errorf("Failed to discover OpenID Connect provider: %w", err)
+		}
+
+		if discovery.JWKSURL == "" {
+			return fmt.Errorf("OpenID Connect provider doesn't advertise its signing keys")
+		}
+
+		v.jwksURL = discovery.JWKSURL
+	}
+
+	jwks := jose.JSONWebKeySet{}
+	err := v.getJSON(v.jwksURL, &jwks)
+	if err != nil {
+		return fmt.Errorf("Failed to fetch OpenID Connect signing keys: %w", err)
+	}
+
+	keys := map[string]jose.JSONWebKey{}
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		if !jwk.Valid() || !jwk.IsPublic() {
+			continue
+		}
+
+		keys[jwk.KeyID] = jwk
+	}
+
+	v.keys = keys
+
+	return nil
+}
+
+func (v *Verifier) getJSON(url string, target interface{}) error {
+	resp, err := v.client.Get(url)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Unexpected response from %q: %s", url, resp.Status)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(target)
+}
@@ -0,0 +1,126 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+func signToken(t *testing.T, key *rsa.PrivateKey, payload map[string]interface{}) string {
+	signingKey := jose.SigningKey{Algorithm: jose.RS256, Key: jose.JSONWebKey{Key: key, KeyID: "test"}}
+	signer, err := jose.NewSigner(signingKey, (&jose.SignerOptions{}).WithType("JWT"))
+	require.NoError(t, err)
+
+	token, err := jwt.Signed(signer).Claims(payload).CompactSerialize()
+	require.NoError(t, err)
+
+	return token
+}
+
+func TestVerifier(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			_ = json.NewEncoder(w).Encode(map[string]string{"issuer": server.URL, "jwks_uri": server.URL + "/keys"})
+		case "/keys":
+			_ = json.NewEncoder(w).Encode(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{
+				Key:       &key.PublicKey,
+				KeyID:     "test",
+				Algorithm: string(jose.RS256),
+				Use:       "sig",
+			}}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	verifier := NewVerifier(server.URL, "lxd", "", "groups")
+
+	payload := func() map[string]interface{} {
+		return map[string]interface{}{
+			"iss":    server.URL,
+			"aud":    []string{"lxd"},
+			"sub":    "1234",
+			"email":          "user@example.com",
+			"email_verified": true,
+			"groups":         []string{"devs", "ops"},
+			"exp":            time.Now().Add(time.Hour).Unix(),
+		}
+	}
+
+	claims, err := verifier.Verify(signToken(t, key, payload()))
+	require.NoError(t, err)
+	assert.Equal(t, "user@example.com", claims.Username())
+	assert.Equal(t, []string{"devs", "ops"}, claims.Groups)
+
+	unverified := payload()
+	unverified["email_verified"] = false
+	claims, err = verifier.Verify(signToken(t, key, unverified))
+	require.NoError(t, err)
+	assert.Equal(t, "1234", claims.Username())
+
+	expired := payload()
+	expired["exp"] = time.Now().Add(-time.Hour).Unix()
+	_, err = verifier.Verify(signToken(t, key, expired))
+	assert.EqualError(t, err, "Token has expired")
+
+	wrongAudience := payload()
+	wrongAudience["aud"] = "other"
+	_, err = verifier.Verify(signToken(t, key, wrongAudience))
+	assert.EqualError(t, err, `Token not issued for audience "lxd"`)
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	_, err = verifier.Verify(signToken(t, otherKey, payload()))
+	assert.EqualError(t, err, "Invalid token signature")
+}
+
+func TestGroupsMapping(t *testing.T) {
+	mapping, err := ParseGroupsMapping("admins", "devs:dev:admin,devs:prod:viewer,org:ops:prod:operator")
+	require.NoError(t, err)
+
+	ua, err := mapping.UserAccess(&Claims{Subject: "1234", Groups: []string{"admins"}})
+	require.NoError(t, err)
+	assert.True(t, ua.Admin)
+
+	ua, err = mapping.UserAccess(&Claims{Subject: "1234", Groups: []string{"devs", "org:ops"}})
+	require.NoError(t, err)
+	assert.False(t, ua.Admin)
+	assert.Contains(t, ua.Projects["dev"], "manage-containers")
+	assert.Contains(t, ua.Projects["prod"], "operate-containers")
+	assert.NotContains(t, ua.Projects["prod"], "manage-containers")
+
+	ua, err = mapping.UserAccess(&Claims{Subject: "1234"})
+	require.NoError(t, err)
+	assert.False(t, ua.Admin)
+	assert.Empty(t, ua.Projects)
+
+	empty, err := ParseGroupsMapping("", "")
+	require.NoError(t, err)
+
+	ua, err = empty.UserAccess(&Claims{Subject: "1234", Groups: []string{"admins"}})
+	require.NoError(t, err)
+	assert.False(t, ua.Admin)
+	assert.Empty(t, ua.Projects)
+
+	_, err = ParseGroupsMapping("", "devs:dev")
+	assert.Error(t, err)
+
+	_, err = ParseGroupsMapping("", "devs:dev:superuser")
+	assert.Error(t, err)
+}
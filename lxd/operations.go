@@ -1,16 +1,24 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/pkg/errors"
 
+	"github.com/lxc/lxd/lxd/backup"
 	"github.com/lxc/lxd/lxd/cluster"
 	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/instance"
 	"github.com/lxc/lxd/lxd/lifecycle"
 	"github.com/lxc/lxd/lxd/node"
 	"github.com/lxc/lxd/lxd/operations"
@@ -19,6 +27,7 @@ import (
 	"github.com/lxc/lxd/lxd/request"
 	"github.com/lxc/lxd/lxd/response"
 	"github.com/lxc/lxd/lxd/state"
+	"github.com/lxc/lxd/lxd/task"
 	"github.com/lxc/lxd/lxd/util"
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/api"
@@ -39,6 +48,12 @@ var operationsCmd = APIEndpoint{
 	Get: APIEndpointAction{Handler: operationsGet, AccessHandler: allowAuthenticated},
 }
 
+var operationsWaitCmd = APIEndpoint{
+	Path: "operations/wait",
+
+	Get: APIEndpointAction{Handler: operationsWaitGet, AccessHandler: allowAuthenticated},
+}
+
 var operationWait = APIEndpoint{
 	Path: "operations/{id}/wait",
 
@@ -180,6 +195,10 @@ func operationGet(d *Daemon, r *http.Request) response.Response {
 	// First check if the query is for a local operation from this node
 	op, err := operations.OperationGetInternal(id)
 	if err == nil {
+		if !operationUserCanView(r, op.Project()) {
+			return response.NotFound(nil)
+		}
+
 		_, body, err = op.Render()
 		if err != nil {
 			return response.SmartError(err)
@@ -190,6 +209,7 @@ func operationGet(d *Daemon, r *http.Request) response.Response {
 
 	// Then check if the query is from an operation on another node, and, if so, forward it
 	var address string
+	var projectName string
 	err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
 		filter := db.OperationFilter{UUID: &id}
 		ops, err := tx.GetOperations(filter)
@@ -197,7 +217,9 @@ func operationGet(d *Daemon, r *http.Request) response.Response {
 			return err
 		}
 		if len(ops) < 1 {
-			return db.ErrNoSuchObject
+			// Lastly check if this is an operation which is done.
+			body, projectName, err = tx.GetOperationHistory(id)
+			return err
 		}
 		if len(ops) > 1 {
 			return fmt.Errorf("More than one operation matches")
@@ -205,6 +227,11 @@ func operationGet(d *Daemon, r *http.Request) response.Response {
 
 		operation := ops[0]
 
+		projectName, err = operationProjectName(tx, operation)
+		if err != nil {
+			return err
+		}
+
 		address = operation.NodeAddress
 		return nil
 	})
@@ -212,6 +239,14 @@ func operationGet(d *Daemon, r *http.Request) response.Response {
 		return response.SmartError(err)
 	}
 
+	if !operationUserCanView(r, projectName) {
+		return response.NotFound(nil)
+	}
+
+	if body != nil {
+		return response.SyncResponse(true, body)
+	}
+
 	client, err := cluster.Connect(address, d.endpoints.NetworkCert(), d.serverCert(), r, false)
 	if err != nil {
 		return response.SmartError(err)
@@ -245,6 +280,10 @@ func operationDelete(d *Daemon, r *http.Request) response.Response {
 	op, err := operations.OperationGetInternal(id)
 	if err == nil {
 		projectName := op.Project()
+		if !operationUserCanView(r, projectName) {
+			return response.NotFound(nil)
+		}
+
 		if op.Permission() != "" {
 			if projectName == "" {
 				projectName = project.Default
@@ -267,6 +306,7 @@ func operationDelete(d *Daemon, r *http.Request) response.Response {
 
 	// Then check if the query is from an operation on another node, and, if so, forward it
 	var address string
+	var projectName string
 	err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
 		filter := db.OperationFilter{UUID: &id}
 		ops, err := tx.GetOperations(filter)
@@ -282,6 +322,11 @@ func operationDelete(d *Daemon, r *http.Request) response.Response {
 
 		operation := ops[0]
 
+		projectName, err = operationProjectName(tx, operation)
+		if err != nil {
+			return err
+		}
+
 		address = operation.NodeAddress
 		return nil
 	})
@@ -289,6 +334,10 @@ func operationDelete(d *Daemon, r *http.Request) response.Response {
 		return response.SmartError(err)
 	}
 
+	if !operationUserCanView(r, projectName) {
+		return response.NotFound(nil)
+	}
+
 	client, err := cluster.Connect(address, d.endpoints.NetworkCert(), d.serverCert(), r, false)
 	if err != nil {
 		return response.SmartError(err)
@@ -361,6 +410,17 @@ func operationCancel(d *Daemon, r *http.Request, projectName string, op *api.Ope
 // ---
 // produces:
 //   - application/json
+// parameters:
+//   - in: query
+//     name: limit
+//     description: Maximum number of entries to return
+//     type: integer
+//     example: 100
+//   - in: query
+//     name: offset
+//     description: Number of entries to skip
+//     type: integer
+//     example: 200
 // responses:
 //   "200":
 //     description: API endpoints
@@ -407,6 +467,17 @@ func operationCancel(d *Daemon, r *http.Request, projectName string, op *api.Ope
 // ---
 // produces:
 //   - application/json
+// parameters:
+//   - in: query
+//     name: limit
+//     description: Maximum number of entries to return
+//     type: integer
+//     example: 100
+//   - in: query
+//     name: offset
+//     description: Number of entries to skip
+//     type: integer
+//     example: 200
 // responses:
 //   "200":
 //     description: API endpoints
@@ -439,6 +510,15 @@ func operationsGet(d *Daemon, r *http.Request) response.Response {
 	projectName := projectParam(r)
 	recursion := util.IsRecursionRequest(r)
 
+	if !operationUserCanView(r, projectName) {
+		return response.Forbidden(nil)
+	}
+
+	pagination, err := util.PaginationFromRequest(r)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
 	localOperationURLs := func() (shared.Jmap, error) {
 		// Get all the operations
 		localOps := operations.Clone()
@@ -516,7 +596,6 @@ func operationsGet(d *Daemon, r *http.Request) response.Response {
 
 	// Start with local operations
 	var md shared.Jmap
-	var err error
 
 	if recursion {
 		md, err = localOperations()
@@ -538,7 +617,8 @@ func operationsGet(d *Daemon, r *http.Request) response.Response {
 
 	// Return now if not clustered
 	if !clustered {
-		return response.SyncResponse(true, md)
+		md, total := operationsPaginate(md, recursion, pagination)
+		return response.SyncResponseHeaders(true, md, pagination.Headers(total))
 	}
 
 	// Get all nodes with running operations in this project.
@@ -603,7 +683,69 @@ func operationsGet(d *Daemon, r *http.Request) response.Response {
 		}
 	}
 
-	return response.SyncResponse(true, md)
+	md, total := operationsPaginate(md, recursion, pagination)
+	return response.SyncResponseHeaders(true, md, pagination.Headers(total))
+}
+
+// operationsPaginate returns the requested page of operations (ordered by status and then by creation date
+// or URL) along with the total number of operations.
+func operationsPaginate(md shared.Jmap, recursion bool, pagination *util.Pagination) (shared.Jmap, int) {
+	if pagination == nil {
+		return md, -1
+	}
+
+	statuses := make([]string, 0, len(md))
+	for status := range md {
+		statuses = append(statuses, status)
+	}
+
+	sort.Strings(statuses)
+
+	// Flatten the operations in a stable order.
+	type entry struct {
+		status string
+		value  interface{}
+	}
+
+	entries := []entry{}
+	for _, status := range statuses {
+		if recursion {
+			ops := md[status].([]*api.Operation)
+			sort.Slice(ops, func(i, j int) bool {
+				if ops[i].CreatedAt.Equal(ops[j].CreatedAt) {
+					return ops[i].ID < ops[j].ID
+				}
+
+				return ops[i].CreatedAt.Before(ops[j].CreatedAt)
+			})
+
+			for _, op := range ops {
+				entries = append(entries, entry{status: status, value: op})
+			}
+		} else {
+			urls := md[status].([]string)
+			sort.Strings(urls)
+
+			for _, url := range urls {
+				entries = append(entries, entry{status: status, value: url})
+			}
+		}
+	}
+
+	start, end := pagination.Range(len(entries))
+
+	page := shared.Jmap{}
+	for _, e := range entries[start:end] {
+		if recursion {
+			ops, _ := page[e.status].([]*api.Operation)
+			page[e.status] = append(ops, e.value.(*api.Operation))
+		} else {
+			urls, _ := page[e.status].([]string)
+			page[e.status] = append(urls, e.value.(string))
+		}
+	}
+
+	return page, len(entries)
 }
 
 // operationsGetByType gets all operations for a project and type.
@@ -734,7 +876,7 @@ func operationsGetByType(d *Daemon, r *http.Request, projectName string, opType
 //
 // Wait for the operation
 //
-// Waits for the operation to reach a final state (or timeout) and retrieve its final state.
+// Waits for the operation to reach a final state or the requested status (or timeout) and retrieve its state.
 //
 // When accessed by an untrusted user, the secret token must be provided.
 //
@@ -752,6 +894,11 @@ func operationsGetByType(d *Daemon, r *http.Request, projectName string, opType
 //     description: Timeout in seconds (-1 means never)
 //     type: integer
 //     example: -1
+//   - in: query
+//     name: status
+//     description: Status to wait for instead of a final state (Running or Cancelling)
+//     type: string
+//     example: Running
 // responses:
 //   "200":
 //     description: Operation
@@ -782,7 +929,7 @@ func operationsGetByType(d *Daemon, r *http.Request, projectName string, opType
 //
 // Wait for the operation
 //
-// Waits for the operation to reach a final state (or timeout) and retrieve its final state.
+// Waits for the operation to reach a final state or the requested status (or timeout) and retrieve its state.
 //
 // ---
 // produces:
@@ -793,6 +940,11 @@ func operationsGetByType(d *Daemon, r *http.Request, projectName string, opType
 //     description: Timeout in seconds (-1 means never)
 //     type: integer
 //     example: -1
+//   - in: query
+//     name: status
+//     description: Status to wait for instead of a final state (Running or Cancelling)
+//     type: string
+//     example: Running
 // responses:
 //   "200":
 //     description: Operation
@@ -832,6 +984,11 @@ func operationWaitGet(d *Daemon, r *http.Request) response.Response {
 		return response.InternalError(err)
 	}
 
+	status, err := operationStatusParam(r.FormValue("status"))
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
 	// First check if the query is for a local operation from this node
 	op, err := operations.OperationGetInternal(id)
 	if err == nil {
@@ -839,7 +996,11 @@ func operationWaitGet(d *Daemon, r *http.Request) response.Response {
 			return response.Forbidden(nil)
 		}
 
-		_, err = op.WaitFinal(timeout)
+		if secret == "" && !operationUserCanView(r, op.Project()) {
+			return response.NotFound(nil)
+		}
+
+		err = operationWaitLocal(op, status, timeout)
 		if err != nil {
 			return response.InternalError(err)
 		}
@@ -854,6 +1015,8 @@ func operationWaitGet(d *Daemon, r *http.Request) response.Response {
 
 	// Then check if the query is from an operation on another node, and, if so, forward it
 	var address string
+	var projectName string
+	var body *api.Operation
 	err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
 		filter := db.OperationFilter{UUID: &id}
 		ops, err := tx.GetOperations(filter)
@@ -861,7 +1024,13 @@ func operationWaitGet(d *Daemon, r *http.Request) response.Response {
 			return err
 		}
 		if len(ops) < 1 {
-			return db.ErrNoSuchObject
+			// Lastly check if this is an operation which is done, those can't be accessed with a secret.
+			if !trusted {
+				return db.ErrNoSuchObject
+			}
+
+			body, projectName, err = tx.GetOperationHistory(id)
+			return err
 		}
 		if len(ops) > 1 {
 			return fmt.Errorf("More than one operation matches")
@@ -869,6 +1038,11 @@ func operationWaitGet(d *Daemon, r *http.Request) response.Response {
 
 		operation := ops[0]
 
+		projectName, err = operationProjectName(tx, operation)
+		if err != nil {
+			return err
+		}
+
 		address = operation.NodeAddress
 		return nil
 	})
@@ -876,6 +1050,15 @@ func operationWaitGet(d *Daemon, r *http.Request) response.Response {
 		return response.SmartError(err)
 	}
 
+	// Operations which are done can't be accessed with a secret.
+	if (body != nil || secret == "") && !operationUserCanView(r, projectName) {
+		return response.NotFound(nil)
+	}
+
+	if body != nil {
+		return response.SyncResponse(true, body)
+	}
+
 	client, err := cluster.Connect(address, d.endpoints.NetworkCert(), d.serverCert(), r, false)
 	if err != nil {
 		return response.SmartError(err)
@@ -884,6 +1067,236 @@ func operationWaitGet(d *Daemon, r *http.Request) response.Response {
 	return response.ForwardedResponse(client, r)
 }
 
+// operationStatusParam parses the status to wait for, returning 0 if none was requested.
+func operationStatusParam(value string) (api.StatusCode, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	for _, status := range []api.StatusCode{api.Running, api.Cancelling} {
+		if strings.EqualFold(value, status.String()) {
+			return status, nil
+		}
+	}
+
+	return 0, fmt.Errorf("Invalid operation status %q", value)
+}
+
+// operationUserCanView returns whether the requestor is allowed to see the operations of the project.
+func operationUserCanView(r *http.Request, projectName string) bool {
+	if projectName == "" {
+		projectName = project.Default
+	}
+
+	return rbac.UserHasPermission(r, projectName, "view")
+}
+
+// operationProjectName returns the name of the project of an operation running on another member.
+func operationProjectName(tx *db.ClusterTx, op db.Operation) (string, error) {
+	if op.ProjectID == nil {
+		return "", nil
+	}
+
+	projects, err := tx.GetProjectIDsToNames()
+	if err != nil {
+		return "", err
+	}
+
+	return projects[*op.ProjectID], nil
+}
+
+// operationWaitLocal waits for a local operation to reach the given status, or a final state if status is 0.
+func operationWaitLocal(op *operations.Operation, status api.StatusCode, timeout int) error {
+	if status != 0 {
+		_, err := op.WaitStatus(status, timeout)
+		return err
+	}
+
+	_, err := op.WaitFinal(timeout)
+	return err
+}
+
+// operationWaitAny waits for an operation running on any cluster member and returns its state.
+func operationWaitAny(d *Daemon, r *http.Request, id string, status api.StatusCode, timeout int) (*api.Operation, error) {
+	op, err := operations.OperationGetInternal(id)
+	if err == nil {
+		if !operationUserCanView(r, op.Project()) {
+			return nil, db.ErrNoSuchObject
+		}
+
+		err = operationWaitLocal(op, status, timeout)
+		if err != nil {
+			return nil, err
+		}
+
+		_, body, err := op.Render()
+		if err != nil {
+			return nil, err
+		}
+
+		return body, nil
+	}
+
+	var address string
+	var projectName string
+	var body *api.Operation
+	err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
+		filter := db.OperationFilter{UUID: &id}
+		ops, err := tx.GetOperations(filter)
+		if err != nil {
+			return err
+		}
+
+		if len(ops) < 1 {
+			body, projectName, err = tx.GetOperationHistory(id)
+			return err
+		}
+
+		if len(ops) > 1 {
+			return fmt.Errorf("More than one operation matches")
+		}
+
+		projectName, err = operationProjectName(tx, ops[0])
+		if err != nil {
+			return err
+		}
+
+		address = ops[0].NodeAddress
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !operationUserCanView(r, projectName) {
+		return nil, db.ErrNoSuchObject
+	}
+
+	if body != nil {
+		return body, nil
+	}
+
+	client, err := cluster.Connect(address, d.endpoints.NetworkCert(), d.serverCert(), r, false)
+	if err != nil {
+		return nil, err
+	}
+
+	values := url.Values{}
+	values.Set("timeout", strconv.Itoa(timeout))
+	if status != 0 {
+		values.Set("status", status.String())
+	}
+
+	resp, _, err := client.RawQuery("GET", fmt.Sprintf("/1.0/operations/%s/wait?%s", url.PathEscape(id), values.Encode()), nil, "")
+	if err != nil {
+		return nil, err
+	}
+
+	body = &api.Operation{}
+	err = resp.MetadataAsStruct(body)
+	if err != nil {
+		return nil, err
+	}
+
+	return body, nil
+}
+
+// swagger:operation GET /1.0/operations/wait operations operations_wait_get
+//
+// Wait for multiple operations
+//
+// Waits for all the given operations to reach a final state or the requested status (or timeout)
+// and retrieve their state, in the order they were requested.
+//
+// ---
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: id
+//     description: Operation UUID (may be repeated)
+//     type: string
+//     example: b8d84888-1dc2-44fd-b386-7f679e171ba5
+//   - in: query
+//     name: timeout
+//     description: Timeout in seconds (-1 means never)
+//     type: integer
+//     example: -1
+//   - in: query
+//     name: status
+//     description: Status to wait for instead of a final state (Running or Cancelling)
+//     type: string
+//     example: Running
+// responses:
+//   "200":
+//     description: Operations
+//     schema:
+//       type: object
+//       description: Sync response
+//       properties:
+//         type:
+//           type: string
+//           description: Response type
+//           example: sync
+//         status:
+//           type: string
+//           description: Status description
+//           example: Success
+//         status_code:
+//           type: integer
+//           description: Status code
+//           example: 200
+//         metadata:
+//           type: array
+//           description: List of operations
+//           items:
+//             $ref: "#/definitions/Operation"
+//   "400":
+//     $ref: "#/responses/BadRequest"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func operationsWaitGet(d *Daemon, r *http.Request) response.Response {
+	ids := r.URL.Query()["id"]
+	if len(ids) == 0 {
+		return response.BadRequest(fmt.Errorf("No operation ID provided"))
+	}
+
+	timeout, err := shared.AtoiEmptyDefault(r.FormValue("timeout"), -1)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	status, err := operationStatusParam(r.FormValue("status"))
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	// Wait for all the operations in parallel.
+	ops := make([]*api.Operation, len(ids))
+	errs := make([]error, len(ids))
+
+	wg := sync.WaitGroup{}
+	for i, id := range ids {
+		wg.Add(1)
+		go func(i int, id string) {
+			defer wg.Done()
+			ops[i], errs[i] = operationWaitAny(d, r, id, status, timeout)
+		}(i, id)
+	}
+
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return response.SmartError(errors.Wrapf(err, "Failed waiting for operation %q", ids[i]))
+		}
+	}
+
+	return response.SyncResponse(true, ops)
+}
+
 type operationWebSocket struct {
 	req *http.Request
 	op  *operations.Operation
@@ -1012,3 +1425,162 @@ func operationWebsocketGet(d *Daemon, r *http.Request) response.Response {
 
 	return operations.ForwardedOperationWebSocket(r, id, source)
 }
+
+func pruneOperationsHistoryTask(d *Daemon) (task.Func, task.Schedule) {
+	f := func(ctx context.Context) {
+		opRun := func(op *operations.Operation) error {
+			return pruneOperationsHistory(ctx, d)
+		}
+
+		op, err := operations.OperationCreate(d.State(), "", operations.OperationClassTask, db.OperationOperationsHistoryExpire, nil, nil, opRun, nil, nil, nil)
+		if err != nil {
+			logger.Error("Failed to start operations history expiry operation", log.Ctx{"err": err})
+			return
+		}
+
+		logger.Info("Pruning operations history")
+		_, err = op.Run()
+		if err != nil {
+			logger.Error("Failed to prune operations history", log.Ctx{"err": err})
+		}
+		logger.Info("Done pruning operations history")
+	}
+
+	return f, task.Daily()
+}
+
+func pruneOperationsHistory(ctx context.Context, d *Daemon) error {
+	// Keep the state of operations which are done for a day.
+	err := d.cluster.Transaction(func(tx *db.ClusterTx) error {
+		return tx.DeleteOperationsHistoryBefore(time.Now().Add(-24 * time.Hour))
+	})
+	if err != nil {
+		return errors.Wrap(err, "Failed to delete operations history")
+	}
+
+	return nil
+}
+
+// reconcileInterruptedOperations cleans up after the operations of this member which were interrupted by
+// the restart, then marks them as failed. Backups which were being created are incomplete and get deleted,
+// while partially downloaded images are removed by pruneLeftoverImages.
+func reconcileInterruptedOperations(d *Daemon) error {
+	var interrupted []db.InterruptedOperation
+	var nodeID int64
+	err := d.cluster.Transaction(func(tx *db.ClusterTx) error {
+		var err error
+		nodeID = tx.GetNodeID()
+		interrupted, err = tx.GetInterruptedOperations(nodeID)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	s := d.State()
+	for _, op := range interrupted {
+		logger.Warn("Operation interrupted by restart", log.Ctx{"operation": op.Operation.ID, "description": op.Operation.Description, "project": op.ProjectName})
+
+		switch op.Type {
+		case db.OperationBackupCreate:
+			err = reconcileInstanceBackupCreate(s, op)
+		case db.OperationCustomVolumeBackupCreate:
+			err = reconcileVolumeBackupCreate(s, op)
+		default:
+			err = nil
+		}
+
+		if err != nil {
+			logger.Warn("Failed to clean up after interrupted operation", log.Ctx{"operation": op.Operation.ID, "err": err})
+		}
+	}
+
+	return d.cluster.Transaction(func(tx *db.ClusterTx) error {
+		return tx.FailInterruptedOperations(nodeID)
+	})
+}
+
+// interruptedOperationResource returns the name of the single resource of the given type of an interrupted
+// operation.
+func interruptedOperationResource(op db.InterruptedOperation, resourceType string) (string, error) {
+	resources := op.Operation.Resources[resourceType]
+	if len(resources) != 1 {
+		return "", fmt.Errorf("Expected one %q resource, got %d", resourceType, len(resources))
+	}
+
+	return path.Base(resources[0]), nil
+}
+
+// reconcileInstanceBackupCreate deletes the incomplete backup of an interrupted backup creation.
+func reconcileInstanceBackupCreate(s *state.State, op db.InterruptedOperation) error {
+	instanceName, err := interruptedOperationResource(op, "instances")
+	if err != nil {
+		return err
+	}
+
+	backupName, err := interruptedOperationResource(op, "backups")
+	if err != nil {
+		return err
+	}
+
+	b, err := instance.BackupLoadByName(s, op.ProjectName, instanceName+shared.SnapshotDelimiter+backupName)
+	if err != nil {
+		if errors.Cause(err) == db.ErrNoSuchObject {
+			return nil
+		}
+
+		return err
+	}
+
+	logger.Info("Deleting incomplete instance backup", log.Ctx{"backup": b.Name(), "project": op.ProjectName})
+
+	return b.Delete()
+}
+
+// reconcileVolumeBackupCreate deletes the incomplete backup of an interrupted custom volume backup creation.
+// The storage pool isn't recorded, so the backup is only deleted if a single pool has one with that name.
+func reconcileVolumeBackupCreate(s *state.State, op db.InterruptedOperation) error {
+	volumeName, err := interruptedOperationResource(op, "storage_volumes")
+	if err != nil {
+		return err
+	}
+
+	backupName, err := interruptedOperationResource(op, "backups")
+	if err != nil {
+		return err
+	}
+
+	poolNames, err := s.Cluster.GetStoragePoolNames()
+	if err != nil && errors.Cause(err) != db.ErrNoSuchObject {
+		return err
+	}
+
+	var backups []*backup.VolumeBackup
+	for _, poolName := range poolNames {
+		b, err := storagePoolVolumeBackupLoadByName(s, op.ProjectName, poolName, volumeName+shared.SnapshotDelimiter+backupName)
+		if err != nil {
+			if errors.Cause(err) == db.ErrNoSuchObject {
+				continue
+			}
+
+			return err
+		}
+
+		backups = append(backups, b)
+	}
+
+	if len(backups) > 1 {
+		return fmt.Errorf("Volume backup %q exists in more than one storage pool", volumeName+shared.SnapshotDelimiter+backupName)
+	}
+
+	for _, b := range backups {
+		logger.Info("Deleting incomplete volume backup", log.Ctx{"backup": b.Name(), "project": op.ProjectName})
+
+		err = b.Delete()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,188 @@
+package operations
+
+import (
+	"context"
+	"sync"
+
+	"github.com/lxc/lxd/lxd/db"
+)
+
+// heavyOperationTypes are the operation types whose concurrency is limited, as they are long running
+// and consume a lot of I/O or network bandwidth.
+var heavyOperationTypes = []db.OperationType{
+	db.OperationBackupCreate,
+	db.OperationBackupRestore,
+	db.OperationCustomVolumeBackupCreate,
+	db.OperationCustomVolumeBackupRestore,
+	db.OperationImageDownload,
+	db.OperationInstanceMigrate,
+	db.OperationInstanceLiveMigrate,
+	db.OperationVolumeMigrate,
+}
+
+// heavyOperationPriorities are the priorities of the queued heavy operations, higher first. Restores and
+// migrations are usually waited on by someone, unlike backups which can wait.
+var heavyOperationPriorities = map[db.OperationType]int{
+	db.OperationBackupRestore:             3,
+	db.OperationCustomVolumeBackupRestore: 3,
+	db.OperationInstanceMigrate:           2,
+	db.OperationInstanceLiveMigrate:       2,
+	db.OperationVolumeMigrate:             2,
+	db.OperationImageDownload:             1,
+	db.OperationBackupCreate:              0,
+	db.OperationCustomVolumeBackupCreate:  0,
+}
+
+// heavyLimiter limits the number of heavy operations running concurrently on this member.
+var heavyLimiter = newLimiter()
+
+// SetHeavyOperationsLimit sets the maximum number of heavy operations (image downloads, backups and
+// migrations) running concurrently on this member. Operations above the limit are queued until others
+// complete. A limit of 0 means no limit.
+func SetHeavyOperationsLimit(limit int) {
+	heavyLimiter.setLimit(limit)
+}
+
+func isHeavyOperation(opType db.OperationType) bool {
+	for _, heavyType := range heavyOperationTypes {
+		if opType == heavyType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// heavyOperationPriority returns the priority of a queued heavy operation. Operations requested through the
+// API go before those started in the background (such as scheduled image updates), then the priority
+// depends on the operation type.
+func heavyOperationPriority(op *Operation) int {
+	priority := heavyOperationPriorities[op.dbOpType]
+	if op.requestor != nil {
+		priority += 10
+	}
+
+	return priority
+}
+
+// limiter is a counting semaphore whose limit can be changed at any time. Waiters are granted a slot by
+// decreasing priority, then in arrival order.
+type limiter struct {
+	mu      sync.Mutex
+	limit   int
+	running int
+	seq     uint64
+	waiters []*limiterWaiter
+}
+
+// limiterWaiter is a holder waiting for a slot. Its ready channel gets closed once it's granted one.
+type limiterWaiter struct {
+	priority int
+	seq      uint64
+	ready    chan struct{}
+	granted  bool
+}
+
+func newLimiter() *limiter {
+	return &limiter{}
+}
+
+// setLimit changes the limit, granting slots to the waiters which may now proceed.
+func (l *limiter) setLimit(limit int) {
+	l.mu.Lock()
+	l.limit = limit
+	l.dispatch()
+	l.mu.Unlock()
+}
+
+// tryAcquire takes a slot if one is free, without waiting.
+func (l *limiter) tryAcquire() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.limit > 0 && l.running >= l.limit {
+		return false
+	}
+
+	l.running++
+
+	return true
+}
+
+// acquire waits until fewer than limit holders are running and takes a slot. It returns an error if the
+// context is done before a slot is granted.
+func (l *limiter) acquire(ctx context.Context, priority int) error {
+	l.mu.Lock()
+	if l.limit <= 0 || l.running < l.limit {
+		l.running++
+		l.mu.Unlock()
+
+		return nil
+	}
+
+	l.seq++
+	w := &limiterWaiter{priority: priority, seq: l.seq, ready: make(chan struct{})}
+	l.waiters = append(l.waiters, w)
+	l.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// The slot may have been granted in the meantime, in which case it's handed over to the next waiter.
+	if w.granted {
+		l.running--
+	} else {
+		for i, waiter := range l.waiters {
+			if waiter == w {
+				l.waiters = append(l.waiters[:i], l.waiters[i+1:]...)
+				break
+			}
+		}
+	}
+
+	l.dispatch()
+
+	return ctx.Err()
+}
+
+// release frees a slot taken by acquire.
+func (l *limiter) release() {
+	l.mu.Lock()
+	l.running--
+	l.dispatch()
+	l.mu.Unlock()
+}
+
+// queued returns the number of waiters.
+func (l *limiter) queued() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return len(l.waiters)
+}
+
+// dispatch grants the free slots to the waiters with the highest priority.
+// Must be called with the lock held.
+func (l *limiter) dispatch() {
+	for len(l.waiters) > 0 && (l.limit <= 0 || l.running < l.limit) {
+		next := 0
+		for i, w := range l.waiters {
+			best := l.waiters[next]
+			if w.priority > best.priority || (w.priority == best.priority && w.seq < best.seq) {
+				next = i
+			}
+		}
+
+		w := l.waiters[next]
+		l.waiters = append(l.waiters[:next], l.waiters[next+1:]...)
+		l.running++
+		w.granted = true
+		close(w.ready)
+	}
+}
@@ -0,0 +1,132 @@
+package operations
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lxc/lxd/lxd/db"
+)
+
+// waitQueued waits until the limiter has the given number of waiters.
+func waitQueued(t *testing.T, l *limiter, n int) {
+	for i := 0; i < 100; i++ {
+		if l.queued() == n {
+			return
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatalf("Expected %d queued holders, got %d", n, l.queued())
+}
+
+func TestLimiter_Priority(t *testing.T) {
+	l := newLimiter()
+	l.setLimit(1)
+	require.True(t, l.tryAcquire())
+	assert.False(t, l.tryAcquire())
+
+	order := make(chan int, 3)
+	for i, priority := range []int{0, 2, 1} {
+		go func(n int, priority int) {
+			err := l.acquire(context.Background(), priority)
+			assert.NoError(t, err)
+			order <- n
+			l.release()
+		}(i, priority)
+
+		waitQueued(t, l, i+1)
+	}
+
+	l.release()
+	assert.Equal(t, 1, <-order)
+	assert.Equal(t, 2, <-order)
+	assert.Equal(t, 0, <-order)
+}
+
+func TestLimiter_Cancel(t *testing.T) {
+	l := newLimiter()
+	l.setLimit(1)
+	require.True(t, l.tryAcquire())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error)
+	go func() {
+		errCh <- l.acquire(ctx, 0)
+	}()
+
+	waitQueued(t, l, 1)
+	cancel()
+	assert.Equal(t, context.Canceled, <-errCh)
+	assert.Equal(t, 0, l.queued())
+
+	// The slot is still taken by the first holder only.
+	assert.False(t, l.tryAcquire())
+	l.release()
+	assert.True(t, l.tryAcquire())
+}
+
+func TestLimiter_SetLimit(t *testing.T) {
+	l := newLimiter()
+	l.setLimit(1)
+	require.True(t, l.tryAcquire())
+
+	errCh := make(chan error)
+	go func() {
+		errCh <- l.acquire(context.Background(), 0)
+	}()
+
+	waitQueued(t, l, 1)
+
+	// Raising the limit lets the waiter proceed, removing it makes it unlimited.
+	l.setLimit(2)
+	assert.NoError(t, <-errCh)
+	assert.False(t, l.tryAcquire())
+
+	l.setLimit(0)
+	assert.True(t, l.tryAcquire())
+}
+
+// Test that an unlimited operation can wait on a nested heavy operation when the limit is 1.
+func TestOperation_UnlimitedNested(t *testing.T) {
+	SetHeavyOperationsLimit(1)
+	defer SetHeavyOperationsLimit(0)
+
+	nested := func(op *Operation) error {
+		return nil
+	}
+
+	run := func(op *Operation) error {
+		nestedOp, err := OperationCreate(nil, "", OperationClassTask, db.OperationInstanceMigrate, nil, nil, nested, nil, nil, nil)
+		if err != nil {
+			return err
+		}
+
+		_, err = nestedOp.Run()
+		if err != nil {
+			return err
+		}
+
+		_, err = nestedOp.WaitFinal(-1)
+		return err
+	}
+
+	op, err := OperationCreate(nil, "", OperationClassTask, db.OperationInstanceMigrate, nil, nil, run, nil, nil, nil)
+	require.NoError(t, err)
+
+	op.SetUnlimited()
+
+	chanRun, err := op.Run()
+	require.NoError(t, err)
+
+	select {
+	case err := <-chanRun:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Nested heavy operation deadlocked")
+	}
+}
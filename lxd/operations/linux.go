@@ -7,6 +7,7 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/shared/api"
 )
 
 func registerDBOperation(op *Operation, opType db.OperationType) error {
@@ -51,6 +52,16 @@ func removeDBOperation(op *Operation) error {
 	return err
 }
 
+func recordDBOperationState(op *Operation, md *api.Operation) error {
+	if op.state == nil {
+		return nil
+	}
+
+	return op.state.Cluster.Transaction(func(tx *db.ClusterTx) error {
+		return tx.UpsertOperationHistory(op.Project(), op.dbOpType, *md)
+	})
+}
+
 func getServerName(op *Operation) (string, error) {
 	if op.state == nil {
 		return "", nil
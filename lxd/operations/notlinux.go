@@ -7,6 +7,7 @@ import (
 	"fmt"
 
 	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/shared/api"
 )
 
 func registerDBOperation(op *Operation, opType db.OperationType) error {
@@ -25,6 +26,14 @@ func removeDBOperation(op *Operation) error {
 	return nil
 }
 
+func recordDBOperationState(op *Operation, md *api.Operation) error {
+	if op.state != nil {
+		return fmt.Errorf("recordDBOperationState not supported on this platform")
+	}
+
+	return nil
+}
+
 func getServerName(op *Operation) (string, error) {
 	if op.state != nil {
 		return "", fmt.Errorf("registerDBOperation not supported on this platform")
@@ -108,13 +108,20 @@ type Operation struct {
 	dbOpType    db.OperationType
 	requestor   *api.EventLifecycleRequestor
 
+	// Set while the operation is queued waiting for a heavy operation slot, cancels the wait.
+	queueCancel context.CancelFunc
+
+	// Whether the operation doesn't take a heavy operation slot despite its type.
+	unlimited bool
+
 	// Those functions are called at various points in the Operation lifecycle
 	onRun     func(*Operation) error
 	onCancel  func(*Operation) error
 	onConnect func(*Operation, *http.Request, http.ResponseWriter) error
 
 	// Channels used for error reporting and state tracking of background actions
-	chanDone chan error
+	chanDone   chan error
+	chanStatus chan struct{} // Closed and replaced whenever the status changes.
 
 	// Locking for concurent access to the Operation
 	lock sync.Mutex
@@ -145,6 +152,7 @@ func OperationCreate(s *state.State, projectName string, opClass OperationClass,
 	op.url = fmt.Sprintf("/%s/operations/%s", version.APIVersion, op.id)
 	op.resources = opResources
 	op.chanDone = make(chan error)
+	op.chanStatus = make(chan struct{})
 	op.state = s
 
 	if s != nil {
@@ -218,6 +226,14 @@ func (op *Operation) Requestor() *api.EventLifecycleRequestor {
 	return op.requestor
 }
 
+// setStatus changes the status of the operation and wakes up the status waiters.
+// Must be called with the operation lock held.
+func (op *Operation) setStatus(status api.StatusCode) {
+	op.status = status
+	close(op.chanStatus)
+	op.chanStatus = make(chan struct{})
+}
+
 func (op *Operation) done() {
 	if op.readonly {
 		return
@@ -231,6 +247,11 @@ func (op *Operation) done() {
 	close(op.chanDone)
 	op.lock.Unlock()
 
+	_, md, err := op.Render()
+	if err == nil {
+		op.recordState(md)
+	}
+
 	time.AfterFunc(time.Second*5, func() {
 		operationsLock.Lock()
 		_, ok := operations[op.id]
@@ -253,24 +274,93 @@ func (op *Operation) done() {
 	})
 }
 
+// recordState durably records the state of task operations, so that it remains available once the operation
+// is gone from memory, including after a restart.
+func (op *Operation) recordState(md *api.Operation) {
+	if op.class != OperationClassTask {
+		return
+	}
+
+	err := recordDBOperationState(op, md)
+	if err != nil {
+		logger.Warn("Failed to record operation state", log.Ctx{"operation": op.id, "err": err})
+	}
+}
+
 // Run runs a pending operation. It returns an error if the operation cannot
 // be started.
+//
+// Heavy operations exceeding the concurrency limit are queued, remaining pending until a slot frees up.
 func (op *Operation) Run() (chan error, error) {
-	if op.status != api.Pending {
+	if op.status != api.Pending || op.queueCancel != nil {
 		return nil, fmt.Errorf("Only pending operations can be started")
 	}
 
 	chanRun := make(chan error, 1)
 
 	op.lock.Lock()
-	op.status = api.Running
+
+	var queueCtx context.Context
+	heavy := op.onRun != nil && !op.unlimited && isHeavyOperation(op.dbOpType)
+	if heavy && !heavyLimiter.tryAcquire() {
+		parentCtx := context.Background()
+		if op.state != nil {
+			parentCtx = op.state.Context
+		}
+
+		queueCtx, op.queueCancel = context.WithCancel(parentCtx)
+	} else {
+		op.setStatus(api.Running)
+	}
 
 	if op.onRun != nil {
 		go func(op *Operation, chanRun chan error) {
+			// Wait for a free slot if this is a queued heavy operation.
+			if queueCtx != nil {
+				logger.Debug("Queued operation", log.Ctx{"operation": op.id, "class": op.class.String()})
+
+				err := heavyLimiter.acquire(queueCtx, heavyOperationPriority(op))
+
+				op.lock.Lock()
+				op.queueCancel()
+				op.queueCancel = nil
+				if err == nil {
+					op.setStatus(api.Running)
+				} else {
+					op.setStatus(api.Cancelled)
+				}
+				op.lock.Unlock()
+
+				if err != nil {
+					op.done()
+					chanRun <- fmt.Errorf("Operation cancelled while queued: %w", err)
+
+					logger.Debug("Cancelled queued operation", log.Ctx{"operation": op.id, "class": op.class.String()})
+					_, md, _ := op.Render()
+
+					op.lock.Lock()
+					op.sendEvent(md)
+					op.lock.Unlock()
+
+					return
+				}
+
+				logger.Debug("Dequeued operation", log.Ctx{"operation": op.id, "class": op.class.String()})
+				_, md, _ := op.Render()
+
+				op.lock.Lock()
+				op.sendEvent(md)
+				op.lock.Unlock()
+			}
+
+			if heavy {
+				defer heavyLimiter.release()
+			}
+
 			err := op.onRun(op)
 			if err != nil {
 				op.lock.Lock()
-				op.status = api.Failure
+				op.setStatus(api.Failure)
 				op.err = response.SmartError(err).String()
 				op.lock.Unlock()
 				op.done()
@@ -287,7 +377,7 @@ func (op *Operation) Run() (chan error, error) {
 			}
 
 			op.lock.Lock()
-			op.status = api.Success
+			op.setStatus(api.Success)
 			op.lock.Unlock()
 			op.done()
 			chanRun <- nil
@@ -304,7 +394,10 @@ func (op *Operation) Run() (chan error, error) {
 	op.lock.Unlock()
 
 	logger.Debugf("Started %s operation: %s", op.class.String(), op.id)
-	_, md, _ := op.Render()
+	_, md, err := op.Render()
+	if err == nil {
+		op.recordState(md)
+	}
 
 	op.lock.Lock()
 	op.sendEvent(md)
@@ -316,6 +409,36 @@ func (op *Operation) Run() (chan error, error) {
 // Cancel cancels a running operation. If the operation cannot be cancelled, it
 // returns an error.
 func (op *Operation) Cancel() (chan error, error) {
+	op.lock.Lock()
+	queueCancel := op.queueCancel
+	op.lock.Unlock()
+
+	// Queued operations are cancelled by removing them from the queue, their run hook never gets called.
+	// If they got a slot in the meantime, they're cancelled as running operations.
+	if queueCancel != nil {
+		queueCancel()
+
+		for {
+			op.lock.Lock()
+			status := op.status
+			chanStatus := op.chanStatus
+			op.lock.Unlock()
+
+			if status.IsFinal() {
+				chanCancel := make(chan error, 1)
+				chanCancel <- nil
+
+				return chanCancel, nil
+			}
+
+			if status != api.Pending {
+				break
+			}
+
+			<-chanStatus
+		}
+	}
+
 	if op.status != api.Running {
 		return nil, fmt.Errorf("Only running operations can be cancelled")
 	}
@@ -328,7 +451,7 @@ func (op *Operation) Cancel() (chan error, error) {
 
 	op.lock.Lock()
 	oldStatus := op.status
-	op.status = api.Cancelling
+	op.setStatus(api.Cancelling)
 	op.lock.Unlock()
 
 	hasOnCancel := op.onCancel != nil
@@ -338,7 +461,7 @@ func (op *Operation) Cancel() (chan error, error) {
 			err := op.onCancel(op)
 			if err != nil {
 				op.lock.Lock()
-				op.status = oldStatus
+				op.setStatus(oldStatus)
 				op.lock.Unlock()
 				chanCancel <- err
 
@@ -353,7 +476,7 @@ func (op *Operation) Cancel() (chan error, error) {
 			}
 
 			op.lock.Lock()
-			op.status = api.Cancelled
+			op.setStatus(api.Cancelled)
 			op.lock.Unlock()
 			op.done()
 			chanCancel <- nil
@@ -381,7 +504,7 @@ func (op *Operation) Cancel() (chan error, error) {
 
 	if !hasOnCancel {
 		op.lock.Lock()
-		op.status = api.Cancelled
+		op.setStatus(api.Cancelled)
 		op.lock.Unlock()
 		op.done()
 		chanCancel <- nil
@@ -404,7 +527,12 @@ func (op *Operation) Connect(r *http.Request, w http.ResponseWriter) (chan error
 		return nil, fmt.Errorf("Only websocket operations can be connected")
 	}
 
-	if op.status != api.Running {
+	// Queued operations accept connections, the run hook then picks them up once it starts.
+	op.lock.Lock()
+	queued := op.queueCancel != nil
+	op.lock.Unlock()
+
+	if op.status != api.Running && !queued {
 		return nil, fmt.Errorf("Only running operations can be connected")
 	}
 
@@ -433,7 +561,7 @@ func (op *Operation) Connect(r *http.Request, w http.ResponseWriter) (chan error
 }
 
 func (op *Operation) mayCancel() bool {
-	if op.class == OperationClassToken {
+	if op.class == OperationClassToken || op.queueCancel != nil {
 		return true
 	}
 
@@ -524,6 +652,39 @@ func (op *Operation) WaitFinal(timeout int) (bool, error) {
 	return false, nil
 }
 
+// WaitStatus waits for the operation to reach the given status or to finish, with a timeout in
+// seconds (-1 means never). It returns whether the operation has reached the status.
+func (op *Operation) WaitStatus(status api.StatusCode, timeout int) (bool, error) {
+	var timer <-chan time.Time
+	if timeout >= 0 {
+		t := time.NewTimer(time.Duration(timeout) * time.Second)
+		defer t.Stop()
+		timer = t.C
+	}
+
+	for {
+		op.lock.Lock()
+		current := op.status
+		chanStatus := op.chanStatus
+		op.lock.Unlock()
+
+		if current == status {
+			return true, nil
+		}
+
+		if current.IsFinal() {
+			return false, nil
+		}
+
+		select {
+		case <-chanStatus:
+		case <-op.chanDone:
+		case <-timer:
+			return false, nil
+		}
+	}
+}
+
 // UpdateResources updates the resources of the operation. It returns an error
 // if the operation is not pending or running, or the operation is read-only.
 func (op *Operation) UpdateResources(opResources map[string][]string) error {
@@ -601,6 +762,13 @@ func (op *Operation) Resources() map[string][]string {
 	return op.resources
 }
 
+// SetUnlimited excludes the operation from the heavy operations limit. This is meant for operations which
+// only coordinate other operations that take a slot themselves, as they would otherwise hold a slot while
+// waiting for one, deadlocking once the limit is reached. Must be called before the operation is run.
+func (op *Operation) SetUnlimited() {
+	op.unlimited = true
+}
+
 // SetCanceler sets a canceler.
 func (op *Operation) SetCanceler(canceler *cancel.Canceler) {
 	op.canceler = canceler
@@ -32,6 +32,7 @@ import (
 	"github.com/lxc/lxd/lxd/util"
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/idmap"
 	log "github.com/lxc/lxd/shared/log15"
 	"github.com/lxc/lxd/shared/logger"
 )
@@ -124,6 +125,7 @@ var patches = []patch{
 	{name: "network_acl_remove_defaults", stage: patchPostDaemonStorage, run: patchNetworkACLRemoveDefaults},
 	{name: "clustering_server_cert_trust", stage: patchPreDaemonStorage, run: patchClusteringServerCertTrust},
 	{name: "warnings_remove_empty_node", stage: patchPostDaemonStorage, run: patchRemoveWarningsWithEmptyNode},
+	{name: "idmap_allocations", stage: patchPostDaemonStorage, run: patchIdmapAllocations},
 }
 
 type patch struct {
@@ -188,6 +190,46 @@ func patchesApply(d *Daemon, stage patchStage) error {
 
 // Patches begin here
 
+// patchIdmapAllocations records the ranges of host uids/gids already used by the containers with an isolated
+// idmap, so that new allocations don't overlap with them.
+func patchIdmapAllocations(name string, d *Daemon) error {
+	return d.State().Cluster.InstanceList(nil, func(inst db.Instance, p db.Project, profiles []api.Profile) error {
+		if inst.Type != instancetype.Container {
+			return nil
+		}
+
+		expandedConfig := db.ExpandInstanceConfig(inst.Config, profiles)
+		if shared.IsTrue(expandedConfig["security.privileged"]) || !shared.IsTrue(expandedConfig["security.idmap.isolated"]) {
+			return nil
+		}
+
+		base, err := strconv.ParseInt(inst.Config["volatile.idmap.base"], 10, 64)
+		if err != nil || base == 0 {
+			return nil
+		}
+
+		idmapSet, err := idmap.JSONUnmarshal(inst.Config["volatile.idmap.next"])
+		if err != nil || idmapSet == nil {
+			return nil
+		}
+
+		// The allocated range is the one starting at the base, other entries come from raw.idmap.
+		for _, entry := range idmapSet.Idmap {
+			if entry.Hostid != base {
+				continue
+			}
+
+			logger.Debugf("Recording idmap allocation %d-%d of instance %q (Project %q)", base, base+entry.Maprange-1, inst.Name, inst.Project)
+
+			return d.State().Cluster.Transaction(func(tx *db.ClusterTx) error {
+				return tx.SetIdmapAllocation(int64(inst.ID), base, entry.Maprange)
+			})
+		}
+
+		return nil
+	})
+}
+
 func patchRemoveWarningsWithEmptyNode(name string, d *Daemon) error {
 	err := d.cluster.Transaction(func(tx *db.ClusterTx) error {
 		warnings, err := tx.GetWarnings()
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"sort"
 	"strings"
 
 	"github.com/gorilla/mux"
@@ -14,7 +15,9 @@ import (
 	"github.com/lxc/lxd/client"
 	"github.com/lxc/lxd/lxd/cluster"
 	"github.com/lxc/lxd/lxd/db"
+	dbCluster "github.com/lxc/lxd/lxd/db/cluster"
 	deviceConfig "github.com/lxc/lxd/lxd/device/config"
+	"github.com/lxc/lxd/lxd/filter"
 	"github.com/lxc/lxd/lxd/instance"
 	"github.com/lxc/lxd/lxd/instance/instancetype"
 	"github.com/lxc/lxd/lxd/lifecycle"
@@ -60,6 +63,21 @@ var profileCmd = APIEndpoint{
 //     description: Project name
 //     type: string
 //     example: default
+//   - in: query
+//     name: filter
+//     description: Collection filter
+//     type: string
+//     example: default
+//   - in: query
+//     name: limit
+//     description: Maximum number of entries to return
+//     type: integer
+//     example: 100
+//   - in: query
+//     name: offset
+//     description: Number of entries to skip
+//     type: integer
+//     example: 200
 // responses:
 //   "200":
 //     description: API endpoints
@@ -109,6 +127,21 @@ var profileCmd = APIEndpoint{
 //     description: Project name
 //     type: string
 //     example: default
+//   - in: query
+//     name: filter
+//     description: Collection filter
+//     type: string
+//     example: default
+//   - in: query
+//     name: limit
+//     description: Maximum number of entries to return
+//     type: integer
+//     example: 100
+//   - in: query
+//     name: offset
+//     description: Number of entries to skip
+//     type: integer
+//     example: 200
 // responses:
 //   "200":
 //     description: API endpoints
@@ -145,33 +178,81 @@ func profilesGet(d *Daemon, r *http.Request) response.Response {
 
 	recursion := util.IsRecursionRequest(r)
 
+	pagination, err := util.PaginationFromRequest(r)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	// Parse filter value.
+	var clauses []filter.Clause
+	filterStr := r.FormValue("filter")
+	if filterStr != "" {
+		clauses, err = filter.Parse(filterStr)
+		if err != nil {
+			return response.BadRequest(errors.Wrap(err, "Invalid filter"))
+		}
+	}
+
 	var result interface{}
+	var total int
 	err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
-		filter := db.ProfileFilter{
+		profileFilter := db.ProfileFilter{
 			Project: &projectName,
 		}
-		if recursion {
-			profiles, err := tx.GetProfiles(filter)
+		if recursion || clauses != nil {
+			profiles, err := tx.GetProfiles(profileFilter)
 			if err != nil {
 				return err
 			}
-			apiProfiles := make([]*api.Profile, len(profiles))
-			for i, profile := range profiles {
-				apiProfiles[i] = db.ProfileToAPI(&profile)
-				apiProfiles[i].UsedBy = project.FilterUsedBy(r, apiProfiles[i].UsedBy)
+
+			sort.Slice(profiles, func(i, j int) bool { return profiles[i].Name < profiles[j].Name })
+
+			apiProfiles := make([]*api.Profile, 0, len(profiles))
+			for _, profile := range profiles {
+				apiProfile := db.ProfileToAPI(&profile)
+				apiProfile.UsedBy = project.FilterUsedBy(r, apiProfile.UsedBy)
+
+				if clauses != nil && !filter.Match(*apiProfile, clauses) {
+					continue
+				}
+
+				apiProfiles = append(apiProfiles, apiProfile)
+			}
+
+			total = len(apiProfiles)
+			start, end := pagination.Range(total)
+			apiProfiles = apiProfiles[start:end]
+
+			if recursion {
+				result = apiProfiles
+				return nil
+			}
+
+			formatter := dbCluster.EntityFormatURIs[dbCluster.TypeProfile]
+			uris := make([]string, 0, len(apiProfiles))
+			for _, profile := range apiProfiles {
+				uris = append(uris, formatter(projectName, profile.Name))
 			}
 
-			result = apiProfiles
+			result = uris
 		} else {
-			result, err = tx.GetProfileURIs(filter)
+			uris, err := tx.GetProfileURIs(profileFilter)
+			if err != nil {
+				return err
+			}
+
+			sort.Strings(uris)
+			total = len(uris)
+			start, end := pagination.Range(total)
+			result = uris[start:end]
 		}
-		return err
+		return nil
 	})
 	if err != nil {
 		return response.SmartError(err)
 	}
 
-	return response.SyncResponse(true, result)
+	return response.SyncResponseHeaders(true, result, pagination.Headers(total))
 }
 
 // swagger:operation POST /1.0/profiles profiles profiles_post
@@ -217,28 +298,38 @@ func profilesPost(d *Daemon, r *http.Request) response.Response {
 		return response.BadRequest(err)
 	}
 
+	err = profileCreate(d, r, projectName, req)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponseLocation(true, nil, fmt.Sprintf("/%s/profiles/%s", version.APIVersion, req.Name))
+}
+
+// profileCreate validates and creates a new profile in the given profile project.
+func profileCreate(d *Daemon, r *http.Request, projectName string, req api.ProfilesPost) error {
 	// Quick checks.
 	if req.Name == "" {
-		return response.BadRequest(fmt.Errorf("No name provided"))
+		return api.StatusErrorf(http.StatusBadRequest, "No name provided")
 	}
 
 	if strings.Contains(req.Name, "/") {
-		return response.BadRequest(fmt.Errorf("Profile names may not contain slashes"))
+		return api.StatusErrorf(http.StatusBadRequest, "Profile names may not contain slashes")
 	}
 
 	if shared.StringInSlice(req.Name, []string{".", ".."}) {
-		return response.BadRequest(fmt.Errorf("Invalid profile name %q", req.Name))
+		return api.StatusErrorf(http.StatusBadRequest, "Invalid profile name %q", req.Name)
 	}
 
-	err = instance.ValidConfig(d.os, req.Config, false, instancetype.Any)
+	err := instance.ValidConfig(d.os, req.Config, false, instancetype.Any)
 	if err != nil {
-		return response.BadRequest(err)
+		return api.StatusErrorf(http.StatusBadRequest, "%v", err)
 	}
 
 	// At this point we don't know the instance type, so just use instancetype.Any type for validation.
 	err = instance.ValidDevices(d.State(), d.cluster, projectName, instancetype.Any, deviceConfig.NewDevices(req.Devices), false)
 	if err != nil {
-		return response.BadRequest(err)
+		return api.StatusErrorf(http.StatusBadRequest, "%v", err)
 	}
 
 	// Update DB entry.
@@ -259,13 +350,13 @@ func profilesPost(d *Daemon, r *http.Request) response.Response {
 		return err
 	})
 	if err != nil {
-		return response.SmartError(errors.Wrapf(err, "Error inserting %q into database", req.Name))
+		return errors.Wrapf(err, "Error inserting %q into database", req.Name)
 	}
 
 	requestor := request.CreateRequestor(r)
 	d.State().Events.SendLifecycle(projectName, lifecycle.ProfileCreated.Event(req.Name, projectName, requestor, nil))
 
-	return response.SyncResponseLocation(true, nil, fmt.Sprintf("/%s/profiles/%s", version.APIVersion, req.Name))
+	return nil
 }
 
 // swagger:operation GET /1.0/profiles/{name} profiles profile_get
@@ -687,7 +778,7 @@ func profileDelete(d *Daemon, r *http.Request) response.Response {
 			return err
 		}
 		if len(profile.UsedBy) > 0 {
-			return fmt.Errorf("Profile is currently in use")
+			return api.StatusErrorTypef(http.StatusBadRequest, api.ErrProfileInUse, "Profile is currently in use")
 		}
 
 		return tx.DeleteProfile(projectName, name)
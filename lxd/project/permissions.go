@@ -92,7 +92,7 @@ func checkTotalInstanceCountLimit(info *projectInfo) error {
 	}
 
 	if limit >= 0 && count >= limit {
-		return fmt.Errorf("Reached maximum number of instances in project %q", info.Project.Name)
+		return api.StatusErrorTypef(http.StatusForbidden, api.ErrQuotaExceeded, "Reached maximum number of instances in project %q", info.Project.Name)
 	}
 
 	return nil
@@ -120,7 +120,7 @@ func checkInstanceCountLimit(info *projectInfo, instanceType instancetype.Type)
 	}
 
 	if limit >= 0 && count >= limit {
-		return fmt.Errorf("Reached maximum number of instances of type %q in project %q", instanceType, info.Project.Name)
+		return api.StatusErrorTypef(http.StatusForbidden, api.ErrQuotaExceeded, "Reached maximum number of instances of type %q in project %q", instanceType, info.Project.Name)
 	}
 
 	return nil
@@ -392,7 +392,7 @@ func checkAggregateLimits(info *projectInfo, aggregateKeys []string) error {
 		}
 
 		if totals[key] > max {
-			return fmt.Errorf(
+			return api.StatusErrorTypef(http.StatusForbidden, api.ErrQuotaExceeded,
 				"Reached maximum aggregate value %s for %q in project %s",
 				info.Project.Config[key], key, info.Project.Name)
 		}
@@ -404,6 +404,7 @@ func checkAggregateLimits(info *projectInfo, aggregateKeys []string) error {
 // instances and profiles.
 func checkRestrictions(project *db.Project, instances []db.Instance, profiles []db.Profile) error {
 	containerConfigChecks := map[string]func(value string) error{}
+	vmConfigChecks := map[string]func(value string) error{}
 	devicesChecks := map[string]func(value map[string]string) error{}
 
 	allowContainerLowLevel := false
@@ -449,21 +450,45 @@ func checkRestrictions(project *db.Project, instances []db.Instance, profiles []
 			if restrictionValue == "allow" {
 				allowVMLowLevel = true
 			}
+		case "restricted.virtual-machines.nesting":
+			vmConfigChecks["limits.cpu.nested"] = func(instanceValue string) error {
+				if restrictionValue == "block" && shared.IsTrue(instanceValue) {
+					return fmt.Errorf("Virtual machine nesting is forbidden")
+				}
+
+				return nil
+			}
 		case "restricted.devices.unix-char":
 			devicesChecks["unix-char"] = func(device map[string]string) error {
-				if restrictionValue != "allow" {
-					return fmt.Errorf("Unix character devices are forbidden")
+				switch restrictionValue {
+				case "allow":
+					return nil
+				case "allowlist":
+					// The host device is matched against the allowlist when it's attached.
+					if device["major"] != "" || device["minor"] != "" {
+						return fmt.Errorf("Only unix character devices identified by their host path are allowed")
+					}
+
+					return nil
 				}
 
-				return nil
+				return fmt.Errorf("Unix character devices are forbidden")
 			}
 		case "restricted.devices.unix-block":
 			devicesChecks["unix-block"] = func(device map[string]string) error {
-				if restrictionValue != "allow" {
-					return fmt.Errorf("Unix block devices are forbidden")
+				switch restrictionValue {
+				case "allow":
+					return nil
+				case "allowlist":
+					// The host device is matched against the allowlist when it's attached.
+					if device["major"] != "" || device["minor"] != "" {
+						return fmt.Errorf("Only unix block devices identified by their host path are allowed")
+					}
+
+					return nil
 				}
 
-				return nil
+				return fmt.Errorf("Unix block devices are forbidden")
 			}
 		case "restricted.devices.unix-hotplug":
 			devicesChecks["unix-hotplug"] = func(device map[string]string) error {
@@ -523,7 +548,8 @@ func checkRestrictions(project *db.Project, instances []db.Instance, profiles []
 						return fmt.Errorf("Only managed network devices are allowed")
 					}
 				}
-				return nil
+
+				return checkRestrictedVLANs(project, device)
 			}
 		case "restricted.devices.disk":
 			devicesChecks["disk"] = func(device map[string]string) error {
@@ -566,20 +592,23 @@ func checkRestrictions(project *db.Project, instances []db.Instance, profiles []
 					key, entityType, entityName, project.Name)
 			}
 
-			var checker func(value string) error
-			if isContainerOrProfile {
-				checker = containerConfigChecks[key]
+			checkers := []func(value string) error{}
+			if isContainerOrProfile && containerConfigChecks[key] != nil {
+				checkers = append(checkers, containerConfigChecks[key])
 			}
 
-			if checker == nil {
-				continue
+			if isVMOrProfile && vmConfigChecks[key] != nil {
+				checkers = append(checkers, vmConfigChecks[key])
 			}
-			err := checker(value)
-			if err != nil {
-				return errors.Wrapf(
-					err,
-					"Invalid value %q for config %q on %s %q of project %q",
-					value, key, entityType, entityName, project.Name)
+
+			for _, checker := range checkers {
+				err := checker(value)
+				if err != nil {
+					return errors.Wrapf(
+						err,
+						"Invalid value %q for config %q on %s %q of project %q",
+						value, key, entityType, entityName, project.Name)
+				}
 			}
 		}
 		return nil
@@ -652,6 +681,7 @@ var allRestrictions = map[string]string{
 	"restricted.containers.lowlevel":       "block",
 	"restricted.containers.privilege":      "unprivileged",
 	"restricted.virtual-machines.lowlevel": "block",
+	"restricted.virtual-machines.nesting":  "block",
 	"restricted.devices.unix-char":         "block",
 	"restricted.devices.unix-block":        "block",
 	"restricted.devices.unix-hotplug":      "block",
@@ -665,12 +695,178 @@ var allRestrictions = map[string]string{
 	"restricted.snapshots":                 "block",
 }
 
+// VLANRange is a range of VLAN IDs.
+type VLANRange struct {
+	Start int
+	End   int
+}
+
+// ParseRestrictedVLANs parses the restricted.networks.vlans project setting and returns the VLAN ranges allocated
+// to the project from each uplink network.
+func ParseRestrictedVLANs(value string) (map[string][]VLANRange, error) {
+	vlans := map[string][]VLANRange{}
+	for _, vlanRaw := range strings.Split(value, ",") {
+		vlanRaw = strings.TrimSpace(vlanRaw)
+		if vlanRaw == "" {
+			continue
+		}
+
+		vlanParts := strings.SplitN(vlanRaw, ":", 2)
+		if len(vlanParts) != 2 || vlanParts[0] == "" {
+			return nil, fmt.Errorf(`VLAN range %q invalid, must be in the format of "<uplink network>:<vlan>" or "<uplink network>:<start>-<end>"`, vlanRaw)
+		}
+
+		bounds := strings.SplitN(vlanParts[1], "-", 2)
+		if len(bounds) == 1 {
+			bounds = append(bounds, bounds[0])
+		}
+
+		var vlanRange VLANRange
+		for i, bound := range []*int{&vlanRange.Start, &vlanRange.End} {
+			vlanID, err := strconv.Atoi(bounds[i])
+			if err != nil || vlanID < 1 || vlanID > 4094 {
+				return nil, fmt.Errorf("Invalid VLAN ID %q in %q (must be between 1 and 4094)", bounds[i], vlanRaw)
+			}
+
+			*bound = vlanID
+		}
+
+		if vlanRange.Start > vlanRange.End {
+			return nil, fmt.Errorf("Invalid VLAN range %q", vlanRaw)
+		}
+
+		vlans[vlanParts[0]] = append(vlans[vlanParts[0]], vlanRange)
+	}
+
+	return vlans, nil
+}
+
+// checkRestrictedVLANs checks that the VLANs used by a NIC device are among those allocated to the project from
+// its uplink networks, if the project has restricted.networks.vlans set.
+func checkRestrictedVLANs(project *db.Project, device map[string]string) error {
+	if project.Config["restricted.networks.vlans"] == "" {
+		return nil
+	}
+
+	allocations, err := ParseRestrictedVLANs(project.Config["restricted.networks.vlans"])
+	if err != nil {
+		return err
+	}
+
+	vlanIDs := strings.Split(device["vlan.tagged"], ",")
+	vlanIDs = append(vlanIDs, device["vlan"])
+
+	for _, vlanIDRaw := range vlanIDs {
+		vlanIDRaw = strings.TrimSpace(vlanIDRaw)
+		if vlanIDRaw == "" {
+			continue
+		}
+
+		vlanID, err := strconv.Atoi(vlanIDRaw)
+		if err != nil {
+			return fmt.Errorf("Invalid VLAN ID %q", vlanIDRaw)
+		}
+
+		allowed := false
+		for _, ranges := range allocations {
+			for _, vlanRange := range ranges {
+				if vlanID >= vlanRange.Start && vlanID <= vlanRange.End {
+					allowed = true
+					break
+				}
+			}
+		}
+
+		if !allowed {
+			return fmt.Errorf("VLAN %d isn't allocated to the project", vlanID)
+		}
+	}
+
+	return nil
+}
+
+// CheckUnixDeviceAllowed checks that the restrictions of the project allow attaching the host device with
+// the given udev properties to an instance through a device of the given type (unix-char or unix-block).
+func CheckUnixDeviceAllowed(project *db.Project, deviceType string, properties map[string]string) error {
+	if !shared.IsTrue(project.Config["restricted"]) || project.Config["restricted.devices."+deviceType] != "allowlist" {
+		return nil
+	}
+
+	for _, match := range parseUnixDeviceAllowlist(project.Config["restricted.devices.unix.allowlist"]) {
+		matched := true
+		for key, value := range match {
+			if properties[key] != value {
+				matched = false
+				break
+			}
+		}
+
+		if matched {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("Host device %q isn't in the allowlist of project %q", properties["DEVNAME"], project.Name)
+}
+
+// ValidateUnixDeviceAllowlist validates an allowlist of host devices, made of semicolon separated entries,
+// each a comma separated list of udev properties, e.g. "SUBSYSTEM=tty,ID_VENDOR_ID=0403;SUBSYSTEM=hidraw".
+func ValidateUnixDeviceAllowlist(value string) error {
+	for _, entry := range strings.Split(value, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			return fmt.Errorf("Empty allowlist entry")
+		}
+
+		for _, property := range strings.Split(entry, ",") {
+			fields := strings.SplitN(strings.TrimSpace(property), "=", 2)
+			if len(fields) != 2 || fields[0] == "" {
+				return fmt.Errorf("Invalid udev property %q in allowlist entry %q", property, entry)
+			}
+		}
+	}
+
+	return nil
+}
+
+// parseUnixDeviceAllowlist returns the udev properties to match for each entry of an allowlist.
+func parseUnixDeviceAllowlist(value string) []map[string]string {
+	matches := []map[string]string{}
+	if ValidateUnixDeviceAllowlist(value) != nil {
+		return matches
+	}
+
+	for _, entry := range strings.Split(value, ";") {
+		match := map[string]string{}
+		for _, property := range strings.Split(strings.TrimSpace(entry), ",") {
+			fields := strings.SplitN(strings.TrimSpace(property), "=", 2)
+			match[fields[0]] = fields[1]
+		}
+
+		matches = append(matches, match)
+	}
+
+	return matches
+}
+
 // Return true if a low-level container option is forbidden.
 func isContainerLowLevelOptionForbidden(key string) bool {
 	if strings.HasPrefix(key, "security.syscalls.intercept") {
 		return true
 	}
 
+	if strings.HasPrefix(key, "raw.apparmor.") {
+		return true
+	}
+
+	if strings.HasPrefix(key, "security.selinux.") {
+		return true
+	}
+
+	if strings.HasPrefix(key, "hooks.") {
+		return true
+	}
+
 	if shared.StringInSlice(key, []string{
 		"boot.host_shutdown_timeout",
 		"linux.kernel_modules",
@@ -690,6 +886,10 @@ func isContainerLowLevelOptionForbidden(key string) bool {
 
 // Return true if a low-level VM option is forbidden.
 func isVMLowLevelOptionForbidden(key string) bool {
+	if strings.HasPrefix(key, "hooks.") {
+		return true
+	}
+
 	if shared.StringInSlice(key, []string{
 		"boot.host_shutdown_timeout",
 		"limits.memory.hugepages",
@@ -1295,6 +1495,22 @@ func AllowBackupCreation(tx *db.ClusterTx, projectName string) error {
 	return nil
 }
 
+// AllowInstanceConversion returns an error if instances of the project can't be converted to
+// another type. The conversion handles the root filesystem of the instance on the host, so it's
+// refused in restricted projects.
+func AllowInstanceConversion(tx *db.ClusterTx, projectName string) error {
+	project, err := tx.GetProject(projectName)
+	if err != nil {
+		return err
+	}
+
+	if shared.IsTrue(project.Config["restricted"]) {
+		return fmt.Errorf("Project %s doesn't allow for instance conversion", projectName)
+	}
+
+	return nil
+}
+
 // AllowSnapshotCreation returns an error if any project-specific restriction is violated
 // when creating a new snapshot in a project.
 func AllowSnapshotCreation(tx *db.ClusterTx, projectName string) error {
@@ -192,3 +192,45 @@ func TestCheckClusterTargetRestriction_RestrictedFalse(t *testing.T) {
 	err = project.CheckClusterTargetRestriction(tx, req, "p1", "n1")
 	assert.NoError(t, err)
 }
+
+// Only the host devices matching an entry of the allowlist can be attached.
+func TestCheckUnixDeviceAllowed(t *testing.T) {
+	p := &db.Project{
+		Name: "p1",
+		Config: map[string]string{
+			"restricted":                        "true",
+			"restricted.devices.unix-char":      "allowlist",
+			"restricted.devices.unix.allowlist": "SUBSYSTEM=tty,ID_VENDOR_ID=0403;SUBSYSTEM=hidraw",
+		},
+	}
+
+	err := project.CheckUnixDeviceAllowed(p, "unix-char", map[string]string{"SUBSYSTEM": "tty", "ID_VENDOR_ID": "0403", "DEVNAME": "ttyUSB0"})
+	assert.NoError(t, err)
+
+	err = project.CheckUnixDeviceAllowed(p, "unix-char", map[string]string{"SUBSYSTEM": "hidraw", "DEVNAME": "hidraw0"})
+	assert.NoError(t, err)
+
+	err = project.CheckUnixDeviceAllowed(p, "unix-char", map[string]string{"SUBSYSTEM": "tty", "DEVNAME": "ttyS0"})
+	assert.EqualError(t, err, `Host device "ttyS0" isn't in the allowlist of project "p1"`)
+
+	// Block devices aren't restricted to the allowlist.
+	err = project.CheckUnixDeviceAllowed(p, "unix-block", map[string]string{"SUBSYSTEM": "block", "DEVNAME": "sda"})
+	assert.NoError(t, err)
+
+	assert.Error(t, project.ValidateUnixDeviceAllowlist("SUBSYSTEM=tty;;"))
+	assert.Error(t, project.ValidateUnixDeviceAllowlist("SUBSYSTEM"))
+}
+
+func TestParseRestrictedVLANs(t *testing.T) {
+	vlans, err := project.ParseRestrictedVLANs("uplink1:100, uplink1:200-299,uplink2:4094")
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]project.VLANRange{
+		"uplink1": {{Start: 100, End: 100}, {Start: 200, End: 299}},
+		"uplink2": {{Start: 4094, End: 4094}},
+	}, vlans)
+
+	for _, value := range []string{"100", ":100", "uplink1:0", "uplink1:4095", "uplink1:300-200", "uplink1:foo"} {
+		_, err = project.ParseRestrictedVLANs(value)
+		assert.Error(t, err, value)
+	}
+}
@@ -0,0 +1,175 @@
+package ratelimit
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// maxBuckets is the number of clients above which the buckets of idle clients are pruned.
+const maxBuckets = 4096
+
+// bucket is a token bucket holding up to one second worth of requests.
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Limiter limits the rate of API requests and the number of concurrent websockets of each client.
+type Limiter struct {
+	mu         sync.Mutex
+	requests   int64
+	websockets int64
+	buckets    map[string]*bucket
+	sockets    map[string]int64
+}
+
+// NewLimiter returns a new Limiter allowing each client the given number of requests per second and
+// concurrent websockets (0 meaning no limit).
+func NewLimiter(requests int64, websockets int64) *Limiter {
+	return &Limiter{
+		requests:   requests,
+		websockets: websockets,
+		buckets:    map[string]*bucket{},
+		sockets:    map[string]int64{},
+	}
+}
+
+// SetLimits changes the number of requests per second and concurrent websockets allowed to each client.
+func (l *Limiter) SetLimits(requests int64, websockets int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.requests = requests
+	l.websockets = websockets
+	l.buckets = map[string]*bucket{}
+}
+
+// Allow returns whether the client is allowed another request now.
+func (l *Limiter) Allow(client string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.requests <= 0 {
+		return true
+	}
+
+	now := time.Now()
+	rate := float64(l.requests)
+
+	b, ok := l.buckets[client]
+	if !ok {
+		if len(l.buckets) >= maxBuckets {
+			l.prune(now)
+		}
+
+		b = &bucket{tokens: rate, last: now}
+		l.buckets[client] = b
+	}
+
+	b.tokens += now.Sub(b.last).Seconds() * rate
+	if b.tokens > rate {
+		b.tokens = rate
+	}
+
+	b.last = now
+
+	if b.tokens < 1 {
+		return false
+	}
+
+	b.tokens--
+
+	return true
+}
+
+// prune removes the buckets of the clients which have been idle long enough for them to be full again.
+func (l *Limiter) prune(now time.Time) {
+	for client, b := range l.buckets {
+		if now.Sub(b.last) > time.Second {
+			delete(l.buckets, client)
+		}
+	}
+}
+
+// Websocket reserves one of the client's websockets for the request, returning false if the client
+// already has as many websockets as allowed. The websocket is released when the returned writer's
+// connection is closed, or by calling its Done function if the connection wasn't hijacked.
+func (l *Limiter) Websocket(w http.ResponseWriter, client string) (*WebsocketWriter, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.websockets > 0 && l.sockets[client] >= l.websockets {
+		return nil, false
+	}
+
+	l.sockets[client]++
+
+	ww := &WebsocketWriter{ResponseWriter: w}
+	ww.release = func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		l.sockets[client]--
+		if l.sockets[client] <= 0 {
+			delete(l.sockets, client)
+		}
+	}
+
+	return ww, true
+}
+
+// WebsocketWriter is a http.ResponseWriter holding one of the client's websockets.
+type WebsocketWriter struct {
+	http.ResponseWriter
+
+	mu       sync.Mutex
+	once     sync.Once
+	hijacked bool
+	release  func()
+}
+
+// Hijack hijacks the underlying connection, which then holds the websocket until it's closed.
+func (w *WebsocketWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("Response writer doesn't support hijacking")
+	}
+
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	w.mu.Lock()
+	w.hijacked = true
+	w.mu.Unlock()
+
+	return &websocketConn{Conn: conn, writer: w}, rw, nil
+}
+
+// Done releases the websocket unless the connection was hijacked.
+func (w *WebsocketWriter) Done() {
+	w.mu.Lock()
+	hijacked := w.hijacked
+	w.mu.Unlock()
+
+	if !hijacked {
+		w.once.Do(w.release)
+	}
+}
+
+type websocketConn struct {
+	net.Conn
+	writer *WebsocketWriter
+}
+
+// Close closes the connection and releases the websocket.
+func (c *websocketConn) Close() error {
+	c.writer.once.Do(c.writer.release)
+
+	return c.Conn.Close()
+}
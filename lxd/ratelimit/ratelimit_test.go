@@ -0,0 +1,88 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiter_Allow(t *testing.T) {
+	l := NewLimiter(2, 0)
+
+	assert.True(t, l.Allow("tls:foo"))
+	assert.True(t, l.Allow("tls:foo"))
+	assert.False(t, l.Allow("tls:foo"))
+
+	// Other clients have their own limit.
+	assert.True(t, l.Allow("tls:bar"))
+
+	// Requests are allowed again once the bucket refills.
+	l.buckets["tls:foo"].last = time.Now().Add(-time.Second)
+	assert.True(t, l.Allow("tls:foo"))
+
+	// No limit.
+	l.SetLimits(0, 0)
+	for i := 0; i < 10; i++ {
+		assert.True(t, l.Allow("tls:foo"))
+	}
+}
+
+func TestLimiter_Websocket(t *testing.T) {
+	l := NewLimiter(0, 1)
+
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ww, ok := l.Websocket(w, "tls:foo")
+		if !ok {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+
+		defer ww.Done()
+
+		conn, err := upgrader.Upgrade(ww, r, nil)
+		if err != nil {
+			return
+		}
+
+		// Keep the connection open until the client closes it.
+		go func() {
+			defer conn.Close()
+			for {
+				_, _, err := conn.ReadMessage()
+				if err != nil {
+					return
+				}
+			}
+		}()
+	}))
+	defer server.Close()
+
+	url := "ws" + server.URL[len("http"):]
+
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+
+	// The websocket is still held once the handler returned.
+	_, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	require.Error(t, err)
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+
+	// Closing the connection releases it.
+	conn.Close()
+	assert.Eventually(t, func() bool {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		return l.sockets["tls:foo"] == 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	conn, _, err = websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	conn.Close()
+}
@@ -0,0 +1,67 @@
+package rbac
+
+import (
+	"fmt"
+)
+
+// Built-in roles which can be granted to a restricted client on a project.
+const (
+	// RoleViewer can only view the project and its resources.
+	RoleViewer = "viewer"
+
+	// RoleOperator can also operate the existing instances (start/stop, exec, console, files, snapshots
+	// and backups).
+	RoleOperator = "operator"
+
+	// RoleAdmin can manage all the resources of the project.
+	RoleAdmin = "admin"
+)
+
+var rolePermissions = map[string][]string{
+	RoleViewer: {
+		"view",
+	},
+	RoleOperator: {
+		"view",
+		"operate-containers",
+	},
+	RoleAdmin: {
+		"view",
+		"manage-containers",
+		"manage-images",
+		"manage-networks",
+		"manage-profiles",
+		"manage-storage-volumes",
+		"operate-containers",
+	},
+}
+
+// RolePermissions returns the project permissions granted by a built-in role.
+func RolePermissions(role string) ([]string, error) {
+	permissions, ok := rolePermissions[role]
+	if !ok {
+		return nil, fmt.Errorf("Invalid role %q", role)
+	}
+
+	return permissions, nil
+}
+
+// ProjectRolesUserAccess returns the access of a restricted client from its role in each of its allowed
+// projects. Projects without a role get the admin role.
+func ProjectRolesUserAccess(roles map[string]string) (*UserAccess, error) {
+	ua := &UserAccess{Projects: map[string][]string{}}
+	for projectName, role := range roles {
+		if role == "" {
+			role = RoleAdmin
+		}
+
+		permissions, err := RolePermissions(role)
+		if err != nil {
+			return nil, err
+		}
+
+		ua.Projects[projectName] = permissions
+	}
+
+	return ua, nil
+}
@@ -0,0 +1,51 @@
+package rbac
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lxc/lxd/lxd/request"
+)
+
+// Test that restricted clients only get the permissions of their role in each project.
+func TestProjectRolesUserAccess(t *testing.T) {
+	ua, err := ProjectRolesUserAccess(map[string]string{
+		"viewer":   RoleViewer,
+		"operator": RoleOperator,
+		"admin":    RoleAdmin,
+		"default":  "",
+	})
+	require.NoError(t, err)
+	assert.False(t, ua.Admin)
+
+	r := httptest.NewRequest("POST", "/1.0/profiles", nil)
+	r = r.WithContext(context.WithValue(r.Context(), request.CtxAccess, ua))
+
+	tests := []struct {
+		project    string
+		permission string
+		allowed    bool
+	}{
+		{"viewer", "view", true},
+		{"viewer", "manage-profiles", false},
+		{"viewer", "manage-containers", false},
+		{"viewer", "operate-containers", false},
+		{"operator", "view", true},
+		{"operator", "operate-containers", true},
+		{"operator", "manage-containers", false},
+		{"admin", "manage-profiles", true},
+		{"default", "manage-containers", true},
+		{"other", "view", false},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.allowed, UserHasPermission(r, test.project, test.permission), "%s in %s", test.permission, test.project)
+	}
+
+	_, err = ProjectRolesUserAccess(map[string]string{"default": "owner"})
+	assert.Error(t, err)
+}
@@ -2,6 +2,7 @@ package resources
 
 import (
 	"bufio"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -273,9 +274,106 @@ func GetMemory() (*api.ResourcesMemory, error) {
 				node.Total = memTotal
 			}
 
+			// Get the local CPUs
+			node.CPUs = []int64{}
+			cpuListPath := filepath.Join(entryPath, "cpulist")
+			if sysfsExists(cpuListPath) {
+				buf, err := ioutil.ReadFile(cpuListPath)
+				if err != nil {
+					return nil, errors.Wrapf(err, "Failed to read %q", cpuListPath)
+				}
+
+				content := strings.TrimSpace(string(buf))
+
+				if content != "" {
+					node.CPUs, err = ParseCpuset(content)
+					if err != nil {
+						return nil, errors.Wrapf(err, "Failed to parse %q", cpuListPath)
+					}
+				}
+			}
+
+			// Get the distances to other nodes
+			node.Distances = []uint64{}
+			distancePath := filepath.Join(entryPath, "distance")
+			if sysfsExists(distancePath) {
+				buf, err := ioutil.ReadFile(distancePath)
+				if err != nil {
+					return nil, errors.Wrapf(err, "Failed to read %q", distancePath)
+				}
+
+				content := strings.TrimSpace(string(buf))
+
+				for _, field := range strings.Fields(content) {
+					distance, err := strconv.ParseUint(field, 10, 64)
+					if err != nil {
+						return nil, errors.Wrapf(err, "Failed to parse %q", distancePath)
+					}
+
+					node.Distances = append(node.Distances, distance)
+				}
+			}
+
 			memory.Nodes = append(memory.Nodes, node)
 		}
 	}
 
 	return &memory, nil
 }
+
+// GetNUMANodes returns a map of NUMA node identifiers to the CPU threads local to them.
+func GetNUMANodes() (map[uint64][]int64, error) {
+	memory, err := GetMemory()
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := map[uint64][]int64{}
+	for _, node := range memory.Nodes {
+		nodes[node.NUMANode] = node.CPUs
+	}
+
+	// Systems without NUMA support have a single node.
+	if len(nodes) == 0 {
+		cpu, err := GetCPU()
+		if err != nil {
+			return nil, err
+		}
+
+		nodes[0] = []int64{}
+		for _, socket := range cpu.Sockets {
+			for _, core := range socket.Cores {
+				for _, thread := range core.Threads {
+					nodes[0] = append(nodes[0], thread.ID)
+				}
+			}
+		}
+	}
+
+	return nodes, nil
+}
+
+// GetNUMANodeCPUs returns the CPU threads local to a set of NUMA nodes (e.g. "0,1").
+func GetNUMANodeCPUs(nodes string) ([]int64, error) {
+	nodeIDs, err := ParseCpuset(nodes)
+	if err != nil {
+		return nil, err
+	}
+
+	hostNodes, err := GetNUMANodes()
+	if err != nil {
+		return nil, err
+	}
+
+	cpus := []int64{}
+	for _, id := range nodeIDs {
+		nodeCPUs, ok := hostNodes[uint64(id)]
+		if !ok {
+			return nil, fmt.Errorf("NUMA node %d doesn't exist", id)
+		}
+
+		cpus = append(cpus, nodeCPUs...)
+	}
+
+	return cpus, nil
+}
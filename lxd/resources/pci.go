@@ -136,6 +136,45 @@ func GetPCI() (*api.ResourcesPCI, error) {
 			device.IOMMUGroup = 0
 		}
 
+		// Get the upstream bridge (the parent directory is a PCI device unless it's the root complex)
+		linkTarget, err := filepath.EvalSymlinks(devicePath)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to find %q", devicePath)
+		}
+
+		device.ParentAddress, err = pciAddress(filepath.Dir(linkTarget))
+		if err != nil {
+			return nil, err
+		}
+
+		// Get the physical function of virtual functions
+		physfnPath := filepath.Join(devicePath, "physfn")
+		if sysfsExists(physfnPath) {
+			physfnTarget, err := filepath.EvalSymlinks(physfnPath)
+			if err != nil {
+				return nil, errors.Wrapf(err, "Failed to find %q", physfnPath)
+			}
+
+			device.PhysicalFunction = filepath.Base(physfnTarget)
+		}
+
+		// Get SRIOV VF counts
+		if sysfsExists(filepath.Join(devicePath, "sriov_numvfs")) {
+			sriov := api.ResourcesPCIDeviceSRIOV{}
+
+			sriov.MaximumVFs, err = readUint(filepath.Join(devicePath, "sriov_totalvfs"))
+			if err != nil {
+				return nil, errors.Wrapf(err, "Failed to read %q", filepath.Join(devicePath, "sriov_totalvfs"))
+			}
+
+			sriov.CurrentVFs, err = readUint(filepath.Join(devicePath, "sriov_numvfs"))
+			if err != nil {
+				return nil, errors.Wrapf(err, "Failed to read %q", filepath.Join(devicePath, "sriov_numvfs"))
+			}
+
+			device.SRIOV = &sriov
+		}
+
 		pci.Devices = append(pci.Devices, device)
 		pci.Total++
 	}
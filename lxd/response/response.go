@@ -3,6 +3,7 @@ package response
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
@@ -136,18 +137,43 @@ func (r *syncResponse) String() string {
 
 // Error response
 type errorResponse struct {
-	code int
-	msg  string
+	code    int
+	msg     string
+	errType api.ErrorType
+}
+
+// errorTypes are the error types used for errors which don't carry one, based on their status code.
+var errorTypes = map[int]api.ErrorType{
+	http.StatusNotFound:           api.ErrNotFound,
+	http.StatusConflict:           api.ErrAlreadyExists,
+	http.StatusForbidden:          api.ErrForbidden,
+	http.StatusPreconditionFailed: api.ErrETagMismatch,
+}
+
+// newErrorResponse returns an error response for the given code and message, using the type of
+// the error if it has one.
+func newErrorResponse(code int, msg string, err error) *errorResponse {
+	errType := errorTypes[code]
+
+	var statusErr api.StatusError
+	var typeErr api.ErrorType
+	if errors.As(err, &statusErr) && statusErr.Type() != "" {
+		errType = statusErr.Type()
+	} else if errors.As(err, &typeErr) {
+		errType = typeErr
+	}
+
+	return &errorResponse{code: code, msg: msg, errType: errType}
 }
 
 // ErrorResponse returns an error response with the given code and msg.
 func ErrorResponse(code int, msg string) Response {
-	return &errorResponse{code, msg}
+	return newErrorResponse(code, msg, nil)
 }
 
 // BadRequest returns a bad request response (400) with the given error.
 func BadRequest(err error) Response {
-	return &errorResponse{http.StatusBadRequest, err.Error()}
+	return newErrorResponse(http.StatusBadRequest, err.Error(), err)
 }
 
 // Conflict returns a conflict response (409) with the given error.
@@ -157,7 +183,7 @@ func Conflict(err error) Response {
 		message = err.Error()
 	}
 
-	return &errorResponse{http.StatusConflict, message}
+	return newErrorResponse(http.StatusConflict, message, err)
 }
 
 // Forbidden returns a forbidden response (403) with the given error.
@@ -167,12 +193,12 @@ func Forbidden(err error) Response {
 		message = err.Error()
 	}
 
-	return &errorResponse{http.StatusForbidden, message}
+	return newErrorResponse(http.StatusForbidden, message, err)
 }
 
 // InternalError returns an internal error response (500) with the given error.
 func InternalError(err error) Response {
-	return &errorResponse{http.StatusInternalServerError, err.Error()}
+	return newErrorResponse(http.StatusInternalServerError, err.Error(), err)
 }
 
 // NotFound returns a not found response (404) with the given error.
@@ -182,7 +208,7 @@ func NotFound(err error) Response {
 		message = err.Error()
 	}
 
-	return &errorResponse{http.StatusNotFound, message}
+	return newErrorResponse(http.StatusNotFound, message, err)
 }
 
 // NotImplemented returns a not implemented response (501) with the given error.
@@ -192,13 +218,13 @@ func NotImplemented(err error) Response {
 		message = err.Error()
 	}
 
-	return &errorResponse{http.StatusNotImplemented, message}
+	return newErrorResponse(http.StatusNotImplemented, message, err)
 }
 
 // PreconditionFailed returns a precondition failed response (412) with the
 // given error.
 func PreconditionFailed(err error) Response {
-	return &errorResponse{http.StatusPreconditionFailed, err.Error()}
+	return newErrorResponse(http.StatusPreconditionFailed, err.Error(), err)
 }
 
 // Unavailable return an unavailable response (503) with the given error.
@@ -208,7 +234,17 @@ func Unavailable(err error) Response {
 		message = err.Error()
 	}
 
-	return &errorResponse{http.StatusServiceUnavailable, message}
+	return newErrorResponse(http.StatusServiceUnavailable, message, err)
+}
+
+// TooManyRequests return a too many requests response (429) with the given error.
+func TooManyRequests(err error) Response {
+	message := "too many requests"
+	if err != nil {
+		message = err.Error()
+	}
+
+	return newErrorResponse(http.StatusTooManyRequests, message, err)
 }
 
 func (r *errorResponse) String() string {
@@ -226,7 +262,12 @@ func (r *errorResponse) Render(w http.ResponseWriter) error {
 		output = io.MultiWriter(buf, captured)
 	}
 
-	err := json.NewEncoder(output).Encode(shared.Jmap{"type": api.ErrorResponse, "error": r.msg, "error_code": r.code})
+	resp := shared.Jmap{"type": api.ErrorResponse, "error": r.msg, "error_code": r.code}
+	if r.errType != "" {
+		resp["error_type"] = r.errType
+	}
+
+	err := json.NewEncoder(output).Encode(resp)
 
 	if err != nil {
 		return err
@@ -357,6 +398,55 @@ func (r *fileResponse) String() string {
 	return fmt.Sprintf("%d files", len(r.files))
 }
 
+type streamResponse struct {
+	contentType string
+	hook        func(w io.Writer) error
+}
+
+// StreamResponse returns a response streaming the data written by hook with the provided content type.
+// An error returned by hook before any data was written is rendered as a regular error response.
+func StreamResponse(contentType string, hook func(w io.Writer) error) Response {
+	return &streamResponse{contentType: contentType, hook: hook}
+}
+
+// streamWriter sends the response headers on the first write.
+type streamWriter struct {
+	w           http.ResponseWriter
+	contentType string
+	started     bool
+}
+
+func (s *streamWriter) Write(data []byte) (int, error) {
+	if !s.started {
+		s.w.Header().Set("Content-Type", s.contentType)
+		s.w.WriteHeader(http.StatusOK)
+		s.started = true
+	}
+
+	return s.w.Write(data)
+}
+
+func (r *streamResponse) Render(w http.ResponseWriter) error {
+	sw := &streamWriter{w: w, contentType: r.contentType}
+
+	err := r.hook(sw)
+	if err != nil {
+		if !sw.started {
+			return SmartError(err).Render(w)
+		}
+
+		return err
+	}
+
+	// Make sure the headers are sent for empty streams.
+	_, err = sw.Write(nil)
+	return err
+}
+
+func (r *streamResponse) String() string {
+	return fmt.Sprintf("%s stream", r.contentType)
+}
+
 type forwardedResponse struct {
 	client  lxd.InstanceServer
 	request *http.Request
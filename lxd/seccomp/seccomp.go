@@ -23,6 +23,7 @@ import (
 	// Used by cgo
 	_ "github.com/lxc/lxd/lxd/include"
 
+	"github.com/lxc/lxd/lxd/cgroup"
 	deviceConfig "github.com/lxc/lxd/lxd/device/config"
 	"github.com/lxc/lxd/lxd/project"
 	"github.com/lxc/lxd/lxd/state"
@@ -129,6 +130,7 @@ struct lxd_seccomp_data_arch {
 	int nr_setxattr;
 	int nr_mount;
 	int nr_bpf;
+	int nr_sysinfo;
 };
 
 #define LXD_SECCOMP_NOTIFY_MKNOD    0
@@ -136,63 +138,64 @@ struct lxd_seccomp_data_arch {
 #define LXD_SECCOMP_NOTIFY_SETXATTR 2
 #define LXD_SECCOMP_NOTIFY_MOUNT 3
 #define LXD_SECCOMP_NOTIFY_BPF 4
+#define LXD_SECCOMP_NOTIFY_SYSINFO 5
 
 // ordered by likelihood of usage...
 static const struct lxd_seccomp_data_arch seccomp_notify_syscall_table[] = {
-	{ -1, LXD_SECCOMP_NOTIFY_MKNOD, LXD_SECCOMP_NOTIFY_MKNODAT, LXD_SECCOMP_NOTIFY_SETXATTR, LXD_SECCOMP_NOTIFY_MOUNT, LXD_SECCOMP_NOTIFY_BPF },
+	{ -1, LXD_SECCOMP_NOTIFY_MKNOD, LXD_SECCOMP_NOTIFY_MKNODAT, LXD_SECCOMP_NOTIFY_SETXATTR, LXD_SECCOMP_NOTIFY_MOUNT, LXD_SECCOMP_NOTIFY_BPF, LXD_SECCOMP_NOTIFY_SYSINFO },
 #ifdef AUDIT_ARCH_X86_64
-	{ AUDIT_ARCH_X86_64,      133, 259, 188, 165, 321 },
+	{ AUDIT_ARCH_X86_64,      133, 259, 188, 165, 321,  99 },
 #endif
 #ifdef AUDIT_ARCH_I386
-	{ AUDIT_ARCH_I386,         14, 297, 226,  21, 357 },
+	{ AUDIT_ARCH_I386,         14, 297, 226,  21, 357, 116 },
 #endif
 #ifdef AUDIT_ARCH_AARCH64
-	{ AUDIT_ARCH_AARCH64,      -1,  33,   5,  21, 386 },
+	{ AUDIT_ARCH_AARCH64,      -1,  33,   5,  21, 386, 179 },
 #endif
 #ifdef AUDIT_ARCH_ARM
-	{ AUDIT_ARCH_ARM,          14, 324, 226,  21, 386 },
+	{ AUDIT_ARCH_ARM,          14, 324, 226,  21, 386, 116 },
 #endif
 #ifdef AUDIT_ARCH_ARMEB
-	{ AUDIT_ARCH_ARMEB,        14, 324, 226,  21, 386 },
+	{ AUDIT_ARCH_ARMEB,        14, 324, 226,  21, 386, 116 },
 #endif
 #ifdef AUDIT_ARCH_S390
-	{ AUDIT_ARCH_S390,         14, 290, 224,  21, 386 },
+	{ AUDIT_ARCH_S390,         14, 290, 224,  21, 386, 116 },
 #endif
 #ifdef AUDIT_ARCH_S390X
-	{ AUDIT_ARCH_S390X,        14, 290, 224,  21, 351 },
+	{ AUDIT_ARCH_S390X,        14, 290, 224,  21, 351, 116 },
 #endif
 #ifdef AUDIT_ARCH_PPC
-	{ AUDIT_ARCH_PPC,          14, 288, 209,  21, 361 },
+	{ AUDIT_ARCH_PPC,          14, 288, 209,  21, 361, 116 },
 #endif
 #ifdef AUDIT_ARCH_PPC64
-	{ AUDIT_ARCH_PPC64,        14, 288, 209,  21, 361 },
+	{ AUDIT_ARCH_PPC64,        14, 288, 209,  21, 361, 116 },
 #endif
 #ifdef AUDIT_ARCH_PPC64LE
-	{ AUDIT_ARCH_PPC64LE,      14, 288, 209,  21, 361 },
+	{ AUDIT_ARCH_PPC64LE,      14, 288, 209,  21, 361, 116 },
 #endif
 #ifdef AUDIT_ARCH_SPARC
-	{ AUDIT_ARCH_SPARC,        14, 286, 169, 167, 349 },
+	{ AUDIT_ARCH_SPARC,        14, 286, 169, 167, 349, 214 },
 #endif
 #ifdef AUDIT_ARCH_SPARC64
-	{ AUDIT_ARCH_SPARC64,      14, 286, 169, 167, 349 },
+	{ AUDIT_ARCH_SPARC64,      14, 286, 169, 167, 349, 214 },
 #endif
 #ifdef AUDIT_ARCH_MIPS
-	{ AUDIT_ARCH_MIPS,         14, 290, 224,  21,  -1 },
+	{ AUDIT_ARCH_MIPS,         14, 290, 224,  21,  -1, 116 },
 #endif
 #ifdef AUDIT_ARCH_MIPSEL
-	{ AUDIT_ARCH_MIPSEL,       14, 290, 224,  21,  -1 },
+	{ AUDIT_ARCH_MIPSEL,       14, 290, 224,  21,  -1, 116 },
 #endif
 #ifdef AUDIT_ARCH_MIPS64
-	{ AUDIT_ARCH_MIPS64,      131, 249, 180, 160,  -1 },
+	{ AUDIT_ARCH_MIPS64,      131, 249, 180, 160,  -1,  97 },
 #endif
 #ifdef AUDIT_ARCH_MIPS64N32
-	{ AUDIT_ARCH_MIPS64N32,   131, 253, 180, 160,  -1 },
+	{ AUDIT_ARCH_MIPS64N32,   131, 253, 180, 160,  -1,  97 },
 #endif
 #ifdef AUDIT_ARCH_MIPSEL64
-	{ AUDIT_ARCH_MIPSEL64,    131, 249, 180, 160,  -1 },
+	{ AUDIT_ARCH_MIPSEL64,    131, 249, 180, 160,  -1,  97 },
 #endif
 #ifdef AUDIT_ARCH_MIPSEL64N32
-	{ AUDIT_ARCH_MIPSEL64N32, 131, 253, 180, 160,  -1 },
+	{ AUDIT_ARCH_MIPSEL64N32, 131, 253, 180, 160,  -1,  97 },
 #endif
 };
 
@@ -227,6 +230,9 @@ static int seccomp_notify_get_syscall(struct seccomp_notif *req,
 		if (entry->nr_bpf == req->data.nr)
 			return LXD_SECCOMP_NOTIFY_BPF;
 
+		if (entry->nr_sysinfo == req->data.nr)
+			return LXD_SECCOMP_NOTIFY_SYSINFO;
+
 		break;
 	}
 
@@ -234,6 +240,23 @@ static int seccomp_notify_get_syscall(struct seccomp_notif *req,
 	return -EINVAL;
 }
 
+// Whether the syscall was made with the native architecture, in which case the layout of the
+// structs passed to the syscall matches the one of the daemon.
+static bool seccomp_notify_native_arch(struct seccomp_notif *req)
+{
+#if defined(__x86_64__)
+	return req->data.arch == AUDIT_ARCH_X86_64;
+#elif defined(__aarch64__)
+	return req->data.arch == AUDIT_ARCH_AARCH64;
+#elif defined(__powerpc64__) && __BYTE_ORDER__ == __ORDER_LITTLE_ENDIAN__
+	return req->data.arch == AUDIT_ARCH_PPC64LE;
+#elif defined(__s390x__)
+	return req->data.arch == AUDIT_ARCH_S390X;
+#else
+	return false;
+#endif
+}
+
 static void seccomp_notify_update_response(struct seccomp_notif_resp *resp,
 					   int new_neg_errno, uint32_t flags)
 {
@@ -436,6 +459,7 @@ const lxdSeccompNotifyMknodat = C.LXD_SECCOMP_NOTIFY_MKNODAT
 const lxdSeccompNotifySetxattr = C.LXD_SECCOMP_NOTIFY_SETXATTR
 const lxdSeccompNotifyMount = C.LXD_SECCOMP_NOTIFY_MOUNT
 const lxdSeccompNotifyBpf = C.LXD_SECCOMP_NOTIFY_BPF
+const lxdSeccompNotifySysinfo = C.LXD_SECCOMP_NOTIFY_SYSINFO
 
 const seccompHeader = `2
 `
@@ -513,6 +537,9 @@ bpf notify [0,8,SCMP_CMP_EQ]
 bpf notify [0,9,SCMP_CMP_EQ]
 `
 
+const seccompNotifySysinfo = `sysinfo notify
+`
+
 const compatBlockingPolicy = `[%s]
 compat_sys_rt_sigaction errno 38
 stub_x32_rt_sigreturn errno 38
@@ -563,6 +590,8 @@ type Instance interface {
 	DiskIdmap() (*idmap.IdmapSet, error)
 	IdmappedStorage(path string) idmap.IdmapStorageType
 	InsertSeccompUnixDevice(prefix string, m deviceConfig.Device, pid int) error
+	InitPID() int
+	CGroup() (*cgroup.CGroup, error)
 }
 
 var seccompPath = shared.VarPath("security", "seccomp")
@@ -600,6 +629,7 @@ func InstanceNeedsPolicy(c Instance) bool {
 		"security.syscalls.intercept.setxattr",
 		"security.syscalls.intercept.mount",
 		"security.syscalls.intercept.bpf",
+		"security.syscalls.intercept.sysinfo",
 	}
 
 	for _, k := range keys {
@@ -639,6 +669,7 @@ func InstanceNeedsIntercept(s *state.State, c Instance) (bool, error) {
 		"security.syscalls.intercept.setxattr": lxcSupportSeccompNotify,
 		"security.syscalls.intercept.mount":    lxcSupportSeccompNotifyContinue,
 		"security.syscalls.intercept.bpf":      lxcSupportSeccompNotifyAddfd,
+		"security.syscalls.intercept.sysinfo":  lxcSupportSeccompNotify,
 	}
 
 	needed := false
@@ -741,6 +772,10 @@ func seccompGetPolicyContent(s *state.State, c Instance) (string, error) {
 		if shared.IsTrue(config["security.syscalls.intercept.bpf"]) {
 			policy += seccompNotifyBpf
 		}
+
+		if shared.IsTrue(config["security.syscalls.intercept.sysinfo"]) {
+			policy += seccompNotifySysinfo
+		}
 	}
 
 	if allowlist != "" {
@@ -1840,6 +1875,143 @@ func (s *Server) HandleBpfSyscall(c Instance, siov *Iovec) int {
 	return 0
 }
 
+// HandleSysinfoSyscall handles sysinfo syscalls, reporting the memory, swap and processes of the
+// instance rather than those of the host.
+func (s *Server) HandleSysinfoSyscall(c Instance, siov *Iovec) int {
+	ctx := log.Ctx{"container": c.Name(),
+		"project":               c.Project(),
+		"syscall_number":        siov.req.data.nr,
+		"audit_architecture":    siov.req.data.arch,
+		"seccomp_notify_id":     siov.req.id,
+		"seccomp_notify_flags":  siov.req.flags,
+		"seccomp_notify_pid":    siov.req.pid,
+		"seccomp_notify_fd":     siov.notifyFd,
+		"seccomp_notify_mem_fd": siov.memFd,
+	}
+
+	defer logger.Debug("Handling sysinfo syscall", ctx)
+
+	if !C.seccomp_notify_native_arch(siov.req) {
+		ctx["syscall_continue"] = "true"
+		ctx["syscall_handler_reason"] = "Non-native architecture"
+		C.seccomp_notify_update_response(siov.resp, 0, C.uint32_t(seccompUserNotifFlagContinue))
+		return 0
+	}
+
+	info, err := instanceSysinfo(c)
+	if err != nil {
+		ctx["syscall_continue"] = "true"
+		ctx["syscall_handler_error"] = fmt.Sprintf("%s - Failed to get instance sysinfo", err)
+		C.seccomp_notify_update_response(siov.resp, 0, C.uint32_t(seccompUserNotifFlagContinue))
+		return 0
+	}
+
+	buf := (*[unsafe.Sizeof(*info)]byte)(unsafe.Pointer(info))[:]
+	_, err = unix.Pwrite(siov.memFd, buf, int64(siov.req.data.args[0]))
+	if err != nil {
+		ctx["syscall_handler_error"] = fmt.Sprintf("%s - Failed to write sysinfo", err)
+		return int(-C.EFAULT)
+	}
+
+	return 0
+}
+
+// instanceSysinfo returns the host's sysinfo with the uptime, memory, swap and processes of the instance.
+func instanceSysinfo(c Instance) (*unix.Sysinfo_t, error) {
+	info := unix.Sysinfo_t{}
+	err := unix.Sysinfo(&info)
+	if err != nil {
+		return nil, err
+	}
+
+	cg, err := c.CGroup()
+	if err != nil {
+		return nil, err
+	}
+
+	unit := uint64(info.Unit)
+	if unit == 0 {
+		unit = 1
+	}
+
+	// Memory.
+	memoryLimit, err := cg.GetMemoryLimit()
+	if err == nil && memoryLimit > 0 && uint64(memoryLimit)/unit < info.Totalram {
+		memoryUsage, err := cg.GetMemoryUsage()
+		if err != nil {
+			return nil, err
+		}
+
+		info.Totalram = uint64(memoryLimit) / unit
+		info.Freeram = 0
+		if memoryUsage < memoryLimit {
+			info.Freeram = uint64(memoryLimit-memoryUsage) / unit
+		}
+
+		info.Sharedram = 0
+		info.Bufferram = 0
+	}
+
+	// Swap.
+	swapLimit, err := cg.GetMemorySwapLimit()
+	if err == nil && swapLimit >= 0 && uint64(swapLimit)/unit < info.Totalswap {
+		swapUsage, err := cg.GetMemorySwapUsage()
+		if err != nil {
+			return nil, err
+		}
+
+		info.Totalswap = uint64(swapLimit) / unit
+		info.Freeswap = 0
+		if swapUsage < swapLimit {
+			info.Freeswap = uint64(swapLimit-swapUsage) / unit
+		}
+	}
+
+	// Processes.
+	procs, err := cg.GetProcessesUsage()
+	if err == nil {
+		info.Procs = uint16(procs)
+	}
+
+	// Uptime, from the start of the instance's init process.
+	pid := c.InitPID()
+	if pid > 0 {
+		startTime, err := processStartTime(pid)
+		if err == nil && startTime <= info.Uptime {
+			info.Uptime -= startTime
+		}
+	}
+
+	return &info, nil
+}
+
+// processStartTime returns the time (in seconds since boot) at which the process started.
+func processStartTime(pid int) (int64, error) {
+	content, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return -1, err
+	}
+
+	// Skip the command name, which can contain spaces.
+	end := strings.LastIndex(string(content), ")")
+	if end < 0 {
+		return -1, fmt.Errorf("Invalid stat file for process %d", pid)
+	}
+
+	// The start time is the 22nd field, the 20th after the command name.
+	fields := strings.Fields(string(content[end+1:]))
+	if len(fields) < 20 {
+		return -1, fmt.Errorf("Invalid stat file for process %d", pid)
+	}
+
+	ticks, err := strconv.ParseInt(fields[19], 10, 64)
+	if err != nil {
+		return -1, err
+	}
+
+	return ticks / int64(C.sysconf(C._SC_CLK_TCK)), nil
+}
+
 func (s *Server) handleSyscall(c Instance, siov *Iovec) int {
 	switch int(C.seccomp_notify_get_syscall(siov.req, siov.resp)) {
 	case lxdSeccompNotifyMknod:
@@ -1852,6 +2024,8 @@ func (s *Server) handleSyscall(c Instance, siov *Iovec) int {
 		return s.HandleMountSyscall(c, siov)
 	case lxdSeccompNotifyBpf:
 		return s.HandleBpfSyscall(c, siov)
+	case lxdSeccompNotifySysinfo:
+		return s.HandleSysinfoSyscall(c, siov)
 	}
 
 	return int(-C.EINVAL)
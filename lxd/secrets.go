@@ -0,0 +1,517 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+
+	"github.com/gorilla/mux"
+
+	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/lifecycle"
+	"github.com/lxc/lxd/lxd/project"
+	"github.com/lxc/lxd/lxd/request"
+	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/lxd/secrets"
+	"github.com/lxc/lxd/lxd/util"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/version"
+)
+
+var secretsCmd = APIEndpoint{
+	Path: "secrets",
+
+	Get:  APIEndpointAction{Handler: secretsGet, AccessHandler: allowProjectPermission("secrets", "view")},
+	Post: APIEndpointAction{Handler: secretsPost, AccessHandler: allowProjectPermission("secrets", "manage-containers")},
+}
+
+var secretCmd = APIEndpoint{
+	Path: "secrets/{name}",
+
+	Delete: APIEndpointAction{Handler: secretDelete, AccessHandler: allowProjectPermission("secrets", "manage-containers")},
+	Get:    APIEndpointAction{Handler: secretGet, AccessHandler: allowProjectPermission("secrets", "view")},
+	Patch:  APIEndpointAction{Handler: secretPatch, AccessHandler: allowProjectPermission("secrets", "manage-containers")},
+	Put:    APIEndpointAction{Handler: secretPut, AccessHandler: allowProjectPermission("secrets", "manage-containers")},
+}
+
+// secretNameRegexp matches the valid secret names.
+var secretNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// secretURL returns the URL of a secret.
+func secretURL(projectName string, name string) string {
+	u := fmt.Sprintf("/%s/secrets/%s", version.APIVersion, url.PathEscape(name))
+	if projectName != project.Default {
+		u = fmt.Sprintf("%s?project=%s", u, url.QueryEscape(projectName))
+	}
+
+	return u
+}
+
+// swagger:operation GET /1.0/secrets secrets secrets_get
+//
+// Get the secrets
+//
+// Returns a list of secrets (URLs).
+//
+// ---
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+// responses:
+//   "200":
+//     description: API endpoints
+//     schema:
+//       type: object
+//       description: Sync response
+//       properties:
+//         type:
+//           type: string
+//           description: Response type
+//           example: sync
+//         status:
+//           type: string
+//           description: Status description
+//           example: Success
+//         status_code:
+//           type: integer
+//           description: Status code
+//           example: 200
+//         metadata:
+//           type: array
+//           description: List of endpoints
+//           items:
+//             type: string
+//           example: |-
+//             [
+//               "/1.0/secrets/db-password",
+//               "/1.0/secrets/api-key"
+//             ]
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+
+// swagger:operation GET /1.0/secrets?recursion=1 secrets secrets_get_recursion1
+//
+// Get the secrets
+//
+// Returns a list of secrets (structs), without their values.
+//
+// ---
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+// responses:
+//   "200":
+//     description: API endpoints
+//     schema:
+//       type: object
+//       description: Sync response
+//       properties:
+//         type:
+//           type: string
+//           description: Response type
+//           example: sync
+//         status:
+//           type: string
+//           description: Status description
+//           example: Success
+//         status_code:
+//           type: integer
+//           description: Status code
+//           example: 200
+//         metadata:
+//           type: array
+//           description: List of secrets
+//           items:
+//             $ref: "#/definitions/Secret"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func secretsGet(d *Daemon, r *http.Request) response.Response {
+	projectName := projectParam(r)
+	recursion := util.IsRecursionRequest(r)
+
+	var dbSecrets []db.Secret
+	err := d.cluster.Transaction(func(tx *db.ClusterTx) error {
+		var err error
+		dbSecrets, err = tx.GetSecrets(projectName)
+		return err
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if recursion {
+		result := make([]api.Secret, 0, len(dbSecrets))
+		for _, secret := range dbSecrets {
+			result = append(result, secret.ToAPI())
+		}
+
+		return response.SyncResponse(true, result)
+	}
+
+	urls := make([]string, 0, len(dbSecrets))
+	for _, secret := range dbSecrets {
+		urls = append(urls, secretURL(projectName, secret.Name))
+	}
+
+	return response.SyncResponse(true, urls)
+}
+
+// swagger:operation POST /1.0/secrets secrets secrets_post
+//
+// Add a secret
+//
+// Creates a new secret, whose value is encrypted and can then be
+// referenced from config values as `${secret:NAME}`.
+//
+// ---
+// consumes:
+//   - application/json
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+//   - in: body
+//     name: secret
+//     description: Secret
+//     required: true
+//     schema:
+//       $ref: "#/definitions/SecretsPost"
+// responses:
+//   "200":
+//     $ref: "#/responses/EmptySyncResponse"
+//   "400":
+//     $ref: "#/responses/BadRequest"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func secretsPost(d *Daemon, r *http.Request) response.Response {
+	projectName := projectParam(r)
+
+	req := api.SecretsPost{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	if !secretNameRegexp.MatchString(req.Name) {
+		return response.BadRequest(fmt.Errorf("Invalid secret name %q", req.Name))
+	}
+
+	if req.Value == "" {
+		return response.BadRequest(fmt.Errorf("No value provided"))
+	}
+
+	key, err := secrets.Key(d.endpoints.NetworkCert().KeyPair())
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	value, err := secrets.Encrypt(key, req.Value)
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
+		_, err := tx.GetSecret(projectName, req.Name)
+		if err == nil {
+			return api.StatusErrorf(http.StatusConflict, "Secret %q already exists", req.Name)
+		} else if err != db.ErrNoSuchObject {
+			return err
+		}
+
+		_, err = tx.CreateSecret(projectName, db.Secret{Name: req.Name, Description: req.Description, Value: value})
+		return err
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	d.State().Events.SendLifecycle(projectName, lifecycle.SecretCreated.Event(req.Name, projectName, request.CreateRequestor(r), nil))
+
+	return response.SyncResponseLocation(true, nil, secretURL(projectName, req.Name))
+}
+
+// swagger:operation GET /1.0/secrets/{name} secrets secret_get
+//
+// Get the secret
+//
+// Gets a specific secret, without its value.
+//
+// ---
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+// responses:
+//   "200":
+//     description: Secret
+//     schema:
+//       type: object
+//       description: Sync response
+//       properties:
+//         type:
+//           type: string
+//           description: Response type
+//           example: sync
+//         status:
+//           type: string
+//           description: Status description
+//           example: Success
+//         status_code:
+//           type: integer
+//           description: Status code
+//           example: 200
+//         metadata:
+//           $ref: "#/definitions/Secret"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "404":
+//     $ref: "#/responses/NotFound"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func secretGet(d *Daemon, r *http.Request) response.Response {
+	projectName := projectParam(r)
+
+	name, err := url.PathUnescape(mux.Vars(r)["name"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	var secret *db.Secret
+	err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
+		secret, err = tx.GetSecret(projectName, name)
+		return err
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	resp := secret.ToAPI()
+
+	return response.SyncResponseETag(true, resp, resp.Writable())
+}
+
+// swagger:operation PATCH /1.0/secrets/{name} secrets secret_patch
+//
+// Partially update the secret
+//
+// Updates the description and, if provided, the value of the secret.
+//
+// ---
+// consumes:
+//   - application/json
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+//   - in: body
+//     name: secret
+//     description: Secret
+//     required: true
+//     schema:
+//       $ref: "#/definitions/SecretPut"
+// responses:
+//   "200":
+//     $ref: "#/responses/EmptySyncResponse"
+//   "400":
+//     $ref: "#/responses/BadRequest"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "412":
+//     $ref: "#/responses/PreconditionFailed"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func secretPatch(d *Daemon, r *http.Request) response.Response {
+	return secretPut(d, r)
+}
+
+// swagger:operation PUT /1.0/secrets/{name} secrets secret_put
+//
+// Update the secret
+//
+// Updates the description and, if provided, the value of the secret.
+//
+// ---
+// consumes:
+//   - application/json
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+//   - in: body
+//     name: secret
+//     description: Secret
+//     required: true
+//     schema:
+//       $ref: "#/definitions/SecretPut"
+// responses:
+//   "200":
+//     $ref: "#/responses/EmptySyncResponse"
+//   "400":
+//     $ref: "#/responses/BadRequest"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "412":
+//     $ref: "#/responses/PreconditionFailed"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func secretPut(d *Daemon, r *http.Request) response.Response {
+	projectName := projectParam(r)
+
+	name, err := url.PathUnescape(mux.Vars(r)["name"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	req := api.SecretPut{}
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	var value string
+	if req.Value != "" {
+		key, err := secrets.Key(d.endpoints.NetworkCert().KeyPair())
+		if err != nil {
+			return response.InternalError(err)
+		}
+
+		value, err = secrets.Encrypt(key, req.Value)
+		if err != nil {
+			return response.InternalError(err)
+		}
+	}
+
+	err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
+		secret, err := tx.GetSecret(projectName, name)
+		if err != nil {
+			return err
+		}
+
+		current := secret.ToAPI()
+		err = util.EtagCheck(r, current.Writable())
+		if err != nil {
+			return err
+		}
+
+		// Keep the current value unless a new one was provided.
+		if value == "" {
+			value = secret.Value
+		}
+
+		return tx.UpdateSecret(projectName, name, req.Description, value)
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	d.State().Events.SendLifecycle(projectName, lifecycle.SecretUpdated.Event(name, projectName, request.CreateRequestor(r), nil))
+
+	return response.EmptySyncResponse
+}
+
+// swagger:operation DELETE /1.0/secrets/{name} secrets secret_delete
+//
+// Delete the secret
+//
+// Removes the secret. Config values still referencing it will fail to be
+// expanded.
+//
+// ---
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+// responses:
+//   "200":
+//     $ref: "#/responses/EmptySyncResponse"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "404":
+//     $ref: "#/responses/NotFound"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func secretDelete(d *Daemon, r *http.Request) response.Response {
+	projectName := projectParam(r)
+
+	name, err := url.PathUnescape(mux.Vars(r)["name"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
+		return tx.DeleteSecret(projectName, name)
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	d.State().Events.SendLifecycle(projectName, lifecycle.SecretDeleted.Event(name, projectName, request.CreateRequestor(r), nil))
+
+	return response.EmptySyncResponse
+}
+
+// secretsReencrypt re-encrypts the values of all the secrets, from the key derived from the old network
+// certificate to the one derived from the new one.
+func secretsReencrypt(tx *db.ClusterTx, oldKey []byte, newKey []byte) error {
+	dbSecrets, err := tx.GetAllSecrets()
+	if err != nil {
+		return err
+	}
+
+	for _, secret := range dbSecrets {
+		value, err := secrets.Decrypt(oldKey, secret.Value)
+		if err != nil {
+			return fmt.Errorf("Failed to decrypt secret %q of project %q: %w", secret.Name, secret.Project, err)
+		}
+
+		encrypted, err := secrets.Encrypt(newKey, value)
+		if err != nil {
+			return err
+		}
+
+		err = tx.UpdateSecretValue(secret.ID, encrypted)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,63 @@
+//go:build linux && cgo && !agent
+// +build linux,cgo,!agent
+
+package secrets
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/state"
+)
+
+// Expand replaces the references to secrets in the value with the values of the secrets of the project.
+func Expand(s *state.State, projectName string, value string) (string, error) {
+	if !strings.Contains(value, "${secret:") {
+		return value, nil
+	}
+
+	key, err := Key(s.Endpoints.NetworkCert().KeyPair())
+	if err != nil {
+		return "", err
+	}
+
+	return ExpandWith(value, func(name string) (string, error) {
+		var secret *db.Secret
+		err := s.Cluster.Transaction(func(tx *db.ClusterTx) error {
+			var err error
+			secret, err = tx.GetSecret(projectName, name)
+			return err
+		})
+		if err != nil {
+			if err == db.ErrNoSuchObject {
+				return "", fmt.Errorf("Secret %q not found in project %q", name, projectName)
+			}
+
+			return "", err
+		}
+
+		return Decrypt(key, secret.Value)
+	})
+}
+
+// ExpandConfig returns a copy of the config with the references to secrets in the values of the keys
+// accepted by filter replaced with the values of the secrets of the project.
+func ExpandConfig(s *state.State, projectName string, config map[string]string, filter func(key string) bool) (map[string]string, error) {
+	expanded := make(map[string]string, len(config))
+	for k, v := range config {
+		if !filter(k) {
+			expanded[k] = v
+			continue
+		}
+
+		value, err := Expand(s, projectName, v)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to expand secrets in %q: %w", k, err)
+		}
+
+		expanded[k] = value
+	}
+
+	return expanded, nil
+}
@@ -0,0 +1,116 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"regexp"
+
+	"github.com/lxc/lxd/shared"
+)
+
+// referenceRegexp matches the references to secrets in config values, e.g. "${secret:db-password}".
+var referenceRegexp = regexp.MustCompile(`\$\{secret:([^}]+)\}`)
+
+// Key derives the key used to encrypt the secrets from the private key of the given key pair.
+// The network key pair is used, so that all the members of a cluster share the same key.
+func Key(keypair tls.Certificate) ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(keypair.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to derive secrets key: %w", err)
+	}
+
+	key := sha256.Sum256(append([]byte("lxd-secrets:"), der...))
+
+	return key[:], nil
+}
+
+// Encrypt encrypts the value of a secret with the given key.
+func Encrypt(key []byte, value string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	_, err = io.ReadFull(rand.Reader, nonce)
+	if err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(value), nil)
+
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt decrypts the value of a secret encrypted with the given key.
+func Decrypt(key []byte, encrypted string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		return "", err
+	}
+
+	if len(sealed) < gcm.NonceSize() {
+		return "", fmt.Errorf("Encrypted secret is too short")
+	}
+
+	value, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("Failed to decrypt secret: %w", err)
+	}
+
+	return string(value), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// References returns the names of the secrets referenced in the value.
+func References(value string) []string {
+	names := []string{}
+	for _, match := range referenceRegexp.FindAllStringSubmatch(value, -1) {
+		if !shared.StringInSlice(match[1], names) {
+			names = append(names, match[1])
+		}
+	}
+
+	return names
+}
+
+// ExpandWith replaces the references to secrets in the value with the values returned by lookup.
+func ExpandWith(value string, lookup func(name string) (string, error)) (string, error) {
+	var err error
+
+	expanded := referenceRegexp.ReplaceAllStringFunc(value, func(reference string) string {
+		if err != nil {
+			return ""
+		}
+
+		var secret string
+		secret, err = lookup(referenceRegexp.FindStringSubmatch(reference)[1])
+
+		return secret
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return expanded, nil
+}
@@ -0,0 +1,55 @@
+package secrets
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	key := make([]byte, 32)
+	for i := range key {
+		key[i] = byte(i)
+	}
+
+	encrypted, err := Encrypt(key, "hunter2")
+	require.NoError(t, err)
+	assert.NotContains(t, encrypted, "hunter2")
+
+	value, err := Decrypt(key, encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, "hunter2", value)
+
+	// A different key can't decrypt it.
+	key[0] = 0xff
+	_, err = Decrypt(key, encrypted)
+	assert.Error(t, err)
+}
+
+func TestExpandWith(t *testing.T) {
+	values := map[string]string{"user": "admin", "password": "hunter2"}
+	lookup := func(name string) (string, error) {
+		value, ok := values[name]
+		if !ok {
+			return "", fmt.Errorf("Secret %q not found", name)
+		}
+
+		return value, nil
+	}
+
+	assert.Equal(t, []string{"user", "password"}, References("${secret:user}:${secret:password}@${secret:user}"))
+	assert.Equal(t, []string{}, References("no secrets here, ${user}"))
+
+	expanded, err := ExpandWith("${secret:user}:${secret:password}", lookup)
+	require.NoError(t, err)
+	assert.Equal(t, "admin:hunter2", expanded)
+
+	expanded, err = ExpandWith("PATH=${PATH}", lookup)
+	require.NoError(t, err)
+	assert.Equal(t, "PATH=${PATH}", expanded)
+
+	_, err = ExpandWith("${secret:missing}", lookup)
+	assert.EqualError(t, err, `Secret "missing" not found`)
+}
@@ -9,6 +9,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/pborman/uuid"
 	"github.com/pkg/errors"
 	yaml "gopkg.in/yaml.v2"
 
@@ -1143,6 +1144,7 @@ func (b *lxdBackend) RefreshCustomVolume(projectName string, srcProjectName stri
 				MigrationType: migrationTypes[0],
 				TrackProgress: true, // Do use a progress tracker on sender.
 				ContentType:   string(contentType),
+				Refresh:       true,
 			}, op)
 
 			if err != nil {
@@ -1289,6 +1291,7 @@ func (b *lxdBackend) RefreshInstance(inst instance.Instance, src instance.Instan
 				Snapshots:     snapshotNames,
 				MigrationType: migrationTypes[0],
 				TrackProgress: true, // Do use a progress tracker on sender.
+				Refresh:       true,
 			}, op)
 
 			if err != nil {
@@ -1344,6 +1347,37 @@ func (b *lxdBackend) RefreshInstance(inst instance.Instance, src instance.Instan
 	return nil
 }
 
+// FlattenInstance makes the instance's volume independent of the volume it was cloned from.
+func (b *lxdBackend) FlattenInstance(inst instance.Instance, op *operations.Operation) error {
+	logger := logging.AddContext(b.logger, log.Ctx{"project": inst.Project(), "instance": inst.Name()})
+	logger.Debug("FlattenInstance started")
+	defer logger.Debug("FlattenInstance finished")
+
+	if inst.IsSnapshot() {
+		return fmt.Errorf("Instance cannot be a snapshot")
+	}
+
+	// Check we can convert the instance to the volume type needed.
+	volType, err := InstanceTypeToVolumeType(inst.Type())
+	if err != nil {
+		return err
+	}
+
+	// Get the root disk device config.
+	rootDiskConf, err := b.instanceRootVolumeConfig(inst)
+	if err != nil {
+		return err
+	}
+
+	contentType := InstanceContentType(inst)
+	volStorageName := project.Instance(inst.Project(), inst.Name())
+
+	// Get the volume.
+	vol := b.newVolume(volType, contentType, volStorageName, rootDiskConf)
+
+	return b.driver.FlattenVolume(vol, op)
+}
+
 // imageFiller returns a function that can be used as a filler function with CreateVolume().
 // The function returned will unpack the specified image archive into the specified mount path
 // provided, and for VM images, a raw root block path is required to unpack the qcow2 image into.
@@ -1354,7 +1388,7 @@ func (b *lxdBackend) imageFiller(fingerprint string, op *operations.Operation) f
 			metadata := make(map[string]interface{})
 			tracker = &ioprogress.ProgressTracker{
 				Handler: func(percent, speed int64) {
-					shared.SetProgressMetadata(metadata, "create_instance_from_image_unpack", "Unpack", percent, 0, speed)
+					shared.SetProgressMetadata(metadata, "create_instance_from_image_unpack", "Unpack", percent, 0, 0, speed)
 					op.UpdateMetadata(metadata)
 				}}
 		}
@@ -1722,6 +1756,102 @@ func (b *lxdBackend) RenameInstance(inst instance.Instance, newName string, op *
 	return nil
 }
 
+// RebuildInstance replaces the root volume of the instance with a new one created from the image, or empty if
+// fingerprint is empty. The instance must not have any snapshot. The volume record and its config are kept,
+// and the old volume is set aside until the new one is created so that it can be restored on failure.
+func (b *lxdBackend) RebuildInstance(inst instance.Instance, fingerprint string, op *operations.Operation) error {
+	logger := logging.AddContext(b.logger, log.Ctx{"project": inst.Project(), "instance": inst.Name(), "fingerprint": fingerprint})
+	logger.Debug("RebuildInstance started")
+	defer logger.Debug("RebuildInstance finished")
+
+	if inst.IsSnapshot() {
+		return fmt.Errorf("Instance must not be a snapshot")
+	}
+
+	snapshots, err := b.state.Cluster.GetInstanceSnapshotsNames(inst.Project(), inst.Name())
+	if err != nil {
+		return err
+	}
+
+	if len(snapshots) > 0 {
+		return fmt.Errorf("Cannot rebuild an instance volume that has snapshots")
+	}
+
+	volType, err := InstanceTypeToVolumeType(inst.Type())
+	if err != nil {
+		return err
+	}
+
+	contentType := InstanceContentType(inst)
+
+	rootDiskConf, err := b.instanceRootVolumeConfig(inst)
+	if err != nil {
+		return err
+	}
+
+	// Get the volume name on storage.
+	volStorageName := project.Instance(inst.Project(), inst.Name())
+	vol := b.newVolume(volType, contentType, volStorageName, rootDiskConf)
+
+	revert := revert.New()
+	defer revert.Fail()
+
+	// Set the old volume aside.
+	oldVolStorageName := fmt.Sprintf("%s_rebuild-%s", volStorageName, uuid.New())
+	oldVol := b.newVolume(volType, contentType, oldVolStorageName, rootDiskConf)
+
+	if b.driver.HasVolume(vol) {
+		err = b.driver.RenameVolume(vol, oldVolStorageName, op)
+		if err != nil {
+			return errors.Wrapf(err, "Failed setting old instance volume aside")
+		}
+
+		revert.Add(func() {
+			if b.driver.HasVolume(vol) {
+				b.driver.DeleteVolume(vol, op)
+			}
+
+			b.driver.RenameVolume(oldVol, volStorageName, op)
+			b.ensureInstanceSymlink(inst.Type(), inst.Project(), inst.Name(), vol.MountPath())
+		})
+	}
+
+	// Create the new volume.
+	if fingerprint == "" {
+		err = b.driver.CreateVolume(vol, nil, op)
+		if err != nil {
+			return err
+		}
+
+		err = b.ensureInstanceSymlink(inst.Type(), inst.Project(), inst.Name(), vol.MountPath())
+		if err != nil {
+			return err
+		}
+
+		err = inst.DeferTemplateApply(instance.TemplateTriggerCreate)
+		if err != nil {
+			return err
+		}
+	} else {
+		err = b.CreateInstanceFromImage(inst, fingerprint, op)
+		if err != nil {
+			return err
+		}
+	}
+
+	revert.Success()
+
+	// Delete the old volume.
+	if b.driver.HasVolume(oldVol) {
+		err = b.driver.DeleteVolume(oldVol, op)
+		if err != nil {
+			logger.Warn("Failed deleting old instance volume", log.Ctx{"volName": oldVolStorageName, "err": err})
+		}
+	}
+
+	return nil
+}
+
 // DeleteInstance removes the instance's root volume (all snapshots need to be removed first).
 func (b *lxdBackend) DeleteInstance(inst instance.Instance, op *operations.Operation) error {
 	logger := logging.AddContext(b.logger, log.Ctx{"project": inst.Project(), "instance": inst.Name()})
@@ -83,6 +83,7 @@ func (d *btrfs) Info() Info {
 		RunningCopyFreeze:     false,
 		DirectIO:              true,
 		MountedRoot:           true,
+		CloneCopy:             true,
 	}
 }
 
@@ -88,6 +88,7 @@ func (d *ceph) Info() Info {
 		RunningCopyFreeze: true,
 		DirectIO:          true,
 		MountedRoot:       false,
+		CloneCopy:         d.cloneCopy(),
 	}
 }
 
@@ -35,6 +35,12 @@ var cephVolTypePrefixes = map[VolumeType]string{
 	VolumeTypeCustom:    db.StoragePoolVolumeTypeNameCustom,
 }
 
+// cloneCopy returns whether copies of volumes without snapshots are lightweight clones of the source
+// (ceph.rbd.clone_copy).
+func (d *ceph) cloneCopy() bool {
+	return d.config["ceph.rbd.clone_copy"] == "" || shared.IsTrue(d.config["ceph.rbd.clone_copy"])
+}
+
 // osdPoolExists checks whether a given OSD pool exists.
 func (d *ceph) osdPoolExists() bool {
 	_, err := shared.RunCommand(
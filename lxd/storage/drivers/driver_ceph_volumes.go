@@ -350,7 +350,7 @@ func (d *ceph) CreateVolumeFromCopy(vol Volume, srcVol Volume, copySnapshots boo
 	// Copy without snapshots.
 	if !copySnapshots || len(snapshots) == 0 {
 		// If lightweight clone mode isn't enabled, perform a full copy of the volume.
-		if d.config["ceph.rbd.clone_copy"] != "" && !shared.IsTrue(d.config["ceph.rbd.clone_copy"]) {
+		if !d.cloneCopy() {
 			_, err = shared.RunCommand(
 				"rbd",
 				"--id", d.config["ceph.user.name"],
@@ -598,6 +598,41 @@ func (d *ceph) CreateVolumeFromMigration(vol Volume, conn io.ReadWriteCloser, vo
 	return nil
 }
 
+// FlattenVolume copies the data shared with the parent snapshot into a volume created as a clone,
+// so that it no longer depends on it.
+func (d *ceph) FlattenVolume(vol Volume, op *operations.Operation) error {
+	// For VMs, also flatten the filesystem volume.
+	if vol.IsVMBlock() {
+		err := d.FlattenVolume(vol.NewVMBlockFilesystemVolume(), op)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Nothing to do if the volume isn't a clone.
+	_, err := d.rbdGetVolumeParent(vol)
+	if err != nil {
+		if err == db.ErrNoSuchObject {
+			return nil
+		}
+
+		return err
+	}
+
+	_, err = shared.RunCommand(
+		"rbd",
+		"--id", d.config["ceph.user.name"],
+		"--cluster", d.config["ceph.cluster_name"],
+		"--pool", d.config["ceph.osd.pool_name"],
+		"flatten",
+		d.getRBDVolumeName(vol, "", false, false))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
 // RefreshVolume updates an existing volume to match the state of another.
 func (d *ceph) RefreshVolume(vol Volume, srcVol Volume, srcSnapshots []Volume, op *operations.Operation) error {
 	return genericVFSCopyVolume(d, nil, vol, srcVol, srcSnapshots, true, op)
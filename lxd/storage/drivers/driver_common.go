@@ -8,6 +8,7 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/lxc/lxd/lxd/migration"
+	"github.com/lxc/lxd/lxd/operations"
 	"github.com/lxc/lxd/lxd/state"
 	"github.com/lxc/lxd/shared"
 	log "github.com/lxc/lxd/shared/log15"
@@ -82,6 +83,12 @@ func (d *common) FillVolumeConfig(vol Volume) error {
 	return nil
 }
 
+// FlattenVolume makes a volume independent of the volume it was copied from.
+// Copies are independent for most drivers, so by default this does nothing.
+func (d *common) FlattenVolume(vol Volume, op *operations.Operation) error {
+	return nil
+}
+
 // validateVolume validates a volume config against common rules and optional driver specific rules.
 // This functions has a removeUnknownKeys option that if set to true will remove any unknown fields
 // (excluding those starting with "user.") which can be used when translating a volume config to a
@@ -97,6 +97,7 @@ func (d *lvm) Info() Info {
 		RunningCopyFreeze: false,
 		DirectIO:          true,
 		MountedRoot:       false,
+		CloneCopy:         d.usesThinpool(), // Only thinpool pools use snapshots for copies.
 	}
 }
 
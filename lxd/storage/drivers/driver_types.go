@@ -15,6 +15,7 @@ type Info struct {
 	RunningCopyFreeze     bool         // Whether instance should be frozen during snapshot if running.
 	DirectIO              bool         // Whether the driver supports direct I/O.
 	MountedRoot           bool         // Whether the pool directory itself is a mount.
+	CloneCopy             bool         // Whether copies of volumes within the pool are copy-on-write clones.
 }
 
 // VolumeFiller provides a struct for filling a volume.
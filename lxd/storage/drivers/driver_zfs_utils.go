@@ -1,6 +1,7 @@
 package drivers
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -9,10 +10,13 @@ import (
 	"strings"
 
 	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
 
 	"github.com/lxc/lxd/lxd/migration"
+	"github.com/lxc/lxd/lxd/operations"
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/ioprogress"
+	log "github.com/lxc/lxd/shared/log15"
 )
 
 // zfsBlockVolSuffix suffix used for block content type volumes.
@@ -84,6 +88,12 @@ func (d *zfs) checkDataset(dataset string) bool {
 	return strings.TrimSpace(out) == dataset
 }
 
+// cloneCopy returns whether copies of volumes without snapshots are clones of the source (zfs.clone_copy).
+// The rebase mode sends the volume on top of its image instead, so the copy doesn't share its data with the source.
+func (d *zfs) cloneCopy() bool {
+	return d.config["zfs.clone_copy"] == "" || shared.IsTrue(d.config["zfs.clone_copy"])
+}
+
 func (d *zfs) deleteDatasetRecursive(dataset string) error {
 	// Locate the origin snapshot (if any).
 	origin, err := d.getDatasetProperty(dataset, "origin")
@@ -367,3 +377,239 @@ func (d *zfs) receiveDataset(vol Volume, conn io.ReadWriteCloser, writeWrapper f
 
 	return nil
 }
+
+// zfsRefreshSnapshot identifies a snapshot of a volume being refreshed. The GUID is kept by zfs send/receive so
+// it tells whether a snapshot on the target is a copy of the one with the same name on the source.
+type zfsRefreshSnapshot struct {
+	Name string `json:"name"`
+	GUID string `json:"guid"`
+}
+
+// zfsRefreshHeader is sent by the source of a refresh and lists the volume's snapshots from oldest to newest.
+type zfsRefreshHeader struct {
+	Snapshots []zfsRefreshSnapshot `json:"snapshots"`
+}
+
+// zfsRefreshResponse is sent back by the target of a refresh. It contains the snapshot to send the changes from
+// (empty to send the volume in full) and the snapshots to send.
+type zfsRefreshResponse struct {
+	Base      string   `json:"base"`
+	Snapshots []string `json:"snapshots"`
+}
+
+// refreshSnapshots returns the LXD snapshots of a data set from oldest to newest, along with their GUIDs.
+func (d *zfs) refreshSnapshots(dataset string) ([]zfsRefreshSnapshot, error) {
+	snapshots := []zfsRefreshSnapshot{}
+	if !d.checkDataset(dataset) {
+		return snapshots, nil
+	}
+
+	out, err := shared.RunCommand("zfs", "list", "-H", "-p", "-o", "name,guid", "-t", "snapshot", "-s", "createtxg", "-d", "1", dataset)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+
+		prefix := fmt.Sprintf("%s@snapshot-", dataset)
+		if !strings.HasPrefix(fields[0], prefix) {
+			continue // Skip temporary snapshots.
+		}
+
+		snapshots = append(snapshots, zfsRefreshSnapshot{
+			Name: strings.TrimPrefix(fields[0], prefix),
+			GUID: fields[1],
+		})
+	}
+
+	return snapshots, nil
+}
+
+// zfsRefreshPlan works out what the source of a refresh has to send. If the target's snapshots are copies of the
+// oldest snapshots of the source, then only the wanted snapshots that follow them are sent, incrementally from
+// the most recent one. Otherwise the volume is sent in full along with all the snapshots the target keeps.
+func zfsRefreshPlan(source []zfsRefreshSnapshot, target []zfsRefreshSnapshot, wanted []string) zfsRefreshResponse {
+	resp := zfsRefreshResponse{Snapshots: []string{}}
+
+	common := 0
+	for common < len(target) && common < len(source) && target[common] == source[common] {
+		common++
+	}
+
+	if common > 0 && common == len(target) {
+		resp.Base = target[common-1].Name
+
+		for _, snapshot := range source[common:] {
+			if shared.StringInSlice(snapshot.Name, wanted) {
+				resp.Snapshots = append(resp.Snapshots, snapshot.Name)
+			}
+		}
+
+		return resp
+	}
+
+	targetNames := make([]string, 0, len(target))
+	for _, snapshot := range target {
+		targetNames = append(targetNames, snapshot.Name)
+	}
+
+	for _, snapshot := range source {
+		if shared.StringInSlice(snapshot.Name, wanted) || shared.StringInSlice(snapshot.Name, targetNames) {
+			resp.Snapshots = append(resp.Snapshots, snapshot.Name)
+		}
+	}
+
+	return resp
+}
+
+// sendRefresh sends a volume to a target that may already have some of its snapshots. The target is told about
+// all the snapshots of the volume and replies with those it needs, which are then sent incrementally.
+func (d *zfs) sendRefresh(vol Volume, conn io.ReadWriteCloser, volSrcArgs *migration.VolumeSourceArgs, op *operations.Operation) error {
+	header := zfsRefreshHeader{Snapshots: []zfsRefreshSnapshot{}}
+	if !vol.IsSnapshot() {
+		snapshots, err := d.refreshSnapshots(d.dataset(vol, false))
+		if err != nil {
+			return err
+		}
+
+		header.Snapshots = snapshots
+	}
+
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		return errors.Wrapf(err, "Failed encoding refresh header")
+	}
+
+	_, err = conn.Write(headerJSON)
+	if err != nil {
+		return errors.Wrapf(err, "Failed sending refresh header")
+	}
+
+	err = conn.Close() // End the frame.
+	if err != nil {
+		return errors.Wrapf(err, "Failed closing refresh header frame")
+	}
+
+	buf, err := ioutil.ReadAll(conn)
+	if err != nil {
+		return errors.Wrapf(err, "Failed reading refresh response")
+	}
+
+	resp := zfsRefreshResponse{}
+	err = json.Unmarshal(buf, &resp)
+	if err != nil {
+		return errors.Wrapf(err, "Failed decoding refresh response")
+	}
+
+	d.logger.Debug("Received refresh response", log.Ctx{"name": vol.name, "base": resp.Base, "snapshots": resp.Snapshots})
+
+	parent := ""
+	if resp.Base != "" {
+		baseVol, _ := vol.NewSnapshot(resp.Base)
+		parent = d.dataset(baseVol, false)
+	}
+
+	// Transfer the snapshots the target asked for.
+	for _, snapName := range resp.Snapshots {
+		snapshot, _ := vol.NewSnapshot(snapName)
+
+		var wrapper *ioprogress.ProgressTracker
+		if volSrcArgs.TrackProgress {
+			wrapper = migration.ProgressTracker(op, "fs_progress", snapshot.name)
+		}
+
+		err = d.sendDataset(d.dataset(snapshot, false), parent, volSrcArgs, conn, wrapper)
+		if err != nil {
+			return err
+		}
+
+		parent = d.dataset(snapshot, false)
+	}
+
+	var wrapper *ioprogress.ProgressTracker
+	if volSrcArgs.TrackProgress {
+		wrapper = migration.ProgressTracker(op, "fs_progress", vol.name)
+	}
+
+	srcSnapshot := d.dataset(vol, false)
+	if !vol.IsSnapshot() {
+		// Create a temporary read-only snapshot.
+		srcSnapshot = fmt.Sprintf("%s@migration-%s", d.dataset(vol, false), uuid.New())
+		_, err := shared.RunCommand("zfs", "snapshot", srcSnapshot)
+		if err != nil {
+			return err
+		}
+
+		defer shared.RunCommand("zfs", "destroy", srcSnapshot)
+	}
+
+	// Send the volume itself.
+	return d.sendDataset(srcSnapshot, parent, volSrcArgs, conn, wrapper)
+}
+
+// receiveRefresh receives a volume from the source of a refresh. The snapshots already on the volume are
+// compared with those of the source to only ask for the snapshots that follow them. If they don't match, the
+// volume is replaced by a full copy.
+func (d *zfs) receiveRefresh(vol Volume, conn io.ReadWriteCloser, volTargetArgs migration.VolumeTargetArgs, op *operations.Operation) error {
+	buf, err := ioutil.ReadAll(conn)
+	if err != nil {
+		return errors.Wrapf(err, "Failed reading refresh header")
+	}
+
+	header := zfsRefreshHeader{}
+	err = json.Unmarshal(buf, &header)
+	if err != nil {
+		return errors.Wrapf(err, "Failed decoding refresh header")
+	}
+
+	dataset := d.dataset(vol, false)
+	targetSnapshots, err := d.refreshSnapshots(dataset)
+	if err != nil {
+		return err
+	}
+
+	resp := zfsRefreshPlan(header.Snapshots, targetSnapshots, volTargetArgs.Snapshots)
+
+	// A full stream can't be received into an existing data set.
+	if resp.Base == "" && d.checkDataset(dataset) {
+		d.logger.Debug("No common snapshot found, replacing volume", log.Ctx{"name": vol.name})
+
+		err = d.deleteDatasetRecursive(dataset)
+		if err != nil {
+			return err
+		}
+	}
+
+	respJSON, err := json.Marshal(resp)
+	if err != nil {
+		return errors.Wrapf(err, "Failed encoding refresh response")
+	}
+
+	_, err = conn.Write(respJSON)
+	if err != nil {
+		return errors.Wrapf(err, "Failed sending refresh response")
+	}
+
+	err = conn.Close() // End the frame.
+	if err != nil {
+		return errors.Wrapf(err, "Failed closing refresh response frame")
+	}
+
+	// Transfer the snapshots.
+	for _, snapName := range resp.Snapshots {
+		wrapper := migration.ProgressWriter(op, "fs_progress", GetSnapshotVolumeName(vol.name, snapName))
+
+		err = d.receiveDataset(vol, conn, wrapper)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Transfer the main volume.
+	wrapper := migration.ProgressWriter(op, "fs_progress", vol.name)
+	return d.receiveDataset(vol, conn, wrapper)
+}
@@ -0,0 +1,71 @@
+package drivers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_zfsRefreshPlan(t *testing.T) {
+	snap0 := zfsRefreshSnapshot{Name: "snap0", GUID: "100"}
+	snap1 := zfsRefreshSnapshot{Name: "snap1", GUID: "101"}
+	snap2 := zfsRefreshSnapshot{Name: "snap2", GUID: "102"}
+	snap3 := zfsRefreshSnapshot{Name: "snap3", GUID: "103"}
+
+	tests := []struct {
+		name   string
+		source []zfsRefreshSnapshot
+		target []zfsRefreshSnapshot
+		wanted []string
+		want   zfsRefreshResponse
+	}{
+		{
+			"Target has the oldest snapshots",
+			[]zfsRefreshSnapshot{snap0, snap1, snap2, snap3},
+			[]zfsRefreshSnapshot{snap0, snap1},
+			[]string{"snap2", "snap3"},
+			zfsRefreshResponse{Base: "snap1", Snapshots: []string{"snap2", "snap3"}},
+		},
+		{
+			"Target has all the snapshots",
+			[]zfsRefreshSnapshot{snap0, snap1},
+			[]zfsRefreshSnapshot{snap0, snap1},
+			[]string{},
+			zfsRefreshResponse{Base: "snap1", Snapshots: []string{}},
+		},
+		{
+			"Volume only refresh",
+			[]zfsRefreshSnapshot{snap0, snap1, snap2},
+			[]zfsRefreshSnapshot{snap0},
+			[]string{},
+			zfsRefreshResponse{Base: "snap0", Snapshots: []string{}},
+		},
+		{
+			"Target has no snapshots",
+			[]zfsRefreshSnapshot{snap0, snap1},
+			[]zfsRefreshSnapshot{},
+			[]string{"snap0", "snap1"},
+			zfsRefreshResponse{Base: "", Snapshots: []string{"snap0", "snap1"}},
+		},
+		{
+			"Target snapshot with the same name isn't a copy",
+			[]zfsRefreshSnapshot{snap0, snap1, snap2},
+			[]zfsRefreshSnapshot{snap0, {Name: "snap1", GUID: "201"}},
+			[]string{"snap2"},
+			zfsRefreshResponse{Base: "", Snapshots: []string{"snap0", "snap1", "snap2"}},
+		},
+		{
+			"Target is missing a snapshot before its most recent one",
+			[]zfsRefreshSnapshot{snap0, snap1, snap2},
+			[]zfsRefreshSnapshot{snap0, snap2},
+			[]string{"snap1"},
+			zfsRefreshResponse{Base: "", Snapshots: []string{"snap0", "snap1", "snap2"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, zfsRefreshPlan(tt.source, tt.target, tt.wanted))
+		})
+	}
+}
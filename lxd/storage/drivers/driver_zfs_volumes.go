@@ -487,7 +487,7 @@ func (d *zfs) CreateVolumeFromCopy(vol Volume, srcVol Volume, copySnapshots bool
 		}
 
 		// If using "zfs.clone_copy" delete the snapshot at the end.
-		if (d.config["zfs.clone_copy"] != "" && !shared.IsTrue(d.config["zfs.clone_copy"])) || len(snapshots) > 0 {
+		if !d.cloneCopy() || len(snapshots) > 0 {
 			// Delete the snapshot at the end.
 			defer shared.RunCommand("zfs", "destroy", srcSnapshot)
 		} else {
@@ -499,7 +499,7 @@ func (d *zfs) CreateVolumeFromCopy(vol Volume, srcVol Volume, copySnapshots bool
 	}
 
 	// If zfs.clone_copy is disabled or source volume has snapshots, then use full copy mode.
-	if (d.config["zfs.clone_copy"] != "" && !shared.IsTrue(d.config["zfs.clone_copy"])) || len(snapshots) > 0 {
+	if !d.cloneCopy() || len(snapshots) > 0 {
 		snapName := strings.SplitN(srcSnapshot, "@", 2)[1]
 
 		// Send/receive the snapshot.
@@ -663,6 +663,9 @@ func (d *zfs) CreateVolumeFromMigration(vol Volume, conn io.ReadWriteCloser, vol
 		}
 	}
 
+	// Snapshots to keep once the transfer is done.
+	keepSnapshots := append([]string{}, volTargetArgs.Snapshots...)
+
 	// Handle zfs send/receive migration.
 	if len(volTargetArgs.Snapshots) > 0 {
 		// Create the parent directory.
@@ -670,24 +673,42 @@ func (d *zfs) CreateVolumeFromMigration(vol Volume, conn io.ReadWriteCloser, vol
 		if err != nil {
 			return err
 		}
+	}
+
+	if volTargetArgs.Refresh && shared.StringInSlice(migration.ZFSFeatureMigrationRefresh, volTargetArgs.MigrationType.Features) {
+		// Keep the snapshots already on the volume too.
+		snapshots, err := d.refreshSnapshots(d.dataset(vol, false))
+		if err != nil {
+			return err
+		}
 
+		for _, snapshot := range snapshots {
+			keepSnapshots = append(keepSnapshots, snapshot.Name)
+		}
+
+		// Only transfer what changed since the most recent common snapshot.
+		err = d.receiveRefresh(vol, conn, volTargetArgs, op)
+		if err != nil {
+			return err
+		}
+	} else {
 		// Transfer the snapshots.
 		for _, snapName := range volTargetArgs.Snapshots {
 			fullSnapshotName := GetSnapshotVolumeName(vol.name, snapName)
 			wrapper := migration.ProgressWriter(op, "fs_progress", fullSnapshotName)
 
-			err = d.receiveDataset(vol, conn, wrapper)
+			err := d.receiveDataset(vol, conn, wrapper)
 			if err != nil {
 				return err
 			}
 		}
-	}
 
-	// Transfer the main volume.
-	wrapper := migration.ProgressWriter(op, "fs_progress", vol.name)
-	err := d.receiveDataset(vol, conn, wrapper)
-	if err != nil {
-		return err
+		// Transfer the main volume.
+		wrapper := migration.ProgressWriter(op, "fs_progress", vol.name)
+		err := d.receiveDataset(vol, conn, wrapper)
+		if err != nil {
+			return err
+		}
 	}
 
 	// Strip internal snapshots.
@@ -697,8 +718,8 @@ func (d *zfs) CreateVolumeFromMigration(vol Volume, conn io.ReadWriteCloser, vol
 	}
 
 	// keepDataset returns whether to keep the data set or delete it. Data sets that are non-snapshots or
-	// snapshots that match the requested snapshots in volTargetArgs.Snapshots are kept. Any other snapshot
-	// data sets should be removed.
+	// snapshots that match the snapshots in keepSnapshots are kept. Any other snapshot data sets should be
+	// removed.
 	keepDataset := func(dataSetName string) bool {
 		// Keep non-snapshot data sets and snapshots that don't have the LXD snapshot prefix indicator.
 		dataSetSnapshotPrefix := "@snapshot-"
@@ -706,10 +727,10 @@ func (d *zfs) CreateVolumeFromMigration(vol Volume, conn io.ReadWriteCloser, vol
 			return false
 		}
 
-		// Check if snapshot data set matches one of the requested snapshots in volTargetArgs.Snapshots.
+		// Check if snapshot data set matches one of the snapshots in keepSnapshots.
 		// If so, then keep it, otherwise request it be removed.
 		entrySnapName := strings.TrimPrefix(dataSetName, dataSetSnapshotPrefix)
-		for _, snapName := range volTargetArgs.Snapshots {
+		for _, snapName := range keepSnapshots {
 			if entrySnapName == snapName {
 				return true // Keep snapshot data set if present in the requested snapshots list.
 			}
@@ -751,6 +772,117 @@ func (d *zfs) CreateVolumeFromMigration(vol Volume, conn io.ReadWriteCloser, vol
 	return nil
 }
 
+// FlattenVolume replaces a volume cloned from a snapshot with a full copy of itself, so that it no
+// longer depends on its origin.
+func (d *zfs) FlattenVolume(vol Volume, op *operations.Operation) error {
+	// For VMs, also flatten the filesystem dataset.
+	if vol.IsVMBlock() {
+		err := d.FlattenVolume(vol.NewVMBlockFilesystemVolume(), op)
+		if err != nil {
+			return err
+		}
+	}
+
+	dataset := d.dataset(vol, false)
+
+	origin, err := d.getDatasetProperty(dataset, "origin")
+	if err != nil {
+		return err
+	}
+
+	// Nothing to do if the volume isn't a clone.
+	if origin == "" || origin == "-" {
+		return nil
+	}
+
+	revert := revert.New()
+	defer revert.Fail()
+
+	// Take a snapshot of the current state of the volume.
+	snapName := fmt.Sprintf("flatten-%s", uuid.New())
+	srcSnapshot := fmt.Sprintf("%s@%s", dataset, snapName)
+
+	_, err = shared.RunCommand("zfs", "snapshot", srcSnapshot)
+	if err != nil {
+		return err
+	}
+
+	revert.Add(func() { shared.RunCommand("zfs", "destroy", srcSnapshot) })
+
+	// Send/receive the volume and its snapshots to a temporary dataset.
+	tmpDataset := fmt.Sprintf("%s_%s", dataset, snapName)
+
+	var receiver *exec.Cmd
+	if vol.ContentType() == ContentTypeBlock {
+		receiver = exec.Command("zfs", "receive", tmpDataset)
+	} else {
+		receiver = exec.Command("zfs", "receive", "-x", "mountpoint", tmpDataset)
+	}
+
+	sender := exec.Command("zfs", "send", "-R", srcSnapshot)
+
+	// Configure the pipes.
+	receiver.Stdin, _ = sender.StdoutPipe()
+	receiver.Stdout = os.Stdout
+	receiver.Stderr = os.Stderr
+
+	// Run the transfer.
+	err = receiver.Start()
+	if err != nil {
+		return err
+	}
+
+	err = sender.Run()
+	if err != nil {
+		return err
+	}
+
+	err = receiver.Wait()
+	if err != nil {
+		return err
+	}
+
+	revert.Add(func() { shared.RunCommand("zfs", "destroy", "-r", tmpDataset) })
+
+	// Replace the clone with the full copy, keeping the clone around until the copy is in place.
+	oldDataset := fmt.Sprintf("%s_%s-old", dataset, snapName)
+
+	_, err = shared.RunCommand("zfs", "rename", dataset, oldDataset)
+	if err != nil {
+		return err
+	}
+
+	revert.Add(func() { shared.RunCommand("zfs", "rename", oldDataset, dataset) })
+
+	_, err = shared.RunCommand("zfs", "rename", tmpDataset, dataset)
+	if err != nil {
+		return err
+	}
+
+	revert.Success()
+
+	// Remove the clone, along with its origin if no longer used.
+	err = d.deleteDatasetRecursive(oldDataset)
+	if err != nil {
+		return err
+	}
+
+	_, err = shared.RunCommand("zfs", "destroy", srcSnapshot)
+	if err != nil {
+		return err
+	}
+
+	// Apply the properties.
+	if vol.contentType == ContentTypeFS {
+		err := d.setDatasetProperties(dataset, fmt.Sprintf("mountpoint=%s", vol.MountPath()), "canmount=noauto")
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // RefreshVolume updates an existing volume to match the state of another.
 func (d *zfs) RefreshVolume(vol Volume, srcVol Volume, srcSnapshots []Volume, op *operations.Operation) error {
 	return genericVFSCopyVolume(d, nil, vol, srcVol, srcSnapshots, true, op)
@@ -1377,6 +1509,11 @@ func (d *zfs) MigrateVolume(vol Volume, conn io.ReadWriteCloser, volSrcArgs *mig
 		}
 	}
 
+	// Only send what the target is missing when refreshing.
+	if volSrcArgs.Refresh && shared.StringInSlice(migration.ZFSFeatureMigrationRefresh, volSrcArgs.MigrationType.Features) {
+		return d.sendRefresh(vol, conn, volSrcArgs, op)
+	}
+
 	// Handle zfs send/receive migration.
 	var finalParent string
 	if !volSrcArgs.FinalSync {
@@ -797,6 +797,7 @@ func VolumeUsedByExclusiveRemoteInstancesWithProfiles(s *state.State, poolName s
 func VolumeUsedByDaemon(s *state.State, poolName string, volumeName string) (bool, error) {
 	var storageBackups string
 	var storageImages string
+	var storageRecordings string
 	err := s.Node.Transaction(func(tx *db.NodeTx) error {
 		nodeConfig, err := node.ConfigLoad(tx)
 		if err != nil {
@@ -805,6 +806,7 @@ func VolumeUsedByDaemon(s *state.State, poolName string, volumeName string) (boo
 
 		storageBackups = nodeConfig.StorageBackupsVolume()
 		storageImages = nodeConfig.StorageImagesVolume()
+		storageRecordings = nodeConfig.StorageRecordingsVolume()
 
 		return nil
 	})
@@ -813,7 +815,7 @@ func VolumeUsedByDaemon(s *state.State, poolName string, volumeName string) (boo
 	}
 
 	fullName := fmt.Sprintf("%s/%s", poolName, volumeName)
-	if storageBackups == fullName || storageImages == fullName {
+	if storageBackups == fullName || storageImages == fullName || storageRecordings == fullName {
 		return true, nil
 	}
 
@@ -54,3 +54,13 @@ func snapshotProtobufToInstanceArgs(inst instance.Instance, snap *migration.Snap
 
 	return args
 }
+
+// migrationPoolMapDevice replaces the storage pool of a disk device according to the pool mapping of a
+// migration request.
+func migrationPoolMapDevice(poolMap map[string]string, device map[string]string) {
+	if device["type"] != "disk" || poolMap[device["pool"]] == "" {
+		return
+	}
+
+	device["pool"] = poolMap[device["pool"]]
+}
@@ -7,7 +7,6 @@ import (
 	"encoding/pem"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"net/http"
 	"os"
 	"strings"
@@ -16,6 +15,7 @@ import (
 	"github.com/gorilla/websocket"
 	"github.com/lxc/lxd/lxd/backup"
 	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/filter"
 	"github.com/lxc/lxd/lxd/instance"
 	"github.com/lxc/lxd/lxd/operations"
 	"github.com/lxc/lxd/lxd/project"
@@ -72,6 +72,11 @@ var storagePoolVolumeTypeCmd = APIEndpoint{
 //     type: string
 //     example: default
 //   - in: query
+//     name: filter
+//     description: Collection filter
+//     type: string
+//     example: default
+//   - in: query
 //     name: target
 //     description: Cluster member name
 //     type: string
@@ -128,6 +133,11 @@ var storagePoolVolumeTypeCmd = APIEndpoint{
 //     type: string
 //     example: default
 //   - in: query
+//     name: filter
+//     description: Collection filter
+//     type: string
+//     example: default
+//   - in: query
 //     name: target
 //     description: Cluster member name
 //     type: string
@@ -167,6 +177,11 @@ func storagePoolVolumesGet(d *Daemon, r *http.Request) response.Response {
 
 	recursion := util.IsRecursionRequest(r)
 
+	clauses, err := storageVolumesFilterParse(r)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
 	// Retrieve ID of the storage pool (and check if the storage pool exists).
 	poolID, err := d.cluster.GetStoragePoolID(poolName)
 	if err != nil {
@@ -215,6 +230,8 @@ func storagePoolVolumesGet(d *Daemon, r *http.Request) response.Response {
 		}
 	}
 
+	volumes = storageVolumesFilter(volumes, clauses)
+
 	resultString := []string{}
 	for _, volume := range volumes {
 		if !recursion {
@@ -256,6 +273,37 @@ func storagePoolVolumesGet(d *Daemon, r *http.Request) response.Response {
 	return response.SyncResponse(true, volumes)
 }
 
+// storageVolumesFilterParse parses the filter of a storage volumes request, if any.
+func storageVolumesFilterParse(r *http.Request) ([]filter.Clause, error) {
+	filterStr := r.FormValue("filter")
+	if filterStr == "" {
+		return nil, nil
+	}
+
+	clauses, err := filter.Parse(filterStr)
+	if err != nil {
+		return nil, errors.Wrap(err, "Invalid filter")
+	}
+
+	return clauses, nil
+}
+
+// storageVolumesFilter returns the storage volumes matching the given filter clauses.
+func storageVolumesFilter(volumes []*api.StorageVolume, clauses []filter.Clause) []*api.StorageVolume {
+	if clauses == nil {
+		return volumes
+	}
+
+	filtered := []*api.StorageVolume{}
+	for _, volume := range volumes {
+		if filter.Match(*volume, clauses) {
+			filtered = append(filtered, volume)
+		}
+	}
+
+	return filtered
+}
+
 // swagger:operation GET /1.0/storage-pools/{name}/volumes/{type} storage storage_pool_volumes_type_get
 //
 // Get the storage volumes
@@ -272,6 +320,11 @@ func storagePoolVolumesGet(d *Daemon, r *http.Request) response.Response {
 //     type: string
 //     example: default
 //   - in: query
+//     name: filter
+//     description: Collection filter
+//     type: string
+//     example: default
+//   - in: query
 //     name: target
 //     description: Cluster member name
 //     type: string
@@ -326,6 +379,11 @@ func storagePoolVolumesGet(d *Daemon, r *http.Request) response.Response {
 //     type: string
 //     example: default
 //   - in: query
+//     name: filter
+//     description: Collection filter
+//     type: string
+//     example: default
+//   - in: query
 //     name: target
 //     description: Cluster member name
 //     type: string
@@ -367,6 +425,11 @@ func storagePoolVolumesTypeGet(d *Daemon, r *http.Request) response.Response {
 
 	recursion := util.IsRecursionRequest(r)
 
+	clauses, err := storageVolumesFilterParse(r)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
 	// Convert the volume type name to our internal integer representation.
 	volumeType, err := storagePools.VolumeTypeNameToDBType(volumeTypeName)
 	if err != nil {
@@ -398,7 +461,7 @@ func storagePoolVolumesTypeGet(d *Daemon, r *http.Request) response.Response {
 	resultString := []string{}
 	resultMap := []*api.StorageVolume{}
 	for _, volume := range volumes {
-		if !recursion {
+		if !recursion && clauses == nil {
 			resultString = append(resultString, fmt.Sprintf("/%s/storage-pools/%s/volumes/%s/%s", version.APIVersion, poolName, volumeTypeName, volume))
 		} else {
 			_, vol, err := d.cluster.GetLocalStoragePoolVolume(projectName, volume, volumeType, poolID)
@@ -412,6 +475,15 @@ func storagePoolVolumesTypeGet(d *Daemon, r *http.Request) response.Response {
 			}
 			vol.UsedBy = project.FilterUsedBy(r, volumeUsedBy)
 
+			if clauses != nil && !filter.Match(*vol, clauses) {
+				continue
+			}
+
+			if !recursion {
+				resultString = append(resultString, fmt.Sprintf("/%s/storage-pools/%s/volumes/%s/%s", version.APIVersion, poolName, volumeTypeName, volume))
+				continue
+			}
+
 			resultMap = append(resultMap, vol)
 		}
 	}
@@ -1648,58 +1720,45 @@ func createStoragePoolVolumeFromBackup(d *Daemon, r *http.Request, requestProjec
 	revert := revert.New()
 	defer revert.Fail()
 
-	// Create temporary file to store uploaded backup data.
-	backupFile, err := ioutil.TempFile(shared.VarPath("backups"), fmt.Sprintf("%s_", backup.WorkingDirPrefix))
+	// Store uploaded backup data into a temporary file.
+	backupFile, err := backupFileTemp(data)
 	if err != nil {
 		return response.InternalError(err)
 	}
-	defer os.Remove(backupFile.Name())
 	revert.Add(func() { backupFile.Close() })
 
-	// Stream uploaded backup data into temporary file.
-	_, err = io.Copy(backupFile, data)
-	if err != nil {
-		return response.InternalError(err)
-	}
-
-	// Detect squashfs compression and convert to tarball.
-	backupFile.Seek(0, 0)
-	_, algo, decomArgs, err := shared.DetectCompressionFile(backupFile)
+	bInfo, restore, err := customVolumeCreateFromBackup(d, projectName, backupFile, pool, volName)
 	if err != nil {
-		return response.InternalError(err)
+		return response.SmartError(err)
 	}
 
-	if algo == ".squashfs" {
-		// Pass the temporary file as program argument to the decompression command.
-		decomArgs := append(decomArgs, backupFile.Name())
-
-		// Create temporary file to store the decompressed tarball in.
-		tarFile, err := ioutil.TempFile(shared.VarPath("backups"), fmt.Sprintf("%s_decompress_", backup.WorkingDirPrefix))
-		if err != nil {
-			return response.InternalError(err)
-		}
-		defer os.Remove(tarFile.Name())
+	run := func(op *operations.Operation) error {
+		defer backupFile.Close()
 
-		// Decompress to tarData temporary file.
-		err = shared.RunCommandWithFds(nil, tarFile, decomArgs[0], decomArgs[1:]...)
-		if err != nil {
-			return response.InternalError(err)
-		}
+		return restore(op)
+	}
 
-		// We don't need the original squashfs file anymore.
-		backupFile.Close()
-		os.Remove(backupFile.Name())
+	resources := map[string][]string{}
+	resources["storage_volumes"] = []string{bInfo.Name}
 
-		// Replace the backup file handle with the handle to the tar file.
-		backupFile = tarFile
+	op, err := operations.OperationCreate(d.State(), requestProjectName, operations.OperationClassTask, db.OperationCustomVolumeBackupRestore, resources, nil, run, nil, nil, r)
+	if err != nil {
+		return response.InternalError(err)
 	}
 
+	revert.Success()
+	return operations.OperationResponse(op)
+}
+
+// customVolumeCreateFromBackup loads the backup information from a backup file and returns a function restoring
+// the custom volume from it. The backup file must be kept open until that function returns.
+func customVolumeCreateFromBackup(d *Daemon, projectName string, backupFile *os.File, pool string, volName string) (*backup.Info, func(op *operations.Operation) error, error) {
 	// Parse the backup information.
 	backupFile.Seek(0, 0)
 	logger.Debug("Reading backup file info")
 	bInfo, err := backup.GetInfo(backupFile)
 	if err != nil {
-		return response.BadRequest(err)
+		return nil, nil, api.StatusErrorf(http.StatusBadRequest, "%v", err)
 	}
 	bInfo.Project = projectName
 
@@ -1730,33 +1789,27 @@ func createStoragePoolVolumeFromBackup(d *Daemon, r *http.Request, requestProjec
 		// the backup.yaml) or the pool has been specified directly from the user restoring
 		// the backup then we cannot proceed so return an error.
 		if *bInfo.OptimizedStorage || pool != "" {
-			return response.InternalError(errors.Wrap(err, "Storage pool not found"))
+			return nil, nil, errors.Wrap(err, "Storage pool not found")
 		}
 
 		// Otherwise try and restore to the project's default profile pool.
 		_, profile, err := d.State().Cluster.GetProfile(bInfo.Project, "default")
 		if err != nil {
-			return response.InternalError(errors.Wrap(err, "Failed to get default profile"))
+			return nil, nil, errors.Wrap(err, "Failed to get default profile")
 		}
 
 		_, v, err := shared.GetRootDiskDevice(profile.Devices)
 		if err != nil {
-			return response.InternalError(errors.Wrap(err, "Failed to get root disk device"))
+			return nil, nil, errors.Wrap(err, "Failed to get root disk device")
 		}
 
 		// Use the default-profile's root pool.
 		bInfo.Pool = v["pool"]
 	} else if err != nil {
-		return response.InternalError(err)
+		return nil, nil, err
 	}
 
-	// Copy reverter so far so we can use it inside run after this function has finished.
-	runRevert := revert.Clone()
-
 	run := func(op *operations.Operation) error {
-		defer backupFile.Close()
-		defer runRevert.Fail()
-
 		pool, err := storagePools.GetPoolByName(d.State(), bInfo.Pool)
 		if err != nil {
 			return err
@@ -1773,18 +1826,8 @@ func createStoragePoolVolumeFromBackup(d *Daemon, r *http.Request, requestProjec
 			return errors.Wrap(err, "Create custom volume from backup")
 		}
 
-		runRevert.Success()
 		return nil
 	}
 
-	resources := map[string][]string{}
-	resources["storage_volumes"] = []string{bInfo.Name}
-
-	op, err := operations.OperationCreate(d.State(), requestProjectName, operations.OperationClassTask, db.OperationCustomVolumeBackupRestore, resources, nil, run, nil, nil, r)
-	if err != nil {
-		return response.InternalError(err)
-	}
-
-	revert.Success()
-	return operations.OperationResponse(op)
+	return bInfo, run, nil
 }
@@ -380,7 +380,7 @@ func storagePoolVolumeTypeCustomBackupsPost(d *Daemon, r *http.Request) response
 			CompressionAlgorithm: req.CompressionAlgorithm,
 		}
 
-		err := volumeBackupCreate(d.State(), args, projectName, poolName, volumeName)
+		err := volumeBackupCreate(d.State(), args, projectName, poolName, volumeName, op)
 		if err != nil {
 			return errors.Wrap(err, "Create volume backup")
 		}
@@ -53,6 +53,7 @@ type OS struct {
 	LxcPath         string // Path to the $LXD_DIR/containers directory
 	MockMode        bool   // If true some APIs will be mocked (for testing)
 	Nodev           bool
+	Rootless        bool // Whether running as an unprivileged user in a user namespace
 	RunningInUserNS bool
 
 	// Privilege dropping
@@ -68,6 +69,10 @@ type OS struct {
 	AppArmorStacked   bool
 	AppArmorStacking  bool
 
+	// SELinux features
+	SELinuxAvailable bool
+	SELinuxEnforcing bool
+
 	// Cgroup features
 	CGInfo cgroup.Info
 
@@ -81,6 +86,7 @@ type OS struct {
 	SeccompListenerAddfd    bool
 	SeccompListenerContinue bool
 	Shiftfs                 bool
+	TimeNamespace           bool
 	UeventInjection         bool
 	VFS3Fscaps              bool
 
@@ -154,11 +160,12 @@ func (s *OS) Init() ([]db.Warning, error) {
 		break
 	}
 
-	s.IdmapSet = util.GetIdmapSet()
+	s.IdmapSet = util.GetIdmapSet(s.Rootless)
 	s.ExecPath = util.GetExecPath()
 	s.RunningInUserNS = shared.RunningInUserNS()
 
 	dbWarnings = s.initAppArmor()
+	s.initSELinux()
 	s.CGInfo = cgroup.GetInfo()
 
 	return dbWarnings, nil
@@ -0,0 +1,39 @@
+//go:build linux && cgo && !agent
+// +build linux,cgo,!agent
+
+package sys
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/logger"
+)
+
+// Initialize SELinux-specific attributes.
+func (s *OS) initSELinux() {
+	if os.Getenv("LXD_SECURITY_SELINUX") == "false" {
+		logger.Debugf("SELinux support has been manually disabled")
+		return
+	}
+
+	if !shared.PathExists("/sys/fs/selinux/enforce") {
+		return
+	}
+
+	_, err := exec.LookPath("chcon")
+	if err != nil {
+		logger.Warnf("SELinux support has been disabled because 'chcon' couldn't be found")
+		return
+	}
+
+	s.SELinuxAvailable = true
+
+	content, err := ioutil.ReadFile("/sys/fs/selinux/enforce")
+	if err == nil && strings.TrimSpace(string(content)) == "1" {
+		s.SELinuxEnforcing = true
+	}
+}
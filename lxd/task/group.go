@@ -15,7 +15,7 @@ import (
 type Group struct {
 	cancel  func()
 	wg      sync.WaitGroup
-	tasks   []Task
+	tasks   []*Task
 	running map[int]bool
 	mu      sync.Mutex
 }
@@ -25,12 +25,13 @@ func (g *Group) Add(f Func, schedule Schedule) *Task {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 	i := len(g.tasks)
-	g.tasks = append(g.tasks, Task{
+	g.tasks = append(g.tasks, &Task{
 		f:        f,
 		schedule: schedule,
 		reset:    make(chan struct{}, 16), // Buffered to not block senders
+		trigger:  make(chan struct{}, 1),  // Buffered to coalesce pending triggers
 	})
-	return &g.tasks[i]
+	return g.tasks[i]
 }
 
 // Start all the tasks in the group.
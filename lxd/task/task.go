@@ -2,6 +2,7 @@ package task
 
 import (
 	"context"
+	"sync"
 	"time"
 )
 
@@ -11,6 +12,19 @@ type Task struct {
 	f        Func          // Function to execute.
 	schedule Schedule      // Decides if and when to execute f.
 	reset    chan struct{} // Resets the shedule and starts over.
+	trigger  chan struct{} // Executes f right away.
+
+	mu     sync.Mutex // Protects the fields below.
+	status Status     // Current state of the task.
+}
+
+// Status captures the state of a task.
+type Status struct {
+	Running      bool          // Whether the task function is currently executing.
+	Paused       bool          // Whether scheduled executions are skipped.
+	LastRun      time.Time     // Start time of the last execution, if any.
+	LastDuration time.Duration // Duration of the last completed execution.
+	NextRun      time.Time     // Time of the next scheduled execution, if any.
 }
 
 // Reset the state of the task as if it had just been started.
@@ -22,6 +36,37 @@ func (t *Task) Reset() {
 	t.reset <- struct{}{}
 }
 
+// Trigger executes the task function as soon as possible, regardless of the
+// schedule and of the task being paused. The schedule starts over afterwards.
+func (t *Task) Trigger() {
+	select {
+	case t.trigger <- struct{}{}:
+	default:
+		// An execution is already pending.
+	}
+}
+
+// Pause makes the task skip its scheduled executions until Resume is called.
+func (t *Task) Pause() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.status.Paused = true
+}
+
+// Resume lets the task execute again according to its schedule.
+func (t *Task) Resume() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.status.Paused = false
+}
+
+// Status returns the current state of the task.
+func (t *Task) Status() Status {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.status
+}
+
 // Execute the our task function according to our schedule, until the given
 // context gets cancelled.
 func (t *Task) loop(ctx context.Context) {
@@ -50,28 +95,37 @@ func (t *Task) loop(ctx context.Context) {
 			// returning values greater than zero).
 			if schedule > 0 {
 				timer = time.After(delay)
+				t.setNextRun(time.Now().Add(delay))
 			} else {
 				timer = make(chan time.Time)
+				t.setNextRun(time.Time{})
 			}
 		default:
 			// If the schedule is not greater than zero, abort the
 			// task and return immediately. Otherwise set up the
 			// timer to retry after that amount of time.
 			if schedule <= 0 {
+				t.setNextRun(time.Time{})
 				return
 			}
 			timer = time.After(schedule)
+			t.setNextRun(time.Time{})
 		}
 
 		select {
 		case <-timer:
 			if err == nil {
+				// Skip the execution if the task is paused, and
+				// wait for the next one.
+				if t.Status().Paused {
+					delay = schedule
+					continue
+				}
+
 				// Execute the task function synchronously. Consumers
 				// are responsible for implementing proper cancellation
 				// of the task function itself using the tomb's context.
-				start := time.Now()
-				t.f(ctx)
-				duration := time.Since(start)
+				duration := t.run(ctx)
 
 				delay = schedule - duration
 				if delay < 0 {
@@ -88,8 +142,41 @@ func (t *Task) loop(ctx context.Context) {
 
 		case <-t.reset:
 			delay = immediately
+
+		case <-t.trigger:
+			t.run(ctx)
+			delay = schedule
 		}
 	}
 }
 
+// Execute the task function, keeping track of its status, and return how long
+// it took.
+func (t *Task) run(ctx context.Context) time.Duration {
+	start := time.Now()
+
+	t.mu.Lock()
+	t.status.Running = true
+	t.status.LastRun = start
+	t.status.NextRun = time.Time{}
+	t.mu.Unlock()
+
+	t.f(ctx)
+	duration := time.Since(start)
+
+	t.mu.Lock()
+	t.status.Running = false
+	t.status.LastDuration = duration
+	t.mu.Unlock()
+
+	return duration
+}
+
+// Record the time of the next scheduled execution.
+func (t *Task) setNextRun(next time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.status.NextRun = next
+}
+
 const immediately = 0 * time.Second
@@ -3,6 +3,7 @@ package task_test
 import (
 	"context"
 	"fmt"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -90,6 +91,50 @@ func TestTask_SkipFirst(t *testing.T) {
 	assert.Equal(t, 1, i) // The function got executed only once, not twice.
 }
 
+// If the task is triggered, it's executed right away even if its schedule is
+// zero, and its status tracks the execution.
+func TestTask_Trigger(t *testing.T) {
+	f, wait := newFunc(t, 1)
+	group := task.Group{}
+	tsk := group.Add(f, task.Every(0))
+	group.Start(context.Background())
+	defer group.Stop(time.Second)
+
+	assert.True(t, tsk.Status().LastRun.IsZero())
+
+	tsk.Trigger()
+	wait(100 * time.Millisecond)
+
+	time.Sleep(10 * time.Millisecond)
+	status := tsk.Status()
+	assert.False(t, status.LastRun.IsZero())
+	assert.False(t, status.Running)
+	assert.True(t, status.NextRun.IsZero())
+}
+
+// If the task is paused, its scheduled executions are skipped until it's
+// resumed.
+func TestTask_Pause(t *testing.T) {
+	var i int32
+	f := func(context.Context) {
+		atomic.AddInt32(&i, 1)
+	}
+
+	group := task.Group{}
+	tsk := group.Add(f, task.Every(200*time.Millisecond, task.SkipFirst))
+	tsk.Pause()
+	group.Start(context.Background())
+	defer group.Stop(time.Second)
+
+	time.Sleep(500 * time.Millisecond)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&i))
+	assert.False(t, tsk.Status().NextRun.IsZero())
+
+	tsk.Resume()
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&i))
+}
+
 // Create a new task function that sends a notification to a channel every time
 // it's run.
 //
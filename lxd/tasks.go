@@ -0,0 +1,404 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/lifecycle"
+	"github.com/lxc/lxd/lxd/project"
+	"github.com/lxc/lxd/lxd/request"
+	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/lxd/task"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/version"
+)
+
+var tasksCmd = APIEndpoint{
+	Path: "tasks",
+
+	Get: APIEndpointAction{Handler: tasksGet},
+}
+
+var taskCmd = APIEndpoint{
+	Path: "tasks/{name}",
+
+	Get: APIEndpointAction{Handler: taskGet},
+}
+
+var taskStateCmd = APIEndpoint{
+	Path: "tasks/{name}/state",
+
+	Put: APIEndpointAction{Handler: taskStatePut},
+}
+
+// taskDescriptions describes the background tasks exposed through the API.
+var taskDescriptions = map[string]string{
+	"create-snapshots":        "Take the scheduled instance snapshots",
+	"create-volume-snapshots": "Take the scheduled custom volume snapshots",
+	"expire-logs":             "Remove old instance log files",
+	"instance-health-checks":  "Run the health checks of instances",
+	"instance-schedules":      "Start and stop instances on schedule",
+	"instance-usage":          "Sample the resource usage of instances",
+	"prune-backups":           "Remove expired instance backups",
+	"prune-events":            "Remove expired recorded events",
+	"prune-images":            "Remove expired cached images",
+	"prune-operations":        "Remove old operations from the history",
+	"prune-snapshots":         "Remove expired instance snapshots",
+	"prune-volume-snapshots":  "Remove expired custom volume snapshots",
+	"prune-warnings":          "Remove resolved warnings",
+	"refresh-instance-types":  "Refresh the instance types definitions",
+	"update-crl":              "Refresh the certificate revocation list of the CA",
+	"update-images":           "Update cached images from their source",
+}
+
+// exposeTask makes a background task visible and controllable through the API under the given name.
+func (d *Daemon) exposeTask(name string, t *task.Task) *task.Task {
+	d.exposedTasksMu.Lock()
+	defer d.exposedTasksMu.Unlock()
+
+	if d.exposedTasks == nil {
+		d.exposedTasks = map[string]*task.Task{}
+	}
+
+	d.exposedTasks[name] = t
+
+	return t
+}
+
+// getExposedTask returns the background task with the given name.
+func (d *Daemon) getExposedTask(name string) (*task.Task, error) {
+	d.exposedTasksMu.Lock()
+	defer d.exposedTasksMu.Unlock()
+
+	t, ok := d.exposedTasks[name]
+	if !ok {
+		return nil, api.StatusErrorf(http.StatusNotFound, "Task %q not found", name)
+	}
+
+	return t, nil
+}
+
+// taskToAPI converts the state of a background task to its API representation.
+func taskToAPI(name string, t *task.Task, location string) api.Task {
+	status := t.Status()
+
+	info := api.Task{
+		Name:         name,
+		Description:  taskDescriptions[name],
+		Status:       "idle",
+		LastRunAt:    status.LastRun,
+		LastDuration: status.LastDuration.Seconds(),
+		NextRunAt:    status.NextRun,
+		Location:     location,
+	}
+
+	if status.Paused {
+		info.Status = "paused"
+		info.NextRunAt = time.Time{}
+	}
+
+	if status.Running {
+		info.Status = "running"
+	}
+
+	return info
+}
+
+// taskLocation returns the name of the local cluster member.
+func taskLocation(d *Daemon) (string, error) {
+	var location string
+	err := d.cluster.Transaction(func(tx *db.ClusterTx) error {
+		var err error
+		location, err = tx.GetLocalNodeName()
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return location, nil
+}
+
+// swagger:operation GET /1.0/tasks tasks tasks_get
+//
+// Get the background tasks
+//
+// Returns a list of background tasks (URLs).
+//
+// ---
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: target
+//     description: Cluster member name
+//     type: string
+//     example: lxd01
+// responses:
+//   "200":
+//     description: API endpoints
+//     schema:
+//       type: object
+//       description: Sync response
+//       properties:
+//         type:
+//           type: string
+//           description: Response type
+//           example: sync
+//         status:
+//           type: string
+//           description: Status description
+//           example: Success
+//         status_code:
+//           type: integer
+//           description: Status code
+//           example: 200
+//         metadata:
+//           type: array
+//           description: List of endpoints
+//           items:
+//             type: string
+//           example: |-
+//             [
+//               "/1.0/tasks/expire-logs",
+//               "/1.0/tasks/prune-images"
+//             ]
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+
+// swagger:operation GET /1.0/tasks?recursion=1 tasks tasks_get_recursion1
+//
+// Get the background tasks
+//
+// Returns a list of background tasks (structs).
+//
+// ---
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: target
+//     description: Cluster member name
+//     type: string
+//     example: lxd01
+// responses:
+//   "200":
+//     description: API endpoints
+//     schema:
+//       type: object
+//       description: Sync response
+//       properties:
+//         type:
+//           type: string
+//           description: Response type
+//           example: sync
+//         status:
+//           type: string
+//           description: Status description
+//           example: Success
+//         status_code:
+//           type: integer
+//           description: Status code
+//           example: 200
+//         metadata:
+//           type: array
+//           description: List of background tasks
+//           items:
+//             $ref: "#/definitions/Task"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func tasksGet(d *Daemon, r *http.Request) response.Response {
+	resp := forwardedResponseIfTargetIsRemote(d, r)
+	if resp != nil {
+		return resp
+	}
+
+	recursion, err := strconv.Atoi(r.FormValue("recursion"))
+	if err != nil {
+		recursion = 0
+	}
+
+	location, err := taskLocation(d)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	d.exposedTasksMu.Lock()
+	names := make([]string, 0, len(d.exposedTasks))
+	tasks := make([]api.Task, 0, len(d.exposedTasks))
+	for name, t := range d.exposedTasks {
+		names = append(names, name)
+		tasks = append(tasks, taskToAPI(name, t, location))
+	}
+	d.exposedTasksMu.Unlock()
+
+	if recursion == 0 {
+		sort.Strings(names)
+
+		urls := make([]string, 0, len(names))
+		for _, name := range names {
+			urls = append(urls, fmt.Sprintf("/%s/tasks/%s", version.APIVersion, url.PathEscape(name)))
+		}
+
+		return response.SyncResponse(true, urls)
+	}
+
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].Name < tasks[j].Name })
+
+	return response.SyncResponse(true, tasks)
+}
+
+// swagger:operation GET /1.0/tasks/{name} tasks task_get
+//
+// Get the background task
+//
+// Gets the state of a specific background task.
+//
+// ---
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: target
+//     description: Cluster member name
+//     type: string
+//     example: lxd01
+// responses:
+//   "200":
+//     description: Task
+//     schema:
+//       type: object
+//       description: Sync response
+//       properties:
+//         type:
+//           type: string
+//           description: Response type
+//           example: sync
+//         status:
+//           type: string
+//           description: Status description
+//           example: Success
+//         status_code:
+//           type: integer
+//           description: Status code
+//           example: 200
+//         metadata:
+//           $ref: "#/definitions/Task"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "404":
+//     $ref: "#/responses/NotFound"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func taskGet(d *Daemon, r *http.Request) response.Response {
+	resp := forwardedResponseIfTargetIsRemote(d, r)
+	if resp != nil {
+		return resp
+	}
+
+	name, err := url.PathUnescape(mux.Vars(r)["name"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	t, err := d.getExposedTask(name)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	location, err := taskLocation(d)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponse(true, taskToAPI(name, t, location))
+}
+
+// swagger:operation PUT /1.0/tasks/{name}/state tasks task_state_put
+//
+// Change the background task state
+//
+// Runs the background task right away, or pauses or resumes its scheduled runs.
+// Paused tasks stay paused until resumed or until the daemon restarts.
+//
+// ---
+// consumes:
+//   - application/json
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: target
+//     description: Cluster member name
+//     type: string
+//     example: lxd01
+//   - in: body
+//     name: state
+//     description: Task action
+//     required: true
+//     schema:
+//       $ref: "#/definitions/TaskStatePut"
+// responses:
+//   "200":
+//     $ref: "#/responses/EmptySyncResponse"
+//   "400":
+//     $ref: "#/responses/BadRequest"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "404":
+//     $ref: "#/responses/NotFound"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func taskStatePut(d *Daemon, r *http.Request) response.Response {
+	resp := forwardedResponseIfTargetIsRemote(d, r)
+	if resp != nil {
+		return resp
+	}
+
+	name, err := url.PathUnescape(mux.Vars(r)["name"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	req := api.TaskStatePut{}
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	t, err := d.getExposedTask(name)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	var action lifecycle.TaskAction
+	switch req.Action {
+	case "run":
+		t.Trigger()
+		action = lifecycle.TaskTriggered
+	case "pause":
+		t.Pause()
+		action = lifecycle.TaskPaused
+	case "resume":
+		t.Resume()
+		action = lifecycle.TaskResumed
+	default:
+		return response.BadRequest(fmt.Errorf("Invalid task action %q", req.Action))
+	}
+
+	d.State().Events.SendLifecycle(project.Default, action.Event(name, request.CreateRequestor(r), nil))
+
+	return response.EmptySyncResponse
+}
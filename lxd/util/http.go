@@ -149,12 +149,8 @@ func CheckTrustState(cert x509.Certificate, trustedCerts map[string]x509.Certifi
 			// Check whether the certificate has been revoked.
 			crl := networkCert.CRL()
 
-			if crl != nil {
-				for _, revoked := range crl.TBSCertList.RevokedCertificates {
-					if cert.SerialNumber.Cmp(revoked.SerialNumber) == 0 {
-						return false, "" // Certificate is revoked, so not trusted anymore.
-					}
-				}
+			if crl != nil && CertificateInCRL(&cert, crl) {
+				return false, "" // Certificate is revoked, so not trusted anymore.
 			}
 
 			// Certificate not revoked, so trust it as is signed by CA cert.
@@ -186,6 +182,166 @@ func IsRecursionRequest(r *http.Request) bool {
 	return recursion != 0
 }
 
+// Pagination represents the window of a collection requested through the
+// "limit" and "offset" form values.
+type Pagination struct {
+	Offset int
+	Limit  int // -1 means no limit.
+}
+
+// PaginationFromRequest returns the pagination requested by the given HTTP request,
+// or nil if neither "limit" nor "offset" are set.
+func PaginationFromRequest(r *http.Request) (*Pagination, error) {
+	limitStr := r.FormValue("limit")
+	offsetStr := r.FormValue("offset")
+
+	if limitStr == "" && offsetStr == "" {
+		return nil, nil
+	}
+
+	p := &Pagination{Limit: -1}
+
+	if limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 0 {
+			return nil, fmt.Errorf("Invalid limit %q", limitStr)
+		}
+
+		p.Limit = limit
+	}
+
+	if offsetStr != "" {
+		offset, err := strconv.Atoi(offsetStr)
+		if err != nil || offset < 0 {
+			return nil, fmt.Errorf("Invalid offset %q", offsetStr)
+		}
+
+		p.Offset = offset
+	}
+
+	return p, nil
+}
+
+// Range returns the start and end indexes of the page within a collection of the given size.
+func (p *Pagination) Range(total int) (int, int) {
+	if p == nil {
+		return 0, total
+	}
+
+	start := p.Offset
+	if start > total {
+		start = total
+	}
+
+	end := total
+	if p.Limit >= 0 && start+p.Limit < total {
+		end = start + p.Limit
+	}
+
+	return start, end
+}
+
+// Headers returns the response headers reporting the total size of the collection.
+func (p *Pagination) Headers(total int) map[string]string {
+	if p == nil {
+		return nil
+	}
+
+	return map[string]string{"X-LXD-Total": strconv.Itoa(total)}
+}
+
+// FieldsFromRequest returns the list of fields requested through the "fields" form value
+// (comma separated), or nil if none were requested.
+func FieldsFromRequest(r *http.Request) []string {
+	var fields []string
+	for _, field := range strings.Split(r.FormValue("fields"), ",") {
+		field = strings.TrimSpace(field)
+		if field != "" {
+			fields = append(fields, field)
+		}
+	}
+
+	return fields
+}
+
+// SelectFields reduces an object, or a list of objects, to the given fields of its JSON representation.
+// Nested fields can be selected using a dot separator (e.g. "state.status" or "config.image.os").
+func SelectFields(data interface{}, fields []string) (interface{}, error) {
+	if len(fields) == 0 {
+		return data, nil
+	}
+
+	buf, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var decoded interface{}
+	err = json.Unmarshal(buf, &decoded)
+	if err != nil {
+		return nil, err
+	}
+
+	list, ok := decoded.([]interface{})
+	if ok {
+		for i, entry := range list {
+			list[i] = selectFields(entry, fields)
+		}
+
+		return list, nil
+	}
+
+	return selectFields(decoded, fields), nil
+}
+
+func selectFields(value interface{}, fields []string) interface{} {
+	obj, ok := value.(map[string]interface{})
+	if !ok {
+		return value
+	}
+
+	out := map[string]interface{}{}
+	for _, field := range fields {
+		// Keys may themselves contain dots, so try the whole field first.
+		v, ok := obj[field]
+		if ok {
+			out[field] = v
+			continue
+		}
+
+		for i := range field {
+			if field[i] != '.' {
+				continue
+			}
+
+			key := field[:i]
+			v, ok := obj[key]
+			if !ok {
+				continue
+			}
+
+			sub, ok := selectFields(v, []string{field[i+1:]}).(map[string]interface{})
+			if !ok || len(sub) == 0 {
+				break
+			}
+
+			existing, ok := out[key].(map[string]interface{})
+			if !ok {
+				out[key] = sub
+				break
+			}
+
+			for k, x := range sub {
+				existing[k] = x
+			}
+
+			break
+		}
+	}
+
+	return out
+}
+
 // ListenAddresses returns a list of host:port combinations at which
 // this machine can be reached
 func ListenAddresses(value string) ([]string, error) {
@@ -0,0 +1,94 @@
+package util_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lxc/lxd/lxd/util"
+)
+
+func TestPaginationFromRequest(t *testing.T) {
+	r := httptest.NewRequest("GET", "/1.0/instances", nil)
+	p, err := util.PaginationFromRequest(r)
+	require.NoError(t, err)
+	assert.Nil(t, p)
+
+	r = httptest.NewRequest("GET", "/1.0/instances?limit=10&offset=20", nil)
+	p, err = util.PaginationFromRequest(r)
+	require.NoError(t, err)
+	assert.Equal(t, &util.Pagination{Offset: 20, Limit: 10}, p)
+
+	r = httptest.NewRequest("GET", "/1.0/instances?offset=5", nil)
+	p, err = util.PaginationFromRequest(r)
+	require.NoError(t, err)
+	assert.Equal(t, &util.Pagination{Offset: 5, Limit: -1}, p)
+
+	for _, query := range []string{"limit=-1", "limit=foo", "offset=-3"} {
+		r = httptest.NewRequest("GET", "/1.0/instances?"+query, nil)
+		_, err = util.PaginationFromRequest(r)
+		assert.Error(t, err, query)
+	}
+}
+
+func TestPaginationRange(t *testing.T) {
+	cases := []struct {
+		p     *util.Pagination
+		total int
+		start int
+		end   int
+	}{
+		{nil, 5, 0, 5},
+		{&util.Pagination{Offset: 0, Limit: 2}, 5, 0, 2},
+		{&util.Pagination{Offset: 4, Limit: 2}, 5, 4, 5},
+		{&util.Pagination{Offset: 7, Limit: 2}, 5, 5, 5},
+		{&util.Pagination{Offset: 1, Limit: -1}, 5, 1, 5},
+		{&util.Pagination{Offset: 1, Limit: 0}, 5, 1, 1},
+	}
+
+	for _, c := range cases {
+		start, end := c.p.Range(c.total)
+		assert.Equal(t, c.start, start)
+		assert.Equal(t, c.end, end)
+	}
+}
+
+func TestSelectFields(t *testing.T) {
+	type state struct {
+		Status string `json:"status"`
+		Pid    int    `json:"pid"`
+	}
+
+	type inst struct {
+		Name   string            `json:"name"`
+		Config map[string]string `json:"config"`
+		State  *state            `json:"state"`
+	}
+
+	data := []inst{
+		{Name: "c1", Config: map[string]string{"image.os": "ubuntu", "limits.cpu": "2"}, State: &state{Status: "Running", Pid: 10}},
+		{Name: "c2", Config: map[string]string{}},
+	}
+
+	result, err := util.SelectFields(data, []string{"name", "config.image.os", "state.status", "missing"})
+	require.NoError(t, err)
+
+	expected := []interface{}{
+		map[string]interface{}{
+			"name":   "c1",
+			"config": map[string]interface{}{"image.os": "ubuntu"},
+			"state":  map[string]interface{}{"status": "Running"},
+		},
+		map[string]interface{}{
+			"name": "c2",
+		},
+	}
+
+	assert.Equal(t, expected, result)
+
+	result, err = util.SelectFields(data[0], []string{"name"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"name": "c1"}, result)
+}
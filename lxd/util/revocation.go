@@ -0,0 +1,281 @@
+package util
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+
+	log "github.com/lxc/lxd/shared/log15"
+
+	"github.com/lxc/lxd/shared/logger"
+)
+
+// ocspCacheDefault is how long OCSP responses without a next update time are cached for.
+const ocspCacheDefault = time.Hour
+
+// ocspRetryInterval is how long to wait before querying the OCSP responders of a certificate again after
+// they failed to answer.
+const ocspRetryInterval = time.Minute
+
+// ocspCacheSize is the maximum number of certificates whose OCSP status is cached, the least recently
+// checked ones being evicted first.
+const ocspCacheSize = 1024
+
+// ocspFirstCheckTimeout is how long the first check of a certificate waits for its OCSP status.
+const ocspFirstCheckTimeout = 2 * time.Second
+
+type ocspCacheEntry struct {
+	serial  string
+	known   bool
+	revoked bool
+	expiry  time.Time
+
+	// Closed once the query in progress, if any, completed.
+	pending chan struct{}
+}
+
+// RevocationChecker checks whether certificates signed by the CA have been revoked, using a certificate
+// revocation list fetched from the CA and, when enabled, the OCSP responders listed in the certificates.
+type RevocationChecker struct {
+	mu         sync.Mutex
+	crl        *pkix.CertificateList
+	ocsp       bool
+	ocspCache  map[string]*list.Element
+	ocspLRU    *list.List
+	httpClient *http.Client
+}
+
+// NewRevocationChecker returns a new RevocationChecker, with no revocation list and OCSP disabled.
+func NewRevocationChecker() *RevocationChecker {
+	return &RevocationChecker{
+		ocspCache:  map[string]*list.Element{},
+		ocspLRU:    list.New(),
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// SetCRL sets the certificate revocation list to check certificates against (nil to unset it).
+func (c *RevocationChecker) SetCRL(crl *pkix.CertificateList) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.crl = crl
+}
+
+// SetOCSP sets whether to query the OCSP responders of the certificates.
+func (c *RevocationChecker) SetOCSP(enabled bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.ocsp = enabled
+	c.ocspCache = map[string]*list.Element{}
+	c.ocspLRU = list.New()
+}
+
+// Revoked returns whether the certificate, signed by the given issuer, has been revoked.
+//
+// The OCSP status of certificates is refreshed in the background once it expires, the cached status being
+// used in the meantime. Only the first check of a certificate waits for its status, for a short while.
+// Failures to reach an OCSP responder are logged and retried later, the certificate being only checked
+// against the revocation list until then, so that an unavailable responder doesn't lock every client out.
+func (c *RevocationChecker) Revoked(cert *x509.Certificate, issuer *x509.Certificate) bool {
+	c.mu.Lock()
+	crl := c.crl
+	ocspEnabled := c.ocsp
+	c.mu.Unlock()
+
+	if crl != nil && CertificateInCRL(cert, crl) {
+		return true
+	}
+
+	if !ocspEnabled || len(cert.OCSPServer) == 0 {
+		return false
+	}
+
+	c.mu.Lock()
+	entry := c.ocspEntry(cert.SerialNumber.String())
+	if entry.pending == nil && time.Now().After(entry.expiry) {
+		entry.pending = make(chan struct{})
+		go c.refreshOCSP(entry, cert, issuer)
+	}
+
+	known := entry.known
+	revoked := entry.revoked
+	pending := entry.pending
+	c.mu.Unlock()
+
+	if known || pending == nil {
+		return revoked
+	}
+
+	select {
+	case <-pending:
+	case <-time.After(ocspFirstCheckTimeout):
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return entry.revoked
+}
+
+// ocspEntry returns the cache entry of the certificate with the given serial, creating it if needed and
+// marking it as the most recently used. Must be called with the lock held.
+func (c *RevocationChecker) ocspEntry(serial string) *ocspCacheEntry {
+	elem, ok := c.ocspCache[serial]
+	if ok {
+		c.ocspLRU.MoveToFront(elem)
+		return elem.Value.(*ocspCacheEntry)
+	}
+
+	entry := &ocspCacheEntry{serial: serial}
+	c.ocspCache[serial] = c.ocspLRU.PushFront(entry)
+
+	for c.ocspLRU.Len() > ocspCacheSize {
+		oldest := c.ocspLRU.Back()
+		c.ocspLRU.Remove(oldest)
+		delete(c.ocspCache, oldest.Value.(*ocspCacheEntry).serial)
+	}
+
+	return entry
+}
+
+// refreshOCSP queries the OCSP status of the certificate and records it in the cache entry.
+func (c *RevocationChecker) refreshOCSP(entry *ocspCacheEntry, cert *x509.Certificate, issuer *x509.Certificate) {
+	revoked, expiry, err := c.queryOCSP(cert, issuer)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err != nil {
+		logger.Warn("Failed checking certificate revocation status", log.Ctx{"serial": entry.serial, "err": err})
+		entry.expiry = time.Now().Add(ocspRetryInterval)
+	} else {
+		entry.known = true
+		entry.revoked = revoked
+		entry.expiry = expiry
+	}
+
+	close(entry.pending)
+	entry.pending = nil
+}
+
+// queryOCSP queries the OCSP responders of the certificate in turn until one answers.
+// It returns whether the certificate is revoked and until when the answer is valid.
+func (c *RevocationChecker) queryOCSP(cert *x509.Certificate, issuer *x509.Certificate) (bool, time.Time, error) {
+	req, err := ocsp.CreateRequest(cert, issuer, nil)
+	if err != nil {
+		return false, time.Time{}, err
+	}
+
+	lastErr := fmt.Errorf("No OCSP responder answered")
+	for _, server := range cert.OCSPServer {
+		revoked, expiry, err := c.queryOCSPServer(server, req, cert, issuer)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		return revoked, expiry, nil
+	}
+
+	return false, time.Time{}, lastErr
+}
+
+func (c *RevocationChecker) queryOCSPServer(server string, req []byte, cert *x509.Certificate, issuer *x509.Certificate) (bool, time.Time, error) {
+	resp, err := c.httpClient.Post(server, "application/ocsp-request", bytes.NewReader(req))
+	if err != nil {
+		return false, time.Time{}, fmt.Errorf("Failed querying OCSP responder %q: %w", server, err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, time.Time{}, fmt.Errorf("Unexpected response from OCSP responder %q: %s", server, resp.Status)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return false, time.Time{}, err
+	}
+
+	ocspResp, err := ocsp.ParseResponseForCert(body, cert, issuer)
+	if err != nil {
+		return false, time.Time{}, fmt.Errorf("Invalid response from OCSP responder %q: %w", server, err)
+	}
+
+	expiry := ocspResp.NextUpdate
+	if expiry.IsZero() {
+		expiry = time.Now().Add(ocspCacheDefault)
+	}
+
+	return ocspResp.Status == ocsp.Revoked, expiry, nil
+}
+
+// CertificateInCRL returns whether the certificate is listed in the certificate revocation list.
+func CertificateInCRL(cert *x509.Certificate, crl *pkix.CertificateList) bool {
+	for _, revoked := range crl.TBSCertList.RevokedCertificates {
+		if cert.SerialNumber.Cmp(revoked.SerialNumber) == 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// FetchCRL downloads the certificate revocation list (PEM or DER encoded) at the given URL, checking
+// that it's signed by the issuer.
+func FetchCRL(client *http.Client, url string, issuer *x509.Certificate) (*pkix.CertificateList, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Unexpected response from %q: %s", url, resp.Status)
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	crl, err := x509.ParseCRL(data)
+	if err != nil {
+		return nil, fmt.Errorf("Failed parsing certificate revocation list: %w", err)
+	}
+
+	err = issuer.CheckCRLSignature(crl)
+	if err != nil {
+		return nil, fmt.Errorf("Certificate revocation list isn't signed by the CA: %w", err)
+	}
+
+	if crl.HasExpired(time.Now()) {
+		logger.Warn("Certificate revocation list has expired", log.Ctx{"url": url, "nextUpdate": crl.TBSCertList.NextUpdate})
+	}
+
+	return crl, nil
+}
+
+// UpdateCRL fetches the certificate revocation list at the given URL, signed by the issuer, and checks
+// certificates against it from then on.
+func (c *RevocationChecker) UpdateCRL(url string, issuer *x509.Certificate) error {
+	crl, err := FetchCRL(c.httpClient, url, issuer)
+	if err != nil {
+		return err
+	}
+
+	c.SetCRL(crl)
+
+	return nil
+}
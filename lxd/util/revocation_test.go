@@ -0,0 +1,151 @@
+package util_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ocsp"
+
+	"github.com/lxc/lxd/lxd/util"
+)
+
+// Generate a certificate with the given serial, signed by the given parent (self-signed if nil).
+func revocationTestCert(t *testing.T, serial int64, parent *x509.Certificate, parentKey crypto.Signer, ocspServer string) (*x509.Certificate, crypto.Signer) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		Subject:               pkix.Name{CommonName: "test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		BasicConstraintsValid: true,
+	}
+
+	if parent == nil {
+		template.IsCA = true
+		template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+		parent = template
+		parentKey = key
+	}
+
+	if ocspServer != "" {
+		template.OCSPServer = []string{ocspServer}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, key.Public(), parentKey)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return cert, key
+}
+
+func TestRevocationChecker_CRL(t *testing.T) {
+	ca, caKey := revocationTestCert(t, 1, nil, nil, "")
+	revoked, _ := revocationTestCert(t, 2, ca, caKey, "")
+	valid, _ := revocationTestCert(t, 3, ca, caKey, "")
+
+	crl, err := ca.CreateCRL(rand.Reader, caKey, []pkix.RevokedCertificate{{SerialNumber: revoked.SerialNumber, RevocationTime: time.Now()}}, time.Now(), time.Now().Add(time.Hour))
+	require.NoError(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(crl)
+	}))
+	defer server.Close()
+
+	checker := util.NewRevocationChecker()
+	assert.False(t, checker.Revoked(revoked, ca))
+
+	require.NoError(t, checker.UpdateCRL(server.URL, ca))
+	assert.True(t, checker.Revoked(revoked, ca))
+	assert.False(t, checker.Revoked(valid, ca))
+
+	// Revocation lists not signed by the CA are rejected.
+	otherCA, _ := revocationTestCert(t, 4, nil, nil, "")
+	assert.Error(t, checker.UpdateCRL(server.URL, otherCA))
+}
+
+func TestRevocationChecker_OCSP(t *testing.T) {
+	var ca *x509.Certificate
+	var caKey crypto.Signer
+	queries := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries++
+
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		req, err := ocsp.ParseRequest(body)
+		require.NoError(t, err)
+
+		status := ocsp.Good
+		if req.SerialNumber.Int64() == 2 {
+			status = ocsp.Revoked
+		}
+
+		resp, err := ocsp.CreateResponse(ca, ca, ocsp.Response{
+			Status:       status,
+			SerialNumber: req.SerialNumber,
+			ThisUpdate:   time.Now(),
+			NextUpdate:   time.Now().Add(time.Hour),
+			RevokedAt:    time.Now(),
+		}, caKey)
+		require.NoError(t, err)
+
+		_, _ = w.Write(resp)
+	}))
+	defer server.Close()
+
+	ca, caKey = revocationTestCert(t, 1, nil, nil, "")
+	revoked, _ := revocationTestCert(t, 2, ca, caKey, server.URL)
+	valid, _ := revocationTestCert(t, 3, ca, caKey, server.URL)
+
+	checker := util.NewRevocationChecker()
+	assert.False(t, checker.Revoked(revoked, ca))
+	assert.Equal(t, 0, queries)
+
+	checker.SetOCSP(true)
+	assert.True(t, checker.Revoked(revoked, ca))
+	assert.False(t, checker.Revoked(valid, ca))
+	assert.Equal(t, 2, queries)
+
+	// Responses are cached until their next update.
+	assert.True(t, checker.Revoked(revoked, ca))
+	assert.Equal(t, 2, queries)
+}
+
+func TestRevocationChecker_OCSPFailure(t *testing.T) {
+	queries := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	ca, caKey := revocationTestCert(t, 1, nil, nil, "")
+	cert, _ := revocationTestCert(t, 2, ca, caKey, server.URL)
+
+	checker := util.NewRevocationChecker()
+	checker.SetOCSP(true)
+
+	// Unavailable responders don't lock clients out, and aren't queried again right away.
+	assert.False(t, checker.Revoked(cert, ca))
+	assert.False(t, checker.Revoked(cert, ca))
+	assert.Equal(t, 1, queries)
+}
@@ -43,9 +43,17 @@ func GetArchitectures() ([]int, error) {
 	return architectures, nil
 }
 
-// GetIdmapSet reads the uid/gid allocation.
-func GetIdmapSet() *idmap.IdmapSet {
-	idmapSet, err := idmap.DefaultIdmapSet("", "")
+// GetIdmapSet reads the uid/gid allocation. When running rootless, the ids mapped into the daemon's user
+// namespace are allocated rather than those listed in /etc/subuid and /etc/subgid.
+func GetIdmapSet(rootless bool) *idmap.IdmapSet {
+	var idmapSet *idmap.IdmapSet
+	var err error
+	if rootless {
+		idmapSet, err = idmap.UserNSIdmapSet()
+	} else {
+		idmapSet, err = idmap.DefaultIdmapSet("", "")
+	}
+
 	if err != nil {
 		logger.Warn("Error reading default uid/gid map", log.Ctx{"err": err.Error()})
 		logger.Warnf("Only privileged containers will be able to run")
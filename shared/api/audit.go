@@ -0,0 +1,43 @@
+package api
+
+import (
+	"time"
+)
+
+// AuditEntry represents a state-changing API request recorded in the audit log
+//
+// swagger:model
+//
+// API extension: audit_log
+type AuditEntry struct {
+	// Time at which the request was received (or its operation completed)
+	// Example: 2021-03-14T00:00:00Z
+	Timestamp time.Time `json:"timestamp" yaml:"timestamp"`
+
+	// Who made the request
+	Requestor *EventLifecycleRequestor `json:"requestor" yaml:"requestor"`
+
+	// HTTP method of the request
+	// Example: POST
+	Method string `json:"method" yaml:"method"`
+
+	// Requested URL
+	// Example: /1.0/instances?project=default
+	URL string `json:"url" yaml:"url"`
+
+	// SHA-256 hash of the request body
+	// Example: e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855
+	BodyHash string `json:"body_hash" yaml:"body_hash"`
+
+	// HTTP status code of the response
+	// Example: 202
+	StatusCode int `json:"status_code" yaml:"status_code"`
+
+	// ID of the background operation started by the request, if any
+	// Example: b4f7d4a5-e4cb-4ab8-9d8b-1f9a4d52b2a0
+	Operation string `json:"operation" yaml:"operation"`
+
+	// Final status of the background operation, only set on the entry recorded once it's done
+	// Example: Success
+	OperationStatus string `json:"operation_status" yaml:"operation_status"`
+}
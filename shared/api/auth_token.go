@@ -0,0 +1,104 @@
+package api
+
+import (
+	"time"
+)
+
+// AuthTokensPost represents the fields of a new API token
+//
+// swagger:model
+//
+// API extension: auth_tokens
+type AuthTokensPost struct {
+	AuthTokenPut `yaml:",inline"`
+
+	// Name of the token, used as the identity of its users
+	// Example: ci
+	Name string `json:"name" yaml:"name"`
+
+	// When the token expires (never if unset)
+	// Example: 2022-01-01T00:00:00Z
+	ExpiresAt time.Time `json:"expires_at" yaml:"expires_at"`
+
+	// Whether to limit the token to the listed projects
+	// Example: true
+	Restricted bool `json:"restricted" yaml:"restricted"`
+
+	// List of allowed projects (applies when restricted)
+	// Example: ["default", "foo"]
+	Projects []string `json:"projects" yaml:"projects"`
+
+	// Role of the token in each of the allowed projects (viewer, operator or admin, defaults to admin)
+	// Example: {"default": "operator", "foo": "viewer"}
+	ProjectRoles map[string]string `json:"project_roles" yaml:"project_roles"`
+}
+
+// AuthTokenPut represents the modifiable fields of an API token
+//
+// swagger:model
+//
+// API extension: auth_tokens
+type AuthTokenPut struct {
+	// Description of the token
+	// Example: Token used by the CI system
+	Description string `json:"description" yaml:"description"`
+}
+
+// AuthToken represents an API token
+//
+// swagger:model
+//
+// API extension: auth_tokens
+type AuthToken struct {
+	AuthTokenPut `yaml:",inline"`
+
+	// Name of the token
+	// Read only: true
+	// Example: ci
+	Name string `json:"name" yaml:"name"`
+
+	// When the token was created
+	// Read only: true
+	// Example: 2021-03-23T17:38:37.753398689-04:00
+	CreatedAt time.Time `json:"created_at" yaml:"created_at"`
+
+	// When the token expires (never if unset)
+	// Read only: true
+	// Example: 2022-01-01T00:00:00Z
+	ExpiresAt time.Time `json:"expires_at" yaml:"expires_at"`
+
+	// Whether the token is limited to the listed projects
+	// Read only: true
+	// Example: true
+	Restricted bool `json:"restricted" yaml:"restricted"`
+
+	// List of allowed projects (applies when restricted)
+	// Read only: true
+	// Example: ["default", "foo"]
+	Projects []string `json:"projects" yaml:"projects"`
+
+	// Role of the token in each of the allowed projects
+	// Read only: true
+	// Example: {"default": "operator", "foo": "viewer"}
+	ProjectRoles map[string]string `json:"project_roles" yaml:"project_roles"`
+}
+
+// AuthTokenSecret represents a newly created API token along with its secret
+//
+// swagger:model
+//
+// API extension: auth_tokens
+type AuthTokenSecret struct {
+	// Name of the token
+	// Example: ci
+	Name string `json:"name" yaml:"name"`
+
+	// Secret to send as a bearer token, only returned on creation
+	// Example: 9jYAmJb0KVuR1i1Ud2Hy1jAf08YWuKxSMoSKv9Fv0xk
+	Secret string `json:"secret" yaml:"secret"`
+}
+
+// Writable converts a full AuthToken struct into an AuthTokenPut struct (filters read-only fields)
+func (token *AuthToken) Writable() AuthTokenPut {
+	return token.AuthTokenPut
+}
@@ -0,0 +1,48 @@
+package api
+
+// BatchPost represents a list of API requests to run as a single batch
+//
+// swagger:model
+//
+// API extension: api_batch
+type BatchPost struct {
+	// Ordered list of requests to run
+	Requests []BatchRequest `json:"requests" yaml:"requests"`
+
+	// Whether to stop at the first failing request
+	// Example: true
+	StopOnError bool `json:"stop_on_error" yaml:"stop_on_error"`
+}
+
+// BatchRequest represents a single API request within a batch
+//
+// swagger:model
+//
+// API extension: api_batch
+type BatchRequest struct {
+	// HTTP method of the request
+	// Example: PUT
+	Method string `json:"method" yaml:"method"`
+
+	// URL of the request (including query string)
+	// Example: /1.0/instances/foo?project=default
+	URL string `json:"url" yaml:"url"`
+
+	// Request body
+	// Example: {"config": {"limits.cpu": "2"}}
+	Body interface{} `json:"body" yaml:"body"`
+}
+
+// BatchResult represents the result of a single API request within a batch
+//
+// swagger:model
+//
+// API extension: api_batch
+type BatchResult struct {
+	// HTTP status code of the response
+	// Example: 200
+	StatusCode int `json:"status_code" yaml:"status_code"`
+
+	// Response to the request (sync, async or error)
+	Response Response `json:"response" yaml:"response"`
+}
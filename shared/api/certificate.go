@@ -1,11 +1,21 @@
 package api
 
+import (
+	"encoding/base64"
+	"encoding/json"
+)
+
 // CertificateTypeClient indicates a client certificate type.
 const CertificateTypeClient = "client"
 
 // CertificateTypeServer indicates a server certificate type.
 const CertificateTypeServer = "server"
 
+// CertificateTypeMetrics indicates a metrics certificate type.
+//
+// API extension: metrics
+const CertificateTypeMetrics = "metrics"
+
 // CertificateTypeUnknown indicates an unknown certificate type.
 const CertificateTypeUnknown = "unknown"
 
@@ -19,9 +29,15 @@ type CertificatesPost struct {
 	// Example: X509 PEM certificate
 	Certificate string `json:"certificate" yaml:"certificate"`
 
-	// Server trust password (used to add an untrusted client)
+	// Server trust password or certificate add token (used to add an untrusted client)
 	// Example: blah
 	Password string `json:"password" yaml:"password"`
+
+	// Whether to create a single-use certificate add token instead of adding a certificate
+	// Example: true
+	//
+	// API extension: certificate_token
+	Token bool `json:"token" yaml:"token"`
 }
 
 // CertificatePut represents the modifiable fields of a LXD certificate
@@ -34,7 +50,7 @@ type CertificatePut struct {
 	// Example: castiana
 	Name string `json:"name" yaml:"name"`
 
-	// Usage type for the certificate (only client currently)
+	// Usage type for the certificate (client or metrics)
 	// Example: client
 	Type string `json:"type" yaml:"type"`
 
@@ -49,6 +65,12 @@ type CertificatePut struct {
 	//
 	// API extension: certificate_project
 	Projects []string `json:"projects" yaml:"projects"`
+
+	// Role of the certificate in each of the allowed projects (viewer, operator or admin, defaults to admin)
+	// Example: {"default": "operator", "foo": "viewer"}
+	//
+	// API extension: certificate_project_roles
+	ProjectRoles map[string]string `json:"project_roles" yaml:"project_roles"`
 }
 
 // Certificate represents a LXD certificate
@@ -72,3 +94,36 @@ type Certificate struct {
 func (cert *Certificate) Writable() CertificatePut {
 	return cert.CertificatePut
 }
+
+// CertificateAddToken represents the fields contained within an encoded certificate add token.
+//
+// swagger:model
+//
+// API extension: certificate_token
+type CertificateAddToken struct {
+	// The name of the new client
+	// Example: user@host
+	ClientName string `json:"client_name" yaml:"client_name"`
+
+	// The fingerprint of the network certificate
+	// Example: 57bb0ff4340b5bb28517e062023101adf788c37846dc8b619eb2c3cb4ef29436
+	Fingerprint string `json:"fingerprint" yaml:"fingerprint"`
+
+	// The addresses of the server
+	// Example: ["10.98.30.229:8443"]
+	Addresses []string `json:"addresses" yaml:"addresses"`
+
+	// The random secret
+	// Example: 2b2284d44db32675923fe0d2020477e0e9be11801ff70c435e032b97028c35cd
+	Secret string `json:"secret" yaml:"secret"`
+}
+
+// String encodes the certificate add token as JSON and then Base64.
+func (t *CertificateAddToken) String() string {
+	tokenJSON, err := json.Marshal(t)
+	if err != nil {
+		return ""
+	}
+
+	return base64.StdEncoding.EncodeToString(tokenJSON)
+}
@@ -6,6 +6,39 @@ import (
 	"net/http"
 )
 
+// ErrorType is a machine readable cause of an API error.
+// Errors returned by the API along with a type match it when using errors.Is.
+type ErrorType string
+
+// Error returns the name of the error type.
+func (t ErrorType) Error() string {
+	return string(t)
+}
+
+// Error types returned by the API.
+const (
+	// ErrNotFound is returned when the requested object doesn't exist.
+	ErrNotFound ErrorType = "not-found"
+
+	// ErrAlreadyExists is returned when an object with the same name already exists.
+	ErrAlreadyExists ErrorType = "already-exists"
+
+	// ErrForbidden is returned when the client isn't allowed to perform the request.
+	ErrForbidden ErrorType = "forbidden"
+
+	// ErrETagMismatch is returned when the ETag sent by the client doesn't match the current object.
+	ErrETagMismatch ErrorType = "etag-mismatch"
+
+	// ErrInstanceRunning is returned when the request requires the instance to be stopped.
+	ErrInstanceRunning ErrorType = "instance-running"
+
+	// ErrProfileInUse is returned when deleting a profile which is still used.
+	ErrProfileInUse ErrorType = "profile-in-use"
+
+	// ErrQuotaExceeded is returned when the request would exceed the limits of a project.
+	ErrQuotaExceeded ErrorType = "quota-exceeded"
+)
+
 // StatusErrorf returns a new StatusError containing the specified status and message.
 func StatusErrorf(status int, format string, a ...interface{}) StatusError {
 	return StatusError{
@@ -14,10 +47,20 @@ func StatusErrorf(status int, format string, a ...interface{}) StatusError {
 	}
 }
 
-// StatusError error type that contains an HTTP status code and message.
+// StatusErrorTypef returns a new StatusError containing the specified status, error type and message.
+func StatusErrorTypef(status int, errType ErrorType, format string, a ...interface{}) StatusError {
+	return StatusError{
+		status:  status,
+		errType: errType,
+		msg:     fmt.Sprintf(format, a...),
+	}
+}
+
+// StatusError error type that contains an HTTP status code, an optional error type and message.
 type StatusError struct {
-	status int
-	msg    string
+	status  int
+	errType ErrorType
+	msg     string
 }
 
 // Error returns the error message or the http.StatusText() of the status code if message is empty.
@@ -34,6 +77,17 @@ func (e StatusError) Status() int {
 	return e.status
 }
 
+// Type returns the error type (empty if not set).
+func (e StatusError) Type() ErrorType {
+	return e.errType
+}
+
+// Is returns whether the error is of the target error type.
+func (e StatusError) Is(target error) bool {
+	errType, ok := target.(ErrorType)
+	return ok && e.errType != "" && errType == e.errType
+}
+
 // StatusErrorMatch checks if err was caused by StatusError. Can optionally also check whether the StatusError's
 // status code matches one of the supplied status codes in matchStatus.
 // Returns the matched StatusError status code and true if match criteria are met, otherwise false.
@@ -27,6 +27,28 @@ type Event struct {
 	//
 	// API extension: event_location
 	Location string `yaml:"location,omitempty" json:"location,omitempty"`
+
+	// Sequence number of the event on the server it was received from
+	// Example: 42
+	//
+	// API extension: event_sequence
+	Sequence uint64 `yaml:"sequence,omitempty" json:"sequence,omitempty"`
+}
+
+// EventFilter represents the filter a client can send over the events websocket to change which events
+// it receives
+//
+// swagger:model
+//
+// API extension: event_filters
+type EventFilter struct {
+	// Event types to receive (all the allowed types if empty)
+	// Example: ["lifecycle", "operation"]
+	Types []string `yaml:"types" json:"types"`
+
+	// Resources (API URLs) to receive events for (all resources if empty)
+	// Example: ["/1.0/instances/c1"]
+	Resources []string `yaml:"resources" json:"resources"`
 }
 
 // ToLogging creates log record for the event
@@ -132,6 +154,18 @@ type EventLifecycle struct {
 
 	// API extension: event_lifecycle_requestor
 	Requestor *EventLifecycleRequestor `yaml:"requestor,omitempty" json:"requestor,omitempty"`
+
+	// Project the event relates to
+	// Example: default
+	//
+	// API extension: event_lifecycle_context
+	Project string `yaml:"project,omitempty" json:"project,omitempty"`
+
+	// Cluster member the event was emitted on
+	// Example: lxd01
+	//
+	// API extension: event_lifecycle_context
+	Location string `yaml:"location,omitempty" json:"location,omitempty"`
 }
 
 // EventLifecycleRequestor represents the initial requestor for an event
@@ -0,0 +1,28 @@
+package api
+
+// IdmapAllocation represents the range of host uids/gids allocated to an instance with an isolated idmap
+//
+// swagger:model
+//
+// API extension: idmap_allocations
+type IdmapAllocation struct {
+	// Project of the instance
+	// Example: default
+	Project string `json:"project" yaml:"project"`
+
+	// Name of the instance
+	// Example: c1
+	Instance string `json:"instance" yaml:"instance"`
+
+	// Cluster member the instance is on
+	// Example: lxd01
+	Location string `json:"location" yaml:"location"`
+
+	// First host uid/gid of the range
+	// Example: 1065536
+	Base int64 `json:"base" yaml:"base"`
+
+	// Number of uids/gids in the range
+	// Example: 65536
+	Size int64 `json:"size" yaml:"size"`
+}
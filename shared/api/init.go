@@ -0,0 +1,35 @@
+package api
+
+// InitPreseed represents the configuration of a LXD server in the "lxd init --preseed" format
+//
+// swagger:model
+//
+// API extension: server_preseed
+type InitPreseed struct {
+	ServerPut `yaml:",inline"`
+
+	// Managed networks
+	Networks []InitNetworksProjectPost `json:"networks" yaml:"networks"`
+
+	// Storage pools
+	StoragePools []StoragePoolsPost `json:"storage_pools" yaml:"storage_pools"`
+
+	// Profiles (default project)
+	Profiles []ProfilesPost `json:"profiles" yaml:"profiles"`
+
+	// Projects
+	Projects []ProjectsPost `json:"projects" yaml:"projects"`
+}
+
+// InitNetworksProjectPost represents a network of a preseed along with its project
+//
+// swagger:model
+//
+// API extension: server_preseed
+type InitNetworksProjectPost struct {
+	NetworksPost `yaml:",inline"`
+
+	// Project the network belongs to
+	// Example: default
+	Project string `json:"project" yaml:"project"`
+}
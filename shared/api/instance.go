@@ -50,6 +50,27 @@ type InstancesPut struct {
 	State *InstanceStatePut `json:"state" yaml:"state"`
 }
 
+// InstanceRebuildPost represents the fields required to rebuild a LXD instance.
+//
+// swagger:model
+//
+// API extension: instances_rebuild
+type InstanceRebuildPost struct {
+	// Source of the new root volume ("image", or "none" for an empty one)
+	Source InstanceSource `json:"source" yaml:"source"`
+}
+
+// InstanceConvertPost represents the fields required to convert a LXD instance to another type.
+//
+// swagger:model
+//
+// API extension: instances_convert
+type InstanceConvertPost struct {
+	// Type to convert the instance to ("container" or "virtual-machine")
+	// Example: virtual-machine
+	Type InstanceType `json:"type" yaml:"type"`
+}
+
 // InstancePost represents the fields required to rename/move a LXD instance.
 //
 // swagger:model
@@ -84,6 +105,12 @@ type InstancePost struct {
 	//
 	// API extension: instance_pool_move
 	Pool string `json:"pool" yaml:"pool"`
+
+	// Target project for local cross-project move
+	// Example: foo
+	//
+	// API extension: instance_project_move
+	Project string `json:"project" yaml:"project"`
 }
 
 // InstancePostTarget represents the migration target host and operation.
@@ -305,4 +332,10 @@ type InstanceSource struct {
 	// Source project name (for copy and local image)
 	// Example: blah
 	Project string `json:"project,omitempty" yaml:"project,omitempty"`
+
+	// Mapping of the source storage pools to the target storage pools (for migration)
+	// Example: {"default": "fast"}
+	//
+	// API extension: instances_migration_pool_map
+	PoolMap map[string]string `json:"pool_map,omitempty" yaml:"pool_map,omitempty"`
 }
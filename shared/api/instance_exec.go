@@ -1,5 +1,9 @@
 package api
 
+import (
+	"time"
+)
+
 // InstanceExecControl represents a message on the instance exec "control" socket.
 //
 // API extension: instances
@@ -53,4 +57,45 @@ type InstanceExecPost struct {
 	// Current working directory for the command
 	// Example: /home/foo/
 	Cwd string `json:"cwd" yaml:"cwd"`
+
+	// Name of the user to spawn the command as, resolved inside the instance (overrides user)
+	// Example: ubuntu
+	//
+	// API extension: instance_exec_options
+	UserName string `json:"user_name" yaml:"user_name"`
+
+	// Name of the group to spawn the command as, resolved inside the instance (overrides group)
+	// Example: ubuntu
+	//
+	// API extension: instance_exec_options
+	GroupName string `json:"group_name" yaml:"group_name"`
+
+	// Number of seconds after which the command is killed (0 for no limit)
+	// Example: 60
+	//
+	// API extension: instance_exec_options
+	Timeout int `json:"timeout" yaml:"timeout"`
+}
+
+// InstanceExecRecording represents the recording of an interactive exec session (asciicast v2).
+//
+// swagger:model
+//
+// API extension: instances_exec_recording
+type InstanceExecRecording struct {
+	// Name of the recording
+	// Example: 0bd85d97-c2ce-4ef8-a0b3-f4e5e2a1c2be.cast
+	Name string `json:"name" yaml:"name"`
+
+	// Command which was run
+	// Example: ["bash"]
+	Command []string `json:"command" yaml:"command"`
+
+	// When the session started
+	// Example: 2021-03-23T20:00:00-04:00
+	CreatedAt time.Time `json:"created_at" yaml:"created_at"`
+
+	// Size of the recording in bytes
+	// Example: 40960
+	Size int64 `json:"size" yaml:"size"`
 }
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"time"
+)
+
+// InstanceFileManifestEntry represents an entry of the manifest of a file tree in a LXD instance
+//
+// swagger:model
+//
+// API extension: instance_file_manifest
+type InstanceFileManifestEntry struct {
+	// Path relative to the root of the tree
+	// Example: etc/hostname
+	Path string `json:"path" yaml:"path"`
+
+	// Type of entry (file, directory, symlink or other)
+	// Example: file
+	Type string `json:"type" yaml:"type"`
+
+	// Permission bits
+	// Example: 420
+	Mode int64 `json:"mode" yaml:"mode"`
+
+	// Owner UID
+	// Example: 0
+	UID int64 `json:"uid" yaml:"uid"`
+
+	// Owner GID
+	// Example: 0
+	GID int64 `json:"gid" yaml:"gid"`
+
+	// Size in bytes (files only)
+	// Example: 3
+	Size int64 `json:"size" yaml:"size"`
+
+	// Last modification date
+	// Example: 2021-03-23T20:00:00-04:00
+	ModifiedAt time.Time `json:"modified_at" yaml:"modified_at"`
+
+	// SHA256 checksum of the content (files only)
+	// Example: 7a8f0c2ed3ba5c1d9c0e3a6e5d23f14b9a4cbe8d6a1ffdcf2c9d0cf6a1b4f8d2
+	Checksum string `json:"checksum" yaml:"checksum"`
+
+	// Target of the symlink (symlinks only)
+	// Example: ../usr/share/zoneinfo/UTC
+	Target string `json:"target" yaml:"target"`
+}
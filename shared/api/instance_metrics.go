@@ -0,0 +1,35 @@
+package api
+
+// InstanceMetrics represents the current resource usage of a LXD instance.
+// Rates can be computed from the difference between two samples.
+//
+// swagger:model
+//
+// API extension: instances_metrics
+type InstanceMetrics struct {
+	InstanceUsageSample `yaml:",inline"`
+
+	// Name of the instance
+	// Example: c1
+	Name string `json:"name" yaml:"name"`
+
+	// Project the instance belongs to
+	// Example: default
+	Project string `json:"project" yaml:"project"`
+
+	// What cluster member the instance is located on
+	// Example: lxd01
+	Location string `json:"location" yaml:"location"`
+
+	// Instance type
+	// Example: container
+	Type string `json:"type" yaml:"type"`
+
+	// Current status of the instance
+	// Example: Running
+	Status string `json:"status" yaml:"status"`
+
+	// Number of processes
+	// Example: 12
+	Processes int64 `json:"processes" yaml:"processes"`
+}
@@ -23,6 +23,51 @@ type InstanceStatePut struct {
 	Stateful bool `json:"stateful" yaml:"stateful"`
 }
 
+// InstancesStatePut represents a state change of a set of instances.
+//
+// swagger:model
+//
+// API extension: instances_state_bulk
+type InstancesStatePut struct {
+	// Names of the instances to change
+	// Example: ["c1", "c2"]
+	Instances []string `json:"instances" yaml:"instances"`
+
+	// Config values the instances must have (including those inherited from profiles)
+	// Example: {"user.role": "web"}
+	Selector map[string]string `json:"selector" yaml:"selector"`
+
+	// Whether to change all the instances of the project (instances and selector must then be empty)
+	// Example: false
+	All bool `json:"all" yaml:"all"`
+
+	// State change
+	State InstanceStatePut `json:"state" yaml:"state"`
+}
+
+// InstanceStateResult represents the outcome of a bulk state change for one instance.
+//
+// swagger:model
+//
+// API extension: instances_state_bulk
+type InstanceStateResult struct {
+	// Name of the instance
+	// Example: c1
+	Name string `json:"name" yaml:"name"`
+
+	// Cluster member the instance is on
+	// Example: lxd01
+	Location string `json:"location" yaml:"location"`
+
+	// Whether the instance was already in the requested state
+	// Example: false
+	Skipped bool `json:"skipped" yaml:"skipped"`
+
+	// Error of the state change (empty on success)
+	// Example: Failed to start device "eth0"
+	Error string `json:"error" yaml:"error"`
+}
+
 // InstanceState represents a LXD instance's state.
 //
 // swagger:model
@@ -56,6 +101,35 @@ type InstanceState struct {
 
 	// CPU usage information
 	CPU InstanceStateCPU `json:"cpu" yaml:"cpu"`
+
+	// Number of consecutive automatic restarts of the instance
+	// Example: 0
+	//
+	// API extension: instances_autorestart
+	Restarts int64 `json:"restarts" yaml:"restarts"`
+
+	// Result of the health check (starting, healthy or unhealthy), empty if none is configured
+	// Example: healthy
+	//
+	// API extension: instances_healthcheck
+	Health string `json:"health" yaml:"health"`
+}
+
+// InstanceHealthProbe represents a TCP or HTTP health check probe run from inside an instance.
+//
+// API extension: instances_healthcheck
+type InstanceHealthProbe struct {
+	// Probe type (tcp or http)
+	// Example: http
+	Type string `json:"type" yaml:"type"`
+
+	// Address to probe (host:port for tcp, URL for http)
+	// Example: http://127.0.0.1:8080/health
+	Address string `json:"address" yaml:"address"`
+
+	// Timeout in seconds
+	// Example: 5
+	Timeout int64 `json:"timeout" yaml:"timeout"`
 }
 
 // InstanceStateDisk represents the disk information section of a LXD instance's state.
@@ -0,0 +1,104 @@
+package api
+
+// InstanceTemplatesPost represents the fields of a new instance template
+//
+// swagger:model
+//
+// API extension: instance_templates
+type InstanceTemplatesPost struct {
+	InstanceTemplatePut `yaml:",inline"`
+
+	// Name of the instance template
+	// Example: webserver
+	Name string `json:"name" yaml:"name"`
+}
+
+// InstanceTemplatePut represents the modifiable fields of an instance template
+//
+// swagger:model
+//
+// API extension: instance_templates
+type InstanceTemplatePut struct {
+	// Description of the instance template
+	// Example: Web server
+	Description string `json:"description" yaml:"description"`
+
+	// Image source of the instances
+	Source InstanceSource `json:"source" yaml:"source"`
+
+	// Instance type ("container" or "virtual-machine")
+	// Example: container
+	Type InstanceType `json:"type" yaml:"type"`
+
+	// List of profiles applied to the instances
+	// Example: ["default"]
+	Profiles []string `json:"profiles" yaml:"profiles"`
+
+	// Instance configuration, which may reference variables as ${var:NAME}
+	// Example: {"environment.ENV": "${var:env}", "cloud-init.user-data": "#cloud-config\npackages: [nginx]"}
+	Config map[string]string `json:"config" yaml:"config"`
+
+	// Instance devices, which may reference variables as ${var:NAME}
+	// Example: {"root": {"type": "disk", "pool": "default", "path": "/"}}
+	Devices map[string]map[string]string `json:"devices" yaml:"devices"`
+
+	// Variables which can be set when creating instances from the template
+	Variables map[string]InstanceTemplateVariable `json:"variables" yaml:"variables"`
+}
+
+// InstanceTemplateVariable represents a variable of an instance template
+//
+// swagger:model
+//
+// API extension: instance_templates
+type InstanceTemplateVariable struct {
+	// Description of the variable
+	// Example: Deployment environment
+	Description string `json:"description" yaml:"description"`
+
+	// Default value of the variable
+	// Example: staging
+	Default string `json:"default" yaml:"default"`
+
+	// Whether a value must be provided when creating an instance
+	// Example: false
+	Required bool `json:"required" yaml:"required"`
+}
+
+// InstanceTemplate represents an instance template
+//
+// swagger:model
+//
+// API extension: instance_templates
+type InstanceTemplate struct {
+	InstanceTemplatePut `yaml:",inline"`
+
+	// Name of the instance template
+	// Read only: true
+	// Example: webserver
+	Name string `json:"name" yaml:"name"`
+}
+
+// Writable converts a full InstanceTemplate struct into a InstanceTemplatePut struct (filters read-only fields).
+func (t *InstanceTemplate) Writable() InstanceTemplatePut {
+	return t.InstanceTemplatePut
+}
+
+// InstanceTemplateInstancesPost represents the fields required to create an instance from a template
+//
+// swagger:model
+//
+// API extension: instance_templates
+type InstanceTemplateInstancesPost struct {
+	// Instance name
+	// Example: web01
+	Name string `json:"name" yaml:"name"`
+
+	// Values of the variables of the template
+	// Example: {"env": "prod"}
+	Variables map[string]string `json:"variables" yaml:"variables"`
+
+	// Whether the instance is ephemeral (deleted on shutdown)
+	// Example: false
+	Ephemeral bool `json:"ephemeral" yaml:"ephemeral"`
+}
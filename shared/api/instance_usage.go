@@ -0,0 +1,50 @@
+package api
+
+import (
+	"time"
+)
+
+// InstanceUsage represents the recorded resource usage of a LXD instance
+//
+// swagger:model
+//
+// API extension: instances_usage_history
+type InstanceUsage struct {
+	// Interval between samples in seconds
+	// Example: 60
+	Interval int64 `json:"interval" yaml:"interval"`
+
+	// Samples recorded over the requested period (oldest first)
+	Samples []InstanceUsageSample `json:"samples" yaml:"samples"`
+}
+
+// InstanceUsageSample represents a sample of the resource usage of a LXD instance
+//
+// swagger:model
+//
+// API extension: instances_usage_history
+type InstanceUsageSample struct {
+	// When the sample was taken
+	// Example: 2021-03-23T20:00:00-04:00
+	Timestamp time.Time `json:"timestamp" yaml:"timestamp"`
+
+	// CPU usage in nanoseconds (since the instance started)
+	// Example: 3637691016
+	CPUUsage int64 `json:"cpu_usage" yaml:"cpu_usage"`
+
+	// Memory usage in bytes
+	// Example: 73248768
+	MemoryUsage int64 `json:"memory_usage" yaml:"memory_usage"`
+
+	// Disk usage in bytes (all disks)
+	// Example: 502239232
+	DiskUsage int64 `json:"disk_usage" yaml:"disk_usage"`
+
+	// Bytes received over the network (all interfaces, since the instance started)
+	// Example: 192021
+	NetworkBytesReceived int64 `json:"network_bytes_received" yaml:"network_bytes_received"`
+
+	// Bytes sent over the network (all interfaces, since the instance started)
+	// Example: 10888579
+	NetworkBytesSent int64 `json:"network_bytes_sent" yaml:"network_bytes_sent"`
+}
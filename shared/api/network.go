@@ -132,6 +132,18 @@ type NetworkLease struct {
 	//
 	// API extension: network_leases_location
 	Location string `json:"location" yaml:"location"`
+
+	// Name of the project of the instance the record belongs to (if any)
+	// Example: default
+	//
+	// API extension: network_leases_instance
+	Project string `json:"project" yaml:"project"`
+
+	// Name of the instance the record belongs to (if any)
+	// Example: c1
+	//
+	// API extension: network_leases_instance
+	Instance string `json:"instance" yaml:"instance"`
 }
 
 // NetworkState represents the network state
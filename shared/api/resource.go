@@ -744,6 +744,18 @@ type ResourcesMemoryNode struct {
 	// Total system memory (bytes)
 	// Example: 343597383680
 	Total uint64 `json:"total" yaml:"total"`
+
+	// List of CPU threads local to the node
+	// Example: [0, 1, 2, 3]
+	//
+	// API extension: instance_numa_nodes
+	CPUs []int64 `json:"cpus" yaml:"cpus"`
+
+	// Distance from the node to each NUMA node (indexed by node)
+	// Example: [10, 21]
+	//
+	// API extension: instance_numa_nodes
+	Distances []uint64 `json:"distances" yaml:"distances"`
 }
 
 // ResourcesStoragePool represents the resources available to a given storage pool
@@ -933,6 +945,38 @@ type ResourcesPCIDevice struct {
 	//
 	// API extension: resources_pci_iommu
 	IOMMUGroup uint64 `json:"iommu_group" yaml:"iommu_group"`
+
+	// PCI address of the upstream bridge the device is connected to
+	// Example: 0000:00:02.0
+	//
+	// API extension: resources_pci_topology
+	ParentAddress string `json:"parent_address,omitempty" yaml:"parent_address,omitempty"`
+
+	// PCI address of the physical function (for SR-IOV virtual functions)
+	// Example: 0000:07:00.0
+	//
+	// API extension: resources_pci_topology
+	PhysicalFunction string `json:"physical_function,omitempty" yaml:"physical_function,omitempty"`
+
+	// SR-IOV information (when supported by the device)
+	//
+	// API extension: resources_pci_topology
+	SRIOV *ResourcesPCIDeviceSRIOV `json:"sriov,omitempty" yaml:"sriov,omitempty"`
+}
+
+// ResourcesPCIDeviceSRIOV represents the SRIOV configuration of a PCI device
+//
+// swagger:model
+//
+// API extension: resources_pci_topology
+type ResourcesPCIDeviceSRIOV struct {
+	// Number of VFs currently configured
+	// Example: 0
+	CurrentVFs uint64 `json:"current_vfs" yaml:"current_vfs"`
+
+	// Maximum number of supported VFs
+	// Example: 0
+	MaximumVFs uint64 `json:"maximum_vfs" yaml:"maximum_vfs"`
 }
 
 // ResourcesSystem represents the system
@@ -19,6 +19,11 @@ type ResponseRaw struct {
 	Code  int    `json:"error_code" yaml:"error_code"`
 	Error string `json:"error" yaml:"error"`
 
+	// Machine readable cause of the error, if known (valid only for Error responses)
+	//
+	// API extension: api_error_types
+	ErrorType ErrorType `json:"error_type,omitempty" yaml:"error_type,omitempty"`
+
 	Metadata interface{} `json:"metadata" yaml:"metadata"`
 }
 
@@ -37,6 +42,11 @@ type Response struct {
 	Code  int    `json:"error_code" yaml:"error_code"`
 	Error string `json:"error" yaml:"error"`
 
+	// Machine readable cause of the error, if known (valid only for Error responses)
+	//
+	// API extension: api_error_types
+	ErrorType ErrorType `json:"error_type,omitempty" yaml:"error_type,omitempty"`
+
 	// Valid for Sync and Error responses
 	Metadata json.RawMessage `json:"metadata" yaml:"metadata"`
 }
@@ -0,0 +1,50 @@
+package api
+
+// SecretsPost represents the fields of a new secret
+//
+// swagger:model
+//
+// API extension: secrets
+type SecretsPost struct {
+	SecretPut `yaml:",inline"`
+
+	// Name of the secret, used to reference it as ${secret:NAME}
+	// Example: db-password
+	Name string `json:"name" yaml:"name"`
+}
+
+// SecretPut represents the modifiable fields of a secret
+//
+// swagger:model
+//
+// API extension: secrets
+type SecretPut struct {
+	// Description of the secret
+	// Example: Password of the database server
+	Description string `json:"description" yaml:"description"`
+
+	// Value of the secret (never returned, left unchanged if empty when updating)
+	// Example: hunter2
+	Value string `json:"value,omitempty" yaml:"value,omitempty"`
+}
+
+// Secret represents a secret, without its value
+//
+// swagger:model
+//
+// API extension: secrets
+type Secret struct {
+	// Name of the secret
+	// Read only: true
+	// Example: db-password
+	Name string `json:"name" yaml:"name"`
+
+	// Description of the secret
+	// Example: Password of the database server
+	Description string `json:"description" yaml:"description"`
+}
+
+// Writable converts a full Secret struct into a SecretPut struct (filters read-only fields).
+func (s *Secret) Writable() SecretPut {
+	return SecretPut{Description: s.Description}
+}
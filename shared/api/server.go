@@ -94,6 +94,18 @@ type ServerEnvironment struct {
 	// Example: 1453969
 	ServerPid int `json:"server_pid" yaml:"server_pid"`
 
+	// Whether LXD runs as an unprivileged user (with reduced functionality)
+	// Example: false
+	//
+	// API extension: server_rootless
+	ServerRootless bool `json:"server_rootless" yaml:"server_rootless"`
+
+	// List of the functionality which isn't available when running rootless
+	// Example: ["privileged_containers", "virtual_machines"]
+	//
+	// API extension: server_rootless
+	ServerRootlessRestrictions []string `json:"server_rootless_restrictions" yaml:"server_rootless_restrictions"`
+
 	// Server version
 	// Example: 4.11
 	ServerVersion string `json:"server_version" yaml:"server_version"`
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"time"
+)
+
+// Task represents a recurring background task of the server.
+//
+// swagger:model
+//
+// API extension: tasks
+type Task struct {
+	// Name of the task
+	// Example: prune-images
+	Name string `json:"name" yaml:"name"`
+
+	// Description of the task
+	// Example: Remove expired cached images
+	Description string `json:"description" yaml:"description"`
+
+	// Status of the task (idle, running or paused)
+	// Example: idle
+	Status string `json:"status" yaml:"status"`
+
+	// When the task was last started
+	// Example: 2021-03-23T17:38:37.753398689-04:00
+	LastRunAt time.Time `json:"last_run_at" yaml:"last_run_at"`
+
+	// How long the last completed run of the task took, in seconds
+	// Example: 0.25
+	LastDuration float64 `json:"last_duration" yaml:"last_duration"`
+
+	// When the task is next scheduled to run (unset if not scheduled)
+	// Example: 2021-03-24T17:38:37.753398689-04:00
+	NextRunAt time.Time `json:"next_run_at" yaml:"next_run_at"`
+
+	// What cluster member this task runs on
+	// Example: lxd01
+	Location string `json:"location" yaml:"location"`
+}
+
+// TaskStatePut represents an action to apply to a task.
+//
+// swagger:model
+//
+// API extension: tasks
+type TaskStatePut struct {
+	// Action to apply (run, pause or resume)
+	// Example: run
+	Action string `json:"action" yaml:"action"`
+}
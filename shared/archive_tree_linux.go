@@ -0,0 +1,341 @@
+//go:build linux
+// +build linux
+
+package shared
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/lxc/lxd/shared/logger"
+)
+
+// TarTree writes the file tree found at path to w as a tar stream.
+// Entries are named relative to the parent of path, so that the tree keeps its base name once extracted.
+// Ownership, permissions, modification times, extended attributes, symlinks, hard links and device nodes
+// are preserved. The optional idShift function maps the ownership found on disk to the one stored in the
+// stream, entries it can't map (-1) are skipped.
+func TarTree(w io.Writer, path string, idShift func(uid int64, gid int64) (int64, int64)) error {
+	path = filepath.Clean(path)
+	parent := filepath.Dir(path)
+
+	tw := tar.NewWriter(w)
+	linkMap := map[uint64]string{}
+
+	err := filepath.Walk(path, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// Sockets cannot be stored in tarballs, just skip them (consistent with tar).
+		if fi.Mode()&os.ModeSocket != 0 {
+			return nil
+		}
+
+		name, err := filepath.Rel(parent, p)
+		if err != nil {
+			return err
+		}
+
+		link := ""
+		if fi.Mode()&os.ModeSymlink != 0 {
+			link, err = os.Readlink(p)
+			if err != nil {
+				return fmt.Errorf("Failed to resolve symlink %q: %w", p, err)
+			}
+		}
+
+		hdr, err := tar.FileInfoHeader(fi, link)
+		if err != nil {
+			return fmt.Errorf("Failed to create tar header for %q: %w", p, err)
+		}
+
+		hdr.Name = name
+		if fi.IsDir() {
+			hdr.Name += "/"
+		}
+
+		// Only keep the numeric ownership.
+		hdr.Uname = ""
+		hdr.Gname = ""
+
+		stat, ok := fi.Sys().(*syscall.Stat_t)
+		if ok {
+			uid, gid := int64(stat.Uid), int64(stat.Gid)
+			if idShift != nil {
+				uid, gid = idShift(uid, gid)
+				if uid == -1 || gid == -1 {
+					return nil
+				}
+			}
+
+			hdr.Uid = int(uid)
+			hdr.Gid = int(gid)
+
+			// If it's a hardlink we've already seen use the old name.
+			if fi.Mode().IsRegular() && stat.Nlink > 1 {
+				firstName, found := linkMap[stat.Ino]
+				if found {
+					hdr.Typeflag = tar.TypeLink
+					hdr.Linkname = firstName
+					hdr.Size = 0
+				} else {
+					linkMap[stat.Ino] = hdr.Name
+				}
+			}
+		}
+
+		// Handle xattrs (for real files only).
+		if link == "" {
+			xattrs, err := GetAllXattr(p)
+			if err != nil {
+				return fmt.Errorf("Failed to read xattrs of %q: %w", p, err)
+			}
+
+			hdr.PAXRecords = make(map[string]string, len(xattrs))
+			for key, val := range xattrs {
+				hdr.PAXRecords["SCHILY.xattr."+key] = val
+			}
+		}
+
+		err = tw.WriteHeader(hdr)
+		if err != nil {
+			return fmt.Errorf("Failed to write tar header for %q: %w", p, err)
+		}
+
+		if hdr.Typeflag == tar.TypeReg {
+			f, err := os.Open(p)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+
+			// Only write the size announced in the header even if the file grew.
+			_, err = io.Copy(tw, io.LimitReader(f, hdr.Size))
+			if err != nil {
+				return fmt.Errorf("Failed to copy content of %q: %w", p, err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return tw.Close()
+}
+
+// UntarTree extracts the tar stream read from r into the directory at path (created if missing).
+// Ownership, permissions, modification times and extended attributes are restored. Entries can't be
+// written outside of path, including through symlinks. The optional idShift function maps the ownership
+// stored in the stream to the one set on disk, failing on entries it can't map (-1).
+func UntarTree(r io.Reader, path string, idShift func(uid int64, gid int64) (int64, int64)) error {
+	path = filepath.Clean(path)
+
+	err := os.MkdirAll(path, 0755)
+	if err != nil {
+		return err
+	}
+
+	tr := tar.NewReader(r)
+	dirs := map[string]*tar.Header{}
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return fmt.Errorf("Failed to read tar header: %w", err)
+		}
+
+		target := filepath.Join(path, filepath.Clean("/"+hdr.Name))
+
+		err = untarTreeParents(path, target)
+		if err != nil {
+			return err
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			err = os.Mkdir(target, 0700)
+			if err != nil && !os.IsExist(err) {
+				return err
+			}
+
+			dirs[target] = hdr
+		case tar.TypeReg:
+			err = untarTreeRemove(target)
+			if err != nil {
+				return err
+			}
+
+			f, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+			if err != nil {
+				return err
+			}
+
+			_, err = io.Copy(f, tr)
+			f.Close()
+			if err != nil {
+				return fmt.Errorf("Failed to write %q: %w", target, err)
+			}
+		case tar.TypeSymlink:
+			err = untarTreeRemove(target)
+			if err != nil {
+				return err
+			}
+
+			err = os.Symlink(hdr.Linkname, target)
+			if err != nil {
+				return err
+			}
+		case tar.TypeLink:
+			err = untarTreeRemove(target)
+			if err != nil {
+				return err
+			}
+
+			err = os.Link(filepath.Join(path, filepath.Clean("/"+hdr.Linkname)), target)
+			if err != nil {
+				return err
+			}
+
+			// Hard links share the metadata of their first entry.
+			continue
+		case tar.TypeChar, tar.TypeBlock, tar.TypeFifo:
+			err = untarTreeRemove(target)
+			if err != nil {
+				return err
+			}
+
+			mode := uint32(unix.S_IFIFO)
+			if hdr.Typeflag == tar.TypeChar {
+				mode = unix.S_IFCHR
+			} else if hdr.Typeflag == tar.TypeBlock {
+				mode = unix.S_IFBLK
+			}
+
+			err = unix.Mknod(target, mode|0600, int(unix.Mkdev(uint32(hdr.Devmajor), uint32(hdr.Devminor))))
+			if err != nil {
+				return fmt.Errorf("Failed to create device %q: %w", target, err)
+			}
+		default:
+			logger.Debugf("Skipping unsupported tar entry %q of type %q", hdr.Name, hdr.Typeflag)
+			continue
+		}
+
+		uid, gid := int64(hdr.Uid), int64(hdr.Gid)
+		if idShift != nil {
+			uid, gid = idShift(uid, gid)
+			if uid == -1 || gid == -1 {
+				return fmt.Errorf("Unable to map ownership %d:%d of %q", hdr.Uid, hdr.Gid, hdr.Name)
+			}
+		}
+
+		err = os.Lchown(target, int(uid), int(gid))
+		if err != nil {
+			return err
+		}
+
+		if hdr.Typeflag == tar.TypeSymlink {
+			continue
+		}
+
+		for key, val := range hdr.PAXRecords {
+			if !strings.HasPrefix(key, "SCHILY.xattr.") {
+				continue
+			}
+
+			err = unix.Lsetxattr(target, strings.TrimPrefix(key, "SCHILY.xattr."), []byte(val), 0)
+			if err != nil {
+				logger.Debugf("Failed to set xattr %q on %q: %v", key, target, err)
+			}
+		}
+
+		// Set the mode after the ownership as changing the owner clears the setuid and setgid bits.
+		err = unix.Chmod(target, uint32(hdr.Mode&07777))
+		if err != nil {
+			return err
+		}
+
+		if hdr.Typeflag != tar.TypeDir {
+			err = os.Chtimes(target, hdr.AccessTime, hdr.ModTime)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// Restore the directory modification times once their content is written.
+	for target, hdr := range dirs {
+		err = os.Chtimes(target, hdr.AccessTime, hdr.ModTime)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// untarTreeParents creates the missing parent directories of target below root, refusing to go through
+// symlinks or other files.
+func untarTreeParents(root string, target string) error {
+	rel, err := filepath.Rel(root, filepath.Dir(target))
+	if err != nil {
+		return err
+	}
+
+	if rel == "." {
+		return nil
+	}
+
+	current := root
+	for _, component := range strings.Split(rel, string(filepath.Separator)) {
+		current = filepath.Join(current, component)
+
+		fi, err := os.Lstat(current)
+		if os.IsNotExist(err) {
+			err = os.Mkdir(current, 0755)
+			if err != nil {
+				return err
+			}
+
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		if !fi.IsDir() {
+			return fmt.Errorf("Refusing to extract %q through non-directory %q", target, current)
+		}
+	}
+
+	return nil
+}
+
+// untarTreeRemove removes the existing file at target, if any, so that it can be replaced.
+// Directories are left alone.
+func untarTreeRemove(target string) error {
+	fi, err := os.Lstat(target)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if fi.IsDir() {
+		return fmt.Errorf("Refusing to replace directory %q", target)
+	}
+
+	return os.Remove(target)
+}
@@ -0,0 +1,86 @@
+package shared
+
+import (
+	"archive/tar"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+func TestTarTreeRoundTrip(t *testing.T) {
+	src, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(src)
+
+	dst, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(dst)
+
+	tree := filepath.Join(src, "tree")
+	require.NoError(t, os.MkdirAll(filepath.Join(tree, "sub"), 0750))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(tree, "sub", "file"), []byte("hello"), 0640))
+	require.NoError(t, os.Link(filepath.Join(tree, "sub", "file"), filepath.Join(tree, "hardlink")))
+	require.NoError(t, os.Symlink("sub/file", filepath.Join(tree, "symlink")))
+
+	hasXattr := unix.Setxattr(filepath.Join(tree, "sub", "file"), "user.test", []byte("value"), 0) == nil
+
+	buf := bytes.Buffer{}
+	require.NoError(t, TarTree(&buf, tree, nil))
+	require.NoError(t, UntarTree(&buf, dst, nil))
+
+	content, err := ioutil.ReadFile(filepath.Join(dst, "tree", "sub", "file"))
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(content))
+
+	fi, err := os.Stat(filepath.Join(dst, "tree", "sub", "file"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0640), fi.Mode().Perm())
+
+	fi, err = os.Stat(filepath.Join(dst, "tree", "sub"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0750), fi.Mode().Perm())
+
+	link, err := os.Readlink(filepath.Join(dst, "tree", "symlink"))
+	require.NoError(t, err)
+	assert.Equal(t, "sub/file", link)
+
+	fi1, err := os.Stat(filepath.Join(dst, "tree", "sub", "file"))
+	require.NoError(t, err)
+	fi2, err := os.Stat(filepath.Join(dst, "tree", "hardlink"))
+	require.NoError(t, err)
+	assert.True(t, os.SameFile(fi1, fi2))
+
+	if hasXattr {
+		xattrs, err := GetAllXattr(filepath.Join(dst, "tree", "sub", "file"))
+		require.NoError(t, err)
+		assert.Equal(t, "value", xattrs["user.test"])
+	}
+}
+
+func TestUntarTreeEscape(t *testing.T) {
+	outside, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(outside)
+
+	dst, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(dst)
+
+	// A symlink pointing outside of the target must not be followed by later entries.
+	buf := bytes.Buffer{}
+	tw := tar.NewWriter(&buf)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Typeflag: tar.TypeSymlink, Name: "escape", Linkname: outside, Mode: 0777}))
+	require.NoError(t, tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: "escape/file", Mode: 0644, Size: 3}))
+	_, err = tw.Write([]byte("bad"))
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+
+	assert.Error(t, UntarTree(&buf, dst, nil))
+	assert.False(t, PathExists(filepath.Join(outside, "file")))
+}
@@ -814,6 +814,59 @@ func DefaultIdmapSet(rootfs string, username string) (*IdmapSet, error) {
 	return kernelDefaultMap()
 }
 
+// UserNSIdmapSet returns the map to allocate container ids from when LXD runs as root of a user namespace
+// owned by an unprivileged user (rootless). It's built from the ids mapped into the namespace, as
+// /etc/subuid and /etc/subgid list ids of the host which aren't usable from within it.
+func UserNSIdmapSet() (*IdmapSet, error) {
+	kernelMap, err := CurrentIdmapSet()
+	if err != nil {
+		return nil, err
+	}
+
+	return userNSIdmapSet(kernelMap)
+}
+
+// userNSIdmapSet returns the largest uid and gid ranges of the kernel map, excluding root which is mapped
+// to the user owning the namespace.
+func userNSIdmapSet(kernelMap *IdmapSet) (*IdmapSet, error) {
+	kernelRanges, err := kernelMap.ValidRanges()
+	if err != nil {
+		return nil, err
+	}
+
+	idmapset := new(IdmapSet)
+	for _, isuid := range []bool{true, false} {
+		var best *IdRange
+		for _, entry := range kernelRanges {
+			if entry.Isuid != isuid {
+				continue
+			}
+
+			start := entry.Startid
+			if start < 1 {
+				start = 1
+			}
+
+			if entry.Endid-start+1 < 65536 {
+				continue
+			}
+
+			if best == nil || entry.Endid-start > best.Endid-best.Startid {
+				best = &IdRange{Isuid: entry.Isuid, Isgid: entry.Isgid, Startid: start, Endid: entry.Endid}
+			}
+		}
+
+		if best == nil {
+			return nil, fmt.Errorf("No range of at least 65536 ids is mapped into the user namespace")
+		}
+
+		e := IdmapEntry{Isuid: best.Isuid, Isgid: best.Isgid, Nsid: 0, Hostid: best.Startid, Maprange: best.Endid - best.Startid + 1}
+		idmapset.Idmap = Extend(idmapset.Idmap, e)
+	}
+
+	return idmapset, nil
+}
+
 func kernelDefaultMap() (*IdmapSet, error) {
 	idmapset := new(IdmapSet)
 
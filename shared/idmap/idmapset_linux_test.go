@@ -108,3 +108,41 @@ func TestIdmapSetIntersects(t *testing.T) {
 		return
 	}
 }
+
+func TestUserNSIdmapSet(t *testing.T) {
+	kernelMap := IdmapSet{Idmap: []IdmapEntry{
+		{Isuid: true, Hostid: 1000, Nsid: 0, Maprange: 1},
+		{Isuid: true, Hostid: 100000, Nsid: 1, Maprange: 65536},
+		{Isgid: true, Hostid: 1000, Nsid: 0, Maprange: 1},
+		{Isgid: true, Hostid: 100000, Nsid: 1, Maprange: 10},
+		{Isgid: true, Hostid: 200000, Nsid: 100, Maprange: 100000},
+	}}
+
+	idmapset, err := userNSIdmapSet(&kernelMap)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if len(idmapset.Idmap) != 2 {
+		t.Error(fmt.Errorf("bad number of entries: %v", idmapset.Idmap))
+		return
+	}
+
+	if !idmapset.Idmap[0].Isuid || idmapset.Idmap[0].Nsid != 0 || idmapset.Idmap[0].Hostid != 1 || idmapset.Idmap[0].Maprange != 65536 {
+		t.Error(fmt.Errorf("bad uid range: %v", idmapset.Idmap[0]))
+		return
+	}
+
+	if !idmapset.Idmap[1].Isgid || idmapset.Idmap[1].Nsid != 0 || idmapset.Idmap[1].Hostid != 100 || idmapset.Idmap[1].Maprange != 100000 {
+		t.Error(fmt.Errorf("bad gid range: %v", idmapset.Idmap[1]))
+		return
+	}
+
+	kernelMap.Idmap[1].Maprange = 1000
+	_, err = userNSIdmapSet(&kernelMap)
+	if err == nil {
+		t.Error("too small uid range accepted")
+		return
+	}
+}
@@ -82,9 +82,40 @@ var InstanceConfigKeysAny = map[string]func(value string) error{
 	"boot.autostart.priority":    validate.Optional(validate.IsInt64),
 	"boot.stop.priority":         validate.Optional(validate.IsInt64),
 	"boot.host_shutdown_timeout": validate.Optional(validate.IsInt64),
+	"boot.depends_on": func(value string) error {
+		for _, name := range strings.Split(value, ",") {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+
+			if strings.Contains(name, SnapshotDelimiter) {
+				return fmt.Errorf("Invalid instance name %q", name)
+			}
+		}
+
+		return nil
+	},
+	"boot.autorestart":             validate.Optional(validate.IsBool),
+	"boot.autorestart.delay":       validate.Optional(validate.IsUint32),
+	"boot.autorestart.max_retries": validate.Optional(validate.IsUint32),
 
 	"cluster.evacuate": validate.Optional(validate.IsOneOf("auto", "migrate", "stop")),
 
+	"console.buffer.size": validate.Optional(validate.IsSize),
+
+	"healthcheck.address":  validate.IsAny,
+	"healthcheck.command":  validate.IsAny,
+	"healthcheck.interval": validate.Optional(validate.IsUint32),
+	"healthcheck.restart":  validate.Optional(validate.IsBool),
+	"healthcheck.retries":  validate.Optional(validate.IsUint32),
+	"healthcheck.timeout":  validate.Optional(validate.IsUint32),
+	"healthcheck.type":     validate.Optional(validate.IsOneOf("exec", "tcp", "http")),
+
+	"hooks.post-start": validate.Optional(validate.IsCommandOrWebhook),
+	"hooks.post-stop":  validate.Optional(validate.IsCommandOrWebhook),
+	"hooks.pre-start":  validate.Optional(validate.IsCommandOrWebhook),
+
 	"limits.cpu": func(value string) error {
 		if value == "" {
 			return nil
@@ -144,8 +175,12 @@ var InstanceConfigKeysAny = map[string]func(value string) error{
 	"raw.apparmor": validate.IsAny,
 
 	"security.devlxd":            validate.Optional(validate.IsBool),
+	"security.exec_recording":    validate.Optional(validate.IsBool),
 	"security.protection.delete": validate.Optional(validate.IsBool),
 
+	"schedule.start": validate.Optional(validate.IsCron([]string{"@hourly", "@daily", "@midnight", "@weekly", "@monthly", "@annually", "@yearly"})),
+	"schedule.stop":  validate.Optional(validate.IsCron([]string{"@hourly", "@daily", "@midnight", "@weekly", "@monthly", "@annually", "@yearly"})),
+
 	"snapshots.schedule":         validate.Optional(validate.IsCron([]string{"@hourly", "@daily", "@midnight", "@weekly", "@monthly", "@annually", "@yearly", "@startup"})),
 	"snapshots.schedule.stopped": validate.Optional(validate.IsBool),
 	"snapshots.pattern":          validate.IsAny,
@@ -158,13 +193,18 @@ var InstanceConfigKeysAny = map[string]func(value string) error{
 	// Volatile keys.
 	"volatile.apply_template":   validate.IsAny,
 	"volatile.base_image":       validate.IsAny,
+	"volatile.clone_source":     validate.IsAny,
+	"volatile.cpu.nodes":        validate.IsAny,
 	"volatile.evacuate.origin":  validate.IsAny,
+	"volatile.health":           validate.IsAny,
 	"volatile.last_state.idmap": validate.IsAny,
 	"volatile.last_state.power": validate.IsAny,
 	"volatile.idmap.base":       validate.IsAny,
 	"volatile.idmap.current":    validate.IsAny,
 	"volatile.idmap.next":       validate.IsAny,
+	"volatile.selinux.file":     validate.IsAny,
 	"volatile.apply_quota":      validate.IsAny,
+	"volatile.restart_count":    validate.Optional(validate.IsInt64),
 	"volatile.uuid":             validate.Optional(validate.IsUUID),
 	"volatile.vsock_id":         validate.Optional(validate.IsInt64),
 }
@@ -204,6 +244,18 @@ var InstanceConfigKeysContainer = map[string]func(value string) error{
 
 		return nil
 	},
+	"limits.cpu.nodes": func(value string) error {
+		if value == "" {
+			return nil
+		}
+
+		match, _ := regexp.MatchString("^[0-9]+(-[0-9]+)?(,[0-9]+(-[0-9]+)?)*$", value)
+		if !match {
+			return fmt.Errorf("Invalid NUMA node list syntax")
+		}
+
+		return nil
+	},
 	"limits.cpu.priority":   validate.Optional(validate.IsPriority),
 	"limits.hugepages.64KB": validate.Optional(validate.IsSize),
 	"limits.hugepages.1MB":  validate.Optional(validate.IsSize),
@@ -220,6 +272,7 @@ var InstanceConfigKeysContainer = map[string]func(value string) error{
 	"migration.incremental.memory":            validate.Optional(validate.IsBool),
 	"migration.incremental.memory.iterations": validate.Optional(validate.IsUint32),
 	"migration.incremental.memory.goal":       validate.Optional(validate.IsUint32),
+	"migration.incremental.memory.downtime":   validate.Optional(validate.IsUint32),
 
 	"nvidia.runtime":             validate.Optional(validate.IsBool),
 	"nvidia.driver.capabilities": validate.IsAny,
@@ -241,6 +294,10 @@ var InstanceConfigKeysContainer = map[string]func(value string) error{
 	"security.privileged":       validate.Optional(validate.IsBool),
 	"security.protection.shift": validate.Optional(validate.IsBool),
 
+	"security.selinux.context":         validate.Optional(validate.IsSELinuxContext),
+	"security.selinux.context.file":    validate.Optional(validate.IsSELinuxContext),
+	"security.selinux.context.keyring": validate.Optional(validate.IsSELinuxContext),
+
 	"security.syscalls.allow":                   validate.IsAny,
 	"security.syscalls.blacklist_default":       validate.Optional(validate.IsBool),
 	"security.syscalls.blacklist_compat":        validate.Optional(validate.IsBool),
@@ -256,11 +313,16 @@ var InstanceConfigKeysContainer = map[string]func(value string) error{
 	"security.syscalls.intercept.mount.fuse":    validate.IsAny,
 	"security.syscalls.intercept.mount.shift":   validate.Optional(validate.IsBool),
 	"security.syscalls.intercept.setxattr":      validate.Optional(validate.IsBool),
+	"security.syscalls.intercept.sysinfo":       validate.Optional(validate.IsBool),
 	"security.syscalls.whitelist":               validate.IsAny,
+
+	"time.offset.boot":      validate.Optional(validate.IsDuration),
+	"time.offset.monotonic": validate.Optional(validate.IsDuration),
 }
 
 // InstanceConfigKeysVM is a map of config key to validator. (keys applying to VM only)
 var InstanceConfigKeysVM = map[string]func(value string) error{
+	"limits.cpu.nested":       validate.Optional(validate.IsBool),
 	"limits.memory.hugepages": validate.Optional(validate.IsBool),
 
 	"migration.stateful": validate.Optional(validate.IsBool),
@@ -348,6 +410,11 @@ func ConfigKeyChecker(key string, instanceType instancetype.Type) (func(value st
 		return validate.IsAny, nil
 	}
 
+	// Caller is responsible for full validation of any raw.* value.
+	if strings.HasPrefix(key, "raw.apparmor.") && len(key) > len("raw.apparmor.") {
+		return validate.IsAny, nil
+	}
+
 	if strings.HasPrefix(key, "user.") {
 		return validate.IsAny, nil
 	}
@@ -13,4 +13,22 @@ type ProgressData struct {
 
 	// Total number of bytes (for files)
 	TotalBytes int64
+
+	// Transfer rate in bytes per second (for files)
+	Speed int64
+}
+
+// NewProgressData returns the progress data of a transfer as reported by a ProgressTracker handler.
+// When length is known, value is a percentage, otherwise it's the number of bytes transferred.
+func NewProgressData(text string, value int64, length int64, speed int64) ProgressData {
+	data := ProgressData{Text: text, Speed: speed}
+	if length > 0 {
+		data.Percentage = int(value)
+		data.TransferredBytes = length * value / 100
+		data.TotalBytes = length
+	} else {
+		data.TransferredBytes = value
+	}
+
+	return data
 }
@@ -2,6 +2,7 @@ package logging
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"reflect"
 	"sort"
@@ -81,6 +82,34 @@ func LogfmtFormat() log.Format {
 	})
 }
 
+// JSONFormat returns a formatter outputting each record as a JSON object on its own line.
+func JSONFormat() log.Format {
+	return log.FormatFunc(func(r *log.Record) []byte {
+		props := map[string]interface{}{
+			r.KeyNames.Time: r.Time.Format(timeFormat),
+			r.KeyNames.Lvl:  r.Lvl.String(),
+			r.KeyNames.Msg:  r.Msg,
+		}
+
+		for i := 0; i < len(r.Ctx); i += 2 {
+			k, ok := r.Ctx[i].(string)
+			if !ok {
+				props[errorKey] = fmt.Sprintf("%+v is not a string key", r.Ctx[i])
+				continue
+			}
+
+			props[k] = formatShared(r.Ctx[i+1])
+		}
+
+		b, err := json.Marshal(props)
+		if err != nil {
+			b, _ = json.Marshal(map[string]string{errorKey: err.Error()})
+		}
+
+		return append(b, '\n')
+	})
+}
+
 func logfmt(buf *bytes.Buffer, ctx []interface{}, color int, sorted bool) {
 	entries := []string{}
 
@@ -0,0 +1,107 @@
+package logging
+
+import (
+	"runtime"
+	"strings"
+	"sync"
+
+	log "github.com/lxc/lxd/shared/log15"
+)
+
+// levelOverride sets the log level of the messages logged from source files whose path contains any of
+// the fragments.
+type levelOverride struct {
+	fragments []string
+	level     log.Lvl
+}
+
+var levelOverrides struct {
+	mu        sync.RWMutex
+	overrides map[string]levelOverride
+}
+
+// SetSubsystemLevel sets the log level of a subsystem, overriding the level of the log handlers for the
+// messages logged from the source files whose path contains any of the given fragments (such as
+// "/lxd/storage/").
+func SetSubsystemLevel(subsystem string, fragments []string, level log.Lvl) {
+	levelOverrides.mu.Lock()
+	defer levelOverrides.mu.Unlock()
+
+	if levelOverrides.overrides == nil {
+		levelOverrides.overrides = map[string]levelOverride{}
+	}
+
+	levelOverrides.overrides[subsystem] = levelOverride{fragments: fragments, level: level}
+}
+
+// ResetSubsystemLevel removes the log level override of a subsystem.
+func ResetSubsystemLevel(subsystem string) {
+	levelOverrides.mu.Lock()
+	defer levelOverrides.mu.Unlock()
+
+	delete(levelOverrides.overrides, subsystem)
+}
+
+// levelAllowed returns whether a record at the given level passes a handler filtering at the handler level,
+// taking the overridden log level of the subsystem the record is logged from into account.
+func levelAllowed(lvl log.Lvl, handlerLevel log.Lvl) bool {
+	levelOverrides.mu.RLock()
+	defer levelOverrides.mu.RUnlock()
+
+	allowed := lvl <= handlerLevel
+
+	// Walking the call stack to find the caller is costly, only do it when an override would change the
+	// outcome for the record.
+	needCaller := false
+	for _, override := range levelOverrides.overrides {
+		if (lvl <= override.level) != allowed {
+			needCaller = true
+			break
+		}
+	}
+
+	if !needCaller {
+		return allowed
+	}
+
+	file := callerFile()
+	if file == "" {
+		return allowed
+	}
+
+	for _, override := range levelOverrides.overrides {
+		for _, fragment := range override.fragments {
+			if strings.Contains(file, fragment) {
+				return lvl <= override.level
+			}
+		}
+	}
+
+	return allowed
+}
+
+// callerFile returns the source file of the first caller outside of the logging packages.
+func callerFile() string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	for {
+		frame, more := frames.Next()
+		if !strings.Contains(frame.File, "/shared/log15/") && !strings.Contains(frame.File, "/shared/logger/") && !strings.Contains(frame.File, "/shared/logging/") {
+			return frame.File
+		}
+
+		if !more {
+			return ""
+		}
+	}
+}
+
+// levelFilterHandler returns a handler which only passes the records at or above the given level, unless
+// the level is overridden for the subsystem the record is logged from.
+func levelFilterHandler(level log.Lvl, h log.Handler) log.Handler {
+	return log.FilterHandler(func(r *log.Record) bool {
+		return levelAllowed(r.Lvl, level)
+	}, h)
+}
@@ -14,13 +14,33 @@ import (
 
 // GetLogger returns a logger suitable for using as logger.Log.
 func GetLogger(syslog string, logfile string, verbose bool, debug bool, customHandler log.Handler) (logger.Logger, error) {
+	return getLogger(syslog, logfile, verbose, debug, customHandler, false)
+}
+
+// GetJSONLogger returns a logger suitable for using as logger.Log which outputs JSON encoded records.
+func GetJSONLogger(syslog string, logfile string, verbose bool, debug bool, customHandler log.Handler) (logger.Logger, error) {
+	return getLogger(syslog, logfile, verbose, debug, customHandler, true)
+}
+
+func getLogger(syslog string, logfile string, verbose bool, debug bool, customHandler log.Handler, json bool) (logger.Logger, error) {
 	Log := log.New()
 
 	var handlers []log.Handler
 	var syshandler log.Handler
 
+	format := LogfmtFormat()
+	if json {
+		format = JSONFormat()
+	}
+
+	// Level of the messages to output, unless overridden for a subsystem.
+	level := log.LvlInfo
+	if debug {
+		level = log.LvlDebug
+	}
+
 	// System specific handler
-	syshandler = getSystemHandler(syslog, debug, LogfmtFormat())
+	syshandler = getSystemHandler(syslog, level, format)
 	if syshandler != nil {
 		handlers = append(handlers, syshandler)
 	}
@@ -31,47 +51,22 @@ func GetLogger(syslog string, logfile string, verbose bool, debug bool, customHa
 			return nil, fmt.Errorf("Log file path doesn't exist: %s", filepath.Dir(logfile))
 		}
 
-		if !debug {
-			handlers = append(
-				handlers,
-				log.LvlFilterHandler(
-					log.LvlInfo,
-					log.Must.FileHandler(logfile, LogfmtFormat()),
-				),
-			)
-		} else {
-			handlers = append(handlers, log.Must.FileHandler(logfile, LogfmtFormat()))
-		}
+		handlers = append(handlers, levelFilterHandler(level, log.Must.FileHandler(logfile, format)))
 	}
 
 	// StderrHandler
-	format := LogfmtFormat()
-	if term.IsTty(os.Stderr.Fd()) {
-		format = TerminalFormat()
+	stderrFormat := format
+	if !json && term.IsTty(os.Stderr.Fd()) {
+		stderrFormat = TerminalFormat()
 	}
 
-	if verbose || debug {
-		if !debug {
-			handlers = append(
-				handlers,
-				log.LvlFilterHandler(
-					log.LvlInfo,
-					log.StreamHandler(os.Stderr, format),
-				),
-			)
-		} else {
-			handlers = append(handlers, log.StreamHandler(os.Stderr, format))
-		}
-	} else {
-		handlers = append(
-			handlers,
-			log.LvlFilterHandler(
-				log.LvlWarn,
-				log.StreamHandler(os.Stderr, format),
-			),
-		)
+	stderrLevel := level
+	if !verbose && !debug {
+		stderrLevel = log.LvlWarn
 	}
 
+	handlers = append(handlers, levelFilterHandler(stderrLevel, log.StreamHandler(os.Stderr, stderrFormat)))
+
 	if customHandler != nil {
 		handlers = append(handlers, customHandler)
 	}
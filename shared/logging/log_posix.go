@@ -8,17 +8,10 @@ import (
 )
 
 // getSystemHandler on Linux writes messages to syslog.
-func getSystemHandler(syslog string, debug bool, format log.Format) log.Handler {
+func getSystemHandler(syslog string, level log.Lvl, format log.Format) log.Handler {
 	// SyslogHandler
 	if syslog != "" {
-		if !debug {
-			return log.LvlFilterHandler(
-				log.LvlInfo,
-				log.Must.SyslogHandler(syslog, format),
-			)
-		}
-
-		return log.Must.SyslogHandler(syslog, format)
+		return levelFilterHandler(level, log.Must.SyslogHandler(syslog, format))
 	}
 
 	return nil
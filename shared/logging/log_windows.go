@@ -8,6 +8,6 @@ import (
 )
 
 // getSystemHandler on Windows does nothing.
-func getSystemHandler(syslog string, debug bool, format log.Format) log.Handler {
+func getSystemHandler(syslog string, level log.Lvl, format log.Format) log.Handler {
 	return nil
 }
@@ -34,6 +34,7 @@ import (
 const SnapshotDelimiter = "/"
 const HTTPSDefaultPort = 8443
 const HTTPDefaultPort = 8080
+const HTTPSMetricsDefaultPort = 9100
 
 // URLEncode encodes a path and query parameters to a URL.
 func URLEncode(path string, query map[string]string) (string, error) {
@@ -1005,7 +1006,9 @@ func EscapePathFstab(path string) string {
 	return r.Replace(path)
 }
 
-func SetProgressMetadata(metadata map[string]interface{}, stage, displayPrefix string, percent, processed, speed int64) {
+// SetProgressMetadata records the progress of an operation stage in its metadata, both as a "progress" map
+// for API callers (stage, percent, processed, total and speed) and as formatted text for older clients.
+func SetProgressMetadata(metadata map[string]interface{}, stage, displayPrefix string, percent, processed, total, speed int64) {
 	progress := make(map[string]string)
 	// stage, percent, speed sent for API callers.
 	progress["stage"] = stage
@@ -1013,6 +1016,10 @@ func SetProgressMetadata(metadata map[string]interface{}, stage, displayPrefix s
 		progress["processed"] = strconv.FormatInt(processed, 10)
 	}
 
+	if total > 0 {
+		progress["total"] = strconv.FormatInt(total, 10)
+	}
+
 	if percent > 0 {
 		progress["percent"] = strconv.FormatInt(percent, 10)
 	}
@@ -1021,13 +1028,20 @@ func SetProgressMetadata(metadata map[string]interface{}, stage, displayPrefix s
 	metadata["progress"] = progress
 
 	// <stage>_progress with formatted text sent for lxc cli.
+	var text string
 	if percent > 0 {
-		metadata[stage+"_progress"] = fmt.Sprintf("%s: %d%% (%s/s)", displayPrefix, percent, units.GetByteSizeString(speed, 2))
+		text = fmt.Sprintf("%d%% (%s/s)", percent, units.GetByteSizeString(speed, 2))
 	} else if processed > 0 {
-		metadata[stage+"_progress"] = fmt.Sprintf("%s: %s (%s/s)", displayPrefix, units.GetByteSizeString(processed, 2), units.GetByteSizeString(speed, 2))
+		text = fmt.Sprintf("%s (%s/s)", units.GetByteSizeString(processed, 2), units.GetByteSizeString(speed, 2))
 	} else {
-		metadata[stage+"_progress"] = fmt.Sprintf("%s: %s/s", displayPrefix, units.GetByteSizeString(speed, 2))
+		text = fmt.Sprintf("%s/s", units.GetByteSizeString(speed, 2))
 	}
+
+	if displayPrefix != "" {
+		text = fmt.Sprintf("%s: %s", displayPrefix, text)
+	}
+
+	metadata[stage+"_progress"] = text
 }
 
 func DownloadFileHash(httpClient *http.Client, useragent string, progress func(progress ioprogress.ProgressData), canceler *cancel.Canceler, filename string, url string, hash string, hashFunc hash.Hash, target io.WriteSeeker) (int64, error) {
@@ -1065,9 +1079,9 @@ func DownloadFileHash(httpClient *http.Client, useragent string, progress func(p
 				Length: r.ContentLength,
 				Handler: func(percent int64, speed int64) {
 					if filename != "" {
-						progress(ioprogress.ProgressData{Text: fmt.Sprintf("%s: %d%% (%s/s)", filename, percent, units.GetByteSizeString(speed, 2))})
+						progress(ioprogress.NewProgressData(fmt.Sprintf("%s: %d%% (%s/s)", filename, percent, units.GetByteSizeString(speed, 2)), percent, r.ContentLength, speed))
 					} else {
-						progress(ioprogress.ProgressData{Text: fmt.Sprintf("%d%% (%s/s)", percent, units.GetByteSizeString(speed, 2))})
+						progress(ioprogress.NewProgressData(fmt.Sprintf("%d%% (%s/s)", percent, units.GetByteSizeString(speed, 2)), percent, r.ContentLength, speed))
 					}
 				},
 			},
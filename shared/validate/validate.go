@@ -4,10 +4,13 @@ import (
 	"bytes"
 	"fmt"
 	"net"
+	"net/url"
 	"os/exec"
+	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/kballard/go-shellquote"
 	"github.com/pborman/uuid"
@@ -586,6 +589,48 @@ func IsUUID(value string) error {
 	return nil
 }
 
+// IsCommandOrWebhook validates whether a value is an absolute path to a command or a HTTP(S) URL.
+func IsCommandOrWebhook(value string) error {
+	if strings.HasPrefix(value, "http://") || strings.HasPrefix(value, "https://") {
+		u, err := url.ParseRequestURI(value)
+		if err != nil || u.Host == "" {
+			return fmt.Errorf("Invalid URL %q", value)
+		}
+
+		return nil
+	}
+
+	if !filepath.IsAbs(value) {
+		return fmt.Errorf("Must be an absolute path or a HTTP(S) URL")
+	}
+
+	return nil
+}
+
+// IsDuration validates whether a value is a duration such as "-1h30m" (as parsed by time.ParseDuration).
+func IsDuration(value string) error {
+	_, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf("Invalid duration %q", value)
+	}
+
+	return nil
+}
+
+// IsSELinuxContext validates whether a value is a SELinux context (user:role:type[:level]).
+func IsSELinuxContext(value string) error {
+	regexContext, err := regexp.Compile(`^[a-zA-Z0-9_.]+:[a-zA-Z0-9_.]+:[a-zA-Z0-9_.]+(:[a-zA-Z0-9_.:,-]+)?$`)
+	if err != nil {
+		return err
+	}
+
+	if !regexContext.MatchString(value) {
+		return fmt.Errorf("Invalid SELinux context")
+	}
+
+	return nil
+}
+
 // IsPCIAddress validates whether a value is a PCI address.
 func IsPCIAddress(value string) error {
 	regexHex, err := regexp.Compile(`^([0-9a-fA-F]{4}?:)?[0-9a-fA-F]{2}:[0-9a-fA-F]{2}\.[0-9a-fA-F]$`)
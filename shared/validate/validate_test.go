@@ -103,3 +103,72 @@ func ExampleRequired() {
 	// <nil> Invalid value for a boolean "foo"
 	// <nil> <nil>
 }
+
+func ExampleIsSELinuxContext() {
+	tests := []string{
+		"system_u:system_r:container_t:s0",             // valid
+		"system_u:system_r:container_t:s0:c1,c2",       // valid
+		"system_u:system_r:container_t:s0-s0:c0.c1023", // valid
+		"system_u:object_r:container_file_t",           // valid
+		"container_t",                                  // missing user and role
+		"system_u:system_r:container_t:s0 extra",       // invalid character
+		"",
+	}
+
+	for _, v := range tests {
+		err := validate.IsSELinuxContext(v)
+		fmt.Printf("%s, %t\n", v, err == nil)
+	}
+
+	// Output: system_u:system_r:container_t:s0, true
+	// system_u:system_r:container_t:s0:c1,c2, true
+	// system_u:system_r:container_t:s0-s0:c0.c1023, true
+	// system_u:object_r:container_file_t, true
+	// container_t, false
+	// system_u:system_r:container_t:s0 extra, false
+	// , false
+}
+
+func ExampleIsCommandOrWebhook() {
+	tests := []string{
+		"/usr/local/bin/ipam-hook",      // valid
+		"https://ipam.example.net/hook", // valid
+		"http://10.0.0.1:8080/",         // valid
+		"ipam-hook",                     // relative path
+		"https://",                      // missing host
+		"ftp://example.net/hook",        // unsupported scheme
+	}
+
+	for _, v := range tests {
+		err := validate.IsCommandOrWebhook(v)
+		fmt.Printf("%s, %t\n", v, err == nil)
+	}
+
+	// Output: /usr/local/bin/ipam-hook, true
+	// https://ipam.example.net/hook, true
+	// http://10.0.0.1:8080/, true
+	// ipam-hook, false
+	// https://, false
+	// ftp://example.net/hook, false
+}
+
+func ExampleIsDuration() {
+	tests := []string{
+		"1h",      // valid
+		"-24h30m", // valid
+		"90s",     // valid
+		"1d",      // invalid unit
+		"10",      // missing unit
+	}
+
+	for _, v := range tests {
+		err := validate.IsDuration(v)
+		fmt.Printf("%s, %t\n", v, err == nil)
+	}
+
+	// Output: 1h, true
+	// -24h30m, true
+	// 90s, true
+	// 1d, false
+	// 10, false
+}
@@ -284,6 +284,87 @@ var APIExtensions = []string{
 	"network_forward",
 	"custom_volume_refresh",
 	"network_counters_errors_dropped",
+	"instance_nic_routed_host_routes",
+	"projects_networks_restricted_subnets_exclusive",
+	"network_leases_instance",
+	"network_bridge_auto_mtu",
+	"instance_nic_routed_bgp",
+	"metrics",
+	"events_history",
+	"event_filters",
+	"audit_log",
+	"operations_history",
+	"instance_migration_cancel",
+	"operations_heavy_limit",
+	"log_levels",
+	"log_shipping",
+	"operation_wait_status",
+	"certificate_project_roles",
+	"auth_tokens",
+	"oidc",
+	"certificate_token",
+	"trust_ca_revocation",
+	"api_rate_limits",
+	"secrets",
+	"instance_apparmor_policies",
+	"container_syscall_intercept_sysinfo",
+	"instance_selinux",
+	"idmap_allocations",
+	"projects_restricted_unix_allowlist",
+	"instances_state_bulk",
+	"instances_rebuild",
+	"instances_clone",
+	"instances_boot_depends_on",
+	"instances_autorestart",
+	"instances_healthcheck",
+	"vm_device_hotplug",
+	"vm_cpu_memory_hotplug",
+	"instances_usage_history",
+	"instances_exec_recording",
+	"instances_console_buffer",
+	"instance_templates",
+	"instances_convert",
+	"instances_migration_pool_map",
+	"migration_precopy_downtime",
+	"instances_schedule",
+	"disk_io_limits_live",
+	"usb_hotplug_serial",
+	"proxy_vm_non_nat",
+	"instances_lifecycle_hooks",
+	"disk_vm_virtiofs_idmap",
+	"instances_nvram",
+	"pci_device_iommu_containers",
+	"vm_cloud_init_seed",
+	"vm_nesting",
+	"instance_time_namespace",
+	"storage_volumes_idmapped_mounts",
+	"instance_numa_nodes",
+	"instance_nic_bandwidth",
+	"api_pagination",
+	"api_fields",
+	"api_filtering_extended",
+	"api_batch",
+	"event_sequence",
+	"api_error_types",
+	"instance_file_tree",
+	"instance_session_observers",
+	"instances_metrics",
+	"instance_file_manifest",
+	"instance_exec_options",
+	"server_preseed",
+	"instance_project_move",
+	"operation_progress",
+	"network_load_balancer",
+	"instances_shutdown_action",
+	"resources_pci_topology",
+	"server_rootless",
+	"https_listeners",
+	"tasks",
+	"event_lifecycle_context",
+	"migration_type_negotiation",
+	"zfs_refresh_incremental",
+	"projects_networks_restricted_allocations",
+	"project_export",
 }
 
 // APIExtensionsCount returns the number of available API extensions.
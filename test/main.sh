@@ -158,6 +158,9 @@ run_test test_database_restore "database restore"
 run_test test_database_no_disk_space "database out of disk space"
 run_test test_sql "lxd sql"
 run_test test_tls_restrictions "TLS restrictions"
+run_test test_auth_tokens "API tokens"
+run_test test_secrets "secrets"
+run_test test_instance_templates "instance templates"
 run_test test_basic_usage "basic usage"
 run_test test_remote_url "remote url handling"
 run_test test_remote_admin "remote administration"
@@ -192,6 +195,7 @@ run_test test_projects_crud "projects CRUD operations"
 run_test test_projects_containers "containers inside projects"
 run_test test_projects_snapshots "snapshots inside projects"
 run_test test_projects_backups "backups inside projects"
+run_test test_projects_import "project export import"
 run_test test_projects_profiles "profiles inside projects"
 run_test test_projects_profiles_default "profiles from the global default project"
 run_test test_projects_images "images inside projects"
@@ -237,6 +241,14 @@ run_test test_config_edit "container configuration edit"
 run_test test_config_edit_container_snapshot_pool_config "container and snapshot volume configuration edit"
 run_test test_container_metadata "manage container metadata and templates"
 run_test test_container_snapshot_config "container snapshot configuration"
+run_test test_instance_rebuild "instance rebuild"
+run_test test_instance_convert "instance conversion"
+run_test test_instance_clone "instance clone"
+run_test test_instance_autorestart "instance automatic restart"
+run_test test_instance_healthcheck "instance health checks"
+run_test test_instance_hooks "instance lifecycle hooks"
+run_test test_instance_usage "instance resource usage history"
+run_test test_instance_exec_recording "instance exec session recording"
 run_test test_server_config "server configuration"
 run_test test_filemanip "file manipulations"
 run_test test_network "network management"
@@ -265,6 +277,9 @@ run_test test_kernel_limits "kernel limits"
 run_test test_macaroon_auth "macaroon authentication"
 run_test test_console "console"
 run_test test_query "query"
+run_test test_metrics "metrics"
+run_test test_events_history "events history"
+run_test test_audit "audit log"
 run_test test_storage_local_volume_handling "storage local volume handling"
 run_test test_backup_import "backup import"
 run_test test_backup_export "backup export"
@@ -0,0 +1,43 @@
+test_audit() {
+  ensure_import_testimage
+  ensure_has_localhost_remote "${LXD_ADDR}"
+
+  since="$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+  lxc init testimage c1
+  lxc config set c1 user.foo bar
+  lxc delete c1
+
+  # Check that the state-changing requests have been recorded along with their result.
+  lxc query "/1.0/audit?since=${since}" | jq -r '.[] | "\(.method) \(.url) \(.status_code)"' | grep -Fx "POST /1.0/instances 202"
+  lxc query "/1.0/audit?since=${since}" | jq -r '.[] | "\(.method) \(.url) \(.status_code)"' | grep -Fx "DELETE /1.0/instances/c1 202"
+  lxc query "/1.0/audit?since=${since}" | jq -r '.[].body_hash' | grep -E '^[0-9a-f]{64}$'
+
+  # Check that the operations started by the requests are recorded along with their final status.
+  lxc query "/1.0/audit?since=${since}" | jq -r '.[] | select(.method == "DELETE" and .operation_status == "") | .operation' | grep -E '^[0-9a-f-]{36}$'
+  lxc query "/1.0/audit?since=${since}" | jq -r '.[] | "\(.method) \(.url) \(.operation_status)"' | grep -Fx "DELETE /1.0/instances/c1 Success"
+
+  # Check that reads aren't recorded.
+  ! lxc query "/1.0/audit?since=${since}" | jq -r '.[].method' | grep -Fx "GET" || false
+
+  # Check that the raw log can be exported.
+  curl -k -s --cert "${LXD_CONF}/client.crt" --key "${LXD_CONF}/client.key" "https://${LXD_ADDR}/1.0/audit/export" | grep -F '"url":"/1.0/instances/c1"'
+
+  # Check that the log is rotated, keeping the configured number of rotated files.
+  lxc config set core.audit_log_max_size 1KiB
+  lxc config set core.audit_log_max_files 2
+  for i in $(seq 10); do
+    lxc profile create "p${i}"
+    lxc profile delete "p${i}"
+  done
+
+  [ -e "${LXD_DIR}/logs/audit.log.1" ]
+  [ -e "${LXD_DIR}/logs/audit.log.2" ]
+  [ ! -e "${LXD_DIR}/logs/audit.log.3" ]
+  lxc query "/1.0/audit?since=${since}" | jq -r '.[] | "\(.method) \(.url) \(.status_code)"' | grep -Fx "DELETE /1.0/profiles/p10 200"
+
+  lxc config set core.audit_log_max_files 0
+  [ ! -e "${LXD_DIR}/logs/audit.log.1" ]
+
+  lxc config unset core.audit_log_max_size
+  lxc config unset core.audit_log_max_files
+}
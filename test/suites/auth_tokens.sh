@@ -0,0 +1,71 @@
+test_auth_tokens() {
+  # Create an unrestricted token and a token restricted to a project.
+  lxc project create blah
+  secret="$(lxc query -X POST -d '{"name": "ci", "description": "CI system"}' /1.0/auth/tokens | jq -r .secret)"
+  restricted_secret="$(lxc query -X POST -d '{"name": "ci-blah", "restricted": true, "projects": ["blah"]}' /1.0/auth/tokens | jq -r .secret)"
+  viewer_secret="$(lxc query -X POST -d '{"name": "viewer-blah", "restricted": true, "projects": ["blah"], "project_roles": {"blah": "viewer"}}' /1.0/auth/tokens | jq -r .secret)"
+
+  # Roles must be valid and given on allowed projects.
+  ! lxc query -X POST -d '{"name": "bad", "restricted": true, "projects": ["blah"], "project_roles": {"blah": "owner"}}' /1.0/auth/tokens || false
+  ! lxc query -X POST -d '{"name": "bad", "restricted": true, "projects": ["blah"], "project_roles": {"default": "viewer"}}' /1.0/auth/tokens || false
+  [ "$(lxc query /1.0/auth/tokens/ci-blah | jq -r '.project_roles.blah')" = "admin" ]
+  [ "$(lxc query /1.0/auth/tokens/viewer-blah | jq -r '.project_roles.blah')" = "viewer" ]
+
+  # Duplicate names are rejected.
+  ! lxc query -X POST -d '{"name": "ci"}' /1.0/auth/tokens || false
+
+  # Tokens can be listed but their secret isn't returned.
+  lxc query /1.0/auth/tokens | jq -r '.[]' | grep -Fx "/1.0/auth/tokens/ci"
+  [ "$(lxc query /1.0/auth/tokens/ci | jq -r .description)" = "CI system" ]
+  ! lxc query /1.0/auth/tokens/ci | grep -F "${secret}" || false
+
+  # PATCH only changes the provided fields while PUT replaces them all.
+  lxc query -X PATCH -d '{}' /1.0/auth/tokens/ci
+  [ "$(lxc query /1.0/auth/tokens/ci | jq -r .description)" = "CI system" ]
+  lxc query -X PATCH -d '{"description": "CI runners"}' /1.0/auth/tokens/ci
+  [ "$(lxc query /1.0/auth/tokens/ci | jq -r .description)" = "CI runners" ]
+  lxc query -X PUT -d '{}' /1.0/auth/tokens/ci
+  [ "$(lxc query /1.0/auth/tokens/ci | jq -r .description)" = "" ]
+
+  # The token authenticates the client.
+  [ "$(curl -k -s -H "Authorization: Bearer ${secret}" "https://${LXD_ADDR}/1.0" | jq -r .metadata.auth)" = "trusted" ]
+  curl -k -s -H "Authorization: Bearer ${secret}" "https://${LXD_ADDR}/1.0/projects" | jq -r '.metadata[]' | grep -Fx "/1.0/projects/default"
+
+  # The restricted token only sees its projects.
+  curl -k -s -H "Authorization: Bearer ${restricted_secret}" "https://${LXD_ADDR}/1.0/projects" | jq -r '.metadata[]' | grep -Fx "/1.0/projects/blah"
+  ! curl -k -s -H "Authorization: Bearer ${restricted_secret}" "https://${LXD_ADDR}/1.0/projects" | jq -r '.metadata[]' | grep -Fx "/1.0/projects/default" || false
+  [ "$(curl -k -s -H "Authorization: Bearer ${restricted_secret}" "https://${LXD_ADDR}/1.0/auth/tokens" | jq -r .error_code)" = "403" ]
+
+  # The restricted admin token can write to its project while the viewer token can only read it.
+  curl -k -s -X POST -H "Authorization: Bearer ${restricted_secret}" -d '{"name": "p1"}' "https://${LXD_ADDR}/1.0/profiles?project=blah" | jq -r .status_code | grep -Fx 200
+  curl -k -s -H "Authorization: Bearer ${viewer_secret}" "https://${LXD_ADDR}/1.0/profiles?project=blah" | jq -r '.metadata[]' | grep -F "/1.0/profiles/p1"
+  [ "$(curl -k -s -X POST -H "Authorization: Bearer ${viewer_secret}" -d '{"name": "p2"}' "https://${LXD_ADDR}/1.0/profiles?project=blah" | jq -r .error_code)" = "403" ]
+  [ "$(curl -k -s -X DELETE -H "Authorization: Bearer ${viewer_secret}" "https://${LXD_ADDR}/1.0/profiles/p1?project=blah" | jq -r .error_code)" = "403" ]
+  lxc profile show p1 --project blah
+
+  # The restricted token can't add certificates without a password or token, nor create certificate add tokens.
+  [ "$(curl -k -s -X POST -H "Authorization: Bearer ${restricted_secret}" -d '{"type": "client"}' "https://${LXD_ADDR}/1.0/certificates" | jq -r .error_code)" = "403" ]
+  [ "$(curl -k -s -X POST -H "Authorization: Bearer ${restricted_secret}" -d '{"type": "client", "name": "foo"}' "https://${LXD_ADDR}/1.0/certificates?token=true" | jq -r .error_code)" = "403" ]
+  [ "$(curl -k -s -X POST -H "Authorization: Bearer ${restricted_secret}" -d '{"type": "client", "name": "foo", "token": true}' "https://${LXD_ADDR}/1.0/certificates" | jq -r .error_code)" = "403" ]
+
+  # Invalid tokens are rejected.
+  [ "$(curl -k -s -H "Authorization: Bearer invalid" "https://${LXD_ADDR}/1.0" | jq -r .metadata.auth)" = "untrusted" ]
+
+  # Expired tokens are rejected.
+  ! lxc query -X POST -d '{"name": "old", "expires_at": "2000-01-01T00:00:00Z"}' /1.0/auth/tokens || false
+  expires_at="$(date -u -d '+3 seconds' +%Y-%m-%dT%H:%M:%SZ)"
+  expiring_secret="$(lxc query -X POST -d "{\"name\": \"expiring\", \"expires_at\": \"${expires_at}\"}" /1.0/auth/tokens | jq -r .secret)"
+  sleep 5
+  [ "$(curl -k -s -H "Authorization: Bearer ${expiring_secret}" "https://${LXD_ADDR}/1.0" | jq -r .metadata.auth)" = "untrusted" ]
+
+  # Revoked tokens are rejected.
+  lxc query -X DELETE /1.0/auth/tokens/ci
+  [ "$(curl -k -s -H "Authorization: Bearer ${secret}" "https://${LXD_ADDR}/1.0" | jq -r .metadata.auth)" = "untrusted" ]
+
+  # Cleanup
+  lxc query -X DELETE /1.0/auth/tokens/ci-blah
+  lxc query -X DELETE /1.0/auth/tokens/viewer-blah
+  lxc profile delete p1 --project blah
+  lxc query -X DELETE /1.0/auth/tokens/expiring
+  lxc project delete blah
+}
@@ -297,6 +297,17 @@ test_basic_usage() {
 
     lxc list --force-local autostart | grep -q RUNNING
 
+    # Check that dependencies are started before the instances depending on them
+    lxc init testimage autostart-dep --force-local
+    lxc config set autostart boot.depends_on autostart-dep --force-local
+    ! lxc config set autostart boot.depends_on autostart-dep/snap0 --force-local || false
+    shutdown_lxd "${LXD_DIR}"
+    respawn_lxd "${LXD_DIR}" true
+    lxc list --force-local autostart | grep -q RUNNING
+    lxc list --force-local autostart-dep | grep -q RUNNING
+    lxc config unset autostart boot.depends_on --force-local
+    lxc delete --force autostart-dep --force-local
+
     # Check for scheduled instance snapshots
     lxc stop --force autostart
     lxc config set autostart snapshots.schedule "* * * * *" --force-local
@@ -308,6 +319,19 @@ test_basic_usage() {
 
     lxc config unset autostart snapshots.schedule --force-local
 
+    # Check for scheduled instance starts
+    ! lxc config set autostart schedule.start "foo" --force-local || false
+    lxc config set autostart schedule.start "0 8 * * 1-5" --force-local
+    lxc config set autostart schedule.stop "0 20 * * *" --force-local
+    shutdown_lxd "${LXD_DIR}"
+    lxd activateifneeded --debug 2>&1 | grep -q "Daemon has scheduled instance starts, activating..."
+
+    # shellcheck disable=SC2031
+    respawn_lxd "${LXD_DIR}" true
+
+    lxc config unset autostart schedule.start --force-local
+    lxc config unset autostart schedule.stop --force-local
+
     # Check for scheduled volume snapshots
     storage_pool="lxdtest-$(basename "${LXD_DIR}")"
 
@@ -384,6 +408,13 @@ test_basic_usage() {
   op=$(my_curl -X POST "https://${LXD_ADDR}/1.0/containers/foo/exec" -d '{"command": ["echo", "test"], "environment": {}, "wait-for-websocket": false, "interactive": false}' | jq -r .operation)
   [ "$(my_curl "https://${LXD_ADDR}${op}/wait" | jq -r .metadata.metadata.return)" != "null" ]
 
+  # check that we can wait for multiple operations at once and for a given status
+  op1=$(my_curl -X POST "https://${LXD_ADDR}/1.0/containers/foo/exec" -d '{"command": ["sleep", "3"], "environment": {}, "wait-for-websocket": false, "interactive": false}' | jq -r .operation)
+  op2=$(my_curl -X POST "https://${LXD_ADDR}/1.0/containers/foo/exec" -d '{"command": ["sleep", "3"], "environment": {}, "wait-for-websocket": false, "interactive": false}' | jq -r .operation)
+  [ "$(my_curl "https://${LXD_ADDR}${op1}/wait?status=Running&timeout=10" | jq -r .metadata.status)" = "Running" ]
+  [ "$(my_curl "https://${LXD_ADDR}/1.0/operations/wait?id=$(basename "${op1}")&id=$(basename "${op2}")&timeout=10" | jq -r '.metadata[].status' | sort -u)" = "Success" ]
+  ! my_curl "https://${LXD_ADDR}${op1}/wait?status=Invalid" | jq -r .error_code | grep -qx 200 || false
+
   # test file transfer
   echo abc > "${LXD_DIR}/in"
 
@@ -508,6 +539,27 @@ test_basic_usage() {
   lxc stop --all -f
   lxc list | grep c1 | grep STOPPED
   lxc list | grep c2 | grep STOPPED
+
+  # Test bulk state changes of selected instances
+  lxc config set c1 user.role web
+  lxc query -X PUT --wait -d '{"selector": {"user.role": "web"}, "state": {"action": "start"}}' /1.0/instances/state > "${TEST_DIR}/bulk-state"
+  [ "$(jq -r '.metadata.results[0].name' "${TEST_DIR}/bulk-state")" = "c1" ]
+  [ "$(jq -r '.metadata.results | length' "${TEST_DIR}/bulk-state")" = "1" ]
+  lxc list | grep c1 | grep RUNNING
+  lxc list | grep c2 | grep STOPPED
+  lxc query -X PUT --wait -d '{"instances": ["c1", "c2"], "state": {"action": "start"}}' /1.0/instances/state > "${TEST_DIR}/bulk-state"
+  [ "$(jq -r '.metadata.results[0].skipped' "${TEST_DIR}/bulk-state")" = "true" ]
+  [ "$(jq -r '.metadata.results[1].skipped' "${TEST_DIR}/bulk-state")" = "false" ]
+  lxc list | grep c2 | grep RUNNING
+  ! lxc query -X PUT --wait -d '{"instances": ["c3"], "state": {"action": "stop"}}' /1.0/instances/state || false
+  ! lxc query -X PUT --wait -d '{"state": {"action": "stop", "force": true}}' /1.0/instances/state || false
+  lxc list | grep c1 | grep RUNNING
+  ! lxc query -X PUT --wait -d '{"all": true, "instances": ["c1"], "state": {"action": "stop", "force": true}}' /1.0/instances/state || false
+  lxc query -X PUT --wait -d '{"all": true, "state": {"action": "stop", "force": true}}' /1.0/instances/state
+  lxc list | grep c1 | grep STOPPED
+  lxc list | grep c2 | grep STOPPED
+  rm "${TEST_DIR}/bulk-state"
+
   # Cleanup the containers
   lxc delete --force c1 c2
 
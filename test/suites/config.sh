@@ -223,6 +223,16 @@ test_config_profiles() {
   ! lxc config set foo raw.lxc a || false
   ! lxc profile set default raw.lxc a || false
 
+  # Test for raw.apparmor.* policies
+  lxc config set foo raw.apparmor.ptrace "ptrace,"
+  lxc profile set default raw.apparmor.mount "mount fstype=tmpfs,"
+  lxc config show foo --expanded | grep -q "raw.apparmor.mount"
+  ! lxc config set foo raw.apparmor.unbalanced "} profile escape {" || false
+  lxc config set foo raw.apparmor "} profile escape {"
+  lxc config unset foo raw.apparmor
+  lxc profile unset default raw.apparmor.mount
+  lxc config unset foo raw.apparmor.ptrace
+
   bad=0
   lxc list user.prop=value | grep foo && bad=1
   if [ "${bad}" -eq 1 ]; then
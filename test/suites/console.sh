@@ -28,4 +28,11 @@ test_console() {
   lxc console cons1 --show-log | grep 'some more content'
 
   lxc delete --force cons1
+
+  # Custom scrollback buffer size.
+  ! lxc init testimage cons1 -c console.buffer.size=foo || false
+  lxc launch testimage cons1 -c console.buffer.size=1MiB
+  echo 'some content' | lxc exec cons1 -- tee /dev/console
+  lxc console cons1 --show-log | grep 'some content'
+  lxc delete --force cons1
 }
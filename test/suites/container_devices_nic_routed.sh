@@ -142,6 +142,8 @@ test_container_devices_nic_routed() {
   lxc config device set "${ctName}" eth0 vlan 1234
   lxc config device set "${ctName}" eth0 ipv4.host_table=100
   lxc config device set "${ctName}" eth0 ipv6.host_table=101
+  lxc config device set "${ctName}" eth0 ipv4.routes=198.51.100.0/24
+  lxc config device set "${ctName}" eth0 ipv6.routes=2001:db8:1::/64
   lxc start "${ctName}"
 
   # Check VLAN interface created
@@ -154,6 +156,12 @@ test_container_devices_nic_routed() {
   ip -4 route show table 100 | grep "192.0.2.1${ipRand}"
   ip -6 route show table 101 | grep "2001:db8::1${ipRand}"
 
+  # Check host routes added via instance address to main and custom routing tables.
+  ip -4 route show | grep "198.51.100.0/24 via 192.0.2.1${ipRand}"
+  ip -4 route show table 100 | grep "198.51.100.0/24 via 192.0.2.1${ipRand}"
+  ip -6 route show | grep "2001:db8:1::/64 via 2001:db8::1${ipRand}"
+  ip -6 route show table 101 | grep "2001:db8:1::/64 via 2001:db8::1${ipRand}"
+
   # Check volatile cleanup on stop.
   lxc stop -f "${ctName}"
   if lxc config show "${ctName}" | grep volatile.eth0 | grep -v volatile.eth0.hwaddr | grep -v volatile.eth0.name ; then
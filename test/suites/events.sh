@@ -0,0 +1,31 @@
+test_events_history() {
+  ensure_import_testimage
+  ensure_has_localhost_remote "${LXD_ADDR}"
+
+  since="$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+  lxc init testimage c1
+  lxc delete c1
+
+  # Events are recorded in the background.
+  sleep 1
+
+  # Check that the lifecycle events have been recorded.
+  lxc query "/1.0/events?type=lifecycle&since=${since}" | jq -r '.[].metadata.action' | grep -Fx "instance-created"
+  lxc query "/1.0/events?type=lifecycle&since=${since}" | jq -r '.[].metadata.action' | grep -Fx "instance-deleted"
+
+  # Check that events can be filtered by resource.
+  lxc query "/1.0/events?type=lifecycle&since=${since}&resource=/1.0/instances/c1" | jq -r '.[].metadata.action' | grep -Fx "instance-created"
+  ! lxc query "/1.0/events?type=lifecycle&since=${since}&resource=/1.0/instances/c2" | jq -r '.[].metadata.action' | grep -F "instance-" || false
+
+  # Check that events from other projects aren't returned.
+  ! lxc query "/1.0/events?type=lifecycle&since=${since}&project=foo" | jq -r '.[].metadata.action' | grep -F "instance-" || false
+
+  # Check that invalid parameters are rejected.
+  ! lxc query "/1.0/events?type=foo" || false
+  ! lxc query "/1.0/events?since=yesterday" || false
+
+  # Check that the expiry setting is validated.
+  lxc config set core.events_expiry 30
+  ! lxc config set core.events_expiry foo || false
+  lxc config unset core.events_expiry
+}
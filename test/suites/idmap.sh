@@ -112,6 +112,12 @@ test_idmap() {
   [ "$(lxc exec idmap1 -- cat /proc/self/uid_map | awk '{print $3}')" = "65536" ]
   [ "$(lxc exec idmap1 -- cat /proc/self/gid_map | awk '{print $3}')" = "65536" ]
 
+  # Check the allocations recorded in the database
+  lxc query /1.0/idmap-allocations | jq -r '.[] | "\(.instance) \(.base) \(.size)"' > "${TEST_DIR}/idmap-allocations"
+  grep -qx "idmap $((UID_BASE+65536)) 65536" "${TEST_DIR}/idmap-allocations"
+  grep -qx "idmap1 $((UID_BASE+131072)) 65536" "${TEST_DIR}/idmap-allocations"
+  rm "${TEST_DIR}/idmap-allocations"
+
   # Validate non-overlapping maps
   lxc exec idmap -- touch /a
   ! lxc exec idmap -- chown 65536 /a || false
@@ -27,6 +27,25 @@ test_init_preseed() {
         source=""
     fi
 
+    # Invalid preseeds are rejected by --dry-run, with all problems reported.
+    ! cat <<EOF | lxd init --preseed --dry-run > "${LXD_DIR}/dry-run.out" || false
+config:
+  core.https_address: 127.0.0.1:9999
+  images.auto_update_interval: foo
+storage_pools:
+- name: invalid-driver
+  driver: foo
+networks:
+- name: lxdt$$
+  type: bridge
+  config:
+    ipv4.address: 10.0.0.1/33
+EOF
+    grep -q "images.auto_update_interval" "${LXD_DIR}/dry-run.out"
+    grep -q "invalid-driver" "${LXD_DIR}/dry-run.out"
+    grep -q "lxdt$$" "${LXD_DIR}/dry-run.out"
+    ! lxc network show "lxdt$$" || false
+
     cat <<EOF | lxd init --preseed
 config:
   core.https_address: 127.0.0.1:9999
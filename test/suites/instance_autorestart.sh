@@ -0,0 +1,28 @@
+test_instance_autorestart() {
+  ensure_import_testimage
+
+  lxc launch testimage c1 -c boot.autorestart=true -c boot.autorestart.delay=0 -c boot.autorestart.max_retries=1
+  [ "$(lxc query /1.0/instances/c1/state | jq .restarts)" = "0" ]
+
+  # Killing the init process gets the container restarted.
+  kill -9 "$(lxc query /1.0/instances/c1/state | jq .pid)"
+  for _ in $(seq 30); do
+    [ "$(lxc query /1.0/instances/c1/state | jq -r .status)" = "Running" ] && [ "$(lxc query /1.0/instances/c1/state | jq .restarts)" = "1" ] && break
+    sleep 1
+  done
+
+  lxc list c1 | grep -q RUNNING
+  [ "$(lxc query /1.0/instances/c1/state | jq .restarts)" = "1" ]
+
+  # Once the maximum number of retries is reached, the container stays stopped.
+  kill -9 "$(lxc query /1.0/instances/c1/state | jq .pid)"
+  sleep 5
+  lxc list c1 | grep -q STOPPED
+
+  # Stopping it through LXD resets the count.
+  lxc start c1
+  lxc stop c1 --force
+  [ "$(lxc query /1.0/instances/c1/state | jq .restarts)" = "0" ]
+
+  lxc delete c1
+}
@@ -0,0 +1,48 @@
+test_instance_clone() {
+  ensure_import_testimage
+
+  # shellcheck disable=2039
+  local lxd_backend
+  lxd_backend=$(storage_backend "$LXD_DIR")
+
+  lxc init testimage c1
+  lxc snapshot c1
+
+  if [ "${lxd_backend}" = "dir" ]; then
+    # The dir driver can't make copy-on-write clones.
+    ! lxc query -X POST --wait -d '{"name": "c2", "source": {"type": "clone", "source": "c1"}}' /1.0/instances || false
+    lxc delete c1
+    return
+  fi
+
+  # Clones can't be created from snapshots or refreshed.
+  ! lxc query -X POST --wait -d '{"name": "c2", "source": {"type": "clone", "source": "c1/snap0"}}' /1.0/instances || false
+  ! lxc query -X POST --wait -d '{"name": "c2", "source": {"type": "clone", "source": "c1", "refresh": true}}' /1.0/instances || false
+
+  # Clones aren't available when the pool makes full copies.
+  if [ "${lxd_backend}" = "zfs" ]; then
+    pool="$(lxc profile device get default root pool)"
+    lxc storage set "${pool}" zfs.clone_copy false
+    ! lxc query -X POST --wait -d '{"name": "c2", "source": {"type": "clone", "source": "c1"}}' /1.0/instances || false
+    lxc storage unset "${pool}" zfs.clone_copy
+  fi
+
+  # Clones don't get the snapshots of the source and record it.
+  lxc query -X POST --wait -d '{"name": "c2", "source": {"type": "clone", "source": "c1"}}' /1.0/instances
+  [ "$(lxc config get c2 volatile.clone_source)" = "default/c1" ]
+  [ "$(lxc query /1.0/instances/c2/snapshots | jq length)" = "0" ]
+
+  # Only stopped clones can be flattened.
+  lxc start c2
+  ! lxc query -X POST --wait /1.0/instances/c2/flatten || false
+  lxc stop c2 --force
+
+  lxc query -X POST --wait /1.0/instances/c2/flatten
+  [ "$(lxc config get c2 volatile.clone_source)" = "" ]
+  ! lxc query -X POST --wait /1.0/instances/c2/flatten || false
+
+  # The flattened clone doesn't depend on its source anymore.
+  lxc delete c1
+  lxc start c2
+  lxc delete -f c2
+}
@@ -0,0 +1,20 @@
+test_instance_convert() {
+  ensure_import_testimage
+
+  lxc launch testimage c1
+
+  # Running instances can't be converted.
+  ! lxc query -X POST --wait -d '{"type": "virtual-machine"}' /1.0/instances/c1/convert || false
+  lxc stop c1 --force
+
+  # Instances with snapshots can't be converted.
+  lxc snapshot c1
+  ! lxc query -X POST --wait -d '{"type": "virtual-machine"}' /1.0/instances/c1/convert || false
+  lxc delete c1/snap0
+
+  # The new type must differ from the current one.
+  ! lxc query -X POST --wait -d '{"type": "container"}' /1.0/instances/c1/convert || false
+  ! lxc query -X POST --wait -d '{"type": "foo"}' /1.0/instances/c1/convert || false
+
+  lxc delete c1
+}
@@ -0,0 +1,25 @@
+test_instance_exec_recording() {
+  ensure_import_testimage
+
+  lxc launch testimage c1
+  [ "$(lxc query /1.0/instances/c1/recordings | jq length)" = "0" ]
+
+  ! lxc config set c1 security.exec_recording foo || false
+  lxc config set c1 security.exec_recording true
+
+  # Non-interactive sessions aren't recorded.
+  lxc exec c1 -T -- echo foo
+  [ "$(lxc query /1.0/instances/c1/recordings | jq length)" = "0" ]
+
+  echo "exit" | lxc exec c1 -t -- sh
+  [ "$(lxc query /1.0/instances/c1/recordings | jq length)" = "1" ]
+
+  recording="$(lxc query "/1.0/instances/c1/recordings?recursion=1" | jq -r '.[0].name')"
+  [ "$(lxc query "/1.0/instances/c1/recordings?recursion=1" | jq -r '.[0].command[0]')" = "sh" ]
+  [ "$(lxc query "/1.0/instances/c1/recordings?recursion=1" | jq -r '.[0].size')" -gt 0 ]
+  [ -e "${LXD_DIR}/recordings/c1/${recording}" ]
+  head -n1 "${LXD_DIR}/recordings/c1/${recording}" | jq -e '.version == 2'
+  ! lxc query "/1.0/instances/c1/recordings/..foo" || false
+
+  lxc delete -f c1
+}
@@ -0,0 +1,38 @@
+test_instance_healthcheck() {
+  ensure_import_testimage
+
+  lxc launch testimage c1 -c healthcheck.type=exec -c healthcheck.command="test -e /tmp/ok" -c healthcheck.interval=1 -c healthcheck.retries=1
+  [ "$(lxc query /1.0/instances/c1/state | jq -r .health)" = "starting" ]
+
+  # The check fails until the file exists.
+  for _ in $(seq 30); do
+    [ "$(lxc query /1.0/instances/c1/state | jq -r .health)" = "unhealthy" ] && break
+    sleep 1
+  done
+
+  [ "$(lxc query /1.0/instances/c1/state | jq -r .health)" = "unhealthy" ]
+
+  lxc exec c1 -- touch /tmp/ok
+  for _ in $(seq 30); do
+    [ "$(lxc query /1.0/instances/c1/state | jq -r .health)" = "healthy" ] && break
+    sleep 1
+  done
+
+  [ "$(lxc query /1.0/instances/c1/state | jq -r .health)" = "healthy" ]
+
+  # Unhealthy instances get restarted when requested.
+  lxc config set c1 healthcheck.restart=true boot.autorestart.delay=0
+  lxc exec c1 -- rm /tmp/ok
+  for _ in $(seq 30); do
+    [ "$(lxc query /1.0/instances/c1/state | jq .restarts)" = "1" ] && break
+    sleep 1
+  done
+
+  [ "$(lxc query /1.0/instances/c1/state | jq .restarts)" = "1" ]
+
+  # Stopped instances have no health.
+  lxc stop c1 --force
+  [ "$(lxc query /1.0/instances/c1/state | jq -r .health)" = "" ]
+
+  lxc delete c1
+}
@@ -0,0 +1,39 @@
+test_instance_hooks() {
+  ensure_import_testimage
+
+  hook="${TEST_DIR}/hook.sh"
+  cat > "${hook}" << EOH
+#!/bin/sh
+echo "\${LXD_HOOK} \${LXD_INSTANCE_PROJECT} \${LXD_INSTANCE_NAME}" >> "${TEST_DIR}/hook.log"
+EOH
+  chmod +x "${hook}"
+
+  # Hooks must be absolute paths or HTTP(S) URLs.
+  ! lxc init testimage c1 -c hooks.pre-start=hook.sh || false
+  lxc init testimage c1 -c hooks.pre-start="${hook}" -c hooks.post-start="${hook}" -c hooks.post-stop="${hook}"
+
+  lxc start c1
+  lxc stop c1 --force
+
+  for _ in $(seq 10); do
+    grep -q "^post-stop default c1$" "${TEST_DIR}/hook.log" && break
+    sleep 1
+  done
+
+  grep -q "^pre-start default c1$" "${TEST_DIR}/hook.log"
+  grep -q "^post-start default c1$" "${TEST_DIR}/hook.log"
+  grep -q "^post-stop default c1$" "${TEST_DIR}/hook.log"
+
+  # A failing pre-start hook prevents the start.
+  lxc config set c1 hooks.pre-start=/bin/false
+  ! lxc start c1 || false
+  [ "$(lxc list c1 -c s --format csv)" = "STOPPED" ]
+
+  # Hooks can't be set in restricted projects.
+  lxc project create p1 -c features.images=false -c restricted=true
+  ! lxc init testimage c2 --project p1 -c hooks.post-start="${hook}" || false
+  lxc project delete p1
+
+  lxc delete c1
+  rm -f "${hook}" "${TEST_DIR}/hook.log"
+}
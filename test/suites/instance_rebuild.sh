@@ -0,0 +1,30 @@
+test_instance_rebuild() {
+  ensure_import_testimage
+
+  lxc launch testimage c1 -c user.foo=bar
+  lxc exec c1 -- touch /root/foo
+
+  # Running instances can't be rebuilt.
+  ! lxc query -X POST --wait -d '{"source": {"type": "image", "alias": "testimage"}}' /1.0/instances/c1/rebuild || false
+  lxc stop c1 --force
+
+  # Instances with snapshots can't be rebuilt.
+  lxc snapshot c1
+  ! lxc query -X POST --wait -d '{"source": {"type": "image", "alias": "testimage"}}' /1.0/instances/c1/rebuild || false
+  lxc delete c1/snap0
+
+  # Rebuilding from the image wipes the root volume and keeps the config.
+  lxc query -X POST --wait -d '{"source": {"type": "image", "alias": "testimage"}}' /1.0/instances/c1/rebuild
+  [ "$(lxc config get c1 user.foo)" = "bar" ]
+  [ "$(lxc config get c1 volatile.base_image)" = "$(lxc image info testimage | awk '/^Fingerprint/ {print $2}')" ]
+  lxc start c1
+  ! lxc exec c1 -- test -e /root/foo || false
+  lxc stop c1 --force
+
+  # Rebuilding as empty drops the image keys.
+  lxc query -X POST --wait -d '{"source": {"type": "none"}}' /1.0/instances/c1/rebuild
+  [ "$(lxc config get c1 volatile.base_image)" = "" ]
+  [ "$(lxc config get c1 user.foo)" = "bar" ]
+
+  lxc delete c1
+}
@@ -0,0 +1,41 @@
+test_instance_templates() {
+  ensure_import_testimage
+
+  # shellcheck disable=SC2016
+  lxc query -X POST -d '{"name": "webserver", "description": "Web server", "source": {"type": "image", "alias": "testimage"}, "config": {"environment.ENV": "${var:env}", "user.owner": "${var:owner}"}, "variables": {"env": {"default": "staging"}, "owner": {"required": true}}}' /1.0/instance-templates
+
+  # Invalid names, duplicates and undeclared variables are rejected.
+  ! lxc query -X POST -d '{"name": "web/server", "source": {"type": "image", "alias": "testimage"}}' /1.0/instance-templates || false
+  ! lxc query -X POST -d '{"name": "webserver", "source": {"type": "image", "alias": "testimage"}}' /1.0/instance-templates || false
+  # shellcheck disable=SC2016
+  ! lxc query -X POST -d '{"name": "broken", "source": {"type": "image", "alias": "testimage"}, "config": {"user.foo": "${var:foo}"}}' /1.0/instance-templates || false
+
+  lxc query /1.0/instance-templates | jq -r '.[]' | grep -Fx "/1.0/instance-templates/webserver"
+  [ "$(lxc query /1.0/instance-templates/webserver | jq -r .description)" = "Web server" ]
+
+  # Required variables must be provided and unknown ones are rejected.
+  ! lxc launch template:webserver c1 || false
+  ! lxc launch template:webserver c1 --var owner=foo --var bar=baz || false
+  ! lxc launch template:webserver c1 --var owner=foo -c user.foo=bar || false
+
+  lxc launch template:webserver c1 --var owner=foo --var env=prod
+  [ "$(lxc config get c1 environment.ENV)" = "prod" ]
+  [ "$(lxc config get c1 user.owner)" = "foo" ]
+  lxc exec c1 -- env | grep -Fx "ENV=prod"
+
+  # Defaults are used for missing variables.
+  lxc init template:webserver c2 --var owner=bar
+  [ "$(lxc config get c2 environment.ENV)" = "staging" ]
+
+  # Templates are per project.
+  lxc project create foo
+  ! lxc query "/1.0/instance-templates/webserver?project=foo" || false
+  lxc project delete foo
+
+  # Instances are independent from their template.
+  lxc query -X DELETE /1.0/instance-templates/webserver
+  ! lxc query /1.0/instance-templates/webserver || false
+  lxc start c2
+
+  lxc delete -f c1 c2
+}
@@ -0,0 +1,19 @@
+test_instance_usage() {
+  ensure_import_testimage
+
+  lxc config set instances.usage_interval 1
+  lxc launch testimage c1
+
+  for _ in $(seq 30); do
+    [ "$(lxc query "/1.0/instances/c1/metrics?period=1m" | jq '.samples | length')" -ge 2 ] && break
+    sleep 1
+  done
+
+  [ "$(lxc query "/1.0/instances/c1/metrics?period=1m" | jq .interval)" = "1" ]
+  [ "$(lxc query "/1.0/instances/c1/metrics?period=1m" | jq '.samples | length')" -ge 2 ]
+  [ "$(lxc query "/1.0/instances/c1/metrics?period=1m" | jq '.samples[-1].memory_usage')" -gt 0 ]
+  ! lxc query "/1.0/instances/c1/metrics?period=foo" || false
+
+  lxc delete -f c1
+  lxc config unset instances.usage_interval
+}
@@ -0,0 +1,40 @@
+test_metrics() {
+  ensure_import_testimage
+  ensure_has_localhost_remote "${LXD_ADDR}"
+
+  lxc launch testimage c1
+  lxc init testimage c2
+
+  # Check that the metrics of the running instance and of the daemon are returned.
+  curl -k -s --cert "${LXD_CONF}/client.crt" --key "${LXD_CONF}/client.key" "https://${LXD_ADDR}/1.0/metrics" > "${TEST_DIR}/metrics.out"
+  grep "lxd_cpu_seconds_total{name=\"c1\",project=\"default\",type=\"container\"}" "${TEST_DIR}/metrics.out"
+  grep "lxd_instances_total{status=\"Stopped\"} 1" "${TEST_DIR}/metrics.out"
+  grep "lxd_go_goroutines" "${TEST_DIR}/metrics.out"
+  ! grep "name=\"c2\"" "${TEST_DIR}/metrics.out" || false
+
+  # Add a metrics certificate.
+  gen_cert metrics
+  lxc config trust add "${LXD_CONF}/metrics.crt" --type=metrics
+  lxc config trust list | grep metrics
+
+  # The metrics certificate can only access the metrics endpoint.
+  curl -k -s --cert "${LXD_CONF}/metrics.crt" --key "${LXD_CONF}/metrics.key" -X GET "https://${LXD_ADDR}/1.0/metrics" | grep "lxd_cpu_seconds_total"
+  curl -k -s --cert "${LXD_CONF}/metrics.crt" --key "${LXD_CONF}/metrics.key" -X GET "https://${LXD_ADDR}/1.0/instances" | grep 403
+
+  # Untrusted clients can't access the metrics.
+  gen_cert metrics-untrusted
+  curl -k -s --cert "${LXD_CONF}/metrics-untrusted.crt" --key "${LXD_CONF}/metrics-untrusted.key" -X GET "https://${LXD_ADDR}/1.0/metrics" | grep 403
+
+  # An additional listener with the metrics scope only serves the metrics.
+  metrics_addr="127.0.0.1:$(local_tcp_port)"
+  lxc config set core.https_listeners "mgmt:metrics:${metrics_addr}"
+  curl -k -s --cert "${LXD_CONF}/metrics.crt" --key "${LXD_CONF}/metrics.key" -X GET "https://${metrics_addr}/1.0/metrics" | grep "lxd_cpu_seconds_total"
+  curl -k -s --cert "${LXD_CONF}/client.crt" --key "${LXD_CONF}/client.key" -X GET "https://${metrics_addr}/1.0/instances" | grep 403
+  ! lxc config set core.https_listeners "mgmt:images:${metrics_addr}" || false
+  lxc config unset core.https_listeners
+  ! curl -k -s --cert "${LXD_CONF}/metrics.crt" --key "${LXD_CONF}/metrics.key" "https://${metrics_addr}/1.0/metrics" || false
+
+  lxc config trust remove "$(lxc config trust list --format csv | grep metrics | cut -d, -f4)"
+  rm "${TEST_DIR}/metrics.out"
+  lxc delete -f c1 c2
+}
@@ -395,6 +395,20 @@ migration() {
   lxc_remote storage volume delete l1:dir vol1
   lxc_remote storage delete l1:dir
 
+  # Check migration with a storage pool mapping.
+  lxc_remote storage create l1:src1 dir
+  lxc_remote storage create l2:dst1 dir
+  lxc_remote init testimage l1:c1 -s src1
+  lxc_remote snapshot l1:c1
+  ! lxc_remote copy l1:c1 l2: --pool-map src1=missing || false
+  lxc_remote copy l1:c1 l2: --pool-map src1=dst1
+  [ "$(lxc_remote config device get l2:c1 root pool)" = "dst1" ]
+  lxc_remote storage volume show l2:dst1 container/c1/snap0
+  lxc_remote delete l1:c1 -f
+  lxc_remote delete l2:c1 -f
+  lxc_remote storage delete l1:src1
+  lxc_remote storage delete l2:dst1
+
   if ! which criu >/dev/null 2>&1; then
     echo "==> SKIP: live migration with CRIU (missing binary)"
     return
@@ -417,6 +431,13 @@ migration() {
   # Test live migration of container
   lxc_remote move l1:migratee l2:migratee
 
+  # Test live migration with memory pre-copy and a downtime target
+  lxc_remote config set l2:migratee migration.incremental.memory true
+  lxc_remote config set l2:migratee migration.incremental.memory.downtime 100
+  ! lxc_remote config set l2:migratee migration.incremental.memory.downtime foo || false
+  lxc_remote move l2:migratee l1:migratee
+  lxc_remote move l1:migratee l2:migratee
+
   # Test copy of stateful snapshot
   lxc_remote copy l2:migratee/snap0 l1:migratee
   ! lxc_remote copy l2:migratee/snap0 l1:migratee-new-name || false
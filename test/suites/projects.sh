@@ -786,6 +786,20 @@ test_projects_restrictions() {
   ! lxc profile device add default tty unix-char path=/dev/ttyS0 || false
   ! lxc config device add c1 tty unix-char path=/dev/ttyS0 || false
 
+  # With an allowlist, only the matching host devices can be attached.
+  lxc project set p1 restricted.devices.unix-char=allowlist
+  lxc project set p1 restricted.devices.unix.allowlist="SUBSYSTEM=mem,DEVNAME=null"
+  ! lxc config device add c1 tty unix-char path=/dev/ttyS0 major=4 minor=64 || false
+  lxc config device add c1 null unix-char source=/dev/null path=/dev/null2
+  lxc config device add c1 zero unix-char source=/dev/zero path=/dev/zero2
+  ! lxc start c1 || false
+  lxc config device remove c1 zero
+  lxc start c1
+  lxc stop c1 --force
+  lxc config device remove c1 null
+  lxc project unset p1 restricted.devices.unix.allowlist
+  lxc project unset p1 restricted.devices.unix-char
+
   # It's not possible to attach raw network devices.
   ! lxc profile device add default eth0 nic nictype=p2p || false
 
@@ -903,3 +917,37 @@ test_projects_usage() {
   lxc image delete testimage --project test-usage
   lxc project delete test-usage
 }
+
+# Import project exports.
+test_projects_import() {
+  lxc project create foo
+  lxc profile create p1 --project foo
+  curl -s --unix-socket "${LXD_DIR}/unix.socket" "lxd/1.0/projects/foo/export" -o "${TEST_DIR}/foo.tar"
+  curl -s --unix-socket "${LXD_DIR}/unix.socket" "lxd/1.0/projects/default/export" -o "${TEST_DIR}/default.tar"
+
+  # Exports aren't imported into existing projects.
+  [ "$(curl -s --unix-socket "${LXD_DIR}/unix.socket" -X POST -H "Content-Type: application/octet-stream" --data-binary "@${TEST_DIR}/foo.tar" lxd/1.0/projects | jq -r .error_code)" = "409" ]
+  [ "$(curl -s --unix-socket "${LXD_DIR}/unix.socket" -X POST -H "Content-Type: application/octet-stream" --data-binary "@${TEST_DIR}/default.tar" lxd/1.0/projects | jq -r .error_code)" = "409" ]
+  [ "$(curl -s --unix-socket "${LXD_DIR}/unix.socket" -X POST -H "Content-Type: application/octet-stream" -H "X-LXD-name: default" --data-binary "@${TEST_DIR}/foo.tar" lxd/1.0/projects | jq -r .error_code)" = "409" ]
+
+  # Exports are imported into a new project under another name.
+  op=$(curl -s --unix-socket "${LXD_DIR}/unix.socket" -X POST -H "Content-Type: application/octet-stream" -H "X-LXD-name: bar" --data-binary "@${TEST_DIR}/foo.tar" lxd/1.0/projects | jq -r .operation)
+  [ "$(curl -s --unix-socket "${LXD_DIR}/unix.socket" "lxd${op}/wait" | jq -r .metadata.status)" = "Success" ]
+  lxc profile show p1 --project bar
+
+  # A failed import deletes what it created.
+  mkdir "${TEST_DIR}/broken"
+  tar -xf "${TEST_DIR}/foo.tar" -C "${TEST_DIR}/broken"
+  echo "instances: [missing]" >> "${TEST_DIR}/broken/index.yaml"
+  tar -cf "${TEST_DIR}/broken.tar" -C "${TEST_DIR}/broken" .
+  op=$(curl -s --unix-socket "${LXD_DIR}/unix.socket" -X POST -H "Content-Type: application/octet-stream" -H "X-LXD-name: baz" --data-binary "@${TEST_DIR}/broken.tar" lxd/1.0/projects | jq -r .operation)
+  [ "$(curl -s --unix-socket "${LXD_DIR}/unix.socket" "lxd${op}/wait" | jq -r .metadata.status)" = "Failure" ]
+  ! lxc project show baz || false
+
+  # Cleanup
+  rm -rf "${TEST_DIR}/broken" "${TEST_DIR}/broken.tar" "${TEST_DIR}/foo.tar" "${TEST_DIR}/default.tar"
+  lxc profile delete p1 --project foo
+  lxc profile delete p1 --project bar
+  lxc project delete foo
+  lxc project delete bar
+}
@@ -63,6 +63,20 @@ test_remote_admin() {
     false
   fi
 
+  # Add a client using a single-use certificate add token
+  token="$(lxc_remote query -X POST /1.0/certificates --data '{"name": "token-client", "type": "client", "token": true}' | jq -r '.metadata | {client_name: .clientName, secret: .secret, fingerprint: .fingerprint, addresses: .addresses} | @base64')"
+  LXD_CONF_TOKEN1=$(mktemp -d -p "${TEST_DIR}" XXX)
+  LXD_CONF_TOKEN2=$(mktemp -d -p "${TEST_DIR}" XXX)
+  LXD_CONF="${LXD_CONF_TOKEN1}" lxc_remote remote add token "${LXD_ADDR}" --accept-certificate --password "${token}"
+  LXD_CONF="${LXD_CONF_TOKEN1}" lxc_remote info token: | grep -q 'auth: trusted'
+  lxc_remote config trust list --format csv | grep -q token-client
+
+  # Tokens can only be used once
+  ! LXD_CONF="${LXD_CONF_TOKEN2}" lxc_remote remote add token "${LXD_ADDR}" --accept-certificate --password "${token}" || false
+
+  lxc_remote config trust remove "$(lxc_remote config trust list --format csv | grep token-client | cut -d, -f4)"
+  rm -rf "${LXD_CONF_TOKEN1}" "${LXD_CONF_TOKEN2}"
+
   # Check that we can add domains with valid certs without confirmation:
   if [ -z "${LXD_OFFLINE:-}" ]; then
     lxc_remote remote add images1 images.linuxcontainers.org
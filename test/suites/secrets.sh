@@ -0,0 +1,59 @@
+test_secrets() {
+  ensure_import_testimage
+
+  lxc query -X POST -d '{"name": "db-password", "description": "Database password", "value": "hunter2"}' /1.0/secrets
+
+  # Invalid names and duplicates are rejected.
+  ! lxc query -X POST -d '{"name": "db/password", "value": "hunter2"}' /1.0/secrets || false
+  ! lxc query -X POST -d '{"name": "db-password", "value": "hunter2"}' /1.0/secrets || false
+
+  # The value is never returned.
+  lxc query /1.0/secrets | jq -r '.[]' | grep -Fx "/1.0/secrets/db-password"
+  [ "$(lxc query /1.0/secrets/db-password | jq -r .description)" = "Database password" ]
+  ! lxc query /1.0/secrets/db-password | grep -F hunter2 || false
+  ! lxd sql global "SELECT value FROM secrets" | grep -F hunter2 || false
+
+  # References are expanded in the environment but not in the config.
+  # shellcheck disable=SC2016
+  lxc launch testimage c1 -c environment.DB_PASSWORD='${secret:db-password}' -c user.password='${secret:db-password}'
+  lxc exec c1 -- env | grep -Fx "DB_PASSWORD=hunter2"
+  ! lxc config show c1 | grep -F hunter2 || false
+
+  # Updating the value without a value keeps it.
+  lxc query -X PUT -d '{"description": "Main database password"}' /1.0/secrets/db-password
+  lxc exec c1 -- env | grep -Fx "DB_PASSWORD=hunter2"
+  lxc query -X PUT -d '{"description": "Main database password", "value": "hunter3"}' /1.0/secrets/db-password
+  lxc exec c1 -- env | grep -Fx "DB_PASSWORD=hunter3"
+
+  # Secrets are per project.
+  lxc project create foo
+  ! lxc query "/1.0/secrets/db-password?project=foo" || false
+  lxc project delete foo
+
+  # References are expanded in the proxy device tokens, which aren't passed on the command line.
+  lxc query -X POST -d '{"name": "proxy-token", "value": "s3cr3t"}' /1.0/secrets
+  HOST_TCP_PORT=$(local_tcp_port)
+  # shellcheck disable=SC2016
+  lxc config device add c1 proxyDev proxy "listen=tcp:127.0.0.1:${HOST_TCP_PORT}" connect=tcp:127.0.0.1:4321 security.token='${secret:proxy-token}'
+  ! pgrep -af s3cr3t || false
+  nsenter -n -U -t "$(lxc query /1.0/instances/c1/state | jq .pid)" -- socat tcp-listen:4321 exec:/bin/cat &
+  NSENTER_PID=$!
+  sleep 0.5
+
+  [ "$( (echo "invalid" ; echo "hello" ; sleep 0.5) | socat - tcp:127.0.0.1:"${HOST_TCP_PORT}")" = "" ]
+  [ "$( (echo "s3cr3t" ; echo "hello" ; sleep 0.5) | socat - tcp:127.0.0.1:"${HOST_TCP_PORT}")" = "hello" ]
+  kill "${NSENTER_PID}" 2>/dev/null || true
+  wait "${NSENTER_PID}" 2>/dev/null || true
+
+  lxc config device remove c1 proxyDev
+  lxc query -X DELETE /1.0/secrets/proxy-token
+
+  # Tokens are only supported by stream listeners.
+  ! lxc config device add c1 proxyDev proxy "listen=udp:127.0.0.1:${HOST_TCP_PORT}" connect=udp:127.0.0.1:4321 security.token=foo || false
+
+  # Missing secrets make the expansion fail.
+  lxc query -X DELETE /1.0/secrets/db-password
+  ! lxc exec c1 -- true || false
+
+  lxc delete -f c1
+}
@@ -6,6 +6,8 @@ test_server_config() {
   test_server_config_password
   test_server_config_access
   test_server_config_storage
+  test_server_config_log_levels
+  test_server_config_shutdown_action
 
   kill_lxd "${LXD_SERVERCONFIG_DIR}"
 }
@@ -37,6 +39,17 @@ test_server_config_access() {
   lxc config unset candid.api.url
 }
 
+test_server_config_log_levels() {
+  lxc config set core.log_level_storage debug
+  lxc config get core.log_level_storage | grep -qx debug
+
+  # Invalid levels are rejected.
+  ! lxc config set core.log_level_db verbose || false
+
+  lxc config unset core.log_level_storage
+  [ -z "$(lxc config get core.log_level_storage)" ]
+}
+
 test_server_config_storage() {
   # shellcheck disable=2039
   local lxd_backend
@@ -107,3 +120,13 @@ test_server_config_storage() {
   lxc storage volume delete "${pool}" images
   lxc delete -f foo
 }
+
+test_server_config_shutdown_action() {
+  lxc config set instances.shutdown_action leave-running
+  [ "$(lxc config get instances.shutdown_action)" = "leave-running" ]
+
+  ! lxc config set instances.shutdown_action suspend || false
+
+  lxc config unset instances.shutdown_action
+  [ "$(lxc config get instances.shutdown_action)" = "" ]
+}
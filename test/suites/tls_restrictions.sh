@@ -26,6 +26,40 @@ test_tls_restrictions() {
 
   ! lxc_remote project create localhost:blah1 || false
 
+  # Validate operations of other projects aren't visible
+  lxc_remote operation list localhost: --project blah
+  ! lxc_remote operation list localhost: --project default || false
+
+  # Validate done operations of other projects aren't visible either
+  op=$(lxc query -X POST -d '{"name":"c-ops","source":{"type":"image","alias":"testimage"}}' /1.0/instances | jq -r .id)
+  lxc query "/1.0/operations/${op}/wait" >/dev/null
+  ! lxc_remote query "localhost:/1.0/operations/${op}" || false
+  ! lxc_remote query "localhost:/1.0/operations/${op}/wait" || false
+
+  # Validate operations of other projects can't be cancelled
+  lxc start c-ops
+  op=$(lxc query -X POST -d '{"command":["sleep","60"]}' /1.0/instances/c-ops/exec | jq -r .id)
+  ! lxc_remote query -X DELETE "localhost:/1.0/operations/${op}" || false
+  [ "$(lxc query "/1.0/operations/${op}" | jq -r .status)" = "Running" ]
+  lxc delete -f c-ops
+
+  # Validate viewer role
+  lxc config trust show "${FINGERPRINT}" | sed -e "s/blah: admin/blah: viewer/" | lxc config trust edit "${FINGERPRINT}"
+  lxc_remote profile list localhost: --project blah | grep -q default
+  ! lxc_remote profile create localhost:blah-profile --project blah || false
+
+  # Validate operator role
+  lxc config trust show "${FINGERPRINT}" | sed -e "s/blah: viewer/blah: operator/" | lxc config trust edit "${FINGERPRINT}"
+  ! lxc_remote profile create localhost:blah-profile --project blah || false
+
+  # Validate admin role
+  lxc config trust show "${FINGERPRINT}" | sed -e "s/blah: operator/blah: admin/" | lxc config trust edit "${FINGERPRINT}"
+  lxc_remote profile create localhost:blah-profile --project blah
+  lxc_remote profile delete localhost:blah-profile --project blah
+
+  # Reject invalid roles
+  ! lxc config trust show "${FINGERPRINT}" | sed -e "s/blah: admin/blah: superuser/" | lxc config trust edit "${FINGERPRINT}" || false
+
   # Cleanup
   lxc config trust show "${FINGERPRINT}" | sed -e "s/restricted: true/restricted: false/" | lxc config trust edit "${FINGERPRINT}"
   lxc project delete blah